@@ -23,8 +23,10 @@ import (
 	"time"
 
 	"github.com/FederatedAI/FedLCM/pkg/utils"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
 )
 
 func sendJSON(method, urlStr string, body interface{}) (*http.Response, error) {
@@ -72,3 +74,71 @@ func parseResponse(response *http.Response) ([]byte, error) {
 	}
 	return body, nil
 }
+
+// envoyCommandFlags returns the flags to locate the lifecycle manager and authenticate as the envoy, followed by the extra ones
+func envoyCommandFlags(extraFlags ...cli.Flag) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "server-host",
+			Aliases:  []string{"s"},
+			Value:    "",
+			Usage:    "Host address of the lifecycle manager",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:     "server-port",
+			Aliases:  []string{"p"},
+			Usage:    "Port number of the lifecycle manager",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:     "tls",
+			Value:    false,
+			Usage:    "Enable TLS",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "credential",
+			Aliases:  []string{"c", "token", "t"},
+			Value:    "",
+			Usage:    "The credential returned by the registration, envoys registered by earlier versions use the token string",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "uuid",
+			Aliases:  []string{"u"},
+			Value:    "",
+			Usage:    "The envoy UUID",
+			Required: true,
+		},
+	}, extraFlags...)
+}
+
+// postEnvoyRequest sends the request to the token-authenticated envoy API specified by the action
+func postEnvoyRequest(c *cli.Context, action string, body interface{}) error {
+	resp, err := sendJSON("POST",
+		getUrl(c.String("server-host"), c.Int("server-port"), c.Bool("tls"), fmt.Sprintf("federation/openfl/envoy/%s/%s", c.String("uuid"), action)),
+		body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = parseResponse(resp)
+	return err
+}
+
+// waitForEnvoyActive waits until the envoy finishes the ongoing operation
+func waitForEnvoyActive(c *cli.Context) error {
+	log.Infof("Waiting for the operation to finish")
+	return utils.ExecuteWithTimeout(func() bool {
+		envoy, err := getEnvoyInfo(c.String("credential"), c.String("uuid"), c.String("server-host"), c.Int("server-port"), c.Bool("tls"))
+		if err != nil {
+			log.Errorf("error getting Envoy info: %v, retry", err)
+			return false
+		}
+		log.Infof("Envoy %s(%s) status is: %v", envoy.Name, envoy.UUID, envoy.Status)
+		return envoy.Status == entity.ParticipantOpenFLStatusActive ||
+			envoy.Status == entity.ParticipantOpenFLStatusFailed ||
+			envoy.Status == entity.ParticipantOpenFLStatusUnknown
+	}, time.Hour, time.Second*2)
+}
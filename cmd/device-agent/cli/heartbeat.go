@@ -0,0 +1,150 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/homedir"
+)
+
+func HeartbeatCommand() *cli.Command {
+	return &cli.Command{
+		Name: "heartbeat",
+		Flags: envoyCommandFlags(
+			&cli.StringFlag{
+				Name:     "kube-config",
+				Aliases:  []string{"k"},
+				Value:    filepath.Join(homedir.HomeDir(), ".kube", "config"),
+				Usage:    "Kubeconfig file",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "namespace",
+				Aliases:  []string{"n"},
+				Value:    "",
+				Usage:    "Namespace of the Envoy, default to the one recorded in the lifecycle manager",
+				Required: false,
+			},
+			&cli.DurationFlag{
+				Name:     "interval",
+				Aliases:  []string{"i"},
+				Value:    time.Minute,
+				Usage:    "Interval between the heartbeats",
+				Required: false,
+			},
+		),
+		Usage: "Run as a daemon that periodically reports the device health to the lifecycle manager",
+		Action: func(c *cli.Context) error {
+			kubeconfigBytes, err := ioutil.ReadFile(c.String("kube-config"))
+			if err != nil {
+				return err
+			}
+			client, err := kubernetes.NewKubernetesClient("", string(kubeconfigBytes), false)
+			if err != nil {
+				return err
+			}
+			namespace := c.String("namespace")
+			if namespace == "" {
+				envoy, err := getEnvoyInfo(c.String("credential"), c.String("uuid"), c.String("server-host"), c.Int("server-port"), c.Bool("tls"))
+				if err != nil {
+					return err
+				}
+				namespace = envoy.Namespace
+			}
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			ticker := time.NewTicker(c.Duration("interval"))
+			defer ticker.Stop()
+			log.Infof("Sending heartbeats of Envoy %s every %v", c.String("uuid"), c.Duration("interval"))
+			for {
+				req := &service.ParticipantOpenFLEnvoyHeartbeatRequest{
+					TokenStr:     c.String("credential"),
+					DeviceHealth: collectDeviceHealth(ctx, client, namespace),
+				}
+				if err := postEnvoyRequest(c, "heartbeat", req); err != nil {
+					log.Errorf("error sending heartbeat: %v", err)
+				} else {
+					log.Debugf("heartbeat sent")
+				}
+				select {
+				case <-ctx.Done():
+					log.Infof("Heartbeat stopped")
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+}
+
+// collectDeviceHealth returns the node resources and the envoy pods states, the failures are logged and skipped
+// as a less privileged kubeconfig may not be able to list the nodes
+func collectDeviceHealth(ctx context.Context, client kubernetes.Client, namespace string) entity.ParticipantOpenFLDeviceHealth {
+	health := entity.ParticipantOpenFLDeviceHealth{}
+	if nodeList, err := client.GetClientSet().CoreV1().Nodes().List(ctx, v1.ListOptions{}); err != nil {
+		log.Warnf("unable to list nodes: %v", err)
+	} else {
+		for _, node := range nodeList.Items {
+			nodeInfo := entity.ParticipantOpenFLDeviceNodeInfo{
+				Name:              node.Name,
+				CPUCapacity:       node.Status.Capacity.Cpu().String(),
+				CPUAllocatable:    node.Status.Allocatable.Cpu().String(),
+				MemoryCapacity:    node.Status.Capacity.Memory().String(),
+				MemoryAllocatable: node.Status.Allocatable.Memory().String(),
+			}
+			for _, condition := range node.Status.Conditions {
+				if condition.Type == corev1.NodeReady {
+					nodeInfo.Ready = condition.Status == corev1.ConditionTrue
+				}
+			}
+			health.Nodes = append(health.Nodes, nodeInfo)
+		}
+	}
+	if podList, err := client.GetClientSet().CoreV1().Pods(namespace).List(ctx, v1.ListOptions{}); err != nil {
+		log.Warnf("unable to list pods in namespace %s: %v", namespace, err)
+	} else {
+		for _, pod := range podList.Items {
+			podInfo := entity.ParticipantOpenFLDevicePodInfo{
+				Name:  pod.Name,
+				Phase: string(pod.Status.Phase),
+			}
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodReady {
+					podInfo.Ready = condition.Status == corev1.ConditionTrue
+				}
+			}
+			for _, containerStatus := range pod.Status.ContainerStatuses {
+				podInfo.RestartCount += containerStatus.RestartCount
+			}
+			health.EnvoyPods = append(health.EnvoyPods, podInfo)
+		}
+	}
+	return health
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func ReconfigureCommand() *cli.Command {
+	return &cli.Command{
+		Name: "reconfigure",
+		Flags: envoyCommandFlags(
+			&cli.StringFlag{
+				Name:     "envoy-config",
+				Usage:    "The new Envoy configuration file path",
+				Required: false,
+			},
+			&cli.StringSliceFlag{
+				Name:     "python-file",
+				Usage:    "Shard descriptor python file to update, can be specified multiple times",
+				Required: false,
+			},
+			&cli.BoolFlag{
+				Name:     "wait",
+				Aliases:  []string{"w"},
+				Value:    false,
+				Usage:    "Wait for the reconfiguration to finish",
+				Required: false,
+			},
+		),
+		Usage: "Update the Envoy configuration and the shard descriptor python files",
+		Action: func(c *cli.Context) error {
+			req := &service.ParticipantOpenFLEnvoyReconfigureRequest{
+				TokenStr:    c.String("credential"),
+				PythonFiles: map[string]string{},
+			}
+			if envoyConfigPath := c.String("envoy-config"); envoyConfigPath != "" {
+				envoyConfigBytes, err := ioutil.ReadFile(envoyConfigPath)
+				if err != nil {
+					return err
+				}
+				var m map[string]interface{}
+				if err := yaml.Unmarshal(envoyConfigBytes, &m); err != nil {
+					return errors.Wrap(err, "invalid envoy config")
+				}
+				req.ConfigYAML = string(envoyConfigBytes)
+			}
+			for _, pythonFilePath := range c.StringSlice("python-file") {
+				content, err := ioutil.ReadFile(pythonFilePath)
+				if err != nil {
+					return err
+				}
+				req.PythonFiles[filepath.Base(pythonFilePath)] = string(content)
+			}
+			if req.ConfigYAML == "" && len(req.PythonFiles) == 0 {
+				return errors.New("either --envoy-config or --python-file must be specified")
+			}
+			if err := postEnvoyRequest(c, "reconfigure", req); err != nil {
+				return err
+			}
+			log.Infof("Envoy %s is being reconfigured", c.String("uuid"))
+			if c.Bool("wait") {
+				return waitForEnvoyActive(c)
+			}
+			return nil
+		},
+	}
+}
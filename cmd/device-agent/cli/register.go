@@ -22,6 +22,7 @@ import (
 
 	"github.com/FederatedAI/FedLCM/pkg/utils"
 	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
 	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -161,19 +162,20 @@ func RegisterCommand() *cli.Command {
 				return err
 			}
 			type RegisterResponse struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-				Data    string `json:"data"`
+				Code    int                                     `json:"code"`
+				Message string                                  `json:"message"`
+				Data    service.ParticipantRegistrationResponse `json:"data"`
 			}
 			var registerResponse RegisterResponse
 			if err := json.Unmarshal(body, &registerResponse); err != nil {
 				return err
 			}
-			log.Infof("New Envoy is being prepared, UUID: %s", registerResponse.Data)
+			log.Infof("New Envoy is being prepared, UUID: %s", registerResponse.Data.UUID)
+			log.Infof("Credential to manage the Envoy: %s, keep it as it won't be shown again", registerResponse.Data.Credential)
 			if c.Bool("wait") {
 				log.Infof("Waiting for the preparation to finish")
 				if err := utils.ExecuteWithTimeout(func() bool {
-					envoy, err := getEnvoyInfo(req.TokenStr, registerResponse.Data, c.String("server-host"), c.Int("server-port"), c.Bool("tls"))
+					envoy, err := getEnvoyInfo(registerResponse.Data.Credential, registerResponse.Data.UUID, c.String("server-host"), c.Int("server-port"), c.Bool("tls"))
 					if err != nil {
 						log.Errorf("error getting Envoy info: %v, retry", err)
 						return false
@@ -40,6 +40,10 @@ func initCommandLine() *cli.App {
 		Commands: []*cli.Command{
 			RegisterCommand(),
 			StatusCommand(),
+			UnregisterCommand(),
+			ReconfigureCommand(),
+			UpgradeCommand(),
+			HeartbeatCommand(),
 		},
 	}
 	app.Before = func(c *cli.Context) error {
@@ -17,9 +17,10 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/pkg/errors"
+	"net/url"
 
 	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -48,10 +49,10 @@ func StatusCommand() *cli.Command {
 				Required: false,
 			},
 			&cli.StringFlag{
-				Name:     "token",
-				Aliases:  []string{"t"},
+				Name:     "credential",
+				Aliases:  []string{"c", "token", "t"},
 				Value:    "",
-				Usage:    "The registration token string",
+				Usage:    "The credential returned by the registration, envoys registered by earlier versions use the token string",
 				Required: true,
 			},
 			&cli.StringFlag{
@@ -64,9 +65,9 @@ func StatusCommand() *cli.Command {
 		},
 		Usage: "Query the status of the Envoy",
 		Action: func(c *cli.Context) error {
-			token := c.String("token")
+			credential := c.String("credential")
 			uuid := c.String("uuid")
-			envoy, err := getEnvoyInfo(token, uuid, c.String("server-host"), c.Int("server-port"), c.Bool("tls"))
+			envoy, err := getEnvoyInfo(credential, uuid, c.String("server-host"), c.Int("server-port"), c.Bool("tls"))
 			if err != nil {
 				return err
 			}
@@ -76,8 +77,8 @@ func StatusCommand() *cli.Command {
 	}
 }
 
-func getEnvoyInfo(token, uuid, host string, port int, tls bool) (*service.OpenFLEnvoyDetail, error) {
-	resp, err := sendJSON("GET", getUrl(host, port, tls, fmt.Sprintf("federation/openfl/envoy/%s?token=%s", uuid, token)), nil)
+func getEnvoyInfo(credential, uuid, host string, port int, tls bool) (*service.OpenFLEnvoyDetail, error) {
+	resp, err := sendJSON("GET", getUrl(host, port, tls, fmt.Sprintf("federation/openfl/envoy/%s?token=%s", uuid, url.QueryEscape(credential))), nil)
 	defer resp.Body.Close()
 	body, err := parseResponse(resp)
 	if err != nil {
@@ -0,0 +1,48 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func UnregisterCommand() *cli.Command {
+	return &cli.Command{
+		Name: "unregister",
+		Flags: envoyCommandFlags(
+			&cli.BoolFlag{
+				Name:     "force",
+				Aliases:  []string{"f"},
+				Value:    false,
+				Usage:    "Remove the envoy record even if the uninstallation fails",
+				Required: false,
+			},
+		),
+		Usage: "Uninstall the Envoy and remove it from the lifecycle manager",
+		Action: func(c *cli.Context) error {
+			req := &service.ParticipantOpenFLEnvoyUnregistrationRequest{
+				TokenStr: c.String("credential"),
+				Force:    c.Bool("force"),
+			}
+			if err := postEnvoyRequest(c, "unregister", req); err != nil {
+				return err
+			}
+			log.Infof("Envoy %s is being removed", c.String("uuid"))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func UpgradeCommand() *cli.Command {
+	return &cli.Command{
+		Name: "upgrade",
+		Flags: envoyCommandFlags(
+			&cli.StringFlag{
+				Name:     "version",
+				Aliases:  []string{"v"},
+				Value:    "",
+				Usage:    "The Envoy chart version to upgrade to",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:     "wait",
+				Aliases:  []string{"w"},
+				Value:    false,
+				Usage:    "Wait for the upgrade to finish",
+				Required: false,
+			},
+		),
+		Usage: "Upgrade the Envoy to a newer chart version",
+		Action: func(c *cli.Context) error {
+			req := &service.ParticipantOpenFLEnvoyUpgradeRequest{
+				TokenStr:       c.String("credential"),
+				UpgradeVersion: c.String("version"),
+			}
+			if err := postEnvoyRequest(c, "upgrade", req); err != nil {
+				return err
+			}
+			log.Infof("Envoy %s is being upgraded to %s", c.String("uuid"), req.UpgradeVersion)
+			if c.Bool("wait") {
+				return waitForEnvoyActive(c)
+			}
+			return nil
+		},
+	}
+}
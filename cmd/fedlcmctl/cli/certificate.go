@@ -0,0 +1,79 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+// CertificateCommand manages the certificates
+func CertificateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "certificate",
+		Usage: "Manage the certificates",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the certificates",
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					certificateList, err := client.ListCertificates()
+					if err != nil {
+						return err
+					}
+					return printOutput(c, certificateList, []string{"UUID", "NAME", "COMMON NAME", "SERIAL NUMBER", "EXPIRATION", "BINDINGS"}, func() [][]string {
+						var rows [][]string
+						for _, certificate := range certificateList {
+							var bindings []string
+							for _, binding := range certificate.Bindings {
+								bindings = append(bindings, binding.ParticipantName+"/"+binding.ServiceType.String())
+							}
+							rows = append(rows, []string{certificate.UUID, certificate.Name, certificate.CommonName,
+								certificate.SerialNumber, formatTime(certificate.ExpirationDate), joinStrings(bindings)})
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a certificate that is not used by any participant",
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:     "revoke",
+						Value:    false,
+						Usage:    "Revoke the certificate before deleting it",
+						Required: false,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.DeleteCertificate(uuid, c.Bool("revoke"))
+				},
+			},
+		},
+	}
+}
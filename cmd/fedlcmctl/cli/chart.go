@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/urfave/cli/v2"
+)
+
+// ChartCommand queries the charts
+func ChartCommand() *cli.Command {
+	chartHeader := []string{"UUID", "NAME", "CHART NAME", "VERSION", "TYPE", "CREATED"}
+	chartRow := func(chart service.ChartListItem) []string {
+		return []string{chart.UUID, chart.Name, chart.ChartName, chart.Version, fmt.Sprintf("%d", chart.Type), formatTime(chart.CreatedAt)}
+	}
+	return &cli.Command{
+		Name:  "chart",
+		Usage: "Query the charts",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the charts",
+				Flags: []cli.Flag{
+					&cli.UintFlag{
+						Name:     "type",
+						Value:    0,
+						Usage:    "Only list the charts of the type, 1: FATE exchange 2: FATE cluster 3: OpenFL director 4: OpenFL envoy",
+						Required: false,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					chartList, err := client.ListCharts(entity.ChartType(c.Uint("type")))
+					if err != nil {
+						return err
+					}
+					return printOutput(c, chartList, chartHeader, func() [][]string {
+						var rows [][]string
+						for _, chart := range chartList {
+							rows = append(rows, chartRow(chart))
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the detail of a chart",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					chart, err := client.GetChart(uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, chart, chartHeader, func() [][]string {
+						return [][]string{chartRow(chart.ChartListItem)}
+					})
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	fedlcmclient "github.com/FederatedAI/FedLCM/pkg/fedlcm-client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// loginContext is a named lifecycle manager server and the token to access it
+type loginContext struct {
+	Name string `json:"name"`
+	fedlcmclient.Config
+}
+
+// config is the content of the config file
+type config struct {
+	CurrentContext string         `json:"current_context"`
+	Contexts       []loginContext `json:"contexts"`
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".fedlcmctl.yaml"
+	}
+	return filepath.Join(home, ".fedlcm", "config.yaml")
+}
+
+func loadConfig(path string) (*config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &config{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read config file %s", path)
+	}
+	cfg := &config{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config file %s", path)
+	}
+	return cfg, nil
+}
+
+func saveConfig(path string, cfg *config) error {
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create config directory")
+	}
+	// the file contains the login tokens
+	return os.WriteFile(path, content, 0600)
+}
+
+// setContext adds or replaces the context of the same name
+func (cfg *config) setContext(newContext loginContext) {
+	for index := range cfg.Contexts {
+		if cfg.Contexts[index].Name == newContext.Name {
+			cfg.Contexts[index] = newContext
+			return
+		}
+	}
+	cfg.Contexts = append(cfg.Contexts, newContext)
+}
+
+func (cfg *config) getContext(name string) (*loginContext, error) {
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return nil, errors.New("no context is selected, use the login command first")
+	}
+	for index := range cfg.Contexts {
+		if cfg.Contexts[index].Name == name {
+			return &cfg.Contexts[index], nil
+		}
+	}
+	return nil, errors.Errorf("context %s does not exist", name)
+}
+
+// newClient returns the client of the context selected by the global flags
+func newClient(c *cli.Context) (fedlcmclient.Client, error) {
+	cfg, err := loadConfig(c.String("config"))
+	if err != nil {
+		return nil, err
+	}
+	loginCtx, err := cfg.getContext(c.String("context"))
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("using context %s with server %s", loginCtx.Name, loginCtx.Server)
+	return fedlcmclient.NewClient(loginCtx.Config), nil
+}
+
+// LoginCommand logs in to a lifecycle manager and saves the token into a context
+func LoginCommand() *cli.Command {
+	return &cli.Command{
+		Name: "login",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "server",
+				Aliases:  []string{"s"},
+				Value:    "",
+				Usage:    "Address of the lifecycle manager, like https://fedlcm.example.com:8443",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "username",
+				Aliases:  []string{"u"},
+				Value:    "Admin",
+				Usage:    "The username",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "password",
+				Aliases:  []string{"p"},
+				Value:    "",
+				Usage:    "The password",
+				EnvVars:  []string{"FEDLCMCTL_PASSWORD"},
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:     "insecure-skip-tls-verify",
+				Value:    false,
+				Usage:    "Do not verify the server certificate",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "name",
+				Value:    "default",
+				Usage:    "Name of the context to save the login info to",
+				Required: false,
+			},
+		},
+		Usage: "Log in to a lifecycle manager and make it the current context",
+		Action: func(c *cli.Context) error {
+			clientConfig := fedlcmclient.Config{
+				Server:             c.String("server"),
+				InsecureSkipVerify: c.Bool("insecure-skip-tls-verify"),
+			}
+			token, err := fedlcmclient.Login(clientConfig, c.String("username"), c.String("password"))
+			if err != nil {
+				return err
+			}
+			clientConfig.Token = token
+
+			cfg, err := loadConfig(c.String("config"))
+			if err != nil {
+				return err
+			}
+			cfg.setContext(loginContext{
+				Name:   c.String("name"),
+				Config: clientConfig,
+			})
+			cfg.CurrentContext = c.String("name")
+			if err := saveConfig(c.String("config"), cfg); err != nil {
+				return err
+			}
+			log.Infof("Logged in to %s, current context is %s", clientConfig.Server, cfg.CurrentContext)
+			return nil
+		},
+	}
+}
+
+// ContextCommand manages the saved login contexts
+func ContextCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "context",
+		Usage: "Manage the login contexts",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the login contexts",
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					var rows [][]string
+					for _, loginCtx := range cfg.Contexts {
+						current := ""
+						if loginCtx.Name == cfg.CurrentContext {
+							current = "*"
+						}
+						rows = append(rows, []string{current, loginCtx.Name, loginCtx.Server})
+					}
+					// never print the tokens
+					return printTable([]string{"CURRENT", "NAME", "SERVER"}, rows)
+				},
+			},
+			{
+				Name:      "use",
+				Usage:     "Switch the current context",
+				ArgsUsage: "NAME",
+				Action: func(c *cli.Context) error {
+					name, err := requiredArg(c, "NAME")
+					if err != nil {
+						return err
+					}
+					cfg, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					if _, err := cfg.getContext(name); err != nil {
+						return err
+					}
+					cfg.CurrentContext = name
+					return saveConfig(c.String("config"), cfg)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a context and its saved token",
+				ArgsUsage: "NAME",
+				Action: func(c *cli.Context) error {
+					name, err := requiredArg(c, "NAME")
+					if err != nil {
+						return err
+					}
+					cfg, err := loadConfig(c.String("config"))
+					if err != nil {
+						return err
+					}
+					var contexts []loginContext
+					for _, loginCtx := range cfg.Contexts {
+						if loginCtx.Name != name {
+							contexts = append(contexts, loginCtx)
+						}
+					}
+					cfg.Contexts = contexts
+					if cfg.CurrentContext == name {
+						cfg.CurrentContext = ""
+					}
+					return saveConfig(c.String("config"), cfg)
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/urfave/cli/v2"
+)
+
+// EndpointCommand manages the KubeFATE endpoints
+func EndpointCommand() *cli.Command {
+	endpointHeader := []string{"UUID", "NAME", "TYPE", "INFRA PROVIDER", "NAMESPACE", "VERSION", "STATUS", "CREATED"}
+	endpointRow := func(endpoint service.EndpointListItem) []string {
+		return []string{endpoint.UUID, endpoint.Name, string(endpoint.Type), endpoint.InfraProviderName, endpoint.Namespace,
+			endpoint.KubeFATEVersion, endpoint.Status.String(), formatTime(endpoint.CreatedAt)}
+	}
+	return &cli.Command{
+		Name:  "endpoint",
+		Usage: "Manage the endpoints",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the endpoints",
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					endpointList, err := client.ListEndpoints()
+					if err != nil {
+						return err
+					}
+					return printOutput(c, endpointList, endpointHeader, func() [][]string {
+						var rows [][]string
+						for _, endpoint := range endpointList {
+							rows = append(rows, endpointRow(endpoint))
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the detail of an endpoint",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					endpoint, err := client.GetEndpoint(uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, endpoint, endpointHeader, func() [][]string {
+						return [][]string{endpointRow(endpoint.EndpointListItem)}
+					})
+				},
+			},
+			{
+				Name:  "create",
+				Usage: "Add an existing endpoint or install a new one",
+				Flags: []cli.Flag{
+					fileFlag("The endpoint creation request"),
+				},
+				Action: func(c *cli.Context) error {
+					req := &service.EndpointCreationRequest{}
+					if err := decodeFile(c.String("file"), req); err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.CreateEndpoint(req)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete an endpoint",
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:     "uninstall",
+						Value:    false,
+						Usage:    "Uninstall the endpoint from the infra provider too",
+						Required: false,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.DeleteEndpoint(uuid, c.Bool("uninstall"))
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+// EventCommand queries the events
+func EventCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "event",
+		Usage: "Query the events",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the events of an endpoint, exchange, cluster, director or envoy",
+				ArgsUsage: "ENTITY_UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "ENTITY_UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					eventList, err := client.ListEvents(uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, eventList, []string{"TIME", "LEVEL", "DESCRIPTION"}, func() [][]string {
+						var rows [][]string
+						for _, event := range eventList {
+							rows = append(rows, []string{formatTime(event.CreatedAt), event.Data.LogLevel, event.Data.Description})
+						}
+						return rows
+					})
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,460 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	fedlcmclient "github.com/FederatedAI/FedLCM/pkg/fedlcm-client"
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// FATECommand manages the FATE exchanges and clusters
+func FATECommand() *cli.Command {
+	return &cli.Command{
+		Name:  "fate",
+		Usage: "Manage the FATE exchanges and clusters",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "participants",
+				Usage: "List the exchanges and clusters in a FATE federation",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					participants, err := client.ListFATEParticipants(c.String("federation"))
+					if err != nil {
+						return err
+					}
+					return printOutput(c, participants, fateParticipantHeader, func() [][]string {
+						var rows [][]string
+						for _, participant := range append(participants.Exchanges, participants.Clusters...) {
+							rows = append(rows, fateParticipantRow(participant))
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:  "sizing-profiles",
+				Usage: "List the sizing profiles of FATE clusters",
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					profiles, err := client.ListFATESizingProfiles()
+					if err != nil {
+						return err
+					}
+					return printOutput(c, profiles, []string{"UUID", "NAME", "BUILT-IN", "DESCRIPTION"}, func() [][]string {
+						var rows [][]string
+						for _, profile := range profiles {
+							rows = append(rows, []string{profile.UUID, profile.Name, fmt.Sprintf("%v", profile.BuiltIn), profile.Description})
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:  "diagnose",
+				Usage: "Check the connectivity between the exchanges and clusters in a FATE federation, the report is printed if --wait is set",
+				Flags: append([]cli.Flag{
+					federationFlag(),
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					uuid, err := client.DiagnoseFATEFederation(c.String("federation"))
+					if err != nil {
+						return err
+					}
+					if !c.Bool("wait") {
+						return printUUID(c, uuid)
+					}
+					var diagnostics *service.FATEFederationDiagnosticsDetail
+					if err := waitUntil(c, "diagnostics "+uuid, func() (bool, error) {
+						diagnostics, err = client.GetFATEDiagnostics(c.String("federation"), uuid)
+						if err != nil {
+							return false, err
+						}
+						return diagnostics.Status != entity.FederationFATEDiagnosticsStatusRunning, nil
+					}); err != nil {
+						return err
+					}
+					return printFATEDiagnostics(c, diagnostics)
+				},
+			},
+			{
+				Name:      "diagnostics",
+				Usage:     "Show the status and the report of a diagnostics started by the diagnose command",
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					diagnostics, err := client.GetFATEDiagnostics(c.String("federation"), uuid)
+					if err != nil {
+						return err
+					}
+					return printFATEDiagnostics(c, diagnostics)
+				},
+			},
+			fateParticipantCommand(entity.ParticipantFATETypeExchange),
+			fateParticipantCommand(entity.ParticipantFATETypeCluster),
+		},
+	}
+}
+
+// printFATEDiagnostics prints the checks in the report of the diagnostics, or its status if there is no report yet
+func printFATEDiagnostics(c *cli.Context, diagnostics *service.FATEFederationDiagnosticsDetail) error {
+	if diagnostics.Status == entity.FederationFATEDiagnosticsStatusFailed {
+		return errors.Errorf("diagnostics %s failed: %s", diagnostics.UUID, diagnostics.Message)
+	}
+	if diagnostics.Report == nil {
+		return printOutput(c, diagnostics, []string{"UUID", "STATUS", "CREATED"}, func() [][]string {
+			return [][]string{{diagnostics.UUID, diagnostics.Status.String(), formatTime(diagnostics.CreatedAt)}}
+		})
+	}
+	return printOutput(c, diagnostics.Report, []string{"SOURCE", "TARGET", "SERVICE", "PROBE", "ADDRESS", "STATUS", "MESSAGE", "SUGGESTION"}, func() [][]string {
+		var rows [][]string
+		for _, check := range diagnostics.Report.Checks {
+			rows = append(rows, []string{check.SourceName, check.TargetName, string(check.Service), string(check.Probe),
+				check.Address, string(check.Status), check.Message, check.Suggestion})
+		}
+		return rows
+	})
+}
+
+var fateParticipantHeader = []string{"UUID", "NAME", "TYPE", "PARTY ID", "NAMESPACE", "VERSION", "STATUS", "MANAGED", "CREATED"}
+
+func fateParticipantRow(participant *service.ParticipantFATEListItem) []string {
+	partyID := ""
+	if participant.Type == entity.ParticipantFATETypeCluster {
+		partyID = fmt.Sprintf("%d", participant.PartyID)
+	}
+	return []string{participant.UUID, participant.Name, participant.Type.String(), partyID, participant.Namespace,
+		participant.Version, participant.Status.String(), fmt.Sprintf("%v", participant.IsManaged), formatTime(participant.CreatedAt)}
+}
+
+func federationFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "federation",
+		Aliases:  []string{"fed"},
+		Value:    "",
+		Usage:    "UUID of the federation",
+		Required: true,
+	}
+}
+
+// fateParticipantCommand returns the command to manage the exchanges or the clusters
+func fateParticipantCommand(participantType entity.ParticipantFATEType) *cli.Command {
+	typeName := participantType.String()
+	get := func(client fedlcmclient.Client, federationUUID, uuid string) (interface{}, *service.ParticipantFATEListItem, error) {
+		if participantType == entity.ParticipantFATETypeExchange {
+			exchange, err := client.GetFATEExchange(federationUUID, uuid)
+			if err != nil {
+				return nil, nil, err
+			}
+			return exchange, &exchange.ParticipantFATEListItem, nil
+		}
+		cluster, err := client.GetFATECluster(federationUUID, uuid)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cluster, &cluster.ParticipantFATEListItem, nil
+	}
+	waitForStatus := func(c *cli.Context, client fedlcmclient.Client, uuid string, version string) error {
+		return waitUntil(c, fmt.Sprintf("%s %s", typeName, uuid), func() (bool, error) {
+			_, participant, err := get(client, c.String("federation"), uuid)
+			if err != nil {
+				return false, err
+			}
+			done, err := fateStatusDone(fmt.Sprintf("%s %s", typeName, participant.Name), participant.Status)
+			if done && err == nil && version != "" && participant.Version != version {
+				return false, nil
+			}
+			return done, err
+		})
+	}
+	command := &cli.Command{
+		Name:  typeName,
+		Usage: fmt.Sprintf("Manage the FATE %ss", typeName),
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: fmt.Sprintf("Create a FATE %s, the deployment yaml is generated if it is not in the request", typeName),
+				Flags: append([]cli.Flag{
+					federationFlag(),
+					fileFlag(fmt.Sprintf("The %s creation request", typeName)),
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					var uuid string
+					if participantType == entity.ParticipantFATETypeExchange {
+						uuid, err = createFATEExchange(c, client)
+					} else {
+						uuid, err = createFATECluster(c, client)
+					}
+					if err != nil {
+						return err
+					}
+					if err := printUUID(c, uuid); err != nil {
+						return err
+					}
+					return waitForStatus(c, client, uuid, "")
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     fmt.Sprintf("Show the detail of a FATE %s", typeName),
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					detail, participant, err := get(client, c.String("federation"), uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, detail, fateParticipantHeader, func() [][]string {
+						return [][]string{fateParticipantRow(participant)}
+					})
+				},
+			},
+			{
+				Name:      "upgrade",
+				Usage:     fmt.Sprintf("Upgrade a FATE %s", typeName),
+				ArgsUsage: "UUID",
+				Flags: append([]cli.Flag{
+					federationFlag(),
+					&cli.StringFlag{
+						Name:     "version",
+						Value:    "",
+						Usage:    "The chart version to upgrade to",
+						Required: true,
+					},
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					if participantType == entity.ParticipantFATETypeExchange {
+						err = client.UpgradeFATEExchange(c.String("federation"), uuid, c.String("version"))
+					} else {
+						err = client.UpgradeFATECluster(c.String("federation"), uuid, c.String("version"))
+					}
+					if err != nil {
+						return err
+					}
+					return waitForStatus(c, client, uuid, c.String("version"))
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     fmt.Sprintf("Remove a FATE %s", typeName),
+				ArgsUsage: "UUID",
+				Flags: append([]cli.Flag{
+					federationFlag(),
+					&cli.BoolFlag{
+						Name:     "force",
+						Value:    false,
+						Usage:    "Remove the record even if the uninstallation fails",
+						Required: false,
+					},
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					if participantType == entity.ParticipantFATETypeExchange {
+						err = client.DeleteFATEExchange(c.String("federation"), uuid, c.Bool("force"))
+					} else {
+						err = client.DeleteFATECluster(c.String("federation"), uuid, c.Bool("force"))
+					}
+					if err != nil {
+						return err
+					}
+					return waitUntil(c, fmt.Sprintf("%s %s to be removed", typeName, uuid), func() (bool, error) {
+						_, _, err := get(client, c.String("federation"), uuid)
+						return removalDone(fmt.Sprintf("%s %s", typeName, uuid), err)
+					})
+				},
+			},
+		},
+	}
+	if participantType == entity.ParticipantFATETypeCluster {
+		command.Subcommands = append(command.Subcommands, &cli.Command{
+			Name:      "resize",
+			Usage:     "Apply a sizing profile to a FATE cluster",
+			ArgsUsage: "UUID",
+			Flags: append([]cli.Flag{
+				federationFlag(),
+				&cli.StringFlag{
+					Name:     "profile",
+					Value:    "",
+					Usage:    "UUID of the sizing profile",
+					Required: true,
+				},
+			}, waitFlags()...),
+			Action: func(c *cli.Context) error {
+				uuid, err := requiredArg(c, "UUID")
+				if err != nil {
+					return err
+				}
+				client, err := newClient(c)
+				if err != nil {
+					return err
+				}
+				if err := client.ResizeFATECluster(c.String("federation"), uuid, c.String("profile")); err != nil {
+					return err
+				}
+				return waitForStatus(c, client, uuid, "")
+			},
+		}, &cli.Command{
+			Name:  "adoptable",
+			Usage: "List the FATE clusters deployed by the KubeFATE service of an endpoint and whether they can be adopted",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "endpoint",
+					Value:    "",
+					Usage:    "UUID of the KubeFATE endpoint",
+					Required: true,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				client, err := newClient(c)
+				if err != nil {
+					return err
+				}
+				candidates, err := client.ListAdoptableFATEClusters(c.String("endpoint"))
+				if err != nil {
+					return err
+				}
+				return printOutput(c, candidates, []string{"CLUSTER UUID", "NAME", "NAMESPACE", "PARTY ID", "VERSION", "BACKEND", "STATUS", "ADOPTABLE", "REASON"}, func() [][]string {
+					var rows [][]string
+					for _, candidate := range candidates {
+						rows = append(rows, []string{candidate.ClusterUUID, candidate.Name, candidate.Namespace, fmt.Sprintf("%d", candidate.PartyID),
+							candidate.ChartVersion, string(candidate.Backend), candidate.Status, fmt.Sprintf("%v", candidate.Adoptable), candidate.Reason})
+					}
+					return rows
+				})
+			},
+		}, &cli.Command{
+			Name:  "adopt",
+			Usage: "Adopt an existing KubeFATE-deployed FATE cluster as a managed cluster",
+			Flags: append([]cli.Flag{
+				federationFlag(),
+				fileFlag("The cluster adoption request"),
+			}, waitFlags()...),
+			Action: func(c *cli.Context) error {
+				client, err := newClient(c)
+				if err != nil {
+					return err
+				}
+				req := &domainService.ParticipantFATEAdoptionRequest{}
+				if err := decodeFile(c.String("file"), req); err != nil {
+					return err
+				}
+				uuid, err := client.AdoptFATECluster(c.String("federation"), req)
+				if err != nil {
+					return err
+				}
+				if err := printUUID(c, uuid); err != nil {
+					return err
+				}
+				return waitForStatus(c, client, uuid, "")
+			},
+		})
+	}
+	return command
+}
+
+func createFATEExchange(c *cli.Context, client fedlcmclient.Client) (string, error) {
+	req := &domainService.ParticipantFATEExchangeCreationRequest{}
+	if err := decodeFile(c.String("file"), req); err != nil {
+		return "", err
+	}
+	req.FederationUUID = c.String("federation")
+	if req.ServiceType == entity.ParticipantDefaultServiceTypeUnknown {
+		req.ServiceType = entity.ParticipantDefaultServiceTypeLoadBalancer
+	}
+	if req.DeploymentYAML == "" {
+		deploymentYAML, err := client.GetFATEExchangeDeploymentYAML(&req.ParticipantFATEExchangeYAMLCreationRequest)
+		if err != nil {
+			return "", err
+		}
+		req.DeploymentYAML = deploymentYAML
+	}
+	return client.CreateFATEExchange(req.FederationUUID, req)
+}
+
+func createFATECluster(c *cli.Context, client fedlcmclient.Client) (string, error) {
+	req := &domainService.ParticipantFATEClusterCreationRequest{}
+	if err := decodeFile(c.String("file"), req); err != nil {
+		return "", err
+	}
+	req.FederationUUID = c.String("federation")
+	if req.ServiceType == entity.ParticipantDefaultServiceTypeUnknown {
+		req.ServiceType = entity.ParticipantDefaultServiceTypeLoadBalancer
+	}
+	if req.DeploymentYAML == "" {
+		deploymentYAML, err := client.GetFATEClusterDeploymentYAML(&req.ParticipantFATEClusterYAMLCreationRequest)
+		if err != nil {
+			return "", err
+		}
+		req.DeploymentYAML = deploymentYAML
+	}
+	return client.CreateFATECluster(req.FederationUUID, req)
+}
@@ -0,0 +1,319 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	fedlcmclient "github.com/FederatedAI/FedLCM/pkg/fedlcm-client"
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// FederationCommand manages the FATE and OpenFL federations
+func FederationCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "federation",
+		Usage: "Manage the federations",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the federations",
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					federationList, err := client.ListFederations()
+					if err != nil {
+						return err
+					}
+					return printOutput(c, federationList, []string{"UUID", "NAME", "TYPE", "DESCRIPTION", "CREATED"}, func() [][]string {
+						var rows [][]string
+						for _, federation := range federationList {
+							rows = append(rows, []string{federation.UUID, federation.Name, string(federation.Type),
+								federation.Description, formatTime(federation.CreatedAt)})
+						}
+						return rows
+					})
+				},
+			},
+			federationFATECommand(),
+			federationOpenFLCommand(),
+		},
+	}
+}
+
+func federationFATECommand() *cli.Command {
+	return &cli.Command{
+		Name:  "fate",
+		Usage: "Manage the FATE federations",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "Create a FATE federation",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "name",
+						Value:    "",
+						Usage:    "Name of the federation",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "description",
+						Value:    "",
+						Usage:    "Description of the federation",
+						Required: false,
+					},
+					&cli.StringFlag{
+						Name:     "domain",
+						Value:    "",
+						Usage:    "Domain name of the federation",
+						Required: true,
+					},
+					&cli.UintFlag{
+						Name:     "mode",
+						Value:    0,
+						Usage:    "How the clusters reach each other, 0: through exchanges 1: peer-to-peer",
+						Required: false,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					uuid, err := client.CreateFATEFederation(&service.FederationFATECreationRequest{
+						FederationCreationRequest: service.FederationCreationRequest{
+							Name:        c.String("name"),
+							Description: c.String("description"),
+						},
+						Domain: c.String("domain"),
+						Mode:   entity.FederationFATEMode(c.Uint("mode")),
+					})
+					if err != nil {
+						return err
+					}
+					return printUUID(c, uuid)
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the detail of a FATE federation",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					federation, err := client.GetFATEFederation(uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, federation, []string{"UUID", "NAME", "DOMAIN", "MODE", "CREATED"}, func() [][]string {
+						return [][]string{{federation.UUID, federation.Name, federation.Domain, federation.Mode.String(), formatTime(federation.CreatedAt)}}
+					})
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a FATE federation that has no participants",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.DeleteFATEFederation(uuid)
+				},
+			},
+			{
+				Name:  "apply",
+				Usage: "Create or update a FATE federation and its participants from a spec",
+				Flags: append([]cli.Flag{
+					fileFlag("The federation spec"),
+					&cli.BoolFlag{
+						Name:     "dry-run",
+						Value:    false,
+						Usage:    "Only show the plan without applying it",
+						Required: false,
+					},
+					&cli.BoolFlag{
+						Name:     "prune",
+						Value:    false,
+						Usage:    "Remove the participants that are not in the spec",
+						Required: false,
+					},
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					spec, err := readFile(c.String("file"))
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					plan, err := client.ApplyFATEFederation(spec, c.Bool("dry-run"), c.Bool("prune"))
+					if err != nil {
+						return err
+					}
+					if err := printOutput(c, plan, []string{"ACTION", "TYPE", "NAME", "PARTY ID", "UUID", "REASON"}, func() [][]string {
+						var rows [][]string
+						for _, item := range plan.Items {
+							partyID := ""
+							if item.PartyID != 0 {
+								partyID = fmt.Sprintf("%d", item.PartyID)
+							}
+							rows = append(rows, []string{string(item.Action), item.Type, item.Name, partyID, item.UUID, item.Reason})
+						}
+						return rows
+					}); err != nil {
+						return err
+					}
+					if plan.DryRun {
+						return nil
+					}
+					return waitUntil(c, "the federation to converge", func() (bool, error) {
+						return fateApplyDone(client, plan)
+					})
+				},
+			},
+		},
+	}
+}
+
+// fateApplyDone returns whether all the actions in the plan are finished
+func fateApplyDone(client fedlcmclient.Client, plan *domainService.FederationFATEApplyPlan) (bool, error) {
+	participants, err := client.ListFATEParticipants(plan.FederationUUID)
+	if err != nil {
+		return false, err
+	}
+	participantList := append(participants.Exchanges, participants.Clusters...)
+	for _, item := range plan.Items {
+		switch item.Action {
+		case domainService.FederationFATEApplyActionRemove:
+			for _, participant := range participantList {
+				if participant.UUID == item.UUID {
+					log.Infof("%s %s is being removed", item.Type, item.Name)
+					return false, nil
+				}
+			}
+		case domainService.FederationFATEApplyActionCreate, domainService.FederationFATEApplyActionReplace,
+			domainService.FederationFATEApplyActionUpgrade, domainService.FederationFATEApplyActionReconfigure:
+			if item.Type != entity.ParticipantFATETypeExchange.String() && item.Type != entity.ParticipantFATETypeCluster.String() {
+				continue
+			}
+			var current *service.ParticipantFATEListItem
+			for _, participant := range participantList {
+				if participant.Name == item.Name && participant.Type.String() == item.Type &&
+					(item.Action != domainService.FederationFATEApplyActionReplace || participant.UUID != item.UUID) {
+					current = participant
+				}
+			}
+			if current == nil {
+				log.Infof("%s %s is not created yet", item.Type, item.Name)
+				return false, nil
+			}
+			if done, err := fateStatusDone(fmt.Sprintf("%s %s", item.Type, item.Name), current.Status); !done || err != nil {
+				return done, err
+			}
+			if item.Action == domainService.FederationFATEApplyActionUpgrade && current.Version != item.UpgradeVersion {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func federationOpenFLCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "openfl",
+		Usage: "Manage the OpenFL federations",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "Create an OpenFL federation",
+				Flags: []cli.Flag{
+					fileFlag("The federation creation request"),
+				},
+				Action: func(c *cli.Context) error {
+					req := &service.FederationOpenFLCreationRequest{}
+					if err := decodeFile(c.String("file"), req); err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					uuid, err := client.CreateOpenFLFederation(req)
+					if err != nil {
+						return err
+					}
+					return printUUID(c, uuid)
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the detail of an OpenFL federation",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					federation, err := client.GetOpenFLFederation(uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, federation, []string{"UUID", "NAME", "DOMAIN", "DEPLOYMENT METHOD", "CREATED"}, func() [][]string {
+						return [][]string{{federation.UUID, federation.Name, federation.Domain, federation.DeploymentMethod.String(), formatTime(federation.CreatedAt)}}
+					})
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete an OpenFL federation that has no participants",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.DeleteOpenFLFederation(uuid)
+				},
+			},
+		},
+	}
+}
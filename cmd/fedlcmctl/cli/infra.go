@@ -0,0 +1,109 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/urfave/cli/v2"
+)
+
+// InfraCommand manages the infra providers
+func InfraCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "infra",
+		Usage: "Manage the infra providers",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the infra providers",
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					providerList, err := client.ListInfraProviders()
+					if err != nil {
+						return err
+					}
+					return printOutput(c, providerList, []string{"UUID", "NAME", "TYPE", "API SERVER", "CREATED"}, func() [][]string {
+						var rows [][]string
+						for _, provider := range providerList {
+							rows = append(rows, []string{provider.UUID, provider.Name, string(provider.Type),
+								provider.KubernetesProviderInfo.APIServer, formatTime(provider.CreatedAt)})
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the detail of an infra provider",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					provider, err := client.GetInfraProvider(uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, provider, []string{"UUID", "NAME", "TYPE", "API SERVER", "NAMESPACES", "CREATED"}, func() [][]string {
+						return [][]string{{provider.UUID, provider.Name, string(provider.Type), provider.KubernetesProviderInfo.APIServer,
+							joinStrings(provider.KubernetesProviderInfo.Namespaces), formatTime(provider.CreatedAt)}}
+					})
+				},
+			},
+			{
+				Name:  "create",
+				Usage: "Create an infra provider",
+				Flags: []cli.Flag{
+					fileFlag("The infra provider creation request"),
+				},
+				Action: func(c *cli.Context) error {
+					req := &service.InfraProviderCreationRequest{}
+					if err := decodeFile(c.String("file"), req); err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.CreateInfraProvider(req)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete an infra provider",
+				ArgsUsage: "UUID",
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.DeleteInfraProvider(uuid)
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/urfave/cli/v2"
+)
+
+// OpenFLCommand manages the OpenFL registration tokens, directors and envoys
+func OpenFLCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "openfl",
+		Usage: "Manage the OpenFL tokens, directors and envoys",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "participants",
+				Usage: "List the director and envoys in an OpenFL federation",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					participants, err := client.ListOpenFLParticipants(c.String("federation"))
+					if err != nil {
+						return err
+					}
+					return printOutput(c, participants, openFLParticipantHeader, func() [][]string {
+						var rows [][]string
+						if participants.Director != nil {
+							rows = append(rows, openFLParticipantRow(participants.Director))
+						}
+						for _, envoy := range participants.Envoy {
+							rows = append(rows, openFLParticipantRow(envoy))
+						}
+						return rows
+					})
+				},
+			},
+			openFLTokenCommand(),
+			openFLDirectorCommand(),
+			openFLEnvoyCommand(),
+		},
+	}
+}
+
+var openFLParticipantHeader = []string{"UUID", "NAME", "TYPE", "NAMESPACE", "STATUS", "LABELS", "CREATED"}
+
+func openFLParticipantRow(participant *service.ParticipantOpenFLListItem) []string {
+	return []string{participant.UUID, participant.Name, participant.Type.String(), participant.Namespace,
+		participant.Status.String(), fmt.Sprintf("%v", participant.Labels), formatTime(participant.CreatedAt)}
+}
+
+func openFLTokenCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "token",
+		Usage: "Manage the envoy registration tokens",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the registration tokens of an OpenFL federation",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					tokenList, err := client.ListOpenFLTokens(c.String("federation"))
+					if err != nil {
+						return err
+					}
+					return printOutput(c, tokenList, []string{"UUID", "NAME", "TOKEN", "USED", "LIMIT", "EXPIRED AT", "DISABLED", "LABELS"}, func() [][]string {
+						var rows [][]string
+						for _, token := range tokenList {
+							rows = append(rows, []string{token.UUID, token.Name, token.DisplayedTokenStr, fmt.Sprintf("%d", token.Used),
+								fmt.Sprintf("%d", token.Limit), formatTime(token.ExpiredAt), fmt.Sprintf("%v", token.Disabled), fmt.Sprintf("%v", token.Labels)})
+						}
+						return rows
+					})
+				},
+			},
+			{
+				Name:  "create",
+				Usage: "Create a registration token in an OpenFL federation",
+				Flags: []cli.Flag{
+					federationFlag(),
+					fileFlag("The token info"),
+				},
+				Action: func(c *cli.Context) error {
+					req := &service.RegistrationTokenOpenFLBasicInfo{}
+					if err := decodeFile(c.String("file"), req); err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.CreateOpenFLToken(c.String("federation"), req)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a registration token",
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					return client.DeleteOpenFLToken(c.String("federation"), uuid)
+				},
+			},
+		},
+	}
+}
+
+func openFLDirectorCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "director",
+		Usage: "Manage the OpenFL directors",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "Create an OpenFL director, the deployment yaml is generated if it is not in the request",
+				Flags: append([]cli.Flag{
+					federationFlag(),
+					fileFlag("The director creation request"),
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					req := &domainService.ParticipantOpenFLDirectorCreationRequest{}
+					if err := decodeFile(c.String("file"), req); err != nil {
+						return err
+					}
+					req.FederationUUID = c.String("federation")
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					if req.DeploymentYAML == "" {
+						deploymentYAML, err := client.GetOpenFLDirectorDeploymentYAML(&req.ParticipantOpenFLDirectorYAMLCreationRequest)
+						if err != nil {
+							return err
+						}
+						req.DeploymentYAML = deploymentYAML
+					}
+					uuid, err := client.CreateOpenFLDirector(req.FederationUUID, req)
+					if err != nil {
+						return err
+					}
+					if err := printUUID(c, uuid); err != nil {
+						return err
+					}
+					return waitUntil(c, "director "+uuid, func() (bool, error) {
+						director, err := client.GetOpenFLDirector(req.FederationUUID, uuid)
+						if err != nil {
+							return false, err
+						}
+						return openFLStatusDone("director "+director.Name, director.Status)
+					})
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the detail of an OpenFL director",
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					director, err := client.GetOpenFLDirector(c.String("federation"), uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, director, openFLParticipantHeader, func() [][]string {
+						return [][]string{openFLParticipantRow(&director.ParticipantOpenFLListItem)}
+					})
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Remove an OpenFL director",
+				ArgsUsage: "UUID",
+				Flags: append([]cli.Flag{
+					federationFlag(),
+					&cli.BoolFlag{
+						Name:     "force",
+						Value:    false,
+						Usage:    "Remove the record even if the uninstallation fails",
+						Required: false,
+					},
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					if err := client.DeleteOpenFLDirector(c.String("federation"), uuid, c.Bool("force")); err != nil {
+						return err
+					}
+					return waitUntil(c, fmt.Sprintf("director %s to be removed", uuid), func() (bool, error) {
+						_, err := client.GetOpenFLDirector(c.String("federation"), uuid)
+						return removalDone("director "+uuid, err)
+					})
+				},
+			},
+		},
+	}
+}
+
+func openFLEnvoyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "envoy",
+		Usage: "Manage the OpenFL envoys",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "get",
+				Usage:     "Show the detail of an OpenFL envoy",
+				ArgsUsage: "UUID",
+				Flags: []cli.Flag{
+					federationFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					envoy, err := client.GetOpenFLEnvoy(c.String("federation"), uuid)
+					if err != nil {
+						return err
+					}
+					return printOutput(c, envoy, openFLParticipantHeader, func() [][]string {
+						return [][]string{openFLParticipantRow(&envoy.ParticipantOpenFLListItem)}
+					})
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Remove an OpenFL envoy",
+				ArgsUsage: "UUID",
+				Flags: append([]cli.Flag{
+					federationFlag(),
+					&cli.BoolFlag{
+						Name:     "force",
+						Value:    false,
+						Usage:    "Remove the record even if the uninstallation fails",
+						Required: false,
+					},
+				}, waitFlags()...),
+				Action: func(c *cli.Context) error {
+					uuid, err := requiredArg(c, "UUID")
+					if err != nil {
+						return err
+					}
+					client, err := newClient(c)
+					if err != nil {
+						return err
+					}
+					if err := client.DeleteOpenFLEnvoy(c.String("federation"), uuid, c.Bool("force")); err != nil {
+						return err
+					}
+					return waitUntil(c, fmt.Sprintf("envoy %s to be removed", uuid), func() (bool, error) {
+						_, err := client.GetOpenFLEnvoy(c.String("federation"), uuid)
+						return removalDone("envoy "+uuid, err)
+					})
+				},
+			},
+		},
+	}
+}
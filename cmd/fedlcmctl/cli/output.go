@@ -0,0 +1,133 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputFormatTable = "table"
+	outputFormatJSON  = "json"
+	outputFormatYAML  = "yaml"
+)
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputFormatTable, outputFormatJSON, outputFormatYAML:
+		return nil
+	}
+	return errors.Errorf("unknown output format %s", format)
+}
+
+// printOutput prints the data in JSON or YAML format, or prints the table built from the data otherwise
+func printOutput(c *cli.Context, data interface{}, header []string, rows func() [][]string) error {
+	switch c.String("output") {
+	case outputFormatJSON:
+		content, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+		return nil
+	case outputFormatYAML:
+		content, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(content))
+		return nil
+	}
+	return printTable(header, rows())
+}
+
+func printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printUUID prints the uuid of the created object
+func printUUID(c *cli.Context, uuid string) error {
+	return printOutput(c, map[string]string{"uuid": uuid}, []string{"UUID"}, func() [][]string {
+		return [][]string{{uuid}}
+	})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// requiredArg returns the first positional argument
+func requiredArg(c *cli.Context, name string) (string, error) {
+	if c.NArg() < 1 || c.Args().First() == "" {
+		return "", errors.Errorf("missing argument %s", name)
+	}
+	return c.Args().First(), nil
+}
+
+// readFile returns the content of the file, or of the stdin if the path is "-"
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %s", path)
+	}
+	return content, nil
+}
+
+// decodeFile decodes the YAML or JSON content of the file into v
+func decodeFile(path string, v interface{}) error {
+	content, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(content, v); err != nil {
+		return errors.Wrapf(err, "failed to parse file %s", path)
+	}
+	return nil
+}
+
+func fileFlag(usage string) cli.Flag {
+	return &cli.StringFlag{
+		Name:     "file",
+		Aliases:  []string{"f"},
+		Value:    "",
+		Usage:    usage + ", in YAML or JSON format, - for the stdin",
+		Required: true,
+	}
+}
+
+func joinStrings(list []string) string {
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2" // imports as package "cli"
+)
+
+func init() {
+	cli.HelpFlag = &cli.BoolFlag{Name: "help", Aliases: []string{"h"}, Usage: "Show help"}
+}
+
+func initCommandLine() *cli.App {
+	app := &cli.App{
+		Name:  "fedlcmctl",
+		Usage: "Command line client of the federated learning lifecycle manager",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:     "debug",
+				Aliases:  []string{"d"},
+				Value:    false,
+				Usage:    "Enable debug logging",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "config",
+				Value:    defaultConfigPath(),
+				Usage:    "Path of the config file containing the login contexts",
+				EnvVars:  []string{"FEDLCMCTL_CONFIG"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "context",
+				Value:    "",
+				Usage:    "The login context to use, default to the current context in the config file",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Value:    outputFormatTable,
+				Usage:    "Output format, one of table, json and yaml",
+				Required: false,
+			},
+		},
+		Commands: []*cli.Command{
+			LoginCommand(),
+			ContextCommand(),
+			InfraCommand(),
+			EndpointCommand(),
+			ChartCommand(),
+			FederationCommand(),
+			FATECommand(),
+			OpenFLCommand(),
+			CertificateCommand(),
+			EventCommand(),
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		// the client package logs with zerolog
+		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+		if c.Bool("debug") {
+			logrus.SetLevel(logrus.DebugLevel)
+			zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		}
+		return validateOutputFormat(c.String("output"))
+	}
+	sort.Sort(cli.FlagsByName(app.Flags))
+	sort.Sort(cli.CommandsByName(app.Commands))
+	return app
+}
+
+func Run(Args []string) error {
+	app := initCommandLine()
+	return app.Run(Args)
+}
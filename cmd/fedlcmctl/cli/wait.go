@@ -0,0 +1,108 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"time"
+
+	fedlcmclient "github.com/FederatedAI/FedLCM/pkg/fedlcm-client"
+	"github.com/FederatedAI/FedLCM/pkg/utils"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// waitFlags returns the flags to wait for the completion of asynchronous operations
+func waitFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:     "wait",
+			Aliases:  []string{"w"},
+			Value:    false,
+			Usage:    "Wait for the operation to finish",
+			Required: false,
+		},
+		&cli.DurationFlag{
+			Name:     "timeout",
+			Value:    time.Hour,
+			Usage:    "How long to wait for the operation to finish",
+			Required: false,
+		},
+	}
+}
+
+// waitUntil polls the check function until it returns true or an error, if the wait flag is set
+func waitUntil(c *cli.Context, description string, check func() (bool, error)) error {
+	if !c.Bool("wait") {
+		return nil
+	}
+	log.Infof("Waiting for %s", description)
+	var checkErr error
+	if err := utils.ExecuteWithTimeout(func() bool {
+		done, err := check()
+		if err != nil {
+			checkErr = err
+			return true
+		}
+		return done
+	}, c.Duration("timeout"), 2*time.Second); err != nil {
+		return errors.Wrapf(err, "failed waiting for %s", description)
+	}
+	return checkErr
+}
+
+// isNotFound returns whether the object queried with the returned error no longer exists
+func isNotFound(err error) bool {
+	var apiErr *fedlcmclient.APIError
+	return errors.As(err, &apiErr)
+}
+
+// fateStatusDone returns whether the FATE participant finishes the ongoing operation, and an error if it failed
+func fateStatusDone(name string, status entity.ParticipantFATEStatus) (bool, error) {
+	log.Infof("%s status is: %v", name, status)
+	switch status {
+	case entity.ParticipantFATEStatusInstalling, entity.ParticipantFATEStatusRemoving,
+		entity.ParticipantFATEStatusUpgrading, entity.ParticipantFATEStatusReconfiguring:
+		return false, nil
+	case entity.ParticipantFATEStatusFailed:
+		return true, errors.Errorf("%s failed, check its events for details", name)
+	}
+	return true, nil
+}
+
+// openFLStatusDone returns whether the OpenFL participant finishes the ongoing operation, and an error if it failed
+func openFLStatusDone(name string, status entity.ParticipantOpenFLStatus) (bool, error) {
+	log.Infof("%s status is: %v", name, status)
+	switch status {
+	case entity.ParticipantOpenFLStatusActive, entity.ParticipantOpenFLStatusUnknown:
+		return true, nil
+	case entity.ParticipantOpenFLStatusFailed:
+		return true, errors.Errorf("%s failed, check its events for details", name)
+	}
+	return false, nil
+}
+
+// removalDone returns whether the object is removed, using the error of querying it
+func removalDone(name string, err error) (bool, error) {
+	if err == nil {
+		log.Infof("%s is being removed", name)
+		return false, nil
+	}
+	if isNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
@@ -15,18 +15,50 @@
 package api
 
 import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/FederatedAI/FedLCM/fml-manager/server/constants"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
+// CertRevocationChecker checks if a certificate has been revoked
+type CertRevocationChecker interface {
+	IsRevoked(cert *x509.Certificate) bool
+}
+
+// certRevocationChecker is used to check the caller's certificate, nil means the check is disabled
+var certRevocationChecker CertRevocationChecker
+
+// SetCertRevocationChecker enables the revocation check of the caller's certificate using the specified checker
+func SetCertRevocationChecker(checker CertRevocationChecker) {
+	certRevocationChecker = checker
+}
+
 func certAuthenticator() gin.HandlerFunc {
 	return func(c *gin.Context) {
-
+		if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, &GeneralResponse{
+				Code:    constants.RespInternalErr,
+				Message: "client certificate is required",
+			})
+			return
+		}
 		clientCert := c.Request.TLS.PeerCertificates[0]
 		clientCommonName := clientCert.Subject.CommonName
 		log.Info().Msgf("Request URL: %s", c.Request.URL.String())
 		log.Info().Msgf("Client common name is: %s", clientCommonName)
 
+		if certRevocationChecker != nil && certRevocationChecker.IsRevoked(clientCert) {
+			log.Warn().Msgf("Client certificate of %s with serial number %s has been revoked", clientCommonName, clientCert.SerialNumber.String())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, &GeneralResponse{
+				Code:    constants.RespInternalErr,
+				Message: "client certificate has been revoked",
+			})
+			return
+		}
+
 		// TODO: validate the clientCommonName's domain is same to the fmlManagerCommonName's domain
 
 		c.Next()
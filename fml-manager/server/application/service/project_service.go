@@ -15,6 +15,7 @@
 package service
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/FederatedAI/FedLCM/fml-manager/server/domain/entity"
@@ -68,6 +69,8 @@ type ProjectDataAssociation struct {
 	TableNamespace string    `json:"table_namespace"`
 	CreationTime   time.Time `json:"creation_time"`
 	UpdateTime     time.Time `json:"update_time"`
+	// Profile is the aggregated statistics of the data, in the JSON format
+	Profile json.RawMessage `json:"profile"`
 }
 
 // ProjectInfoWithStatus contains project basic information and the status inferred for certain participant
@@ -156,6 +159,7 @@ func (app *ProjectApp) ProcessInvitation(req *ProjectInvitationRequest) error {
 			TableNamespace: data.TableNamespace,
 			CreationTime:   data.CreationTime,
 			UpdateTime:     data.UpdateTime,
+			Profile:        string(data.Profile),
 		}
 	}
 	invitationReq.AssociatedData = projectDataList
@@ -466,6 +470,7 @@ func (app *ProjectApp) ProcessDataAssociation(projectUUID string, data *ProjectD
 		TableNamespace: data.TableNamespace,
 		CreationTime:   data.CreationTime,
 		UpdateTime:     data.UpdateTime,
+		Profile:        string(data.Profile),
 	}, otherSiteList)
 }
 
@@ -631,6 +636,7 @@ func (app *ProjectApp) ListDataAssociationByProject(projectUUID string) (map[str
 				TableNamespace: data.TableNamespace,
 				CreationTime:   data.CreationTime,
 				UpdateTime:     data.UpdateTime,
+				Profile:        data.GetProfileJSON(),
 			}
 		}
 	}
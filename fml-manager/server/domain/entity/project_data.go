@@ -15,6 +15,8 @@
 package entity
 
 import (
+	"encoding/json"
+
 	"github.com/FederatedAI/FedLCM/fml-manager/server/domain/repo"
 	"gorm.io/gorm"
 	"time"
@@ -36,6 +38,7 @@ type ProjectData struct {
 	TableNamespace string `gorm:"type:varchar(255)"`
 	CreationTime   time.Time
 	UpdateTime     time.Time
+	Profile        string                     `gorm:"type:text"`
 	Repo           repo.ProjectDataRepository `gorm:"-"`
 }
 
@@ -47,3 +50,11 @@ const (
 	ProjectDataStatusDismissed
 	ProjectDataStatusAssociated
 )
+
+// GetProfileJSON returns the data profile to be sent as a JSON object
+func (d *ProjectData) GetProfileJSON() json.RawMessage {
+	if d.Profile == "" {
+		return nil
+	}
+	return json.RawMessage(d.Profile)
+}
@@ -29,6 +29,8 @@ type ProjectDataRepository interface {
 	GetByProjectAndDataUUID(string, string) (interface{}, error)
 	// UpdateStatusByUUID takes an *entity.ProjectData and update its status
 	UpdateStatusByUUID(interface{}) error
+	// UpdateProfileByUUID takes an *entity.ProjectData and update its data profile
+	UpdateProfileByUUID(interface{}) error
 	// GetListByProjectUUID returns []entity.ProjectData that associated in the specified project
 	GetListByProjectUUID(string) (interface{}, error)
 	// GetListByProjectAndSiteUUID returns []entity.ProjectData that associated in the specified project by the specified site
@@ -265,6 +265,7 @@ func (s *ProjectService) HandleInvitationAcceptance(req *ProjectInvitationReques
 					TableNamespace: data.TableNamespace,
 					CreationTime:   data.CreationTime,
 					UpdateTime:     data.UpdateTime,
+					Profile:        data.GetProfileJSON(),
 				})
 			}
 		}
@@ -503,6 +504,7 @@ func (s *ProjectService) HandleDataAssociation(newData *entity.ProjectData, othe
 							TableNamespace: newData.TableNamespace,
 							CreationTime:   newData.CreationTime,
 							UpdateTime:     newData.UpdateTime,
+							Profile:        newData.GetProfileJSON(),
 						},
 					}); err != nil {
 						log.Err(err).Msgf("failed to send new project data info to site: %s(%s), continue", site.Name, site.UUID)
@@ -570,6 +572,12 @@ func (s *ProjectService) createOrUpdateData(newData *entity.ProjectData) error {
 		if err := s.ProjectDataRepo.UpdateStatusByUUID(data); err != nil {
 			return errors.Wrapf(err, "failed to update data association")
 		}
+		if newData.Profile != "" {
+			data.Profile = newData.Profile
+			if err := s.ProjectDataRepo.UpdateProfileByUUID(data); err != nil {
+				return errors.Wrapf(err, "failed to update data profile")
+			}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,151 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crl
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Checker loads the certificate revocation list (CRL) from a file or a URL and checks if a certificate is revoked
+type Checker struct {
+	// source is the file path or the http(s) URL of the CRL
+	source string
+	// issuers are the certificates that can be used to verify the signature of the CRL
+	issuers []*x509.Certificate
+
+	mu         sync.RWMutex
+	revoked    map[string]time.Time
+	nextUpdate time.Time
+}
+
+// NewChecker returns a checker using the CRL from the source, which is verified using the certificates in the issuer
+// files. The issuer files can be the CA certificate file or the certificate chain file of this service.
+func NewChecker(source string, issuerFiles ...string) (*Checker, error) {
+	checker := &Checker{
+		source:  source,
+		revoked: map[string]time.Time{},
+	}
+	for _, file := range issuerFiles {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read issuer file %s", file)
+		}
+		for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse certificate in %s", file)
+			}
+			checker.issuers = append(checker.issuers, cert)
+		}
+	}
+	if len(checker.issuers) == 0 {
+		return nil, errors.New("no issuer certificate found")
+	}
+	// the CRL source may be temporarily unavailable, in which case it will be loaded in the next refresh
+	if err := checker.Refresh(); err != nil {
+		log.Err(err).Msgf("failed to load CRL from %s", source)
+	}
+	return checker, nil
+}
+
+// Start refreshes the CRL periodically in the background
+func (c *Checker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := c.Refresh(); err != nil {
+				log.Err(err).Msgf("failed to refresh CRL from %s, keep using the previous one", c.source)
+			}
+		}
+	}()
+}
+
+// Refresh loads and verifies the CRL and replaces the revoked certificate list
+func (c *Checker) Refresh() error {
+	content, err := c.load()
+	if err != nil {
+		return errors.Wrapf(err, "failed to load CRL")
+	}
+	if block, _ := pem.Decode(content); block != nil {
+		content = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(content)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse CRL")
+	}
+	verified := false
+	for _, issuer := range c.issuers {
+		if err := crl.CheckSignatureFrom(issuer); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return errors.New("the CRL is not signed by any of the issuers")
+	}
+	revoked := map[string]time.Time{}
+	for _, entry := range crl.RevokedCertificates {
+		revoked[string(crl.RawIssuer)+"/"+entry.SerialNumber.String()] = entry.RevocationTime
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revoked = revoked
+	c.nextUpdate = crl.NextUpdate
+	log.Info().Msgf("loaded CRL from %s with %d revoked certificate(s)", c.source, len(revoked))
+	return nil
+}
+
+// IsRevoked returns true if the certificate is in the CRL
+func (c *Checker) IsRevoked(cert *x509.Certificate) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.nextUpdate.IsZero() && time.Now().After(c.nextUpdate) {
+		log.Warn().Msgf("the CRL from %s is outdated", c.source)
+	}
+	_, ok := c.revoked[string(cert.RawIssuer)+"/"+cert.SerialNumber.String()]
+	return ok
+}
+
+func (c *Checker) load() ([]byte, error) {
+	if !strings.HasPrefix(c.source, "http://") && !strings.HasPrefix(c.source, "https://") {
+		return os.ReadFile(c.source)
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Get(c.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -15,6 +15,7 @@
 package siteportal
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -58,6 +59,8 @@ type ProjectData struct {
 	TableNamespace string    `json:"table_namespace"`
 	CreationTime   time.Time `json:"creation_time"`
 	UpdateTime     time.Time `json:"update_time"`
+	// Profile is the aggregated statistics of the data, in the JSON format
+	Profile json.RawMessage `json:"profile"`
 }
 
 // ProjectParticipantUpdateEvent represents a site info update event
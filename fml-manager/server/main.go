@@ -27,6 +27,7 @@ import (
 
 	"github.com/FederatedAI/FedLCM/fml-manager/server/api"
 	"github.com/FederatedAI/FedLCM/fml-manager/server/constants"
+	"github.com/FederatedAI/FedLCM/fml-manager/server/infrastructure/crl"
 	"github.com/FederatedAI/FedLCM/fml-manager/server/infrastructure/gorm"
 	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/utils/logging"
 	"github.com/gin-contrib/logger"
@@ -87,6 +88,18 @@ func main() {
 			ClientAuth: tls.RequireAndVerifyClientCert,
 			ClientCAs:  pool,
 		}
+		if crlSource := viper.GetString("fmlmanager.tls.crl.source"); crlSource != "" {
+			checker, err := crl.NewChecker(crlSource, caCertPath, fmlManagerServerCert)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to initialize CRL checker")
+			}
+			interval := viper.GetDuration("fmlmanager.tls.crl.interval")
+			if interval <= 0 {
+				interval = time.Hour
+			}
+			checker.Start(interval)
+			api.SetCertRevocationChecker(checker)
+		}
 		tlsPort := viper.GetString("fmlmanager.tls.port")
 		if tlsPort == "" {
 			tlsPort = "8443"
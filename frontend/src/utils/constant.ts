@@ -16,7 +16,8 @@ export const ENDPOINTSTATUS:ConstantModel = {
   Ready: 2,
   Dismissed: 3,
   Unavailable: 4,
-  Deleting: 5
+  Deleting: 5,
+  Upgrading: 6
 }
 export const CHARTTYPE:ConstantModel = {
   Unknown: 0,
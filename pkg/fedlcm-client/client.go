@@ -0,0 +1,338 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedlcm_client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/utils"
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Client provides typed access to the lifecycle manager API
+type Client interface {
+	// ListInfraProviders returns all the infra providers
+	ListInfraProviders() ([]service.InfraProviderListItem, error)
+	// GetInfraProvider returns the detail of an infra provider
+	GetInfraProvider(uuid string) (*service.InfraProviderDetail, error)
+	// CreateInfraProvider creates an infra provider
+	CreateInfraProvider(req *service.InfraProviderCreationRequest) error
+	// DeleteInfraProvider deletes an infra provider
+	DeleteInfraProvider(uuid string) error
+
+	// ListEndpoints returns all the endpoints
+	ListEndpoints() ([]service.EndpointListItem, error)
+	// GetEndpoint returns the detail of an endpoint
+	GetEndpoint(uuid string) (*service.EndpointDetail, error)
+	// CreateEndpoint adds or installs an endpoint
+	CreateEndpoint(req *service.EndpointCreationRequest) error
+	// DeleteEndpoint deletes an endpoint, and uninstalls it if uninstall is set
+	DeleteEndpoint(uuid string, uninstall bool) error
+
+	// ListCharts returns the charts of the specified type, or all the charts for entity.ChartTypeUnknown
+	ListCharts(chartType entity.ChartType) ([]service.ChartListItem, error)
+	// GetChart returns the detail of a chart
+	GetChart(uuid string) (*service.ChartDetail, error)
+
+	// ListFederations returns all the federations
+	ListFederations() ([]service.FederationListItem, error)
+	// GetFATEFederation returns the detail of a FATE federation
+	GetFATEFederation(uuid string) (*service.FederationFATEDetail, error)
+	// CreateFATEFederation creates a FATE federation and returns its uuid
+	CreateFATEFederation(req *service.FederationFATECreationRequest) (string, error)
+	// DeleteFATEFederation deletes a FATE federation
+	DeleteFATEFederation(uuid string) error
+	// ApplyFATEFederation sends the FATE federation spec in YAML or JSON format and returns the plan, the participants
+	// not in the spec are only removed if prune is set
+	ApplyFATEFederation(spec []byte, dryRun, prune bool) (*domainService.FederationFATEApplyPlan, error)
+	// ListFATEParticipants returns the exchanges and clusters in a FATE federation
+	ListFATEParticipants(federationUUID string) (*service.ParticipantFATEListInFederation, error)
+	// GetFATEExchangeDeploymentYAML returns the deployment yaml of a FATE exchange
+	GetFATEExchangeDeploymentYAML(req *domainService.ParticipantFATEExchangeYAMLCreationRequest) (string, error)
+	// GetFATEClusterDeploymentYAML returns the deployment yaml of a FATE cluster
+	GetFATEClusterDeploymentYAML(req *domainService.ParticipantFATEClusterYAMLCreationRequest) (string, error)
+	// CreateFATEExchange creates a FATE exchange and returns its uuid
+	CreateFATEExchange(federationUUID string, req *domainService.ParticipantFATEExchangeCreationRequest) (string, error)
+	// CreateFATECluster creates a FATE cluster and returns its uuid
+	CreateFATECluster(federationUUID string, req *domainService.ParticipantFATEClusterCreationRequest) (string, error)
+	// GetFATEExchange returns the detail of a FATE exchange
+	GetFATEExchange(federationUUID, uuid string) (*service.FATEExchangeDetail, error)
+	// GetFATECluster returns the detail of a FATE cluster
+	GetFATECluster(federationUUID, uuid string) (*service.FATEClusterDetail, error)
+	// UpgradeFATEExchange upgrades a FATE exchange to the specified version
+	UpgradeFATEExchange(federationUUID, uuid, version string) error
+	// UpgradeFATECluster upgrades a FATE cluster to the specified version
+	UpgradeFATECluster(federationUUID, uuid, version string) error
+	// DeleteFATEExchange removes a FATE exchange
+	DeleteFATEExchange(federationUUID, uuid string, force bool) error
+	// DeleteFATECluster removes a FATE cluster
+	DeleteFATECluster(federationUUID, uuid string, force bool) error
+	// ListFATESizingProfiles returns the sizing profiles of FATE clusters
+	ListFATESizingProfiles() ([]service.FATESizingProfile, error)
+	// ResizeFATECluster applies a sizing profile to a FATE cluster
+	ResizeFATECluster(federationUUID, uuid, profileUUID string) error
+	// ListAdoptableFATEClusters returns the FATE clusters deployed by the KubeFATE service of an endpoint
+	ListAdoptableFATEClusters(endpointUUID string) ([]domainService.ParticipantFATEAdoptionCandidate, error)
+	// AdoptFATECluster adopts an existing KubeFATE-deployed FATE cluster and returns its uuid
+	AdoptFATECluster(federationUUID string, req *domainService.ParticipantFATEAdoptionRequest) (string, error)
+	// GetFATETopology returns the topology graph of a FATE federation
+	GetFATETopology(federationUUID string) (*domainService.FederationFATETopology, error)
+	// DiagnoseFATEFederation starts checking the connectivity between the participants of a FATE federation and returns
+	// the uuid of the diagnostics
+	DiagnoseFATEFederation(federationUUID string) (string, error)
+	// GetFATEDiagnostics returns the status of a diagnostics run of a FATE federation and its report once succeeded
+	GetFATEDiagnostics(federationUUID, uuid string) (*service.FATEFederationDiagnosticsDetail, error)
+
+	// GetOpenFLFederation returns the detail of an OpenFL federation
+	GetOpenFLFederation(uuid string) (*service.FederationOpenFLDetail, error)
+	// CreateOpenFLFederation creates an OpenFL federation and returns its uuid
+	CreateOpenFLFederation(req *service.FederationOpenFLCreationRequest) (string, error)
+	// DeleteOpenFLFederation deletes an OpenFL federation
+	DeleteOpenFLFederation(uuid string) error
+	// ListOpenFLTokens returns the registration tokens of an OpenFL federation
+	ListOpenFLTokens(federationUUID string) ([]service.RegistrationTokenOpenFLListItem, error)
+	// CreateOpenFLToken creates a registration token in an OpenFL federation
+	CreateOpenFLToken(federationUUID string, req *service.RegistrationTokenOpenFLBasicInfo) error
+	// DeleteOpenFLToken deletes a registration token of an OpenFL federation
+	DeleteOpenFLToken(federationUUID, uuid string) error
+	// ListOpenFLParticipants returns the director and envoys in an OpenFL federation
+	ListOpenFLParticipants(federationUUID string) (*service.ParticipantOpenFLListInFederation, error)
+	// GetOpenFLDirectorDeploymentYAML returns the deployment yaml of an OpenFL director
+	GetOpenFLDirectorDeploymentYAML(req *domainService.ParticipantOpenFLDirectorYAMLCreationRequest) (string, error)
+	// CreateOpenFLDirector creates an OpenFL director and returns its uuid
+	CreateOpenFLDirector(federationUUID string, req *domainService.ParticipantOpenFLDirectorCreationRequest) (string, error)
+	// GetOpenFLDirector returns the detail of an OpenFL director
+	GetOpenFLDirector(federationUUID, uuid string) (*service.OpenFLDirectorDetail, error)
+	// DeleteOpenFLDirector removes an OpenFL director
+	DeleteOpenFLDirector(federationUUID, uuid string, force bool) error
+	// GetOpenFLEnvoy returns the detail of an OpenFL envoy
+	GetOpenFLEnvoy(federationUUID, uuid string) (*service.OpenFLEnvoyDetail, error)
+	// DeleteOpenFLEnvoy removes an OpenFL envoy
+	DeleteOpenFLEnvoy(federationUUID, uuid string, force bool) error
+
+	// ListCertificates returns all the certificates
+	ListCertificates() ([]service.CertificateListItem, error)
+	// DeleteCertificate deletes a certificate, and revokes it first if revoke is set
+	DeleteCertificate(uuid string, revoke bool) error
+
+	// ListEvents returns the events of an entity
+	ListEvents(entityUUID string) ([]service.EventListItem, error)
+}
+
+type client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+var _ Client = (*client)(nil)
+
+// NewClient returns a client using the specified config
+func NewClient(config Config) Client {
+	return &client{
+		config:     config,
+		httpClient: newHTTPClient(config),
+	}
+}
+
+// Login authenticates with the lifecycle manager and returns the JWT token
+func Login(config Config, username, password string) (string, error) {
+	c := &client{
+		config:     config,
+		httpClient: newHTTPClient(config),
+	}
+	var token string
+	if err := c.do(http.MethodPost, "user/login", service.LoginInfo{
+		Username: username,
+		Password: password,
+	}, &token); err != nil {
+		return "", errors.Wrap(err, "failed to login")
+	}
+	return token, nil
+}
+
+func newHTTPClient(config Config) *http.Client {
+	if config.InsecureSkipVerify {
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: true,
+				},
+			},
+		}
+	}
+	return http.DefaultClient
+}
+
+func (c *client) ListInfraProviders() ([]service.InfraProviderListItem, error) {
+	var providerList []service.InfraProviderListItem
+	return providerList, c.do(http.MethodGet, "infra", nil, &providerList)
+}
+
+func (c *client) GetInfraProvider(uuid string) (*service.InfraProviderDetail, error) {
+	provider := &service.InfraProviderDetail{}
+	return provider, c.do(http.MethodGet, "infra/"+uuid, nil, provider)
+}
+
+func (c *client) CreateInfraProvider(req *service.InfraProviderCreationRequest) error {
+	return c.do(http.MethodPost, "infra", req, nil)
+}
+
+func (c *client) DeleteInfraProvider(uuid string) error {
+	return c.do(http.MethodDelete, "infra/"+uuid, nil, nil)
+}
+
+func (c *client) ListEndpoints() ([]service.EndpointListItem, error) {
+	var endpointList []service.EndpointListItem
+	return endpointList, c.do(http.MethodGet, "endpoint", nil, &endpointList)
+}
+
+func (c *client) GetEndpoint(uuid string) (*service.EndpointDetail, error) {
+	endpoint := &service.EndpointDetail{}
+	return endpoint, c.do(http.MethodGet, "endpoint/"+uuid, nil, endpoint)
+}
+
+func (c *client) CreateEndpoint(req *service.EndpointCreationRequest) error {
+	return c.do(http.MethodPost, "endpoint", req, nil)
+}
+
+func (c *client) DeleteEndpoint(uuid string, uninstall bool) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("endpoint/%s?uninstall=%v", uuid, uninstall), nil, nil)
+}
+
+func (c *client) ListCharts(chartType entity.ChartType) ([]service.ChartListItem, error) {
+	path := "chart"
+	if chartType != entity.ChartTypeUnknown {
+		path = fmt.Sprintf("chart?type=%d", chartType)
+	}
+	var chartList []service.ChartListItem
+	return chartList, c.do(http.MethodGet, path, nil, &chartList)
+}
+
+func (c *client) GetChart(uuid string) (*service.ChartDetail, error) {
+	chart := &service.ChartDetail{}
+	return chart, c.do(http.MethodGet, "chart/"+uuid, nil, chart)
+}
+
+func (c *client) ListFederations() ([]service.FederationListItem, error) {
+	var federationList []service.FederationListItem
+	return federationList, c.do(http.MethodGet, "federation", nil, &federationList)
+}
+
+func (c *client) ListCertificates() ([]service.CertificateListItem, error) {
+	var certificateList []service.CertificateListItem
+	return certificateList, c.do(http.MethodGet, "certificate", nil, &certificateList)
+}
+
+func (c *client) DeleteCertificate(uuid string, revoke bool) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("certificate/%s?revoke=%v", uuid, revoke), nil, nil)
+}
+
+func (c *client) ListEvents(entityUUID string) ([]service.EventListItem, error) {
+	var eventList []service.EventListItem
+	return eventList, c.do(http.MethodGet, "event/"+entityUUID, nil, &eventList)
+}
+
+// do sends the request to the API of the specified path and decodes the data field of the response into result, if
+// it is not nil. A string body is sent as is and other bodies are sent in JSON format.
+func (c *client) do(method, path string, body interface{}, result interface{}) error {
+	urlStr := c.genURL(path)
+	var payload []byte
+	if body != nil {
+		if stringBody, ok := body.(string); ok {
+			payload = []byte(stringBody)
+		} else {
+			var err error
+			if payload, err = json.Marshal(body); err != nil {
+				return err
+			}
+		}
+	}
+	var resp *http.Response
+	if err := utils.RetryWithMaxAttempts(func() error {
+		req, err := http.NewRequest(method, urlStr, bytes.NewBuffer(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.config.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.config.Token)
+		}
+		log.Debug().Msgf("%s request to %s", method, urlStr)
+		resp, err = c.httpClient.Do(req)
+		return err
+	}, 3, 2*time.Second); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return parseResponse(resp, result)
+}
+
+func (c *client) genURL(path string) string {
+	return fmt.Sprintf("%s/api/v1/%s", strings.TrimSuffix(c.config.Server, "/"), path)
+}
+
+// queryString encodes the query parameters, skipping the empty ones
+func queryString(params map[string]string) string {
+	values := url.Values{}
+	for key, value := range params {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values.Encode()
+}
+
+func parseResponse(resp *http.Response, result interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response body error")
+	}
+	envelope := &response{}
+	if err := json.Unmarshal(body, envelope); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &APIError{
+				StatusCode: resp.StatusCode,
+				Message:    string(body),
+			}
+		}
+		return errors.Wrapf(err, "invalid response body: %s", string(body))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Code:       envelope.Code,
+			Message:    envelope.Message,
+		}
+	}
+	if result == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, result); err != nil {
+		return errors.Wrap(err, "failed to decode response data")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedlcm_client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+)
+
+func (c *client) GetFATEFederation(uuid string) (*service.FederationFATEDetail, error) {
+	federation := &service.FederationFATEDetail{}
+	return federation, c.do(http.MethodGet, "federation/fate/"+uuid, nil, federation)
+}
+
+func (c *client) CreateFATEFederation(req *service.FederationFATECreationRequest) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, "federation/fate", req, &uuid)
+}
+
+func (c *client) DeleteFATEFederation(uuid string) error {
+	return c.do(http.MethodDelete, "federation/fate/"+uuid, nil, nil)
+}
+
+func (c *client) ApplyFATEFederation(spec []byte, dryRun, prune bool) (*domainService.FederationFATEApplyPlan, error) {
+	plan := &domainService.FederationFATEApplyPlan{}
+	return plan, c.do(http.MethodPost, fmt.Sprintf("federation/fate/apply?dry_run=%v&prune=%v", dryRun, prune), string(spec), plan)
+}
+
+func (c *client) ListFATEParticipants(federationUUID string) (*service.ParticipantFATEListInFederation, error) {
+	participants := &service.ParticipantFATEListInFederation{}
+	return participants, c.do(http.MethodGet, fmt.Sprintf("federation/fate/%s/participant", federationUUID), nil, participants)
+}
+
+func (c *client) GetFATEExchangeDeploymentYAML(req *domainService.ParticipantFATEExchangeYAMLCreationRequest) (string, error) {
+	params := exchangeYAMLQueryParams(req)
+	params["enable_rollsite"] = strconv.FormatBool(req.EnableRollsite)
+	var deploymentYAML string
+	return deploymentYAML, c.do(http.MethodGet, "federation/fate/exchange/yaml?"+queryString(params), nil, &deploymentYAML)
+}
+
+func (c *client) GetFATEClusterDeploymentYAML(req *domainService.ParticipantFATEClusterYAMLCreationRequest) (string, error) {
+	params := exchangeYAMLQueryParams(&req.ParticipantFATEExchangeYAMLCreationRequest)
+	params["federation_uuid"] = req.FederationUUID
+	params["party_id"] = strconv.Itoa(req.PartyID)
+	params["enable_persistence"] = strconv.FormatBool(req.EnablePersistence)
+	params["storage_class"] = req.StorageClass
+	params["fateflow_gpu_num"] = strconv.Itoa(req.FATEFlowGPUNum)
+	params["exchange_uuid"] = req.ExchangeUUID
+	params["sizing_profile_uuid"] = req.SizingProfileUUID
+	params["backend"] = string(req.Backend)
+	var deploymentYAML string
+	return deploymentYAML, c.do(http.MethodGet, "federation/fate/cluster/yaml?"+queryString(params), nil, &deploymentYAML)
+}
+
+func exchangeYAMLQueryParams(req *domainService.ParticipantFATEExchangeYAMLCreationRequest) map[string]string {
+	return map[string]string{
+		"chart_uuid":          req.ChartUUID,
+		"name":                req.Name,
+		"namespace":           req.Namespace,
+		"service_type":        strconv.Itoa(int(req.ServiceType)),
+		"use_registry":        strconv.FormatBool(req.RegistryConfig.UseRegistry),
+		"registry":            req.RegistryConfig.Registry,
+		"use_registry_secret": strconv.FormatBool(req.RegistryConfig.UseRegistrySecret),
+		"enable_psp":          strconv.FormatBool(req.EnablePSP),
+	}
+}
+
+func (c *client) CreateFATEExchange(federationUUID string, req *domainService.ParticipantFATEExchangeCreationRequest) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/exchange", federationUUID), req, &uuid)
+}
+
+func (c *client) CreateFATECluster(federationUUID string, req *domainService.ParticipantFATEClusterCreationRequest) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/cluster", federationUUID), req, &uuid)
+}
+
+func (c *client) GetFATEExchange(federationUUID, uuid string) (*service.FATEExchangeDetail, error) {
+	exchange := &service.FATEExchangeDetail{}
+	return exchange, c.do(http.MethodGet, fmt.Sprintf("federation/fate/%s/exchange/%s", federationUUID, uuid), nil, exchange)
+}
+
+func (c *client) GetFATECluster(federationUUID, uuid string) (*service.FATEClusterDetail, error) {
+	cluster := &service.FATEClusterDetail{}
+	return cluster, c.do(http.MethodGet, fmt.Sprintf("federation/fate/%s/cluster/%s", federationUUID, uuid), nil, cluster)
+}
+
+func (c *client) UpgradeFATEExchange(federationUUID, uuid, version string) error {
+	return c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/exchange/%s/upgrade?upgradeVersion=%s", federationUUID, uuid, url.QueryEscape(version)), nil, nil)
+}
+
+func (c *client) UpgradeFATECluster(federationUUID, uuid, version string) error {
+	return c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/cluster/%s/upgrade?upgradeVersion=%s", federationUUID, uuid, url.QueryEscape(version)), nil, nil)
+}
+
+func (c *client) DeleteFATEExchange(federationUUID, uuid string, force bool) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("federation/fate/%s/exchange/%s?force=%v", federationUUID, uuid, force), nil, nil)
+}
+
+func (c *client) DeleteFATECluster(federationUUID, uuid string, force bool) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("federation/fate/%s/cluster/%s?force=%v", federationUUID, uuid, force), nil, nil)
+}
+
+func (c *client) ListFATESizingProfiles() ([]service.FATESizingProfile, error) {
+	var profiles []service.FATESizingProfile
+	return profiles, c.do(http.MethodGet, "federation/fate/sizing-profile", nil, &profiles)
+}
+
+func (c *client) ResizeFATECluster(federationUUID, uuid, profileUUID string) error {
+	return c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/cluster/%s/resize", federationUUID, uuid),
+		&service.FATEClusterResizeRequest{SizingProfileUUID: profileUUID}, nil)
+}
+
+func (c *client) ListAdoptableFATEClusters(endpointUUID string) ([]domainService.ParticipantFATEAdoptionCandidate, error) {
+	var candidates []domainService.ParticipantFATEAdoptionCandidate
+	return candidates, c.do(http.MethodGet, "federation/fate/cluster/adoptable?endpoint_uuid="+url.QueryEscape(endpointUUID), nil, &candidates)
+}
+
+func (c *client) AdoptFATECluster(federationUUID string, req *domainService.ParticipantFATEAdoptionRequest) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/cluster/adopt", federationUUID), req, &uuid)
+}
+
+func (c *client) GetFATETopology(federationUUID string) (*domainService.FederationFATETopology, error) {
+	topology := &domainService.FederationFATETopology{}
+	return topology, c.do(http.MethodGet, fmt.Sprintf("federation/fate/%s/topology", federationUUID), nil, topology)
+}
+
+func (c *client) DiagnoseFATEFederation(federationUUID string) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, fmt.Sprintf("federation/fate/%s/diagnostics", federationUUID), nil, &uuid)
+}
+
+func (c *client) GetFATEDiagnostics(federationUUID, uuid string) (*service.FATEFederationDiagnosticsDetail, error) {
+	diagnostics := &service.FATEFederationDiagnosticsDetail{}
+	return diagnostics, c.do(http.MethodGet, fmt.Sprintf("federation/fate/%s/diagnostics/%s", federationUUID, uuid), nil, diagnostics)
+}
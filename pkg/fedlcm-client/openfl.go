@@ -0,0 +1,96 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedlcm_client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+)
+
+func (c *client) GetOpenFLFederation(uuid string) (*service.FederationOpenFLDetail, error) {
+	federation := &service.FederationOpenFLDetail{}
+	return federation, c.do(http.MethodGet, "federation/openfl/"+uuid, nil, federation)
+}
+
+func (c *client) CreateOpenFLFederation(req *service.FederationOpenFLCreationRequest) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, "federation/openfl", req, &uuid)
+}
+
+func (c *client) DeleteOpenFLFederation(uuid string) error {
+	return c.do(http.MethodDelete, "federation/openfl/"+uuid, nil, nil)
+}
+
+func (c *client) ListOpenFLTokens(federationUUID string) ([]service.RegistrationTokenOpenFLListItem, error) {
+	var tokenList []service.RegistrationTokenOpenFLListItem
+	return tokenList, c.do(http.MethodGet, fmt.Sprintf("federation/openfl/%s/token", federationUUID), nil, &tokenList)
+}
+
+func (c *client) CreateOpenFLToken(federationUUID string, req *service.RegistrationTokenOpenFLBasicInfo) error {
+	return c.do(http.MethodPost, fmt.Sprintf("federation/openfl/%s/token", federationUUID), req, nil)
+}
+
+func (c *client) DeleteOpenFLToken(federationUUID, uuid string) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("federation/openfl/%s/token/%s", federationUUID, uuid), nil, nil)
+}
+
+func (c *client) ListOpenFLParticipants(federationUUID string) (*service.ParticipantOpenFLListInFederation, error) {
+	participants := &service.ParticipantOpenFLListInFederation{}
+	return participants, c.do(http.MethodGet, fmt.Sprintf("federation/openfl/%s/participant", federationUUID), nil, participants)
+}
+
+func (c *client) GetOpenFLDirectorDeploymentYAML(req *domainService.ParticipantOpenFLDirectorYAMLCreationRequest) (string, error) {
+	params := map[string]string{
+		"federation_uuid":     req.FederationUUID,
+		"chart_uuid":          req.ChartUUID,
+		"name":                req.Name,
+		"namespace":           req.Namespace,
+		"service_type":        strconv.Itoa(int(req.ServiceType)),
+		"jupyter_password":    req.JupyterPassword,
+		"use_registry":        strconv.FormatBool(req.RegistryConfig.UseRegistry),
+		"registry":            req.RegistryConfig.Registry,
+		"use_registry_secret": strconv.FormatBool(req.RegistryConfig.UseRegistrySecret),
+		"enable_psp":          strconv.FormatBool(req.EnablePSP),
+	}
+	var deploymentYAML string
+	return deploymentYAML, c.do(http.MethodGet, "federation/openfl/director/yaml?"+queryString(params), nil, &deploymentYAML)
+}
+
+func (c *client) CreateOpenFLDirector(federationUUID string, req *domainService.ParticipantOpenFLDirectorCreationRequest) (string, error) {
+	var uuid string
+	return uuid, c.do(http.MethodPost, fmt.Sprintf("federation/openfl/%s/director", federationUUID), req, &uuid)
+}
+
+func (c *client) GetOpenFLDirector(federationUUID, uuid string) (*service.OpenFLDirectorDetail, error) {
+	director := &service.OpenFLDirectorDetail{}
+	return director, c.do(http.MethodGet, fmt.Sprintf("federation/openfl/%s/director/%s", federationUUID, uuid), nil, director)
+}
+
+func (c *client) DeleteOpenFLDirector(federationUUID, uuid string, force bool) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("federation/openfl/%s/director/%s?force=%v", federationUUID, uuid, force), nil, nil)
+}
+
+func (c *client) GetOpenFLEnvoy(federationUUID, uuid string) (*service.OpenFLEnvoyDetail, error) {
+	envoy := &service.OpenFLEnvoyDetail{}
+	return envoy, c.do(http.MethodGet, fmt.Sprintf("federation/openfl/%s/envoy/%s", federationUUID, uuid), nil, envoy)
+}
+
+func (c *client) DeleteOpenFLEnvoy(federationUUID, uuid string, force bool) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("federation/openfl/%s/envoy/%s?force=%v", federationUUID, uuid, force), nil, nil)
+}
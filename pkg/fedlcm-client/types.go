@@ -0,0 +1,48 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedlcm_client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Config contains the info to connect to a lifecycle manager service
+type Config struct {
+	// Server is the base address of the service, like https://fedlcm.example.com:8443
+	Server string `json:"server" yaml:"server"`
+	// Token is the JWT token returned by the login API
+	Token string `json:"token" yaml:"token"`
+	// InsecureSkipVerify disables the verification of the server certificate
+	InsecureSkipVerify bool `json:"insecure_skip_tls_verify" yaml:"insecure_skip_tls_verify"`
+}
+
+// response is the general response envelope of the lifecycle manager API
+type response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// APIError is returned when the lifecycle manager responds with an error
+type APIError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request error: status %d, code %d, message: %s", e.StatusCode, e.Code, e.Message)
+}
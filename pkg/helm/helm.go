@@ -0,0 +1,233 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// storageDriver is the helm storage driver for release information, same as the helm CLI default
+const storageDriver = "secret"
+
+// defaultTimeout is the time to wait for the resources of a release to be ready
+const defaultTimeout = 15 * time.Minute
+
+// Client installs and manages charts as helm releases directly through the Kubernetes API
+type Client interface {
+	// Install installs the release and waits for its resources to be ready
+	Install(release *Release) error
+	// Upgrade upgrades the release and waits for its resources to be ready, a failed upgrade is rolled back
+	Upgrade(release *Release) error
+	// Uninstall deletes the release, a not-found release is not treated as an error
+	Uninstall(namespace, name string) error
+	// Status returns the status of the latest revision of the release
+	Status(namespace, name string) (*ReleaseStatus, error)
+}
+
+// Release is a chart to be installed with the rendered values
+type Release struct {
+	Name         string
+	Namespace    string
+	ChartArchive []byte
+	Values       map[string]interface{}
+}
+
+// ReleaseStatus is the status of a helm release
+type ReleaseStatus struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace"`
+	Revision     int       `json:"revision"`
+	Status       string    `json:"status"`
+	ChartName    string    `json:"chart_name"`
+	ChartVersion string    `json:"chart_version"`
+	Description  string    `json:"description"`
+	LastDeployed time.Time `json:"last_deployed"`
+}
+
+type client struct {
+	config  *rest.Config
+	timeout time.Duration
+}
+
+// NewClient returns a helm client working with the cluster the kubernetes client connects to
+func NewClient(k8sClient kubernetes.Client) (Client, error) {
+	config, err := k8sClient.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		config:  config,
+		timeout: defaultTimeout,
+	}, nil
+}
+
+func (c *client) Install(rel *Release) error {
+	cfg, err := c.actionConfig(rel.Namespace)
+	if err != nil {
+		return err
+	}
+	chartRequested, err := loader.LoadArchive(bytes.NewReader(rel.ChartArchive))
+	if err != nil {
+		return errors.Wrapf(err, "failed to load chart archive")
+	}
+	install := action.NewInstall(cfg)
+	install.ReleaseName = rel.Name
+	install.Namespace = rel.Namespace
+	install.Wait = true
+	install.Timeout = c.timeout
+	if _, err := install.Run(chartRequested, rel.Values); err != nil {
+		return errors.Wrapf(err, "failed to install release %s", rel.Name)
+	}
+	log.Info().Msgf("installed release %s in namespace %s", rel.Name, rel.Namespace)
+	return nil
+}
+
+func (c *client) Upgrade(rel *Release) error {
+	cfg, err := c.actionConfig(rel.Namespace)
+	if err != nil {
+		return err
+	}
+	chartRequested, err := loader.LoadArchive(bytes.NewReader(rel.ChartArchive))
+	if err != nil {
+		return errors.Wrapf(err, "failed to load chart archive")
+	}
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = rel.Namespace
+	// atomic makes helm roll back the failed upgrade, same as what KubeFATE does
+	upgrade.Atomic = true
+	upgrade.Wait = true
+	upgrade.Timeout = c.timeout
+	if _, err := upgrade.Run(rel.Name, chartRequested, rel.Values); err != nil {
+		return errors.Wrapf(err, "failed to upgrade release %s", rel.Name)
+	}
+	log.Info().Msgf("upgraded release %s in namespace %s", rel.Name, rel.Namespace)
+	return nil
+}
+
+func (c *client) Uninstall(namespace, name string) error {
+	cfg, err := c.actionConfig(namespace)
+	if err != nil {
+		return err
+	}
+	uninstall := action.NewUninstall(cfg)
+	uninstall.Wait = true
+	uninstall.Timeout = c.timeout
+	if _, err := uninstall.Run(name); err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			log.Warn().Msgf("release %s not found in namespace %s", name, namespace)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to uninstall release %s", name)
+	}
+	log.Info().Msgf("uninstalled release %s in namespace %s", name, namespace)
+	return nil
+}
+
+func (c *client) Status(namespace, name string) (*ReleaseStatus, error) {
+	cfg, err := c.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := action.NewStatus(cfg).Run(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get status of release %s", name)
+	}
+	return toReleaseStatus(rel), nil
+}
+
+func (c *client) actionConfig(namespace string) (*action.Configuration, error) {
+	cfg := &action.Configuration{}
+	getter := &restClientGetter{
+		config:    c.config,
+		namespace: namespace,
+	}
+	if err := cfg.Init(getter, namespace, storageDriver, func(format string, v ...interface{}) {
+		log.Debug().Msgf(format, v...)
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to init helm action config")
+	}
+	return cfg, nil
+}
+
+func toReleaseStatus(rel *release.Release) *ReleaseStatus {
+	status := &ReleaseStatus{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+	}
+	if rel.Info != nil {
+		status.Status = rel.Info.Status.String()
+		status.Description = rel.Info.Description
+		status.LastDeployed = rel.Info.LastDeployed.Time
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		status.ChartName = rel.Chart.Metadata.Name
+		status.ChartVersion = rel.Chart.Metadata.Version
+	}
+	return status
+}
+
+// restClientGetter implements the genericclioptions.RESTClientGetter interface with an existing rest config,
+// so that we don't need to write the kubeconfig to a file
+type restClientGetter struct {
+	config    *rest.Config
+	namespace string
+}
+
+func (g *restClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(g.config), nil
+}
+
+func (g *restClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(g.config)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(discoveryClient), nil
+}
+
+func (g *restClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	discoveryClient, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+	return restmapper.NewShortcutExpander(mapper, discoveryClient), nil
+}
+
+// ToRawKubeConfigLoader is only used by helm to get the namespace
+func (g *restClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return clientcmd.NewDefaultClientConfig(clientcmdapi.Config{}, &clientcmd.ConfigOverrides{
+		Context: clientcmdapi.Context{
+			Namespace: g.namespace,
+		},
+	})
+}
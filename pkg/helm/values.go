@@ -0,0 +1,107 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ReleaseFromDeploymentYAML builds a release from the deployment yaml used by KubeFATE, the release name and namespace
+// come from the "name" and "namespace" fields, and the chart values are rendered from the values template of the chart
+func ReleaseFromDeploymentYAML(deploymentYAML, valuesTemplate string, chartArchive []byte) (*Release, error) {
+	if len(chartArchive) == 0 {
+		return nil, errors.New("missing chart archive")
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	name, ok := m["name"].(string)
+	if !ok || name == "" {
+		return nil, errors.New("name not found")
+	}
+	namespace, ok := m["namespace"].(string)
+	if !ok || namespace == "" {
+		return nil, errors.New("namespace not found")
+	}
+	values, err := RenderValues(valuesTemplate, m)
+	if err != nil {
+		return nil, err
+	}
+	return &Release{
+		Name:         name,
+		Namespace:    namespace,
+		ChartArchive: chartArchive,
+		Values:       values,
+	}, nil
+}
+
+// RenderValues renders the chart values template with the deployment settings, in the same way KubeFATE does
+func RenderValues(valuesTemplate string, settings map[string]interface{}) (map[string]interface{}, error) {
+	t, err := template.New("values-template").Funcs(funcMap()).Option("missingkey=zero").Parse(valuesTemplate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse values template")
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, settings); err != nil {
+		return nil, errors.Wrapf(err, "failed to render values template")
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(strings.ReplaceAll(buf.String(), "<no value>", "")), &values); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal rendered values")
+	}
+	return values, nil
+}
+
+func funcMap() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	f["toYaml"] = func(v interface{}) string {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(string(data), "\n")
+	}
+	f["fromYaml"] = func(str string) map[string]interface{} {
+		m := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(str), &m); err != nil {
+			m["Error"] = err.Error()
+		}
+		return m
+	}
+	f["toJson"] = func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	f["fromJson"] = func(str string) map[string]interface{} {
+		m := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(str), &m); err != nil {
+			m["Error"] = err.Error()
+		}
+		return m
+	}
+	return f
+}
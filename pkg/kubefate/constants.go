@@ -19,6 +19,11 @@ func GetDefaultYAML() string {
 	return defaultKubeFATEYAMLVersion180
 }
 
+// GetDefaultVersion returns the KubeFATE version of the default kubefate deployment yaml
+func GetDefaultVersion() string {
+	return "1.4.5"
+}
+
 const defaultKubeFATEYAMLVersion180 = `{{- if .IsClusterAdmin -}}
 apiVersion: v1
 kind: Namespace
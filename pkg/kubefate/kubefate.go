@@ -29,6 +29,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	appv1 "k8s.io/api/apps/v1"
 	"k8s.io/client-go/tools/portforward"
 	"sigs.k8s.io/yaml"
 )
@@ -41,6 +42,8 @@ type Client interface {
 	EnsureChartExist(name, version string, content []byte) error
 	// ListClusterByNamespace returns clusters list in the specified namespace
 	ListClusterByNamespace(namespace string) ([]*modules.Cluster, error)
+	// ListClusters returns all the clusters managed by the KubeFATE service
+	ListClusters() ([]*modules.Cluster, error)
 
 	SubmitClusterInstallationJob(yamlStr string) (string, error)
 	SubmitClusterUpdateJob(yamlStr string) (string, error)
@@ -139,6 +142,69 @@ func BuildInstallationMetaFromYAML(namespace, yamlStr, ingressControllerYAMLStr
 	return meta, nil
 }
 
+// GetVersionFromYAML returns the KubeFATE version in the image tag of the kubefate container in the deployment yaml
+func GetVersionFromYAML(yamlStr string) (string, error) {
+	_, image, err := findKubeFATEImage(strings.Split(yamlStr, "\n---"))
+	if err != nil {
+		return "", err
+	}
+	_, tag, err := splitImageTag(image)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(tag, "v"), nil
+}
+
+// SetVersionInYAML returns the deployment yaml with the image tag of the kubefate container changed to the version,
+// other parts of the yaml are kept as is
+func SetVersionInYAML(yamlStr, version string) (string, error) {
+	docs := strings.Split(yamlStr, "\n---")
+	index, image, err := findKubeFATEImage(docs)
+	if err != nil {
+		return "", err
+	}
+	repository, tag, err := splitImageTag(image)
+	if err != nil {
+		return "", err
+	}
+	version = strings.TrimPrefix(version, "v")
+	if strings.HasPrefix(tag, "v") {
+		version = "v" + version
+	}
+	docs[index] = strings.Replace(docs[index], image, repository+":"+version, 1)
+	return strings.Join(docs, "\n---"), nil
+}
+
+// findKubeFATEImage returns the index of the document containing the kubefate deployment and the image of its
+// kubefate container
+func findKubeFATEImage(docs []string) (int, string, error) {
+	for index, doc := range docs {
+		deployment := &appv1.Deployment{}
+		if err := yaml.Unmarshal([]byte(doc), deployment); err != nil {
+			return 0, "", errors.Wrapf(err, "failed to parse the yaml")
+		}
+		if deployment.Kind != "Deployment" || deployment.Name != "kubefate" {
+			continue
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			if container.Name == "kubefate" {
+				return index, container.Image, nil
+			}
+		}
+	}
+	return 0, "", errors.New("no kubefate deployment found in the yaml")
+}
+
+// splitImageTag returns the repository and the tag of the image, ignoring the digest
+func splitImageTag(image string) (string, string, error) {
+	name := strings.Split(image, "@")[0]
+	index := strings.LastIndex(name, ":")
+	if index < 0 || strings.Contains(name[index:], "/") {
+		return "", "", errors.Errorf("no tag in the kubefate image %s", image)
+	}
+	return name[:index], name[index+1:], nil
+}
+
 func (c *client) IngressAddress() string {
 	return c.ingressAddress
 }
@@ -258,6 +324,22 @@ func (c *client) EnsureChartExist(name, version string, content []byte) error {
 }
 
 func (c *client) ListClusterByNamespace(namespace string) ([]*modules.Cluster, error) {
+	clusters, err := c.ListClusters()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*modules.Cluster
+	for _, cluster := range clusters {
+		if cluster.NameSpace == namespace {
+			res = append(res, cluster)
+			break
+		}
+	}
+	return res, err
+}
+
+func (c *client) ListClusters() ([]*modules.Cluster, error) {
 	resp, err := c.sendJSON("GET", "cluster", nil)
 	if err != nil {
 		return nil, err
@@ -271,15 +353,7 @@ func (c *client) ListClusterByNamespace(namespace string) ([]*modules.Cluster, e
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, err
 	}
-
-	var res []*modules.Cluster
-	for _, cluster := range response.Data {
-		if cluster.NameSpace == namespace {
-			res = append(res, cluster)
-			break
-		}
-	}
-	return res, err
+	return response.Data, nil
 }
 
 func (c *client) getToken() (string, error) {
@@ -53,12 +53,16 @@ type Manager interface {
 	ClientManager
 	// Install installs a KubeFATE deployment
 	Install(bool) error
+	// Upgrade applies the yaml to an existing KubeFATE installation and waits for the new version to be ready
+	Upgrade() error
 	// Uninstall uninstalls a KubeFATE installation
 	Uninstall() error
 	// InstallIngressNginxController installs a default ingress nginx controller
 	InstallIngressNginxController() error
 	// GetKubeFATEDeployment returns the Deployment object of KubeFATE
 	GetKubeFATEDeployment() (*appv1.Deployment, error)
+	// GetIngressNginxControllerDeployment returns the Deployment object of the default ingress nginx controller
+	GetIngressNginxControllerDeployment() (*appv1.Deployment, error)
 }
 
 type manager struct {
@@ -128,6 +132,52 @@ func (manager *manager) Install(checkIngress bool) error {
 		}
 	}
 
+	if err := manager.waitForService(); err != nil {
+		return err
+	}
+	log.Info().Msg("kubefate is installed and ready")
+	return nil
+}
+
+func (manager *manager) Upgrade() error {
+	if _, err := manager.GetKubeFATEDeployment(); err != nil {
+		return errors.Wrapf(err, "failed to get the kubefate deployment")
+	}
+	if err := manager.client.ApplyOrDeleteYAML(manager.meta.yaml, false); err != nil {
+		return errors.Wrapf(err, "failed to upgrade kubefate, yaml: %s", manager.meta.yaml)
+	}
+
+	if err := utils.ExecuteWithTimeout(func() bool {
+		log.Info().Msgf("checking kubefate deployment rollout...")
+		kubefateDeployment, err := manager.GetKubeFATEDeployment()
+		if err != nil {
+			log.Err(err).Msgf("kubefate deployment upgrade error")
+			return false
+		}
+		replicas := int32(1)
+		if kubefateDeployment.Spec.Replicas != nil {
+			replicas = *kubefateDeployment.Spec.Replicas
+		}
+		status := kubefateDeployment.Status
+		if status.ObservedGeneration >= kubefateDeployment.Generation && status.UpdatedReplicas == replicas &&
+			status.ReadyReplicas == replicas && status.Replicas == replicas {
+			return true
+		}
+		log.Info().Msgf("kubefate upgrade not rolled out yet, status: %s", status.String())
+		return false
+	}, time.Minute*30, time.Second*10); err != nil {
+		return errors.Wrapf(err, "error checking kubefate deployment")
+	}
+
+	if err := manager.waitForService(); err != nil {
+		return err
+	}
+	log.Info().Msg("kubefate is upgraded and ready")
+	return nil
+}
+
+// waitForService waits until the KubeFATE service can return its version
+func (manager *manager) waitForService() error {
 	if err := utils.ExecuteWithTimeout(func() bool {
 		log.Info().Msgf("verifying kubefate version...")
 		kfc, err := manager.BuildClient()
@@ -150,7 +200,6 @@ func (manager *manager) Install(checkIngress bool) error {
 	}, time.Minute*20, time.Second*10); err != nil {
 		return errors.Wrapf(err, "error checking kubefate service readiness")
 	}
-	log.Info().Msg("kubefate is installed and ready")
 	return nil
 }
 
@@ -0,0 +1,161 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openfl_director_client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	methodGetEnvoys                = "/openfl.director.Director/GetEnvoys"
+	methodGetExperimentsList       = "/openfl.director.Director/GetExperimentsList"
+	methodGetExperimentDescription = "/openfl.director.Director/GetExperimentDescription"
+	methodGetMetricStream          = "/openfl.director.Director/GetMetricStream"
+)
+
+// Client provides interface to work with the gRPC API of an OpenFL director
+type Client interface {
+	// GetEnvoys returns the envoys connected to the director and their shard information
+	GetEnvoys(ctx context.Context) ([]EnvoyInfo, error)
+	// GetExperimentsList returns the experiments known to the director
+	GetExperimentsList(ctx context.Context) ([]ExperimentListItem, error)
+	// GetExperimentDescription returns the detailed status of the specified experiment
+	GetExperimentDescription(ctx context.Context, name string) (*ExperimentDescription, error)
+	// GetMetricStream collects the metrics of the specified experiment until the stream ends or ctx is done
+	GetMetricStream(ctx context.Context, experimentName string) ([]Metric, error)
+	// Close closes the underlying connection
+	Close() error
+}
+
+// Config contains the connection info of the director
+type Config struct {
+	// Address is the host:port of the director service
+	Address string
+	// ServerName is used to verify the server certificate, typically the director FQDN
+	ServerName string
+	// CACert is the PEM encoded CA certificate to verify the director
+	CACert []byte
+	// ClientCert is the PEM encoded certificate for the client to authenticate itself
+	ClientCert []byte
+	// ClientKey is the PEM encoded private key of ClientCert
+	ClientKey []byte
+}
+
+type client struct {
+	conn *grpc.ClientConn
+}
+
+var _ Client = (*client)(nil)
+
+// NewClient returns a client connecting to the director using mTLS
+func NewClient(config Config) (Client, error) {
+	certificate, err := tls.X509KeyPair(config.ClientCert, config.ClientKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load client certificate")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(config.CACert) {
+		return nil, errors.New("failed to load CA certificate")
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      pool,
+		ServerName:   config.ServerName,
+	}
+	conn, err := grpc.Dial(config.Address,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to director %s", config.Address)
+	}
+	return &client{
+		conn: conn,
+	}, nil
+}
+
+func (c *client) GetEnvoys(ctx context.Context) ([]EnvoyInfo, error) {
+	resp, err := c.invoke(ctx, methodGetEnvoys, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGetEnvoysResponse(resp)
+}
+
+func (c *client) GetExperimentsList(ctx context.Context) ([]ExperimentListItem, error) {
+	resp, err := c.invoke(ctx, methodGetExperimentsList, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGetExperimentsListResponse(resp)
+}
+
+func (c *client) GetExperimentDescription(ctx context.Context, name string) (*ExperimentDescription, error) {
+	resp, err := c.invoke(ctx, methodGetExperimentDescription, encodeGetExperimentDescriptionRequest(name))
+	if err != nil {
+		return nil, err
+	}
+	return decodeGetExperimentDescriptionResponse(resp)
+}
+
+func (c *client) GetMetricStream(ctx context.Context, experimentName string) ([]Metric, error) {
+	stream, err := c.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, methodGetMetricStream)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open metric stream")
+	}
+	if err := stream.SendMsg(&rawMessage{data: encodeGetMetricStreamRequest(experimentName)}); err != nil {
+		return nil, errors.Wrap(err, "failed to send metric stream request")
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, errors.Wrap(err, "failed to close metric stream request")
+	}
+	metrics := []Metric{}
+	for {
+		resp := &rawMessage{}
+		if err := stream.RecvMsg(resp); err != nil {
+			// the director keeps the stream open while the experiment is running, so running out of time is expected
+			if err == io.EOF || status.Code(err) == codes.DeadlineExceeded || status.Code(err) == codes.Canceled {
+				return metrics, nil
+			}
+			return metrics, errors.Wrap(err, "failed to receive metrics")
+		}
+		metric, err := decodeGetMetricStreamResponse(resp.data)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, metric)
+	}
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *client) invoke(ctx context.Context, method string, req []byte) ([]byte, error) {
+	resp := &rawMessage{}
+	if err := c.conn.Invoke(ctx, method, &rawMessage{data: req}, resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to call %s", method)
+	}
+	return resp.data, nil
+}
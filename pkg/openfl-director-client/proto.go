@@ -0,0 +1,396 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openfl_director_client
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The director API messages are encoded and decoded using protowire directly, so that we don't need to
+// vendor the generated code of the OpenFL proto files. Only the fields we use are handled, and unknown
+// fields are skipped, which keeps the client compatible with newer OpenFL releases.
+
+// rawMessage holds the serialized bytes of a protobuf message
+type rawMessage struct {
+	data []byte
+}
+
+// rawCodec is a gRPC codec that passes through the already serialized protobuf messages
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return nil, errors.Errorf("unsupported message type %T", v)
+	}
+	return msg.data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return errors.Errorf("unsupported message type %T", v)
+	}
+	msg.data = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// fieldValue is a decoded field of a protobuf message
+type fieldValue struct {
+	typ   protowire.Type
+	num   uint64
+	bytes []byte
+}
+
+func (v fieldValue) string() string {
+	return string(v.bytes)
+}
+
+func (v fieldValue) uint() uint64 {
+	return v.num
+}
+
+func (v fieldValue) bool() bool {
+	return v.num != 0
+}
+
+// float returns the value as a float, tolerating integer encoded fields
+func (v fieldValue) float() float32 {
+	switch v.typ {
+	case protowire.Fixed32Type:
+		return math.Float32frombits(uint32(v.num))
+	case protowire.Fixed64Type:
+		return float32(math.Float64frombits(v.num))
+	default:
+		return float32(v.num)
+	}
+}
+
+// decodeMessage calls fn for every field in the serialized message b
+func decodeMessage(b []byte, fn func(num protowire.Number, value fieldValue) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errors.Wrap(protowire.ParseError(n), "failed to parse field tag")
+		}
+		b = b[n:]
+		value := fieldValue{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			value.num, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			value.num = uint64(v)
+		case protowire.Fixed64Type:
+			value.num, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			value.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errors.Wrapf(protowire.ParseError(n), "failed to parse field %d", num)
+		}
+		b = b[n:]
+		if err := fn(num, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func encodeGetExperimentDescriptionRequest(name string) []byte {
+	return appendString(nil, 1, name)
+}
+
+func encodeGetMetricStreamRequest(experimentName string) []byte {
+	return appendString(nil, 1, experimentName)
+}
+
+func decodeGetEnvoysResponse(b []byte) ([]EnvoyInfo, error) {
+	envoys := []EnvoyInfo{}
+	err := decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		if num == 1 {
+			envoy, err := decodeEnvoyInfo(value.bytes)
+			if err != nil {
+				return err
+			}
+			envoys = append(envoys, envoy)
+		}
+		return nil
+	})
+	return envoys, err
+}
+
+func decodeEnvoyInfo(b []byte) (EnvoyInfo, error) {
+	envoy := EnvoyInfo{}
+	err := decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			return decodeShardInfo(value.bytes, &envoy)
+		case 2:
+			envoy.IsOnline = value.bool()
+		case 3:
+			envoy.IsExperimentRunning = value.bool()
+		case 4:
+			seconds, nanos, err := decodeSecondsAndNanos(value.bytes)
+			if err != nil {
+				return err
+			}
+			envoy.LastUpdated = time.Unix(seconds, nanos).UTC()
+		case 5:
+			seconds, _, err := decodeSecondsAndNanos(value.bytes)
+			if err != nil {
+				return err
+			}
+			envoy.ValidDurationSeconds = seconds
+		case 6:
+			envoy.ExperimentName = value.string()
+		}
+		return nil
+	})
+	return envoy, err
+}
+
+func decodeShardInfo(b []byte, envoy *EnvoyInfo) error {
+	return decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			return decodeNodeInfo(value.bytes, envoy)
+		case 2:
+			envoy.ShardDescription = value.string()
+		case 3:
+			envoy.NSamples = value.uint()
+		case 4:
+			envoy.SampleShape = append(envoy.SampleShape, value.string())
+		case 5:
+			envoy.TargetShape = append(envoy.TargetShape, value.string())
+		}
+		return nil
+	})
+}
+
+func decodeNodeInfo(b []byte, envoy *EnvoyInfo) error {
+	return decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			envoy.Name = value.string()
+		case 2:
+			device, err := decodeCUDADeviceInfo(value.bytes)
+			if err != nil {
+				return err
+			}
+			envoy.CUDADevices = append(envoy.CUDADevices, device)
+		}
+		return nil
+	})
+}
+
+func decodeCUDADeviceInfo(b []byte) (CUDADeviceInfo, error) {
+	device := CUDADeviceInfo{}
+	err := decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			device.MemoryTotal = value.uint()
+		case 2:
+			device.MemoryUtilized = value.uint()
+		case 3:
+			device.DeviceUtilization = value.string()
+		case 4:
+			device.CUDADriverVersion = value.string()
+		case 5:
+			device.CUDAVersion = value.string()
+		case 6:
+			device.Name = value.string()
+		case 7:
+			device.Index = value.uint()
+		}
+		return nil
+	})
+	return device, err
+}
+
+// decodeSecondsAndNanos decodes a google.protobuf.Timestamp or google.protobuf.Duration message
+func decodeSecondsAndNanos(b []byte) (seconds int64, nanos int64, err error) {
+	err = decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			seconds = int64(value.uint())
+		case 2:
+			nanos = int64(int32(value.uint()))
+		}
+		return nil
+	})
+	return
+}
+
+func decodeGetExperimentsListResponse(b []byte) ([]ExperimentListItem, error) {
+	experiments := []ExperimentListItem{}
+	err := decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		if num != 1 {
+			return nil
+		}
+		item := ExperimentListItem{}
+		if err := decodeMessage(value.bytes, func(num protowire.Number, value fieldValue) error {
+			switch num {
+			case 1:
+				item.Name = value.string()
+			case 2:
+				item.Status = value.string()
+			case 3:
+				item.CollaboratorsAmount = value.uint()
+			case 4:
+				item.TasksAmount = value.uint()
+			case 5:
+				item.Progress = value.float()
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		experiments = append(experiments, item)
+		return nil
+	})
+	return experiments, err
+}
+
+func decodeGetExperimentDescriptionResponse(b []byte) (*ExperimentDescription, error) {
+	experiment := &ExperimentDescription{}
+	err := decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		if num != 1 {
+			return nil
+		}
+		return decodeExperimentDescription(value.bytes, experiment)
+	})
+	return experiment, err
+}
+
+func decodeExperimentDescription(b []byte, experiment *ExperimentDescription) error {
+	return decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			experiment.Name = value.string()
+		case 2:
+			experiment.Status = value.string()
+		case 3:
+			experiment.Progress = value.float()
+		case 4:
+			experiment.TotalRounds = value.uint()
+		case 5:
+			experiment.CurrentRound = value.uint()
+		case 6:
+			return decodeDownloadStatuses(value.bytes, experiment)
+		case 7:
+			collaborator := CollaboratorDescription{}
+			if err := decodeMessage(value.bytes, func(num protowire.Number, value fieldValue) error {
+				switch num {
+				case 1:
+					collaborator.Name = value.string()
+				case 2:
+					collaborator.Status = value.string()
+				case 3:
+					collaborator.Progress = value.float()
+				case 4:
+					collaborator.Round = value.uint()
+				case 5:
+					collaborator.CurrentTask = value.string()
+				case 6:
+					collaborator.NextTask = value.string()
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			experiment.Collaborators = append(experiment.Collaborators, collaborator)
+		case 8:
+			task := TaskDescription{}
+			if err := decodeMessage(value.bytes, func(num protowire.Number, value fieldValue) error {
+				switch num {
+				case 1:
+					task.Name = value.string()
+				case 2:
+					task.Description = value.string()
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			experiment.Tasks = append(experiment.Tasks, task)
+		}
+		return nil
+	})
+}
+
+// decodeDownloadStatuses decodes the DownloadStatuses message, whose field 1 holds the model statuses
+// and field 2 holds the log statuses
+func decodeDownloadStatuses(b []byte, experiment *ExperimentDescription) error {
+	return decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		if num != 1 && num != 2 {
+			return nil
+		}
+		status := DownloadStatus{}
+		if err := decodeMessage(value.bytes, func(num protowire.Number, value fieldValue) error {
+			switch num {
+			case 1:
+				status.Name = value.string()
+			case 2:
+				status.Status = value.string()
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if num == 1 {
+			experiment.ModelDownloadStatuses = append(experiment.ModelDownloadStatuses, status)
+		} else {
+			experiment.LogDownloadStatuses = append(experiment.LogDownloadStatuses, status)
+		}
+		return nil
+	})
+}
+
+func decodeGetMetricStreamResponse(b []byte) (Metric, error) {
+	metric := Metric{}
+	err := decodeMessage(b, func(num protowire.Number, value fieldValue) error {
+		switch num {
+		case 1:
+			metric.Origin = value.string()
+		case 2:
+			metric.TaskName = value.string()
+		case 3:
+			metric.Name = value.string()
+		case 4:
+			metric.Value = value.float()
+		case 5:
+			metric.Round = value.uint()
+		}
+		return nil
+	})
+	return metric, err
+}
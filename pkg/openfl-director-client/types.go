@@ -0,0 +1,96 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openfl_director_client
+
+import "time"
+
+// EnvoyInfo contains the status and shard information of an envoy connected to the director
+type EnvoyInfo struct {
+	Name                 string           `json:"name"`
+	ShardDescription     string           `json:"shard_description"`
+	NSamples             uint64           `json:"n_samples"`
+	SampleShape          []string         `json:"sample_shape"`
+	TargetShape          []string         `json:"target_shape"`
+	CUDADevices          []CUDADeviceInfo `json:"cuda_devices"`
+	IsOnline             bool             `json:"is_online"`
+	IsExperimentRunning  bool             `json:"is_experiment_running"`
+	ExperimentName       string           `json:"experiment_name"`
+	LastUpdated          time.Time        `json:"last_updated"`
+	ValidDurationSeconds int64            `json:"valid_duration_seconds"`
+}
+
+// CUDADeviceInfo contains the information of a CUDA device reported by an envoy
+type CUDADeviceInfo struct {
+	Index             uint64 `json:"index"`
+	Name              string `json:"name"`
+	MemoryTotal       uint64 `json:"memory_total"`
+	MemoryUtilized    uint64 `json:"memory_utilized"`
+	DeviceUtilization string `json:"device_utilization"`
+	CUDADriverVersion string `json:"cuda_driver_version"`
+	CUDAVersion       string `json:"cuda_version"`
+}
+
+// ExperimentListItem is the brief information of an experiment known to the director
+type ExperimentListItem struct {
+	Name                string  `json:"name"`
+	Status              string  `json:"status"`
+	CollaboratorsAmount uint64  `json:"collaborators_amount"`
+	TasksAmount         uint64  `json:"tasks_amount"`
+	Progress            float32 `json:"progress"`
+}
+
+// ExperimentDescription contains the detailed status of an experiment
+type ExperimentDescription struct {
+	Name                  string                    `json:"name"`
+	Status                string                    `json:"status"`
+	Progress              float32                   `json:"progress"`
+	TotalRounds           uint64                    `json:"total_rounds"`
+	CurrentRound          uint64                    `json:"current_round"`
+	ModelDownloadStatuses []DownloadStatus          `json:"model_download_statuses"`
+	LogDownloadStatuses   []DownloadStatus          `json:"log_download_statuses"`
+	Collaborators         []CollaboratorDescription `json:"collaborators"`
+	Tasks                 []TaskDescription         `json:"tasks"`
+}
+
+// DownloadStatus is the availability of a downloadable artifact of an experiment
+type DownloadStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// CollaboratorDescription contains the status of a collaborator in an experiment
+type CollaboratorDescription struct {
+	Name        string  `json:"name"`
+	Status      string  `json:"status"`
+	Progress    float32 `json:"progress"`
+	Round       uint64  `json:"round"`
+	CurrentTask string  `json:"current_task"`
+	NextTask    string  `json:"next_task"`
+}
+
+// TaskDescription describes a task of an experiment
+type TaskDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Metric is a metric value reported by the aggregator or a collaborator
+type Metric struct {
+	Origin   string  `json:"origin"`
+	TaskName string  `json:"task_name"`
+	Name     string  `json:"name"`
+	Value    float32 `json:"value"`
+	Round    uint64  `json:"round"`
+}
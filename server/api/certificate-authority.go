@@ -15,6 +15,7 @@
 package api
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/FederatedAI/FedLCM/server/application/service"
@@ -29,10 +30,12 @@ type CertificateAuthorityController struct {
 }
 
 // NewCertificateAuthorityController returns a controller instance to handle certificate authority API requests
-func NewCertificateAuthorityController(caRepo repo.CertificateAuthorityRepository) *CertificateAuthorityController {
+func NewCertificateAuthorityController(caRepo repo.CertificateAuthorityRepository,
+	certRepo repo.CertificateRepository) *CertificateAuthorityController {
 	return &CertificateAuthorityController{
 		certificateAuthorityApp: &service.CertificateAuthorityApp{
 			CertificateAuthorityRepo: caRepo,
+			CertificateRepo:          certRepo,
 		},
 	}
 }
@@ -40,6 +43,11 @@ func NewCertificateAuthorityController(caRepo repo.CertificateAuthorityRepositor
 // Route sets up route mappings to certificate-authority related APIs
 func (controller *CertificateAuthorityController) Route(r *gin.RouterGroup) {
 	ca := r.Group("certificate-authority")
+	// the revocation info is public so that the participants can check the peer certificates without credentials
+	{
+		ca.GET("/crl", controller.getCRL)
+		ca.POST("/ocsp", controller.ocsp)
+	}
 	ca.Use(authMiddleware.MiddlewareFunc())
 	{
 		ca.GET("", controller.get)
@@ -81,7 +89,7 @@ func (controller *CertificateAuthorityController) get(c *gin.Context) {
 // @Summary Create a new certificate authority
 // @Tags    CertificateAuthority
 // @Produce json
-// @Param   certificateAuthority body     service.CertificateAuthorityEditableItem true "The CA information, currently for the type field only '1(StepCA)'  is  supported"
+// @Param   certificateAuthority body     service.CertificateAuthorityEditableItem true "The CA information, the type field can be 1(StepCA), 2(Embedded), 3(Vault) or 4(cert-manager)"
 // @Success 200                  {object} GeneralResponse                          "Success"
 // @Failure 401                  {object} GeneralResponse                          "Unauthorized operation"
 // @Failure 500                  {object} GeneralResponse{code=int}                "Internal server error"
@@ -167,3 +175,57 @@ func (controller *CertificateAuthorityController) getBuiltInCAConfig(c *gin.Cont
 		c.JSON(http.StatusOK, resp)
 	}
 }
+
+// getCRL returns the certificate revocation list
+//
+// @Summary Return the DER encoded CRL signed by the embedded CA
+// @Tags    CertificateAuthority
+// @Produce application/pkix-crl
+// @Success 200 {string} string                    "The CRL content"
+// @Failure 500 {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /certificate-authority/crl [get]
+func (controller *CertificateAuthorityController) getCRL(c *gin.Context) {
+	crl, err := controller.certificateAuthorityApp.GetCRL()
+	if err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		c.Data(http.StatusOK, "application/pkix-crl", crl)
+	}
+}
+
+// ocsp responds to the OCSP request
+//
+// @Summary Return the DER encoded OCSP response signed by the embedded CA
+// @Tags    CertificateAuthority
+// @Accept  application/ocsp-request
+// @Produce application/ocsp-response
+// @Success 200 {string} string                    "The OCSP response"
+// @Failure 500 {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /certificate-authority/ocsp [post]
+func (controller *CertificateAuthorityController) ocsp(c *gin.Context) {
+	request, err := io.ReadAll(io.LimitReader(c.Request.Body, 10240))
+	if err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	response, err := controller.certificateAuthorityApp.GetOCSPResponse(request)
+	if err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		c.Data(http.StatusOK, "application/ocsp-response", response)
+	}
+}
@@ -16,6 +16,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/FederatedAI/FedLCM/server/application/service"
 	"github.com/FederatedAI/FedLCM/server/constants"
@@ -35,7 +37,10 @@ func NewCertificateController(caRepo repo.CertificateAuthorityRepository,
 	participantFATERepo repo.ParticipantFATERepository,
 	participantOpenFLRepo repo.ParticipantOpenFLRepository,
 	federationFATERepo repo.FederationRepository,
-	federationOpenFLRepo repo.FederationRepository) *CertificateController {
+	federationOpenFLRepo repo.FederationRepository,
+	infraProviderRepo repo.InfraProviderRepository,
+	endpointKubeFATERepo repo.EndpointRepository,
+	eventRepo repo.EventRepository) *CertificateController {
 	return &CertificateController{
 		certificateApp: &service.CertificateApp{
 			CertificateAuthorityRepo: caRepo,
@@ -45,6 +50,9 @@ func NewCertificateController(caRepo repo.CertificateAuthorityRepository,
 			ParticipantOpenFLRepo:    participantOpenFLRepo,
 			FederationFATERepo:       federationFATERepo,
 			FederationOpenFLRepo:     federationOpenFLRepo,
+			InfraProviderRepo:        infraProviderRepo,
+			EndpointKubeFATERepo:     endpointKubeFATERepo,
+			EventRepo:                eventRepo,
 		},
 	}
 }
@@ -55,11 +63,18 @@ func (controller *CertificateController) Route(r *gin.RouterGroup) {
 	certificate.Use(authMiddleware.MiddlewareFunc())
 	{
 		certificate.GET("", controller.list)
+		certificate.POST("/import", controller.importCertificate)
 		certificate.DELETE("/:uuid", controller.delete)
+		certificate.POST("/:uuid/rotate", controller.rotate)
 
 	}
 }
 
+// StartAutoRotation starts the background routine renewing the expiring certificates
+func (controller *CertificateController) StartAutoRotation(interval, renewBefore time.Duration) {
+	controller.certificateApp.StartAutoRotation(interval, renewBefore)
+}
+
 // list returns the certificate list
 //
 // @Summary Return issued certificate list
@@ -86,19 +101,82 @@ func (controller *CertificateController) list(c *gin.Context) {
 	}
 }
 
+// importCertificate saves an externally issued certificate and its private key
+//
+// @Summary Import an externally issued certificate with its private key, in PEM or PKCS#12 format
+// @Tags    Certificate
+// @Produce json
+// @Param   certificate body     service.CertificateImportRequest true "The certificate, private key and the chain"
+// @Success 200         {object} GeneralResponse{data=string}     "Success, the data is the uuid of the imported certificate"
+// @Failure 401         {object} GeneralResponse                  "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int}        "Internal server error"
+// @Router  /certificate/import [post]
+func (controller *CertificateController) importCertificate(c *gin.Context) {
+	if uuid, err := func() (string, error) {
+		req := &service.CertificateImportRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return "", err
+		}
+		return controller.certificateApp.ImportCertificate(req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: uuid,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 // delete removes the certificate which has no participant bindings
 //
 // @Summary Delete the certificate  which has no participant bindings
 // @Tags    Certificate
 // @Produce json
+// @Param   uuid   path     string                    true  "Certificate UUID"
+// @Param   revoke query    bool                      false "if set to true, the certificate will be revoked before being deleted"
+// @Success 200    {object} GeneralResponse           "Success"
+// @Failure 401    {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500    {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /certificate/{uuid} [delete]
+func (controller *CertificateController) delete(c *gin.Context) {
+	uuid := c.Param("uuid")
+	revoke, err := strconv.ParseBool(c.DefaultQuery("revoke", "false"))
+	if err != nil {
+		revoke = false
+	}
+	if err := controller.certificateApp.DeleteCertificate(uuid, revoke); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// rotate renews the certificate and rotates it in the bound services
+//
+// @Summary Renew the certificate and update the secrets and workloads of the bound services
+// @Tags    Certificate
+// @Produce json
 // @Param   uuid path     string                    true "Certificate UUID"
 // @Success 200  {object} GeneralResponse           "Success"
 // @Failure 401  {object} GeneralResponse           "Unauthorized operation"
 // @Failure 500  {object} GeneralResponse{code=int} "Internal server error"
-// @Router  /certificate/{uuid} [delete]
-func (controller *CertificateController) delete(c *gin.Context) {
+// @Router  /certificate/{uuid}/rotate [post]
+func (controller *CertificateController) rotate(c *gin.Context) {
 	uuid := c.Param("uuid")
-	if err := controller.certificateApp.DeleteCertificate(uuid); err != nil {
+	if err := controller.certificateApp.RotateCertificate(uuid); err != nil {
 		resp := &GeneralResponse{
 			Code:    constants.RespInternalErr,
 			Message: err.Error(),
@@ -17,6 +17,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/FederatedAI/FedLCM/server/application/service"
 	"github.com/FederatedAI/FedLCM/server/constants"
@@ -61,6 +62,8 @@ func (controller *EndpointController) Route(r *gin.RouterGroup) {
 
 		endpoint.POST("/scan", controller.scan)
 		endpoint.POST("/:uuid/kubefate/check", controller.checkKubeFATE)
+		endpoint.POST("/:uuid/kubefate/upgrade", controller.upgradeKubeFATE)
+		endpoint.POST("/:uuid/kubefate/probe", controller.probeKubeFATE)
 
 		endpoint.POST("", controller.create)
 	}
@@ -210,6 +213,72 @@ func (controller *EndpointController) checkKubeFATE(c *gin.Context) {
 	}
 }
 
+// upgradeKubeFATE upgrades the KubeFATE deployment of the endpoint
+//
+// @Summary Upgrade the KubeFATE service of the endpoint, the endpoint must be installed by this service
+// @Tags    Endpoint
+// @Produce json
+// @Param   uuid    path     string                                 true "Endpoint UUID"
+// @Param   request body     service.EndpointKubeFATEUpgradeRequest true "The new KubeFATE deployment yaml, empty means using the default one"
+// @Success 200     {object} GeneralResponse                        "Success"
+// @Failure 401     {object} GeneralResponse                        "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}              "Internal server error"
+// @Router  /endpoint/{uuid}/kubefate/upgrade [post]
+func (controller *EndpointController) upgradeKubeFATE(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if err := func() error {
+		req := &service.EndpointKubeFATEUpgradeRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.endpointAppService.UpgradeKubeFATEEndpoint(uuid, req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// probeKubeFATE checks the health of the KubeFATE endpoint and updates its status
+//
+// @Summary Probe the KubeFATE endpoint health and update its status
+// @Tags    Endpoint
+// @Produce json
+// @Param   uuid        path     string                    true  "Endpoint UUID"
+// @Param   auto_repair query    bool                      false "re-install the missing KubeFATE or ingress controller deployment"
+// @Success 200         {object} GeneralResponse           "Success"
+// @Failure 401         {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /endpoint/{uuid}/kubefate/probe [post]
+func (controller *EndpointController) probeKubeFATE(c *gin.Context) {
+	uuid := c.Param("uuid")
+	autoRepair, _ := strconv.ParseBool(c.DefaultQuery("auto_repair", "false"))
+	if err := controller.endpointAppService.ProbeKubeFATEEndpoint(uuid, autoRepair); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// StartHealthMonitor starts the background routine probing the KubeFATE endpoints
+func (controller *EndpointController) StartHealthMonitor(interval time.Duration, autoRepair bool) {
+	controller.endpointAppService.StartHealthMonitor(interval, autoRepair)
+}
+
 // getKubeFATEDeploymentYAML returns the yaml content for deploying KubeFATE
 //
 // @Summary Get KubeFATE installation YAML content
@@ -47,6 +47,12 @@ func NewFederationController(infraProviderKubernetesRepo repo.InfraProviderRepos
 	certificateRepo repo.CertificateRepository,
 	certificateBindingRepo repo.CertificateBindingRepository,
 	registrationTokenOpenFLRepo repo.RegistrationTokenRepository,
+	registrationTokenFATERepo repo.RegistrationTokenRepository,
+	registrationRecordRepo repo.RegistrationRecordRepository,
+	participantOpenFLBatchOperationRepo repo.ParticipantOpenFLBatchOperationRepository,
+	federationOpenFLShardDescriptorRepo repo.FederationOpenFLShardDescriptorRepository,
+	participantFATESizingProfileRepo repo.ParticipantFATESizingProfileRepository,
+	federationFATEDiagnosticsRepo repo.FederationFATEDiagnosticsRepository,
 	eventRepo repo.EventRepository) *FederationController {
 	return &FederationController{
 		federationApp: &service.FederationApp{
@@ -55,6 +61,10 @@ func NewFederationController(infraProviderKubernetesRepo repo.InfraProviderRepos
 			FederationOpenFLRepo:        federationOpenFLRepo,
 			ParticipantOpenFLRepo:       participantOpenflRepo,
 			RegistrationTokenOpenFLRepo: registrationTokenOpenFLRepo,
+			RegistrationTokenFATERepo:   registrationTokenFATERepo,
+			RegistrationRecordRepo:      registrationRecordRepo,
+
+			FederationOpenFLShardDescriptorRepo: federationOpenFLShardDescriptorRepo,
 		},
 		participantAppService: &service.ParticipantApp{
 			ParticipantFATERepo:         participantFATERepo,
@@ -62,6 +72,8 @@ func NewFederationController(infraProviderKubernetesRepo repo.InfraProviderRepos
 			FederationOpenFLRepo:        federationOpenFLRepo,
 			ParticipantOpenFLRepo:       participantOpenflRepo,
 			RegistrationTokenOpenFLRepo: registrationTokenOpenFLRepo,
+			RegistrationTokenFATERepo:   registrationTokenFATERepo,
+			RegistrationRecordRepo:      registrationRecordRepo,
 			EndpointKubeFATERepo:        endpointKubeFATERepo,
 			InfraProviderKubernetesRepo: infraProviderKubernetesRepo,
 			ChartRepo:                   chartRepo,
@@ -69,6 +81,11 @@ func NewFederationController(infraProviderKubernetesRepo repo.InfraProviderRepos
 			CertificateRepo:             certificateRepo,
 			CertificateBindingRepo:      certificateBindingRepo,
 			EventRepo:                   eventRepo,
+
+			ParticipantOpenFLBatchOperationRepo: participantOpenFLBatchOperationRepo,
+			FederationOpenFLShardDescriptorRepo: federationOpenFLShardDescriptorRepo,
+			ParticipantFATESizingProfileRepo:    participantFATESizingProfileRepo,
+			FederationFATEDiagnosticsRepo:       federationFATEDiagnosticsRepo,
 		},
 	}
 }
@@ -80,6 +97,12 @@ func (controller *FederationController) Route(r *gin.RouterGroup) {
 	// we use the token string in the request for authentication
 	federation.POST("/openfl/envoy/register", controller.registerOpenFLEnvoy)
 	federation.GET("/openfl/envoy/:uuid", controller.getOpenFLEnvoyWithToken)
+	federation.POST("/openfl/envoy/:uuid/unregister", controller.unregisterOpenFLEnvoy)
+	federation.POST("/openfl/envoy/:uuid/heartbeat", controller.heartbeatOpenFLEnvoy)
+	federation.POST("/openfl/envoy/:uuid/reconfigure", controller.reconfigureOpenFLEnvoy)
+	federation.POST("/openfl/envoy/:uuid/upgrade", controller.upgradeOpenFLEnvoy)
+	federation.POST("/fate/cluster/register", controller.registerFATECluster)
+	federation.GET("/fate/cluster/register/:uuid", controller.getFATEClusterWithToken)
 
 	federation.Use(authMiddleware.MiddlewareFunc())
 	{
@@ -88,17 +111,20 @@ func (controller *FederationController) Route(r *gin.RouterGroup) {
 	fate := federation.Group("fate")
 	{
 		fate.POST("", controller.createFATE)
+		fate.POST("/apply", controller.applyFATE)
 		fate.GET("/:uuid", controller.getFATE)
 		fate.DELETE("/:uuid", controller.deleteFATE)
 
 		fate.GET("/exchange/yaml", controller.getFATEExchangeDeploymentYAML)
 		fate.GET("/cluster/yaml", controller.getFATEClusterDeploymentYAML)
+		fate.GET("/cluster/adoptable", controller.listAdoptableFATECluster)
 
 		fate.POST("/:uuid/exchange", controller.createFATEExchange)
 		fate.POST("/:uuid/exchange/external", controller.createExternalFATEExchange)
 		fate.POST("/:uuid/cluster", controller.createFATECluster)
 		fate.POST("/:uuid/partyID/check", controller.checkFATEPartyID)
 		fate.POST("/:uuid/cluster/external", controller.createExternalFATECluster)
+		fate.POST("/:uuid/cluster/adopt", controller.adoptFATECluster)
 
 		fate.DELETE("/:uuid/exchange/:exchangeUUID", controller.deleteFATEExchange)
 		fate.DELETE("/:uuid/cluster/:clusterUUID", controller.deleteFATECluster)
@@ -114,6 +140,31 @@ func (controller *FederationController) Route(r *gin.RouterGroup) {
 		fate.POST("/:uuid/exchange/:exchangeUUID/upgrade", controller.upgradeFATEExchange)
 		fate.POST("/:uuid/cluster/:clusterUUID/upgrade", controller.upgradeFATECluster)
 
+		fate.POST("/:uuid/exchange/:exchangeUUID/failover", controller.failoverFATEExchange)
+		fate.POST("/:uuid/cluster/:clusterUUID/failover", controller.failoverFATECluster)
+
+		fate.GET("/sizing-profile", controller.listFATESizingProfile)
+		fate.POST("/sizing-profile", controller.createFATESizingProfile)
+		fate.GET("/sizing-profile/:profileUUID", controller.getFATESizingProfile)
+		fate.PUT("/sizing-profile/:profileUUID", controller.updateFATESizingProfile)
+		fate.DELETE("/sizing-profile/:profileUUID", controller.deleteFATESizingProfile)
+		fate.POST("/:uuid/cluster/:clusterUUID/resize", controller.resizeFATECluster)
+
+		fateToken := fate.Group("/:uuid/token")
+		fateToken.POST("", controller.createFATEToken)
+		fateToken.GET("", controller.listFATEToken)
+		fateToken.DELETE("/:tokenUUID", controller.deleteFATEToken)
+		fateToken.POST("/:tokenUUID/enable", controller.enableFATEToken)
+		fateToken.POST("/:tokenUUID/disable", controller.disableFATEToken)
+		fateToken.POST("/:tokenUUID/rotate", controller.rotateFATEToken)
+
+		fate.GET("/:uuid/registration", controller.listRegistrationRecords)
+
+		fate.GET("/:uuid/topology", controller.getFATETopology)
+		fate.POST("/:uuid/diagnostics", controller.diagnoseFATE)
+		fate.GET("/:uuid/diagnostics", controller.listFATEDiagnostics)
+		fate.GET("/:uuid/diagnostics/:diagnosticsUUID", controller.getFATEDiagnostics)
+
 	}
 
 	openfl := federation.Group("openfl")
@@ -126,6 +177,11 @@ func (controller *FederationController) Route(r *gin.RouterGroup) {
 		token.POST("", controller.createOpenFLToken)
 		token.GET("", controller.listOpenFLToken)
 		token.DELETE("/:tokenUUID", controller.deleteOpenFLToken)
+		token.POST("/:tokenUUID/enable", controller.enableOpenFLToken)
+		token.POST("/:tokenUUID/disable", controller.disableOpenFLToken)
+		token.POST("/:tokenUUID/rotate", controller.rotateOpenFLToken)
+
+		openfl.GET("/:uuid/registration", controller.listRegistrationRecords)
 
 		openfl.GET("/:uuid/participant", controller.getOpenFLParticipant)
 
@@ -134,9 +190,23 @@ func (controller *FederationController) Route(r *gin.RouterGroup) {
 		openfl.POST("/:uuid/director", controller.createOpenFLDirector)
 		openfl.DELETE("/:uuid/director/:directorUUID", controller.deleteOpenFLDirector)
 		openfl.GET("/:uuid/director/:directorUUID", controller.getOpenFLDirector)
+		openfl.GET("/:uuid/director/:directorUUID/experiments", controller.getOpenFLDirectorExperiments)
+		openfl.GET("/:uuid/director/:directorUUID/experiments/:experimentName", controller.getOpenFLDirectorExperimentDetail)
+		openfl.GET("/:uuid/director/:directorUUID/deployment", controller.getOpenFLDirectorDeploymentStatus)
 
 		openfl.GET("/:uuid/envoy/:envoyUUID", controller.getOpenFLEnvoy)
 		openfl.DELETE("/:uuid/envoy/:envoyUUID", controller.deleteOpenFLEnvoy)
+		openfl.PUT("/:uuid/envoy/:envoyUUID/labels", controller.updateOpenFLEnvoyLabels)
+		openfl.GET("/:uuid/envoy/:envoyUUID/deployment", controller.getOpenFLEnvoyDeploymentStatus)
+
+		openfl.POST("/:uuid/envoy/batch", controller.createOpenFLEnvoyBatchOperation)
+		openfl.GET("/:uuid/envoy/batch", controller.listOpenFLEnvoyBatchOperation)
+		openfl.GET("/:uuid/envoy/batch/:batchUUID", controller.getOpenFLEnvoyBatchOperation)
+
+		openfl.POST("/:uuid/shard-descriptor", controller.createOpenFLShardDescriptorVersion)
+		openfl.GET("/:uuid/shard-descriptor", controller.listOpenFLShardDescriptorVersion)
+		openfl.GET("/:uuid/shard-descriptor/:version", controller.getOpenFLShardDescriptorVersion)
+		openfl.POST("/:uuid/shard-descriptor/:version/rollout", controller.rolloutOpenFLShardDescriptorVersion)
 	}
 }
 
@@ -267,6 +337,7 @@ func (controller *FederationController) deleteFATE(c *gin.Context) {
 // @Param   use_registry        query    bool                         true "choose if use the customized registry config"
 // @Param   use_registry_secret query    bool                         true "choose if use the customized registry secret"
 // @Param   enable_psp          query    bool                         true "choose if enable the podSecurityPolicy"
+// @Param   enable_rollsite     query    bool                         false "choose if deploy the rollsite to route eggroll clusters"
 // @Success 200                 {object} GeneralResponse{data=string} "Success, the data field is the yaml content"
 // @Failure 401                 {object} GeneralResponse              "Unauthorized operation"
 // @Failure 500                 {object} GeneralResponse{code=int}    "Internal server error"
@@ -299,6 +370,10 @@ func (controller *FederationController) getFATEExchangeDeploymentYAML(c *gin.Con
 		if err != nil {
 			return "", err
 		}
+		enableRollsite, err := strconv.ParseBool(c.DefaultQuery("enable_rollsite", "false"))
+		if err != nil {
+			return "", err
+		}
 		return controller.participantAppService.GetFATEExchangeDeploymentYAML(&domainService.ParticipantFATEExchangeYAMLCreationRequest{
 			ChartUUID:   chartUUID,
 			Name:        name,
@@ -309,7 +384,8 @@ func (controller *FederationController) getFATEExchangeDeploymentYAML(c *gin.Con
 				Registry:          registry,
 				UseRegistrySecret: useRegistrySecretFATE,
 			},
-			EnablePSP: enablePSP,
+			EnablePSP:      enablePSP,
+			EnableRollsite: enableRollsite,
 		})
 	}(); err != nil {
 		resp := &GeneralResponse{
@@ -495,6 +571,9 @@ func (controller *FederationController) deleteFATEExchange(c *gin.Context) {
 // @Param   storage_class                        query    string                       true  "provide the name of StorageClass"
 // @Param   enable_psp                           query    bool                         true  "choose if enable the podSecurityPolicy"
 // @Param   fateflow_gpu_num                     query    int                          true  "number of gpu to assign to fateflow pod, default 0"
+// @Param   exchange_uuid                        query    string                       false "the primary exchange of the cluster, default to the first exchange of the federation"
+// @Param   sizing_profile_uuid                  query    string                       false "the sizing profile to apply, default to the chart defaults"
+// @Param   backend                              query    string                       false "the computing and federation backend: spark_pulsar (default), spark_rabbitmq or eggroll"
 // @Success 200                                  {object} GeneralResponse{data=string} "Success, the data field is the yaml content"
 // @Failure 401                                  {object} GeneralResponse              "Unauthorized operation"
 // @Failure 500                                  {object} GeneralResponse{code=int}    "Internal server error"
@@ -622,6 +701,9 @@ func (controller *FederationController) getFATEClusterDeploymentYAML(c *gin.Cont
 			EnablePersistence: enablePersistence,
 			StorageClass:      storageClass,
 			FATEFlowGPUNum:    fateflowGPUNum,
+			ExchangeUUID:      c.DefaultQuery("exchange_uuid", ""),
+			SizingProfileUUID: c.DefaultQuery("sizing_profile_uuid", ""),
+			Backend:           entity.ParticipantFATEBackend(c.DefaultQuery("backend", "")),
 			ExternalSpark: domainService.ExternalSpark{
 				Enable:                enableExternalSpark,
 				Cores_per_node:        externalSparkCoresPerNode,
@@ -766,6 +848,67 @@ func (controller *FederationController) createExternalFATECluster(c *gin.Context
 	}
 }
 
+// listAdoptableFATECluster returns the FATE clusters deployed by the KubeFATE service of an endpoint
+//
+// @Summary Scan the FATE clusters deployed by a KubeFATE endpoint and check whether they can be adopted
+// @Tags    Federation
+// @Produce json
+// @Param   endpoint_uuid query    string                                                            true "the endpoint uuid"
+// @Success 200           {object} GeneralResponse{data=[]service.ParticipantFATEAdoptionCandidate} "Success"
+// @Failure 401           {object} GeneralResponse                                                   "Unauthorized operation"
+// @Failure 500           {object} GeneralResponse{code=int}                                         "Internal server error"
+// @Router  /federation/fate/cluster/adoptable [get]
+func (controller *FederationController) listAdoptableFATECluster(c *gin.Context) {
+	if candidateList, err := controller.participantAppService.ScanAdoptableFATEClusters(c.DefaultQuery("endpoint_uuid", "")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: candidateList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// adoptFATECluster adopts an existing KubeFATE-deployed FATE cluster
+//
+// @Summary Adopt an existing KubeFATE-deployed FATE cluster as a managed cluster
+// @Tags    Federation
+// @Produce json
+// @Param   uuid            path     string                                 true "federation UUID"
+// @Param   adoptionRequest body     service.ParticipantFATEAdoptionRequest true "The adoption request"
+// @Success 200             {object} GeneralResponse                        "Success, the data field is the adopted cluster's uuid"
+// @Failure 401             {object} GeneralResponse                        "Unauthorized operation"
+// @Failure 500             {object} GeneralResponse{code=int}              "Internal server error"
+// @Router  /federation/fate/:uuid/cluster/adopt [post]
+func (controller *FederationController) adoptFATECluster(c *gin.Context) {
+	if clusterUUID, err := func() (string, error) {
+		federationUUID := c.Param("uuid")
+		req := &domainService.ParticipantFATEAdoptionRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return "", err
+		}
+		req.FederationUUID = federationUUID
+		return controller.participantAppService.AdoptFATECluster(req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: clusterUUID,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 // checkFATEPartyID checks if the party ID is available
 //
 // @Summary Check if the party ID is available
@@ -980,3 +1123,108 @@ func (controller *FederationController) upgradeFATECluster(c *gin.Context) {
 		c.JSON(http.StatusOK, resp)
 	}
 }
+
+// getFATETopology returns the topology graph of the FATE federation
+//
+// @Summary Get the participants of the FATE federation and the connections between them
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                                                   true "federation UUID"
+// @Success 200  {object} GeneralResponse{data=service.FederationFATETopology}     "Success"
+// @Failure 401  {object} GeneralResponse                                          "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int}                                "Internal server error"
+// @Router  /federation/fate/{uuid}/topology [get]
+func (controller *FederationController) getFATETopology(c *gin.Context) {
+	if topology, err := controller.participantAppService.GetFATEFederationTopology(c.Param("uuid")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: topology,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// diagnoseFATE starts checking the connectivity between the participants of the FATE federation
+//
+// @Summary Run probe jobs in the background to check the connectivity between the participants of the FATE federation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                    true "federation UUID"
+// @Success 200  {object} GeneralResponse           "Success, the data field is the created diagnostics' uuid"
+// @Failure 401  {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/diagnostics [post]
+func (controller *FederationController) diagnoseFATE(c *gin.Context) {
+	if uuid, err := controller.participantAppService.StartFATEFederationDiagnostics(c.Param("uuid")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: uuid,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// listFATEDiagnostics returns the diagnostics runs of a FATE federation
+//
+// @Summary Get the diagnostics list of the specified FATE federation, without the reports
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                                                          true "federation UUID"
+// @Success 200  {object} GeneralResponse{data=[]service.FATEFederationDiagnosticsDetail} "Success"
+// @Failure 401  {object} GeneralResponse                                                 "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int}                                       "Internal server error"
+// @Router  /federation/fate/{uuid}/diagnostics [get]
+func (controller *FederationController) listFATEDiagnostics(c *gin.Context) {
+	if diagnosticsList, err := controller.participantAppService.ListFATEFederationDiagnostics(c.Param("uuid")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: diagnosticsList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getFATEDiagnostics returns the status of a diagnostics run and its report
+//
+// @Summary Get the status of a diagnostics run, and the connectivity report once it succeeded
+// @Tags    Federation
+// @Produce json
+// @Param   uuid            path     string                                                        true "federation UUID"
+// @Param   diagnosticsUUID path     string                                                        true "diagnostics UUID"
+// @Success 200             {object} GeneralResponse{data=service.FATEFederationDiagnosticsDetail} "Success"
+// @Failure 401             {object} GeneralResponse                                               "Unauthorized operation"
+// @Failure 500             {object} GeneralResponse{code=int}                                     "Internal server error"
+// @Router  /federation/fate/{uuid}/diagnostics/{diagnosticsUUID} [get]
+func (controller *FederationController) getFATEDiagnostics(c *gin.Context) {
+	if diagnostics, err := controller.participantAppService.GetFATEFederationDiagnostics(c.Param("diagnosticsUUID")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: diagnostics,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
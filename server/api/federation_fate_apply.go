@@ -0,0 +1,74 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/FederatedAI/FedLCM/server/constants"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// applyFATE converges a FATE federation to the declarative spec
+//
+// @Summary Create or update a FATE federation and its participants from a spec in YAML or JSON format
+// @Tags    Federation
+// @Accept  json
+// @Produce json
+// @Param   spec    body     domainService.FederationFATESpec                            true  "The federation spec"
+// @Param   dry_run query    bool                                                        false "if set to true, only return the plan without applying it"
+// @Param   prune   query    bool                                                        false "if set to true, remove the participants not in the spec"
+// @Success 200     {object} GeneralResponse{data=domainService.FederationFATEApplyPlan} "Success, the data field is the plan to converge the federation"
+// @Failure 401     {object} GeneralResponse                                             "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}                                   "Internal server error"
+// @Router  /federation/fate/apply [post]
+func (controller *FederationController) applyFATE(c *gin.Context) {
+	if plan, err := func() (*domainService.FederationFATEApplyPlan, error) {
+		dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+		if err != nil {
+			return nil, err
+		}
+		prune, err := strconv.ParseBool(c.DefaultQuery("prune", "false"))
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(c.Request.Body, 1048576))
+		if err != nil {
+			return nil, err
+		}
+		spec := &domainService.FederationFATESpec{}
+		if err := yaml.Unmarshal(content, spec); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the spec")
+		}
+		return controller.participantAppService.ApplyFATEFederation(spec, dryRun, prune)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: plan,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/FederatedAI/FedLCM/server/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// failoverFATEExchange fails over the clusters using the exchange to their standby exchanges
+//
+// @Summary Switch the routing of all the clusters using the exchange as their primary exchange to their standby exchanges
+// @Tags    Federation
+// @Produce json
+// @Param   uuid         path     string                    true "federation UUID"
+// @Param   exchangeUUID path     string                    true "exchange UUID"
+// @Success 200          {object} GeneralResponse           "Success"
+// @Failure 401          {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500          {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/exchange/{exchangeUUID}/failover [post]
+func (controller *FederationController) failoverFATEExchange(c *gin.Context) {
+	exchangeUUID := c.Param("exchangeUUID")
+	if err := controller.participantAppService.FailoverFATEExchange(exchangeUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// failoverFATECluster fails over the cluster to its standby exchange
+//
+// @Summary Switch the routing of the cluster to its standby exchange, the original primary exchange becomes the standby
+// @Tags    Federation
+// @Produce json
+// @Param   uuid        path     string                    true "federation UUID"
+// @Param   clusterUUID path     string                    true "cluster UUID"
+// @Success 200         {object} GeneralResponse           "Success"
+// @Failure 401         {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/cluster/{clusterUUID}/failover [post]
+func (controller *FederationController) failoverFATECluster(c *gin.Context) {
+	clusterUUID := c.Param("clusterUUID")
+	if err := controller.participantAppService.FailoverFATECluster(clusterUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/constants"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/gin-gonic/gin"
+)
+
+// listFATESizingProfile returns the sizing profiles of FATE clusters
+//
+// @Summary Get the built-in and custom sizing profile list of FATE clusters
+// @Tags    Federation
+// @Produce json
+// @Success 200 {object} GeneralResponse{data=[]service.FATESizingProfile} "Success"
+// @Failure 401 {object} GeneralResponse                                   "Unauthorized operation"
+// @Failure 500 {object} GeneralResponse{code=int}                         "Internal server error"
+// @Router  /federation/fate/sizing-profile [get]
+func (controller *FederationController) listFATESizingProfile(c *gin.Context) {
+	if profileList, err := controller.participantAppService.ListFATESizingProfiles(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: profileList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getFATESizingProfile returns the settings of a sizing profile
+//
+// @Summary Get the settings of a FATE cluster sizing profile
+// @Tags    Federation
+// @Produce json
+// @Param   profileUUID path     string                                         true "sizing profile UUID"
+// @Success 200         {object} GeneralResponse{data=service.FATESizingProfile} "Success"
+// @Failure 401         {object} GeneralResponse                                 "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int}                       "Internal server error"
+// @Router  /federation/fate/sizing-profile/{profileUUID} [get]
+func (controller *FederationController) getFATESizingProfile(c *gin.Context) {
+	if profile, err := controller.participantAppService.GetFATESizingProfile(c.Param("profileUUID")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: profile,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// createFATESizingProfile creates a custom sizing profile
+//
+// @Summary Create a custom FATE cluster sizing profile
+// @Tags    Federation
+// @Produce json
+// @Param   profile body     domainService.ParticipantFATESizingProfileRequest true "The profile settings, zero values mean keeping the chart defaults"
+// @Success 200     {object} GeneralResponse{data=string}                      "Success, the data field is the uuid of the profile"
+// @Failure 401     {object} GeneralResponse                                   "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}                         "Internal server error"
+// @Router  /federation/fate/sizing-profile [post]
+func (controller *FederationController) createFATESizingProfile(c *gin.Context) {
+	if uuid, err := func() (string, error) {
+		req := &domainService.ParticipantFATESizingProfileRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return "", err
+		}
+		return controller.participantAppService.CreateFATESizingProfile(req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: uuid,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// updateFATESizingProfile updates a custom sizing profile
+//
+// @Summary Update the description and settings of a custom FATE cluster sizing profile, the clusters using it are not changed until they are resized
+// @Tags    Federation
+// @Produce json
+// @Param   profileUUID path     string                                            true "sizing profile UUID"
+// @Param   profile     body     domainService.ParticipantFATESizingProfileRequest true "The profile settings, the name field is not used"
+// @Success 200         {object} GeneralResponse                                   "Success"
+// @Failure 401         {object} GeneralResponse                                   "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int}                         "Internal server error"
+// @Router  /federation/fate/sizing-profile/{profileUUID} [put]
+func (controller *FederationController) updateFATESizingProfile(c *gin.Context) {
+	if err := func() error {
+		req := &domainService.ParticipantFATESizingProfileRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.participantAppService.UpdateFATESizingProfile(c.Param("profileUUID"), req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// deleteFATESizingProfile deletes a custom sizing profile
+//
+// @Summary Delete a custom FATE cluster sizing profile that is not used by any cluster
+// @Tags    Federation
+// @Produce json
+// @Param   profileUUID path     string                    true "sizing profile UUID"
+// @Success 200         {object} GeneralResponse           "Success"
+// @Failure 401         {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/sizing-profile/{profileUUID} [delete]
+func (controller *FederationController) deleteFATESizingProfile(c *gin.Context) {
+	if err := controller.participantAppService.DeleteFATESizingProfile(c.Param("profileUUID")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// resizeFATECluster applies a sizing profile to a FATE cluster
+//
+// @Summary Apply a sizing profile to the FATE cluster and update the cluster deployment
+// @Tags    Federation
+// @Produce json
+// @Param   uuid        path     string                           true "federation UUID"
+// @Param   clusterUUID path     string                           true "cluster UUID"
+// @Param   request     body     service.FATEClusterResizeRequest true "The sizing profile to apply"
+// @Success 200         {object} GeneralResponse                  "Success"
+// @Failure 401         {object} GeneralResponse                  "Unauthorized operation"
+// @Failure 500         {object} GeneralResponse{code=int}        "Internal server error"
+// @Router  /federation/fate/{uuid}/cluster/{clusterUUID}/resize [post]
+func (controller *FederationController) resizeFATECluster(c *gin.Context) {
+	if err := func() error {
+		req := &service.FATEClusterResizeRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.participantAppService.ResizeFATECluster(c.Param("clusterUUID"), req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
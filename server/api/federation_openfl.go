@@ -317,7 +317,8 @@ func (controller *FederationController) deleteOpenFLDirector(c *gin.Context) {
 // @Summary Get participant list of the specified OpenFL federation
 // @Tags    Federation
 // @Produce json
-// @Param   uuid path     string                                                          true "federation UUID"
+// @Param   uuid     path     string                                                          true  "federation UUID"
+// @Param   selector query    string                                                          false "label selector to filter the envoys, e.g. region=eu,gpu!=true"
 // @Success 200  {object} GeneralResponse{data=service.ParticipantOpenFLListInFederation} "Success"
 // @Failure 401  {object} GeneralResponse                                                 "Unauthorized operation"
 // @Failure 500  {object} GeneralResponse{code=int}                                       "Internal server error"
@@ -325,7 +326,7 @@ func (controller *FederationController) deleteOpenFLDirector(c *gin.Context) {
 func (controller *FederationController) getOpenFLParticipant(c *gin.Context) {
 	if participants, err := func() (*service.ParticipantOpenFLListInFederation, error) {
 		federationUUID := c.Param("uuid")
-		return controller.participantAppService.GetOpenFLParticipantList(federationUUID)
+		return controller.participantAppService.GetOpenFLParticipantList(federationUUID, c.Query("selector"))
 	}(); err != nil {
 		resp := &GeneralResponse{
 			Code:    constants.RespInternalErr,
@@ -374,18 +375,19 @@ func (controller *FederationController) getOpenFLDirector(c *gin.Context) {
 // @Summary Process Envoy registration request
 // @Tags    Federation
 // @Produce json
-// @Param   uuid                path     string                                            true "federation UUID"
-// @Param   registrationRequest body     service.ParticipantOpenFLEnvoyRegistrationRequest true "The creation requests"
-// @Success 200                 {object} GeneralResponse                                   "Success, the data field is the created director's uuid"
-// @Failure 401                 {object} GeneralResponse                                   "Unauthorized operation"
-// @Failure 500                 {object} GeneralResponse{code=int}                         "Internal server error"
+// @Param   uuid                path     string                                                        true "federation UUID"
+// @Param   registrationRequest body     service.ParticipantOpenFLEnvoyRegistrationRequest             true "The creation requests"
+// @Success 200                 {object} GeneralResponse{data=service.ParticipantRegistrationResponse} "Success, the data field contains the created envoy's uuid and the credential to manage it"
+// @Failure 401                 {object} GeneralResponse                                               "Unauthorized operation"
+// @Failure 500                 {object} GeneralResponse{code=int}                                     "Internal server error"
 // @Router  /federation/openfl/envoy/register [post]
 func (controller *FederationController) registerOpenFLEnvoy(c *gin.Context) {
-	if uuid, err := func() (string, error) {
+	if registration, err := func() (*domainService.ParticipantRegistrationResponse, error) {
 		req := &domainService.ParticipantOpenFLEnvoyRegistrationRequest{}
 		if err := c.ShouldBindJSON(req); err != nil {
-			return "", err
+			return nil, err
 		}
+		req.SourceAddress = c.ClientIP()
 		return controller.participantAppService.HandleOpenFLEnvoyRegistration(req)
 	}(); err != nil {
 		resp := &GeneralResponse{
@@ -396,7 +398,7 @@ func (controller *FederationController) registerOpenFLEnvoy(c *gin.Context) {
 	} else {
 		resp := &GeneralResponse{
 			Code: constants.RespNoErr,
-			Data: uuid,
+			Data: registration,
 		}
 		c.JSON(http.StatusOK, resp)
 	}
@@ -464,13 +466,13 @@ func (controller *FederationController) getOpenFLEnvoy(c *gin.Context) {
 	}
 }
 
-// getOpenFLEnvoyWithToken returns detailed information of a OpenFL envoy, if the provided token is valid
+// getOpenFLEnvoyWithToken returns detailed information of a OpenFL envoy, if the provided credential is valid
 //
-// @Summary Get specific info of OpenFL envoy, by providing the envoy uuid and token string
+// @Summary Get specific info of OpenFL envoy, by providing the envoy uuid and the credential returned by the registration
 // @Tags    Federation
 // @Produce json
 // @Param   uuid  path     string                                          true "envoy UUID"
-// @Param   token query    string                                          true "token string"
+// @Param   token query    string                                          true "the credential returned by the registration"
 // @Success 200   {object} GeneralResponse{data=service.OpenFLEnvoyDetail} "Success"
 // @Failure 401   {object} GeneralResponse                                 "Unauthorized operation"
 // @Failure 500   {object} GeneralResponse{code=int}                       "Internal server error"
@@ -492,3 +494,131 @@ func (controller *FederationController) getOpenFLEnvoyWithToken(c *gin.Context)
 		c.JSON(http.StatusOK, resp)
 	}
 }
+
+// unregisterOpenFLEnvoy removes the envoy on behalf of its device agent, the credential in the request is used for authentication
+//
+// @Summary Unregister and uninstall an OpenFL envoy, by providing the envoy uuid and its credential
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                                    true "envoy UUID"
+// @Param   request body     domainService.ParticipantOpenFLEnvoyUnregistrationRequest true "The credential and whether to remove the envoy forcefully"
+// @Success 200     {object} GeneralResponse                                           "Success"
+// @Failure 500     {object} GeneralResponse{code=int}                                 "Internal server error"
+// @Router  /federation/openfl/envoy/{uuid}/unregister [post]
+func (controller *FederationController) unregisterOpenFLEnvoy(c *gin.Context) {
+	envoyUUID := c.Param("uuid")
+	if err := func() error {
+		req := &domainService.ParticipantOpenFLEnvoyUnregistrationRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.participantAppService.UnregisterOpenFLEnvoy(envoyUUID, req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// heartbeatOpenFLEnvoy records the heartbeat and device health info reported by the device agent
+//
+// @Summary Report the heartbeat of an OpenFL envoy, by providing the envoy uuid and its credential
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                               true "envoy UUID"
+// @Param   request body     domainService.ParticipantOpenFLEnvoyHeartbeatRequest true "The credential and the device health info"
+// @Success 200     {object} GeneralResponse                                      "Success"
+// @Failure 500     {object} GeneralResponse{code=int}                            "Internal server error"
+// @Router  /federation/openfl/envoy/{uuid}/heartbeat [post]
+func (controller *FederationController) heartbeatOpenFLEnvoy(c *gin.Context) {
+	envoyUUID := c.Param("uuid")
+	if err := func() error {
+		req := &domainService.ParticipantOpenFLEnvoyHeartbeatRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.participantAppService.HandleOpenFLEnvoyHeartbeat(envoyUUID, req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// reconfigureOpenFLEnvoy updates the envoy config and the shard descriptor python files of an envoy
+//
+// @Summary Reconfigure an OpenFL envoy, by providing the envoy uuid and its credential
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                                 true "envoy UUID"
+// @Param   request body     domainService.ParticipantOpenFLEnvoyReconfigureRequest true "The credential, the new envoy config and python files"
+// @Success 200     {object} GeneralResponse                                        "Success"
+// @Failure 500     {object} GeneralResponse{code=int}                              "Internal server error"
+// @Router  /federation/openfl/envoy/{uuid}/reconfigure [post]
+func (controller *FederationController) reconfigureOpenFLEnvoy(c *gin.Context) {
+	envoyUUID := c.Param("uuid")
+	if err := func() error {
+		req := &domainService.ParticipantOpenFLEnvoyReconfigureRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.participantAppService.ReconfigureOpenFLEnvoy(envoyUUID, req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// upgradeOpenFLEnvoy upgrades an envoy to a newer chart version
+//
+// @Summary Upgrade an OpenFL envoy, by providing the envoy uuid and its credential
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                             true "envoy UUID"
+// @Param   request body     domainService.ParticipantOpenFLEnvoyUpgradeRequest true "The credential and the version to upgrade to"
+// @Success 200     {object} GeneralResponse                                    "Success"
+// @Failure 500     {object} GeneralResponse{code=int}                          "Internal server error"
+// @Router  /federation/openfl/envoy/{uuid}/upgrade [post]
+func (controller *FederationController) upgradeOpenFLEnvoy(c *gin.Context) {
+	envoyUUID := c.Param("uuid")
+	if err := func() error {
+		req := &domainService.ParticipantOpenFLEnvoyUpgradeRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return err
+		}
+		return controller.participantAppService.UpgradeOpenFLEnvoy(envoyUUID, req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/FederatedAI/FedLCM/server/constants"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getOpenFLDirectorDeploymentStatus returns the status of the chart release of an OpenFL director
+//
+// @Summary Get the status of the chart release of an OpenFL director, from KubeFATE or helm according to the deployment method
+// @Tags    Federation
+// @Produce json
+// @Param   uuid         path     string                                                                true "federation UUID"
+// @Param   directorUUID path     string                                                                true "director UUID"
+// @Success 200          {object} GeneralResponse{data=domainService.ParticipantOpenFLDeploymentStatus} "Success"
+// @Failure 401          {object} GeneralResponse                                                       "Unauthorized operation"
+// @Failure 500          {object} GeneralResponse{code=int}                                             "Internal server error"
+// @Router  /federation/openfl/{uuid}/director/{directorUUID}/deployment [get]
+func (controller *FederationController) getOpenFLDirectorDeploymentStatus(c *gin.Context) {
+	controller.getOpenFLParticipantDeploymentStatus(c, c.Param("directorUUID"))
+}
+
+// getOpenFLEnvoyDeploymentStatus returns the status of the chart release of an OpenFL envoy
+//
+// @Summary Get the status of the chart release of an OpenFL envoy, from KubeFATE or helm according to the deployment method
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                                                                true "federation UUID"
+// @Param   envoyUUID path     string                                                                true "envoy UUID"
+// @Success 200       {object} GeneralResponse{data=domainService.ParticipantOpenFLDeploymentStatus} "Success"
+// @Failure 401       {object} GeneralResponse                                                       "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int}                                             "Internal server error"
+// @Router  /federation/openfl/{uuid}/envoy/{envoyUUID}/deployment [get]
+func (controller *FederationController) getOpenFLEnvoyDeploymentStatus(c *gin.Context) {
+	controller.getOpenFLParticipantDeploymentStatus(c, c.Param("envoyUUID"))
+}
+
+func (controller *FederationController) getOpenFLParticipantDeploymentStatus(c *gin.Context, participantUUID string) {
+	if status, err := func() (*domainService.ParticipantOpenFLDeploymentStatus, error) {
+		return controller.participantAppService.GetOpenFLParticipantDeploymentStatus(c.Param("uuid"), participantUUID)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: status,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FederatedAI/FedLCM/server/constants"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getOpenFLDirectorExperiments returns the connected envoys and the experiments of an OpenFL director
+//
+// @Summary Get the connected envoys and the experiments of an OpenFL director, queried from the director API
+// @Tags    Federation
+// @Produce json
+// @Param   uuid         path     string                                                        true "federation UUID"
+// @Param   directorUUID path     string                                                        true "director UUID"
+// @Success 200          {object} GeneralResponse{data=domainService.OpenFLDirectorExperiments} "Success"
+// @Failure 401          {object} GeneralResponse                                               "Unauthorized operation"
+// @Failure 500          {object} GeneralResponse{code=int}                                     "Internal server error"
+// @Router  /federation/openfl/{uuid}/director/{directorUUID}/experiments [get]
+func (controller *FederationController) getOpenFLDirectorExperiments(c *gin.Context) {
+	if experiments, err := controller.participantAppService.GetOpenFLDirectorExperiments(c.Param("uuid"), c.Param("directorUUID")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: experiments,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getOpenFLDirectorExperimentDetail returns the status, metrics and director logs of an experiment
+//
+// @Summary Get the status, metrics and recent director logs of an experiment
+// @Tags    Federation
+// @Produce json
+// @Param   uuid           path     string                                                             true  "federation UUID"
+// @Param   directorUUID   path     string                                                             true  "director UUID"
+// @Param   experimentName path     string                                                             true  "experiment name"
+// @Param   tail           query    int                                                                false "number of director log lines to return, default 200"
+// @Success 200            {object} GeneralResponse{data=domainService.OpenFLDirectorExperimentDetail} "Success"
+// @Failure 401            {object} GeneralResponse                                                    "Unauthorized operation"
+// @Failure 500            {object} GeneralResponse{code=int}                                          "Internal server error"
+// @Router  /federation/openfl/{uuid}/director/{directorUUID}/experiments/{experimentName} [get]
+func (controller *FederationController) getOpenFLDirectorExperimentDetail(c *gin.Context) {
+	if detail, err := func() (*domainService.OpenFLDirectorExperimentDetail, error) {
+		var tailLines int64
+		if tail := c.Query("tail"); tail != "" {
+			var err error
+			if tailLines, err = strconv.ParseInt(tail, 10, 64); err != nil {
+				return nil, err
+			}
+		}
+		return controller.participantAppService.GetOpenFLDirectorExperimentDetail(c.Param("uuid"), c.Param("directorUUID"), c.Param("experimentName"), tailLines)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: detail,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
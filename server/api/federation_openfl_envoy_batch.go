@@ -0,0 +1,147 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/constants"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/gin-gonic/gin"
+)
+
+// updateOpenFLEnvoyLabels replaces the labels of an OpenFL envoy
+//
+// @Summary Update the labels of an OpenFL envoy
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   envoyUUID path     string                    true "envoy UUID"
+// @Param   labels    body     valueobject.Labels        true "The new labels"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/openfl/{uuid}/envoy/{envoyUUID}/labels [put]
+func (controller *FederationController) updateOpenFLEnvoyLabels(c *gin.Context) {
+	envoyUUID := c.Param("envoyUUID")
+	if err := func() error {
+		labels := valueobject.Labels{}
+		if err := c.ShouldBindJSON(&labels); err != nil {
+			return err
+		}
+		return controller.participantAppService.UpdateOpenFLEnvoyLabels(envoyUUID, labels)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// createOpenFLEnvoyBatchOperation starts an operation on all the envoys matching the label selector
+//
+// @Summary Remove, upgrade or reconfigure the OpenFL envoys matching the label selector
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                           true "federation UUID"
+// @Param   request body     domainService.ParticipantOpenFLEnvoyBatchRequest true "The selector and the operation, type 1: remove 2: upgrade 3: reconfigure 4: shard descriptor rollout"
+// @Success 200     {object} GeneralResponse                                  "Success, the data field is the created operation's uuid"
+// @Failure 401     {object} GeneralResponse                                  "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}                        "Internal server error"
+// @Router  /federation/openfl/{uuid}/envoy/batch [post]
+func (controller *FederationController) createOpenFLEnvoyBatchOperation(c *gin.Context) {
+	if uuid, err := func() (string, error) {
+		req := &domainService.ParticipantOpenFLEnvoyBatchRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return "", err
+		}
+		return controller.participantAppService.CreateOpenFLEnvoyBatchOperation(c.Param("uuid"), req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: uuid,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// listOpenFLEnvoyBatchOperation returns the envoy batch operations of an OpenFL federation
+//
+// @Summary Get the envoy batch operation list of the specified OpenFL federation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                                                          true "federation UUID"
+// @Success 200  {object} GeneralResponse{data=[]service.OpenFLEnvoyBatchOperationDetail} "Success"
+// @Failure 401  {object} GeneralResponse                                                 "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int}                                       "Internal server error"
+// @Router  /federation/openfl/{uuid}/envoy/batch [get]
+func (controller *FederationController) listOpenFLEnvoyBatchOperation(c *gin.Context) {
+	if operationList, err := controller.participantAppService.ListOpenFLEnvoyBatchOperations(c.Param("uuid")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: operationList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getOpenFLEnvoyBatchOperation returns the per-envoy progress of a batch operation
+//
+// @Summary Get the progress of an envoy batch operation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                                                        true "federation UUID"
+// @Param   batchUUID path     string                                                        true "batch operation UUID"
+// @Success 200       {object} GeneralResponse{data=service.OpenFLEnvoyBatchOperationDetail} "Success"
+// @Failure 401       {object} GeneralResponse                                               "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int}                                     "Internal server error"
+// @Router  /federation/openfl/{uuid}/envoy/batch/{batchUUID} [get]
+func (controller *FederationController) getOpenFLEnvoyBatchOperation(c *gin.Context) {
+	if operation, err := func() (*service.OpenFLEnvoyBatchOperationDetail, error) {
+		return controller.participantAppService.GetOpenFLEnvoyBatchOperation(c.Param("batchUUID"))
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: operation,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
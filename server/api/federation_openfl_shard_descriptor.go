@@ -0,0 +1,154 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// createOpenFLShardDescriptorVersion creates a new shard descriptor version of an OpenFL federation
+//
+// @Summary Create a new shard descriptor version, which will be used by new envoys
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                              true "federation UUID"
+// @Param   request body     service.OpenFLShardDescriptorVersionCreationRequest true "The shard descriptor config"
+// @Success 200     {object} GeneralResponse{data=int}                           "Success, the data field is the created version"
+// @Failure 401     {object} GeneralResponse                                     "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}                           "Internal server error"
+// @Router  /federation/openfl/{uuid}/shard-descriptor [post]
+func (controller *FederationController) createOpenFLShardDescriptorVersion(c *gin.Context) {
+	if version, err := func() (uint, error) {
+		req := &service.OpenFLShardDescriptorVersionCreationRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return 0, err
+		}
+		return controller.federationApp.CreateOpenFLShardDescriptorVersion(c.Param("uuid"), req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: version,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// listOpenFLShardDescriptorVersion returns the shard descriptor versions of an OpenFL federation
+//
+// @Summary Get the shard descriptor version list of the specified OpenFL federation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                                                               true "federation UUID"
+// @Success 200  {object} GeneralResponse{data=[]service.OpenFLShardDescriptorVersionListItem} "Success"
+// @Failure 401  {object} GeneralResponse                                                      "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int}                                            "Internal server error"
+// @Router  /federation/openfl/{uuid}/shard-descriptor [get]
+func (controller *FederationController) listOpenFLShardDescriptorVersion(c *gin.Context) {
+	if versionList, err := controller.federationApp.ListOpenFLShardDescriptorVersions(c.Param("uuid")); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: versionList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getOpenFLShardDescriptorVersion returns the config of a shard descriptor version and the envoys using it
+//
+// @Summary Get the config of a shard descriptor version and the envoys it is deployed to
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                                           true "federation UUID"
+// @Param   version path     int                                                              true "shard descriptor version"
+// @Success 200     {object} GeneralResponse{data=service.OpenFLShardDescriptorVersionDetail} "Success"
+// @Failure 401     {object} GeneralResponse                                                  "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}                                        "Internal server error"
+// @Router  /federation/openfl/{uuid}/shard-descriptor/{version} [get]
+func (controller *FederationController) getOpenFLShardDescriptorVersion(c *gin.Context) {
+	if detail, err := func() (*service.OpenFLShardDescriptorVersionDetail, error) {
+		version, err := strconv.ParseUint(c.Param("version"), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return controller.federationApp.GetOpenFLShardDescriptorVersion(c.Param("uuid"), uint(version))
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: detail,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// rolloutOpenFLShardDescriptorVersion deploys a shard descriptor version to the envoys matching the label selector
+//
+// @Summary Deploy a shard descriptor version to the envoys matching the label selector and restart them
+// @Tags    Federation
+// @Produce json
+// @Param   uuid    path     string                                      true "federation UUID"
+// @Param   version path     int                                         true "shard descriptor version"
+// @Param   request body     service.OpenFLShardDescriptorRolloutRequest true "The label selector of the envoys"
+// @Success 200     {object} GeneralResponse                             "Success, the data field is the uuid of the envoy batch operation"
+// @Failure 401     {object} GeneralResponse                             "Unauthorized operation"
+// @Failure 500     {object} GeneralResponse{code=int}                   "Internal server error"
+// @Router  /federation/openfl/{uuid}/shard-descriptor/{version}/rollout [post]
+func (controller *FederationController) rolloutOpenFLShardDescriptorVersion(c *gin.Context) {
+	if uuid, err := func() (string, error) {
+		version, err := strconv.ParseUint(c.Param("version"), 10, 32)
+		if err != nil {
+			return "", err
+		}
+		req := &service.OpenFLShardDescriptorRolloutRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return "", err
+		}
+		return controller.participantAppService.RolloutOpenFLShardDescriptor(c.Param("uuid"), uint(version), req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: uuid,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/constants"
+	domainService "github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/gin-gonic/gin"
+)
+
+// listFATEToken returns the registration token list of a FATE federation
+//
+// @Summary Get registration token list of the specified FATE federation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                                                      true "federation UUID"
+// @Success 200  {object} GeneralResponse{data=[]service.RegistrationTokenFATEListItem} "Success"
+// @Failure 401  {object} GeneralResponse                                             "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int}                                   "Internal server error"
+// @Router  /federation/fate/{uuid}/token [get]
+func (controller *FederationController) listFATEToken(c *gin.Context) {
+	federationUUID := c.Param("uuid")
+	if tokenList, err := controller.federationApp.ListFATEToken(federationUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: tokenList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// createFATEToken creates a new registration token for a FATE federation
+//
+// @Summary Create a new registration token for a FATE federation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid  path     string                                 true "federation UUID"
+// @Param   token body     service.RegistrationTokenFATEBasicInfo true "The token info"
+// @Success 200   {object} GeneralResponse                        "Success"
+// @Failure 401   {object} GeneralResponse                        "Unauthorized operation"
+// @Failure 500   {object} GeneralResponse{code=int}              "Internal server error"
+// @Router  /federation/fate/{uuid}/token [post]
+func (controller *FederationController) createFATEToken(c *gin.Context) {
+	if err := func() error {
+		creationInfo := &service.RegistrationTokenFATEBasicInfo{}
+		if err := c.ShouldBindJSON(creationInfo); err != nil {
+			return err
+		}
+		federationUUID := c.Param("uuid")
+		return controller.federationApp.GenerateFATEToken(creationInfo, federationUUID)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// deleteFATEToken deletes the specified FATE federation registration token
+//
+// @Summary Delete a FATE federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/token/{tokenUUID} [delete]
+func (controller *FederationController) deleteFATEToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.DeleteFATEToken(uuid, federationUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// enableFATEToken enables the specified FATE federation registration token
+//
+// @Summary Enable a FATE federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/token/{tokenUUID}/enable [post]
+func (controller *FederationController) enableFATEToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.SetFATETokenDisabled(uuid, federationUUID, false); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// disableFATEToken disables the specified FATE federation registration token, registered participants are not affected
+//
+// @Summary Disable a FATE federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/token/{tokenUUID}/disable [post]
+func (controller *FederationController) disableFATEToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.SetFATETokenDisabled(uuid, federationUUID, true); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// rotateFATEToken replaces the token string of the specified FATE federation registration token
+//
+// @Summary Rotate a FATE federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/fate/{uuid}/token/{tokenUUID}/rotate [post]
+func (controller *FederationController) rotateFATEToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.RotateFATEToken(uuid, federationUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// enableOpenFLToken enables the specified OpenFL federation registration token
+//
+// @Summary Enable an OpenFL federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/openfl/{uuid}/token/{tokenUUID}/enable [post]
+func (controller *FederationController) enableOpenFLToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.SetOpenFLTokenDisabled(uuid, federationUUID, false); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// disableOpenFLToken disables the specified OpenFL federation registration token, registered participants are not affected
+//
+// @Summary Disable an OpenFL federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/openfl/{uuid}/token/{tokenUUID}/disable [post]
+func (controller *FederationController) disableOpenFLToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.SetOpenFLTokenDisabled(uuid, federationUUID, true); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// rotateOpenFLToken replaces the token string of the specified OpenFL federation registration token
+//
+// @Summary Rotate an OpenFL federation registration token
+// @Tags    Federation
+// @Produce json
+// @Param   uuid      path     string                    true "federation UUID"
+// @Param   tokenUUID path     string                    true "token UUID"
+// @Success 200       {object} GeneralResponse           "Success"
+// @Failure 401       {object} GeneralResponse           "Unauthorized operation"
+// @Failure 500       {object} GeneralResponse{code=int} "Internal server error"
+// @Router  /federation/openfl/{uuid}/token/{tokenUUID}/rotate [post]
+func (controller *FederationController) rotateOpenFLToken(c *gin.Context) {
+	uuid := c.Param("tokenUUID")
+	federationUUID := c.Param("uuid")
+	if err := controller.federationApp.RotateOpenFLToken(uuid, federationUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// listRegistrationRecords returns the token based self-registration records of a federation
+//
+// @Summary Get the self-registration audit records of the specified federation
+// @Tags    Federation
+// @Produce json
+// @Param   uuid path     string                                                   true "federation UUID"
+// @Success 200  {object} GeneralResponse{data=[]service.RegistrationRecordListItem} "Success"
+// @Failure 401  {object} GeneralResponse                                          "Unauthorized operation"
+// @Failure 500  {object} GeneralResponse{code=int}                                "Internal server error"
+// @Router  /federation/fate/{uuid}/registration [get]
+// @Router  /federation/openfl/{uuid}/registration [get]
+func (controller *FederationController) listRegistrationRecords(c *gin.Context) {
+	federationUUID := c.Param("uuid")
+	if recordList, err := controller.federationApp.ListRegistrationRecords(federationUUID); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: recordList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// registerFATECluster handles the FATE cluster self-registration request, the token string in the request is used for authentication
+//
+// @Summary Process FATE cluster registration request
+// @Tags    Federation
+// @Produce json
+// @Param   registrationRequest body     domainService.ParticipantFATEClusterRegistrationRequest             true "The registration request"
+// @Success 200                 {object} GeneralResponse{data=domainService.ParticipantRegistrationResponse} "Success, the data field contains the created cluster's uuid and the credential to access it"
+// @Failure 500                 {object} GeneralResponse{code=int}                                           "Internal server error"
+// @Router  /federation/fate/cluster/register [post]
+func (controller *FederationController) registerFATECluster(c *gin.Context) {
+	if registration, err := func() (*domainService.ParticipantRegistrationResponse, error) {
+		req := &domainService.ParticipantFATEClusterRegistrationRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			return nil, err
+		}
+		req.SourceAddress = c.ClientIP()
+		return controller.participantAppService.RegisterFATECluster(req)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: registration,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getFATEClusterWithToken returns detailed information of a self-registered FATE cluster, if the provided credential is valid
+//
+// @Summary Get specific info of a self-registered FATE cluster, by providing the cluster uuid and the credential returned by the registration
+// @Tags    Federation
+// @Produce json
+// @Param   uuid  path     string                                          true "cluster UUID"
+// @Param   token query    string                                          true "the credential returned by the registration"
+// @Success 200   {object} GeneralResponse{data=service.FATEClusterDetail} "Success"
+// @Failure 500   {object} GeneralResponse{code=int}                       "Internal server error"
+// @Router  /federation/fate/cluster/register/{uuid} [get]
+func (controller *FederationController) getFATEClusterWithToken(c *gin.Context) {
+	token := c.Query("token")
+	clusterUUID := c.Param("uuid")
+	if clusterDetail, err := controller.participantAppService.GetFATEClusterDetailWithTokenVerification(clusterUUID, token); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: clusterDetail,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
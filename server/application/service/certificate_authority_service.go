@@ -24,6 +24,7 @@ import (
 
 	"github.com/FederatedAI/FedLCM/server/domain/entity"
 	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
@@ -33,6 +34,7 @@ import (
 // CertificateAuthorityApp provides functions to manage the Certificate Authority
 type CertificateAuthorityApp struct {
 	CertificateAuthorityRepo repo.CertificateAuthorityRepository
+	CertificateRepo          repo.CertificateRepository
 }
 
 // CertificateAuthorityDetail contains the detail info of Certificate Authority
@@ -52,6 +54,9 @@ type CertificateAuthorityEditableItem struct {
 	Config      map[string]interface{}          `json:"config"`
 }
 
+// revocationInfoValidity is the validity period of the published CRL and OCSP responses
+const revocationInfoValidity = 24 * time.Hour
+
 // CertificateAuthorityStatus is the certificate authority status
 type CertificateAuthorityStatus uint8
 
@@ -74,15 +79,12 @@ func (app *CertificateAuthorityApp) Get() (*CertificateAuthorityDetail, error) {
 	// check the ca status
 	caStatus := CertificateAuthorityStatusUnknown
 	caStatusMessage := ""
-	switch ca.Type {
-	case entity.CertificateAuthorityTypeStepCA:
-		_, err = ca.Client()
-		if err != nil {
-			caStatus = CertificateAuthorityStatusUnhealthy
-			caStatusMessage = err.Error()
-		} else {
-			caStatus = CertificateAuthorityStatusHealthy
-		}
+	_, err = ca.Client()
+	if err != nil {
+		caStatus = CertificateAuthorityStatusUnhealthy
+		caStatusMessage = err.Error()
+	} else {
+		caStatus = CertificateAuthorityStatusHealthy
 	}
 
 	var config map[string]interface{}
@@ -90,6 +92,15 @@ func (app *CertificateAuthorityApp) Get() (*CertificateAuthorityDetail, error) {
 	if err != nil {
 		return nil, err
 	}
+	if ca.Type == entity.CertificateAuthorityTypeEmbedded {
+		// the private keys of the embedded CA never leave the service
+		delete(config, "encrypted_root_key_pem")
+		delete(config, "encrypted_intermediate_key_pem")
+	} else if ca.Type == entity.CertificateAuthorityTypeVault {
+		// the token is kept if it is not provided when updating the CA
+		delete(config, "token")
+		delete(config, "encrypted_token")
+	}
 	return &CertificateAuthorityDetail{
 		CertificateAuthorityEditableItem: CertificateAuthorityEditableItem{
 			Name:        ca.Name,
@@ -112,76 +123,113 @@ func (app *CertificateAuthorityApp) CreateCA(caInfo *CertificateAuthorityEditabl
 	if instance != nil {
 		return errors.Errorf("certificate authority already exists")
 	}
-	switch caInfo.Type {
-	case entity.CertificateAuthorityTypeStepCA:
-		//Decode map, remove unmapped keys
-		var config entity.CertificateAuthorityConfigurationStepCA
-		err := mapstructure.Decode(caInfo.Config, &config)
-		if err != nil {
-			return err
-		}
-		// validate URL schema
-		u, err := url.ParseRequestURI(config.ServiceURL)
-		if err != nil || u.Scheme == "" && u.Host == "" {
-			return errors.Errorf("Service URL is invalid: http:// or https:// schema is required")
-		}
-		// marshal to json
-		caConfig, err := json.Marshal(config)
-		if err != nil {
-			return err
-		}
-		ca := &entity.CertificateAuthority{
-			UUID:              uuid.NewV4().String(),
-			Name:              caInfo.Name,
-			Description:       caInfo.Description,
-			Type:              caInfo.Type,
-			ConfigurationJSON: string(caConfig),
-		}
-		// validate CA config info
-		_, err = ca.Client()
-		if err != nil {
-			return err
-		}
-		return app.CertificateAuthorityRepo.Create(ca)
+	ca, err := app.buildCA(uuid.NewV4().String(), caInfo, nil)
+	if err != nil {
+		return err
 	}
-	return errors.Errorf("unknown certificate authority type: %v", caInfo.Type)
+	return app.CertificateAuthorityRepo.Create(ca)
 }
 
 // Update changes CA settings
 func (app *CertificateAuthorityApp) Update(uuid string, caInfo *CertificateAuthorityEditableItem) error {
+	var current *entity.CertificateAuthority
+	if instance, err := app.CertificateAuthorityRepo.GetFirst(); err == nil {
+		current = instance.(*entity.CertificateAuthority)
+	}
+	ca, err := app.buildCA(uuid, caInfo, current)
+	if err != nil {
+		return err
+	}
+	return app.CertificateAuthorityRepo.UpdateByUUID(ca)
+}
+
+// buildCA validates the user provided settings and returns the CA entity. For the embedded CA, the keys of the current
+// CA are kept if it is already an embedded one, otherwise new keys are generated.
+func (app *CertificateAuthorityApp) buildCA(uuid string, caInfo *CertificateAuthorityEditableItem, current *entity.CertificateAuthority) (*entity.CertificateAuthority, error) {
+	var config interface{}
 	switch caInfo.Type {
 	case entity.CertificateAuthorityTypeStepCA:
-		var config entity.CertificateAuthorityConfigurationStepCA
+		var stepCAConfig entity.CertificateAuthorityConfigurationStepCA
 		// decode map, remove unmapped keys
-		err := mapstructure.Decode(caInfo.Config, &config)
-		if err != nil {
-			return err
+		if err := mapstructure.Decode(caInfo.Config, &stepCAConfig); err != nil {
+			return nil, err
 		}
 		// validate URL schema
-		u, err := url.ParseRequestURI(config.ServiceURL)
+		u, err := url.ParseRequestURI(stepCAConfig.ServiceURL)
 		if err != nil || u.Scheme == "" && u.Host == "" {
-			return errors.Errorf("Service URL is invalid: http:// or https:// schema is required")
+			return nil, errors.Errorf("Service URL is invalid: http:// or https:// schema is required")
 		}
-		// marshal to json
-		caConfig, err := json.Marshal(config)
-		if err != nil {
-			return err
+		config = stepCAConfig
+	case entity.CertificateAuthorityTypeEmbedded:
+		if current != nil && current.UUID == uuid && current.Type == entity.CertificateAuthorityTypeEmbedded {
+			var embeddedConfig entity.CertificateAuthorityConfigurationEmbedded
+			if err := json.Unmarshal([]byte(current.ConfigurationJSON), &embeddedConfig); err != nil {
+				return nil, err
+			}
+			// only the publishing addresses can be changed for an existing embedded CA
+			var updatedConfig entity.CertificateAuthorityConfigurationEmbedded
+			if err := mapstructure.Decode(caInfo.Config, &updatedConfig); err != nil {
+				return nil, err
+			}
+			embeddedConfig.CRLURL = updatedConfig.CRLURL
+			embeddedConfig.OCSPURL = updatedConfig.OCSPURL
+			config = embeddedConfig
+			break
 		}
-		ca := &entity.CertificateAuthority{
-			UUID:              uuid,
-			Name:              caInfo.Name,
-			Description:       caInfo.Description,
-			Type:              caInfo.Type,
-			ConfigurationJSON: string(caConfig),
+		var embeddedConfig entity.CertificateAuthorityConfigurationEmbedded
+		if err := mapstructure.Decode(caInfo.Config, &embeddedConfig); err != nil {
+			return nil, err
 		}
-		// validate CA config info
-		_, err = ca.Client()
+		generatedConfig, err := entity.GenerateEmbeddedCAConfiguration(embeddedConfig.CommonName, embeddedConfig.Organization)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		generatedConfig.CRLURL = embeddedConfig.CRLURL
+		generatedConfig.OCSPURL = embeddedConfig.OCSPURL
+		config = generatedConfig
+	case entity.CertificateAuthorityTypeVault:
+		var vaultConfig entity.CertificateAuthorityConfigurationVault
+		if err := mapstructure.Decode(caInfo.Config, &vaultConfig); err != nil {
+			return nil, err
+		}
+		if vaultConfig.Token == "" && current != nil && current.UUID == uuid && current.Type == entity.CertificateAuthorityTypeVault {
+			var currentConfig entity.CertificateAuthorityConfigurationVault
+			if err := json.Unmarshal([]byte(current.ConfigurationJSON), &currentConfig); err != nil {
+				return nil, err
+			}
+			vaultConfig.Token = currentConfig.Token
+			vaultConfig.EncryptedToken = currentConfig.EncryptedToken
 		}
-		return app.CertificateAuthorityRepo.UpdateByUUID(ca)
+		if err := vaultConfig.EncryptToken(); err != nil {
+			return nil, errors.Wrapf(err, "failed to encrypt token")
+		}
+		config = vaultConfig
+	case entity.CertificateAuthorityTypeCertManager:
+		var certManagerConfig entity.CertificateAuthorityConfigurationCertManager
+		if err := mapstructure.Decode(caInfo.Config, &certManagerConfig); err != nil {
+			return nil, err
+		}
+		config = certManagerConfig
+	default:
+		return nil, errors.Errorf("unknown certificate authority type: %v", caInfo.Type)
+	}
+	// marshal to json
+	caConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
 	}
-	return errors.Errorf("unknown certificate authority type: %v", caInfo.Type)
+	ca := &entity.CertificateAuthority{
+		UUID:              uuid,
+		Name:              caInfo.Name,
+		Description:       caInfo.Description,
+		Type:              caInfo.Type,
+		ConfigurationJSON: string(caConfig),
+	}
+	// validate CA config info
+	if _, err := ca.Client(); err != nil {
+		return nil, err
+	}
+	return ca, nil
 }
 
 // GetBuiltInCAConfig returns the config of built-in StepCA config
@@ -214,3 +262,20 @@ func (app *CertificateAuthorityApp) GetBuiltInCAConfig() (*entity.CertificateAut
 	}
 	return config, nil
 }
+
+// GetCRL returns the DER encoded CRL of the revoked certificates, signed by the embedded CA
+func (app *CertificateAuthorityApp) GetCRL() ([]byte, error) {
+	return app.getCertificateDomainService().GenerateCRL(revocationInfoValidity)
+}
+
+// GetOCSPResponse returns the DER encoded OCSP response for the DER encoded OCSP request
+func (app *CertificateAuthorityApp) GetOCSPResponse(request []byte) ([]byte, error) {
+	return app.getCertificateDomainService().GenerateOCSPResponse(request, revocationInfoValidity)
+}
+
+func (app *CertificateAuthorityApp) getCertificateDomainService() *service.CertificateService {
+	return &service.CertificateService{
+		CertificateAuthorityRepo: app.CertificateAuthorityRepo,
+		CertificateRepo:          app.CertificateRepo,
+	}
+}
@@ -20,7 +20,10 @@ import (
 
 	"github.com/FederatedAI/FedLCM/server/domain/entity"
 	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ocsp"
 )
 
 // CertificateApp provides functions to manage the certificates
@@ -32,6 +35,9 @@ type CertificateApp struct {
 	ParticipantOpenFLRepo    repo.ParticipantOpenFLRepository
 	FederationFATERepo       repo.FederationRepository
 	FederationOpenFLRepo     repo.FederationRepository
+	InfraProviderRepo        repo.InfraProviderRepository
+	EndpointKubeFATERepo     repo.EndpointRepository
+	EventRepo                repo.EventRepository
 }
 
 // CertificateListItem contains basic info of a certificate
@@ -41,9 +47,21 @@ type CertificateListItem struct {
 	SerialNumber   string                       `json:"serial_number"`
 	ExpirationDate time.Time                    `json:"expiration_date"`
 	CommonName     string                       `json:"common_name"`
+	Imported       bool                         `json:"imported"`
 	Bindings       []CertificateBindingListItem `json:"bindings"`
 }
 
+// CertificateImportRequest contains an externally issued certificate and its private key, in PEM or PKCS#12 format.
+// The PKCS#12 content is used if it is provided.
+type CertificateImportRequest struct {
+	Name           string `json:"name"`
+	CertificatePEM string `json:"certificate_pem"`
+	PrivateKeyPEM  string `json:"private_key_pem"`
+	ChainPEM       string `json:"chain_pem"`
+	PKCS12         []byte `json:"pkcs12" swaggertype:"string" format:"base64"`
+	PKCS12Password string `json:"pkcs12_password"`
+}
+
 // CertificateBindingListItem contains binding information of a certificate
 type CertificateBindingListItem struct {
 	ServiceType        entity.CertificateBindingServiceType `json:"service_type"`
@@ -70,6 +88,7 @@ func (app *CertificateApp) List() (certificateList []CertificateListItem, err er
 			SerialNumber:   domainCert.SerialNumber.String(),
 			ExpirationDate: domainCert.NotAfter,
 			CommonName:     domainCert.Subject.CommonName,
+			Imported:       domainCert.Imported,
 			Bindings:       []CertificateBindingListItem{},
 		}
 		instanceList, err = app.CertificateBindingRepo.ListByCertificateUUID(domainCert.UUID)
@@ -129,8 +148,8 @@ func getBindingOpenFLServiceDescription(serviceType entity.CertificateBindingSer
 	return fmt.Sprintf("%v service of OpenFL %v", serviceType, participant.Type)
 }
 
-// DeleteCertificate deletes the certificate which has no participants bindings
-func (app *CertificateApp) DeleteCertificate(uuid string) error {
+// DeleteCertificate deletes the certificate which has no participants bindings, and revokes it if requested
+func (app *CertificateApp) DeleteCertificate(uuid string, revoke bool) error {
 	instanceList, err := app.CertificateBindingRepo.ListByCertificateUUID(uuid)
 	if err != nil {
 		return errors.Errorf("unable to get the certificate bindings")
@@ -139,5 +158,94 @@ func (app *CertificateApp) DeleteCertificate(uuid string) error {
 	if len(bindingList) > 0 {
 		return errors.Errorf("unable to delete certificate: there is(are) %v particicant(s) still binding to this certificate", len(bindingList))
 	}
+	if revoke {
+		instance, err := app.CertificateRepo.GetByUUID(uuid)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get the certificate")
+		}
+		certService := &service.CertificateService{
+			CertificateAuthorityRepo: app.CertificateAuthorityRepo,
+			CertificateRepo:          app.CertificateRepo,
+			CertificateBindingRepo:   app.CertificateBindingRepo,
+		}
+		if err := certService.RevokeCertificate(instance.(*entity.Certificate), ocsp.Unspecified, "revoked by the administrator"); err != nil {
+			return errors.Wrapf(err, "failed to revoke the certificate")
+		}
+	}
 	return app.CertificateRepo.DeleteByUUID(uuid)
 }
+
+// ImportCertificate saves an externally issued certificate, which can be reused by participant services whose
+// certificate binding mode is "Reuse", and returns its UUID
+func (app *CertificateApp) ImportCertificate(req *CertificateImportRequest) (string, error) {
+	certService := &service.CertificateService{
+		CertificateAuthorityRepo: app.CertificateAuthorityRepo,
+		CertificateRepo:          app.CertificateRepo,
+		CertificateBindingRepo:   app.CertificateBindingRepo,
+	}
+	var cert *entity.Certificate
+	var err error
+	if len(req.PKCS12) > 0 {
+		cert, err = certService.ImportCertificatePKCS12(req.Name, req.PKCS12, req.PKCS12Password)
+	} else {
+		cert, err = certService.ImportCertificate(req.Name, req.CertificatePEM, req.PrivateKeyPEM, req.ChainPEM)
+	}
+	if err != nil {
+		return "", err
+	}
+	return cert.UUID, nil
+}
+
+// RotateCertificate renews the certificate and updates the secrets and workloads of the bound services
+func (app *CertificateApp) RotateCertificate(uuid string) error {
+	return app.getRotationDomainService().RotateCertificate(uuid)
+}
+
+// StartAutoRotation periodically renews and rotates the bound certificates expiring within the renewBefore duration
+func (app *CertificateApp) StartAutoRotation(interval, renewBefore time.Duration) {
+	log.Info().Msgf("certificate auto rotation started, checking interval: %v, renewing certificates expiring within %v", interval, renewBefore)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := app.getRotationDomainService().RotateExpiringCertificates(renewBefore); err != nil {
+				log.Err(err).Msg("error occurred when rotating expiring certificates")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (app *CertificateApp) getRotationDomainService() *service.CertificateRotationService {
+	eventService := &service.EventService{
+		EventRepo: app.EventRepo,
+	}
+	endpointService := &service.EndpointService{
+		InfraProviderKubernetesRepo: app.InfraProviderRepo,
+		EndpointKubeFATERepo:        app.EndpointKubeFATERepo,
+		ParticipantFATERepo:         app.ParticipantFATERepo,
+		ParticipantOpenFLRepo:       app.ParticipantOpenFLRepo,
+		EventService:                eventService,
+	}
+	return &service.CertificateRotationService{
+		CertificateRepo:        app.CertificateRepo,
+		CertificateBindingRepo: app.CertificateBindingRepo,
+		ParticipantFATERepo:    app.ParticipantFATERepo,
+		ParticipantOpenFLRepo:  app.ParticipantOpenFLRepo,
+		CertificateService: &service.CertificateService{
+			CertificateAuthorityRepo: app.CertificateAuthorityRepo,
+			CertificateRepo:          app.CertificateRepo,
+			CertificateBindingRepo:   app.CertificateBindingRepo,
+		},
+		EndpointService: endpointService,
+		OpenFLService: &service.ParticipantOpenFLService{
+			ParticipantOpenFLRepo: app.ParticipantOpenFLRepo,
+			InfraRepo:             app.InfraProviderRepo,
+			ParticipantService: service.ParticipantService{
+				EventService:    eventService,
+				EndpointService: endpointService,
+			},
+		},
+		EventService: eventService,
+	}
+}
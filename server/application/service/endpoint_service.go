@@ -36,6 +36,12 @@ type EndpointApp struct {
 	EventRepo                   repo.EventRepository
 }
 
+// EndpointKubeFATEUpgradeRequest contains the new KubeFATE deployment yaml, an empty yaml means the installed one with
+// the default KubeFATE version
+type EndpointKubeFATEUpgradeRequest struct {
+	KubeFATEDeploymentYAML string `json:"kubefate_deployment_yaml"`
+}
+
 // EndpointListItem contains basic information of an endpoint
 type EndpointListItem struct {
 	UUID              string                `json:"uuid"`
@@ -209,6 +215,33 @@ func (app *EndpointApp) CheckKubeFATEConnection(uuid string) error {
 	return app.getDomainService().TestKubeFATE(uuid)
 }
 
+// UpgradeKubeFATEEndpoint upgrades the KubeFATE deployment of the endpoint
+func (app *EndpointApp) UpgradeKubeFATEEndpoint(uuid string, req *EndpointKubeFATEUpgradeRequest) error {
+	_, err := app.getDomainService().UpgradeKubeFATEEndpoint(uuid, req.KubeFATEDeploymentYAML)
+	return err
+}
+
+// ProbeKubeFATEEndpoint checks the health of the endpoint and updates its status
+func (app *EndpointApp) ProbeKubeFATEEndpoint(uuid string, autoRepair bool) error {
+	_, err := app.getDomainService().ProbeKubeFATEEndpoint(uuid, autoRepair)
+	return err
+}
+
+// StartHealthMonitor periodically probes the KubeFATE endpoints and optionally repairs the missing deployments
+func (app *EndpointApp) StartHealthMonitor(interval time.Duration, autoRepair bool) {
+	log.Info().Msgf("endpoint health monitor started, checking interval: %v, auto repair: %v", interval, autoRepair)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := app.getDomainService().ProbeKubeFATEEndpoints(autoRepair); err != nil {
+				log.Err(err).Msg("error occurred when probing endpoints")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
 // GetKubeFATEDeploymentYAML returns the default yaml content for deploying KubeFATE
 func (app *EndpointApp) GetKubeFATEDeploymentYAML(namespace, serviceUsername, servicePassword, hostname string, registryConfig valueobject.KubeRegistryConfig) (string, error) {
 	return app.getDomainService().GetDeploymentYAML(namespace, serviceUsername, servicePassword, hostname, registryConfig)
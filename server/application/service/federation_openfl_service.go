@@ -29,6 +29,8 @@ type FederationOpenFLCreationRequest struct {
 	Domain                       string                             `json:"domain"`
 	UseCustomizedShardDescriptor bool                               `json:"use_customized_shard_descriptor"`
 	ShardDescriptorConfig        *valueobject.ShardDescriptorConfig `json:"shard_descriptor_config"`
+	// DeploymentMethod is how the director and envoys are deployed, 0 for KubeFATE and 1 for Helm
+	DeploymentMethod entity.FederationOpenFLDeploymentMethod `json:"deployment_method"`
 }
 
 // FederationOpenFLDetail contains specific info for an OpenFL federation
@@ -37,6 +39,8 @@ type FederationOpenFLDetail struct {
 	Domain                       string                             `json:"domain"`
 	UseCustomizedShardDescriptor bool                               `json:"use_customized_shard_descriptor"`
 	ShardDescriptorConfig        *valueobject.ShardDescriptorConfig `json:"shard_descriptor_config"`
+	// DeploymentMethod is how the director and envoys are deployed, 0 for KubeFATE and 1 for Helm
+	DeploymentMethod entity.FederationOpenFLDeploymentMethod `json:"deployment_method"`
 }
 
 // RegistrationTokenOpenFLBasicInfo contains necessary info to generate token for an OpenFL federation
@@ -47,6 +51,8 @@ type RegistrationTokenOpenFLBasicInfo struct {
 	Limit       int                `json:"limit"`
 	Used        int                `json:"used"`
 	Labels      valueobject.Labels `json:"labels"`
+	Disabled    bool               `json:"disabled"`
+	RotateOnUse bool               `json:"rotate_on_use"`
 }
 
 type RegistrationTokenOpenFLListItem struct {
@@ -68,10 +74,22 @@ func (app *FederationApp) CreateOpenFLFederation(req *FederationOpenFLCreationRe
 		Domain:                       req.Domain,
 		UseCustomizedShardDescriptor: req.UseCustomizedShardDescriptor,
 		ShardDescriptorConfig:        req.ShardDescriptorConfig,
+		DeploymentMethod:             req.DeploymentMethod,
 	}
 	if err := federation.Create(); err != nil {
 		return "", err
 	}
+	if federation.UseCustomizedShardDescriptor {
+		// the config is tracked as the first version so that envoys can be rolled back to it
+		descriptor := &entity.FederationOpenFLShardDescriptor{
+			Description: "initial version",
+			Config:      *federation.ShardDescriptorConfig,
+			Repo:        app.FederationOpenFLShardDescriptorRepo,
+		}
+		if err := descriptor.Create(federation); err != nil {
+			return "", errors.Wrap(err, "failed to save the initial shard descriptor version")
+		}
+	}
 	return federation.UUID, nil
 }
 
@@ -89,6 +107,9 @@ func (app *FederationApp) DeleteOpenFLFederation(uuid string) error {
 	if err := app.RegistrationTokenOpenFLRepo.DeleteByFederation(uuid); err != nil {
 		return errors.Wrap(err, "failed to clean up tokens")
 	}
+	if err := app.FederationOpenFLShardDescriptorRepo.DeleteByFederationUUID(uuid); err != nil {
+		return errors.Wrap(err, "failed to clean up shard descriptor versions")
+	}
 	return app.FederationOpenFLRepo.DeleteByUUID(uuid)
 }
 
@@ -110,6 +131,7 @@ func (app *FederationApp) GetOpenFLFederation(uuid string) (*FederationOpenFLDet
 		Domain:                       domainFederation.Domain,
 		UseCustomizedShardDescriptor: domainFederation.UseCustomizedShardDescriptor,
 		ShardDescriptorConfig:        domainFederation.ShardDescriptorConfig,
+		DeploymentMethod:             domainFederation.DeploymentMethod,
 	}, nil
 }
 
@@ -117,14 +139,16 @@ func (app *FederationApp) GetOpenFLFederation(uuid string) (*FederationOpenFLDet
 func (app *FederationApp) GeneratedOpenFLToken(req *RegistrationTokenOpenFLBasicInfo, federationUUID string) error {
 	token := &entity.RegistrationTokenOpenFL{
 		RegistrationToken: entity.RegistrationToken{
-			Name:        req.Name,
-			Description: req.Description,
-			Repo:        app.RegistrationTokenOpenFLRepo,
+			Name:           req.Name,
+			Description:    req.Description,
+			FederationUUID: federationUUID,
+			ExpiredAt:      req.ExpiredAt,
+			Limit:          req.Limit,
+			Disabled:       req.Disabled,
+			RotateOnUse:    req.RotateOnUse,
+			Repo:           app.RegistrationTokenOpenFLRepo,
 		},
-		ExpiredAt:      req.ExpiredAt,
-		Limit:          req.Limit,
-		Labels:         req.Labels,
-		FederationUUID: federationUUID,
+		Labels: req.Labels,
 	}
 	return token.Create()
 }
@@ -150,6 +174,8 @@ func (app *FederationApp) ListOpenFLToken(federationUUID string) ([]Registration
 				Limit:       token.Limit,
 				Used:        count,
 				Labels:      token.Labels,
+				Disabled:    token.Disabled,
+				RotateOnUse: token.RotateOnUse,
 			},
 			UUID:              token.UUID,
 			DisplayedTokenStr: token.Display(),
@@ -160,6 +186,8 @@ func (app *FederationApp) ListOpenFLToken(federationUUID string) ([]Registration
 
 // DeleteOpenFLToken removes the specified token
 func (app *FederationApp) DeleteOpenFLToken(uuid, federationUUID string) error {
-	// TODO: validate federation UUID
+	if _, err := app.loadToken(app.RegistrationTokenOpenFLRepo, uuid, federationUUID); err != nil {
+		return err
+	}
 	return app.RegistrationTokenOpenFLRepo.DeleteByUUID(uuid)
 }
@@ -0,0 +1,147 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+)
+
+// OpenFLShardDescriptorVersionCreationRequest contains the new shard descriptor config of an OpenFL federation
+type OpenFLShardDescriptorVersionCreationRequest struct {
+	Description string                            `json:"description"`
+	Config      valueobject.ShardDescriptorConfig `json:"config"`
+}
+
+// OpenFLShardDescriptorVersionListItem contains basic info of a shard descriptor version
+type OpenFLShardDescriptorVersionListItem struct {
+	UUID        string    `json:"uuid"`
+	Version     uint      `json:"version"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	// EnvoyCount is the number of envoys this version is deployed to
+	EnvoyCount int `json:"envoy_count"`
+}
+
+// OpenFLShardDescriptorVersionDetail contains the config of a shard descriptor version and the envoys using it
+type OpenFLShardDescriptorVersionDetail struct {
+	OpenFLShardDescriptorVersionListItem
+	Config     valueobject.ShardDescriptorConfig `json:"config"`
+	EnvoyUUIDs []string                          `json:"envoy_uuids"`
+}
+
+// OpenFLShardDescriptorRolloutRequest contains the envoys to deploy a shard descriptor version to
+type OpenFLShardDescriptorRolloutRequest struct {
+	// Selector is a label selector in the Kubernetes syntax, e.g. "region=eu,gpu!=true"
+	Selector string `json:"selector"`
+}
+
+// CreateOpenFLShardDescriptorVersion saves the config as the new version of the federation's shard descriptor,
+// which will be used by new envoys, and returns the version number
+func (app *FederationApp) CreateOpenFLShardDescriptorVersion(federationUUID string, req *OpenFLShardDescriptorVersionCreationRequest) (uint, error) {
+	instance, err := app.FederationOpenFLRepo.GetByUUID(federationUUID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query federation")
+	}
+	descriptor := &entity.FederationOpenFLShardDescriptor{
+		Description: req.Description,
+		Config:      req.Config,
+		Repo:        app.FederationOpenFLShardDescriptorRepo,
+	}
+	if err := descriptor.Create(instance.(*entity.FederationOpenFL)); err != nil {
+		return 0, err
+	}
+	return descriptor.Version, nil
+}
+
+// ListOpenFLShardDescriptorVersions returns the shard descriptor versions of a federation, latest version first
+func (app *FederationApp) ListOpenFLShardDescriptorVersions(federationUUID string) ([]OpenFLShardDescriptorVersionListItem, error) {
+	instanceList, err := app.FederationOpenFLShardDescriptorRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list shard descriptor versions")
+	}
+	envoyVersions, err := app.getOpenFLEnvoyShardDescriptorVersions(federationUUID)
+	if err != nil {
+		return nil, err
+	}
+	versionList := make([]OpenFLShardDescriptorVersionListItem, 0)
+	for _, descriptor := range instanceList.([]entity.FederationOpenFLShardDescriptor) {
+		versionList = append(versionList, OpenFLShardDescriptorVersionListItem{
+			UUID:        descriptor.UUID,
+			Version:     descriptor.Version,
+			Description: descriptor.Description,
+			CreatedAt:   descriptor.CreatedAt,
+			EnvoyCount:  len(envoyVersions[descriptor.Version]),
+		})
+	}
+	return versionList, nil
+}
+
+// GetOpenFLShardDescriptorVersion returns the config of a shard descriptor version and the envoys it is deployed to
+func (app *FederationApp) GetOpenFLShardDescriptorVersion(federationUUID string, version uint) (*OpenFLShardDescriptorVersionDetail, error) {
+	instance, err := app.FederationOpenFLShardDescriptorRepo.GetByFederationUUIDAndVersion(federationUUID, version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query shard descriptor version %v", version)
+	}
+	descriptor := instance.(*entity.FederationOpenFLShardDescriptor)
+	envoyVersions, err := app.getOpenFLEnvoyShardDescriptorVersions(federationUUID)
+	if err != nil {
+		return nil, err
+	}
+	envoyUUIDs := envoyVersions[descriptor.Version]
+	if envoyUUIDs == nil {
+		envoyUUIDs = []string{}
+	}
+	return &OpenFLShardDescriptorVersionDetail{
+		OpenFLShardDescriptorVersionListItem: OpenFLShardDescriptorVersionListItem{
+			UUID:        descriptor.UUID,
+			Version:     descriptor.Version,
+			Description: descriptor.Description,
+			CreatedAt:   descriptor.CreatedAt,
+			EnvoyCount:  len(envoyUUIDs),
+		},
+		Config:     descriptor.Config,
+		EnvoyUUIDs: envoyUUIDs,
+	}, nil
+}
+
+// getOpenFLEnvoyShardDescriptorVersions returns the uuids of the envoys in the federation grouped by their deployed shard descriptor version
+func (app *FederationApp) getOpenFLEnvoyShardDescriptorVersions(federationUUID string) (map[uint][]string, error) {
+	instanceList, err := app.ParticipantOpenFLRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list federation participants")
+	}
+	envoyVersions := map[uint][]string{}
+	for _, participant := range instanceList.([]entity.ParticipantOpenFL) {
+		if participant.Type == entity.ParticipantOpenFLTypeEnvoy {
+			envoyVersions[participant.ShardDescriptorVersion] = append(envoyVersions[participant.ShardDescriptorVersion], participant.UUID)
+		}
+	}
+	return envoyVersions, nil
+}
+
+// RolloutOpenFLShardDescriptor deploys the shard descriptor version to the envoys matching the selector and restarts them,
+// it returns the uuid of the batch operation tracking the progress
+func (app *ParticipantApp) RolloutOpenFLShardDescriptor(federationUUID string, version uint, req *OpenFLShardDescriptorRolloutRequest) (string, error) {
+	return app.CreateOpenFLEnvoyBatchOperation(federationUUID, &service.ParticipantOpenFLEnvoyBatchRequest{
+		Selector:               req.Selector,
+		Type:                   entity.ParticipantOpenFLBatchOperationTypeShardDescriptorRollout,
+		ShardDescriptorVersion: version,
+	})
+}
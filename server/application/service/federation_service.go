@@ -30,6 +30,10 @@ type FederationApp struct {
 	FederationOpenFLRepo        repo.FederationRepository
 	ParticipantOpenFLRepo       repo.ParticipantOpenFLRepository
 	RegistrationTokenOpenFLRepo repo.RegistrationTokenRepository
+	RegistrationTokenFATERepo   repo.RegistrationTokenRepository
+	RegistrationRecordRepo      repo.RegistrationRecordRepository
+
+	FederationOpenFLShardDescriptorRepo repo.FederationOpenFLShardDescriptorRepository
 }
 
 // FederationListItem contains basic info of a federation
@@ -51,12 +55,16 @@ type FederationCreationRequest struct {
 type FederationFATECreationRequest struct {
 	FederationCreationRequest
 	Domain string `json:"domain"`
+	// Mode is how the clusters reach each other, 0 for through exchanges and 1 for peer-to-peer
+	Mode entity.FederationFATEMode `json:"mode"`
 }
 
 // FederationFATEDetail contains specific info for a FATE federation
 type FederationFATEDetail struct {
 	FederationListItem
 	Domain string `json:"domain"`
+	// Mode is how the clusters reach each other, 0 for through exchanges and 1 for peer-to-peer
+	Mode entity.FederationFATEMode `json:"mode"`
 }
 
 // List returns all saved federation
@@ -110,6 +118,7 @@ func (app *FederationApp) GetFATEFederation(uuid string) (*FederationFATEDetail,
 			CreatedAt:   domainFederation.CreatedAt,
 		},
 		Domain: domainFederation.Domain,
+		Mode:   domainFederation.Mode,
 	}, nil
 }
 
@@ -124,6 +133,7 @@ func (app *FederationApp) CreateFATEFederation(req *FederationFATECreationReques
 			Repo:        app.FederationFATERepo,
 		},
 		Domain: req.Domain,
+		Mode:   req.Mode,
 	}
 	if err := federation.Create(); err != nil {
 		return "", err
@@ -142,5 +152,8 @@ func (app *FederationApp) DeleteFATEFederation(uuid string) error {
 	if len(participantList) > 0 {
 		return errors.Errorf("cannot remove federation that still contains %v participants", len(participantList))
 	}
+	if err := app.RegistrationTokenFATERepo.DeleteByFederation(uuid); err != nil {
+		return errors.Wrap(err, "failed to clean up tokens")
+	}
 	return app.FederationFATERepo.DeleteByUUID(uuid)
 }
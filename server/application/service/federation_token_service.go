@@ -0,0 +1,193 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/pkg/errors"
+)
+
+// RegistrationTokenFATEBasicInfo contains necessary info to generate token for a FATE federation
+type RegistrationTokenFATEBasicInfo struct {
+	Name        string                                  `json:"name"`
+	Description string                                  `json:"description"`
+	ExpiredAt   time.Time                               `json:"expired_at"`
+	Limit       int                                     `json:"limit"`
+	Used        int                                     `json:"used"`
+	Disabled    bool                                    `json:"disabled"`
+	RotateOnUse bool                                    `json:"rotate_on_use"`
+	Constraints entity.RegistrationTokenFATEConstraints `json:"constraints"`
+}
+
+// RegistrationTokenFATEListItem contains the basic info and the displayed token string of a FATE token
+type RegistrationTokenFATEListItem struct {
+	RegistrationTokenFATEBasicInfo
+	UUID              string `json:"uuid"`
+	DisplayedTokenStr string `json:"token_str"`
+}
+
+// RegistrationRecordListItem contains the audit info of a token based self-registration
+type RegistrationRecordListItem struct {
+	TokenUUID       string    `json:"token_uuid"`
+	TokenName       string    `json:"token_name"`
+	ParticipantUUID string    `json:"participant_uuid"`
+	ParticipantName string    `json:"participant_name"`
+	ParticipantType string    `json:"participant_type"`
+	SourceAddress   string    `json:"source_address"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// GenerateFATEToken creates a new token for a FATE federation
+func (app *FederationApp) GenerateFATEToken(req *RegistrationTokenFATEBasicInfo, federationUUID string) error {
+	if _, err := app.FederationFATERepo.GetByUUID(federationUUID); err != nil {
+		return errors.Wrap(err, "failed to query federation")
+	}
+	token := &entity.RegistrationTokenFATE{
+		RegistrationToken: entity.RegistrationToken{
+			Name:           req.Name,
+			Description:    req.Description,
+			FederationUUID: federationUUID,
+			ExpiredAt:      req.ExpiredAt,
+			Limit:          req.Limit,
+			Disabled:       req.Disabled,
+			RotateOnUse:    req.RotateOnUse,
+			Repo:           app.RegistrationTokenFATERepo,
+		},
+		Constraints: req.Constraints,
+	}
+	return token.Create()
+}
+
+// ListFATEToken list all registration tokens in a FATE federation
+func (app *FederationApp) ListFATEToken(federationUUID string) ([]RegistrationTokenFATEListItem, error) {
+	instanceList, err := app.RegistrationTokenFATERepo.ListByFederation(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query token list")
+	}
+	domainTokenList := instanceList.([]entity.RegistrationTokenFATE)
+	var tokenList []RegistrationTokenFATEListItem
+	for _, token := range domainTokenList {
+		count, err := app.ParticipantFATERepo.CountByTokenUUID(token.UUID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to query token count")
+		}
+		tokenList = append(tokenList, RegistrationTokenFATEListItem{
+			RegistrationTokenFATEBasicInfo: RegistrationTokenFATEBasicInfo{
+				Name:        token.Name,
+				Description: token.Description,
+				ExpiredAt:   token.ExpiredAt,
+				Limit:       token.Limit,
+				Used:        count,
+				Disabled:    token.Disabled,
+				RotateOnUse: token.RotateOnUse,
+				Constraints: token.Constraints,
+			},
+			UUID:              token.UUID,
+			DisplayedTokenStr: token.Display(),
+		})
+	}
+	return tokenList, nil
+}
+
+// DeleteFATEToken removes the specified token
+func (app *FederationApp) DeleteFATEToken(uuid, federationUUID string) error {
+	if _, err := app.loadToken(app.RegistrationTokenFATERepo, uuid, federationUUID); err != nil {
+		return err
+	}
+	return app.RegistrationTokenFATERepo.DeleteByUUID(uuid)
+}
+
+// SetFATETokenDisabled enables or disables a FATE token
+func (app *FederationApp) SetFATETokenDisabled(uuid, federationUUID string, disabled bool) error {
+	token, err := app.loadToken(app.RegistrationTokenFATERepo, uuid, federationUUID)
+	if err != nil {
+		return err
+	}
+	return token.SetDisabled(disabled)
+}
+
+// RotateFATEToken replaces the token string of a FATE token
+func (app *FederationApp) RotateFATEToken(uuid, federationUUID string) error {
+	token, err := app.loadToken(app.RegistrationTokenFATERepo, uuid, federationUUID)
+	if err != nil {
+		return err
+	}
+	return token.Rotate()
+}
+
+// SetOpenFLTokenDisabled enables or disables an OpenFL token
+func (app *FederationApp) SetOpenFLTokenDisabled(uuid, federationUUID string, disabled bool) error {
+	token, err := app.loadToken(app.RegistrationTokenOpenFLRepo, uuid, federationUUID)
+	if err != nil {
+		return err
+	}
+	return token.SetDisabled(disabled)
+}
+
+// RotateOpenFLToken replaces the token string of an OpenFL token
+func (app *FederationApp) RotateOpenFLToken(uuid, federationUUID string) error {
+	token, err := app.loadToken(app.RegistrationTokenOpenFLRepo, uuid, federationUUID)
+	if err != nil {
+		return err
+	}
+	return token.Rotate()
+}
+
+// ListRegistrationRecords returns the self-registration audit records of a federation
+func (app *FederationApp) ListRegistrationRecords(federationUUID string) ([]RegistrationRecordListItem, error) {
+	instanceList, err := app.RegistrationRecordRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query registration records")
+	}
+	domainRecordList := instanceList.([]entity.RegistrationRecord)
+	recordList := make([]RegistrationRecordListItem, 0)
+	for _, record := range domainRecordList {
+		recordList = append(recordList, RegistrationRecordListItem{
+			TokenUUID:       record.TokenUUID,
+			TokenName:       record.TokenName,
+			ParticipantUUID: record.ParticipantUUID,
+			ParticipantName: record.ParticipantName,
+			ParticipantType: record.ParticipantType,
+			SourceAddress:   record.SourceAddress,
+			CreatedAt:       record.CreatedAt,
+		})
+	}
+	return recordList, nil
+}
+
+// loadToken returns the base token entity and makes sure it belongs to the federation
+func (app *FederationApp) loadToken(tokenRepo repo.RegistrationTokenRepository, uuid, federationUUID string) (*entity.RegistrationToken, error) {
+	instance, err := tokenRepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query token")
+	}
+	var token *entity.RegistrationToken
+	switch t := instance.(type) {
+	case *entity.RegistrationTokenOpenFL:
+		token = &t.RegistrationToken
+	case *entity.RegistrationTokenFATE:
+		token = &t.RegistrationToken
+	default:
+		return nil, errors.Errorf("unknown token type %T", instance)
+	}
+	if token.FederationUUID != federationUUID {
+		return nil, errors.Errorf("token %s doesn't belong to federation %s", uuid, federationUUID)
+	}
+	token.Repo = tokenRepo
+	return token, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+)
+
+// FATESizingProfile contains the settings of a FATE cluster sizing profile
+type FATESizingProfile struct {
+	UUID        string                        `json:"uuid"`
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	BuiltIn     bool                          `json:"built_in"`
+	CreatedAt   time.Time                     `json:"created_at"`
+	Sizing      valueobject.FATEClusterSizing `json:"sizing"`
+}
+
+// FATEClusterResizeRequest contains the sizing profile to apply to a FATE cluster
+type FATEClusterResizeRequest struct {
+	SizingProfileUUID string `json:"sizing_profile_uuid"`
+}
+
+// ListFATESizingProfiles returns the built-in and custom sizing profiles
+func (app *ParticipantApp) ListFATESizingProfiles() ([]FATESizingProfile, error) {
+	instanceList, err := app.ParticipantFATESizingProfileRepo.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sizing profiles")
+	}
+	profileList := make([]FATESizingProfile, 0)
+	for _, profile := range instanceList.([]entity.ParticipantFATESizingProfile) {
+		profileList = append(profileList, toFATESizingProfile(&profile))
+	}
+	return profileList, nil
+}
+
+// GetFATESizingProfile returns the settings of a sizing profile
+func (app *ParticipantApp) GetFATESizingProfile(uuid string) (*FATESizingProfile, error) {
+	instance, err := app.ParticipantFATESizingProfileRepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query sizing profile")
+	}
+	profile := toFATESizingProfile(instance.(*entity.ParticipantFATESizingProfile))
+	return &profile, nil
+}
+
+// CreateFATESizingProfile creates a custom sizing profile and returns its uuid
+func (app *ParticipantApp) CreateFATESizingProfile(req *service.ParticipantFATESizingProfileRequest) (string, error) {
+	profile, err := app.getFATEDomainService().CreateSizingProfile(req)
+	if err != nil {
+		return "", err
+	}
+	return profile.UUID, nil
+}
+
+// UpdateFATESizingProfile updates a custom sizing profile
+func (app *ParticipantApp) UpdateFATESizingProfile(uuid string, req *service.ParticipantFATESizingProfileRequest) error {
+	return app.getFATEDomainService().UpdateSizingProfile(uuid, req)
+}
+
+// DeleteFATESizingProfile deletes a custom sizing profile
+func (app *ParticipantApp) DeleteFATESizingProfile(uuid string) error {
+	return app.getFATEDomainService().DeleteSizingProfile(uuid)
+}
+
+// ResizeFATECluster applies a sizing profile to a FATE cluster
+func (app *ParticipantApp) ResizeFATECluster(uuid string, req *FATEClusterResizeRequest) error {
+	_, _, err := app.getFATEDomainService().ResizeCluster(uuid, req.SizingProfileUUID)
+	return err
+}
+
+func toFATESizingProfile(profile *entity.ParticipantFATESizingProfile) FATESizingProfile {
+	return FATESizingProfile{
+		UUID:        profile.UUID,
+		Name:        profile.Name,
+		Description: profile.Description,
+		BuiltIn:     profile.BuiltIn,
+		CreatedAt:   profile.CreatedAt,
+		Sizing:      profile.Sizing,
+	}
+}
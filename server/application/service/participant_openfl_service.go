@@ -41,6 +41,11 @@ type ParticipantOpenFLListItem struct {
 	TokenStr          string                                   `json:"token_str"`
 	TokenName         string                                   `json:"token_name"`
 	Labels            valueobject.Labels                       `json:"labels"`
+	LastSeen          *time.Time                               `json:"last_seen"`
+	// ShardDescriptorVersion is the deployed federation shard descriptor version of an envoy
+	ShardDescriptorVersion uint `json:"shard_descriptor_version"`
+	// DeploymentMethod is how the participant is deployed, 0 for KubeFATE and 1 for Helm
+	DeploymentMethod entity.FederationOpenFLDeploymentMethod `json:"deployment_method"`
 }
 
 // ParticipantOpenFLListInFederation contains all the participants in an OpenFL federation
@@ -61,8 +66,28 @@ type OpenFLDirectorDetail struct {
 // OpenFLEnvoyDetail contains detailed info of an OpenFL envoy
 type OpenFLEnvoyDetail struct {
 	ParticipantOpenFLListItem
-	ChartUUID           string                              `json:"chart_uuid"`
-	EnvoyClientCertInfo entity.ParticipantComponentCertInfo `json:"envoy_client_cert_info"`
+	ChartUUID           string                               `json:"chart_uuid"`
+	EnvoyClientCertInfo entity.ParticipantComponentCertInfo  `json:"envoy_client_cert_info"`
+	DeviceHealth        entity.ParticipantOpenFLDeviceHealth `json:"device_health"`
+}
+
+// OpenFLEnvoyBatchOperationDetail contains the progress of a batch operation on the envoys
+type OpenFLEnvoyBatchOperationDetail struct {
+	UUID      string                                       `json:"uuid"`
+	Type      entity.ParticipantOpenFLBatchOperationType   `json:"type"`
+	Selector  string                                       `json:"selector"`
+	Status    entity.ParticipantOpenFLBatchOperationStatus `json:"status"`
+	Total     int                                          `json:"total"`
+	Succeeded int                                          `json:"succeeded"`
+	Failed    int                                          `json:"failed"`
+	Items     entity.ParticipantOpenFLBatchOperationItems  `json:"items"`
+	CreatedAt time.Time                                    `json:"created_at"`
+}
+
+// OpenFLDomainService returns the domain service of the OpenFL participants, for the callers driving it directly like
+// the custom resource controllers
+func (app *ParticipantApp) OpenFLDomainService() *service.ParticipantOpenFLService {
+	return app.getOpenFLDomainService()
 }
 
 func (app *ParticipantApp) getOpenFLDomainService() *service.ParticipantOpenFLService {
@@ -73,9 +98,12 @@ func (app *ParticipantApp) getOpenFLDomainService() *service.ParticipantOpenFLSe
 		ParticipantOpenFLRepo: app.ParticipantOpenFLRepo,
 		TokenRepo:             app.RegistrationTokenOpenFLRepo,
 		InfraRepo:             app.InfraProviderKubernetesRepo,
+		BatchOperationRepo:    app.ParticipantOpenFLBatchOperationRepo,
+		ShardDescriptorRepo:   app.FederationOpenFLShardDescriptorRepo,
 		ParticipantService: service.ParticipantService{
-			FederationRepo: app.FederationOpenFLRepo,
-			ChartRepo:      app.ChartRepo,
+			FederationRepo:         app.FederationOpenFLRepo,
+			ChartRepo:              app.ChartRepo,
+			RegistrationRecordRepo: app.RegistrationRecordRepo,
 			CertificateService: &service.CertificateService{
 				CertificateAuthorityRepo: app.CertificateAuthorityRepo,
 				CertificateRepo:          app.CertificateRepo,
@@ -108,12 +136,15 @@ func (app *ParticipantApp) CreateOpenFLDirector(req *service.ParticipantOpenFLDi
 }
 
 // HandleOpenFLEnvoyRegistration handles registration request from an Envoy node
-func (app *ParticipantApp) HandleOpenFLEnvoyRegistration(req *service.ParticipantOpenFLEnvoyRegistrationRequest) (string, error) {
-	envoy, err := app.getOpenFLDomainService().HandleRegistrationRequest(req)
+func (app *ParticipantApp) HandleOpenFLEnvoyRegistration(req *service.ParticipantOpenFLEnvoyRegistrationRequest) (*service.ParticipantRegistrationResponse, error) {
+	envoy, credential, err := app.getOpenFLDomainService().HandleRegistrationRequest(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return envoy.UUID, err
+	return &service.ParticipantRegistrationResponse{
+		UUID:       envoy.UUID,
+		Credential: credential,
+	}, nil
 }
 
 // RemoveOpenFLDirector removes and uninstalls an OpenFL director deployment
@@ -124,11 +155,91 @@ func (app *ParticipantApp) RemoveOpenFLDirector(uuid string, force bool) error {
 
 // RemoveOpenFLEnvoy removes and uninstalls an OpenFL envoy deployment
 func (app *ParticipantApp) RemoveOpenFLEnvoy(uuid string, force bool) error {
-	return app.getOpenFLDomainService().RemoveEnvoy(uuid, force)
+	_, err := app.getOpenFLDomainService().RemoveEnvoy(uuid, force)
+	return err
+}
+
+// UnregisterOpenFLEnvoy removes and uninstalls an OpenFL envoy on behalf of its device agent
+func (app *ParticipantApp) UnregisterOpenFLEnvoy(uuid string, req *service.ParticipantOpenFLEnvoyUnregistrationRequest) error {
+	return app.getOpenFLDomainService().UnregisterEnvoy(uuid, req)
+}
+
+// HandleOpenFLEnvoyHeartbeat records the heartbeat from the device agent of an OpenFL envoy
+func (app *ParticipantApp) HandleOpenFLEnvoyHeartbeat(uuid string, req *service.ParticipantOpenFLEnvoyHeartbeatRequest) error {
+	return app.getOpenFLDomainService().HandleEnvoyHeartbeat(uuid, req)
+}
+
+// ReconfigureOpenFLEnvoy updates the config and shard descriptor files of an OpenFL envoy
+func (app *ParticipantApp) ReconfigureOpenFLEnvoy(uuid string, req *service.ParticipantOpenFLEnvoyReconfigureRequest) error {
+	_, err := app.getOpenFLDomainService().ReconfigureEnvoy(uuid, req)
+	return err
+}
+
+// UpgradeOpenFLEnvoy upgrades an OpenFL envoy to a newer chart version
+func (app *ParticipantApp) UpgradeOpenFLEnvoy(uuid string, req *service.ParticipantOpenFLEnvoyUpgradeRequest) error {
+	_, err := app.getOpenFLDomainService().UpgradeEnvoy(uuid, req)
+	return err
+}
+
+// UpdateOpenFLEnvoyLabels replaces the labels of an OpenFL envoy
+func (app *ParticipantApp) UpdateOpenFLEnvoyLabels(uuid string, labels valueobject.Labels) error {
+	return app.getOpenFLDomainService().UpdateEnvoyLabels(uuid, labels)
+}
+
+// CreateOpenFLEnvoyBatchOperation starts an operation on the envoys matching the label selector and returns the operation's uuid
+func (app *ParticipantApp) CreateOpenFLEnvoyBatchOperation(federationUUID string, req *service.ParticipantOpenFLEnvoyBatchRequest) (string, error) {
+	operation, _, err := app.getOpenFLDomainService().CreateEnvoyBatchOperation(federationUUID, req)
+	if err != nil {
+		return "", err
+	}
+	return operation.UUID, nil
+}
+
+// ListOpenFLEnvoyBatchOperations returns the batch operations in an OpenFL federation
+func (app *ParticipantApp) ListOpenFLEnvoyBatchOperations(federationUUID string) ([]OpenFLEnvoyBatchOperationDetail, error) {
+	instanceList, err := app.ParticipantOpenFLBatchOperationRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list batch operations")
+	}
+	operationList := make([]OpenFLEnvoyBatchOperationDetail, 0)
+	for _, operation := range instanceList.([]entity.ParticipantOpenFLBatchOperation) {
+		operationList = append(operationList, *toOpenFLEnvoyBatchOperationDetail(&operation))
+	}
+	return operationList, nil
+}
+
+// GetOpenFLEnvoyBatchOperation returns the detailed progress of a batch operation
+func (app *ParticipantApp) GetOpenFLEnvoyBatchOperation(uuid string) (*OpenFLEnvoyBatchOperationDetail, error) {
+	instance, err := app.ParticipantOpenFLBatchOperationRepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query batch operation")
+	}
+	return toOpenFLEnvoyBatchOperationDetail(instance.(*entity.ParticipantOpenFLBatchOperation)), nil
+}
+
+func toOpenFLEnvoyBatchOperationDetail(operation *entity.ParticipantOpenFLBatchOperation) *OpenFLEnvoyBatchOperationDetail {
+	detail := &OpenFLEnvoyBatchOperationDetail{
+		UUID:      operation.UUID,
+		Type:      operation.Type,
+		Selector:  operation.Selector,
+		Status:    operation.Status,
+		Items:     operation.Items,
+		Total:     len(operation.Items),
+		CreatedAt: operation.CreatedAt,
+	}
+	for _, item := range operation.Items {
+		switch item.Status {
+		case entity.ParticipantOpenFLBatchOperationStatusSucceeded:
+			detail.Succeeded++
+		case entity.ParticipantOpenFLBatchOperationStatusFailed:
+			detail.Failed++
+		}
+	}
+	return detail
 }
 
-// GetOpenFLParticipantList returns the current participants in an OpenFL federation
-func (app *ParticipantApp) GetOpenFLParticipantList(federationUUID string) (*ParticipantOpenFLListInFederation, error) {
+// GetOpenFLParticipantList returns the current participants in an OpenFL federation, envoys are filtered by the label selector if it is not empty
+func (app *ParticipantApp) GetOpenFLParticipantList(federationUUID, selector string) (*ParticipantOpenFLListInFederation, error) {
 	var participants ParticipantOpenFLListInFederation
 	instanceList, err := app.ParticipantOpenFLRepo.ListByFederationUUID(federationUUID)
 	if err != nil {
@@ -136,7 +247,22 @@ func (app *ParticipantApp) GetOpenFLParticipantList(federationUUID string) (*Par
 	}
 	domainParticipantList := instanceList.([]entity.ParticipantOpenFL)
 
+	var matchedEnvoys map[string]bool
+	if selector != "" {
+		envoyList, err := service.FilterEnvoysBySelector(domainParticipantList, selector)
+		if err != nil {
+			return nil, err
+		}
+		matchedEnvoys = map[string]bool{}
+		for _, envoy := range envoyList {
+			matchedEnvoys[envoy.UUID] = true
+		}
+	}
+
 	for _, domainParticipant := range domainParticipantList {
+		if matchedEnvoys != nil && domainParticipant.Type == entity.ParticipantOpenFLTypeEnvoy && !matchedEnvoys[domainParticipant.UUID] {
+			continue
+		}
 		item := &ParticipantOpenFLListItem{
 			UUID:              domainParticipant.UUID,
 			Name:              domainParticipant.Name,
@@ -151,20 +277,15 @@ func (app *ParticipantApp) GetOpenFLParticipantList(federationUUID string) (*Par
 			ClusterUUID:       domainParticipant.ClusterUUID,
 			Status:            domainParticipant.Status,
 			AccessInfo:        domainParticipant.AccessInfo,
+			DeploymentMethod:  domainParticipant.DeploymentMethod,
 		}
-		if endpointInstance, err := app.EndpointKubeFATERepo.GetByUUID(domainParticipant.EndpointUUID); err == nil {
-			endpoint := endpointInstance.(*entity.EndpointKubeFATE)
-			item.EndpointName = endpoint.Name
-			item.InfraProviderUUID = endpoint.InfraProviderUUID
-			if infraInstance, err := app.InfraProviderKubernetesRepo.GetByUUID(endpoint.InfraProviderUUID); err == nil {
-				infra := infraInstance.(*entity.InfraProviderKubernetes)
-				item.InfraProviderName = infra.Name
-			}
-		}
+		app.fillOpenFLParticipantInfraInfo(item, &domainParticipant)
 		if domainParticipant.Type == entity.ParticipantOpenFLTypeDirector {
 			participants.Director = item
 		} else {
 			item.Labels = domainParticipant.Labels
+			item.LastSeen = domainParticipant.LastSeen
+			item.ShardDescriptorVersion = domainParticipant.ShardDescriptorVersion
 			item.TokenName = "Unknown"
 			item.TokenStr = "Unknown"
 			if instance, err := app.RegistrationTokenOpenFLRepo.GetByUUID(domainParticipant.TokenUUID); err == nil {
@@ -178,6 +299,28 @@ func (app *ParticipantApp) GetOpenFLParticipantList(federationUUID string) (*Par
 	return &participants, nil
 }
 
+// fillOpenFLParticipantInfraInfo sets the endpoint and infra provider info of the participant item, participants
+// deployed with helm have no endpoint so the infra provider is queried directly
+func (app *ParticipantApp) fillOpenFLParticipantInfraInfo(item *ParticipantOpenFLListItem, participant *entity.ParticipantOpenFL) {
+	infraProviderUUID := participant.InfraUUID
+	if participant.DeploymentMethod == entity.FederationOpenFLDeploymentMethodKubeFATE {
+		endpointInstance, err := app.EndpointKubeFATERepo.GetByUUID(participant.EndpointUUID)
+		if err != nil {
+			return
+		}
+		endpoint := endpointInstance.(*entity.EndpointKubeFATE)
+		item.EndpointName = endpoint.Name
+		infraProviderUUID = endpoint.InfraProviderUUID
+	} else {
+		item.EndpointName = ""
+	}
+	item.InfraProviderUUID = infraProviderUUID
+	if infraInstance, err := app.InfraProviderKubernetesRepo.GetByUUID(infraProviderUUID); err == nil {
+		infra := infraInstance.(*entity.InfraProviderKubernetes)
+		item.InfraProviderName = infra.Name
+	}
+}
+
 // GetOpenFLDirectorDetail returns the detailed information of a OpenFL director
 func (app *ParticipantApp) GetOpenFLDirectorDetail(uuid string) (*OpenFLDirectorDetail, error) {
 	participantInstance, err := app.ParticipantOpenFLRepo.GetByUUID(uuid)
@@ -200,21 +343,14 @@ func (app *ParticipantApp) GetOpenFLDirectorDetail(uuid string) (*OpenFLDirector
 			ClusterUUID:       participant.ClusterUUID,
 			Status:            participant.Status,
 			AccessInfo:        participant.AccessInfo,
+			DeploymentMethod:  participant.DeploymentMethod,
 		},
 		ChartUUID:              participant.ChartUUID,
 		DeploymentYAML:         participant.DeploymentYAML,
 		DirectorServerCertInfo: participant.CertConfig.DirectorServerCertInfo,
 		JupyterClientCertInfo:  participant.CertConfig.JupyterClientCertInfo,
 	}
-	if endpointInstance, err := app.EndpointKubeFATERepo.GetByUUID(participant.EndpointUUID); err == nil {
-		endpoint := endpointInstance.(*entity.EndpointKubeFATE)
-		participantDetail.EndpointName = endpoint.Name
-		participantDetail.InfraProviderUUID = endpoint.InfraProviderUUID
-		if infraInstance, err := app.InfraProviderKubernetesRepo.GetByUUID(endpoint.InfraProviderUUID); err == nil {
-			infra := infraInstance.(*entity.InfraProviderKubernetes)
-			participantDetail.InfraProviderName = infra.Name
-		}
-	}
+	app.fillOpenFLParticipantInfraInfo(&participantDetail.ParticipantOpenFLListItem, participant)
 	return participantDetail, nil
 }
 
@@ -240,22 +376,19 @@ func (app *ParticipantApp) GetOpenFLEnvoyDetail(uuid string) (*OpenFLEnvoyDetail
 			ClusterUUID:       participant.ClusterUUID,
 			Status:            participant.Status,
 			AccessInfo:        participant.AccessInfo,
+			DeploymentMethod:  participant.DeploymentMethod,
 			TokenStr:          "Unknown",
 			TokenName:         "Unknown",
 			Labels:            participant.Labels,
+			LastSeen:          participant.LastSeen,
+
+			ShardDescriptorVersion: participant.ShardDescriptorVersion,
 		},
 		ChartUUID:           participant.ChartUUID,
 		EnvoyClientCertInfo: participant.CertConfig.EnvoyClientCertInfo,
+		DeviceHealth:        participant.DeviceHealth,
 	}
-	if endpointInstance, err := app.EndpointKubeFATERepo.GetByUUID(participant.EndpointUUID); err == nil {
-		endpoint := endpointInstance.(*entity.EndpointKubeFATE)
-		participantDetail.EndpointName = endpoint.Name
-		participantDetail.InfraProviderUUID = endpoint.InfraProviderUUID
-		if infraInstance, err := app.InfraProviderKubernetesRepo.GetByUUID(endpoint.InfraProviderUUID); err == nil {
-			infra := infraInstance.(*entity.InfraProviderKubernetes)
-			participantDetail.InfraProviderName = infra.Name
-		}
-	}
+	app.fillOpenFLParticipantInfraInfo(&participantDetail.ParticipantOpenFLListItem, participant)
 	if tokenInstance, err := app.RegistrationTokenOpenFLRepo.GetByUUID(participant.TokenUUID); err == nil {
 		token := tokenInstance.(*entity.RegistrationTokenOpenFL)
 		participantDetail.TokenStr = token.Display()
@@ -264,14 +397,50 @@ func (app *ParticipantApp) GetOpenFLEnvoyDetail(uuid string) (*OpenFLEnvoyDetail
 	return participantDetail, nil
 }
 
-// GetOpenFLEnvoyDetailWithTokenVerification returns the detailed information of a OpenFL envoy if the supplied token string is correct
-func (app *ParticipantApp) GetOpenFLEnvoyDetailWithTokenVerification(uuid, tokenStr string) (*OpenFLEnvoyDetail, error) {
-	envoy, err := app.GetOpenFLEnvoyDetail(uuid)
-	if err != nil {
+// GetOpenFLEnvoyDetailWithTokenVerification returns the detailed information of a OpenFL envoy if the supplied credential is correct
+func (app *ParticipantApp) GetOpenFLEnvoyDetailWithTokenVerification(uuid, credential string) (*OpenFLEnvoyDetail, error) {
+	if _, err := app.getOpenFLDomainService().AuthenticateEnvoy(uuid, credential); err != nil {
+		return nil, err
+	}
+	return app.GetOpenFLEnvoyDetail(uuid)
+}
+
+// GetOpenFLDirectorExperiments returns the envoys connected to the director and the experiments it runs
+func (app *ParticipantApp) GetOpenFLDirectorExperiments(federationUUID, directorUUID string) (*service.OpenFLDirectorExperiments, error) {
+	if err := app.checkOpenFLDirectorFederation(federationUUID, directorUUID); err != nil {
+		return nil, err
+	}
+	return app.getOpenFLDomainService().GetDirectorExperiments(directorUUID)
+}
+
+// GetOpenFLDirectorExperimentDetail returns the status, metrics and director logs of an experiment
+func (app *ParticipantApp) GetOpenFLDirectorExperimentDetail(federationUUID, directorUUID, experimentName string, logTailLines int64) (*service.OpenFLDirectorExperimentDetail, error) {
+	if err := app.checkOpenFLDirectorFederation(federationUUID, directorUUID); err != nil {
 		return nil, err
 	}
-	if envoy.TokenStr != tokenStr {
-		return nil, errors.New("invalid token")
+	return app.getOpenFLDomainService().GetDirectorExperimentDetail(directorUUID, experimentName, logTailLines)
+}
+
+func (app *ParticipantApp) checkOpenFLDirectorFederation(federationUUID, directorUUID string) error {
+	instance, err := app.ParticipantOpenFLRepo.GetByUUID(directorUUID)
+	if err != nil {
+		return errors.Wrap(err, "failed to query director")
+	}
+	if director := instance.(*entity.ParticipantOpenFL); director.FederationUUID != federationUUID {
+		return errors.Errorf("director %s does not belong to federation %s", directorUUID, federationUUID)
+	}
+	return nil
+}
+
+// GetOpenFLParticipantDeploymentStatus returns the status of the chart release of a director or envoy, queried from
+// KubeFATE or helm according to the deployment method
+func (app *ParticipantApp) GetOpenFLParticipantDeploymentStatus(federationUUID, participantUUID string) (*service.ParticipantOpenFLDeploymentStatus, error) {
+	instance, err := app.ParticipantOpenFLRepo.GetByUUID(participantUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query participant")
+	}
+	if participant := instance.(*entity.ParticipantOpenFL); participant.FederationUUID != federationUUID {
+		return nil, errors.Errorf("participant %s does not belong to federation %s", participantUUID, federationUUID)
 	}
-	return envoy, nil
+	return app.getOpenFLDomainService().GetDeploymentStatus(participantUUID)
 }
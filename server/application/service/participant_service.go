@@ -15,6 +15,7 @@
 package service
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/FederatedAI/FedLCM/server/domain/entity"
@@ -34,6 +35,13 @@ type ParticipantApp struct {
 	FederationOpenFLRepo        repo.FederationRepository
 	ParticipantOpenFLRepo       repo.ParticipantOpenFLRepository
 	RegistrationTokenOpenFLRepo repo.RegistrationTokenRepository
+	RegistrationTokenFATERepo   repo.RegistrationTokenRepository
+	RegistrationRecordRepo      repo.RegistrationRecordRepository
+
+	ParticipantOpenFLBatchOperationRepo repo.ParticipantOpenFLBatchOperationRepository
+	FederationOpenFLShardDescriptorRepo repo.FederationOpenFLShardDescriptorRepository
+	ParticipantFATESizingProfileRepo    repo.ParticipantFATESizingProfileRepository
+	FederationFATEDiagnosticsRepo       repo.FederationFATEDiagnosticsRepository
 
 	EndpointKubeFATERepo        repo.EndpointRepository
 	InfraProviderKubernetesRepo repo.InfraProviderRepository
@@ -64,12 +72,21 @@ type ParticipantFATEListItem struct {
 	Status            entity.ParticipantFATEStatus           `json:"status"`
 	AccessInfo        entity.ParticipantFATEModulesAccessMap `json:"access_info"`
 	IsManaged         bool                                   `json:"is_managed"`
+	// ExchangeUUID and StandbyExchangeUUID are the primary and standby exchange of a cluster
+	ExchangeUUID        string `json:"exchange_uuid"`
+	StandbyExchangeUUID string `json:"standby_exchange_uuid"`
+	// SizingProfileUUID is the sizing profile last applied to a cluster
+	SizingProfileUUID string `json:"sizing_profile_uuid"`
+	// Backend is the computing and federation engine combination of a cluster
+	Backend entity.ParticipantFATEBackend `json:"backend"`
 }
 
 // ParticipantFATEListInFederation has all the participants in a FATE federation
 type ParticipantFATEListInFederation struct {
-	Exchange *ParticipantFATEListItem   `json:"exchange"`
-	Clusters []*ParticipantFATEListItem `json:"clusters"`
+	// Exchange is the first exchange of the federation
+	Exchange  *ParticipantFATEListItem   `json:"exchange"`
+	Exchanges []*ParticipantFATEListItem `json:"exchanges"`
+	Clusters  []*ParticipantFATEListItem `json:"clusters"`
 }
 
 // FATEExchangeDetail contains the detailed info of a FATE exchange
@@ -140,6 +157,26 @@ func (app *ParticipantApp) CreateFATECluster(req *service.ParticipantFATECluster
 	return cluster.UUID, err
 }
 
+// RegisterFATECluster handles the self-registration request of a FATE cluster
+func (app *ParticipantApp) RegisterFATECluster(req *service.ParticipantFATEClusterRegistrationRequest) (*service.ParticipantRegistrationResponse, error) {
+	cluster, credential, _, err := app.getFATEDomainService().HandleClusterRegistrationRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return &service.ParticipantRegistrationResponse{
+		UUID:       cluster.UUID,
+		Credential: credential,
+	}, nil
+}
+
+// GetFATEClusterDetailWithTokenVerification returns the detailed information of a self-registered FATE cluster if the supplied credential is correct
+func (app *ParticipantApp) GetFATEClusterDetailWithTokenVerification(uuid, credential string) (*FATEClusterDetail, error) {
+	if _, err := app.getFATEDomainService().GetClusterWithTokenVerification(uuid, credential); err != nil {
+		return nil, err
+	}
+	return app.GetFATEClusterDetail(uuid)
+}
+
 // CreateExternalFATECluster creates an external FATE cluster
 func (app *ParticipantApp) CreateExternalFATECluster(req *service.ParticipantFATEExternalClusterCreationRequest) (string, error) {
 	cluster, _, err := app.getFATEDomainService().CreateExternalCluster(req)
@@ -149,6 +186,88 @@ func (app *ParticipantApp) CreateExternalFATECluster(req *service.ParticipantFAT
 	return cluster.UUID, err
 }
 
+// ScanAdoptableFATEClusters returns the FATE clusters deployed by the KubeFATE service of the endpoint and whether they
+// can be adopted
+func (app *ParticipantApp) ScanAdoptableFATEClusters(endpointUUID string) ([]service.ParticipantFATEAdoptionCandidate, error) {
+	return app.getFATEDomainService().ScanAdoptableClusters(endpointUUID)
+}
+
+// AdoptFATECluster adopts an existing KubeFATE-deployed FATE cluster as a managed cluster
+func (app *ParticipantApp) AdoptFATECluster(req *service.ParticipantFATEAdoptionRequest) (string, error) {
+	cluster, _, err := app.getFATEDomainService().AdoptCluster(req)
+	if err != nil {
+		return "", err
+	}
+	return cluster.UUID, nil
+}
+
+// GetFATEFederationTopology returns the topology graph of the FATE federation
+func (app *ParticipantApp) GetFATEFederationTopology(federationUUID string) (*service.FederationFATETopology, error) {
+	return app.getFATEDomainService().GetFederationTopology(federationUUID)
+}
+
+// FATEFederationDiagnosticsDetail contains the status of a diagnostics run and its report once succeeded
+type FATEFederationDiagnosticsDetail struct {
+	UUID           string                                   `json:"uuid"`
+	FederationUUID string                                   `json:"federation_uuid"`
+	Status         entity.FederationFATEDiagnosticsStatus   `json:"status"`
+	Message        string                                   `json:"message"`
+	CreatedAt      time.Time                                `json:"created_at"`
+	Report         *service.FederationFATEDiagnosticsReport `json:"report,omitempty"`
+}
+
+// StartFATEFederationDiagnostics starts checking the connectivity between the participants of the FATE federation in
+// the background and returns the uuid of the diagnostics
+func (app *ParticipantApp) StartFATEFederationDiagnostics(federationUUID string) (string, error) {
+	diagnostics, _, err := app.getFATEDomainService().StartFederationDiagnostics(federationUUID)
+	if err != nil {
+		return "", err
+	}
+	return diagnostics.UUID, nil
+}
+
+// ListFATEFederationDiagnostics returns the diagnostics runs of the FATE federation, without their reports
+func (app *ParticipantApp) ListFATEFederationDiagnostics(federationUUID string) ([]FATEFederationDiagnosticsDetail, error) {
+	instanceList, err := app.FederationFATEDiagnosticsRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list diagnostics")
+	}
+	diagnosticsList := make([]FATEFederationDiagnosticsDetail, 0)
+	for _, diagnostics := range instanceList.([]entity.FederationFATEDiagnostics) {
+		diagnosticsList = append(diagnosticsList, FATEFederationDiagnosticsDetail{
+			UUID:           diagnostics.UUID,
+			FederationUUID: diagnostics.FederationUUID,
+			Status:         diagnostics.Status,
+			Message:        diagnostics.Message,
+			CreatedAt:      diagnostics.CreatedAt,
+		})
+	}
+	return diagnosticsList, nil
+}
+
+// GetFATEFederationDiagnostics returns the status of a diagnostics run and its report
+func (app *ParticipantApp) GetFATEFederationDiagnostics(uuid string) (*FATEFederationDiagnosticsDetail, error) {
+	instance, err := app.FederationFATEDiagnosticsRepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query diagnostics")
+	}
+	diagnostics := instance.(*entity.FederationFATEDiagnostics)
+	detail := &FATEFederationDiagnosticsDetail{
+		UUID:           diagnostics.UUID,
+		FederationUUID: diagnostics.FederationUUID,
+		Status:         diagnostics.Status,
+		Message:        diagnostics.Message,
+		CreatedAt:      diagnostics.CreatedAt,
+	}
+	if diagnostics.Report != "" {
+		detail.Report = &service.FederationFATEDiagnosticsReport{}
+		if err := json.Unmarshal([]byte(diagnostics.Report), detail.Report); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal the diagnostics report")
+		}
+	}
+	return detail, nil
+}
+
 // RemoveFATEExchange removes and uninstalls a FATE exchange deployment
 func (app *ParticipantApp) RemoveFATEExchange(uuid string, force bool) error {
 	_, err := app.getFATEDomainService().RemoveExchange(uuid, force)
@@ -161,12 +280,41 @@ func (app *ParticipantApp) RemoveFATECluster(uuid string, force bool) error {
 	return err
 }
 
+// FailoverFATECluster switches the routing of a FATE cluster to its standby exchange
+func (app *ParticipantApp) FailoverFATECluster(uuid string) error {
+	_, err := app.getFATEDomainService().FailoverCluster(uuid)
+	return err
+}
+
+// FailoverFATEExchange switches the routing of all the FATE clusters using the exchange to their standby exchanges
+func (app *ParticipantApp) FailoverFATEExchange(uuid string) error {
+	_, err := app.getFATEDomainService().FailoverExchange(uuid)
+	return err
+}
+
+// ApplyFATEFederation converges a FATE federation and its participants to the spec and returns the plan
+func (app *ParticipantApp) ApplyFATEFederation(spec *service.FederationFATESpec, dryRun, prune bool) (*service.FederationFATEApplyPlan, error) {
+	plan, _, err := app.getFATEDomainService().ApplyFederation(spec, dryRun, prune)
+	return plan, err
+}
+
+// FATEDomainService returns the domain service of the FATE participants, for the callers driving it directly like the
+// custom resource controllers
+func (app *ParticipantApp) FATEDomainService() *service.ParticipantFATEService {
+	return app.getFATEDomainService()
+}
+
 func (app *ParticipantApp) getFATEDomainService() *service.ParticipantFATEService {
 	return &service.ParticipantFATEService{
 		ParticipantFATERepo: app.ParticipantFATERepo,
+		TokenRepo:           app.RegistrationTokenFATERepo,
+		InfraRepo:           app.InfraProviderKubernetesRepo,
+		SizingProfileRepo:   app.ParticipantFATESizingProfileRepo,
+		DiagnosticsRepo:     app.FederationFATEDiagnosticsRepo,
 		ParticipantService: service.ParticipantService{
-			FederationRepo: app.FederationFATERepo,
-			ChartRepo:      app.ChartRepo,
+			FederationRepo:         app.FederationFATERepo,
+			ChartRepo:              app.ChartRepo,
+			RegistrationRecordRepo: app.RegistrationRecordRepo,
 			CertificateService: &service.CertificateService{
 				CertificateAuthorityRepo: app.CertificateAuthorityRepo,
 				CertificateRepo:          app.CertificateRepo,
@@ -193,26 +341,31 @@ func (app *ParticipantApp) GetFATEParticipantList(federationUUID string) (*Parti
 	}
 	domainParticipantList := instanceList.([]entity.ParticipantFATE)
 
+	var exchangeID uint
 	for _, domainParticipant := range domainParticipantList {
 		item := &ParticipantFATEListItem{
-			UUID:              domainParticipant.UUID,
-			Name:              domainParticipant.Name,
-			Description:       domainParticipant.Description,
-			CreatedAt:         domainParticipant.CreatedAt,
-			Type:              domainParticipant.Type,
-			EndpointName:      "Unknown",
-			EndpointUUID:      domainParticipant.EndpointUUID,
-			InfraProviderName: "Unknown",
-			InfraProviderUUID: "Unknown",
-			ChartUUID:         domainParticipant.ChartUUID,
-			Version:           utils.GetChartVersionFromDeploymentYAML(domainParticipant.DeploymentYAML),
-			Namespace:         domainParticipant.Namespace,
-			PartyID:           domainParticipant.PartyID,
-			ClusterUUID:       domainParticipant.ClusterUUID,
-			Status:            domainParticipant.Status,
-			Upgradeable:       app.checkFATEClusterUpgrade(domainParticipant.UUID) && domainParticipant.Status == entity.ParticipantFATEStatusActive,
-			AccessInfo:        domainParticipant.AccessInfo,
-			IsManaged:         domainParticipant.IsManaged,
+			UUID:                domainParticipant.UUID,
+			Name:                domainParticipant.Name,
+			Description:         domainParticipant.Description,
+			CreatedAt:           domainParticipant.CreatedAt,
+			Type:                domainParticipant.Type,
+			EndpointName:        "Unknown",
+			EndpointUUID:        domainParticipant.EndpointUUID,
+			InfraProviderName:   "Unknown",
+			InfraProviderUUID:   "Unknown",
+			ChartUUID:           domainParticipant.ChartUUID,
+			Version:             utils.GetChartVersionFromDeploymentYAML(domainParticipant.DeploymentYAML),
+			Namespace:           domainParticipant.Namespace,
+			PartyID:             domainParticipant.PartyID,
+			ClusterUUID:         domainParticipant.ClusterUUID,
+			Status:              domainParticipant.Status,
+			Upgradeable:         app.checkFATEClusterUpgrade(domainParticipant.UUID) && domainParticipant.Status == entity.ParticipantFATEStatusActive,
+			AccessInfo:          domainParticipant.AccessInfo,
+			IsManaged:           domainParticipant.IsManaged,
+			ExchangeUUID:        domainParticipant.ExchangeUUID,
+			StandbyExchangeUUID: domainParticipant.StandbyExchangeUUID,
+			SizingProfileUUID:   domainParticipant.SizingProfileUUID,
+			Backend:             domainParticipant.Backend,
 		}
 		if endpointInstance, err := app.EndpointKubeFATERepo.GetByUUID(domainParticipant.EndpointUUID); err == nil {
 			endpoint := endpointInstance.(*entity.EndpointKubeFATE)
@@ -224,7 +377,11 @@ func (app *ParticipantApp) GetFATEParticipantList(federationUUID string) (*Parti
 			}
 		}
 		if domainParticipant.Type == entity.ParticipantFATETypeExchange {
-			participants.Exchange = item
+			if participants.Exchange == nil || domainParticipant.ID < exchangeID {
+				participants.Exchange = item
+				exchangeID = domainParticipant.ID
+			}
+			participants.Exchanges = append(participants.Exchanges, item)
 		} else {
 			participants.Clusters = append(participants.Clusters, item)
 		}
@@ -241,24 +398,28 @@ func (app *ParticipantApp) GetFATEExchangeDetail(uuid string) (*FATEExchangeDeta
 	participant := participantInstance.(*entity.ParticipantFATE)
 	participantDetail := &FATEExchangeDetail{
 		ParticipantFATEListItem: ParticipantFATEListItem{
-			UUID:              participant.UUID,
-			Name:              participant.Name,
-			Description:       participant.Description,
-			CreatedAt:         participant.CreatedAt,
-			Type:              participant.Type,
-			EndpointName:      "Unknown",
-			EndpointUUID:      participant.EndpointUUID,
-			InfraProviderName: "Unknown",
-			InfraProviderUUID: "Unknown",
-			ChartUUID:         participant.ChartUUID,
-			Version:           utils.GetChartVersionFromDeploymentYAML(participant.DeploymentYAML),
-			Namespace:         participant.Namespace,
-			PartyID:           participant.PartyID,
-			ClusterUUID:       participant.ClusterUUID,
-			Upgradeable:       app.checkFATEClusterUpgrade(participant.UUID) && participant.Status == entity.ParticipantFATEStatusActive,
-			Status:            participant.Status,
-			AccessInfo:        participant.AccessInfo,
-			IsManaged:         participant.IsManaged,
+			UUID:                participant.UUID,
+			Name:                participant.Name,
+			Description:         participant.Description,
+			CreatedAt:           participant.CreatedAt,
+			Type:                participant.Type,
+			EndpointName:        "Unknown",
+			EndpointUUID:        participant.EndpointUUID,
+			InfraProviderName:   "Unknown",
+			InfraProviderUUID:   "Unknown",
+			ChartUUID:           participant.ChartUUID,
+			Version:             utils.GetChartVersionFromDeploymentYAML(participant.DeploymentYAML),
+			Namespace:           participant.Namespace,
+			PartyID:             participant.PartyID,
+			ClusterUUID:         participant.ClusterUUID,
+			Upgradeable:         app.checkFATEClusterUpgrade(participant.UUID) && participant.Status == entity.ParticipantFATEStatusActive,
+			Status:              participant.Status,
+			AccessInfo:          participant.AccessInfo,
+			IsManaged:           participant.IsManaged,
+			ExchangeUUID:        participant.ExchangeUUID,
+			StandbyExchangeUUID: participant.StandbyExchangeUUID,
+			SizingProfileUUID:   participant.SizingProfileUUID,
+			Backend:             participant.Backend,
 		},
 		DeploymentYAML:           participant.DeploymentYAML,
 		ProxyServerCertInfo:      participant.CertConfig.ProxyServerCertInfo,
@@ -286,24 +447,28 @@ func (app *ParticipantApp) GetFATEClusterDetail(uuid string) (*FATEClusterDetail
 	participant := participantInstance.(*entity.ParticipantFATE)
 	participantDetail := &FATEClusterDetail{
 		ParticipantFATEListItem: ParticipantFATEListItem{
-			UUID:              participant.UUID,
-			Name:              participant.Name,
-			Description:       participant.Description,
-			CreatedAt:         participant.CreatedAt,
-			Type:              participant.Type,
-			EndpointName:      "Unknown",
-			EndpointUUID:      participant.EndpointUUID,
-			InfraProviderName: "Unknown",
-			InfraProviderUUID: "Unknown",
-			ChartUUID:         participant.ChartUUID,
-			Version:           utils.GetChartVersionFromDeploymentYAML(participant.DeploymentYAML),
-			Namespace:         participant.Namespace,
-			PartyID:           participant.PartyID,
-			ClusterUUID:       participant.ClusterUUID,
-			Upgradeable:       app.checkFATEClusterUpgrade(participant.UUID) && participant.Status == entity.ParticipantFATEStatusActive,
-			Status:            participant.Status,
-			AccessInfo:        participant.AccessInfo,
-			IsManaged:         participant.IsManaged,
+			UUID:                participant.UUID,
+			Name:                participant.Name,
+			Description:         participant.Description,
+			CreatedAt:           participant.CreatedAt,
+			Type:                participant.Type,
+			EndpointName:        "Unknown",
+			EndpointUUID:        participant.EndpointUUID,
+			InfraProviderName:   "Unknown",
+			InfraProviderUUID:   "Unknown",
+			ChartUUID:           participant.ChartUUID,
+			Version:             utils.GetChartVersionFromDeploymentYAML(participant.DeploymentYAML),
+			Namespace:           participant.Namespace,
+			PartyID:             participant.PartyID,
+			ClusterUUID:         participant.ClusterUUID,
+			Upgradeable:         app.checkFATEClusterUpgrade(participant.UUID) && participant.Status == entity.ParticipantFATEStatusActive,
+			Status:              participant.Status,
+			AccessInfo:          participant.AccessInfo,
+			IsManaged:           participant.IsManaged,
+			ExchangeUUID:        participant.ExchangeUUID,
+			StandbyExchangeUUID: participant.StandbyExchangeUUID,
+			SizingProfileUUID:   participant.SizingProfileUUID,
+			Backend:             participant.Backend,
 		},
 		DeploymentYAML:           participant.DeploymentYAML,
 		IngressInfo:              participant.IngressInfo,
@@ -0,0 +1,118 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// TestEnvtest runs the reconcilers in a manager against a local API server. The binaries of the API server are found
+// via the KUBEBUILDER_ASSETS environment variable, for example
+// KUBEBUILDER_ASSETS=$(setup-envtest use -p path 1.25.x) go test ./server/controller/...
+func TestEnvtest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("crds")},
+		ErrorIfCRDPathMissing: true,
+	}
+	config, err := testEnv.Start()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, testEnv.Stop())
+	}()
+
+	fateService := &fakeFATEService{}
+	r := NewReconciler(nil, nil, &fakeFederationRepo{}, &fakeOpenFLFederationRepo{}, nil,
+		fateService.repo(), (&fakeOpenFLService{}).repo(), nil, nil, nil,
+		&mock.RegistrationTokenOpenFLRepoMock{}, &mock.RegistrationTokenOpenFLRepoMock{}, nil, nil, nil, nil, nil)
+	r.FATEService = fateService
+	r.PollInterval = 100 * time.Millisecond
+
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     "0",
+		HealthProbeBindAddress: "0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, r.SetupWithManager(mgr))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		assert.NoError(t, mgr.Start(ctx))
+	}()
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	require.NoError(t, err)
+	federation := &v1alpha1.FATEFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "federation", Namespace: "default"},
+		Spec:       v1alpha1.FATEFederationSpec{Domain: "example.com", Mode: entity.FederationFATEModePeerToPeer.String()},
+	}
+	cluster := &v1alpha1.FATECluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+		Spec: v1alpha1.FATEClusterSpec{
+			FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID},
+			PartyID:        9999,
+		},
+	}
+	require.NoError(t, c.Create(ctx, federation))
+	require.NoError(t, c.Create(ctx, cluster))
+
+	// the cluster is created once the federation is ready
+	assert.Eventually(t, func() bool {
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: cluster.Name}, cluster); err != nil {
+			return false
+		}
+		return cluster.Status.UUID != "" && fateService.status(cluster.Status.UUID) == entity.ParticipantFATEStatusInstalling
+	}, 10*time.Second, 100*time.Millisecond)
+	fateService.setStatus(cluster.Status.UUID, entity.ParticipantFATEStatusActive)
+	assert.Eventually(t, func() bool {
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: cluster.Name}, cluster); err != nil {
+			return false
+		}
+		return meta.IsStatusConditionTrue(cluster.Status.Conditions, v1alpha1.ConditionTypeReady)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// the resource is released after the cluster is removed
+	require.NoError(t, c.Delete(ctx, cluster))
+	assert.Eventually(t, func() bool {
+		return fateService.status(cluster.Status.UUID) == entity.ParticipantFATEStatusRemoving
+	}, 10*time.Second, 100*time.Millisecond)
+	fateService.delete(cluster.Status.UUID)
+	assert.Eventually(t, func() bool {
+		return apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: cluster.Name}, cluster))
+	}, 10*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,424 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// fateParticipantHandler contains what differs between reconciling exchanges and clusters
+type fateParticipantHandler struct {
+	object          client.Object
+	status          *v1alpha1.Status
+	federationRef   v1alpha1.LocalObjectReference
+	participantType entity.ParticipantFATEType
+	// deploymentSpec is set by prepare
+	deploymentSpec *service.ParticipantFATEDeploymentSpec
+
+	// prepare builds the validated domain spec. The returned reason is set in the Ready condition if there is an error,
+	// and an empty reason means the error is transient and the request should be retried.
+	prepare func(ctx context.Context, federation *v1alpha1.FATEFederation) (string, error)
+	// matches returns whether an existing participant can be adopted by the resource
+	matches func(participant *entity.ParticipantFATE) bool
+	// reconfigureReason returns why the participant should be reconfigured in place besides the changes in the
+	// deployment spec, and reconfigure starts the reconfiguration
+	reconfigureReason func(participant *entity.ParticipantFATE, participantList []entity.ParticipantFATE) string
+	reconfigure       func(uuid string) error
+	// settingFields returns the type specific fields that only take effect when the participant is installed, in the
+	// same form as the ones returned by unappliedFields
+	settingFields func(settings *service.ParticipantFATEDeploymentSettings) [][3]string
+	create        func(federationUUID string) (*entity.ParticipantFATE, error)
+	upgrade       func(federationUUID, uuid, version string) error
+	remove        func(uuid string, force bool) error
+}
+
+// unappliedFields returns the fields of the spec that are compared with the settings the participant is installed
+// with, each field is a triple of the field name, the current value and the desired value. Changes to these fields are
+// not applied to an installed participant.
+func (h *fateParticipantHandler) unappliedFields(settings *service.ParticipantFATEDeploymentSettings) [][3]string {
+	spec := h.deploymentSpec
+	fields := [][3]string{
+		{"serviceType", settings.ServiceType, spec.ServiceType.String()},
+		{"registry", settings.Registry, spec.RegistryConfig.Registry},
+		{"enablePSP", strconv.FormatBool(settings.EnablePSP), strconv.FormatBool(spec.EnablePSP)},
+	}
+	if h.settingFields != nil {
+		fields = append(fields, h.settingFields(settings)...)
+	}
+	return fields
+}
+
+// FATEExchangeReconciler reconciles the FATEExchange resources
+type FATEExchangeReconciler struct {
+	*Reconciler
+}
+
+// Reconcile converges the exchange to the spec of the resource
+func (r *FATEExchangeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	exchange := &v1alpha1.FATEExchange{}
+	if err := r.Get(ctx, req.NamespacedName, exchange); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	spec := &service.ParticipantFATEExchangeSpec{}
+	handler := &fateParticipantHandler{
+		object:          exchange,
+		status:          &exchange.Status,
+		federationRef:   exchange.Spec.FederationRef,
+		participantType: entity.ParticipantFATETypeExchange,
+		deploymentSpec:  &spec.ParticipantFATEDeploymentSpec,
+		prepare: func(ctx context.Context, federation *v1alpha1.FATEFederation) (string, error) {
+			if federation.Spec.Mode == entity.FederationFATEModePeerToPeer.String() {
+				return v1alpha1.ReasonInvalidSpec, errors.New("a peer-to-peer federation cannot have an exchange")
+			}
+			deploymentSpec, err := toFATEDeploymentSpec(&exchange.Spec.DeploymentSpec, exchange.Name)
+			if err != nil {
+				return v1alpha1.ReasonInvalidSpec, err
+			}
+			spec.ParticipantFATEDeploymentSpec = deploymentSpec
+			if err := r.FATEService.ValidateExchangeSpec(resourceObjectName(federation.Spec.Name, federation.Name), spec); err != nil {
+				return v1alpha1.ReasonInvalidSpec, err
+			}
+			return "", nil
+		},
+		matches: func(participant *entity.ParticipantFATE) bool {
+			return participant.Name == spec.Name
+		},
+		create: func(federationUUID string) (*entity.ParticipantFATE, error) {
+			participant, _, err := r.FATEService.CreateExchangeFromSpec(federationUUID, spec)
+			return participant, err
+		},
+		upgrade: func(federationUUID, uuid, version string) error {
+			_, _, err := r.FATEService.UpgradeExchange(&service.ParticipantFATEExchangeUpgradeRequest{
+				ExchangeUUID:   uuid,
+				FederationUUID: federationUUID,
+				UpgradeVersion: version,
+			})
+			return err
+		},
+		remove: func(uuid string, force bool) error {
+			_, err := r.FATEService.RemoveExchange(uuid, force)
+			return err
+		},
+	}
+	return r.reconcileFATEParticipant(ctx, handler)
+}
+
+// FATEClusterReconciler reconciles the FATECluster resources
+type FATEClusterReconciler struct {
+	*Reconciler
+}
+
+// Reconcile converges the cluster to the spec of the resource. A cluster is reconfigured in place if the exchange it
+// connects to is changed or removed.
+func (r *FATEClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cluster := &v1alpha1.FATECluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	spec := &service.ParticipantFATEClusterSpec{}
+	var exchangeMode bool
+	var exchangeUUID string
+	handler := &fateParticipantHandler{
+		object:          cluster,
+		status:          &cluster.Status,
+		federationRef:   cluster.Spec.FederationRef,
+		participantType: entity.ParticipantFATETypeCluster,
+		deploymentSpec:  &spec.ParticipantFATEDeploymentSpec,
+		prepare: func(ctx context.Context, federation *v1alpha1.FATEFederation) (string, error) {
+			exchangeMode = federation.Spec.Mode != entity.FederationFATEModePeerToPeer.String()
+			if ref := cluster.Spec.ExchangeRef; ref != nil {
+				if !exchangeMode {
+					return v1alpha1.ReasonInvalidSpec, errors.New("clusters in a peer-to-peer federation don't use exchanges")
+				}
+				exchange := &v1alpha1.FATEExchange{}
+				if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: ref.Name}, exchange); err != nil {
+					if client.IgnoreNotFound(err) == nil {
+						return v1alpha1.ReasonDependencyNotReady, errors.Errorf("FATEExchange %s not found", ref.Name)
+					}
+					return "", err
+				}
+				if exchange.Spec.FederationRef.Name != cluster.Spec.FederationRef.Name {
+					return v1alpha1.ReasonInvalidSpec, errors.Errorf("FATEExchange %s is not in FATEFederation %s", ref.Name, cluster.Spec.FederationRef.Name)
+				}
+				if !isReady(exchange, &exchange.Status) {
+					return v1alpha1.ReasonDependencyNotReady, errors.Errorf("FATEExchange %s is not ready", ref.Name)
+				}
+				exchangeUUID = exchange.Status.UUID
+			}
+			deploymentSpec, err := toFATEDeploymentSpec(&cluster.Spec.DeploymentSpec, cluster.Name)
+			if err != nil {
+				return v1alpha1.ReasonInvalidSpec, err
+			}
+			spec.ParticipantFATEDeploymentSpec = deploymentSpec
+			spec.PartyID = cluster.Spec.PartyID
+			spec.EnablePersistence = cluster.Spec.EnablePersistence
+			spec.StorageClass = cluster.Spec.StorageClass
+			if err := r.FATEService.ValidateClusterSpec(resourceObjectName(federation.Spec.Name, federation.Name), spec); err != nil {
+				return v1alpha1.ReasonInvalidSpec, err
+			}
+			return "", nil
+		},
+		matches: func(participant *entity.ParticipantFATE) bool {
+			return participant.PartyID == spec.PartyID
+		},
+		reconfigureReason: func(participant *entity.ParticipantFATE, participantList []entity.ParticipantFATE) string {
+			if !exchangeMode {
+				return ""
+			}
+			currentExchangeUUID := participant.ExchangeUUID
+			found := false
+			for _, p := range participantList {
+				if p.Type != entity.ParticipantFATETypeExchange {
+					continue
+				}
+				if currentExchangeUUID == "" {
+					currentExchangeUUID = p.UUID
+				}
+				found = found || p.UUID == currentExchangeUUID
+			}
+			if !found {
+				return "its exchange is removed"
+			}
+			if exchangeUUID != "" && exchangeUUID != currentExchangeUUID {
+				return fmt.Sprintf("exchange changed from %s to %s", currentExchangeUUID, exchangeUUID)
+			}
+			return ""
+		},
+		reconfigure: func(uuid string) error {
+			_, err := r.FATEService.ConnectClusterToExchange(uuid, exchangeUUID)
+			return err
+		},
+		settingFields: func(settings *service.ParticipantFATEDeploymentSettings) [][3]string {
+			return [][3]string{
+				{"enablePersistence", strconv.FormatBool(settings.EnablePersistence), strconv.FormatBool(spec.EnablePersistence)},
+				{"storageClass", settings.StorageClass, spec.StorageClass},
+			}
+		},
+		create: func(federationUUID string) (*entity.ParticipantFATE, error) {
+			participant, _, err := r.FATEService.CreateClusterFromSpec(federationUUID, exchangeUUID, spec)
+			return participant, err
+		},
+		upgrade: func(federationUUID, uuid, version string) error {
+			_, _, err := r.FATEService.UpgradeCluster(&service.ParticipantFATEClusterUpgradeRequest{
+				ClusterUUID:    uuid,
+				FederationUUID: federationUUID,
+				UpgradeVersion: version,
+			})
+			return err
+		},
+		remove: func(uuid string, force bool) error {
+			_, err := r.FATEService.RemoveCluster(uuid, force)
+			return err
+		},
+	}
+	return r.reconcileFATEParticipant(ctx, handler)
+}
+
+// reconcileFATEParticipant creates, adopts, upgrades, reconfigures or replaces the participant of the resource. The operations run in
+// the background, and their progress is polled until the participant is active. Replacing requires the allow-replace
+// annotation as it deletes the data of the participant.
+func (r *Reconciler) reconcileFATEParticipant(ctx context.Context, h *fateParticipantHandler) (ctrl.Result, error) {
+	object, status := h.object, h.status
+	if !object.GetDeletionTimestamp().IsZero() {
+		return r.finalizeFATEParticipant(ctx, h)
+	}
+	if err := r.addFinalizer(ctx, object); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	federation, message, err := r.getReadyFATEFederation(ctx, object.GetNamespace(), h.federationRef)
+	if err != nil {
+		return r.finish(ctx, object, status, ctrl.Result{}, err)
+	}
+	if federation == nil {
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonDependencyNotReady, message)
+		return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+	}
+	if reason, err := h.prepare(ctx, federation); err != nil {
+		if reason == "" {
+			return r.finish(ctx, object, status, ctrl.Result{}, err)
+		}
+		setReadyCondition(object, status, metav1.ConditionFalse, reason, err.Error())
+		result := ctrl.Result{RequeueAfter: r.resyncInterval()}
+		if reason == v1alpha1.ReasonDependencyNotReady {
+			result.RequeueAfter = r.pollInterval()
+		}
+		return r.finish(ctx, object, status, result, nil)
+	}
+
+	federationUUID := federation.Status.UUID
+	instanceList, err := r.ParticipantFATERepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return r.finish(ctx, object, status, ctrl.Result{}, errors.Wrap(err, "failed to list participants"))
+	}
+	participantList := instanceList.([]entity.ParticipantFATE)
+	var participant *entity.ParticipantFATE
+	for index := range participantList {
+		p := &participantList[index]
+		if p.Type == h.participantType && (status.UUID != "" && p.UUID == status.UUID || status.UUID == "" && h.matches(p)) {
+			participant = p
+			break
+		}
+	}
+	typeName := h.participantType.String()
+	name := h.deploymentSpec.Name
+	if participant != nil && status.UUID == "" {
+		// an existing participant is only taken over when explicitly asked, and never if it is not managed by us
+		message := ""
+		switch {
+		case !participant.IsManaged:
+			message = fmt.Sprintf("%s %s already exists and is not managed by FedLCM, it cannot be taken over", typeName, participant.Name)
+		case object.GetAnnotations()[v1alpha1.AnnotationAdopt] != "true":
+			message = fmt.Sprintf("%s %s already exists, set the %s annotation to \"true\" to take it over", typeName, participant.Name, v1alpha1.AnnotationAdopt)
+		}
+		if message != "" {
+			setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec, message)
+			return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("%s adopted", typeName), "uuid", participant.UUID)
+	}
+	if participant == nil {
+		if status.UUID != "" {
+			log.FromContext(ctx).Info(fmt.Sprintf("%s no longer exists and will be created again", typeName), "uuid", status.UUID)
+			status.UUID = ""
+		}
+		participant, err := h.create(federationUUID)
+		if err != nil {
+			return r.finish(ctx, object, status, ctrl.Result{}, errors.Wrapf(err, "failed to create %s %s", typeName, name))
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("%s created", typeName), "uuid", participant.UUID)
+		status.UUID = participant.UUID
+		status.Phase = participant.Status.String()
+		status.ObservedGeneration = object.GetGeneration()
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress, fmt.Sprintf("%s %s is being installed", typeName, name))
+		return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+	}
+
+	status.UUID = participant.UUID
+	status.Phase = participant.Status.String()
+	switch participant.Status {
+	case entity.ParticipantFATEStatusInstalling, entity.ParticipantFATEStatusRemoving,
+		entity.ParticipantFATEStatusUpgrading, entity.ParticipantFATEStatusReconfiguring:
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+			fmt.Sprintf("%s %s is in %v status", typeName, participant.Name, participant.Status))
+		return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+	case entity.ParticipantFATEStatusFailed:
+		// the failed operation is not retried until the spec is changed
+		if status.ObservedGeneration == object.GetGeneration() {
+			setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonFailed,
+				fmt.Sprintf("%s %s failed, check its events for details and change the spec to retry", typeName, participant.Name))
+			return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+	}
+
+	item, err := r.FATEService.PlanParticipant(participant, h.deploymentSpec)
+	if err != nil {
+		return r.finish(ctx, object, status, ctrl.Result{}, err)
+	}
+	if item.Action == service.FederationFATEApplyActionNone && h.reconfigureReason != nil {
+		if reason := h.reconfigureReason(participant, participantList); reason != "" {
+			item.Action = service.FederationFATEApplyActionReconfigure
+			item.Reason = reason
+		}
+	}
+	switch item.Action {
+	case service.FederationFATEApplyActionNone:
+		settings, err := r.FATEService.GetDeploymentSettings(participant)
+		if err != nil {
+			return r.finish(ctx, object, status, ctrl.Result{}, err)
+		}
+		status.ObservedGeneration = object.GetGeneration()
+		if message := immutableFieldsMessage(h.unappliedFields(settings)...); message != "" {
+			setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec,
+				fmt.Sprintf("%s, these settings of %s %s are only applied when it is installed", message, typeName, participant.Name))
+			return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+		setReadyCondition(object, status, metav1.ConditionTrue, v1alpha1.ReasonReady, "")
+		return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+	case service.FederationFATEApplyActionReconfigure:
+		if err := h.reconfigure(participant.UUID); err != nil {
+			return r.finish(ctx, object, status, ctrl.Result{}, errors.Wrapf(err, "failed to reconfigure %s %s", typeName, participant.Name))
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("%s reconfiguring", typeName), "uuid", participant.UUID, "reason", item.Reason)
+		status.ObservedGeneration = object.GetGeneration()
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+			fmt.Sprintf("%s %s is being reconfigured: %s", typeName, participant.Name, item.Reason))
+	case service.FederationFATEApplyActionUpgrade:
+		if err := h.upgrade(federationUUID, participant.UUID, item.UpgradeVersion); err != nil {
+			return r.finish(ctx, object, status, ctrl.Result{}, errors.Wrapf(err, "failed to upgrade %s %s", typeName, participant.Name))
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("%s upgrading", typeName), "uuid", participant.UUID, "version", item.UpgradeVersion)
+		status.ObservedGeneration = object.GetGeneration()
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+			fmt.Sprintf("%s %s is being upgraded: %s", typeName, participant.Name, item.Reason))
+	case service.FederationFATEApplyActionReplace:
+		if object.GetAnnotations()[v1alpha1.AnnotationAllowReplace] != "true" {
+			status.ObservedGeneration = object.GetGeneration()
+			setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec,
+				fmt.Sprintf("%s %s can only be changed by replacing it: %s, which uninstalls it and deletes its data. "+
+					"Revert the change, or set the %s annotation to \"true\" to replace it", typeName, participant.Name, item.Reason, v1alpha1.AnnotationAllowReplace))
+			return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+		if err := h.remove(participant.UUID, participant.Status == entity.ParticipantFATEStatusFailed); err != nil {
+			return r.finish(ctx, object, status, ctrl.Result{}, errors.Wrapf(err, "failed to remove %s %s", typeName, participant.Name))
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("%s replacing", typeName), "uuid", participant.UUID, "reason", item.Reason)
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+			fmt.Sprintf("%s %s is being replaced: %s", typeName, participant.Name, item.Reason))
+	default:
+		return r.finish(ctx, object, status, ctrl.Result{}, errors.Errorf("unexpected action %s for %s %s", item.Action, typeName, participant.Name))
+	}
+	return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+}
+
+// finalizeFATEParticipant removes the participant of the deleted resource, and then releases the resource
+func (r *Reconciler) finalizeFATEParticipant(ctx context.Context, h *fateParticipantHandler) (ctrl.Result, error) {
+	object, status := h.object, h.status
+	if status.UUID != "" {
+		instanceList, err := r.ParticipantFATERepo.List()
+		if err != nil {
+			return r.finish(ctx, object, status, ctrl.Result{}, errors.Wrap(err, "failed to list participants"))
+		}
+		for _, participant := range instanceList.([]entity.ParticipantFATE) {
+			if participant.UUID != status.UUID {
+				continue
+			}
+			status.Phase = participant.Status.String()
+			if participant.Status != entity.ParticipantFATEStatusRemoving {
+				if err := h.remove(participant.UUID, participant.Status == entity.ParticipantFATEStatusFailed); err != nil {
+					setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonDeletionBlocked, err.Error())
+					return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+				}
+				log.FromContext(ctx).Info(fmt.Sprintf("%s removing", h.participantType), "uuid", participant.UUID)
+			}
+			setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+				fmt.Sprintf("%s %s is being removed", h.participantType, participant.Name))
+			return r.finish(ctx, object, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+		}
+	}
+	return ctrl.Result{}, r.removeFinalizer(ctx, object)
+}
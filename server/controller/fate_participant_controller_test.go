@@ -0,0 +1,342 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const testNamespace = "fedlcm"
+
+// reconcileObject runs the reconciler and reloads the object, it returns false if the object no longer exists
+func reconcileObject(t *testing.T, r reconcile.Reconciler, c client.Client, object client.Object) (ctrl.Result, bool) {
+	key := types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	if err := c.Get(context.Background(), key, object); err != nil {
+		assert.NoError(t, client.IgnoreNotFound(err))
+		return result, false
+	}
+	return result, true
+}
+
+func assertReadyCondition(t *testing.T, status *v1alpha1.Status, conditionStatus metav1.ConditionStatus, reason string) {
+	condition := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypeReady)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, conditionStatus, condition.Status)
+		assert.Equal(t, reason, condition.Reason, condition.Message)
+	}
+}
+
+func TestFATEReconcilers_Lifecycle(t *testing.T) {
+	fateService := &fakeFATEService{}
+	federation := &v1alpha1.FATEFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "federation", Namespace: testNamespace},
+		Spec:       v1alpha1.FATEFederationSpec{Domain: "example.com"},
+	}
+	exchange := &v1alpha1.FATEExchange{
+		ObjectMeta: metav1.ObjectMeta{Name: "exchange", Namespace: testNamespace},
+		Spec: v1alpha1.FATEExchangeSpec{
+			FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID},
+		},
+	}
+	cluster := &v1alpha1.FATECluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: testNamespace},
+		Spec: v1alpha1.FATEClusterSpec{
+			FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+			ExchangeRef:    &v1alpha1.LocalObjectReference{Name: exchange.Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID},
+			PartyID:        9999,
+		},
+	}
+	openflService := &fakeOpenFLService{}
+	scheme, err := NewScheme()
+	assert.NoError(t, err)
+	r := NewReconciler(nil, nil, &fakeFederationRepo{}, &fakeOpenFLFederationRepo{}, nil,
+		fateService.repo(), openflService.repo(), nil, nil, nil,
+		&mock.RegistrationTokenOpenFLRepoMock{}, &mock.RegistrationTokenOpenFLRepoMock{}, nil, nil, nil, nil, nil)
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(federation, exchange, cluster).Build()
+	r.FATEService = fateService
+	r.OpenFLService = openflService
+	r.PollInterval = time.Second
+	federationReconciler := &FATEFederationReconciler{r}
+	exchangeReconciler := &FATEExchangeReconciler{r}
+	clusterReconciler := &FATEClusterReconciler{r}
+
+	// the cluster waits for the federation
+	result, _ := reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assert.Equal(t, time.Second, result.RequeueAfter)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonDependencyNotReady)
+	assert.Contains(t, cluster.Finalizers, v1alpha1.Finalizer)
+
+	reconcileObject(t, federationReconciler, r.Client, federation)
+	assertReadyCondition(t, &federation.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+	assert.NotEmpty(t, federation.Status.UUID)
+
+	// and then for the exchange
+	reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonDependencyNotReady)
+
+	reconcileObject(t, exchangeReconciler, r.Client, exchange)
+	assertReadyCondition(t, &exchange.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	assert.NotEmpty(t, exchange.Status.UUID)
+	fateService.setStatus(exchange.Status.UUID, entity.ParticipantFATEStatusActive)
+	reconcileObject(t, exchangeReconciler, r.Client, exchange)
+	assertReadyCondition(t, &exchange.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	created := fateService.get(cluster.Status.UUID)
+	if assert.NotNil(t, created) {
+		assert.Equal(t, exchange.Status.UUID, created.ExchangeUUID)
+		assert.Equal(t, federation.Status.UUID, created.FederationUUID)
+		assert.Equal(t, "cluster", created.Name)
+	}
+	fateService.setStatus(cluster.Status.UUID, entity.ParticipantFATEStatusActive)
+	reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+	assert.Equal(t, entity.ParticipantFATEStatusActive.String(), cluster.Status.Phase)
+
+	// a chart change is rolled out as an upgrade
+	cluster.Spec.ChartUUID = testUpgradeChartUUID
+	cluster.Generation++
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assert.Equal(t, []string{cluster.Status.UUID}, fateService.upgraded)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	fateService.setStatus(cluster.Status.UUID, entity.ParticipantFATEStatusActive)
+	reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	// the federation can't be deleted before its participants
+	assert.NoError(t, r.Delete(context.Background(), federation))
+	_, exists := reconcileObject(t, federationReconciler, r.Client, federation)
+	assert.True(t, exists)
+	assertReadyCondition(t, &federation.Status, metav1.ConditionFalse, v1alpha1.ReasonDeletionBlocked)
+
+	// deleting the resource removes the cluster before releasing the resource
+	clusterUUID := cluster.Status.UUID
+	assert.NoError(t, r.Delete(context.Background(), cluster))
+	_, exists = reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assert.True(t, exists)
+	assert.Equal(t, []string{clusterUUID}, fateService.removed)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	fateService.delete(clusterUUID)
+	_, exists = reconcileObject(t, clusterReconciler, r.Client, cluster)
+	assert.False(t, exists)
+}
+
+func TestFATEClusterReconciler_Adopt(t *testing.T) {
+	fateService := &fakeFATEService{}
+	federation := &v1alpha1.FATEFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "federation", Namespace: testNamespace},
+		Spec:       v1alpha1.FATEFederationSpec{Domain: "example.com", Mode: entity.FederationFATEModePeerToPeer.String()},
+	}
+	cluster := &v1alpha1.FATECluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: testNamespace},
+		Spec: v1alpha1.FATEClusterSpec{
+			FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID, Namespace: "fate-9999"},
+			PartyID:        9999,
+		},
+	}
+	openflService := &fakeOpenFLService{}
+	scheme, err := NewScheme()
+	assert.NoError(t, err)
+	r := NewReconciler(nil, nil, &fakeFederationRepo{}, &fakeOpenFLFederationRepo{}, nil,
+		fateService.repo(), openflService.repo(), nil, nil, nil,
+		&mock.RegistrationTokenOpenFLRepoMock{}, &mock.RegistrationTokenOpenFLRepoMock{}, nil, nil, nil, nil, nil)
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(federation, cluster).Build()
+	r.FATEService = fateService
+	r.OpenFLService = openflService
+	reconcileObject(t, &FATEFederationReconciler{r}, r.Client, federation)
+
+	existing := fateService.create(entity.ParticipantFATETypeCluster, federation.Status.UUID, &service.ParticipantFATEDeploymentSpec{
+		Name:        "existing",
+		ChartUUID:   testChartUUID,
+		Namespace:   "fate-9999",
+		ServiceType: entity.ParticipantDefaultServiceTypeLoadBalancer,
+	})
+	existing.PartyID = 9999
+	existing.Status = entity.ParticipantFATEStatusActive
+
+	// the existing cluster is not taken over without the annotation
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assert.Empty(t, cluster.Status.UUID)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec)
+
+	// nor if it is not managed by FedLCM
+	cluster.Annotations = map[string]string{v1alpha1.AnnotationAdopt: "true"}
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	existing.IsManaged = false
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assert.Empty(t, cluster.Status.UUID)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec)
+	assert.Empty(t, fateService.removed)
+
+	existing.IsManaged = true
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assert.Equal(t, existing.UUID, cluster.Status.UUID)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+	assert.Len(t, fateService.participants, 1)
+
+	// settings only applied at installation are reported instead of being ignored
+	cluster.Spec.StorageClass = "fast"
+	cluster.Generation++
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec)
+	assert.Contains(t, meta.FindStatusCondition(cluster.Status.Conditions, v1alpha1.ConditionTypeReady).Message, "storageClass")
+	assert.Empty(t, fateService.removed)
+	cluster.Spec.StorageClass = ""
+	cluster.Generation++
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	// an exchange reference is invalid in a peer-to-peer federation
+	cluster.Spec.ExchangeRef = &v1alpha1.LocalObjectReference{Name: "exchange"}
+	cluster.Generation++
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec)
+}
+
+func TestFATEClusterReconciler_ExchangeChanged(t *testing.T) {
+	fateService := &fakeFATEService{}
+	federation := &v1alpha1.FATEFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "federation", Namespace: testNamespace},
+		Spec:       v1alpha1.FATEFederationSpec{Domain: "example.com"},
+	}
+	var exchanges []*v1alpha1.FATEExchange
+	for _, name := range []string{"exchange-1", "exchange-2"} {
+		exchanges = append(exchanges, &v1alpha1.FATEExchange{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: v1alpha1.FATEExchangeSpec{
+				FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+				DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID},
+			},
+		})
+	}
+	cluster := &v1alpha1.FATECluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: testNamespace},
+		Spec: v1alpha1.FATEClusterSpec{
+			FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+			ExchangeRef:    &v1alpha1.LocalObjectReference{Name: exchanges[0].Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID},
+			PartyID:        9999,
+		},
+	}
+	openflService := &fakeOpenFLService{}
+	scheme, err := NewScheme()
+	assert.NoError(t, err)
+	r := NewReconciler(nil, nil, &fakeFederationRepo{}, &fakeOpenFLFederationRepo{}, nil,
+		fateService.repo(), openflService.repo(), nil, nil, nil,
+		&mock.RegistrationTokenOpenFLRepoMock{}, &mock.RegistrationTokenOpenFLRepoMock{}, nil, nil, nil, nil, nil)
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(federation, exchanges[0], exchanges[1], cluster).Build()
+	r.FATEService = fateService
+	r.OpenFLService = openflService
+	reconcileObject(t, &FATEFederationReconciler{r}, r.Client, federation)
+	for _, exchange := range exchanges {
+		reconcileObject(t, &FATEExchangeReconciler{r}, r.Client, exchange)
+		fateService.setStatus(exchange.Status.UUID, entity.ParticipantFATEStatusActive)
+		reconcileObject(t, &FATEExchangeReconciler{r}, r.Client, exchange)
+		assertReadyCondition(t, &exchange.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+	}
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	fateService.setStatus(cluster.Status.UUID, entity.ParticipantFATEStatusActive)
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	// the cluster is reconfigured in place instead of being replaced
+	clusterUUID := cluster.Status.UUID
+	cluster.Spec.ExchangeRef = &v1alpha1.LocalObjectReference{Name: exchanges[1].Name}
+	cluster.Generation++
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	assert.Equal(t, []string{clusterUUID}, fateService.reconfigured)
+	assert.Empty(t, fateService.removed)
+	assert.Equal(t, exchanges[1].Status.UUID, fateService.get(clusterUUID).ExchangeUUID)
+
+	fateService.setStatus(clusterUUID, entity.ParticipantFATEStatusActive)
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+	assert.Equal(t, clusterUUID, cluster.Status.UUID)
+}
+
+func TestFATEClusterReconciler_Replace(t *testing.T) {
+	fateService := &fakeFATEService{}
+	federation := &v1alpha1.FATEFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "federation", Namespace: testNamespace},
+		Spec:       v1alpha1.FATEFederationSpec{Domain: "example.com", Mode: entity.FederationFATEModePeerToPeer.String()},
+	}
+	cluster := &v1alpha1.FATECluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: testNamespace},
+		Spec: v1alpha1.FATEClusterSpec{
+			FederationRef:  v1alpha1.LocalObjectReference{Name: federation.Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{ChartUUID: testChartUUID, Namespace: "fate-9999"},
+			PartyID:        9999,
+		},
+	}
+	openflService := &fakeOpenFLService{}
+	scheme, err := NewScheme()
+	assert.NoError(t, err)
+	r := NewReconciler(nil, nil, &fakeFederationRepo{}, &fakeOpenFLFederationRepo{}, nil,
+		fateService.repo(), openflService.repo(), nil, nil, nil,
+		&mock.RegistrationTokenOpenFLRepoMock{}, &mock.RegistrationTokenOpenFLRepoMock{}, nil, nil, nil, nil, nil)
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(federation, cluster).Build()
+	r.FATEService = fateService
+	r.OpenFLService = openflService
+	reconcileObject(t, &FATEFederationReconciler{r}, r.Client, federation)
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	clusterUUID := cluster.Status.UUID
+	fateService.setStatus(clusterUUID, entity.ParticipantFATEStatusActive)
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	// a change that can't be applied in place is reported and the cluster is kept running
+	cluster.Spec.Namespace = "fate-9999-new"
+	cluster.Generation++
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec)
+	assert.Contains(t, meta.FindStatusCondition(cluster.Status.Conditions, v1alpha1.ConditionTypeReady).Message, v1alpha1.AnnotationAllowReplace)
+	assert.Empty(t, fateService.removed)
+	assert.Equal(t, entity.ParticipantFATEStatusActive, fateService.get(clusterUUID).Status)
+
+	// the cluster is replaced once it is allowed
+	cluster.Annotations = map[string]string{v1alpha1.AnnotationAllowReplace: "true"}
+	assert.NoError(t, r.Update(context.Background(), cluster))
+	reconcileObject(t, &FATEClusterReconciler{r}, r.Client, cluster)
+	assertReadyCondition(t, &cluster.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	assert.Equal(t, []string{clusterUUID}, fateService.removed)
+}
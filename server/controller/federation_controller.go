@@ -0,0 +1,229 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appService "github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// FATEFederationReconciler reconciles the FATEFederation resources
+type FATEFederationReconciler struct {
+	*Reconciler
+}
+
+// Reconcile creates the FATE federation of the resource, or adopts the existing one of the same name. The federation is
+// deleted with the resource, once all its participants are removed.
+func (r *FATEFederationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	federation := &v1alpha1.FATEFederation{}
+	if err := r.Get(ctx, req.NamespacedName, federation); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	status := &federation.Status
+	if !federation.DeletionTimestamp.IsZero() {
+		if status.UUID != "" {
+			if err := r.FederationApp.DeleteFATEFederation(status.UUID); err != nil {
+				setReadyCondition(federation, status, metav1.ConditionFalse, v1alpha1.ReasonDeletionBlocked, err.Error())
+				return r.finish(ctx, federation, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+			}
+			log.FromContext(ctx).Info("federation deleted", "uuid", status.UUID)
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, federation)
+	}
+	if err := r.addFinalizer(ctx, federation); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	name := resourceObjectName(federation.Spec.Name, federation.Name)
+	mode, err := parseFATEFederationMode(federation.Spec.Mode)
+	if err == nil && !utils.IsDomainName(federation.Spec.Domain) {
+		err = errors.Errorf("invalid domain name %s", federation.Spec.Domain)
+	}
+	if err != nil {
+		setReadyCondition(federation, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec, err.Error())
+		return r.finish(ctx, federation, status, ctrl.Result{}, nil)
+	}
+
+	instanceList, err := r.FederationFATERepo.List()
+	if err != nil {
+		return r.finish(ctx, federation, status, ctrl.Result{}, errors.Wrap(err, "failed to list federations"))
+	}
+	federationList, _ := instanceList.([]entity.FederationFATE)
+	var existing *entity.FederationFATE
+	for index := range federationList {
+		if status.UUID != "" && federationList[index].UUID == status.UUID ||
+			status.UUID == "" && federationList[index].Name == name {
+			existing = &federationList[index]
+			break
+		}
+	}
+	if existing == nil {
+		federationUUID, err := r.FederationApp.CreateFATEFederation(&appService.FederationFATECreationRequest{
+			FederationCreationRequest: appService.FederationCreationRequest{
+				Name:        name,
+				Description: federation.Spec.Description,
+			},
+			Domain: federation.Spec.Domain,
+			Mode:   mode,
+		})
+		if err != nil {
+			return r.finish(ctx, federation, status, ctrl.Result{}, errors.Wrap(err, "failed to create federation"))
+		}
+		log.FromContext(ctx).Info("federation created", "uuid", federationUUID)
+		status.UUID = federationUUID
+	} else {
+		status.UUID = existing.UUID
+		if message := immutableFieldsMessage(
+			[3]string{"name", existing.Name, name},
+			[3]string{"domain", existing.Domain, federation.Spec.Domain},
+			[3]string{"mode", existing.Mode.String(), mode.String()},
+		); message != "" {
+			setReadyCondition(federation, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec, message)
+			return r.finish(ctx, federation, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+	}
+	status.ObservedGeneration = federation.Generation
+	setReadyCondition(federation, status, metav1.ConditionTrue, v1alpha1.ReasonReady, "")
+	return r.finish(ctx, federation, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+}
+
+// OpenFLFederationReconciler reconciles the OpenFLFederation resources
+type OpenFLFederationReconciler struct {
+	*Reconciler
+}
+
+// Reconcile creates the OpenFL federation of the resource, or adopts the existing one of the same name. The federation
+// is deleted with the resource, once all its participants are removed.
+func (r *OpenFLFederationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	federation := &v1alpha1.OpenFLFederation{}
+	if err := r.Get(ctx, req.NamespacedName, federation); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	status := &federation.Status
+	if !federation.DeletionTimestamp.IsZero() {
+		if status.UUID != "" {
+			if err := r.FederationApp.DeleteOpenFLFederation(status.UUID); err != nil {
+				setReadyCondition(federation, status, metav1.ConditionFalse, v1alpha1.ReasonDeletionBlocked, err.Error())
+				return r.finish(ctx, federation, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+			}
+			log.FromContext(ctx).Info("federation deleted", "uuid", status.UUID)
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, federation)
+	}
+	if err := r.addFinalizer(ctx, federation); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	name := resourceObjectName(federation.Spec.Name, federation.Name)
+	deploymentMethod, err := parseOpenFLDeploymentMethod(federation.Spec.DeploymentMethod)
+	if err == nil && !utils.IsDomainName(federation.Spec.Domain) {
+		err = errors.Errorf("invalid domain name %s", federation.Spec.Domain)
+	}
+	if err != nil {
+		setReadyCondition(federation, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec, err.Error())
+		return r.finish(ctx, federation, status, ctrl.Result{}, nil)
+	}
+
+	instanceList, err := r.FederationOpenFLRepo.List()
+	if err != nil {
+		return r.finish(ctx, federation, status, ctrl.Result{}, errors.Wrap(err, "failed to list federations"))
+	}
+	federationList, _ := instanceList.([]entity.FederationOpenFL)
+	var existing *entity.FederationOpenFL
+	for index := range federationList {
+		if status.UUID != "" && federationList[index].UUID == status.UUID ||
+			status.UUID == "" && federationList[index].Name == name {
+			existing = &federationList[index]
+			break
+		}
+	}
+	if existing == nil {
+		federationUUID, err := r.FederationApp.CreateOpenFLFederation(&appService.FederationOpenFLCreationRequest{
+			FederationCreationRequest: appService.FederationCreationRequest{
+				Name:        name,
+				Description: federation.Spec.Description,
+			},
+			Domain:           federation.Spec.Domain,
+			DeploymentMethod: deploymentMethod,
+		})
+		if err != nil {
+			return r.finish(ctx, federation, status, ctrl.Result{}, errors.Wrap(err, "failed to create federation"))
+		}
+		log.FromContext(ctx).Info("federation created", "uuid", federationUUID)
+		status.UUID = federationUUID
+	} else {
+		status.UUID = existing.UUID
+		if message := immutableFieldsMessage(
+			[3]string{"name", existing.Name, name},
+			[3]string{"domain", existing.Domain, federation.Spec.Domain},
+			[3]string{"deploymentMethod", existing.DeploymentMethod.String(), deploymentMethod.String()},
+		); message != "" {
+			setReadyCondition(federation, status, metav1.ConditionFalse, v1alpha1.ReasonInvalidSpec, message)
+			return r.finish(ctx, federation, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+	}
+	status.ObservedGeneration = federation.Generation
+	setReadyCondition(federation, status, metav1.ConditionTrue, v1alpha1.ReasonReady, "")
+	return r.finish(ctx, federation, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+}
+
+// getReadyFATEFederation returns the FATEFederation referred by a participant resource. A nil federation and the
+// reason are returned if it is not ready yet.
+func (r *Reconciler) getReadyFATEFederation(ctx context.Context, namespace string, ref v1alpha1.LocalObjectReference) (*v1alpha1.FATEFederation, string, error) {
+	federation := &v1alpha1.FATEFederation{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, federation); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return nil, fmt.Sprintf("FATEFederation %s not found", ref.Name), nil
+		}
+		return nil, "", err
+	}
+	if !federation.DeletionTimestamp.IsZero() {
+		return nil, fmt.Sprintf("FATEFederation %s is being deleted", ref.Name), nil
+	}
+	if !isReady(federation, &federation.Status) {
+		return nil, fmt.Sprintf("FATEFederation %s is not ready", ref.Name), nil
+	}
+	return federation, "", nil
+}
+
+// getReadyOpenFLFederation returns the OpenFLFederation referred by a participant resource. A nil federation and the
+// reason are returned if it is not ready yet.
+func (r *Reconciler) getReadyOpenFLFederation(ctx context.Context, namespace string, ref v1alpha1.LocalObjectReference) (*v1alpha1.OpenFLFederation, string, error) {
+	federation := &v1alpha1.OpenFLFederation{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, federation); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return nil, fmt.Sprintf("OpenFLFederation %s not found", ref.Name), nil
+		}
+		return nil, "", err
+	}
+	if !federation.DeletionTimestamp.IsZero() {
+		return nil, fmt.Sprintf("OpenFLFederation %s is being deleted", ref.Name), nil
+	}
+	if !isReady(federation, &federation.Status) {
+		return nil, fmt.Sprintf("OpenFLFederation %s is not ready", ref.Name), nil
+	}
+	return federation, "", nil
+}
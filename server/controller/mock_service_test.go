@@ -0,0 +1,379 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	testChartUUID        = "test-chart"
+	testUpgradeChartUUID = "test-upgrade-chart"
+)
+
+// fakeFATEService keeps the participants in memory, the statuses are changed by the tests to simulate the progress
+type fakeFATEService struct {
+	mu           sync.Mutex
+	participants []*entity.ParticipantFATE
+	// settings are the deployment settings of the created participants, the others use the default settings
+	settings     map[string]*service.ParticipantFATEDeploymentSettings
+	upgraded     []string
+	reconfigured []string
+	removed      []string
+}
+
+var _ FATEService = (*fakeFATEService)(nil)
+
+func (s *fakeFATEService) repo() *mock.ParticipantFATERepoMock {
+	list := func(federationUUID string) (interface{}, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		participantList := []entity.ParticipantFATE{}
+		for _, participant := range s.participants {
+			if federationUUID == "" || participant.FederationUUID == federationUUID {
+				participantList = append(participantList, *participant)
+			}
+		}
+		return participantList, nil
+	}
+	return &mock.ParticipantFATERepoMock{
+		ListFn: func() (interface{}, error) {
+			return list("")
+		},
+		ListByFederationUUIDFn: list,
+	}
+}
+
+func (s *fakeFATEService) get(uuid string) *entity.ParticipantFATE {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, participant := range s.participants {
+		if participant.UUID == uuid {
+			return participant
+		}
+	}
+	return nil
+}
+
+// status returns the status of a participant, or the unknown status if it doesn't exist
+func (s *fakeFATEService) status(uuid string) entity.ParticipantFATEStatus {
+	participant := s.get(uuid)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if participant == nil {
+		return entity.ParticipantFATEStatusUnknown
+	}
+	return participant.Status
+}
+
+func (s *fakeFATEService) setStatus(uuid string, status entity.ParticipantFATEStatus) {
+	participant := s.get(uuid)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant.Status = status
+}
+
+func (s *fakeFATEService) delete(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index, participant := range s.participants {
+		if participant.UUID == uuid {
+			s.participants = append(s.participants[:index], s.participants[index+1:]...)
+			return
+		}
+	}
+}
+
+func (s *fakeFATEService) validate(spec *service.ParticipantFATEDeploymentSpec) error {
+	if spec.ChartUUID == "" {
+		return errors.New("missing chart uuid")
+	}
+	if spec.ServiceType == entity.ParticipantDefaultServiceTypeUnknown {
+		spec.ServiceType = entity.ParticipantDefaultServiceTypeLoadBalancer
+	}
+	return nil
+}
+
+func (s *fakeFATEService) ValidateExchangeSpec(federationName string, spec *service.ParticipantFATEExchangeSpec) error {
+	if spec.Namespace == "" {
+		spec.Namespace = federationName + "-fate-exchange"
+	}
+	return s.validate(&spec.ParticipantFATEDeploymentSpec)
+}
+
+func (s *fakeFATEService) ValidateClusterSpec(federationName string, spec *service.ParticipantFATEClusterSpec) error {
+	if spec.Namespace == "" {
+		spec.Namespace = fmt.Sprintf("%s-fate-%v", federationName, spec.PartyID)
+	}
+	return s.validate(&spec.ParticipantFATEDeploymentSpec)
+}
+
+func (s *fakeFATEService) PlanParticipant(participant *entity.ParticipantFATE, spec *service.ParticipantFATEDeploymentSpec) (*service.FederationFATEApplyPlanItem, error) {
+	item := &service.FederationFATEApplyPlanItem{
+		Action: service.FederationFATEApplyActionNone,
+		Name:   participant.Name,
+		UUID:   participant.UUID,
+	}
+	switch {
+	case participant.Status == entity.ParticipantFATEStatusFailed || participant.Namespace != spec.Namespace:
+		item.Action = service.FederationFATEApplyActionReplace
+		item.Reason = "changed"
+	case participant.ChartUUID != spec.ChartUUID && spec.ChartUUID == testUpgradeChartUUID:
+		item.Action = service.FederationFATEApplyActionUpgrade
+		item.UpgradeVersion = "v2"
+	case participant.ChartUUID != spec.ChartUUID:
+		item.Action = service.FederationFATEApplyActionReplace
+		item.Reason = "chart changed"
+	}
+	return item, nil
+}
+
+func (s *fakeFATEService) create(participantType entity.ParticipantFATEType, federationUUID string, spec *service.ParticipantFATEDeploymentSpec) *entity.ParticipantFATE {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant := &entity.ParticipantFATE{
+		Participant: entity.Participant{
+			UUID:           uuid.NewV4().String(),
+			Name:           spec.Name,
+			FederationUUID: federationUUID,
+			ChartUUID:      spec.ChartUUID,
+			Namespace:      spec.Namespace,
+			IsManaged:      true,
+		},
+		Type:   participantType,
+		Status: entity.ParticipantFATEStatusInstalling,
+	}
+	s.participants = append(s.participants, participant)
+	if s.settings == nil {
+		s.settings = map[string]*service.ParticipantFATEDeploymentSettings{}
+	}
+	s.settings[participant.UUID] = &service.ParticipantFATEDeploymentSettings{
+		ServiceType: spec.ServiceType.String(),
+		Registry:    spec.RegistryConfig.Registry,
+		EnablePSP:   spec.EnablePSP,
+	}
+	return participant
+}
+
+func (s *fakeFATEService) GetDeploymentSettings(participant *entity.ParticipantFATE) (*service.ParticipantFATEDeploymentSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if settings, ok := s.settings[participant.UUID]; ok {
+		copied := *settings
+		return &copied, nil
+	}
+	return &service.ParticipantFATEDeploymentSettings{
+		ServiceType: entity.ParticipantDefaultServiceTypeLoadBalancer.String(),
+	}, nil
+}
+
+func (s *fakeFATEService) CreateExchangeFromSpec(federationUUID string, spec *service.ParticipantFATEExchangeSpec) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	return s.create(entity.ParticipantFATETypeExchange, federationUUID, &spec.ParticipantFATEDeploymentSpec), &sync.WaitGroup{}, nil
+}
+
+func (s *fakeFATEService) CreateClusterFromSpec(federationUUID, exchangeUUID string, spec *service.ParticipantFATEClusterSpec) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	participant := s.create(entity.ParticipantFATETypeCluster, federationUUID, &spec.ParticipantFATEDeploymentSpec)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant.PartyID = spec.PartyID
+	participant.ExchangeUUID = exchangeUUID
+	s.settings[participant.UUID].EnablePersistence = spec.EnablePersistence
+	s.settings[participant.UUID].StorageClass = spec.StorageClass
+	return participant, &sync.WaitGroup{}, nil
+}
+
+func (s *fakeFATEService) ConnectClusterToExchange(clusterUUID, exchangeUUID string) (*sync.WaitGroup, error) {
+	participant := s.get(clusterUUID)
+	if participant == nil {
+		return nil, errors.Errorf("participant %s not found", clusterUUID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant.Status = entity.ParticipantFATEStatusReconfiguring
+	participant.ExchangeUUID = exchangeUUID
+	s.reconfigured = append(s.reconfigured, clusterUUID)
+	return &sync.WaitGroup{}, nil
+}
+
+func (s *fakeFATEService) upgrade(uuid string) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	participant := s.get(uuid)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant.Status = entity.ParticipantFATEStatusUpgrading
+	participant.ChartUUID = testUpgradeChartUUID
+	s.upgraded = append(s.upgraded, uuid)
+	return participant, &sync.WaitGroup{}, nil
+}
+
+func (s *fakeFATEService) UpgradeExchange(req *service.ParticipantFATEExchangeUpgradeRequest) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	return s.upgrade(req.ExchangeUUID)
+}
+
+func (s *fakeFATEService) UpgradeCluster(req *service.ParticipantFATEClusterUpgradeRequest) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	return s.upgrade(req.ClusterUUID)
+}
+
+func (s *fakeFATEService) remove(uuid string) (*sync.WaitGroup, error) {
+	participant := s.get(uuid)
+	if participant == nil {
+		return nil, errors.Errorf("participant %s not found", uuid)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant.Status = entity.ParticipantFATEStatusRemoving
+	s.removed = append(s.removed, uuid)
+	return &sync.WaitGroup{}, nil
+}
+
+func (s *fakeFATEService) RemoveExchange(uuid string, force bool) (*sync.WaitGroup, error) {
+	return s.remove(uuid)
+}
+
+func (s *fakeFATEService) RemoveCluster(uuid string, force bool) (*sync.WaitGroup, error) {
+	return s.remove(uuid)
+}
+
+// fakeFederationRepo keeps the created federations in memory
+type fakeFederationRepo struct {
+	mock.FederationFATERepoMock
+	mu             sync.Mutex
+	federationList []entity.FederationFATE
+}
+
+func (r *fakeFederationRepo) Create(instance interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.federationList = append(r.federationList, *instance.(*entity.FederationFATE))
+	return nil
+}
+
+func (r *fakeFederationRepo) List() (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]entity.FederationFATE{}, r.federationList...), nil
+}
+
+// fakeOpenFLFederationRepo keeps the created OpenFL federations in memory
+type fakeOpenFLFederationRepo struct {
+	mock.FederationFATERepoMock
+	mu             sync.Mutex
+	federationList []entity.FederationOpenFL
+}
+
+func (r *fakeOpenFLFederationRepo) Create(instance interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.federationList = append(r.federationList, *instance.(*entity.FederationOpenFL))
+	return nil
+}
+
+func (r *fakeOpenFLFederationRepo) List() (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]entity.FederationOpenFL{}, r.federationList...), nil
+}
+
+// fakeOpenFLService keeps the directors in memory and records the creation requests
+type fakeOpenFLService struct {
+	mu           sync.Mutex
+	participants []*entity.ParticipantOpenFL
+	requests     []*service.ParticipantOpenFLDirectorCreationRequest
+	removed      []string
+}
+
+var _ OpenFLService = (*fakeOpenFLService)(nil)
+
+func (s *fakeOpenFLService) repo() *mock.ParticipantOpenFLRepoMock {
+	list := func(federationUUID string) (interface{}, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		participantList := []entity.ParticipantOpenFL{}
+		for _, participant := range s.participants {
+			if federationUUID == "" || participant.FederationUUID == federationUUID {
+				participantList = append(participantList, *participant)
+			}
+		}
+		return participantList, nil
+	}
+	return &mock.ParticipantOpenFLRepoMock{
+		ListFn: func() (interface{}, error) {
+			return list("")
+		},
+		ListByFederationUUIDFn: list,
+	}
+}
+
+func (s *fakeOpenFLService) setStatus(uuid string, status entity.ParticipantOpenFLStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, participant := range s.participants {
+		if participant.UUID == uuid {
+			participant.Status = status
+		}
+	}
+}
+
+func (s *fakeOpenFLService) delete(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index, participant := range s.participants {
+		if participant.UUID == uuid {
+			s.participants = append(s.participants[:index], s.participants[index+1:]...)
+			return
+		}
+	}
+}
+
+func (s *fakeOpenFLService) GetOpenFLDirectorYAML(req *service.ParticipantOpenFLDirectorYAMLCreationRequest) (string, error) {
+	return "name: " + req.Name, nil
+}
+
+func (s *fakeOpenFLService) CreateDirector(req *service.ParticipantOpenFLDirectorCreationRequest) (*entity.ParticipantOpenFL, *sync.WaitGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant := &entity.ParticipantOpenFL{
+		Participant: entity.Participant{
+			UUID:           uuid.NewV4().String(),
+			Name:           req.Name,
+			FederationUUID: req.FederationUUID,
+			EndpointUUID:   req.EndpointUUID,
+			ChartUUID:      req.ChartUUID,
+			Namespace:      req.Namespace,
+			DeploymentYAML: req.DeploymentYAML,
+			IsManaged:      true,
+		},
+		Type:             entity.ParticipantOpenFLTypeDirector,
+		Status:           entity.ParticipantOpenFLStatusInstallingDirector,
+		DeploymentMethod: entity.FederationOpenFLDeploymentMethodKubeFATE,
+	}
+	s.participants = append(s.participants, participant)
+	s.requests = append(s.requests, req)
+	return participant, &sync.WaitGroup{}, nil
+}
+
+func (s *fakeOpenFLService) RemoveDirector(uuid string, force bool) (*sync.WaitGroup, error) {
+	s.setStatus(uuid, entity.ParticipantOpenFLStatusRemoving)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removed = append(s.removed, uuid)
+	return &sync.WaitGroup{}, nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// OpenFLDirectorReconciler reconciles the OpenFLDirector resources
+type OpenFLDirectorReconciler struct {
+	*Reconciler
+}
+
+// Reconcile creates or adopts the director of the federation. As directors can't be upgraded, any change of the
+// deployment spec replaces the director.
+func (r *OpenFLDirectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	director := &v1alpha1.OpenFLDirector{}
+	if err := r.Get(ctx, req.NamespacedName, director); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	status := &director.Status
+	if !director.DeletionTimestamp.IsZero() {
+		return r.finalizeOpenFLDirector(ctx, director)
+	}
+	if err := r.addFinalizer(ctx, director); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	federation, message, err := r.getReadyOpenFLFederation(ctx, director.Namespace, director.Spec.FederationRef)
+	if err != nil {
+		return r.finish(ctx, director, status, ctrl.Result{}, err)
+	}
+	if federation == nil {
+		setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonDependencyNotReady, message)
+		return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+	}
+	creationReq, reason, err := r.buildDirectorCreationRequest(ctx, director, federation)
+	if err != nil {
+		if reason == "" {
+			return r.finish(ctx, director, status, ctrl.Result{}, err)
+		}
+		setReadyCondition(director, status, metav1.ConditionFalse, reason, err.Error())
+		result := ctrl.Result{RequeueAfter: r.resyncInterval()}
+		if reason == v1alpha1.ReasonDependencyNotReady {
+			result.RequeueAfter = r.pollInterval()
+		}
+		return r.finish(ctx, director, status, result, nil)
+	}
+
+	federationUUID := federation.Status.UUID
+	instanceList, err := r.ParticipantOpenFLRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return r.finish(ctx, director, status, ctrl.Result{}, errors.Wrap(err, "failed to list participants"))
+	}
+	var participant *entity.ParticipantOpenFL
+	for _, p := range instanceList.([]entity.ParticipantOpenFL) {
+		if p.Type == entity.ParticipantOpenFLTypeDirector && (status.UUID == "" || p.UUID == status.UUID) {
+			p := p
+			participant = &p
+			break
+		}
+	}
+	if participant == nil {
+		if status.UUID != "" {
+			log.FromContext(ctx).Info("director no longer exists and will be created again", "uuid", status.UUID)
+			status.UUID = ""
+		}
+		deploymentYAML, err := r.OpenFLService.GetOpenFLDirectorYAML(&creationReq.ParticipantOpenFLDirectorYAMLCreationRequest)
+		if err != nil {
+			return r.finish(ctx, director, status, ctrl.Result{}, errors.Wrap(err, "failed to get the deployment yaml"))
+		}
+		creationReq.DeploymentYAML = deploymentYAML
+		participant, _, err := r.OpenFLService.CreateDirector(creationReq)
+		if err != nil {
+			return r.finish(ctx, director, status, ctrl.Result{}, errors.Wrapf(err, "failed to create director %s", creationReq.Name))
+		}
+		log.FromContext(ctx).Info("director created", "uuid", participant.UUID)
+		status.UUID = participant.UUID
+		status.Phase = participant.Status.String()
+		status.ObservedGeneration = director.Generation
+		setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress, fmt.Sprintf("director %s is being installed", creationReq.Name))
+		return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+	}
+
+	status.UUID = participant.UUID
+	status.Phase = participant.Status.String()
+	switch participant.Status {
+	case entity.ParticipantOpenFLStatusActive:
+	case entity.ParticipantOpenFLStatusFailed:
+		// the failed operation is not retried until the spec is changed
+		if status.ObservedGeneration == director.Generation {
+			setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonFailed,
+				fmt.Sprintf("director %s failed, check its events for details and change the spec to retry", participant.Name))
+			return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+		}
+	default:
+		setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+			fmt.Sprintf("director %s is in %v status", participant.Name, participant.Status))
+		return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+	}
+
+	replaceReason := directorReplaceReason(participant, creationReq)
+	if replaceReason == "" {
+		status.ObservedGeneration = director.Generation
+		setReadyCondition(director, status, metav1.ConditionTrue, v1alpha1.ReasonReady, "")
+		return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.resyncInterval()}, nil)
+	}
+	if _, err := r.OpenFLService.RemoveDirector(participant.UUID, participant.Status == entity.ParticipantOpenFLStatusFailed); err != nil {
+		return r.finish(ctx, director, status, ctrl.Result{}, errors.Wrapf(err, "failed to remove director %s", participant.Name))
+	}
+	log.FromContext(ctx).Info("director replacing", "uuid", participant.UUID, "reason", replaceReason)
+	setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+		fmt.Sprintf("director %s is being replaced: %s", participant.Name, replaceReason))
+	return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+}
+
+// buildDirectorCreationRequest converts the resource to the director creation request, without the deployment yaml.
+// The returned reason is set in the Ready condition if there is an error, and an empty reason means the error is
+// transient.
+func (r *OpenFLDirectorReconciler) buildDirectorCreationRequest(ctx context.Context, director *v1alpha1.OpenFLDirector, federation *v1alpha1.OpenFLFederation) (*service.ParticipantOpenFLDirectorCreationRequest, string, error) {
+	spec := &director.Spec
+	serviceType, err := parseServiceType(spec.ServiceType)
+	if err != nil {
+		return nil, v1alpha1.ReasonInvalidSpec, err
+	}
+	if serviceType == entity.ParticipantDefaultServiceTypeUnknown {
+		serviceType = entity.ParticipantDefaultServiceTypeLoadBalancer
+	}
+	if federation.Spec.DeploymentMethod == entity.FederationOpenFLDeploymentMethodHelm.String() {
+		if spec.InfraProviderUUID == "" {
+			return nil, v1alpha1.ReasonInvalidSpec, errors.New("infraProviderUUID is required when deploying with Helm")
+		}
+	} else if spec.EndpointUUID == "" {
+		return nil, v1alpha1.ReasonInvalidSpec, errors.New("endpointUUID is required when deploying with KubeFATE")
+	}
+	if spec.ChartUUID == "" {
+		return nil, v1alpha1.ReasonInvalidSpec, errors.New("chartUUID is required")
+	}
+	namespace := spec.Namespace
+	if namespace == "" {
+		namespace = fmt.Sprintf("%s-openfl-director", federation.Name)
+	}
+
+	secret := &corev1.Secret{}
+	secretRef := spec.JupyterPasswordSecretRef
+	if err := r.Get(ctx, types.NamespacedName{Namespace: director.Namespace, Name: secretRef.Name}, secret); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return nil, v1alpha1.ReasonDependencyNotReady, errors.Errorf("secret %s not found", secretRef.Name)
+		}
+		return nil, "", err
+	}
+	password, ok := secret.Data[secretRef.Key]
+	if !ok || len(password) == 0 {
+		return nil, v1alpha1.ReasonDependencyNotReady, errors.Errorf("key %s not found in secret %s", secretRef.Key, secretRef.Name)
+	}
+
+	return &service.ParticipantOpenFLDirectorCreationRequest{
+		ParticipantOpenFLDirectorYAMLCreationRequest: service.ParticipantOpenFLDirectorYAMLCreationRequest{
+			FederationUUID:  federation.Status.UUID,
+			ChartUUID:       spec.ChartUUID,
+			Name:            resourceObjectName(spec.Name, director.Name),
+			Namespace:       namespace,
+			ServiceType:     serviceType,
+			RegistryConfig:  registryConfig(spec.Registry),
+			JupyterPassword: string(password),
+			EnablePSP:       spec.EnablePSP,
+		},
+		ParticipantDeploymentBaseInfo: service.ParticipantDeploymentBaseInfo{
+			Description:  spec.Description,
+			EndpointUUID: spec.EndpointUUID,
+		},
+		DirectorServerCertInfo: entity.ParticipantComponentCertInfo{
+			BindingMode: entity.CertBindingModeCreate,
+		},
+		JupyterClientCertInfo: entity.ParticipantComponentCertInfo{
+			BindingMode: entity.CertBindingModeCreate,
+		},
+		InfraProviderUUID: spec.InfraProviderUUID,
+	}, "", nil
+}
+
+// directorReplaceReason returns why the director doesn't match the creation request, or an empty string if it does
+func directorReplaceReason(director *entity.ParticipantOpenFL, req *service.ParticipantOpenFLDirectorCreationRequest) string {
+	switch {
+	case !director.IsManaged:
+		return "not managed by FedLCM"
+	case director.Status == entity.ParticipantOpenFLStatusFailed:
+		return "in failed status"
+	case director.Name != req.Name:
+		return fmt.Sprintf("name changed from %s to %s", director.Name, req.Name)
+	case director.Namespace != req.Namespace:
+		return fmt.Sprintf("namespace changed from %s to %s", director.Namespace, req.Namespace)
+	case director.ChartUUID != req.ChartUUID:
+		return fmt.Sprintf("chart changed from %s to %s", director.ChartUUID, req.ChartUUID)
+	case director.DeploymentMethod == entity.FederationOpenFLDeploymentMethodHelm && director.InfraUUID != req.InfraProviderUUID:
+		return fmt.Sprintf("infra provider changed from %s to %s", director.InfraUUID, req.InfraProviderUUID)
+	case director.DeploymentMethod == entity.FederationOpenFLDeploymentMethodKubeFATE && director.EndpointUUID != req.EndpointUUID:
+		return fmt.Sprintf("endpoint changed from %s to %s", director.EndpointUUID, req.EndpointUUID)
+	}
+	return ""
+}
+
+// finalizeOpenFLDirector removes the director of the deleted resource, and then releases the resource
+func (r *OpenFLDirectorReconciler) finalizeOpenFLDirector(ctx context.Context, director *v1alpha1.OpenFLDirector) (ctrl.Result, error) {
+	status := &director.Status
+	if status.UUID != "" {
+		instanceList, err := r.ParticipantOpenFLRepo.List()
+		if err != nil {
+			return r.finish(ctx, director, status, ctrl.Result{}, errors.Wrap(err, "failed to list participants"))
+		}
+		for _, participant := range instanceList.([]entity.ParticipantOpenFL) {
+			if participant.UUID != status.UUID {
+				continue
+			}
+			status.Phase = participant.Status.String()
+			if participant.Status != entity.ParticipantOpenFLStatusRemoving {
+				if _, err := r.OpenFLService.RemoveDirector(participant.UUID, participant.Status == entity.ParticipantOpenFLStatusFailed); err != nil {
+					setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonDeletionBlocked, err.Error())
+					return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+				}
+				log.FromContext(ctx).Info("director removing", "uuid", participant.UUID)
+			}
+			setReadyCondition(director, status, metav1.ConditionFalse, v1alpha1.ReasonInProgress,
+				fmt.Sprintf("director %s is being removed", participant.Name))
+			return r.finish(ctx, director, status, ctrl.Result{RequeueAfter: r.pollInterval()}, nil)
+		}
+	}
+	return ctrl.Result{}, r.removeFinalizer(ctx, director)
+}
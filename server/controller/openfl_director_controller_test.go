@@ -0,0 +1,109 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOpenFLDirectorReconciler_Lifecycle(t *testing.T) {
+	openflService := &fakeOpenFLService{}
+	federation := &v1alpha1.OpenFLFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "openfl", Namespace: testNamespace},
+		Spec:       v1alpha1.OpenFLFederationSpec{Domain: "example.com"},
+	}
+	director := &v1alpha1.OpenFLDirector{
+		ObjectMeta: metav1.ObjectMeta{Name: "director", Namespace: testNamespace},
+		Spec: v1alpha1.OpenFLDirectorSpec{
+			FederationRef: v1alpha1.LocalObjectReference{Name: federation.Name},
+			DeploymentSpec: v1alpha1.DeploymentSpec{
+				EndpointUUID: "endpoint",
+				ChartUUID:    testChartUUID,
+			},
+			JupyterPasswordSecretRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "jupyter"},
+				Key:                  "password",
+			},
+		},
+	}
+	fateService := &fakeFATEService{}
+	scheme, err := NewScheme()
+	assert.NoError(t, err)
+	r := NewReconciler(nil, nil, &fakeFederationRepo{}, &fakeOpenFLFederationRepo{}, nil,
+		fateService.repo(), openflService.repo(), nil, nil, nil,
+		&mock.RegistrationTokenOpenFLRepoMock{}, &mock.RegistrationTokenOpenFLRepoMock{}, nil, nil, nil, nil, nil)
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(federation, director).Build()
+	r.FATEService = fateService
+	r.OpenFLService = openflService
+	directorReconciler := &OpenFLDirectorReconciler{r}
+
+	reconcileObject(t, &OpenFLFederationReconciler{r}, r.Client, federation)
+	assertReadyCondition(t, &federation.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	// the director waits for the password secret
+	reconcileObject(t, directorReconciler, r.Client, director)
+	assertReadyCondition(t, &director.Status, metav1.ConditionFalse, v1alpha1.ReasonDependencyNotReady)
+	assert.Empty(t, openflService.requests)
+
+	assert.NoError(t, r.Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jupyter", Namespace: testNamespace},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}))
+	reconcileObject(t, directorReconciler, r.Client, director)
+	assertReadyCondition(t, &director.Status, metav1.ConditionFalse, v1alpha1.ReasonInProgress)
+	if assert.Len(t, openflService.requests, 1) {
+		req := openflService.requests[0]
+		assert.Equal(t, federation.Status.UUID, req.FederationUUID)
+		assert.Equal(t, "director", req.Name)
+		assert.Equal(t, "openfl-openfl-director", req.Namespace)
+		assert.Equal(t, "secret", req.JupyterPassword)
+		assert.Equal(t, "name: director", req.DeploymentYAML)
+		assert.Equal(t, entity.ParticipantDefaultServiceTypeLoadBalancer, req.ServiceType)
+	}
+	openflService.setStatus(director.Status.UUID, entity.ParticipantOpenFLStatusActive)
+	reconcileObject(t, directorReconciler, r.Client, director)
+	assertReadyCondition(t, &director.Status, metav1.ConditionTrue, v1alpha1.ReasonReady)
+
+	// directors can't be upgraded so a chart change replaces the director
+	directorUUID := director.Status.UUID
+	director.Spec.ChartUUID = testUpgradeChartUUID
+	director.Generation++
+	assert.NoError(t, r.Update(context.Background(), director))
+	reconcileObject(t, directorReconciler, r.Client, director)
+	assert.Equal(t, []string{directorUUID}, openflService.removed)
+	openflService.delete(directorUUID)
+	reconcileObject(t, directorReconciler, r.Client, director)
+	assert.Len(t, openflService.requests, 2)
+	assert.NotEqual(t, directorUUID, director.Status.UUID)
+
+	// deleting the resource removes the director
+	directorUUID = director.Status.UUID
+	assert.NoError(t, r.Delete(context.Background(), director))
+	_, exists := reconcileObject(t, directorReconciler, r.Client, director)
+	assert.True(t, exists)
+	assert.Equal(t, directorUUID, openflService.removed[1])
+	openflService.delete(directorUUID)
+	_, exists = reconcileObject(t, directorReconciler, r.Client, director)
+	assert.False(t, exists)
+}
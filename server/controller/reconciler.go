@@ -0,0 +1,255 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controller reconciles the lifecycle manager custom resources in a management cluster by driving the domain
+// services, so that federations and their participants can be managed declaratively
+package controller
+
+import (
+	"context"
+	"time"
+
+	appService "github.com/FederatedAI/FedLCM/server/application/service"
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/go-logr/logr/funcr"
+	zerolog "github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// +kubebuilder:rbac:groups=fedlcm.fate.fedai.org,resources=*,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=fedlcm.fate.fedai.org,resources=*/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+const (
+	defaultPollInterval   = 15 * time.Second
+	defaultResyncInterval = 5 * time.Minute
+)
+
+// Reconciler contains the dependencies shared by the reconcilers of the custom resources
+type Reconciler struct {
+	client.Client
+	FederationApp         *appService.FederationApp
+	FederationFATERepo    repo.FederationRepository
+	FederationOpenFLRepo  repo.FederationRepository
+	ParticipantFATERepo   repo.ParticipantFATERepository
+	ParticipantOpenFLRepo repo.ParticipantOpenFLRepository
+	FATEService           FATEService
+	OpenFLService         OpenFLService
+	// PollInterval is how often the objects with ongoing operations are checked, defaults to 15 seconds
+	PollInterval time.Duration
+	// ResyncInterval is how often the converged objects are checked for changes made outside of the resources, like
+	// participants removed from the portal, defaults to 5 minutes
+	ResyncInterval time.Duration
+}
+
+// NewReconciler returns a Reconciler driving the domain services built from the repos
+func NewReconciler(infraProviderKubernetesRepo repo.InfraProviderRepository,
+	endpointKubeFATERepo repo.EndpointRepository,
+	federationFATERepo repo.FederationRepository,
+	federationOpenFLRepo repo.FederationRepository,
+	chartRepo repo.ChartRepository,
+	participantFATERepo repo.ParticipantFATERepository,
+	participantOpenFLRepo repo.ParticipantOpenFLRepository,
+	certificateAuthorityRepo repo.CertificateAuthorityRepository,
+	certificateRepo repo.CertificateRepository,
+	certificateBindingRepo repo.CertificateBindingRepository,
+	registrationTokenOpenFLRepo repo.RegistrationTokenRepository,
+	registrationTokenFATERepo repo.RegistrationTokenRepository,
+	registrationRecordRepo repo.RegistrationRecordRepository,
+	participantOpenFLBatchOperationRepo repo.ParticipantOpenFLBatchOperationRepository,
+	federationOpenFLShardDescriptorRepo repo.FederationOpenFLShardDescriptorRepository,
+	participantFATESizingProfileRepo repo.ParticipantFATESizingProfileRepository,
+	eventRepo repo.EventRepository) *Reconciler {
+	participantApp := &appService.ParticipantApp{
+		ParticipantFATERepo:         participantFATERepo,
+		FederationFATERepo:          federationFATERepo,
+		FederationOpenFLRepo:        federationOpenFLRepo,
+		ParticipantOpenFLRepo:       participantOpenFLRepo,
+		RegistrationTokenOpenFLRepo: registrationTokenOpenFLRepo,
+		RegistrationTokenFATERepo:   registrationTokenFATERepo,
+		RegistrationRecordRepo:      registrationRecordRepo,
+		EndpointKubeFATERepo:        endpointKubeFATERepo,
+		InfraProviderKubernetesRepo: infraProviderKubernetesRepo,
+		ChartRepo:                   chartRepo,
+		CertificateAuthorityRepo:    certificateAuthorityRepo,
+		CertificateRepo:             certificateRepo,
+		CertificateBindingRepo:      certificateBindingRepo,
+		EventRepo:                   eventRepo,
+
+		ParticipantOpenFLBatchOperationRepo: participantOpenFLBatchOperationRepo,
+		FederationOpenFLShardDescriptorRepo: federationOpenFLShardDescriptorRepo,
+		ParticipantFATESizingProfileRepo:    participantFATESizingProfileRepo,
+	}
+	return &Reconciler{
+		FederationApp: &appService.FederationApp{
+			FederationFATERepo:          federationFATERepo,
+			ParticipantFATERepo:         participantFATERepo,
+			FederationOpenFLRepo:        federationOpenFLRepo,
+			ParticipantOpenFLRepo:       participantOpenFLRepo,
+			RegistrationTokenOpenFLRepo: registrationTokenOpenFLRepo,
+			RegistrationTokenFATERepo:   registrationTokenFATERepo,
+			RegistrationRecordRepo:      registrationRecordRepo,
+
+			FederationOpenFLShardDescriptorRepo: federationOpenFLShardDescriptorRepo,
+		},
+		FederationFATERepo:    federationFATERepo,
+		FederationOpenFLRepo:  federationOpenFLRepo,
+		ParticipantFATERepo:   participantFATERepo,
+		ParticipantOpenFLRepo: participantOpenFLRepo,
+		FATEService:           participantApp.FATEDomainService(),
+		OpenFLService:         participantApp.OpenFLDomainService(),
+	}
+}
+
+// Options contains the settings of the controller manager
+type Options struct {
+	// Namespace restricts the watched custom resources to a namespace, empty means all namespaces
+	Namespace string
+	// LeaderElection makes only one of the lifecycle manager replicas reconcile the resources
+	LeaderElection          bool
+	LeaderElectionNamespace string
+}
+
+// NewScheme returns the scheme containing the types used by the controller
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// SetupWithManager registers the reconcilers of all the custom resources in the manager
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	if err := ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.FATEFederation{}).Complete(&FATEFederationReconciler{r}); err != nil {
+		return err
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.FATEExchange{}).Complete(&FATEExchangeReconciler{r}); err != nil {
+		return err
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.FATECluster{}).Complete(&FATEClusterReconciler{r}); err != nil {
+		return err
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.OpenFLFederation{}).Complete(&OpenFLFederationReconciler{r}); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.OpenFLDirector{}).Complete(&OpenFLDirectorReconciler{r})
+}
+
+// Start runs the reconcilers against the API server of the management cluster until the context is done
+func (r *Reconciler) Start(ctx context.Context, config *rest.Config, options Options) error {
+	// the logs of controller-runtime are written into the same zerolog logger as the rest of the service
+	ctrl.SetLogger(funcr.New(func(prefix, args string) {
+		zerolog.Info().Str("logger", prefix).Msg(args)
+	}, funcr.Options{}))
+	scheme, err := NewScheme()
+	if err != nil {
+		return err
+	}
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:    scheme,
+		Namespace: options.Namespace,
+		// the metrics and health probe servers are disabled as the API server of the lifecycle manager is used instead
+		MetricsBindAddress:      "0",
+		HealthProbeBindAddress:  "0",
+		LeaderElection:          options.LeaderElection,
+		LeaderElectionID:        "fedlcm-controller.fedlcm.fate.fedai.org",
+		LeaderElectionNamespace: options.LeaderElectionNamespace,
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.SetupWithManager(mgr); err != nil {
+		return err
+	}
+	return mgr.Start(ctx)
+}
+
+func (r *Reconciler) pollInterval() time.Duration {
+	if r.PollInterval <= 0 {
+		return defaultPollInterval
+	}
+	return r.PollInterval
+}
+
+func (r *Reconciler) resyncInterval() time.Duration {
+	if r.ResyncInterval <= 0 {
+		return defaultResyncInterval
+	}
+	return r.ResyncInterval
+}
+
+// setReadyCondition sets the Ready condition of the resource
+func setReadyCondition(object client.Object, status *v1alpha1.Status, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionTypeReady,
+		Status:             conditionStatus,
+		ObservedGeneration: object.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// isReady returns whether the Ready condition of the resource is true for its latest generation
+func isReady(object client.Object, status *v1alpha1.Status) bool {
+	condition := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypeReady)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == object.GetGeneration()
+}
+
+// finish writes the status of the resource back and returns the reconciliation result. If the reconciliation failed
+// with reconcileErr, the error is recorded in the Ready condition and returned so the request is retried with backoff.
+func (r *Reconciler) finish(ctx context.Context, object client.Object, status *v1alpha1.Status, result ctrl.Result, reconcileErr error) (ctrl.Result, error) {
+	if reconcileErr != nil {
+		setReadyCondition(object, status, metav1.ConditionFalse, v1alpha1.ReasonError, reconcileErr.Error())
+	}
+	if err := r.Status().Update(ctx, object); err != nil {
+		if reconcileErr == nil {
+			reconcileErr = err
+		}
+	}
+	if reconcileErr != nil {
+		return ctrl.Result{}, reconcileErr
+	}
+	return result, nil
+}
+
+// addFinalizer makes sure the resource has the finalizer before any lifecycle manager object is created for it
+func (r *Reconciler) addFinalizer(ctx context.Context, object client.Object) error {
+	if controllerutil.AddFinalizer(object, v1alpha1.Finalizer) {
+		return r.Update(ctx, object)
+	}
+	return nil
+}
+
+// removeFinalizer lets Kubernetes delete the resource after its lifecycle manager object is removed
+func (r *Reconciler) removeFinalizer(ctx context.Context, object client.Object) error {
+	if controllerutil.RemoveFinalizer(object, v1alpha1.Finalizer) {
+		return r.Update(ctx, object)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+)
+
+// FATEService is the domain service the FATE exchange and cluster reconcilers drive, implemented by
+// service.ParticipantFATEService
+type FATEService interface {
+	ValidateExchangeSpec(federationName string, spec *service.ParticipantFATEExchangeSpec) error
+	ValidateClusterSpec(federationName string, spec *service.ParticipantFATEClusterSpec) error
+	PlanParticipant(participant *entity.ParticipantFATE, spec *service.ParticipantFATEDeploymentSpec) (*service.FederationFATEApplyPlanItem, error)
+	GetDeploymentSettings(participant *entity.ParticipantFATE) (*service.ParticipantFATEDeploymentSettings, error)
+	CreateExchangeFromSpec(federationUUID string, spec *service.ParticipantFATEExchangeSpec) (*entity.ParticipantFATE, *sync.WaitGroup, error)
+	CreateClusterFromSpec(federationUUID, exchangeUUID string, spec *service.ParticipantFATEClusterSpec) (*entity.ParticipantFATE, *sync.WaitGroup, error)
+	UpgradeExchange(req *service.ParticipantFATEExchangeUpgradeRequest) (*entity.ParticipantFATE, *sync.WaitGroup, error)
+	UpgradeCluster(req *service.ParticipantFATEClusterUpgradeRequest) (*entity.ParticipantFATE, *sync.WaitGroup, error)
+	RemoveExchange(uuid string, force bool) (*sync.WaitGroup, error)
+	RemoveCluster(uuid string, force bool) (*sync.WaitGroup, error)
+	ConnectClusterToExchange(clusterUUID, exchangeUUID string) (*sync.WaitGroup, error)
+}
+
+// OpenFLService is the domain service the OpenFL director reconciler drives, implemented by
+// service.ParticipantOpenFLService
+type OpenFLService interface {
+	GetOpenFLDirectorYAML(req *service.ParticipantOpenFLDirectorYAMLCreationRequest) (string, error)
+	CreateDirector(req *service.ParticipantOpenFLDirectorCreationRequest) (*entity.ParticipantOpenFL, *sync.WaitGroup, error)
+	RemoveDirector(uuid string, force bool) (*sync.WaitGroup, error)
+}
+
+var _ FATEService = (*service.ParticipantFATEService)(nil)
+var _ OpenFLService = (*service.ParticipantOpenFLService)(nil)
@@ -0,0 +1,102 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FederatedAI/FedLCM/server/controller/v1alpha1"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/service"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+)
+
+// resourceObjectName returns the name of the lifecycle manager object, which defaults to the resource name
+func resourceObjectName(specName, resourceName string) string {
+	if specName != "" {
+		return specName
+	}
+	return resourceName
+}
+
+func parseFATEFederationMode(mode string) (entity.FederationFATEMode, error) {
+	for _, m := range []entity.FederationFATEMode{entity.FederationFATEModeExchange, entity.FederationFATEModePeerToPeer} {
+		if mode == "" || mode == m.String() {
+			return m, nil
+		}
+	}
+	return 0, errors.Errorf("unknown federation mode %s", mode)
+}
+
+func parseOpenFLDeploymentMethod(method string) (entity.FederationOpenFLDeploymentMethod, error) {
+	for _, m := range []entity.FederationOpenFLDeploymentMethod{entity.FederationOpenFLDeploymentMethodKubeFATE, entity.FederationOpenFLDeploymentMethodHelm} {
+		if method == "" || method == m.String() {
+			return m, nil
+		}
+	}
+	return 0, errors.Errorf("unknown deployment method %s", method)
+}
+
+func parseServiceType(serviceType string) (entity.ParticipantDefaultServiceType, error) {
+	if serviceType == "" {
+		return entity.ParticipantDefaultServiceTypeUnknown, nil
+	}
+	for _, t := range []entity.ParticipantDefaultServiceType{entity.ParticipantDefaultServiceTypeLoadBalancer, entity.ParticipantDefaultServiceTypeNodePort} {
+		if serviceType == t.String() {
+			return t, nil
+		}
+	}
+	return 0, errors.Errorf("unknown service type %s", serviceType)
+}
+
+func registryConfig(registry string) valueobject.KubeRegistryConfig {
+	return valueobject.KubeRegistryConfig{
+		UseRegistry: registry != "",
+		Registry:    registry,
+	}
+}
+
+// toFATEDeploymentSpec converts the deployment settings of a resource to the domain spec
+func toFATEDeploymentSpec(spec *v1alpha1.DeploymentSpec, resourceName string) (service.ParticipantFATEDeploymentSpec, error) {
+	serviceType, err := parseServiceType(spec.ServiceType)
+	if err != nil {
+		return service.ParticipantFATEDeploymentSpec{}, err
+	}
+	return service.ParticipantFATEDeploymentSpec{
+		Name:              resourceObjectName(spec.Name, resourceName),
+		Description:       spec.Description,
+		InfraProviderUUID: spec.InfraProviderUUID,
+		EndpointUUID:      spec.EndpointUUID,
+		Namespace:         spec.Namespace,
+		ChartUUID:         spec.ChartUUID,
+		ServiceType:       serviceType,
+		RegistryConfig:    registryConfig(spec.Registry),
+		EnablePSP:         spec.EnablePSP,
+	}, nil
+}
+
+// immutableFieldsMessage returns the message about the fields that are changed but can't be, each field is a triple
+// of the field name, the current value and the desired value
+func immutableFieldsMessage(fields ...[3]string) string {
+	var messages []string
+	for _, field := range fields {
+		if field[1] != field[2] {
+			messages = append(messages, fmt.Sprintf("%s cannot be changed from %s to %s", field[0], field[1], field[2]))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
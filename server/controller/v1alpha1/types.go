@@ -0,0 +1,282 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionTypeReady is the condition type set when the lifecycle manager object is converged to the spec
+	ConditionTypeReady = "Ready"
+
+	// Finalizer is added to the custom resources so that the lifecycle manager objects are removed with them
+	Finalizer = "fedlcm.fate.fedai.org/finalizer"
+
+	// AnnotationAdopt must be set to "true" for an exchange or cluster resource to take over an existing participant
+	// managed by the lifecycle manager, instead of failing with the InvalidSpec reason
+	AnnotationAdopt = "fedlcm.fate.fedai.org/adopt"
+
+	// AnnotationAllowReplace must be set to "true" for an exchange or cluster resource to replace its participant, which
+	// uninstalls it and deletes its data, when a spec change can't be applied in place. Otherwise the change is reported
+	// with the InvalidSpec reason and the participant is kept running.
+	AnnotationAllowReplace = "fedlcm.fate.fedai.org/allow-replace"
+)
+
+// Condition reasons set in the Ready condition
+const (
+	ReasonReady              = "Ready"
+	ReasonInProgress         = "InProgress"
+	ReasonFailed             = "Failed"
+	ReasonInvalidSpec        = "InvalidSpec"
+	ReasonDependencyNotReady = "DependencyNotReady"
+	ReasonDeletionBlocked    = "DeletionBlocked"
+	ReasonError              = "Error"
+)
+
+// LocalObjectReference refers to another custom resource in the same namespace
+type LocalObjectReference struct {
+	Name string `json:"name"`
+}
+
+// Status is the common status of the custom resources
+type Status struct {
+	// UUID is the uuid of the lifecycle manager object this resource is bound to
+	// +optional
+	UUID string `json:"uuid,omitempty"`
+	// Phase is the status of the lifecycle manager object, like "Active" or "Installing"
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// ObservedGeneration is the latest generation of the spec that has been acted on
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeploymentSpec contains the deployment settings shared by the participants. Either the EndpointUUID or the
+// InfraProviderUUID is required, and with the latter, a KubeFATE endpoint is installed if there isn't one.
+type DeploymentSpec struct {
+	// Name defaults to the name of the resource
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Description string `json:"description,omitempty"`
+	// +optional
+	InfraProviderUUID string `json:"infraProviderUUID,omitempty"`
+	// +optional
+	EndpointUUID string `json:"endpointUUID,omitempty"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	ChartUUID string `json:"chartUUID"`
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	// +optional
+	ServiceType string `json:"serviceType,omitempty"`
+	// Registry is the image registry to use instead of the default one
+	// +optional
+	Registry string `json:"registry,omitempty"`
+	// +optional
+	EnablePSP bool `json:"enablePSP,omitempty"`
+}
+
+// FATEFederationSpec defines the desired state of a FATE federation
+type FATEFederationSpec struct {
+	// Name defaults to the name of the resource
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Description string `json:"description,omitempty"`
+	Domain      string `json:"domain"`
+	// Mode is how the clusters reach each other, and it can't be changed
+	// +kubebuilder:validation:Enum=Exchange;PeerToPeer
+	// +optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="UUID",type=string,JSONPath=`.status.uuid`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// FATEFederation is a FATE federation managed by the lifecycle manager
+type FATEFederation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FATEFederationSpec `json:"spec,omitempty"`
+	Status Status             `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FATEFederationList contains a list of FATEFederation
+type FATEFederationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FATEFederation `json:"items"`
+}
+
+// FATEExchangeSpec defines the desired state of a FATE exchange
+type FATEExchangeSpec struct {
+	FederationRef  LocalObjectReference `json:"federationRef"`
+	DeploymentSpec `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Federation",type=string,JSONPath=`.spec.federationRef.name`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// FATEExchange is a FATE exchange managed by the lifecycle manager
+type FATEExchange struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FATEExchangeSpec `json:"spec,omitempty"`
+	Status Status           `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FATEExchangeList contains a list of FATEExchange
+type FATEExchangeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FATEExchange `json:"items"`
+}
+
+// FATEClusterSpec defines the desired state of a FATE cluster
+type FATEClusterSpec struct {
+	FederationRef LocalObjectReference `json:"federationRef"`
+	// ExchangeRef is the exchange the cluster connects to, defaults to the first exchange of the federation
+	// +optional
+	ExchangeRef    *LocalObjectReference `json:"exchangeRef,omitempty"`
+	DeploymentSpec `json:",inline"`
+	// +kubebuilder:validation:Minimum=1
+	PartyID int `json:"partyID"`
+	// +optional
+	EnablePersistence bool `json:"enablePersistence,omitempty"`
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Federation",type=string,JSONPath=`.spec.federationRef.name`
+// +kubebuilder:printcolumn:name="Party ID",type=integer,JSONPath=`.spec.partyID`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// FATECluster is a FATE cluster managed by the lifecycle manager
+type FATECluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FATEClusterSpec `json:"spec,omitempty"`
+	Status Status          `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FATEClusterList contains a list of FATECluster
+type FATEClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FATECluster `json:"items"`
+}
+
+// OpenFLFederationSpec defines the desired state of an OpenFL federation
+type OpenFLFederationSpec struct {
+	// Name defaults to the name of the resource
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Description string `json:"description,omitempty"`
+	Domain      string `json:"domain"`
+	// DeploymentMethod is how the director and envoys are deployed, and it can't be changed
+	// +kubebuilder:validation:Enum=KubeFATE;Helm
+	// +optional
+	DeploymentMethod string `json:"deploymentMethod,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="UUID",type=string,JSONPath=`.status.uuid`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// OpenFLFederation is an OpenFL federation managed by the lifecycle manager
+type OpenFLFederation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OpenFLFederationSpec `json:"spec,omitempty"`
+	Status Status               `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OpenFLFederationList contains a list of OpenFLFederation
+type OpenFLFederationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OpenFLFederation `json:"items"`
+}
+
+// OpenFLDirectorSpec defines the desired state of an OpenFL director
+type OpenFLDirectorSpec struct {
+	FederationRef  LocalObjectReference `json:"federationRef"`
+	DeploymentSpec `json:",inline"`
+	// JupyterPasswordSecretRef is the secret key containing the password of the Jupyter notebook
+	JupyterPasswordSecretRef corev1.SecretKeySelector `json:"jupyterPasswordSecretRef"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Federation",type=string,JSONPath=`.spec.federationRef.name`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// OpenFLDirector is an OpenFL director managed by the lifecycle manager
+type OpenFLDirector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OpenFLDirectorSpec `json:"spec,omitempty"`
+	Status Status             `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OpenFLDirectorList contains a list of OpenFLDirector
+type OpenFLDirectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OpenFLDirector `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FATEFederation{}, &FATEFederationList{},
+		&FATEExchange{}, &FATEExchangeList{},
+		&FATECluster{}, &FATEClusterList{},
+		&OpenFLFederation{}, &OpenFLFederationList{},
+		&OpenFLDirector{}, &OpenFLDirectorList{})
+}
@@ -15,8 +15,13 @@
 package entity
 
 import (
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
@@ -28,6 +33,10 @@ type Certificate struct {
 	SerialNumberStr   string              `gorm:"type:varchar(255)"`
 	PEM               string              `gorm:"type:text"`
 	ChainPEM          string              `gorm:"type:text"`
+	RevokedAt         *time.Time          `gorm:"index"`
+	RevocationReason  string              `gorm:"type:varchar(255)"`
+	Imported          bool                `gorm:"default:false"`
+	EncryptedKeyPEM   string              `gorm:"type:text"`
 	Chain             []*x509.Certificate `gorm:"-"`
 	*x509.Certificate `gorm:"-"`
 }
@@ -89,3 +98,32 @@ func (c *Certificate) EncodePEM() ([]byte, error) {
 	certBytes = append(certBytes, c.ChainPEM...)
 	return certBytes, nil
 }
+
+// PrivateKey returns the private key of an imported certificate. FedLCM doesn't store the keys of the certificates it issues.
+func (c *Certificate) PrivateKey() (*rsa.PrivateKey, error) {
+	if !c.Imported || c.EncryptedKeyPEM == "" {
+		return nil, errors.Errorf("private key of certificate %s is not available", c.UUID)
+	}
+	keyPEM, err := utils.Decrypt(c.EncryptedKeyPEM)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt the private key")
+	}
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("invalid private key content")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// SetPrivateKey encrypts and saves the private key of an imported certificate
+func (c *Certificate) SetPrivateKey(key *rsa.PrivateKey) error {
+	encryptedKeyPEM, err := utils.Encrypt(string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})))
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt the private key")
+	}
+	c.EncryptedKeyPEM = encryptedKeyPEM
+	return nil
+}
@@ -18,13 +18,16 @@ import (
 	"context"
 	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	stepcaapiv1 "github.com/smallstep/certificates/cas/apiv1"
 	"github.com/smallstep/certificates/cas/stepcas"
+	"golang.org/x/crypto/ocsp"
 	"gorm.io/gorm"
 )
 
@@ -49,8 +52,25 @@ type CertificateAuthorityType uint8
 const (
 	CertificateAuthorityTypeUnknown CertificateAuthorityType = iota
 	CertificateAuthorityTypeStepCA
+	CertificateAuthorityTypeEmbedded
+	CertificateAuthorityTypeVault
+	CertificateAuthorityTypeCertManager
 )
 
+func (t CertificateAuthorityType) String() string {
+	switch t {
+	case CertificateAuthorityTypeStepCA:
+		return "StepCA"
+	case CertificateAuthorityTypeEmbedded:
+		return "Embedded"
+	case CertificateAuthorityTypeVault:
+		return "Vault"
+	case CertificateAuthorityTypeCertManager:
+		return "cert-manager"
+	}
+	return "Unknown"
+}
+
 // CertificateAuthorityConfigurationStepCA contains basic configuration for a StepCA service
 type CertificateAuthorityConfigurationStepCA struct {
 	ServiceURL            string `json:"service_url" mapstructure:"service_url"`
@@ -81,11 +101,63 @@ func (ca *CertificateAuthority) Client() (CertificateAuthorityClient, error) {
 				Password:    config.ProvisionerPassword,
 			},
 		})
+	case CertificateAuthorityTypeEmbedded:
+		return newEmbeddedCAClient(ca.ConfigurationJSON)
+	case CertificateAuthorityTypeVault:
+		return newVaultCAClient(ca.ConfigurationJSON)
+	case CertificateAuthorityTypeCertManager:
+		return newCertManagerCAClient(ca.ConfigurationJSON)
 	}
 	return nil, errors.Errorf("unknown CA type: %v", ca.Type)
 }
 
+// CreateCRL returns a DER encoded CRL containing the revoked certificates. Only the embedded CA supports it as
+// the other types of CA publish their own CRLs.
+func (ca *CertificateAuthority) CreateCRL(revokedList []pkix.RevokedCertificate, validity time.Duration) ([]byte, error) {
+	if ca.Type != CertificateAuthorityTypeEmbedded {
+		return nil, errors.Errorf("CRL of %v CA should be retrieved from the CA service", ca.Type)
+	}
+	client, err := newEmbeddedCAClient(ca.ConfigurationJSON)
+	if err != nil {
+		return nil, err
+	}
+	return client.createCRL(revokedList, validity)
+}
+
+// CreateOCSPResponse returns a DER encoded OCSP response using the specified template. Only the embedded CA supports it.
+func (ca *CertificateAuthority) CreateOCSPResponse(template ocsp.Response) ([]byte, error) {
+	if ca.Type != CertificateAuthorityTypeEmbedded {
+		return nil, errors.Errorf("OCSP responder is not available for %v CA", ca.Type)
+	}
+	client, err := newEmbeddedCAClient(ca.ConfigurationJSON)
+	if err != nil {
+		return nil, err
+	}
+	return client.createOCSPResponse(template)
+}
+
+// RootCert returns the root certificate of the CA, which should be trusted by the services using the issued certificates
 func (ca *CertificateAuthority) RootCert() (*x509.Certificate, error) {
+	switch ca.Type {
+	case CertificateAuthorityTypeEmbedded:
+		var config CertificateAuthorityConfigurationEmbedded
+		if err := json.Unmarshal([]byte(ca.ConfigurationJSON), &config); err != nil {
+			return nil, err
+		}
+		return parseCertificatePEM(config.RootCertificatePEM)
+	case CertificateAuthorityTypeVault:
+		client, err := newVaultCAClient(ca.ConfigurationJSON)
+		if err != nil {
+			return nil, err
+		}
+		return client.rootCert()
+	case CertificateAuthorityTypeCertManager:
+		var config CertificateAuthorityConfigurationCertManager
+		if err := json.Unmarshal([]byte(ca.ConfigurationJSON), &config); err != nil {
+			return nil, err
+		}
+		return parseCertificatePEM(config.CACertificatePEM)
+	}
 	var config CertificateAuthorityConfigurationStepCA
 	err := json.Unmarshal([]byte(ca.ConfigurationJSON), &config)
 	if err != nil {
@@ -0,0 +1,247 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/pkg/errors"
+	stepcaapiv1 "github.com/smallstep/certificates/cas/apiv1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	certManagerIssuerKindIssuer        = "Issuer"
+	certManagerIssuerKindClusterIssuer = "ClusterIssuer"
+	certManagerDefaultGroup            = "cert-manager.io"
+	certManagerRequestTimeout          = time.Minute
+	certManagerRequestPollInterval     = time.Second * 2
+)
+
+var (
+	certManagerCertificateRequestGVR = schema.GroupVersionResource{Group: certManagerDefaultGroup, Version: "v1", Resource: "certificaterequests"}
+	certManagerIssuerGVR             = schema.GroupVersionResource{Group: certManagerDefaultGroup, Version: "v1", Resource: "issuers"}
+	certManagerClusterIssuerGVR      = schema.GroupVersionResource{Group: certManagerDefaultGroup, Version: "v1", Resource: "clusterissuers"}
+)
+
+// CertificateAuthorityConfigurationCertManager contains basic configuration for signing certificates using a cert-manager issuer
+type CertificateAuthorityConfigurationCertManager struct {
+	// KubeConfig is the kubeconfig content of the cluster running cert-manager, empty means the cluster FedLCM is running in
+	KubeConfig string `json:"kubeconfig" mapstructure:"kubeconfig"`
+	// Namespace is where the CertificateRequest objects are created, must be the namespace of the Issuer if IssuerKind is "Issuer"
+	Namespace  string `json:"namespace" mapstructure:"namespace"`
+	IssuerName string `json:"issuer_name" mapstructure:"issuer_name"`
+	// IssuerKind is "Issuer" or "ClusterIssuer", default to "Issuer"
+	IssuerKind string `json:"issuer_kind" mapstructure:"issuer_kind"`
+	// IssuerGroup is the API group of the issuer, default to "cert-manager.io", can be set to use external issuers
+	IssuerGroup string `json:"issuer_group" mapstructure:"issuer_group"`
+	// CACertificatePEM is the root CA certificate of the issuer, which will be distributed to the participants
+	CACertificatePEM string `json:"ca_cert_pem" mapstructure:"ca_cert_pem"`
+}
+
+// certManagerCAClient signs the CSRs by creating cert-manager CertificateRequest objects
+type certManagerCAClient struct {
+	config        CertificateAuthorityConfigurationCertManager
+	dynamicClient dynamic.Interface
+}
+
+var _ CertificateAuthorityClient = (*certManagerCAClient)(nil)
+
+func newCertManagerCAClient(configJSON string) (*certManagerCAClient, error) {
+	var config CertificateAuthorityConfigurationCertManager
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return nil, err
+	}
+	if config.IssuerName == "" {
+		return nil, errors.New("issuer name is required")
+	}
+	if config.Namespace == "" {
+		return nil, errors.New("namespace is required")
+	}
+	if config.IssuerKind == "" {
+		config.IssuerKind = certManagerIssuerKindIssuer
+	}
+	if config.IssuerKind != certManagerIssuerKindIssuer && config.IssuerKind != certManagerIssuerKindClusterIssuer {
+		return nil, errors.Errorf("unknown issuer kind: %s", config.IssuerKind)
+	}
+	if config.IssuerGroup == "" {
+		config.IssuerGroup = certManagerDefaultGroup
+	}
+	if _, err := parseCertificatePEM(config.CACertificatePEM); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse CA certificate")
+	}
+	client, err := kubernetes.NewKubernetesClient("", config.KubeConfig, config.KubeConfig == "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create kubernetes client")
+	}
+	restConfig, err := client.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	c := &certManagerCAClient{
+		config:        config,
+		dynamicClient: dynamicClient,
+	}
+	if config.IssuerGroup == certManagerDefaultGroup {
+		if err := c.checkIssuer(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *certManagerCAClient) checkIssuer() error {
+	var err error
+	if c.config.IssuerKind == certManagerIssuerKindClusterIssuer {
+		_, err = c.dynamicClient.Resource(certManagerClusterIssuerGVR).Get(context.TODO(), c.config.IssuerName, v1.GetOptions{})
+	} else {
+		_, err = c.dynamicClient.Resource(certManagerIssuerGVR).Namespace(c.config.Namespace).Get(context.TODO(), c.config.IssuerName, v1.GetOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %s %s", c.config.IssuerKind, c.config.IssuerName)
+	}
+	return nil
+}
+
+func (c *certManagerCAClient) CreateCertificate(req *stepcaapiv1.CreateCertificateRequest) (*stepcaapiv1.CreateCertificateResponse, error) {
+	if req.CSR == nil {
+		return nil, errors.New("CSR is required")
+	}
+	spec := map[string]interface{}{
+		"request": base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE REQUEST",
+			Bytes: req.CSR.Raw,
+		})),
+		"issuerRef": map[string]interface{}{
+			"name":  c.config.IssuerName,
+			"kind":  c.config.IssuerKind,
+			"group": c.config.IssuerGroup,
+		},
+		"usages": []interface{}{"digital signature", "key encipherment", "server auth", "client auth"},
+	}
+	if req.Lifetime > 0 {
+		spec["duration"] = req.Lifetime.String()
+	}
+	request := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": certManagerCertificateRequestGVR.GroupVersion().String(),
+			"kind":       "CertificateRequest",
+			"metadata": map[string]interface{}{
+				"generateName": "fedlcm-",
+			},
+			"spec": spec,
+		},
+	}
+	requestClient := c.dynamicClient.Resource(certManagerCertificateRequestGVR).Namespace(c.config.Namespace)
+	created, err := requestClient.Create(context.TODO(), request, v1.CreateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create CertificateRequest")
+	}
+	defer func() {
+		_ = requestClient.Delete(context.TODO(), created.GetName(), v1.DeleteOptions{})
+	}()
+
+	deadline := time.Now().Add(certManagerRequestTimeout)
+	for {
+		current, err := requestClient.Get(context.TODO(), created.GetName(), v1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get CertificateRequest %s", created.GetName())
+		}
+		certB64, _, _ := unstructured.NestedString(current.Object, "status", "certificate")
+		if certB64 != "" {
+			return parseCertManagerCertificate(certB64)
+		}
+		if failed, message := getCertManagerRequestFailure(current); failed {
+			return nil, errors.Errorf("CertificateRequest %s failed: %s", created.GetName(), message)
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("timed out waiting for CertificateRequest %s to be issued", created.GetName())
+		}
+		time.Sleep(certManagerRequestPollInterval)
+	}
+}
+
+func (c *certManagerCAClient) RenewCertificate(req *stepcaapiv1.RenewCertificateRequest) (*stepcaapiv1.RenewCertificateResponse, error) {
+	return nil, stepcaapiv1.NotImplementedError{Message: "renewing certificate is not supported, create a new one instead"}
+}
+
+func (c *certManagerCAClient) RevokeCertificate(req *stepcaapiv1.RevokeCertificateRequest) (*stepcaapiv1.RevokeCertificateResponse, error) {
+	return nil, stepcaapiv1.NotImplementedError{Message: "cert-manager does not support revoking certificates"}
+}
+
+// getCertManagerRequestFailure returns whether the request is denied or failed, with the reason message
+func getCertManagerRequestFailure(request *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(request.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _ := conditionMap["type"].(string)
+		status, _ := conditionMap["status"].(string)
+		reason, _ := conditionMap["reason"].(string)
+		message, _ := conditionMap["message"].(string)
+		if (conditionType == "Denied" || conditionType == "InvalidRequest") && status == "True" ||
+			conditionType == "Ready" && status == "False" && (reason == "Failed" || reason == "Denied") {
+			return true, message
+		}
+	}
+	return false, ""
+}
+
+// parseCertManagerCertificate parses the base64 encoded PEM bundle in the CertificateRequest status
+func parseCertManagerCertificate(certB64 string) (*stepcaapiv1.CreateCertificateResponse, error) {
+	bundle, err := base64.StdEncoding.DecodeString(certB64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the issued certificate")
+	}
+	var certList []*x509.Certificate
+	rest := bundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the issued certificate")
+		}
+		certList = append(certList, cert)
+	}
+	if len(certList) == 0 {
+		return nil, errors.New("no certificate found in the CertificateRequest status")
+	}
+	return &stepcaapiv1.CreateCertificateResponse{
+		Certificate:      certList[0],
+		CertificateChain: certList[1:],
+	}, nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
+	"github.com/pkg/errors"
+	stepcaapiv1 "github.com/smallstep/certificates/cas/apiv1"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	embeddedCARootLifetime         = time.Hour * 24 * 365 * 10
+	embeddedCAIntermediateLifetime = time.Hour * 24 * 365 * 5
+	embeddedCADefaultCommonName    = "FedLCM"
+)
+
+// CertificateAuthorityConfigurationEmbedded contains the root and intermediate CA generated and managed by FedLCM itself,
+// the private keys are encrypted using the configured secret key
+type CertificateAuthorityConfigurationEmbedded struct {
+	CommonName                  string `json:"common_name" mapstructure:"common_name"`
+	Organization                string `json:"organization" mapstructure:"organization"`
+	RootCertificatePEM          string `json:"root_cert_pem" mapstructure:"-"`
+	IntermediateCertificatePEM  string `json:"intermediate_cert_pem" mapstructure:"-"`
+	EncryptedRootKeyPEM         string `json:"encrypted_root_key_pem" mapstructure:"-"`
+	EncryptedIntermediateKeyPEM string `json:"encrypted_intermediate_key_pem" mapstructure:"-"`
+	// CRLURL is the address where the CRL of this CA is published, which will be added to the issued certificates if set
+	CRLURL string `json:"crl_url" mapstructure:"crl_url"`
+	// OCSPURL is the address of the OCSP responder of this CA, which will be added to the issued certificates if set
+	OCSPURL string `json:"ocsp_url" mapstructure:"ocsp_url"`
+}
+
+// GenerateEmbeddedCAConfiguration generates a new root CA and an intermediate CA signed by it
+func GenerateEmbeddedCAConfiguration(commonName, organization string) (*CertificateAuthorityConfigurationEmbedded, error) {
+	if commonName == "" {
+		commonName = embeddedCADefaultCommonName
+	}
+	var orgList []string
+	if organization != "" {
+		orgList = []string{organization}
+	}
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	rootTemplate := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   commonName + " Root CA",
+			Organization: orgList,
+		},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            1,
+	}
+	rootCert, err := signEmbeddedCACertificate(rootTemplate, rootKey.Public(), nil, rootKey, embeddedCARootLifetime)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate root CA")
+	}
+
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	intermediateTemplate := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   commonName + " Intermediate CA",
+			Organization: orgList,
+		},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	intermediateCert, err := signEmbeddedCACertificate(intermediateTemplate, intermediateKey.Public(), rootCert, rootKey, embeddedCAIntermediateLifetime)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate intermediate CA")
+	}
+
+	encryptedRootKey, err := encryptECPrivateKey(rootKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedIntermediateKey, err := encryptECPrivateKey(intermediateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateAuthorityConfigurationEmbedded{
+		CommonName:                  commonName,
+		Organization:                organization,
+		RootCertificatePEM:          string(encodeCertificatePEM(rootCert)),
+		IntermediateCertificatePEM:  string(encodeCertificatePEM(intermediateCert)),
+		EncryptedRootKeyPEM:         encryptedRootKey,
+		EncryptedIntermediateKeyPEM: encryptedIntermediateKey,
+	}, nil
+}
+
+// embeddedCAClient signs the CSRs using the intermediate CA of the embedded CA
+type embeddedCAClient struct {
+	rootCert         *x509.Certificate
+	intermediateCert *x509.Certificate
+	intermediateKey  crypto.Signer
+	crlURL           string
+	ocspURL          string
+}
+
+var _ CertificateAuthorityClient = (*embeddedCAClient)(nil)
+
+func newEmbeddedCAClient(configJSON string) (*embeddedCAClient, error) {
+	var config CertificateAuthorityConfigurationEmbedded
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return nil, err
+	}
+	rootCert, err := parseCertificatePEM(config.RootCertificatePEM)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse root CA certificate")
+	}
+	intermediateCert, err := parseCertificatePEM(config.IntermediateCertificatePEM)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse intermediate CA certificate")
+	}
+	if err := intermediateCert.CheckSignatureFrom(rootCert); err != nil {
+		return nil, errors.Wrapf(err, "intermediate CA is not signed by the root CA")
+	}
+	keyPEM, err := utils.Decrypt(config.EncryptedIntermediateKeyPEM)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt intermediate CA key")
+	}
+	b, _ := pem.Decode([]byte(keyPEM))
+	if b == nil {
+		return nil, errors.Errorf("failed to decode intermediate CA key")
+	}
+	intermediateKey, err := x509.ParseECPrivateKey(b.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse intermediate CA key, is the secret key changed?")
+	}
+	return &embeddedCAClient{
+		rootCert:         rootCert,
+		intermediateCert: intermediateCert,
+		intermediateKey:  intermediateKey,
+		crlURL:           config.CRLURL,
+		ocspURL:          config.OCSPURL,
+	}, nil
+}
+
+func (c *embeddedCAClient) CreateCertificate(req *stepcaapiv1.CreateCertificateRequest) (*stepcaapiv1.CreateCertificateResponse, error) {
+	if req.CSR == nil {
+		return nil, errors.New("CSR is required")
+	}
+	if err := req.CSR.CheckSignature(); err != nil {
+		return nil, errors.Wrapf(err, "invalid CSR signature")
+	}
+	template := &x509.Certificate{
+		Subject:        req.CSR.Subject,
+		DNSNames:       req.CSR.DNSNames,
+		IPAddresses:    req.CSR.IPAddresses,
+		EmailAddresses: req.CSR.EmailAddresses,
+		URIs:           req.CSR.URIs,
+		KeyUsage:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if c.crlURL != "" {
+		template.CRLDistributionPoints = []string{c.crlURL}
+	}
+	if c.ocspURL != "" {
+		template.OCSPServer = []string{c.ocspURL}
+	}
+	lifetime := req.Lifetime
+	if lifetime <= 0 {
+		lifetime = time.Hour * 24
+	}
+	cert, err := signEmbeddedCACertificate(template, req.CSR.PublicKey, c.intermediateCert, c.intermediateKey, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return &stepcaapiv1.CreateCertificateResponse{
+		Certificate:      cert,
+		CertificateChain: []*x509.Certificate{c.intermediateCert},
+	}, nil
+}
+
+func (c *embeddedCAClient) RenewCertificate(req *stepcaapiv1.RenewCertificateRequest) (*stepcaapiv1.RenewCertificateResponse, error) {
+	return nil, stepcaapiv1.NotImplementedError{Message: "renewing certificate is not supported, create a new one instead"}
+}
+
+// RevokeCertificate does nothing as the revocation status is recorded by the caller and published via createCRL and createOCSPResponse
+func (c *embeddedCAClient) RevokeCertificate(req *stepcaapiv1.RevokeCertificateRequest) (*stepcaapiv1.RevokeCertificateResponse, error) {
+	return &stepcaapiv1.RevokeCertificateResponse{
+		Certificate:      req.Certificate,
+		CertificateChain: []*x509.Certificate{c.intermediateCert},
+	}, nil
+}
+
+// createCRL returns a DER encoded CRL signed by the intermediate CA
+func (c *embeddedCAClient) createCRL(revokedList []pkix.RevokedCertificate, validity time.Duration) ([]byte, error) {
+	now := time.Now()
+	return x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: revokedList,
+		Number:              big.NewInt(now.Unix()),
+		ThisUpdate:          now,
+		NextUpdate:          now.Add(validity),
+	}, c.intermediateCert, c.intermediateKey)
+}
+
+// createOCSPResponse returns a DER encoded OCSP response signed by the intermediate CA
+func (c *embeddedCAClient) createOCSPResponse(template ocsp.Response) ([]byte, error) {
+	return ocsp.CreateResponse(c.intermediateCert, c.intermediateCert, template, c.intermediateKey)
+}
+
+// signEmbeddedCACertificate signs the template with the parent certificate, or self-signs it if parent is nil
+func signEmbeddedCACertificate(template *x509.Certificate, pub crypto.PublicKey, parent *x509.Certificate, signer crypto.Signer, lifetime time.Duration) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template.SerialNumber = serialNumber
+	template.NotBefore = now.Add(-time.Minute)
+	template.NotAfter = now.Add(lifetime)
+	if parent == nil {
+		parent = template
+	} else if template.NotAfter.After(parent.NotAfter) {
+		template.NotAfter = parent.NotAfter
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(certBytes)
+}
+
+func encryptECPrivateKey(key *ecdsa.PrivateKey) (string, error) {
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return utils.Encrypt(string(pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: keyBytes,
+	})))
+}
+
+func encodeCertificatePEM(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: cert.Raw,
+	})
+}
+
+func parseCertificatePEM(certPEM string) (*x509.Certificate, error) {
+	b, _ := pem.Decode([]byte(certPEM))
+	if b == nil {
+		return nil, errors.Errorf("failed to decode PEM block")
+	}
+	return x509.ParseCertificate(b.Bytes)
+}
@@ -0,0 +1,237 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	stepcaapiv1 "github.com/smallstep/certificates/cas/apiv1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+func newTestCSR(t *testing.T, commonName string) *x509.CertificateRequest {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: []string{commonName},
+	}, pk)
+	assert.NoError(t, err)
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	assert.NoError(t, err)
+	return csr
+}
+
+func TestEmbeddedCA(t *testing.T) {
+	config, err := GenerateEmbeddedCAConfiguration("Test", "FedLCM")
+	assert.NoError(t, err)
+	assert.NotContains(t, config.EncryptedIntermediateKeyPEM, "PRIVATE KEY")
+	configJSON, _ := json.Marshal(config)
+	ca := &CertificateAuthority{
+		Type:              CertificateAuthorityTypeEmbedded,
+		ConfigurationJSON: string(configJSON),
+	}
+
+	client, err := ca.Client()
+	assert.NoError(t, err)
+	resp, err := client.CreateCertificate(&stepcaapiv1.CreateCertificateRequest{
+		CSR:      newTestCSR(t, "test.example.com"),
+		Lifetime: time.Hour * 24,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "test.example.com", resp.Certificate.Subject.CommonName)
+	assert.Equal(t, []string{"test.example.com"}, resp.Certificate.DNSNames)
+	assert.Len(t, resp.CertificateChain, 1)
+
+	rootCert, err := ca.RootCert()
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Root CA", rootCert.Subject.CommonName)
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(resp.CertificateChain[0])
+	_, err = resp.Certificate.Verify(x509.VerifyOptions{
+		DNSName:       "test.example.com",
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+}
+
+func TestEmbeddedCARevocation(t *testing.T) {
+	config, err := GenerateEmbeddedCAConfiguration("Test", "FedLCM")
+	assert.NoError(t, err)
+	config.CRLURL = "http://lifecycle-manager/api/v1/certificate-authority/crl"
+	config.OCSPURL = "http://lifecycle-manager/api/v1/certificate-authority/ocsp"
+	configJSON, _ := json.Marshal(config)
+	ca := &CertificateAuthority{
+		Type:              CertificateAuthorityTypeEmbedded,
+		ConfigurationJSON: string(configJSON),
+	}
+
+	client, err := ca.Client()
+	assert.NoError(t, err)
+	resp, err := client.CreateCertificate(&stepcaapiv1.CreateCertificateRequest{
+		CSR:      newTestCSR(t, "test.example.com"),
+		Lifetime: time.Hour * 24,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{config.CRLURL}, resp.Certificate.CRLDistributionPoints)
+	assert.Equal(t, []string{config.OCSPURL}, resp.Certificate.OCSPServer)
+	_, err = client.RevokeCertificate(&stepcaapiv1.RevokeCertificateRequest{Certificate: resp.Certificate})
+	assert.NoError(t, err)
+
+	revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	crlBytes, err := ca.CreateCRL([]pkix.RevokedCertificate{{
+		SerialNumber:   resp.Certificate.SerialNumber,
+		RevocationTime: revokedAt,
+	}}, time.Hour)
+	assert.NoError(t, err)
+	crl, err := x509.ParseRevocationList(crlBytes)
+	assert.NoError(t, err)
+	assert.NoError(t, crl.CheckSignatureFrom(resp.CertificateChain[0]))
+	assert.Len(t, crl.RevokedCertificates, 1)
+	assert.Equal(t, resp.Certificate.SerialNumber, crl.RevokedCertificates[0].SerialNumber)
+
+	ocspBytes, err := ca.CreateOCSPResponse(ocsp.Response{
+		Status:       ocsp.Revoked,
+		SerialNumber: resp.Certificate.SerialNumber,
+		ThisUpdate:   time.Now(),
+		NextUpdate:   time.Now().Add(time.Hour),
+		RevokedAt:    revokedAt,
+	})
+	assert.NoError(t, err)
+	ocspResp, err := ocsp.ParseResponseForCert(ocspBytes, resp.Certificate, resp.CertificateChain[0])
+	assert.NoError(t, err)
+	assert.Equal(t, ocsp.Revoked, ocspResp.Status)
+	assert.True(t, revokedAt.Equal(ocspResp.RevokedAt))
+
+	stepCA := &CertificateAuthority{Type: CertificateAuthorityTypeStepCA}
+	_, err = stepCA.CreateCRL(nil, time.Hour)
+	assert.Error(t, err)
+	_, err = stepCA.CreateOCSPResponse(ocsp.Response{})
+	assert.Error(t, err)
+}
+
+func TestVaultCA(t *testing.T) {
+	embeddedConfig, err := GenerateEmbeddedCAConfiguration("Vault", "")
+	assert.NoError(t, err)
+	embeddedClient, err := newEmbeddedCAClient(func() string {
+		configJSON, _ := json.Marshal(embeddedConfig)
+		return string(configJSON)
+	}())
+	assert.NoError(t, err)
+
+	var revokedSerial string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/pki-int/sign/fedlcm":
+			var body map[string]string
+			bodyBytes, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(bodyBytes, &body)
+			assert.Equal(t, "86400s", body["ttl"])
+			b, _ := pem.Decode([]byte(body["csr"]))
+			csr, err := x509.ParseCertificateRequest(b.Bytes)
+			assert.NoError(t, err)
+			resp, err := embeddedClient.CreateCertificate(&stepcaapiv1.CreateCertificateRequest{
+				CSR:      csr,
+				Lifetime: time.Hour * 24,
+			})
+			assert.NoError(t, err)
+			data, _ := json.Marshal(map[string]interface{}{
+				"data": vaultSignResponseData{
+					Certificate: string(encodeCertificatePEM(resp.Certificate)),
+					IssuingCA:   embeddedConfig.IntermediateCertificatePEM,
+					CAChain:     []string{embeddedConfig.IntermediateCertificatePEM, embeddedConfig.RootCertificatePEM},
+				},
+			})
+			_, _ = w.Write(data)
+		case "/v1/pki-int/ca_chain":
+			_, _ = w.Write([]byte(embeddedConfig.IntermediateCertificatePEM + embeddedConfig.RootCertificatePEM))
+		case "/v1/pki-int/revoke":
+			var body map[string]string
+			bodyBytes, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(bodyBytes, &body)
+			revokedSerial = body["serial_number"]
+			_, _ = w.Write([]byte(`{"data":{"revocation_time":1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vaultConfig := CertificateAuthorityConfigurationVault{
+		ServiceURL: server.URL,
+		Token:      "test-token",
+		Mount:      "pki-int",
+		Role:       "fedlcm",
+	}
+	assert.NoError(t, vaultConfig.EncryptToken())
+	configJSON, err := json.Marshal(vaultConfig)
+	assert.NoError(t, err)
+	// the token is not saved in plaintext
+	assert.NotContains(t, string(configJSON), "test-token")
+	ca := &CertificateAuthority{
+		Type:              CertificateAuthorityTypeVault,
+		ConfigurationJSON: string(configJSON),
+	}
+	client, err := ca.Client()
+	assert.NoError(t, err)
+	resp, err := client.CreateCertificate(&stepcaapiv1.CreateCertificateRequest{
+		CSR:      newTestCSR(t, "vault.example.com"),
+		Lifetime: time.Hour * 24,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "vault.example.com", resp.Certificate.Subject.CommonName)
+	// the root CA is not included in the chain
+	assert.Len(t, resp.CertificateChain, 1)
+
+	rootCert, err := ca.RootCert()
+	assert.NoError(t, err)
+	assert.Equal(t, "Vault Root CA", rootCert.Subject.CommonName)
+
+	_, err = client.RevokeCertificate(&stepcaapiv1.RevokeCertificateRequest{
+		Certificate: resp.Certificate,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, vaultSerialNumber(resp.Certificate), revokedSerial)
+	assert.True(t, strings.Contains(revokedSerial, ":"))
+
+	ca.ConfigurationJSON = `{"service_url":"` + server.URL + `","token":"wrong-token","mount":"pki-int","role":"fedlcm"}`
+	client, err = ca.Client()
+	assert.NoError(t, err)
+	_, err = client.CreateCertificate(&stepcaapiv1.CreateCertificateRequest{
+		CSR: newTestCSR(t, "vault.example.com"),
+	})
+	assert.ErrorContains(t, err, "permission denied")
+}
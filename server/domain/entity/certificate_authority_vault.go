@@ -0,0 +1,298 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
+	"github.com/pkg/errors"
+	stepcaapiv1 "github.com/smallstep/certificates/cas/apiv1"
+)
+
+// CertificateAuthorityConfigurationVault contains basic configuration for a HashiCorp Vault PKI secrets engine
+type CertificateAuthorityConfigurationVault struct {
+	ServiceURL string `json:"service_url" mapstructure:"service_url"`
+	// ServiceCertificatePEM is the optional CA certificate to verify the Vault server's TLS certificate
+	ServiceCertificatePEM string `json:"service_cert_pem" mapstructure:"service_cert_pem"`
+	// Token is the plaintext token provided by the user, it is encrypted into EncryptedToken before being saved
+	Token          string `json:"token,omitempty" mapstructure:"token"`
+	EncryptedToken string `json:"encrypted_token" mapstructure:"-"`
+	// Namespace is the Vault Enterprise namespace, can be empty
+	Namespace string `json:"namespace" mapstructure:"namespace"`
+	// Mount is the path where the PKI secrets engine is mounted, default to "pki"
+	Mount string `json:"mount" mapstructure:"mount"`
+	Role  string `json:"role" mapstructure:"role"`
+}
+
+// vaultCAClient signs the CSRs using the "sign" endpoint of a Vault PKI secrets engine
+type vaultCAClient struct {
+	config     CertificateAuthorityConfigurationVault
+	httpClient *http.Client
+}
+
+var _ CertificateAuthorityClient = (*vaultCAClient)(nil)
+
+// EncryptToken moves the plaintext token into EncryptedToken so it is not saved in plaintext
+func (c *CertificateAuthorityConfigurationVault) EncryptToken() error {
+	if c.Token == "" {
+		return nil
+	}
+	encryptedToken, err := utils.Encrypt(c.Token)
+	if err != nil {
+		return err
+	}
+	c.EncryptedToken = encryptedToken
+	c.Token = ""
+	return nil
+}
+
+func newVaultCAClient(configJSON string) (*vaultCAClient, error) {
+	var config CertificateAuthorityConfigurationVault
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return nil, err
+	}
+	u, err := url.ParseRequestURI(config.ServiceURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("service URL is invalid: http:// or https:// schema is required")
+	}
+	if config.Token == "" && config.EncryptedToken != "" {
+		token, err := utils.Decrypt(config.EncryptedToken)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt token")
+		}
+		config.Token = token
+	}
+	if config.Token == "" {
+		return nil, errors.New("token is required")
+	}
+	if config.Role == "" {
+		return nil, errors.New("role is required")
+	}
+	if config.Mount == "" {
+		config.Mount = "pki"
+	}
+	config.ServiceURL = strings.TrimSuffix(config.ServiceURL, "/")
+	config.Mount = strings.Trim(config.Mount, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.ServiceCertificatePEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.ServiceCertificatePEM)) {
+			return nil, errors.Errorf("failed to decode service certificate PEM")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: pool,
+		}
+	}
+	return &vaultCAClient{
+		config: config,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   time.Second * 30,
+		},
+	}, nil
+}
+
+// vaultResponse is the general response structure of the Vault HTTP API
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+// vaultSignResponseData is the data of the "sign" API response
+type vaultSignResponseData struct {
+	Certificate string   `json:"certificate"`
+	IssuingCA   string   `json:"issuing_ca"`
+	CAChain     []string `json:"ca_chain"`
+}
+
+func (c *vaultCAClient) CreateCertificate(req *stepcaapiv1.CreateCertificateRequest) (*stepcaapiv1.CreateCertificateResponse, error) {
+	if req.CSR == nil {
+		return nil, errors.New("CSR is required")
+	}
+	body := map[string]interface{}{
+		"csr": string(pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE REQUEST",
+			Bytes: req.CSR.Raw,
+		})),
+		"common_name": req.CSR.Subject.CommonName,
+		"alt_names":   strings.Join(req.CSR.DNSNames, ","),
+		"format":      "pem",
+	}
+	if req.Lifetime > 0 {
+		body["ttl"] = fmt.Sprintf("%ds", int64(req.Lifetime.Seconds()))
+	}
+	respData, err := c.request(http.MethodPost, fmt.Sprintf("sign/%s", c.config.Role), body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to sign the certificate")
+	}
+	var signData vaultSignResponseData
+	if err := json.Unmarshal(respData, &signData); err != nil {
+		return nil, err
+	}
+	cert, err := parseCertificatePEM(signData.Certificate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the signed certificate")
+	}
+	chainPEMList := signData.CAChain
+	if len(chainPEMList) == 0 && signData.IssuingCA != "" {
+		chainPEMList = []string{signData.IssuingCA}
+	}
+	var chain []*x509.Certificate
+	for _, chainPEM := range chainPEMList {
+		chainCert, err := parseCertificatePEM(chainPEM)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the CA chain")
+		}
+		// the root CA is distributed separately
+		if chainCert.IsCA && bytes.Equal(chainCert.RawIssuer, chainCert.RawSubject) {
+			continue
+		}
+		chain = append(chain, chainCert)
+	}
+	return &stepcaapiv1.CreateCertificateResponse{
+		Certificate:      cert,
+		CertificateChain: chain,
+	}, nil
+}
+
+func (c *vaultCAClient) RenewCertificate(req *stepcaapiv1.RenewCertificateRequest) (*stepcaapiv1.RenewCertificateResponse, error) {
+	return nil, stepcaapiv1.NotImplementedError{Message: "renewing certificate is not supported, create a new one instead"}
+}
+
+func (c *vaultCAClient) RevokeCertificate(req *stepcaapiv1.RevokeCertificateRequest) (*stepcaapiv1.RevokeCertificateResponse, error) {
+	serialNumber := req.SerialNumber
+	if req.Certificate != nil {
+		serialNumber = vaultSerialNumber(req.Certificate)
+	}
+	if serialNumber == "" {
+		return nil, errors.New("serial number is required")
+	}
+	if _, err := c.request(http.MethodPost, "revoke", map[string]interface{}{
+		"serial_number": serialNumber,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to revoke the certificate")
+	}
+	return &stepcaapiv1.RevokeCertificateResponse{
+		Certificate: req.Certificate,
+	}, nil
+}
+
+// rootCert returns the last certificate in the CA chain of the PKI secrets engine
+func (c *vaultCAClient) rootCert() (*x509.Certificate, error) {
+	chainPEM, err := c.requestRaw(http.MethodGet, "ca_chain", nil)
+	if err != nil || len(bytes.TrimSpace(chainPEM)) == 0 {
+		if chainPEM, err = c.requestRaw(http.MethodGet, "ca/pem", nil); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the CA certificate")
+		}
+	}
+	var root *x509.Certificate
+	rest := chainPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		root = cert
+	}
+	if root == nil {
+		return nil, errors.New("no CA certificate found")
+	}
+	return root, nil
+}
+
+// request sends the request to the PKI secrets engine and returns the "data" field of the response
+func (c *vaultCAClient) request(method, path string, body interface{}) (json.RawMessage, error) {
+	respBody, err := c.requestRaw(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	var resp vaultResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse response")
+	}
+	return resp.Data, nil
+}
+
+func (c *vaultCAClient) requestRaw(method, path string, body interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s/%s", c.config.ServiceURL, c.config.Mount, path), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", c.config.Token)
+	if c.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errResp vaultResponse
+		if err := json.Unmarshal(respBody, &errResp); err == nil && len(errResp.Errors) > 0 {
+			return nil, errors.Errorf("vault returned %d: %s", resp.StatusCode, strings.Join(errResp.Errors, "; "))
+		}
+		return nil, errors.Errorf("vault returned %d", resp.StatusCode)
+	}
+	return respBody, nil
+}
+
+// vaultSerialNumber returns the serial number in the colon separated hex format used by Vault
+func vaultSerialNumber(cert *x509.Certificate) string {
+	hexStr := fmt.Sprintf("%x", cert.SerialNumber)
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	var parts []string
+	for i := 0; i < len(hexStr); i += 2 {
+		parts = append(parts, hexStr[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
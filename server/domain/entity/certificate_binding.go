@@ -64,6 +64,8 @@ func (t CertificateBindingServiceType) String() string {
 		return "openfl director"
 	case CertificateBindingServiceTypeOpenFLJupyter:
 		return "openfl jupyter client"
+	case CertificateBindingServiceTypeOpenFLEnvoy:
+		return "openfl envoy"
 	}
 	return "unknown"
 }
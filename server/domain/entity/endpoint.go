@@ -47,6 +47,7 @@ const (
 	EndpointStatusDismissed
 	EndpointStatusUnavailable
 	EndpointStatusDeleting
+	EndpointStatusUpgrading
 )
 
 func (s EndpointStatus) String() string {
@@ -62,6 +63,8 @@ func (s EndpointStatus) String() string {
 		res = "Unavailable"
 	case EndpointStatusDeleting:
 		res = "Deleting"
+	case EndpointStatusUpgrading:
+		res = "Upgrading"
 	}
 	return res
 }
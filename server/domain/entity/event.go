@@ -87,6 +87,8 @@ func (t EntityType) String() string {
 		return "Cluster"
 	case EntityTypeOpenFLDirector:
 		return "OpenFL Director"
+	case EntityTypeOpenFLEnvoy:
+		return "OpenFL Envoy"
 	}
 	return "Unknown"
 }
@@ -23,6 +23,27 @@ import (
 type FederationFATE struct {
 	Federation
 	Domain string `gorm:"type:varchar(255);not null"`
+	Mode   FederationFATEMode
+}
+
+// FederationFATEMode is how the clusters in the federation reach each other
+type FederationFATEMode uint8
+
+const (
+	// FederationFATEModeExchange routes the traffic between clusters through exchanges
+	FederationFATEModeExchange FederationFATEMode = iota
+	// FederationFATEModePeerToPeer routes the traffic between clusters directly, and no exchange is used
+	FederationFATEModePeerToPeer
+)
+
+func (m FederationFATEMode) String() string {
+	switch m {
+	case FederationFATEModeExchange:
+		return "Exchange"
+	case FederationFATEModePeerToPeer:
+		return "PeerToPeer"
+	}
+	return "Unknown"
 }
 
 // Create creates the FATE federation record in the repo
@@ -31,5 +52,8 @@ func (federation *FederationFATE) Create() error {
 	if !utils.IsDomainName(federation.Domain) {
 		return errors.New("invalid domain name")
 	}
+	if federation.Mode > FederationFATEModePeerToPeer {
+		return errors.Errorf("unknown federation mode: %v", federation.Mode)
+	}
 	return federation.Repo.Create(federation)
 }
@@ -0,0 +1,53 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import "gorm.io/gorm"
+
+// FederationFATEDiagnostics is a run of the connectivity checks between the participants of a FATE federation
+type FederationFATEDiagnostics struct {
+	gorm.Model
+	UUID           string `gorm:"type:varchar(36);index;unique"`
+	FederationUUID string `gorm:"type:varchar(36);index"`
+	Status         FederationFATEDiagnosticsStatus
+	// Message is the reason of the failure if the checks can't be run
+	Message string `gorm:"type:text"`
+	// Report is the JSON encoded report of the checks, set when the run succeeded
+	Report string `gorm:"type:text"`
+}
+
+// FederationFATEDiagnosticsStatus is the status of the diagnostics run
+type FederationFATEDiagnosticsStatus uint8
+
+const (
+	FederationFATEDiagnosticsStatusUnknown FederationFATEDiagnosticsStatus = iota
+	FederationFATEDiagnosticsStatusRunning
+	// FederationFATEDiagnosticsStatusSucceeded means the checks are finished, some of them may have failed
+	FederationFATEDiagnosticsStatusSucceeded
+	// FederationFATEDiagnosticsStatusFailed means the checks can't be run
+	FederationFATEDiagnosticsStatusFailed
+)
+
+func (t FederationFATEDiagnosticsStatus) String() string {
+	switch t {
+	case FederationFATEDiagnosticsStatusRunning:
+		return "Running"
+	case FederationFATEDiagnosticsStatusSucceeded:
+		return "Succeeded"
+	case FederationFATEDiagnosticsStatusFailed:
+		return "Failed"
+	}
+	return "Unknown"
+}
@@ -15,8 +15,6 @@
 package entity
 
 import (
-	"strings"
-
 	"github.com/FederatedAI/FedLCM/server/domain/utils"
 	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
 	"github.com/pkg/errors"
@@ -28,6 +26,27 @@ type FederationOpenFL struct {
 	Domain                       string `gorm:"type:varchar(255);not null"`
 	UseCustomizedShardDescriptor bool
 	ShardDescriptorConfig        *valueobject.ShardDescriptorConfig `gorm:"type:text"`
+	DeploymentMethod             FederationOpenFLDeploymentMethod
+}
+
+// FederationOpenFLDeploymentMethod is how the director and envoys in the federation are deployed
+type FederationOpenFLDeploymentMethod uint8
+
+const (
+	// FederationOpenFLDeploymentMethodKubeFATE deploys the participants through a KubeFATE endpoint
+	FederationOpenFLDeploymentMethodKubeFATE FederationOpenFLDeploymentMethod = iota
+	// FederationOpenFLDeploymentMethodHelm installs the charts as helm releases directly through the Kubernetes API
+	FederationOpenFLDeploymentMethodHelm
+)
+
+func (m FederationOpenFLDeploymentMethod) String() string {
+	switch m {
+	case FederationOpenFLDeploymentMethodKubeFATE:
+		return "KubeFATE"
+	case FederationOpenFLDeploymentMethodHelm:
+		return "Helm"
+	}
+	return "Unknown"
 }
 
 // Create creates the OpenFL federation record in the repo
@@ -36,16 +55,28 @@ func (federation *FederationOpenFL) Create() error {
 	if !utils.IsDomainName(federation.Domain) {
 		return errors.New("invalid domain name")
 	}
+	if federation.DeploymentMethod > FederationOpenFLDeploymentMethodHelm {
+		return errors.Errorf("unknown deployment method: %v", federation.DeploymentMethod)
+	}
 	if federation.UseCustomizedShardDescriptor {
-		for file, _ := range federation.ShardDescriptorConfig.PythonFiles {
-			if strings.Contains(file, " ") {
-				return errors.New("filename cannot contain space")
-			}
+		if federation.ShardDescriptorConfig == nil {
+			return errors.New("missing shard descriptor config")
+		}
+		if err := federation.ShardDescriptorConfig.Validate(); err != nil {
+			return err
 		}
 	}
 	return federation.Repo.Create(federation)
 }
 
+// DirectorShapes returns the sample and target shape the director is configured with
+func (federation *FederationOpenFL) DirectorShapes() (sampleShape []string, targetShape []string) {
+	if federation.UseCustomizedShardDescriptor && federation.ShardDescriptorConfig != nil {
+		return federation.ShardDescriptorConfig.SampleShape, federation.ShardDescriptorConfig.TargetShape
+	}
+	return []string{"1"}, []string{"1"}
+}
+
 func (FederationOpenFL) TableName() string {
 	// just following the gorm convention
 	return "federation_openfls"
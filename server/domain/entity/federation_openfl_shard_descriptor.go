@@ -0,0 +1,65 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"reflect"
+
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// FederationOpenFLShardDescriptor is a version of the shard descriptor config of an OpenFL federation. New envoys
+// are deployed with the latest version, and existing envoys can be rolled out to a specific version.
+type FederationOpenFLShardDescriptor struct {
+	gorm.Model
+	UUID           string                                         `gorm:"type:varchar(36);index;unique"`
+	FederationUUID string                                         `gorm:"type:varchar(36);uniqueIndex:idx_federation_shard_descriptor_version"`
+	Version        uint                                           `gorm:"uniqueIndex:idx_federation_shard_descriptor_version"`
+	Description    string                                         `gorm:"type:text"`
+	Config         valueobject.ShardDescriptorConfig              `gorm:"type:text"`
+	Repo           repo.FederationOpenFLShardDescriptorRepository `gorm:"-"`
+}
+
+// Create validates the config and saves it as the next version of the federation's shard descriptor
+func (descriptor *FederationOpenFLShardDescriptor) Create(federation *FederationOpenFL) error {
+	if err := descriptor.Config.Validate(); err != nil {
+		return err
+	}
+	// envoys can only connect to the director if their shapes match the director's
+	sampleShape, targetShape := federation.DirectorShapes()
+	if len(descriptor.Config.SampleShape) == 0 {
+		descriptor.Config.SampleShape = sampleShape
+	} else if !reflect.DeepEqual(descriptor.Config.SampleShape, sampleShape) {
+		return errors.Errorf("sample shape %v doesn't match the director's %v", descriptor.Config.SampleShape, sampleShape)
+	}
+	if len(descriptor.Config.TargetShape) == 0 {
+		descriptor.Config.TargetShape = targetShape
+	} else if !reflect.DeepEqual(descriptor.Config.TargetShape, targetShape) {
+		return errors.Errorf("target shape %v doesn't match the director's %v", descriptor.Config.TargetShape, targetShape)
+	}
+
+	latestVersion, err := descriptor.Repo.GetLatestVersionByFederationUUID(federation.UUID)
+	if err != nil {
+		return errors.Wrap(err, "failed to query the latest version")
+	}
+	descriptor.UUID = uuid.NewV4().String()
+	descriptor.FederationUUID = federation.UUID
+	descriptor.Version = latestVersion + 1
+	return descriptor.Repo.Create(descriptor)
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeShardDescriptorRepo struct {
+	created []*FederationOpenFLShardDescriptor
+}
+
+func (r *fakeShardDescriptorRepo) Create(instance interface{}) error {
+	r.created = append(r.created, instance.(*FederationOpenFLShardDescriptor))
+	return nil
+}
+
+func (r *fakeShardDescriptorRepo) ListByFederationUUID(string) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *fakeShardDescriptorRepo) GetByFederationUUIDAndVersion(string, uint) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *fakeShardDescriptorRepo) GetLatestVersionByFederationUUID(string) (uint, error) {
+	return uint(len(r.created)), nil
+}
+
+func (r *fakeShardDescriptorRepo) DeleteByFederationUUID(string) error {
+	return nil
+}
+
+func TestFederationOpenFLShardDescriptorCreate(t *testing.T) {
+	federation := &FederationOpenFL{
+		Federation:                   Federation{UUID: "federation-uuid"},
+		UseCustomizedShardDescriptor: true,
+		ShardDescriptorConfig: &valueobject.ShardDescriptorConfig{
+			SampleShape: []string{"28", "28"},
+			TargetShape: []string{"1"},
+		},
+	}
+	repo := &fakeShardDescriptorRepo{}
+	newDescriptor := func(config valueobject.ShardDescriptorConfig) *FederationOpenFLShardDescriptor {
+		return &FederationOpenFLShardDescriptor{Config: config, Repo: repo}
+	}
+
+	descriptor := newDescriptor(valueobject.ShardDescriptorConfig{PythonFiles: map[string]string{"shard_descriptor.py": "# v1"}})
+	assert.NoError(t, descriptor.Create(federation))
+	assert.Equal(t, uint(1), descriptor.Version)
+	assert.Equal(t, "federation-uuid", descriptor.FederationUUID)
+	assert.Equal(t, []string{"28", "28"}, descriptor.Config.SampleShape, "shapes should default to the director's")
+
+	descriptor = newDescriptor(valueobject.ShardDescriptorConfig{SampleShape: []string{"28", "28"}, TargetShape: []string{"1"}})
+	assert.NoError(t, descriptor.Create(federation))
+	assert.Equal(t, uint(2), descriptor.Version)
+
+	assert.Error(t, newDescriptor(valueobject.ShardDescriptorConfig{SampleShape: []string{"32", "32"}}).Create(federation))
+	assert.Error(t, newDescriptor(valueobject.ShardDescriptorConfig{PythonFiles: map[string]string{"my descriptor.py": ""}}).Create(federation))
+	assert.Error(t, newDescriptor(valueobject.ShardDescriptorConfig{PythonFiles: map[string]string{"big.py": strings.Repeat("#", 300*1024)}}).Create(federation))
+	assert.Error(t, newDescriptor(valueobject.ShardDescriptorConfig{EnvoyConfigYaml: "params: ["}).Create(federation))
+	assert.Len(t, repo.created, 2)
+}
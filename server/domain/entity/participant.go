@@ -92,6 +92,12 @@ const (
 	CertBindingModeCreate
 )
 
+// RequiresCertificate returns true if a certificate should be prepared for the component, by either creating a new one
+// or reusing an imported one
+func (m ParticipantCertBindingMode) RequiresCertificate() bool {
+	return m == CertBindingModeCreate || m == CertBindingModeReuse
+}
+
 // ParticipantModulesAccess contains access info of a participant service
 type ParticipantModulesAccess struct {
 	ServiceType corev1.ServiceType `json:"service_type"`
@@ -18,6 +18,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
@@ -31,6 +32,38 @@ type ParticipantFATE struct {
 	CertConfig  ParticipantFATECertConfig       `gorm:"type:text"`
 	AccessInfo  ParticipantFATEModulesAccessMap `gorm:"type:text"`
 	IngressInfo ParticipantFATEIngressMap       `gorm:"type:text"`
+	// TokenUUID is the registration token used if the cluster is self-registered
+	TokenUUID string `gorm:"type:varchar(36)"`
+	// ExchangeUUID is the primary exchange a cluster is routed through, empty means the first exchange of the federation
+	ExchangeUUID string `gorm:"type:varchar(36)"`
+	// StandbyExchangeUUID is the exchange a cluster's routing can fail over to, empty means no standby
+	StandbyExchangeUUID string `gorm:"type:varchar(36)"`
+	// SizingProfileUUID is the sizing profile last applied to a cluster, empty means the chart defaults or a hand-edited yaml
+	SizingProfileUUID string `gorm:"type:varchar(36)"`
+	// Backend is the computing and federation engine combination of a cluster, empty means Spark with Pulsar
+	Backend ParticipantFATEBackend `gorm:"type:varchar(32)"`
+	// EncryptedRabbitMQPassword is the encrypted password of the rabbitmq user of a spark_rabbitmq cluster, which the
+	// peer clusters use to federate with its rabbitmq
+	EncryptedRabbitMQPassword string `gorm:"type:text"`
+}
+
+// RabbitMQPassword returns the password of the rabbitmq user of a spark_rabbitmq cluster
+func (p *ParticipantFATE) RabbitMQPassword() (string, error) {
+	password, err := utils.Decrypt(p.EncryptedRabbitMQPassword)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decrypt the rabbitmq password")
+	}
+	return password, nil
+}
+
+// SetRabbitMQPassword encrypts and saves the password of the rabbitmq user of a spark_rabbitmq cluster
+func (p *ParticipantFATE) SetRabbitMQPassword(password string) error {
+	encryptedPassword, err := utils.Encrypt(password)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt the rabbitmq password")
+	}
+	p.EncryptedRabbitMQPassword = encryptedPassword
+	return nil
 }
 
 // GetSitePortalAdminPassword returns the admin password of the deployed site portal service
@@ -79,6 +112,9 @@ const (
 	ParticipantFATEServiceNamePulsar ParticipantFATEServiceName = "pulsar-public-tls"
 	ParticipantFATEServiceNamePortal ParticipantFATEServiceName = "frontend"
 	ParticipantFATEServiceNameFMLMgr ParticipantFATEServiceName = "fml-manager-server"
+
+	ParticipantFATEServiceNameRollsite ParticipantFATEServiceName = "rollsite"
+	ParticipantFATEServiceNameRabbitMQ ParticipantFATEServiceName = "rabbitmq"
 )
 
 const (
@@ -0,0 +1,79 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import "github.com/pkg/errors"
+
+// ParticipantFATEBackend is the combination of the computing, storage and federation engines of a FATE cluster
+type ParticipantFATEBackend string
+
+const (
+	// ParticipantFATEBackendSparkPulsar uses Spark and HDFS, and talks to other parties via Pulsar tunneled by the exchange's ATS
+	ParticipantFATEBackendSparkPulsar ParticipantFATEBackend = "spark_pulsar"
+	// ParticipantFATEBackendSparkRabbitMQ uses Spark and HDFS, and talks to other parties' RabbitMQ directly
+	ParticipantFATEBackendSparkRabbitMQ ParticipantFATEBackend = "spark_rabbitmq"
+	// ParticipantFATEBackendEggroll uses Eggroll for computing and storage, and talks to other parties via the rollsite
+	ParticipantFATEBackendEggroll ParticipantFATEBackend = "eggroll"
+)
+
+// OrDefault returns the backend, or Spark with Pulsar which clusters used before the backend could be chosen
+func (b ParticipantFATEBackend) OrDefault() ParticipantFATEBackend {
+	if b == "" {
+		return ParticipantFATEBackendSparkPulsar
+	}
+	return b
+}
+
+// Validate returns error if the backend is unknown, an empty backend means the default one
+func (b ParticipantFATEBackend) Validate() error {
+	switch b.OrDefault() {
+	case ParticipantFATEBackendSparkPulsar, ParticipantFATEBackendSparkRabbitMQ, ParticipantFATEBackendEggroll:
+		return nil
+	}
+	return errors.Errorf("unknown FATE backend: %s", b)
+}
+
+// Computing returns the computing engine name used in the deployment yaml
+func (b ParticipantFATEBackend) Computing() string {
+	if b.OrDefault() == ParticipantFATEBackendEggroll {
+		return "Eggroll"
+	}
+	return "Spark"
+}
+
+// Federation returns the federation engine name used in the deployment yaml
+func (b ParticipantFATEBackend) Federation() string {
+	switch b.OrDefault() {
+	case ParticipantFATEBackendSparkRabbitMQ:
+		return "RabbitMQ"
+	case ParticipantFATEBackendEggroll:
+		return "Eggroll"
+	}
+	return "Pulsar"
+}
+
+// Storage returns the storage engine name used in the deployment yaml
+func (b ParticipantFATEBackend) Storage() string {
+	if b.OrDefault() == ParticipantFATEBackendEggroll {
+		return "Eggroll"
+	}
+	return "HDFS"
+}
+
+// IsFederationCompatible returns whether parties using the two backends can talk to each other, which requires the same
+// federation engine
+func (b ParticipantFATEBackend) IsFederationCompatible(other ParticipantFATEBackend) bool {
+	return b.Federation() == other.Federation()
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"gorm.io/gorm"
+)
+
+// ParticipantFATESizingProfile is a named set of sizing settings that can be applied to FATE clusters
+type ParticipantFATESizingProfile struct {
+	gorm.Model
+	UUID        string                        `gorm:"type:varchar(36);index;unique"`
+	Name        string                        `gorm:"type:varchar(255);not null"`
+	Description string                        `gorm:"type:text"`
+	BuiltIn     bool                          `gorm:"not null;default:false"`
+	Sizing      valueobject.FATEClusterSizing `gorm:"type:text"`
+}
+
+// BuiltInParticipantFATESizingProfiles returns the profiles created by FedLCM, which cannot be modified or deleted
+func BuiltInParticipantFATESizingProfiles() []ParticipantFATESizingProfile {
+	profile := func(name, description string, replicas, processors int, cpuRequest, memoryRequest, cpuLimit, memoryLimit string) ParticipantFATESizingProfile {
+		component := valueobject.FATEComponentSizing{
+			CPURequest:    cpuRequest,
+			MemoryRequest: memoryRequest,
+			CPULimit:      cpuLimit,
+			MemoryLimit:   memoryLimit,
+		}
+		computing := component
+		computing.Replicas = replicas
+		return ParticipantFATESizingProfile{
+			Name:        name,
+			Description: description,
+			BuiltIn:     true,
+			Sizing: valueobject.FATEClusterSizing{
+				Python:            component,
+				NodeManager:       computing,
+				SparkWorker:       computing,
+				ProcessorsPerNode: processors,
+			},
+		}
+	}
+	return []ParticipantFATESizingProfile{
+		profile("small", "for trying out FATE with small datasets", 1, 2, "1", "2Gi", "2", "4Gi"),
+		profile("medium", "for typical jobs of a party", 2, 4, "2", "4Gi", "4", "8Gi"),
+		profile("large", "for jobs with large datasets or models", 3, 8, "4", "8Gi", "8", "16Gi"),
+	}
+}
@@ -17,6 +17,7 @@ package entity
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"time"
 
 	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
 )
@@ -37,6 +38,14 @@ type ParticipantOpenFL struct {
 	CertConfig ParticipantOpenFLCertConfig       `gorm:"type:text"`
 	AccessInfo ParticipantOpenFLModulesAccessMap `gorm:"type:text"`
 	Labels     valueobject.Labels                `gorm:"type:text"`
+	// LastSeen is the time of the latest heartbeat from the device agent of an envoy
+	LastSeen     *time.Time
+	DeviceHealth ParticipantOpenFLDeviceHealth `gorm:"type:text"`
+	// ShardDescriptorVersion is the version of the federation shard descriptor deployed in an envoy, 0 means the envoy
+	// uses a shard descriptor not tracked by the federation
+	ShardDescriptorVersion uint
+	// DeploymentMethod is copied from the federation when the participant is created
+	DeploymentMethod FederationOpenFLDeploymentMethod
 }
 
 // ParticipantOpenFLType is the openfl participant type
@@ -70,6 +79,8 @@ const (
 	ParticipantOpenFLStatusConfiguringInfra
 	ParticipantOpenFLStatusInstallingEndpoint
 	ParticipantOpenFLStatusInstallingEnvoy
+	ParticipantOpenFLStatusUpgrading
+	ParticipantOpenFLStatusReconfiguring
 )
 
 func (t ParticipantOpenFLStatus) String() string {
@@ -88,6 +99,10 @@ func (t ParticipantOpenFLStatus) String() string {
 		return "Installing Endpoint"
 	case ParticipantOpenFLStatusInstallingEnvoy:
 		return "Installing Envoy"
+	case ParticipantOpenFLStatusUpgrading:
+		return "Upgrading"
+	case ParticipantOpenFLStatusReconfiguring:
+		return "Reconfiguring"
 	}
 	return "Unknown"
 }
@@ -129,3 +144,38 @@ func (c ParticipantOpenFLModulesAccessMap) Value() (driver.Value, error) {
 func (c *ParticipantOpenFLModulesAccessMap) Scan(v interface{}) error {
 	return json.Unmarshal([]byte(v.(string)), c)
 }
+
+// ParticipantOpenFLDeviceHealth contains the device health info reported by the device agent of an envoy
+type ParticipantOpenFLDeviceHealth struct {
+	Nodes     []ParticipantOpenFLDeviceNodeInfo `json:"nodes"`
+	EnvoyPods []ParticipantOpenFLDevicePodInfo  `json:"envoy_pods"`
+}
+
+// ParticipantOpenFLDeviceNodeInfo contains the resource info of a K8s node in the envoy device
+type ParticipantOpenFLDeviceNodeInfo struct {
+	Name              string `json:"name"`
+	Ready             bool   `json:"ready"`
+	CPUCapacity       string `json:"cpu_capacity"`
+	CPUAllocatable    string `json:"cpu_allocatable"`
+	MemoryCapacity    string `json:"memory_capacity"`
+	MemoryAllocatable string `json:"memory_allocatable"`
+}
+
+// ParticipantOpenFLDevicePodInfo contains the state of an envoy pod
+type ParticipantOpenFLDevicePodInfo struct {
+	Name         string `json:"name"`
+	Phase        string `json:"phase"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restart_count"`
+}
+
+func (h ParticipantOpenFLDeviceHealth) Value() (driver.Value, error) {
+	bJson, err := json.Marshal(h)
+	return bJson, err
+}
+
+func (h *ParticipantOpenFLDeviceHealth) Scan(v interface{}) error {
+	// ignore any errors
+	_ = json.Unmarshal([]byte(v.(string)), h)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// ParticipantOpenFLBatchOperation is an operation performed on the envoys matching a label selector
+type ParticipantOpenFLBatchOperation struct {
+	gorm.Model
+	UUID           string `gorm:"type:varchar(36);index;unique"`
+	FederationUUID string `gorm:"type:varchar(36);index"`
+	Type           ParticipantOpenFLBatchOperationType
+	Selector       string `gorm:"type:text"`
+	Status         ParticipantOpenFLBatchOperationStatus
+	Items          ParticipantOpenFLBatchOperationItems `gorm:"type:text"`
+}
+
+// ParticipantOpenFLBatchOperationType is the type of the batch operation
+type ParticipantOpenFLBatchOperationType uint8
+
+const (
+	ParticipantOpenFLBatchOperationTypeUnknown ParticipantOpenFLBatchOperationType = iota
+	ParticipantOpenFLBatchOperationTypeRemove
+	ParticipantOpenFLBatchOperationTypeUpgrade
+	ParticipantOpenFLBatchOperationTypeReconfigure
+	// ParticipantOpenFLBatchOperationTypeShardDescriptorRollout deploys a version of the federation shard descriptor
+	ParticipantOpenFLBatchOperationTypeShardDescriptorRollout
+)
+
+func (t ParticipantOpenFLBatchOperationType) String() string {
+	switch t {
+	case ParticipantOpenFLBatchOperationTypeRemove:
+		return "Remove"
+	case ParticipantOpenFLBatchOperationTypeUpgrade:
+		return "Upgrade"
+	case ParticipantOpenFLBatchOperationTypeReconfigure:
+		return "Reconfigure"
+	case ParticipantOpenFLBatchOperationTypeShardDescriptorRollout:
+		return "ShardDescriptorRollout"
+	}
+	return "Unknown"
+}
+
+// ParticipantOpenFLBatchOperationStatus is the status of the batch operation or of an envoy in the operation
+type ParticipantOpenFLBatchOperationStatus uint8
+
+const (
+	ParticipantOpenFLBatchOperationStatusUnknown ParticipantOpenFLBatchOperationStatus = iota
+	ParticipantOpenFLBatchOperationStatusPending
+	ParticipantOpenFLBatchOperationStatusRunning
+	ParticipantOpenFLBatchOperationStatusSucceeded
+	// ParticipantOpenFLBatchOperationStatusFailed means the operation failed on the envoy, or on at least one envoy for the whole batch
+	ParticipantOpenFLBatchOperationStatusFailed
+)
+
+func (t ParticipantOpenFLBatchOperationStatus) String() string {
+	switch t {
+	case ParticipantOpenFLBatchOperationStatusPending:
+		return "Pending"
+	case ParticipantOpenFLBatchOperationStatusRunning:
+		return "Running"
+	case ParticipantOpenFLBatchOperationStatusSucceeded:
+		return "Succeeded"
+	case ParticipantOpenFLBatchOperationStatusFailed:
+		return "Failed"
+	}
+	return "Unknown"
+}
+
+// ParticipantOpenFLBatchOperationItem is the progress of the operation on one envoy
+type ParticipantOpenFLBatchOperationItem struct {
+	EnvoyUUID string                                `json:"envoy_uuid"`
+	EnvoyName string                                `json:"envoy_name"`
+	Status    ParticipantOpenFLBatchOperationStatus `json:"status"`
+	Message   string                                `json:"message"`
+}
+
+// ParticipantOpenFLBatchOperationItems is the list of the envoys in the operation
+type ParticipantOpenFLBatchOperationItems []ParticipantOpenFLBatchOperationItem
+
+func (c ParticipantOpenFLBatchOperationItems) Value() (driver.Value, error) {
+	bJson, err := json.Marshal(c)
+	return bJson, err
+}
+
+func (c *ParticipantOpenFLBatchOperationItems) Scan(v interface{}) error {
+	return json.Unmarshal([]byte(v.(string)), c)
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+
+	"gorm.io/gorm"
+)
+
+// RegistrationRecord is the audit record of a participant registered using a registration token
+type RegistrationRecord struct {
+	gorm.Model
+	TokenUUID string `gorm:"type:varchar(36);index"`
+	TokenName string `gorm:"type:varchar(255)"`
+	// CredentialHash is the SHA-256 hash of the credential returned to the registered participant, which is used to
+	// authenticate the requests of the participant afterwards
+	CredentialHash  string         `gorm:"type:varchar(64)"`
+	FederationUUID  string         `gorm:"type:varchar(36);index"`
+	FederationType  FederationType `gorm:"type:varchar(255)"`
+	ParticipantUUID string         `gorm:"type:varchar(36);index"`
+	ParticipantName string         `gorm:"type:varchar(255)"`
+	ParticipantType string         `gorm:"type:varchar(255)"`
+	SourceAddress   string         `gorm:"type:varchar(255)"`
+}
+
+// NewRegistrationCredential generates a random credential for a registered participant and returns it with its hash
+func NewRegistrationCredential() (credential string, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	credential = base64.RawURLEncoding.EncodeToString(b)
+	return credential, HashRegistrationCredential(credential), nil
+}
+
+// HashRegistrationCredential returns the hex encoded SHA-256 hash of the credential
+func HashRegistrationCredential(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyCredential checks the credential matches the one returned to the registered participant
+func (r *RegistrationRecord) VerifyCredential(credential string) bool {
+	if credential == "" || r.CredentialHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashRegistrationCredential(credential)), []byte(r.CredentialHash)) == 1
+}
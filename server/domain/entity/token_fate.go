@@ -0,0 +1,95 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/pkg/errors"
+)
+
+// RegistrationTokenFATE is the token a remote organization can use to register its FATE cluster into a FATE federation
+type RegistrationTokenFATE struct {
+	RegistrationToken
+	Constraints     RegistrationTokenFATEConstraints `gorm:"type:text"`
+	ParticipantRepo repo.ParticipantFATERepository   `gorm:"-"`
+}
+
+// RegistrationTokenFATEConstraints limits what can be registered using the token
+type RegistrationTokenFATEConstraints struct {
+	// ChartUUIDs contains the allowed charts, empty means any FATE cluster chart
+	ChartUUIDs []string `json:"chart_uuids"`
+	// PartyIDMin and PartyIDMax is the allowed party ID range, both inclusive, 0 means no limit
+	PartyIDMin int `json:"party_id_min"`
+	PartyIDMax int `json:"party_id_max"`
+}
+
+func (c RegistrationTokenFATEConstraints) Value() (driver.Value, error) {
+	bJson, err := json.Marshal(c)
+	return bJson, err
+}
+
+func (c *RegistrationTokenFATEConstraints) Scan(v interface{}) error {
+	return json.Unmarshal([]byte(v.(string)), c)
+}
+
+// Check returns error if the chart or the party ID is not allowed
+func (c RegistrationTokenFATEConstraints) Check(chartUUID string, partyID int) error {
+	if len(c.ChartUUIDs) > 0 {
+		allowed := false
+		for _, uuid := range c.ChartUUIDs {
+			if uuid == chartUUID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.Errorf("chart %s is not allowed by the token", chartUUID)
+		}
+	}
+	if c.PartyIDMin > 0 && partyID < c.PartyIDMin {
+		return errors.Errorf("party id %v is less than the allowed minimum %v", partyID, c.PartyIDMin)
+	}
+	if c.PartyIDMax > 0 && partyID > c.PartyIDMax {
+		return errors.Errorf("party id %v is greater than the allowed maximum %v", partyID, c.PartyIDMax)
+	}
+	return nil
+}
+
+// Create creates the FATE registration token record in the repo
+func (token *RegistrationTokenFATE) Create() error {
+	if token.Constraints.PartyIDMin > 0 && token.Constraints.PartyIDMax > 0 && token.Constraints.PartyIDMin > token.Constraints.PartyIDMax {
+		return errors.New("invalid party id range")
+	}
+	if err := token.prepare(); err != nil {
+		return err
+	}
+	return token.Repo.Create(token)
+}
+
+// Validate returns whether this token is still valid
+func (token *RegistrationTokenFATE) Validate() error {
+	if token.ParticipantRepo == nil {
+		return errors.New("nil participant repo")
+	}
+	return token.validate(token.ParticipantRepo.CountByTokenUUID)
+}
+
+func (RegistrationTokenFATE) TableName() string {
+	// just following the gorm convention
+	return "registration_token_fates"
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationTokenFATEConstraintsCheck(t *testing.T) {
+	constraints := RegistrationTokenFATEConstraints{
+		ChartUUIDs: []string{"chart-a"},
+		PartyIDMin: 9000,
+		PartyIDMax: 9999,
+	}
+	assert.NoError(t, constraints.Check("chart-a", 9000))
+	assert.NoError(t, constraints.Check("chart-a", 9999))
+	assert.Error(t, constraints.Check("chart-b", 9001))
+	assert.Error(t, constraints.Check("chart-a", 8999))
+	assert.Error(t, constraints.Check("chart-a", 10000))
+
+	assert.NoError(t, RegistrationTokenFATEConstraints{}.Check("any-chart", 1))
+}
+
+func TestRegistrationTokenValidate(t *testing.T) {
+	countFn := func(count int) func(string) (int, error) {
+		return func(string) (int, error) {
+			return count, nil
+		}
+	}
+	token := &RegistrationToken{
+		UUID:      "token-uuid",
+		TokenType: RegistrationTokenTypeRand16,
+		TokenStr:  "test-token",
+		ExpiredAt: time.Now().Add(time.Hour),
+		Limit:     2,
+	}
+	assert.NoError(t, token.validate(countFn(1)))
+	assert.Error(t, token.validate(countFn(2)))
+
+	token.Disabled = true
+	assert.EqualError(t, token.validate(countFn(0)), "token disabled")
+
+	token.Disabled = false
+	token.ExpiredAt = time.Now().Add(-time.Hour)
+	assert.EqualError(t, token.validate(countFn(0)), "token expired")
+}
@@ -51,11 +51,19 @@ func RegistrationTokenParse(tokenDisplayedStr string) (RegistrationTokenType, st
 // RegistrationToken is the token entity a participant can use to register itself
 type RegistrationToken struct {
 	gorm.Model
-	UUID        string `gorm:"type:varchar(36);index;unique"`
-	Name        string `gorm:"type:varchar(255);not null"`
-	Description string `gorm:"type:text"`
-	TokenType   RegistrationTokenType
-	TokenStr    string                           `gorm:"type:varchar(255)"`
+	UUID           string `gorm:"type:varchar(36);index;unique"`
+	Name           string `gorm:"type:varchar(255);not null"`
+	Description    string `gorm:"type:text"`
+	TokenType      RegistrationTokenType
+	TokenStr       string `gorm:"type:varchar(255)"`
+	FederationUUID string `gorm:"type:varchar(36)"`
+	ExpiredAt      time.Time
+	Limit          int
+	// Disabled tokens cannot be used for new registrations, and the participants registered with them cannot use their
+	// credentials
+	Disabled bool
+	// RotateOnUse means the token string is replaced after each registration so a token string can only be used once
+	RotateOnUse bool
 	Repo        repo.RegistrationTokenRepository `gorm:"-"`
 }
 
@@ -70,15 +78,51 @@ const (
 // RegistrationTokenOpenFL contains extra information for a token used in OpenFL federations
 type RegistrationTokenOpenFL struct {
 	RegistrationToken
-	FederationUUID  string `gorm:"type:varchar(36)"`
-	ExpiredAt       time.Time
-	Limit           int
 	Labels          valueobject.Labels               `gorm:"type:text"`
 	ParticipantRepo repo.ParticipantOpenFLRepository `gorm:"-"`
 }
 
 // Create creates the OpenFL federation record in the repo
 func (token *RegistrationTokenOpenFL) Create() error {
+	if err := token.prepare(); err != nil {
+		return err
+	}
+	return token.Repo.Create(token)
+}
+
+// Validate returns whether this token is still valid
+func (token *RegistrationTokenOpenFL) Validate() error {
+	if token.ParticipantRepo == nil {
+		return errors.New("nil participant repo")
+	}
+	return token.validate(token.ParticipantRepo.CountByTokenUUID)
+}
+
+// Display returns a string representing the token and its type
+func (token *RegistrationToken) Display() string {
+	if token.TokenStr != "" {
+		return token.TokenType.DisplayStr() + ":" + token.TokenStr
+	}
+	return ""
+}
+
+// SetDisabled disables or enables the token
+func (token *RegistrationToken) SetDisabled(disabled bool) error {
+	token.Disabled = disabled
+	return token.Repo.UpdateDisabledByUUID(token.UUID, disabled)
+}
+
+// Rotate replaces the token string with a newly generated one, the previous string can no longer be used
+func (token *RegistrationToken) Rotate() error {
+	if token.TokenType == RegistrationTokenTypeUnknown {
+		token.TokenType = RegistrationTokenTypeRand16
+	}
+	token.TokenStr = token.TokenType.Generate()
+	return token.Repo.UpdateTokenStrByUUID(token.UUID, token.TokenStr)
+}
+
+// prepare validates the basic info and fills the missing fields before creating the token
+func (token *RegistrationToken) prepare() error {
 	if token.Name == "" {
 		return errors.New("missing name")
 	}
@@ -100,29 +144,21 @@ func (token *RegistrationTokenOpenFL) Create() error {
 	if token.TokenStr == "" {
 		token.TokenStr = token.TokenType.Generate()
 	}
-	return token.Repo.Create(token)
-}
-
-// Display returns a string representing the token and its type
-func (token *RegistrationTokenOpenFL) Display() string {
-	if token.TokenStr != "" {
-		return token.TokenType.DisplayStr() + ":" + token.TokenStr
-	}
-	return ""
+	return nil
 }
 
-// Validate returns whether this token is still valid
-func (token *RegistrationTokenOpenFL) Validate() error {
+// validate checks the token can be used for a new registration, countFn returns the number of participants registered using this token
+func (token *RegistrationToken) validate(countFn func(string) (int, error)) error {
 	if token.TokenStr == "" {
 		return errors.New("empty token string")
 	}
+	if token.Disabled {
+		return errors.New("token disabled")
+	}
 	if time.Now().After(token.ExpiredAt) {
 		return errors.New("token expired")
 	}
-	if token.ParticipantRepo == nil {
-		return errors.New("nil participant repo")
-	}
-	if count, err := token.ParticipantRepo.CountByTokenUUID(token.UUID); err != nil {
+	if count, err := countFn(token.UUID); err != nil {
 		return errors.Wrap(err, "failed to query token count")
 	} else if count >= token.Limit {
 		return errors.New("token limit reached")
@@ -26,4 +26,10 @@ type CertificateRepository interface {
 	GetByUUID(string) (interface{}, error)
 	// GetBySerialNumber returns an *entity.Certificate with the specified serial number
 	GetBySerialNumber(string) (interface{}, error)
+	// UpdateByUUID takes an *entity.Certificate and updates the certificate content of the record with the same UUID
+	UpdateByUUID(interface{}) error
+	// UpdateRevocationByUUID takes an *entity.Certificate and updates the revocation info of the record with the same UUID
+	UpdateRevocationByUUID(interface{}) error
+	// ListRevoked returns []entity.Certificate of all revoked certificates, including the deleted ones
+	ListRevoked() (interface{}, error)
 }
@@ -32,4 +32,6 @@ type EndpointRepository interface {
 	UpdateStatusByUUID(interface{}) error
 	// UpdateInfoByUUID takes an *entity.EndpointBase or its derived struct and updates endpoint editable fields
 	UpdateInfoByUUID(interface{}) error
+	// UpdateDeploymentYAMLByUUID takes an *entity.EndpointBase's derived struct and updates the deployment yaml fields
+	UpdateDeploymentYAMLByUUID(interface{}) error
 }
@@ -0,0 +1,27 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+// FederationFATEDiagnosticsRepository is the interface to manage the diagnostics runs of the FATE federations in the repo
+type FederationFATEDiagnosticsRepository interface {
+	// Create takes an *entity.FederationFATEDiagnostics and creates a record in the repository
+	Create(interface{}) error
+	// UpdateStatusAndReportByUUID takes an *entity.FederationFATEDiagnostics and updates the status, the message and the report
+	UpdateStatusAndReportByUUID(interface{}) error
+	// GetByUUID returns an *entity.FederationFATEDiagnostics of the specified uuid
+	GetByUUID(string) (interface{}, error)
+	// ListByFederationUUID returns []entity.FederationFATEDiagnostics of the specified federation
+	ListByFederationUUID(string) (interface{}, error)
+}
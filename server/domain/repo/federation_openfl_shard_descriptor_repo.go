@@ -0,0 +1,29 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+// FederationOpenFLShardDescriptorRepository is the interface to manage the shard descriptor versions of OpenFL federations
+type FederationOpenFLShardDescriptorRepository interface {
+	// Create takes an *entity.FederationOpenFLShardDescriptor and creates a record in the repository
+	Create(interface{}) error
+	// ListByFederationUUID returns []entity.FederationOpenFLShardDescriptor of the specified federation, latest version first
+	ListByFederationUUID(string) (interface{}, error)
+	// GetByFederationUUIDAndVersion returns an *entity.FederationOpenFLShardDescriptor of the specified federation and version
+	GetByFederationUUIDAndVersion(string, uint) (interface{}, error)
+	// GetLatestVersionByFederationUUID returns the latest version number of the specified federation, or 0 if there is none
+	GetLatestVersionByFederationUUID(string) (uint, error)
+	// DeleteByFederationUUID deletes all the versions of the specified federation
+	DeleteByFederationUUID(string) error
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type CertificateBindingRepoMock struct {
+	CreateFn                  func(instance interface{}) error
+	ListByCertificateUUIDFn   func(uuid string) (interface{}, error)
+	DeleteByParticipantUUIDFn func(uuid string) error
+	ListByParticipantUUIDFn   func(uuid string) (interface{}, error)
+}
+
+func (m *CertificateBindingRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *CertificateBindingRepoMock) ListByCertificateUUID(uuid string) (interface{}, error) {
+	if m.ListByCertificateUUIDFn != nil {
+		return m.ListByCertificateUUIDFn(uuid)
+	}
+	return nil, nil
+}
+
+func (m *CertificateBindingRepoMock) DeleteByParticipantUUID(uuid string) error {
+	if m.DeleteByParticipantUUIDFn != nil {
+		return m.DeleteByParticipantUUIDFn(uuid)
+	}
+	return nil
+}
+
+func (m *CertificateBindingRepoMock) ListByParticipantUUID(uuid string) (interface{}, error) {
+	if m.ListByParticipantUUIDFn != nil {
+		return m.ListByParticipantUUIDFn(uuid)
+	}
+	return nil, nil
+}
+
+var _ repo.CertificateBindingRepository = (*CertificateBindingRepoMock)(nil)
@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type CertificateRepoMock struct {
+	CreateFn                 func(instance interface{}) error
+	ListFn                   func() (interface{}, error)
+	DeleteByUUIDFn           func(uuid string) error
+	GetByUUIDFn              func(uuid string) (interface{}, error)
+	GetBySerialNumberFn      func(serialNumberStr string) (interface{}, error)
+	UpdateByUUIDFn           func(instance interface{}) error
+	UpdateRevocationByUUIDFn func(instance interface{}) error
+	ListRevokedFn            func() (interface{}, error)
+}
+
+func (m *CertificateRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *CertificateRepoMock) List() (interface{}, error) {
+	if m.ListFn != nil {
+		return m.ListFn()
+	}
+	return nil, nil
+}
+
+func (m *CertificateRepoMock) DeleteByUUID(uuid string) error {
+	if m.DeleteByUUIDFn != nil {
+		return m.DeleteByUUIDFn(uuid)
+	}
+	return nil
+}
+
+func (m *CertificateRepoMock) GetByUUID(uuid string) (interface{}, error) {
+	if m.GetByUUIDFn != nil {
+		return m.GetByUUIDFn(uuid)
+	}
+	return nil, nil
+}
+
+func (m *CertificateRepoMock) GetBySerialNumber(serialNumberStr string) (interface{}, error) {
+	if m.GetBySerialNumberFn != nil {
+		return m.GetBySerialNumberFn(serialNumberStr)
+	}
+	return nil, nil
+}
+
+func (m *CertificateRepoMock) UpdateByUUID(instance interface{}) error {
+	if m.UpdateByUUIDFn != nil {
+		return m.UpdateByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *CertificateRepoMock) UpdateRevocationByUUID(instance interface{}) error {
+	if m.UpdateRevocationByUUIDFn != nil {
+		return m.UpdateRevocationByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *CertificateRepoMock) ListRevoked() (interface{}, error) {
+	if m.ListRevokedFn != nil {
+		return m.ListRevokedFn()
+	}
+	return nil, nil
+}
+
+var _ repo.CertificateRepository = (*CertificateRepoMock)(nil)
@@ -24,6 +24,7 @@ type EndpointKubeFATERepoMock struct {
 	ListByInfraProviderUUIDFn             func(uuid string) (interface{}, error)
 	UpdateStatusByUUIDFn                  func(instance interface{}) error
 	UpdateInfoByUUIDFn                    func(instance interface{}) error
+	UpdateDeploymentYAMLByUUIDFn          func(instance interface{}) error
 	ListByInfraProviderUUIDAndNamespaceFn func(string, string) (interface{}, error)
 }
 
@@ -62,4 +63,8 @@ func (m *EndpointKubeFATERepoMock) UpdateInfoByUUID(instance interface{}) error
 	return m.UpdateInfoByUUIDFn(instance)
 }
 
+func (m *EndpointKubeFATERepoMock) UpdateDeploymentYAMLByUUID(instance interface{}) error {
+	return m.UpdateDeploymentYAMLByUUIDFn(instance)
+}
+
 var _ repo.EndpointRepository = (*EndpointKubeFATERepoMock)(nil)
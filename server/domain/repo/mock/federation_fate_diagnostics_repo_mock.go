@@ -0,0 +1,57 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type FederationFATEDiagnosticsRepoMock struct {
+	CreateFn                      func(instance interface{}) error
+	UpdateStatusAndReportByUUIDFn func(instance interface{}) error
+	GetByUUIDFn                   func(uuid string) (interface{}, error)
+	ListByFederationUUIDFn        func(federationUUID string) (interface{}, error)
+}
+
+func (m *FederationFATEDiagnosticsRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *FederationFATEDiagnosticsRepoMock) UpdateStatusAndReportByUUID(instance interface{}) error {
+	if m.UpdateStatusAndReportByUUIDFn != nil {
+		return m.UpdateStatusAndReportByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *FederationFATEDiagnosticsRepoMock) GetByUUID(uuid string) (interface{}, error) {
+	if m.GetByUUIDFn != nil {
+		return m.GetByUUIDFn(uuid)
+	}
+	return &entity.FederationFATEDiagnostics{}, nil
+}
+
+func (m *FederationFATEDiagnosticsRepoMock) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	if m.ListByFederationUUIDFn != nil {
+		return m.ListByFederationUUIDFn(federationUUID)
+	}
+	return []entity.FederationFATEDiagnostics{}, nil
+}
+
+var _ repo.FederationFATEDiagnosticsRepository = (*FederationFATEDiagnosticsRepoMock)(nil)
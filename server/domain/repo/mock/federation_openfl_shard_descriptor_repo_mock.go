@@ -0,0 +1,65 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type FederationOpenFLShardDescriptorRepoMock struct {
+	CreateFn                           func(instance interface{}) error
+	ListByFederationUUIDFn             func(federationUUID string) (interface{}, error)
+	GetByFederationUUIDAndVersionFn    func(federationUUID string, version uint) (interface{}, error)
+	GetLatestVersionByFederationUUIDFn func(federationUUID string) (uint, error)
+	DeleteByFederationUUIDFn           func(federationUUID string) error
+}
+
+func (m *FederationOpenFLShardDescriptorRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *FederationOpenFLShardDescriptorRepoMock) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	if m.ListByFederationUUIDFn != nil {
+		return m.ListByFederationUUIDFn(federationUUID)
+	}
+	return []entity.FederationOpenFLShardDescriptor{}, nil
+}
+
+func (m *FederationOpenFLShardDescriptorRepoMock) GetByFederationUUIDAndVersion(federationUUID string, version uint) (interface{}, error) {
+	if m.GetByFederationUUIDAndVersionFn != nil {
+		return m.GetByFederationUUIDAndVersionFn(federationUUID, version)
+	}
+	return &entity.FederationOpenFLShardDescriptor{}, nil
+}
+
+func (m *FederationOpenFLShardDescriptorRepoMock) GetLatestVersionByFederationUUID(federationUUID string) (uint, error) {
+	if m.GetLatestVersionByFederationUUIDFn != nil {
+		return m.GetLatestVersionByFederationUUIDFn(federationUUID)
+	}
+	return 0, nil
+}
+
+func (m *FederationOpenFLShardDescriptorRepoMock) DeleteByFederationUUID(federationUUID string) error {
+	if m.DeleteByFederationUUIDFn != nil {
+		return m.DeleteByFederationUUIDFn(federationUUID)
+	}
+	return nil
+}
+
+var _ repo.FederationOpenFLShardDescriptorRepository = (*FederationOpenFLShardDescriptorRepoMock)(nil)
@@ -31,7 +31,9 @@ type ParticipantFATERepoMock struct {
 	UpdateInfoByUUIDFn                       func(instance interface{}) error
 	IsExchangeCreatedByFederationUUIDFn      func(uuid string) (bool, error)
 	GetExchangeByFederationUUIDFn            func(uuid string) (interface{}, error)
+	ListExchangesByFederationUUIDFn          func(uuid string) (interface{}, error)
 	IsConflictedByFederationUUIDAndPartyIDFn func(uuid string, partyID int) (bool, error)
+	CountByTokenUUIDFn                       func(uuid string) (int, error)
 }
 
 func (m *ParticipantFATERepoMock) Create(instance interface{}) error {
@@ -111,6 +113,13 @@ func (m *ParticipantFATERepoMock) GetExchangeByFederationUUID(uuid string) (inte
 	return nil, nil
 }
 
+func (m *ParticipantFATERepoMock) ListExchangesByFederationUUID(uuid string) (interface{}, error) {
+	if m.ListExchangesByFederationUUIDFn != nil {
+		return m.ListExchangesByFederationUUIDFn(uuid)
+	}
+	return []entity.ParticipantFATE{}, nil
+}
+
 func (m *ParticipantFATERepoMock) IsConflictedByFederationUUIDAndPartyID(uuid string, partyID int) (bool, error) {
 	if m.IsConflictedByFederationUUIDAndPartyIDFn != nil {
 		return m.IsConflictedByFederationUUIDAndPartyIDFn(uuid, partyID)
@@ -118,4 +127,11 @@ func (m *ParticipantFATERepoMock) IsConflictedByFederationUUIDAndPartyID(uuid st
 	return false, nil
 }
 
+func (m *ParticipantFATERepoMock) CountByTokenUUID(uuid string) (int, error) {
+	if m.CountByTokenUUIDFn != nil {
+		return m.CountByTokenUUIDFn(uuid)
+	}
+	return 0, nil
+}
+
 var _ repo.ParticipantFATERepository = (*ParticipantFATERepoMock)(nil)
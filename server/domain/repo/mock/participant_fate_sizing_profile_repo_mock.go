@@ -0,0 +1,65 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type ParticipantFATESizingProfileRepoMock struct {
+	CreateFn       func(instance interface{}) error
+	ListFn         func() (interface{}, error)
+	GetByUUIDFn    func(uuid string) (interface{}, error)
+	UpdateByUUIDFn func(instance interface{}) error
+	DeleteByUUIDFn func(uuid string) error
+}
+
+func (m *ParticipantFATESizingProfileRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantFATESizingProfileRepoMock) List() (interface{}, error) {
+	if m.ListFn != nil {
+		return m.ListFn()
+	}
+	return []entity.ParticipantFATESizingProfile{}, nil
+}
+
+func (m *ParticipantFATESizingProfileRepoMock) GetByUUID(uuid string) (interface{}, error) {
+	if m.GetByUUIDFn != nil {
+		return m.GetByUUIDFn(uuid)
+	}
+	return &entity.ParticipantFATESizingProfile{}, nil
+}
+
+func (m *ParticipantFATESizingProfileRepoMock) UpdateByUUID(instance interface{}) error {
+	if m.UpdateByUUIDFn != nil {
+		return m.UpdateByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantFATESizingProfileRepoMock) DeleteByUUID(uuid string) error {
+	if m.DeleteByUUIDFn != nil {
+		return m.DeleteByUUIDFn(uuid)
+	}
+	return nil
+}
+
+var _ repo.ParticipantFATESizingProfileRepository = (*ParticipantFATESizingProfileRepoMock)(nil)
@@ -0,0 +1,57 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type ParticipantOpenFLBatchOperationRepoMock struct {
+	CreateFn                     func(instance interface{}) error
+	UpdateStatusAndItemsByUUIDFn func(instance interface{}) error
+	GetByUUIDFn                  func(uuid string) (interface{}, error)
+	ListByFederationUUIDFn       func(federationUUID string) (interface{}, error)
+}
+
+func (m *ParticipantOpenFLBatchOperationRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLBatchOperationRepoMock) UpdateStatusAndItemsByUUID(instance interface{}) error {
+	if m.UpdateStatusAndItemsByUUIDFn != nil {
+		return m.UpdateStatusAndItemsByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLBatchOperationRepoMock) GetByUUID(uuid string) (interface{}, error) {
+	if m.GetByUUIDFn != nil {
+		return m.GetByUUIDFn(uuid)
+	}
+	return &entity.ParticipantOpenFLBatchOperation{}, nil
+}
+
+func (m *ParticipantOpenFLBatchOperationRepoMock) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	if m.ListByFederationUUIDFn != nil {
+		return m.ListByFederationUUIDFn(federationUUID)
+	}
+	return []entity.ParticipantOpenFLBatchOperation{}, nil
+}
+
+var _ repo.ParticipantOpenFLBatchOperationRepository = (*ParticipantOpenFLBatchOperationRepoMock)(nil)
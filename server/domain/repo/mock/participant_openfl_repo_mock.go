@@ -0,0 +1,145 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type ParticipantOpenFLRepoMock struct {
+	CreateFn                             func(instance interface{}) error
+	ListFn                               func() (interface{}, error)
+	DeleteByUUIDFn                       func(uuid string) error
+	GetByUUIDFn                          func(uuid string) (interface{}, error)
+	ListByFederationUUIDFn               func(uuid string) (interface{}, error)
+	ListByEndpointUUIDFn                 func(uuid string) (interface{}, error)
+	UpdateStatusByUUIDFn                 func(instance interface{}) error
+	UpdateDeploymentYAMLByUUIDFn         func(instance interface{}) error
+	UpdateInfoByUUIDFn                   func(instance interface{}) error
+	IsDirectorCreatedByFederationUUIDFn  func(uuid string) (bool, error)
+	CountByTokenUUIDFn                   func(uuid string) (int, error)
+	GetDirectorByFederationUUIDFn        func(uuid string) (interface{}, error)
+	UpdateHeartbeatByUUIDFn              func(instance interface{}) error
+	UpdateLabelsByUUIDFn                 func(instance interface{}) error
+	UpdateDeploymentYAMLAndChartByUUIDFn func(instance interface{}) error
+}
+
+func (m *ParticipantOpenFLRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLRepoMock) List() (interface{}, error) {
+	if m.ListFn != nil {
+		return m.ListFn()
+	}
+	return nil, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) DeleteByUUID(uuid string) error {
+	if m.DeleteByUUIDFn != nil {
+		return m.DeleteByUUIDFn(uuid)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLRepoMock) GetByUUID(uuid string) (interface{}, error) {
+	if m.GetByUUIDFn != nil {
+		return m.GetByUUIDFn(uuid)
+	}
+	return &entity.ParticipantOpenFL{}, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) ListByFederationUUID(uuid string) (interface{}, error) {
+	if m.ListByFederationUUIDFn != nil {
+		return m.ListByFederationUUIDFn(uuid)
+	}
+	return nil, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) ListByEndpointUUID(uuid string) (interface{}, error) {
+	if m.ListByEndpointUUIDFn != nil {
+		return m.ListByEndpointUUIDFn(uuid)
+	}
+	return nil, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) UpdateStatusByUUID(instance interface{}) error {
+	if m.UpdateStatusByUUIDFn != nil {
+		return m.UpdateStatusByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLRepoMock) UpdateDeploymentYAMLByUUID(instance interface{}) error {
+	if m.UpdateDeploymentYAMLByUUIDFn != nil {
+		return m.UpdateDeploymentYAMLByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLRepoMock) UpdateInfoByUUID(instance interface{}) error {
+	if m.UpdateInfoByUUIDFn != nil {
+		return m.UpdateInfoByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLRepoMock) IsDirectorCreatedByFederationUUID(uuid string) (bool, error) {
+	if m.IsDirectorCreatedByFederationUUIDFn != nil {
+		return m.IsDirectorCreatedByFederationUUIDFn(uuid)
+	}
+	return false, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) CountByTokenUUID(uuid string) (int, error) {
+	if m.CountByTokenUUIDFn != nil {
+		return m.CountByTokenUUIDFn(uuid)
+	}
+	return 0, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) GetDirectorByFederationUUID(uuid string) (interface{}, error) {
+	if m.GetDirectorByFederationUUIDFn != nil {
+		return m.GetDirectorByFederationUUIDFn(uuid)
+	}
+	return nil, nil
+}
+
+func (m *ParticipantOpenFLRepoMock) UpdateHeartbeatByUUID(instance interface{}) error {
+	if m.UpdateHeartbeatByUUIDFn != nil {
+		return m.UpdateHeartbeatByUUIDFn(instance)
+	}
+	return nil
+}
+
+func (m *ParticipantOpenFLRepoMock) UpdateLabelsByUUID(instance interface{}) error {
+	if m.UpdateLabelsByUUIDFn != nil {
+		return m.UpdateLabelsByUUIDFn(instance)
+	}
+	return nil
+}
+
+var _ repo.ParticipantOpenFLRepository = (*ParticipantOpenFLRepoMock)(nil)
+
+func (m *ParticipantOpenFLRepoMock) UpdateDeploymentYAMLAndChartByUUID(instance interface{}) error {
+	if m.UpdateDeploymentYAMLAndChartByUUIDFn != nil {
+		return m.UpdateDeploymentYAMLAndChartByUUIDFn(instance)
+	}
+	return nil
+}
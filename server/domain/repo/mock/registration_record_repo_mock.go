@@ -0,0 +1,49 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type RegistrationRecordRepoMock struct {
+	CreateFn               func(instance interface{}) error
+	ListByFederationUUIDFn func(federationUUID string) (interface{}, error)
+	GetByParticipantUUIDFn func(participantUUID string) (interface{}, error)
+}
+
+func (m *RegistrationRecordRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *RegistrationRecordRepoMock) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	if m.ListByFederationUUIDFn != nil {
+		return m.ListByFederationUUIDFn(federationUUID)
+	}
+	return []entity.RegistrationRecord{}, nil
+}
+
+func (m *RegistrationRecordRepoMock) GetByParticipantUUID(participantUUID string) (interface{}, error) {
+	if m.GetByParticipantUUIDFn != nil {
+		return m.GetByParticipantUUIDFn(participantUUID)
+	}
+	return &entity.RegistrationRecord{}, nil
+}
+
+var _ repo.RegistrationRecordRepository = (*RegistrationRecordRepoMock)(nil)
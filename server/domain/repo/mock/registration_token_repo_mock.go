@@ -0,0 +1,89 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+type RegistrationTokenOpenFLRepoMock struct {
+	CreateFn               func(instance interface{}) error
+	ListByFederationFn     func(uuid string) (interface{}, error)
+	DeleteByUUIDFn         func(uuid string) error
+	GetByUUIDFn            func(uuid string) (interface{}, error)
+	LoadByTypeAndStrFn     func(instance interface{}) error
+	DeleteByFederationFn   func(uuid string) error
+	UpdateDisabledByUUIDFn func(uuid string, disabled bool) error
+	UpdateTokenStrByUUIDFn func(uuid string, tokenStr string) error
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) Create(instance interface{}) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(instance)
+	}
+	return nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) ListByFederation(uuid string) (interface{}, error) {
+	if m.ListByFederationFn != nil {
+		return m.ListByFederationFn(uuid)
+	}
+	return nil, nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) DeleteByUUID(uuid string) error {
+	if m.DeleteByUUIDFn != nil {
+		return m.DeleteByUUIDFn(uuid)
+	}
+	return nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) GetByUUID(uuid string) (interface{}, error) {
+	if m.GetByUUIDFn != nil {
+		return m.GetByUUIDFn(uuid)
+	}
+	return &entity.RegistrationTokenOpenFL{}, nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) LoadByTypeAndStr(instance interface{}) error {
+	if m.LoadByTypeAndStrFn != nil {
+		return m.LoadByTypeAndStrFn(instance)
+	}
+	return nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) DeleteByFederation(uuid string) error {
+	if m.DeleteByFederationFn != nil {
+		return m.DeleteByFederationFn(uuid)
+	}
+	return nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) UpdateDisabledByUUID(uuid string, disabled bool) error {
+	if m.UpdateDisabledByUUIDFn != nil {
+		return m.UpdateDisabledByUUIDFn(uuid, disabled)
+	}
+	return nil
+}
+
+func (m *RegistrationTokenOpenFLRepoMock) UpdateTokenStrByUUID(uuid string, tokenStr string) error {
+	if m.UpdateTokenStrByUUIDFn != nil {
+		return m.UpdateTokenStrByUUIDFn(uuid, tokenStr)
+	}
+	return nil
+}
+
+var _ repo.RegistrationTokenRepository = (*RegistrationTokenOpenFLRepoMock)(nil)
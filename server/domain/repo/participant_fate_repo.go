@@ -19,8 +19,12 @@ type ParticipantFATERepository interface {
 	ParticipantRepository
 	// IsExchangeCreatedByFederationUUID returns whether an exchange exists in the specified federation
 	IsExchangeCreatedByFederationUUID(string) (bool, error)
-	// GetExchangeByFederationUUID returns an *entity.ParticipantFATE that is the exchange of the specified federation
+	// GetExchangeByFederationUUID returns an *entity.ParticipantFATE that is the first exchange of the specified federation
 	GetExchangeByFederationUUID(string) (interface{}, error)
+	// ListExchangesByFederationUUID returns an []entity.ParticipantFATE of all the exchanges in the specified federation
+	ListExchangesByFederationUUID(string) (interface{}, error)
 	// IsConflictedByFederationUUIDAndPartyID returns whether a party id in a federation is already used
 	IsConflictedByFederationUUIDAndPartyID(string, int) (bool, error)
+	// CountByTokenUUID returns the number of participant using a specified token
+	CountByTokenUUID(string) (int, error)
 }
@@ -0,0 +1,29 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+// ParticipantFATESizingProfileRepository is the interface to manage the sizing profiles of FATE clusters
+type ParticipantFATESizingProfileRepository interface {
+	// Create takes an *entity.ParticipantFATESizingProfile and creates a record in the repository
+	Create(interface{}) error
+	// List returns []entity.ParticipantFATESizingProfile of all the profiles
+	List() (interface{}, error)
+	// GetByUUID returns an *entity.ParticipantFATESizingProfile of the specified uuid
+	GetByUUID(string) (interface{}, error)
+	// UpdateByUUID takes an *entity.ParticipantFATESizingProfile and updates its description and sizing settings
+	UpdateByUUID(interface{}) error
+	// DeleteByUUID deletes the profile with the specified uuid
+	DeleteByUUID(string) error
+}
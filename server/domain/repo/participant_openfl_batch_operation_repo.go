@@ -0,0 +1,27 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+// ParticipantOpenFLBatchOperationRepository is the interface to manage the envoy batch operations in the repo
+type ParticipantOpenFLBatchOperationRepository interface {
+	// Create takes an *entity.ParticipantOpenFLBatchOperation and creates a record in the repository
+	Create(interface{}) error
+	// UpdateStatusAndItemsByUUID takes an *entity.ParticipantOpenFLBatchOperation and updates the status and the items
+	UpdateStatusAndItemsByUUID(interface{}) error
+	// GetByUUID returns an *entity.ParticipantOpenFLBatchOperation of the specified uuid
+	GetByUUID(string) (interface{}, error)
+	// ListByFederationUUID returns []entity.ParticipantOpenFLBatchOperation of the specified federation
+	ListByFederationUUID(string) (interface{}, error)
+}
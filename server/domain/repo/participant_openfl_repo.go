@@ -23,4 +23,10 @@ type ParticipantOpenFLRepository interface {
 	CountByTokenUUID(string) (int, error)
 	// GetDirectorByFederationUUID returns an *entity.ParticipantOpenFL that is the director of the specified federation
 	GetDirectorByFederationUUID(string) (interface{}, error)
+	// UpdateHeartbeatByUUID takes an *entity.ParticipantOpenFL and updates the last_seen and device_health fields
+	UpdateHeartbeatByUUID(interface{}) error
+	// UpdateLabelsByUUID takes an *entity.ParticipantOpenFL and updates the labels field
+	UpdateLabelsByUUID(interface{}) error
+	// UpdateDeploymentYAMLAndChartByUUID takes an *entity.ParticipantOpenFL and updates the deployment_yaml and chart_uuid fields
+	UpdateDeploymentYAMLAndChartByUUID(interface{}) error
 }
@@ -0,0 +1,32 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import "github.com/pkg/errors"
+
+// ErrRegistrationRecordNotFound means the participant has no registration record, like the ones registered before the
+// records were introduced
+var ErrRegistrationRecordNotFound = errors.New("registration record not found")
+
+// RegistrationRecordRepository is the repo interface for persisting the registration audit records
+type RegistrationRecordRepository interface {
+	// Create takes an *entity.RegistrationRecord and creates a record in the repository
+	Create(interface{}) error
+	// ListByFederationUUID returns []entity.RegistrationRecord of the specified federation
+	ListByFederationUUID(string) (interface{}, error)
+	// GetByParticipantUUID returns the latest *entity.RegistrationRecord of the specified participant, or
+	// ErrRegistrationRecordNotFound if there is none
+	GetByParticipantUUID(string) (interface{}, error)
+}
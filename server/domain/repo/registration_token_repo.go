@@ -18,7 +18,7 @@ package repo
 type RegistrationTokenRepository interface {
 	// Create takes an *entity.RegistrationToken's derived struct and creates a record in the repository
 	Create(interface{}) error
-	// ListByFederation returns token list, currently []entity.RegistrationTokenOpenFL
+	// ListByFederation returns token list, []entity.RegistrationTokenOpenFL or []entity.RegistrationTokenFATE
 	ListByFederation(string) (interface{}, error)
 	// DeleteByUUID delete the token with the specified uuid
 	DeleteByUUID(string) error
@@ -28,4 +28,8 @@ type RegistrationTokenRepository interface {
 	LoadByTypeAndStr(interface{}) error
 	// DeleteByFederation deletes all tokens within the specified federation
 	DeleteByFederation(string) error
+	// UpdateDisabledByUUID updates the disabled field of the token with the specified uuid
+	UpdateDisabledByUUID(string, bool) error
+	// UpdateTokenStrByUUID updates the token string of the token with the specified uuid
+	UpdateTokenStrByUUID(string, string) error
 }
@@ -0,0 +1,439 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// certificateRotationRestartAnnotation is the pod template annotation used to trigger rolling restarts, same as kubectl
+const certificateRotationRestartAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// CertificateRotationService renews the certificates that are about to expire and rotates them in the bound services
+type CertificateRotationService struct {
+	CertificateRepo        repo.CertificateRepository
+	CertificateBindingRepo repo.CertificateBindingRepository
+	ParticipantFATERepo    repo.ParticipantFATERepository
+	ParticipantOpenFLRepo  repo.ParticipantOpenFLRepository
+	CertificateService     CertificateRotationCertificateServiceInt
+	EndpointService        ParticipantEndpointServiceInt
+	OpenFLService          CertificateRotationOpenFLServiceInt
+	EventService           EventServiceInt
+}
+
+// CertificateRotationCertificateServiceInt declares the methods of a certificate service that this service needs
+type CertificateRotationCertificateServiceInt interface {
+	DefaultCA() (*entity.CertificateAuthority, error)
+	RenewCertificate(cert *entity.Certificate) (*entity.Certificate, *rsa.PrivateKey, error)
+	SaveRenewedCertificate(renewedCert *entity.Certificate) error
+	RevokeReplacedCertificate(replacedCert *entity.Certificate) error
+}
+
+// CertificateRotationOpenFLServiceInt declares the methods of an OpenFL participant service that this service needs
+type CertificateRotationOpenFLServiceInt interface {
+	buildOpenFLInstaller(participant *entity.ParticipantOpenFL) (openFLInstaller, error)
+}
+
+// certificateWorkloadKind is the kind of the workload using a certificate
+type certificateWorkloadKind string
+
+const (
+	certificateWorkloadKindDeployment  certificateWorkloadKind = "Deployment"
+	certificateWorkloadKindStatefulSet certificateWorkloadKind = "StatefulSet"
+)
+
+// certificateWorkload is a workload that mounts the certificate secret
+type certificateWorkload struct {
+	Kind certificateWorkloadKind
+	Name string
+}
+
+// certificateSecretSpec describes where a bound certificate is stored in the K8s secret and which workloads use it
+type certificateSecretSpec struct {
+	SecretName    string
+	CertKey       string
+	PrivateKeyKey string
+	// PKCS8 means the private key is stored in the PKCS #8 format instead of PKCS #1
+	PKCS8     bool
+	CAKey     string
+	Workloads []certificateWorkload
+}
+
+// certificateSecretSpecMap contains the secret specs of the certificates created when deploying the participants,
+// it must be kept consistent with the createXXXSecret functions and the helm charts
+var certificateSecretSpecMap = map[entity.CertificateBindingServiceType]certificateSecretSpec{
+	entity.CertificateBindingServiceTypeATS: {
+		SecretName:    entity.ParticipantFATESecretNameATS,
+		CertKey:       "proxy.cert.pem",
+		PrivateKeyKey: "proxy.key.pem",
+		CAKey:         "ca.cert.pem",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindDeployment, "traffic-server"}},
+	},
+	entity.CertificateBindingServiceTypePulsarServer: {
+		SecretName:    entity.ParticipantFATESecretNamePulsar,
+		CertKey:       "broker.cert.pem",
+		PrivateKeyKey: "broker.key-pk8.pem",
+		PKCS8:         true,
+		CAKey:         "ca.cert.pem",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindStatefulSet, "pulsar"}},
+	},
+	entity.CertificateBindingServiceFMLManagerServer: {
+		SecretName:    entity.ParticipantFATESecretNameFMLMgr,
+		CertKey:       "server.crt",
+		PrivateKeyKey: "server.key",
+		CAKey:         "ca.crt",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindDeployment, "fml-manager-server"}},
+	},
+	entity.CertificateBindingServiceFMLManagerClient: {
+		SecretName:    entity.ParticipantFATESecretNameFMLMgr,
+		CertKey:       "client.crt",
+		PrivateKeyKey: "client.key",
+		CAKey:         "ca.crt",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindDeployment, "fml-manager-server"}},
+	},
+	entity.CertificateBindingServiceSitePortalServer: {
+		SecretName:    entity.ParticipantFATESecretNamePortal,
+		CertKey:       "server.crt",
+		PrivateKeyKey: "server.key",
+		CAKey:         "ca.crt",
+		Workloads: []certificateWorkload{
+			{certificateWorkloadKindDeployment, "site-portal-server"},
+			{certificateWorkloadKindDeployment, "frontend"},
+		},
+	},
+	entity.CertificateBindingServiceSitePortalClient: {
+		SecretName:    entity.ParticipantFATESecretNamePortal,
+		CertKey:       "client.crt",
+		PrivateKeyKey: "client.key",
+		CAKey:         "ca.crt",
+		Workloads: []certificateWorkload{
+			{certificateWorkloadKindDeployment, "site-portal-server"},
+			{certificateWorkloadKindDeployment, "frontend"},
+		},
+	},
+	entity.CertificateBindingServiceTypeOpenFLDirector: {
+		SecretName:    entity.ParticipantOpenFLSecretNameDirector,
+		CertKey:       "director.crt",
+		PrivateKeyKey: "priv.key",
+		CAKey:         "root_ca.crt",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindDeployment, "director"}},
+	},
+	entity.CertificateBindingServiceTypeOpenFLJupyter: {
+		SecretName:    entity.ParticipantOpenFLSecretNameJupyter,
+		CertKey:       "notebook.crt",
+		PrivateKeyKey: "priv.key",
+		CAKey:         "root_ca.crt",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindDeployment, "notebook"}},
+	},
+	entity.CertificateBindingServiceTypeOpenFLEnvoy: {
+		SecretName:    entity.ParticipantOpenFLSecretNameEnvoy,
+		CertKey:       "envoy.crt",
+		PrivateKeyKey: "priv.key",
+		CAKey:         "root_ca.crt",
+		Workloads:     []certificateWorkload{{certificateWorkloadKindDeployment, "envoy"}},
+	},
+}
+
+// certificateRotationTarget contains the deployment info of a participant using a certificate
+type certificateRotationTarget struct {
+	EntityType   entity.EntityType
+	EntityUUID   string
+	EndpointUUID string
+	Namespace    string
+	// OpenFLParticipant is set for OpenFL targets, which may be deployed without a KubeFATE endpoint
+	OpenFLParticipant *entity.ParticipantOpenFL
+}
+
+// RotateExpiringCertificates renews all the bound certificates expiring within the renewBefore duration
+func (s *CertificateRotationService) RotateExpiringCertificates(renewBefore time.Duration) error {
+	instanceList, err := s.CertificateRepo.List()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list certificates")
+	}
+	certList := instanceList.([]entity.Certificate)
+	var rotationErr error
+	for index := range certList {
+		cert := &certList[index]
+		if time.Until(cert.NotAfter) > renewBefore {
+			continue
+		}
+		if cert.Imported {
+			log.Warn().Str("certificate uuid", cert.UUID).Msgf("imported certificate expires at %v, please import and bind a new one", cert.NotAfter)
+			continue
+		}
+		bindingList, err := s.listBindings(cert.UUID)
+		if err != nil {
+			rotationErr = err
+			continue
+		}
+		if len(bindingList) == 0 {
+			continue
+		}
+		log.Info().Str("certificate uuid", cert.UUID).Msgf("certificate expires at %v, renewing it", cert.NotAfter)
+		if err := s.rotate(cert, bindingList); err != nil {
+			log.Err(err).Str("certificate uuid", cert.UUID).Msg("failed to rotate certificate")
+			rotationErr = err
+		}
+	}
+	return rotationErr
+}
+
+// RotateCertificate renews the specified certificate and rotates it in the bound services regardless of its expiration date
+func (s *CertificateRotationService) RotateCertificate(uuid string) error {
+	instance, err := s.CertificateRepo.GetByUUID(uuid)
+	if err != nil {
+		return errors.Wrapf(err, "failed to query certificate")
+	}
+	cert := instance.(*entity.Certificate)
+	if cert.Imported {
+		return errors.Errorf("certificate %s is imported and cannot be renewed by the CA", cert.UUID)
+	}
+	bindingList, err := s.listBindings(cert.UUID)
+	if err != nil {
+		return err
+	}
+	if len(bindingList) == 0 {
+		return errors.Errorf("certificate %s is not bound to any service", cert.UUID)
+	}
+	return s.rotate(cert, bindingList)
+}
+
+func (s *CertificateRotationService) listBindings(certUUID string) ([]entity.CertificateBinding, error) {
+	instanceList, err := s.CertificateBindingRepo.ListByCertificateUUID(certUUID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query bindings of certificate %s", certUUID)
+	}
+	if instanceList == nil {
+		return nil, nil
+	}
+	return instanceList.([]entity.CertificateBinding), nil
+}
+
+// rotate renews the certificate, updates the bound secrets and restarts the workloads using them
+func (s *CertificateRotationService) rotate(cert *entity.Certificate, bindingList []entity.CertificateBinding) error {
+	ca, err := s.CertificateService.DefaultCA()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get CA")
+	}
+	caCert, err := ca.RootCert()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get CA cert")
+	}
+	renewedCert, pk, err := s.CertificateService.RenewCertificate(cert)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("certificate uuid", cert.UUID).Msgf("certificate renewed, new serial number: %v, new expiration date: %v",
+		renewedCert.SerialNumber, renewedCert.NotAfter)
+
+	var rotationErr error
+	rotatedCount := 0
+	for _, binding := range bindingList {
+		target, err := s.getRotationTarget(binding)
+		if err != nil {
+			log.Err(err).Str("binding uuid", binding.UUID).Msg("failed to get the participant using the certificate")
+			rotationErr = err
+			continue
+		}
+		if err := s.rotateInTarget(binding, target, caCert, renewedCert, pk); err != nil {
+			rotationErr = err
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, target.EntityType, target.EntityUUID,
+				fmt.Sprintf("failed to rotate the %v certificate: %v", binding.ServiceType, err), entity.EventLogLevelError)
+			continue
+		}
+		_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, target.EntityType, target.EntityUUID,
+			fmt.Sprintf("rotated the %v certificate (serial number %v -> %v), new expiration date: %v",
+				binding.ServiceType, cert.SerialNumber, renewedCert.SerialNumber, renewedCert.NotAfter.Format(time.RFC3339)),
+			entity.EventLogLevelInfo)
+		rotatedCount++
+	}
+	// the record is only updated when the renewed certificate is deployed somewhere, and the replaced certificate is
+	// only revoked when it is no longer deployed anywhere
+	if rotatedCount == 0 {
+		return rotationErr
+	}
+	if err := s.CertificateService.SaveRenewedCertificate(renewedCert); err != nil {
+		return err
+	}
+	if rotationErr != nil {
+		log.Warn().Str("certificate uuid", cert.UUID).Msgf("certificate partially rotated, keeping the replaced certificate with serial number %v valid", cert.SerialNumber)
+		return rotationErr
+	}
+	if err := s.CertificateService.RevokeReplacedCertificate(cert); err != nil {
+		return errors.Wrapf(err, "failed to revoke the replaced certificate")
+	}
+	return nil
+}
+
+func (s *CertificateRotationService) getRotationTarget(binding entity.CertificateBinding) (*certificateRotationTarget, error) {
+	switch binding.FederationType {
+	case entity.FederationTypeFATE:
+		instance, err := s.ParticipantFATERepo.GetByUUID(binding.ParticipantUUID)
+		if err != nil {
+			return nil, err
+		}
+		participant := instance.(*entity.ParticipantFATE)
+		entityType := entity.EntityTypeCluster
+		if participant.Type == entity.ParticipantFATETypeExchange {
+			entityType = entity.EntityTypeExchange
+		}
+		return &certificateRotationTarget{
+			EntityType:   entityType,
+			EntityUUID:   participant.UUID,
+			EndpointUUID: participant.EndpointUUID,
+			Namespace:    participant.Namespace,
+		}, nil
+	case entity.FederationTypeOpenFL:
+		instance, err := s.ParticipantOpenFLRepo.GetByUUID(binding.ParticipantUUID)
+		if err != nil {
+			return nil, err
+		}
+		participant := instance.(*entity.ParticipantOpenFL)
+		entityType := entity.EntityTypeOpenFLEnvoy
+		if participant.Type == entity.ParticipantOpenFLTypeDirector {
+			entityType = entity.EntityTypeOpenFLDirector
+		}
+		return &certificateRotationTarget{
+			EntityType:        entityType,
+			EntityUUID:        participant.UUID,
+			EndpointUUID:      participant.EndpointUUID,
+			Namespace:         participant.Namespace,
+			OpenFLParticipant: participant,
+		}, nil
+	}
+	return nil, errors.Errorf("unknown federation type: %v", binding.FederationType)
+}
+
+func (s *CertificateRotationService) rotateInTarget(binding entity.CertificateBinding, target *certificateRotationTarget,
+	caCert *x509.Certificate, cert *entity.Certificate, pk *rsa.PrivateKey) error {
+	spec, ok := certificateSecretSpecMap[binding.ServiceType]
+	if !ok {
+		return errors.Errorf("unknown certificate service type: %v", binding.ServiceType)
+	}
+	k8sClient, closer, err := s.buildTargetK8sClient(target)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	if err := updateCertificateSecret(k8sClient, target.Namespace, spec, caCert, cert, pk); err != nil {
+		return errors.Wrapf(err, "failed to update secret %s", spec.SecretName)
+	}
+	for _, workload := range spec.Workloads {
+		if err := restartCertificateWorkload(k8sClient, target.Namespace, workload); err != nil {
+			return errors.Wrapf(err, "failed to restart %s %s", workload.Kind, workload.Name)
+		}
+	}
+	return nil
+}
+
+// buildTargetK8sClient returns the client of the cluster the target is deployed in, and a function to release it
+func (s *CertificateRotationService) buildTargetK8sClient(target *certificateRotationTarget) (kubernetes.Client, func(), error) {
+	if target.OpenFLParticipant != nil {
+		if s.OpenFLService == nil {
+			return nil, nil, errors.New("OpenFL service is not configured")
+		}
+		installer, err := s.OpenFLService.buildOpenFLInstaller(target.OpenFLParticipant)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get the installer")
+		}
+		return installer.K8sClient(), installer.Close, nil
+	}
+	endpointMgr, err := s.EndpointService.buildKubeFATEClientManagerFromEndpointUUID(target.EndpointUUID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get endpoint manager")
+	}
+	return endpointMgr.K8sClient(), func() {}, nil
+}
+
+// For mocking purpose
+var (
+	updateCertificateSecret = func(client kubernetes.Client, namespace string, spec certificateSecretSpec,
+		caCert *x509.Certificate, cert *entity.Certificate, pk *rsa.PrivateKey) error {
+		secret, err := client.GetClientSet().CoreV1().Secrets(namespace).Get(context.TODO(), spec.SecretName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		certBytes, err := cert.EncodePEM()
+		if err != nil {
+			return err
+		}
+		keyBlock := &pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(pk),
+		}
+		if spec.PKCS8 {
+			pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(pk)
+			if err != nil {
+				return err
+			}
+			keyBlock = &pem.Block{
+				Type:  "PRIVATE KEY",
+				Bytes: pkcs8Bytes,
+			}
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[spec.CertKey] = certBytes
+		secret.Data[spec.PrivateKeyKey] = pem.EncodeToMemory(keyBlock)
+		secret.Data[spec.CAKey] = pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: caCert.Raw,
+		})
+		_, err = client.GetClientSet().CoreV1().Secrets(namespace).Update(context.TODO(), secret, v1.UpdateOptions{})
+		return err
+	}
+
+	restartCertificateWorkload = func(client kubernetes.Client, namespace string, workload certificateWorkload) error {
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]string{
+							certificateRotationRestartAnnotation: time.Now().Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("restarting %s %s in namespace %s", workload.Kind, workload.Name, namespace)
+		switch workload.Kind {
+		case certificateWorkloadKindDeployment:
+			_, err = client.GetClientSet().AppsV1().Deployments(namespace).Patch(context.TODO(), workload.Name, types.StrategicMergePatchType, patch, v1.PatchOptions{})
+		case certificateWorkloadKindStatefulSet:
+			_, err = client.GetClientSet().AppsV1().StatefulSets(namespace).Patch(context.TODO(), workload.Name, types.StrategicMergePatchType, patch, v1.PatchOptions{})
+		default:
+			err = errors.Errorf("unknown workload kind: %s", workload.Kind)
+		}
+		return err
+	}
+)
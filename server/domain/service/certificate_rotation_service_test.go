@@ -0,0 +1,371 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/helm"
+	"github.com/FederatedAI/FedLCM/pkg/kubefate"
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type mockRotationCertificateServiceInt struct {
+	caCert     *x509.Certificate
+	caKey      *rsa.PrivateKey
+	renewCount int
+	savedCount int
+	revoked    []string
+}
+
+func (m *mockRotationCertificateServiceInt) DefaultCA() (*entity.CertificateAuthority, error) {
+	config, _ := json.Marshal(entity.CertificateAuthorityConfigurationStepCA{
+		ServiceCertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: m.caCert.Raw})),
+	})
+	return &entity.CertificateAuthority{
+		Type:              entity.CertificateAuthorityTypeStepCA,
+		ConfigurationJSON: string(config),
+	}, nil
+}
+
+func (m *mockRotationCertificateServiceInt) RenewCertificate(cert *entity.Certificate) (*entity.Certificate, *rsa.PrivateKey, error) {
+	m.renewCount++
+	x509Cert, pk := issueTestCertificate(m.caCert, m.caKey, cert.Subject.CommonName, int64(100+m.renewCount), time.Hour*24*365)
+	return &entity.Certificate{
+		UUID:        cert.UUID,
+		Name:        cert.Name,
+		Certificate: x509Cert,
+	}, pk, nil
+}
+
+func (m *mockRotationCertificateServiceInt) SaveRenewedCertificate(*entity.Certificate) error {
+	m.savedCount++
+	return nil
+}
+
+func (m *mockRotationCertificateServiceInt) RevokeReplacedCertificate(cert *entity.Certificate) error {
+	m.revoked = append(m.revoked, cert.SerialNumber.String())
+	return nil
+}
+
+type mockRotationEndpointServiceInt struct {
+	mockParticipantFATEEndpointServiceInt
+	client kubernetes.Client
+}
+
+func (m *mockRotationEndpointServiceInt) buildKubeFATEClientManagerFromEndpointUUID(string) (kubefate.ClientManager, error) {
+	return &mockKubeFATEManager{
+		K8sClientFn: func() kubernetes.Client {
+			return m.client
+		},
+	}, nil
+}
+
+type mockRecordingEventServiceInt struct {
+	descriptions []string
+}
+
+func (m *mockRecordingEventServiceInt) CreateEvent(_ entity.EventType, _ entity.EntityType, _ string, description string, _ entity.EventLogLevel) error {
+	m.descriptions = append(m.descriptions, description)
+	return nil
+}
+
+func issueTestCertificate(parent *x509.Certificate, parentKey *rsa.PrivateKey, commonName string, serial int64, lifetime time.Duration) (*x509.Certificate, *rsa.PrivateKey) {
+	pk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(lifetime),
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, pk
+	}
+	certBytes, _ := x509.CreateCertificate(rand.Reader, template, parent, &pk.PublicKey, parentKey)
+	cert, _ := x509.ParseCertificate(certBytes)
+	return cert, pk
+}
+
+func TestRotateExpiringCertificates(t *testing.T) {
+	caCert, caKey := issueTestCertificate(nil, nil, "test-ca", 1, time.Hour*24*3650)
+	expiringCert, _ := issueTestCertificate(caCert, caKey, "site-portal-server", 2, time.Hour*24)
+	validCert, _ := issueTestCertificate(caCert, caKey, "site-portal-client", 3, time.Hour*24*300)
+
+	clientSet := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: entity.ParticipantFATESecretNamePortal, Namespace: "test-ns"},
+			Data: map[string][]byte{
+				"server.crt": []byte("old-server-cert"),
+				"server.key": []byte("old-server-key"),
+				"client.crt": []byte("client-cert"),
+				"client.key": []byte("client-key"),
+				"ca.crt":     []byte("ca-cert"),
+			},
+		},
+		&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "site-portal-server", Namespace: "test-ns"}},
+		&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "frontend", Namespace: "test-ns"}},
+	)
+	certService := &mockRotationCertificateServiceInt{
+		caCert: caCert,
+		caKey:  caKey,
+	}
+	eventService := &mockRecordingEventServiceInt{}
+	service := CertificateRotationService{
+		CertificateRepo: &mock.CertificateRepoMock{
+			ListFn: func() (interface{}, error) {
+				return []entity.Certificate{
+					{UUID: "expiring-cert", Certificate: expiringCert},
+					{UUID: "valid-cert", Certificate: validCert},
+				}, nil
+			},
+		},
+		CertificateBindingRepo: &mock.CertificateBindingRepoMock{
+			ListByCertificateUUIDFn: func(uuid string) (interface{}, error) {
+				serviceType := entity.CertificateBindingServiceSitePortalServer
+				if uuid == "valid-cert" {
+					serviceType = entity.CertificateBindingServiceSitePortalClient
+				}
+				return []entity.CertificateBinding{{
+					CertificateUUID: uuid,
+					ParticipantUUID: "cluster-uuid",
+					ServiceType:     serviceType,
+					FederationType:  entity.FederationTypeFATE,
+				}}, nil
+			},
+		},
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.ParticipantFATE{
+					Participant: entity.Participant{UUID: uuid, Namespace: "test-ns"},
+					Type:        entity.ParticipantFATETypeCluster,
+				}, nil
+			},
+		},
+		CertificateService: certService,
+		EndpointService: &mockRotationEndpointServiceInt{
+			client: &mockK8sClient{
+				GetClientSetFn: func() clientgo.Interface {
+					return clientSet
+				},
+			},
+		},
+		EventService: eventService,
+	}
+
+	err := service.RotateExpiringCertificates(time.Hour * 24 * 30)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, certService.renewCount)
+	assert.Equal(t, 1, certService.savedCount)
+	assert.Equal(t, []string{"2"}, certService.revoked)
+	assert.Len(t, eventService.descriptions, 1)
+
+	secret, err := clientSet.CoreV1().Secrets("test-ns").Get(context.TODO(), entity.ParticipantFATESecretNamePortal, v1.GetOptions{})
+	assert.NoError(t, err)
+	block, _ := pem.Decode(secret.Data["server.crt"])
+	if assert.NotNil(t, block) {
+		renewedCert, err := x509.ParseCertificate(block.Bytes)
+		assert.NoError(t, err)
+		assert.Equal(t, "site-portal-server", renewedCert.Subject.CommonName)
+		assert.Equal(t, int64(101), renewedCert.SerialNumber.Int64())
+	}
+	block, _ = pem.Decode(secret.Data["server.key"])
+	if assert.NotNil(t, block) {
+		assert.Equal(t, "RSA PRIVATE KEY", block.Type)
+	}
+	block, _ = pem.Decode(secret.Data["ca.crt"])
+	if assert.NotNil(t, block) {
+		assert.Equal(t, caCert.Raw, block.Bytes)
+	}
+	assert.Equal(t, []byte("client-cert"), secret.Data["client.crt"])
+	assert.Equal(t, []byte("client-key"), secret.Data["client.key"])
+
+	for _, name := range []string{"site-portal-server", "frontend"} {
+		deployment, err := clientSet.AppsV1().Deployments("test-ns").Get(context.TODO(), name, v1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Contains(t, deployment.Spec.Template.Annotations, certificateRotationRestartAnnotation)
+	}
+}
+
+func TestRotateCertificate_NoBinding(t *testing.T) {
+	caCert, caKey := issueTestCertificate(nil, nil, "test-ca", 1, time.Hour*24*3650)
+	cert, _ := issueTestCertificate(caCert, caKey, "test", 2, time.Hour*24)
+	certService := &mockRotationCertificateServiceInt{
+		caCert: caCert,
+		caKey:  caKey,
+	}
+	service := CertificateRotationService{
+		CertificateRepo: &mock.CertificateRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.Certificate{UUID: uuid, Certificate: cert}, nil
+			},
+		},
+		CertificateBindingRepo: &mock.CertificateBindingRepoMock{},
+		CertificateService:     certService,
+		EventService:           &mockRecordingEventServiceInt{},
+	}
+	assert.Error(t, service.RotateCertificate("test-cert"))
+	assert.Equal(t, 0, certService.renewCount)
+}
+
+func TestRotateCertificate_SecretUpdateFailed(t *testing.T) {
+	updateCertificateSecretOrig := updateCertificateSecret
+	defer func() {
+		updateCertificateSecret = updateCertificateSecretOrig
+	}()
+	updateCertificateSecret = func(client kubernetes.Client, namespace string, spec certificateSecretSpec,
+		caCert *x509.Certificate, cert *entity.Certificate, pk *rsa.PrivateKey) error {
+		return assert.AnError
+	}
+
+	caCert, caKey := issueTestCertificate(nil, nil, "test-ca", 1, time.Hour*24*3650)
+	cert, _ := issueTestCertificate(caCert, caKey, "site-portal-server", 2, time.Hour*24)
+	certService := &mockRotationCertificateServiceInt{
+		caCert: caCert,
+		caKey:  caKey,
+	}
+	service := CertificateRotationService{
+		CertificateRepo: &mock.CertificateRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.Certificate{UUID: uuid, Certificate: cert}, nil
+			},
+		},
+		CertificateBindingRepo: &mock.CertificateBindingRepoMock{
+			ListByCertificateUUIDFn: func(uuid string) (interface{}, error) {
+				return []entity.CertificateBinding{{
+					CertificateUUID: uuid,
+					ParticipantUUID: "cluster-uuid",
+					ServiceType:     entity.CertificateBindingServiceSitePortalServer,
+					FederationType:  entity.FederationTypeFATE,
+				}}, nil
+			},
+		},
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.ParticipantFATE{
+					Participant: entity.Participant{UUID: uuid, Namespace: "test-ns"},
+					Type:        entity.ParticipantFATETypeCluster,
+				}, nil
+			},
+		},
+		CertificateService: certService,
+		EndpointService:    &mockRotationEndpointServiceInt{client: &mockK8sClient{}},
+		EventService:       &mockRecordingEventServiceInt{},
+	}
+	assert.Error(t, service.RotateCertificate("test-cert"))
+	assert.Equal(t, 1, certService.renewCount)
+	// the record is kept and the current certificate stays valid
+	assert.Equal(t, 0, certService.savedCount)
+	assert.Empty(t, certService.revoked)
+}
+
+func TestRotateCertificate_OpenFLHelmEnvoy(t *testing.T) {
+	caCert, caKey := issueTestCertificate(nil, nil, "test-ca", 1, time.Hour*24*3650)
+	cert, _ := issueTestCertificate(caCert, caKey, "envoy", 2, time.Hour*24)
+	clientSet := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: entity.ParticipantOpenFLSecretNameEnvoy, Namespace: "test-ns"},
+			Data:       map[string][]byte{"envoy.crt": []byte("old-cert")},
+		},
+		&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "envoy", Namespace: "test-ns"}},
+	)
+	originalNewK8sClientFn, originalNewHelmClientFn := newK8sClientFn, newHelmClientFn
+	defer func() {
+		newK8sClientFn, newHelmClientFn = originalNewK8sClientFn, originalNewHelmClientFn
+	}()
+	newK8sClientFn = func(string, string, bool) (kubernetes.Client, error) {
+		return &mockK8sClient{
+			GetClientSetFn: func() clientgo.Interface {
+				return clientSet
+			},
+		}, nil
+	}
+	newHelmClientFn = func(kubernetes.Client) (helm.Client, error) {
+		return &fakeHelmClient{}, nil
+	}
+
+	envoy := &entity.ParticipantOpenFL{
+		Participant:      entity.Participant{UUID: "envoy-uuid", Namespace: "test-ns"},
+		Type:             entity.ParticipantOpenFLTypeEnvoy,
+		InfraUUID:        "infra-uuid",
+		DeploymentMethod: entity.FederationOpenFLDeploymentMethodHelm,
+	}
+	participantOpenFLRepo := &mock.ParticipantOpenFLRepoMock{
+		GetByUUIDFn: func(uuid string) (interface{}, error) {
+			return envoy, nil
+		},
+	}
+	certService := &mockRotationCertificateServiceInt{
+		caCert: caCert,
+		caKey:  caKey,
+	}
+	service := CertificateRotationService{
+		CertificateRepo: &mock.CertificateRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.Certificate{UUID: uuid, Certificate: cert}, nil
+			},
+		},
+		CertificateBindingRepo: &mock.CertificateBindingRepoMock{
+			ListByCertificateUUIDFn: func(uuid string) (interface{}, error) {
+				return []entity.CertificateBinding{{
+					CertificateUUID: uuid,
+					ParticipantUUID: "envoy-uuid",
+					ServiceType:     entity.CertificateBindingServiceTypeOpenFLEnvoy,
+					FederationType:  entity.FederationTypeOpenFL,
+				}}, nil
+			},
+		},
+		ParticipantOpenFLRepo: participantOpenFLRepo,
+		CertificateService:    certService,
+		OpenFLService: &ParticipantOpenFLService{
+			ParticipantOpenFLRepo: participantOpenFLRepo,
+			InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+				GetByUUIDFn: func(uuid string) (interface{}, error) {
+					return &entity.InfraProviderKubernetes{}, nil
+				},
+			},
+		},
+		EventService: &mockRecordingEventServiceInt{},
+	}
+	assert.NoError(t, service.RotateCertificate("test-cert"))
+	assert.Equal(t, 1, certService.savedCount)
+
+	secret, err := clientSet.CoreV1().Secrets("test-ns").Get(context.TODO(), entity.ParticipantOpenFLSecretNameEnvoy, v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, []byte("old-cert"), secret.Data["envoy.crt"])
+	deployment, err := clientSet.AppsV1().Deployments("test-ns").Get(context.TODO(), "envoy", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, deployment.Spec.Template.Annotations, certificateRotationRestartAnnotation)
+}
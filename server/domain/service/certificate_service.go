@@ -15,10 +15,12 @@
 package service
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"time"
 
@@ -28,6 +30,8 @@ import (
 	"github.com/rs/zerolog/log"
 	uuid "github.com/satori/go.uuid"
 	"github.com/smallstep/certificates/cas/apiv1"
+	"golang.org/x/crypto/ocsp"
+	"golang.org/x/crypto/pkcs12"
 )
 
 // CertificateService provides functions to work with certificate related workflows
@@ -39,16 +43,94 @@ type CertificateService struct {
 
 // CreateCertificateSimple just take the CN, lifetime and dnsNames and will give the automatically generated private key and the certificate using the first available CA
 func (s *CertificateService) CreateCertificateSimple(commonName string, lifetime time.Duration, dnsNames []string) (cert *entity.Certificate, pk *rsa.PrivateKey, err error) {
+	resp, pk, err := s.issueCertificate(commonName, lifetime, dnsNames)
+	if err != nil {
+		return
+	}
+	cert = &entity.Certificate{
+		UUID:        uuid.NewV4().String(),
+		Name:        fmt.Sprintf("Certificate for %s", commonName),
+		Certificate: resp.Certificate,
+		Chain:       resp.CertificateChain,
+	}
+
+	if saveErr := s.CertificateRepo.Create(cert); saveErr != nil {
+		err = errors.Wrap(err, "failed to save certificate")
+	}
+	return
+}
+
+// RenewCertificate issues a new certificate with the same CN, DNS names and lifetime of the specified one, with a newly
+// generated private key. The returned certificate keeps the UUID of the original one but is not saved, the caller
+// should call SaveRenewedCertificate after it is deployed.
+func (s *CertificateService) RenewCertificate(cert *entity.Certificate) (*entity.Certificate, *rsa.PrivateKey, error) {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore).Round(time.Hour)
+	if lifetime <= 0 {
+		lifetime = defaultCertLifetime
+	}
+	resp, pk, err := s.issueCertificate(cert.Subject.CommonName, lifetime, cert.DNSNames)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to issue new certificate")
+	}
+	renewedCert := &entity.Certificate{
+		Model:       cert.Model,
+		UUID:        cert.UUID,
+		Name:        cert.Name,
+		Certificate: resp.Certificate,
+		Chain:       resp.CertificateChain,
+	}
+	return renewedCert, pk, nil
+}
+
+// SaveRenewedCertificate updates the certificate record in place with the renewed certificate, so the existing
+// bindings are kept
+func (s *CertificateService) SaveRenewedCertificate(renewedCert *entity.Certificate) error {
+	if err := s.CertificateRepo.UpdateByUUID(renewedCert); err != nil {
+		return errors.Wrapf(err, "failed to save renewed certificate")
+	}
+	return nil
+}
 
+// RevokeReplacedCertificate revokes a certificate that has been replaced by a renewed one. As the renewed certificate
+// takes over the record, the replaced one is kept in a separate record so it is still included in the published CRL.
+func (s *CertificateService) RevokeReplacedCertificate(replacedCert *entity.Certificate) error {
+	if replacedCert.Imported {
+		return errors.Errorf("certificate %s is imported, it should be revoked by its issuer", replacedCert.UUID)
+	}
+	reason := "superseded by a renewed certificate"
+	if err := s.revokeViaCA(replacedCert, ocsp.Superseded, reason); err != nil {
+		return err
+	}
+	now := time.Now()
+	revokedCert := &entity.Certificate{
+		UUID:             uuid.NewV4().String(),
+		Name:             fmt.Sprintf("%s (replaced)", replacedCert.Name),
+		Certificate:      replacedCert.Certificate,
+		Chain:            replacedCert.Chain,
+		RevokedAt:        &now,
+		RevocationReason: reason,
+	}
+	if err := s.CertificateRepo.Create(revokedCert); err != nil {
+		return errors.Wrapf(err, "failed to save revocation info")
+	}
+	log.Info().Str("certificate uuid", replacedCert.UUID).Str("serial number", replacedCert.SerialNumber.String()).Msg("replaced certificate revoked")
+	return nil
+}
+
+// issueCertificate generates a private key and gets a certificate for it from the default CA
+func (s *CertificateService) issueCertificate(commonName string, lifetime time.Duration, dnsNames []string) (*apiv1.CreateCertificateResponse, *rsa.PrivateKey, error) {
 	certificateAuthority, err := s.DefaultCA()
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 	caClient, err := certificateAuthority.Client()
 	if err != nil {
-		return
+		return nil, nil, err
+	}
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
 	}
-	pk, err = rsa.GenerateKey(rand.Reader, 2048)
 	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
 		Subject: pkix.Name{
 			CommonName: commonName,
@@ -56,12 +138,12 @@ func (s *CertificateService) CreateCertificateSimple(commonName string, lifetime
 		DNSNames: dnsNames,
 	}, pk)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 
 	csr, err := x509.ParseCertificateRequest(csrBytes)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 
 	resp, err := caClient.CreateCertificate(&apiv1.CreateCertificateRequest{
@@ -69,19 +151,9 @@ func (s *CertificateService) CreateCertificateSimple(commonName string, lifetime
 		Lifetime: lifetime,
 	})
 	if err != nil {
-		return
+		return nil, nil, err
 	}
-	cert = &entity.Certificate{
-		UUID:        uuid.NewV4().String(),
-		Name:        fmt.Sprintf("Certificate for %s", commonName),
-		Certificate: resp.Certificate,
-		Chain:       resp.CertificateChain,
-	}
-
-	if saveErr := s.CertificateRepo.Create(cert); saveErr != nil {
-		err = errors.Wrap(err, "failed to save certificate")
-	}
-	return
+	return resp, pk, nil
 }
 
 // DefaultCA returns the default CA info
@@ -94,6 +166,15 @@ func (s *CertificateService) DefaultCA() (*entity.CertificateAuthority, error) {
 	return certificateAuthority, nil
 }
 
+// GetCertificateBySerialNumber returns the certificate issued or imported by FedLCM with the specified serial number
+func (s *CertificateService) GetCertificateBySerialNumber(serialNumber string) (*entity.Certificate, error) {
+	instance, err := s.CertificateRepo.GetBySerialNumber(serialNumber)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query certificate with serial number %s", serialNumber)
+	}
+	return instance.(*entity.Certificate), nil
+}
+
 // CreateBinding create a binding record of a certificate and a participant
 func (s *CertificateService) CreateBinding(cert *entity.Certificate,
 	serviceType entity.CertificateBindingServiceType,
@@ -110,7 +191,8 @@ func (s *CertificateService) CreateBinding(cert *entity.Certificate,
 	})
 }
 
-// RemoveBinding deletes a bindings record and deletes the certificate if there is no bindings for it
+// RemoveBinding deletes a bindings record and revokes and deletes the certificate if there is no bindings for it. A
+// certificate failed to be revoked is not deleted.
 func (s *CertificateService) RemoveBinding(participantUUID string) error {
 	instanceList, err := s.CertificateBindingRepo.ListByParticipantUUID(participantUUID)
 	if err != nil {
@@ -135,6 +217,17 @@ func (s *CertificateService) RemoveBinding(participantUUID string) error {
 				continue
 			}
 		}
+		if instance, err := s.CertificateRepo.GetByUUID(certificateUUID); err != nil {
+			log.Err(err).Msgf("failed to query certificate %s", certificateUUID)
+		} else if cert := instance.(*entity.Certificate); cert.Imported {
+			// imported certificates are kept so they can be bound to other participants
+			log.Info().Str("certificate uuid", certificateUUID).Msgf("keeping unused imported certificate")
+			continue
+		} else if err := s.RevokeCertificate(cert, ocsp.CessationOfOperation, "participant removed"); err != nil {
+			// the record is kept so the certificate can still be revoked later, instead of staying valid and unknown
+			log.Err(err).Msgf("failed to revoke certificate %s, keeping it for a later revocation", certificateUUID)
+			continue
+		}
 		log.Info().Str("certificate uuid", certificateUUID).Msgf("removing unused certificate")
 		if err := s.CertificateRepo.DeleteByUUID(certificateUUID); err != nil {
 			log.Err(err).Msgf("failed to remove certificate %s", certificateUUID)
@@ -142,3 +235,314 @@ func (s *CertificateService) RemoveBinding(participantUUID string) error {
 	}
 	return nil
 }
+
+// DeleteBinding deletes the bindings records of the participant, the certificates are kept as they may still be used
+func (s *CertificateService) DeleteBinding(participantUUID string) error {
+	if err := s.CertificateBindingRepo.DeleteByParticipantUUID(participantUUID); err != nil {
+		return errors.Wrapf(err, "failed to delete bindings")
+	}
+	return nil
+}
+
+// RevokeCertificate revokes the certificate via the CA and records the revocation info, so it will be included in
+// the published CRL even after the certificate record is deleted
+func (s *CertificateService) RevokeCertificate(cert *entity.Certificate, reasonCode int, reason string) error {
+	if cert.RevokedAt != nil {
+		return nil
+	}
+	if cert.Imported {
+		return errors.Errorf("certificate %s is imported, it should be revoked by its issuer", cert.UUID)
+	}
+	// the revocation is only recorded locally after the CA accepts it, so a failed revocation can be retried. The
+	// local record is what the CRL and OCSP responses of the embedded CA are built from.
+	if err := s.revokeViaCA(cert, reasonCode, reason); err != nil {
+		return err
+	}
+	now := time.Now()
+	cert.RevokedAt = &now
+	cert.RevocationReason = reason
+	if err := s.CertificateRepo.UpdateRevocationByUUID(cert); err != nil {
+		return errors.Wrapf(err, "failed to save revocation info")
+	}
+	log.Info().Str("certificate uuid", cert.UUID).Str("serial number", cert.SerialNumber.String()).Msg("certificate revoked")
+	return nil
+}
+
+// revokeViaCA revokes the certificate via the default CA. CAs that do not support revocation are skipped as the
+// revocation is recorded locally.
+func (s *CertificateService) revokeViaCA(cert *entity.Certificate, reasonCode int, reason string) error {
+	certificateAuthority, err := s.DefaultCA()
+	if err != nil {
+		return err
+	}
+	caClient, err := certificateAuthority.Client()
+	if err != nil {
+		return err
+	}
+	if _, err := caClient.RevokeCertificate(&apiv1.RevokeCertificateRequest{
+		Certificate:  cert.Certificate,
+		SerialNumber: cert.SerialNumber.String(),
+		Reason:       reason,
+		ReasonCode:   reasonCode,
+	}); err != nil {
+		var notImplementedError apiv1.NotImplementedError
+		if errors.As(err, &notImplementedError) {
+			log.Warn().Str("certificate uuid", cert.UUID).Msgf("%v CA does not support revocation, only recorded locally", certificateAuthority.Type)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to revoke certificate via the CA")
+	}
+	return nil
+}
+
+// GenerateCRL returns a DER encoded CRL, signed by the default CA, containing all the revoked certificates
+func (s *CertificateService) GenerateCRL(validity time.Duration) ([]byte, error) {
+	certificateAuthority, err := s.DefaultCA()
+	if err != nil {
+		return nil, err
+	}
+	instanceList, err := s.CertificateRepo.ListRevoked()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query revoked certificates")
+	}
+	var revokedList []pkix.RevokedCertificate
+	for _, cert := range instanceList.([]entity.Certificate) {
+		revokedList = append(revokedList, pkix.RevokedCertificate{
+			SerialNumber:   cert.SerialNumber,
+			RevocationTime: *cert.RevokedAt,
+		})
+	}
+	return certificateAuthority.CreateCRL(revokedList, validity)
+}
+
+// GenerateOCSPResponse parses the DER encoded OCSP request and returns a DER encoded response signed by the default CA
+func (s *CertificateService) GenerateOCSPResponse(requestBytes []byte, validity time.Duration) ([]byte, error) {
+	req, err := ocsp.ParseRequest(requestBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid OCSP request")
+	}
+	certificateAuthority, err := s.DefaultCA()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(validity),
+	}
+	instanceList, err := s.CertificateRepo.ListRevoked()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query revoked certificates")
+	}
+	for _, cert := range instanceList.([]entity.Certificate) {
+		if cert.SerialNumber.Cmp(req.SerialNumber) == 0 {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = *cert.RevokedAt
+			template.RevocationReason = ocsp.Unspecified
+			break
+		}
+	}
+	if template.Status == ocsp.Unknown {
+		if _, err := s.CertificateRepo.GetBySerialNumber(req.SerialNumber.String()); err == nil {
+			template.Status = ocsp.Good
+		}
+	}
+	return certificateAuthority.CreateOCSPResponse(template)
+}
+
+// ImportCertificate validates and saves an externally issued certificate with its private key. The certificate content
+// can contain the chain following the leaf certificate, and additional chain certificates can be provided in chainPEM.
+func (s *CertificateService) ImportCertificate(name, certPEM, keyPEM, chainPEM string) (*entity.Certificate, error) {
+	var certs []*x509.Certificate
+	var key *rsa.PrivateKey
+	rest := []byte(certPEM + "\n" + chainPEM + "\n" + keyPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse certificate")
+			}
+			certs = append(certs, cert)
+		case "RSA PRIVATE KEY", "PRIVATE KEY":
+			if key != nil {
+				return nil, errors.New("more than one private key is provided")
+			}
+			var err error
+			if key, err = parseImportedPrivateKey(block.Bytes); err != nil {
+				return nil, err
+			}
+		case "EC PRIVATE KEY":
+			return nil, errors.New("only RSA private key is supported")
+		default:
+			return nil, errors.Errorf("unsupported PEM block type: %s", block.Type)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate is provided")
+	}
+	return s.importCertificate(name, certs[0], certs[1:], key)
+}
+
+// ImportCertificatePKCS12 validates and saves an externally issued certificate with its private key and chain from
+// the PKCS#12 content
+func (s *CertificateService) ImportCertificatePKCS12(name string, data []byte, password string) (*entity.Certificate, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode PKCS#12 content")
+	}
+	var certs []*x509.Certificate
+	var key *rsa.PrivateKey
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse certificate")
+			}
+			certs = append(certs, cert)
+		case "PRIVATE KEY":
+			if key != nil {
+				return nil, errors.New("more than one private key is contained")
+			}
+			if key, err = parseImportedPrivateKey(block.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if key == nil {
+		return nil, errors.New("no private key is contained")
+	}
+	// the leaf certificate is the one matching the private key, the others are the chain
+	for index, cert := range certs {
+		if publicKey, ok := cert.PublicKey.(*rsa.PublicKey); ok && publicKey.Equal(&key.PublicKey) {
+			chain := append(append([]*x509.Certificate{}, certs[:index]...), certs[index+1:]...)
+			return s.importCertificate(name, cert, chain, key)
+		}
+	}
+	return nil, errors.New("no certificate matching the private key is contained")
+}
+
+// importCertificate checks the key, validity period and chain of the certificate and saves it. The chain should lead
+// to a root certificate either contained in the chain or being the root certificate of the default CA.
+func (s *CertificateService) importCertificate(name string, cert *x509.Certificate, chain []*x509.Certificate, key *rsa.PrivateKey) (*entity.Certificate, error) {
+	if key == nil {
+		return nil, errors.New("no private key is provided")
+	}
+	if publicKey, ok := cert.PublicKey.(*rsa.PublicKey); !ok || !publicKey.Equal(&key.PublicKey) {
+		return nil, errors.New("the private key doesn't match the certificate")
+	}
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, errors.Errorf("the certificate is not valid now, validity period: %v - %v", cert.NotBefore, cert.NotAfter)
+	}
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	var servedChain []*x509.Certificate
+	for _, chainCert := range chain {
+		if bytes.Equal(chainCert.RawIssuer, chainCert.RawSubject) && chainCert.CheckSignatureFrom(chainCert) == nil {
+			roots.AddCert(chainCert)
+		} else {
+			intermediates.AddCert(chainCert)
+			servedChain = append(servedChain, chainCert)
+		}
+	}
+	if ca, err := s.DefaultCA(); err == nil {
+		if caCert, err := ca.RootCert(); err == nil {
+			roots.AddCert(caCert)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify the certificate chain")
+	}
+	if _, err := s.CertificateRepo.GetBySerialNumber(cert.SerialNumber.String()); err == nil {
+		return nil, errors.Errorf("certificate with serial number %v already exists", cert.SerialNumber)
+	}
+	if name == "" {
+		name = fmt.Sprintf("Imported certificate for %s", cert.Subject.CommonName)
+	}
+	importedCert := &entity.Certificate{
+		UUID:        uuid.NewV4().String(),
+		Name:        name,
+		Imported:    true,
+		Certificate: cert,
+		Chain:       servedChain,
+	}
+	if err := importedCert.SetPrivateKey(key); err != nil {
+		return nil, err
+	}
+	if err := s.CertificateRepo.Create(importedCert); err != nil {
+		return nil, errors.Wrapf(err, "failed to save certificate")
+	}
+	log.Info().Str("certificate uuid", importedCert.UUID).Msgf("imported certificate with serial number: %v for CN: %s", cert.SerialNumber, cert.Subject.CommonName)
+	return importedCert, nil
+}
+
+// GetImportedCertificate returns the imported certificate and its private key, after checking it can be used by a
+// service with the specified DNS names and key usage
+func (s *CertificateService) GetImportedCertificate(certUUID string, dnsNames []string, usage x509.ExtKeyUsage) (*entity.Certificate, *rsa.PrivateKey, error) {
+	instance, err := s.CertificateRepo.GetByUUID(certUUID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to query certificate %s", certUUID)
+	}
+	cert := instance.(*entity.Certificate)
+	if !cert.Imported {
+		return nil, nil, errors.Errorf("certificate %s is not an imported one, only imported certificates can be reused", certUUID)
+	}
+	if cert.RevokedAt != nil {
+		return nil, nil, errors.Errorf("certificate %s has been revoked", certUUID)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, nil, errors.Errorf("certificate %s expired at %v", certUUID, cert.NotAfter)
+	}
+	for _, dnsName := range dnsNames {
+		if err := cert.VerifyHostname(dnsName); err != nil {
+			return nil, nil, errors.Errorf("certificate %s doesn't contain the SAN %s required by the service", certUUID, dnsName)
+		}
+	}
+	if len(cert.ExtKeyUsage) > 0 {
+		allowed := false
+		for _, extKeyUsage := range cert.ExtKeyUsage {
+			if extKeyUsage == usage || extKeyUsage == x509.ExtKeyUsageAny {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, nil, errors.Errorf("certificate %s is not allowed for the key usage required by the service", certUUID)
+		}
+	}
+	pk, err := cert.PrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, pk, nil
+}
+
+// parseImportedPrivateKey parses PKCS#1 or PKCS#8 encoded RSA private key, which is the only key type supported by the
+// deployed services
+func parseImportedPrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse private key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("only RSA private key is supported")
+	}
+	return rsaKey, nil
+}
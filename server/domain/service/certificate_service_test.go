@@ -0,0 +1,238 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestCertificateService_RevokeAndPublish(t *testing.T) {
+	caConfig, err := entity.GenerateEmbeddedCAConfiguration("Test", "FedLCM")
+	assert.NoError(t, err)
+	caConfigJSON, _ := json.Marshal(caConfig)
+	ca := &entity.CertificateAuthority{
+		Type:              entity.CertificateAuthorityTypeEmbedded,
+		ConfigurationJSON: string(caConfigJSON),
+	}
+
+	var savedCert *entity.Certificate
+	deleted := map[string]bool{}
+	certificateRepo := &mock.CertificateRepoMock{
+		CreateFn: func(instance interface{}) error {
+			savedCert = instance.(*entity.Certificate)
+			return nil
+		},
+		GetByUUIDFn: func(uuid string) (interface{}, error) {
+			return savedCert, nil
+		},
+		DeleteByUUIDFn: func(uuid string) error {
+			deleted[uuid] = true
+			return nil
+		},
+		ListRevokedFn: func() (interface{}, error) {
+			if savedCert.RevokedAt == nil {
+				return []entity.Certificate{}, nil
+			}
+			return []entity.Certificate{*savedCert}, nil
+		},
+	}
+	s := &CertificateService{
+		CertificateAuthorityRepo: &mock.CertificateAuthorityRepoMock{
+			GetFirstFn: func() (interface{}, error) {
+				return ca, nil
+			},
+		},
+		CertificateRepo: certificateRepo,
+		CertificateBindingRepo: &mock.CertificateBindingRepoMock{
+			ListByParticipantUUIDFn: func(uuid string) (interface{}, error) {
+				return []entity.CertificateBinding{{CertificateUUID: savedCert.UUID, ParticipantUUID: uuid}}, nil
+			},
+			ListByCertificateUUIDFn: func(uuid string) (interface{}, error) {
+				return []entity.CertificateBinding{}, nil
+			},
+		},
+	}
+
+	cert, _, err := s.CreateCertificateSimple("test.example.com", time.Hour, []string{"test.example.com"})
+	assert.NoError(t, err)
+	issuer := cert.Chain[0]
+
+	crlBytes, err := s.GenerateCRL(time.Hour)
+	assert.NoError(t, err)
+	crl, err := x509.ParseRevocationList(crlBytes)
+	assert.NoError(t, err)
+	assert.Empty(t, crl.RevokedCertificates)
+
+	ocspRequest, err := ocsp.CreateRequest(cert.Certificate, issuer, nil)
+	assert.NoError(t, err)
+	ocspBytes, err := s.GenerateOCSPResponse(ocspRequest, time.Hour)
+	assert.NoError(t, err)
+	ocspResp, err := ocsp.ParseResponseForCert(ocspBytes, cert.Certificate, issuer)
+	assert.NoError(t, err)
+	assert.Equal(t, ocsp.Good, ocspResp.Status)
+
+	// removing the participant revokes and deletes the unused certificate
+	assert.NoError(t, s.RemoveBinding("participant-uuid"))
+	assert.NotNil(t, cert.RevokedAt)
+	assert.Equal(t, "participant removed", cert.RevocationReason)
+	assert.True(t, deleted[cert.UUID])
+
+	crlBytes, err = s.GenerateCRL(time.Hour)
+	assert.NoError(t, err)
+	crl, err = x509.ParseRevocationList(crlBytes)
+	assert.NoError(t, err)
+	assert.NoError(t, crl.CheckSignatureFrom(issuer))
+	assert.Len(t, crl.RevokedCertificates, 1)
+	assert.Equal(t, cert.SerialNumber, crl.RevokedCertificates[0].SerialNumber)
+
+	ocspBytes, err = s.GenerateOCSPResponse(ocspRequest, time.Hour)
+	assert.NoError(t, err)
+	ocspResp, err = ocsp.ParseResponseForCert(ocspBytes, cert.Certificate, issuer)
+	assert.NoError(t, err)
+	assert.Equal(t, ocsp.Revoked, ocspResp.Status)
+}
+
+func TestCertificateService_ImportCertificate(t *testing.T) {
+	caCert, caKey := issueTestCertificate(nil, nil, "enterprise-ca", 1, time.Hour*24*3650)
+	serverCert, serverKey := issueTestCertificate(caCert, caKey, "proxy.example.com", 2, time.Hour*24*365)
+	otherCert, _ := issueTestCertificate(caCert, caKey, "other.example.com", 3, time.Hour*24*365)
+	encodeCert := func(cert *x509.Certificate) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	serverKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(serverKey)}))
+
+	var savedCert *entity.Certificate
+	s := &CertificateService{
+		CertificateAuthorityRepo: &mock.CertificateAuthorityRepoMock{
+			GetFirstFn: func() (interface{}, error) {
+				return nil, errors.New("no CA configured")
+			},
+		},
+		CertificateRepo: &mock.CertificateRepoMock{
+			CreateFn: func(instance interface{}) error {
+				savedCert = instance.(*entity.Certificate)
+				return nil
+			},
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return savedCert, nil
+			},
+			GetBySerialNumberFn: func(serialNumberStr string) (interface{}, error) {
+				return nil, errors.New("record not found")
+			},
+		},
+	}
+
+	// the chain cannot be verified without the root certificate
+	_, err := s.ImportCertificate("", encodeCert(serverCert), serverKeyPEM, "")
+	assert.Error(t, err)
+	// the private key doesn't match the certificate
+	_, err = s.ImportCertificate("", encodeCert(otherCert), serverKeyPEM, encodeCert(caCert))
+	assert.Error(t, err)
+
+	cert, err := s.ImportCertificate("", encodeCert(serverCert), serverKeyPEM, encodeCert(caCert))
+	assert.NoError(t, err)
+	assert.True(t, cert.Imported)
+	assert.Equal(t, "Imported certificate for proxy.example.com", cert.Name)
+	assert.NotContains(t, cert.EncryptedKeyPEM, "PRIVATE KEY")
+	// the self-signed root is not served in the chain
+	assert.Empty(t, cert.Chain)
+
+	_, _, err = s.GetImportedCertificate(cert.UUID, []string{"fmlmanager.example.com"}, x509.ExtKeyUsageServerAuth)
+	assert.Error(t, err)
+	reusedCert, pk, err := s.GetImportedCertificate(cert.UUID, []string{"proxy.example.com"}, x509.ExtKeyUsageServerAuth)
+	assert.NoError(t, err)
+	assert.Equal(t, cert.UUID, reusedCert.UUID)
+	assert.True(t, serverKey.Equal(pk))
+
+	// imported certificates cannot be revoked by FedLCM
+	assert.Error(t, s.RevokeCertificate(cert, ocsp.Unspecified, ""))
+
+	_, err = s.ImportCertificatePKCS12("", []byte("invalid"), "")
+	assert.Error(t, err)
+}
+
+func TestCertificateService_RemoveBinding_RevocationFailed(t *testing.T) {
+	caCert, caKey := issueTestCertificate(nil, nil, "test-ca", 1, time.Hour*24*3650)
+	x509Cert, _ := issueTestCertificate(caCert, caKey, "test", 2, time.Hour*24)
+	cert := &entity.Certificate{UUID: "test-cert", Certificate: x509Cert}
+	bindingsDeleted, certDeleted := false, false
+	s := &CertificateService{
+		CertificateAuthorityRepo: &mock.CertificateAuthorityRepoMock{
+			GetFirstFn: func() (interface{}, error) {
+				return nil, errors.New("CA unavailable")
+			},
+		},
+		CertificateRepo: &mock.CertificateRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return cert, nil
+			},
+			DeleteByUUIDFn: func(uuid string) error {
+				certDeleted = true
+				return nil
+			},
+		},
+		CertificateBindingRepo: &mock.CertificateBindingRepoMock{
+			ListByParticipantUUIDFn: func(uuid string) (interface{}, error) {
+				return []entity.CertificateBinding{{CertificateUUID: cert.UUID, ParticipantUUID: uuid}}, nil
+			},
+			DeleteByParticipantUUIDFn: func(uuid string) error {
+				bindingsDeleted = true
+				return nil
+			},
+			ListByCertificateUUIDFn: func(uuid string) (interface{}, error) {
+				return []entity.CertificateBinding{}, nil
+			},
+		},
+	}
+	assert.NoError(t, s.RemoveBinding("participant-uuid"))
+	assert.True(t, bindingsDeleted)
+	// the certificate record is kept so it can still be revoked
+	assert.False(t, certDeleted)
+	assert.Nil(t, cert.RevokedAt)
+}
+
+func TestCertificateService_RevokeCertificate_CAFailed(t *testing.T) {
+	caCert, caKey := issueTestCertificate(nil, nil, "test-ca", 1, time.Hour*24*3650)
+	x509Cert, _ := issueTestCertificate(caCert, caKey, "test", 2, time.Hour*24)
+	saved := false
+	s := &CertificateService{
+		CertificateAuthorityRepo: &mock.CertificateAuthorityRepoMock{
+			GetFirstFn: func() (interface{}, error) {
+				return nil, errors.New("CA unavailable")
+			},
+		},
+		CertificateRepo: &mock.CertificateRepoMock{
+			UpdateRevocationByUUIDFn: func(instance interface{}) error {
+				saved = true
+				return nil
+			},
+		},
+	}
+	cert := &entity.Certificate{UUID: "test-cert", Certificate: x509Cert}
+	assert.Error(t, s.RevokeCertificate(cert, ocsp.Unspecified, ""))
+	// the revocation is not recorded so it can be retried
+	assert.False(t, saved)
+	assert.Nil(t, cert.RevokedAt)
+}
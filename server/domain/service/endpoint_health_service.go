@@ -0,0 +1,222 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubefate"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// endpointHealth is the result of probing a KubeFATE endpoint
+type endpointHealth struct {
+	// Err is why the endpoint is not healthy, nil means it is healthy
+	Err                      error
+	Version                  string
+	KubeFATEMissing          bool
+	IngressControllerMissing bool
+}
+
+// ProbeKubeFATEEndpoints checks the health of all the ready or unavailable KubeFATE endpoints
+func (s *EndpointService) ProbeKubeFATEEndpoints(autoRepair bool) error {
+	instanceList, err := s.EndpointKubeFATERepo.List()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list KubeFATE endpoints")
+	}
+	var probeErr error
+	for _, endpoint := range instanceList.([]entity.EndpointKubeFATE) {
+		if endpoint.Status != entity.EndpointStatusReady && endpoint.Status != entity.EndpointStatusUnavailable {
+			continue
+		}
+		if _, err := s.ProbeKubeFATEEndpoint(endpoint.UUID, autoRepair); err != nil {
+			log.Err(err).Str("endpoint uuid", endpoint.UUID).Msg("failed to probe endpoint")
+			probeErr = err
+		}
+	}
+	return probeErr
+}
+
+// ProbeKubeFATEEndpoint checks whether the KubeFATE service of the endpoint works and updates the endpoint status
+// accordingly. If autoRepair is true and the KubeFATE or ingress controller deployment installed by FedLCM is missing,
+// the manifests are re-applied in the background, and the returned WaitGroup can be used to wait for the repair.
+func (s *EndpointService) ProbeKubeFATEEndpoint(uuid string, autoRepair bool) (*sync.WaitGroup, error) {
+	endpointInstance, err := s.EndpointKubeFATERepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get KubeFATE endpoint instance")
+	}
+	endpoint := endpointInstance.(*entity.EndpointKubeFATE)
+	if endpoint.Status != entity.EndpointStatusReady && endpoint.Status != entity.EndpointStatusUnavailable {
+		return nil, errors.Errorf("cannot probe endpoint in %v status", endpoint.Status)
+	}
+	yaml := endpoint.DeploymentYAML
+	if yaml == "" {
+		yaml, err = s.GetDeploymentYAML(endpoint.Namespace, "admin", "admin", "kubefate.net", valueobject.KubeRegistryConfig{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get default deployment yaml")
+		}
+	}
+	mgr, err := s.BuildKubeFATEManager(endpoint.InfraProviderUUID, endpoint.Namespace, yaml, endpoint.IngressControllerYAML)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build kubefate manager")
+	}
+
+	health := s.checkKubeFATEHealth(mgr, endpoint.IngressControllerYAML != "")
+	if health.Err == nil {
+		if endpoint.Status != entity.EndpointStatusReady || endpoint.Version != health.Version {
+			previousStatus := endpoint.Status
+			endpoint.Status = entity.EndpointStatusReady
+			endpoint.Version = health.Version
+			if err := s.EndpointKubeFATERepo.UpdateInfoByUUID(endpoint); err != nil {
+				return nil, errors.Wrapf(err, "failed to update endpoint info")
+			}
+			if previousStatus != entity.EndpointStatusReady {
+				message := "endpoint is available again"
+				log.Info().Str("endpoint uuid", uuid).Msg(message)
+				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, message, entity.EventLogLevelInfo)
+			}
+		}
+		return nil, nil
+	}
+
+	if endpoint.Status == entity.EndpointStatusReady {
+		endpoint.Status = entity.EndpointStatusUnavailable
+		if err := s.EndpointKubeFATERepo.UpdateStatusByUUID(endpoint); err != nil {
+			return nil, errors.Wrapf(err, "failed to update endpoint status")
+		}
+		message := "endpoint is unavailable"
+		log.Warn().Err(health.Err).Str("endpoint uuid", uuid).Msg(message)
+		_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, errors.Wrapf(health.Err, message).Error(), entity.EventLogLevelError)
+	}
+	if !autoRepair || !health.KubeFATEMissing && !health.IngressControllerMissing {
+		return nil, nil
+	}
+	if endpoint.DeploymentYAML == "" {
+		log.Warn().Str("endpoint uuid", uuid).Msg("the KubeFATE deployment is missing but it is not installed by FedLCM, skip repairing")
+		return nil, nil
+	}
+	return s.repairKubeFATEEndpoint(endpoint, mgr, health)
+}
+
+// checkKubeFATEHealth returns the health of the KubeFATE installation
+func (s *EndpointService) checkKubeFATEHealth(mgr kubefate.Manager, checkIngressController bool) *endpointHealth {
+	health := &endpointHealth{}
+	if checkIngressController {
+		if _, err := mgr.GetIngressNginxControllerDeployment(); err != nil {
+			if !k8sErr.IsNotFound(err) {
+				health.Err = errors.Wrapf(err, "failed to get the ingress controller deployment")
+				return health
+			}
+			health.IngressControllerMissing = true
+			health.Err = errors.New("the ingress controller deployment is missing")
+		}
+	}
+	deployment, err := mgr.GetKubeFATEDeployment()
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			health.Err = errors.Wrapf(err, "failed to get the KubeFATE deployment")
+			return health
+		}
+		health.KubeFATEMissing = true
+		health.Err = errors.New("the KubeFATE deployment is missing")
+		return health
+	}
+	if health.Err != nil {
+		return health
+	}
+	if deployment.Status.ReadyReplicas == 0 {
+		health.Err = errors.New("the KubeFATE deployment has no ready replica")
+		return health
+	}
+	kfc, closer, err := mgr.BuildPFClient()
+	if closer != nil {
+		defer closer()
+	}
+	if err != nil {
+		health.Err = errors.Wrapf(err, "failed to get kubefate client")
+		return health
+	}
+	health.Version, err = kfc.CheckVersion()
+	if err != nil {
+		health.Err = errors.Wrapf(err, "failed to get kubefate version")
+	}
+	return health
+}
+
+// repairKubeFATEEndpoint re-applies the manifests of the missing deployments of the endpoint
+func (s *EndpointService) repairKubeFATEEndpoint(endpoint *entity.EndpointKubeFATE, mgr kubefate.Manager, health *endpointHealth) (*sync.WaitGroup, error) {
+	uuid := endpoint.UUID
+	endpoint.Status = entity.EndpointStatusCreating
+	if err := s.EndpointKubeFATERepo.UpdateStatusByUUID(endpoint); err != nil {
+		return nil, errors.Wrapf(err, "failed to update endpoint status")
+	}
+	message := fmt.Sprintf("start repairing endpoint, KubeFATE missing: %v, ingress controller missing: %v", health.KubeFATEMissing, health.IngressControllerMissing)
+	log.Info().Str("endpoint uuid", uuid).Msg(message)
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, message, entity.EventLogLevelInfo)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := func() error {
+			if health.IngressControllerMissing {
+				if err := mgr.InstallIngressNginxController(); err != nil {
+					return errors.Wrapf(err, "failed to install ingress controller")
+				}
+			}
+			if health.KubeFATEMissing {
+				if err := mgr.Install(!endpoint.Config.UsePortForwarding); err != nil {
+					return errors.Wrapf(err, "failed to install kubefate")
+				}
+			}
+			kfc, closer, err := mgr.BuildPFClient()
+			if closer != nil {
+				defer closer()
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to get kubefate client")
+			}
+			versionStr, err := kfc.CheckVersion()
+			if err != nil {
+				return errors.Wrapf(err, "failed to get kubefate version")
+			}
+			endpoint.Version = versionStr
+			endpoint.Status = entity.EndpointStatusReady
+			if err := s.EndpointKubeFATERepo.UpdateInfoByUUID(endpoint); err != nil {
+				return errors.Wrapf(err, "failed to update endpoint info")
+			}
+			return nil
+		}()
+		if err != nil {
+			message := "error repairing kubefate endpoint"
+			log.Err(err).Str("endpoint uuid", uuid).Msg(message)
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, errors.Wrapf(err, message).Error(), entity.EventLogLevelError)
+			endpoint.Status = entity.EndpointStatusUnavailable
+			if err := s.EndpointKubeFATERepo.UpdateStatusByUUID(endpoint); err != nil {
+				log.Err(err).Str("endpoint uuid", uuid).Msg("failed to update endpoint status")
+			}
+			return
+		}
+		message := "KubeFATE endpoint repaired without error"
+		log.Info().Str("endpoint uuid", uuid).Msg(message)
+		_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, message, entity.EventLogLevelInfo)
+	}()
+	return wg, nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubefate"
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	appv1 "k8s.io/api/apps/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestProbeKubeFATEEndpoints(t *testing.T) {
+	deployment := &appv1.Deployment{Status: appv1.DeploymentStatus{ReadyReplicas: 1}}
+	var deploymentErr error
+	mgr := &mockKubeFATEManager{
+		GetKubeFATEDeploymentFn: func() (*appv1.Deployment, error) {
+			return deployment, deploymentErr
+		},
+	}
+	originalNewK8sClientFn, originalNewKubeFATEMgrFn := newK8sClientFn, newKubeFATEMgrFn
+	defer func() {
+		newK8sClientFn, newKubeFATEMgrFn = originalNewK8sClientFn, originalNewKubeFATEMgrFn
+	}()
+	newK8sClientFn = func(string, string, bool) (kubernetes.Client, error) {
+		return &mockK8sClient{}, nil
+	}
+	newKubeFATEMgrFn = func(kubernetes.Client, *kubefate.InstallationMeta) kubefate.Manager {
+		return mgr
+	}
+
+	endpointRepo := &mockRecordingEndpointKubeFATERepo{
+		endpoint: &entity.EndpointKubeFATE{
+			EndpointBase: entity.EndpointBase{
+				UUID:    "test-endpoint",
+				Version: "1.4.4",
+				Type:    entity.EndpointTypeKubeFATE,
+				Status:  entity.EndpointStatusReady,
+			},
+			Config: entity.KubeFATEConfig{
+				IngressRuleHost: "test.kubefate.net",
+			},
+		},
+	}
+	events := &mockRecordingEventServiceInt{}
+	service := &EndpointService{
+		InfraProviderKubernetesRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		EndpointKubeFATERepo: endpointRepo,
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantFATE{}, nil
+			},
+		},
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantOpenFL{}, nil
+			},
+		},
+		EventService: events,
+	}
+
+	// the version is updated from the service
+	assert.NoError(t, service.ProbeKubeFATEEndpoints(false))
+	assert.Equal(t, entity.EndpointStatusReady, endpointRepo.endpoint.Status)
+	assert.Equal(t, "1.4.2", endpointRepo.endpoint.Version)
+	assert.Empty(t, events.descriptions)
+
+	deployment.Status.ReadyReplicas = 0
+	assert.NoError(t, service.ProbeKubeFATEEndpoints(false))
+	assert.Equal(t, entity.EndpointStatusUnavailable, endpointRepo.endpoint.Status)
+	assert.Len(t, events.descriptions, 1)
+	assert.Contains(t, events.descriptions[0], "no ready replica")
+
+	// unavailable endpoints are still probed, and no more events are emitted until the status changes
+	assert.NoError(t, service.ProbeKubeFATEEndpoints(false))
+	assert.Len(t, events.descriptions, 1)
+
+	deployment.Status.ReadyReplicas = 1
+	assert.NoError(t, service.ProbeKubeFATEEndpoints(false))
+	assert.Equal(t, entity.EndpointStatusReady, endpointRepo.endpoint.Status)
+	assert.Len(t, events.descriptions, 2)
+
+	// endpoints in other status are skipped
+	endpointRepo.endpoint.Status = entity.EndpointStatusUpgrading
+	deploymentErr = k8sErr.NewNotFound(schema.GroupResource{Resource: "deployments"}, "kubefate")
+	assert.NoError(t, service.ProbeKubeFATEEndpoints(true))
+	assert.Equal(t, entity.EndpointStatusUpgrading, endpointRepo.endpoint.Status)
+}
+
+func TestProbeKubeFATEEndpoint_Repair(t *testing.T) {
+	notFoundErr := k8sErr.NewNotFound(schema.GroupResource{Resource: "deployments"}, "kubefate")
+	installed, ingressControllerInstalled := false, false
+	mgr := &mockKubeFATEManager{
+		GetKubeFATEDeploymentFn: func() (*appv1.Deployment, error) {
+			return nil, notFoundErr
+		},
+		GetIngressNginxControllerDeploymentFn: func() (*appv1.Deployment, error) {
+			return nil, notFoundErr
+		},
+		InstallFn: func() error {
+			installed = true
+			return nil
+		},
+		InstallIngressNginxControllerFn: func() error {
+			ingressControllerInstalled = true
+			return nil
+		},
+	}
+
+	originalNewK8sClientFn, originalNewKubeFATEMgrFn := newK8sClientFn, newKubeFATEMgrFn
+	defer func() {
+		newK8sClientFn, newKubeFATEMgrFn = originalNewK8sClientFn, originalNewKubeFATEMgrFn
+	}()
+	newK8sClientFn = func(string, string, bool) (kubernetes.Client, error) {
+		return &mockK8sClient{}, nil
+	}
+	newKubeFATEMgrFn = func(kubernetes.Client, *kubefate.InstallationMeta) kubefate.Manager {
+		return mgr
+	}
+
+	// endpoints not installed by FedLCM are not repaired
+	endpointRepo := &mockRecordingEndpointKubeFATERepo{
+		endpoint: &entity.EndpointKubeFATE{
+			EndpointBase: entity.EndpointBase{
+				UUID:    "test-endpoint",
+				Version: "1.4.4",
+				Type:    entity.EndpointTypeKubeFATE,
+				Status:  entity.EndpointStatusReady,
+			},
+			Config: entity.KubeFATEConfig{
+				IngressRuleHost: "test.kubefate.net",
+			},
+		},
+	}
+	events := &mockRecordingEventServiceInt{}
+	service := &EndpointService{
+		InfraProviderKubernetesRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		EndpointKubeFATERepo: endpointRepo,
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantFATE{}, nil
+			},
+		},
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantOpenFL{}, nil
+			},
+		},
+		EventService: events,
+	}
+	wg, err := service.ProbeKubeFATEEndpoint("test-endpoint", true)
+	assert.NoError(t, err)
+	assert.Nil(t, wg)
+	assert.Equal(t, entity.EndpointStatusUnavailable, endpointRepo.endpoint.Status)
+	assert.False(t, installed)
+
+	endpointRepo = &mockRecordingEndpointKubeFATERepo{
+		endpoint: &entity.EndpointKubeFATE{
+			EndpointBase: entity.EndpointBase{
+				UUID:    "test-endpoint",
+				Version: "1.4.4",
+				Type:    entity.EndpointTypeKubeFATE,
+				Status:  entity.EndpointStatusReady,
+			},
+			Config: entity.KubeFATEConfig{
+				IngressRuleHost: "test.kubefate.net",
+			},
+			DeploymentYAML:        "test-yaml",
+			IngressControllerYAML: "test-ingress-controller-yaml",
+		},
+	}
+	service = &EndpointService{
+		InfraProviderKubernetesRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		EndpointKubeFATERepo: endpointRepo,
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantFATE{}, nil
+			},
+		},
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantOpenFL{}, nil
+			},
+		},
+		EventService: events,
+	}
+
+	// the missing deployments are only reported without auto repair
+	wg, err = service.ProbeKubeFATEEndpoint("test-endpoint", false)
+	assert.NoError(t, err)
+	assert.Nil(t, wg)
+	assert.False(t, installed)
+
+	wg, err = service.ProbeKubeFATEEndpoint("test-endpoint", true)
+	assert.NoError(t, err)
+	if assert.NotNil(t, wg) {
+		wg.Wait()
+	}
+	assert.True(t, installed)
+	assert.True(t, ingressControllerInstalled)
+	assert.Equal(t, []entity.EndpointStatus{entity.EndpointStatusUnavailable, entity.EndpointStatusCreating, entity.EndpointStatusReady}, endpointRepo.statuses)
+}
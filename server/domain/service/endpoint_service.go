@@ -560,6 +560,15 @@ func (s *EndpointService) GetIngressControllerDeploymentYAML(mode entity.Endpoin
 	return buf.String(), nil
 }
 
+// getInfraProviderUUID returns the uuid of the infra provider where the endpoint is deployed
+func (s *EndpointService) getInfraProviderUUID(uuid string) (string, error) {
+	endpointInstance, err := s.EndpointKubeFATERepo.GetByUUID(uuid)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get KubeFATE endpoint instance")
+	}
+	return endpointInstance.(*entity.EndpointKubeFATE).InfraProviderUUID, nil
+}
+
 // ensureEndpointExist returns try to add/install a KubeFATE into the specified cluster
 func (s *EndpointService) ensureEndpointExist(infraUUID string, namespace string, registryConfig valueobject.KubeRegistryConfig) (string, error) {
 	endpointScanResult, err := s.FindKubeFATEEndpoint(infraUUID, namespace)
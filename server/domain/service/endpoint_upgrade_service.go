@@ -0,0 +1,159 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubefate"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// UpgradeKubeFATEEndpoint applies a new KubeFATE deployment yaml to the endpoint. An empty yaml means the deployment
+// yaml the endpoint is installed with, with the KubeFATE image tag changed to the default version. The upgrade runs in the background and the returned
+// WaitGroup can be used to wait for it.
+func (s *EndpointService) UpgradeKubeFATEEndpoint(uuid, yaml string) (*sync.WaitGroup, error) {
+	endpointInstance, err := s.EndpointKubeFATERepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get KubeFATE endpoint instance")
+	}
+	endpoint := endpointInstance.(*entity.EndpointKubeFATE)
+	if endpoint.Status != entity.EndpointStatusReady && endpoint.Status != entity.EndpointStatusUnavailable {
+		return nil, errors.Errorf("cannot upgrade endpoint in %v status", endpoint.Status)
+	}
+	if err := s.checkNoOngoingParticipantOperations(uuid); err != nil {
+		return nil, err
+	}
+
+	if yaml == "" {
+		// keep the credentials, the ingress host and the registry settings the endpoint is installed with
+		if endpoint.DeploymentYAML == "" {
+			return nil, errors.New("the endpoint has no recorded deployment yaml, provide the yaml to upgrade with")
+		}
+		yaml, err = kubefate.SetVersionInYAML(endpoint.DeploymentYAML, kubefate.GetDefaultVersion())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to set the KubeFATE version in the deployment yaml")
+		}
+	}
+	targetVersionStr, err := kubefate.GetVersionFromYAML(yaml)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the KubeFATE version from the yaml")
+	}
+	targetVersion, err := version.NewVersion(targetVersionStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid KubeFATE version %s", targetVersionStr)
+	}
+	if targetVersion.LessThan(minKubeFATEVersion) {
+		return nil, errors.Errorf("KubeFATE version %s is not compatible, the minimal version is %s", targetVersion, minKubeFATEVersion)
+	}
+	if currentVersion, err := version.NewVersion(endpoint.Version); err == nil && targetVersion.LessThan(currentVersion) {
+		return nil, errors.Errorf("cannot downgrade KubeFATE from version %s to %s", currentVersion, targetVersion)
+	}
+
+	mgr, err := s.BuildKubeFATEManager(endpoint.InfraProviderUUID, endpoint.Namespace, yaml, endpoint.IngressControllerYAML)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build kubefate manager")
+	}
+	if _, err := mgr.GetKubeFATEDeployment(); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the current KubeFATE deployment")
+	}
+
+	previousVersion := endpoint.Version
+	endpoint.Status = entity.EndpointStatusUpgrading
+	if err := s.EndpointKubeFATERepo.UpdateStatusByUUID(endpoint); err != nil {
+		return nil, errors.Wrapf(err, "failed to update endpoint status")
+	}
+	message := fmt.Sprintf("start upgrading endpoint from version %s to %s", previousVersion, targetVersionStr)
+	log.Info().Str("endpoint uuid", uuid).Msg(message)
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, message, entity.EventLogLevelInfo)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := func() error {
+			if err := mgr.Upgrade(); err != nil {
+				return errors.Wrapf(err, "failed to upgrade kubefate")
+			}
+			kfc, closer, err := mgr.BuildPFClient()
+			if closer != nil {
+				defer closer()
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to get kubefate client")
+			}
+			versionStr, err := kfc.CheckVersion()
+			if err != nil {
+				return errors.Wrapf(err, "failed to get kubefate version")
+			}
+			endpoint.Version = versionStr
+			endpoint.Status = entity.EndpointStatusReady
+			if err := s.EndpointKubeFATERepo.UpdateInfoByUUID(endpoint); err != nil {
+				return errors.Wrapf(err, "failed to update endpoint info")
+			}
+			endpoint.DeploymentYAML = yaml
+			if err := s.EndpointKubeFATERepo.UpdateDeploymentYAMLByUUID(endpoint); err != nil {
+				return errors.Wrapf(err, "failed to update endpoint deployment yaml")
+			}
+			return nil
+		}()
+		if err != nil {
+			message := "error upgrading kubefate endpoint"
+			log.Err(err).Str("endpoint uuid", uuid).Msg(message)
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, errors.Wrapf(err, message).Error(), entity.EventLogLevelError)
+			endpoint.Status = entity.EndpointStatusUnavailable
+			if err := s.EndpointKubeFATERepo.UpdateStatusByUUID(endpoint); err != nil {
+				log.Err(err).Str("endpoint uuid", uuid).Msg("failed to update endpoint status")
+			}
+			return
+		}
+		message := fmt.Sprintf("KubeFATE upgraded to version %s without error", endpoint.Version)
+		log.Info().Str("endpoint uuid", uuid).Msg(message)
+		_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeEndpoint, uuid, message, entity.EventLogLevelInfo)
+	}()
+	return wg, nil
+}
+
+// checkNoOngoingParticipantOperations makes sure no participant is being deployed via the endpoint, as the operations
+// would be interrupted when the KubeFATE service restarts
+func (s *EndpointService) checkNoOngoingParticipantOperations(uuid string) error {
+	instanceList, err := s.ParticipantFATERepo.ListByEndpointUUID(uuid)
+	if err != nil {
+		return errors.Wrap(err, "failed to query endpoint participants")
+	}
+	for _, participant := range instanceList.([]entity.ParticipantFATE) {
+		switch participant.Status {
+		case entity.ParticipantFATEStatusInstalling, entity.ParticipantFATEStatusRemoving,
+			entity.ParticipantFATEStatusUpgrading, entity.ParticipantFATEStatusReconfiguring:
+			return errors.Errorf("participant %s is in %v status", participant.Name, participant.Status)
+		}
+	}
+	instanceListOpenFL, err := s.ParticipantOpenFLRepo.ListByEndpointUUID(uuid)
+	if err != nil {
+		return errors.Wrap(err, "failed to query endpoint participants")
+	}
+	for _, participant := range instanceListOpenFL.([]entity.ParticipantOpenFL) {
+		switch participant.Status {
+		case entity.ParticipantOpenFLStatusActive, entity.ParticipantOpenFLStatusFailed, entity.ParticipantOpenFLStatusUnknown:
+		default:
+			return errors.Errorf("OpenFL participant %s is in %v status", participant.Name, participant.Status)
+		}
+	}
+	return nil
+}
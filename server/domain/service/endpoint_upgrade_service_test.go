@@ -0,0 +1,253 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubefate"
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	appv1 "k8s.io/api/apps/v1"
+)
+
+func TestUpgradeKubeFATEEndpoint(t *testing.T) {
+	upgraded := false
+	mgr := &mockKubeFATEManager{
+		GetKubeFATEDeploymentFn: func() (*appv1.Deployment, error) {
+			return &appv1.Deployment{}, nil
+		},
+		UpgradeFn: func() error {
+			upgraded = true
+			return nil
+		},
+	}
+	originalNewK8sClientFn, originalNewKubeFATEMgrFn := newK8sClientFn, newKubeFATEMgrFn
+	defer func() {
+		newK8sClientFn, newKubeFATEMgrFn = originalNewK8sClientFn, originalNewKubeFATEMgrFn
+	}()
+	newK8sClientFn = func(string, string, bool) (kubernetes.Client, error) {
+		return &mockK8sClient{}, nil
+	}
+	newKubeFATEMgrFn = func(kubernetes.Client, *kubefate.InstallationMeta) kubefate.Manager {
+		return mgr
+	}
+
+	installedYAML, err := (&EndpointService{}).GetDeploymentYAML("", "test-user", "test-password", "test.kubefate.net", valueobject.KubeRegistryConfig{
+		UseRegistry: true,
+		Registry:    "registry.example.com/federatedai",
+	})
+	assert.NoError(t, err)
+	endpointRepo := &mockRecordingEndpointKubeFATERepo{
+		endpoint: &entity.EndpointKubeFATE{
+			EndpointBase: entity.EndpointBase{
+				UUID:    "test-endpoint",
+				Version: "1.4.4",
+				Type:    entity.EndpointTypeKubeFATE,
+				Status:  entity.EndpointStatusReady,
+			},
+			Config: entity.KubeFATEConfig{
+				IngressRuleHost: "test.kubefate.net",
+			},
+			DeploymentYAML: strings.ReplaceAll(installedYAML, "kubefate:v1.4.5", "kubefate:v1.4.4"),
+		},
+	}
+	service := &EndpointService{
+		InfraProviderKubernetesRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		EndpointKubeFATERepo: endpointRepo,
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantFATE{}, nil
+			},
+		},
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantOpenFL{}, nil
+			},
+		},
+		EventService: &mockRecordingEventServiceInt{},
+	}
+
+	wg, err := service.UpgradeKubeFATEEndpoint("test-endpoint", "")
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.True(t, upgraded)
+	assert.Equal(t, []entity.EndpointStatus{entity.EndpointStatusUpgrading, entity.EndpointStatusReady}, endpointRepo.statuses)
+	// the version is the one returned by the mocked KubeFATE client
+	assert.Equal(t, "1.4.2", endpointRepo.endpoint.Version)
+	// only the image tag is changed in the installed yaml
+	assert.Equal(t, installedYAML, endpointRepo.endpoint.DeploymentYAML)
+	assert.Contains(t, endpointRepo.endpoint.DeploymentYAML, "registry.example.com/federatedai/kubefate:v1.4.5")
+	assert.Contains(t, endpointRepo.endpoint.DeploymentYAML, "test-password")
+}
+
+func TestUpgradeKubeFATEEndpoint_Failed(t *testing.T) {
+	mgr := &mockKubeFATEManager{
+		GetKubeFATEDeploymentFn: func() (*appv1.Deployment, error) {
+			return &appv1.Deployment{}, nil
+		},
+		UpgradeFn: func() error {
+			return errors.New("test error")
+		},
+	}
+	originalNewK8sClientFn, originalNewKubeFATEMgrFn := newK8sClientFn, newKubeFATEMgrFn
+	defer func() {
+		newK8sClientFn, newKubeFATEMgrFn = originalNewK8sClientFn, originalNewKubeFATEMgrFn
+	}()
+	newK8sClientFn = func(string, string, bool) (kubernetes.Client, error) {
+		return &mockK8sClient{}, nil
+	}
+	newKubeFATEMgrFn = func(kubernetes.Client, *kubefate.InstallationMeta) kubefate.Manager {
+		return mgr
+	}
+
+	endpointRepo := &mockRecordingEndpointKubeFATERepo{
+		endpoint: &entity.EndpointKubeFATE{
+			EndpointBase: entity.EndpointBase{
+				UUID:    "test-endpoint",
+				Version: "1.4.4",
+				Type:    entity.EndpointTypeKubeFATE,
+				Status:  entity.EndpointStatusReady,
+			},
+			Config: entity.KubeFATEConfig{
+				IngressRuleHost: "test.kubefate.net",
+			},
+		},
+	}
+	events := &mockRecordingEventServiceInt{}
+	service := &EndpointService{
+		InfraProviderKubernetesRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		EndpointKubeFATERepo: endpointRepo,
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantFATE{}, nil
+			},
+		},
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByEndpointUUIDFn: func(string) (interface{}, error) {
+				return []entity.ParticipantOpenFL{}, nil
+			},
+		},
+		EventService: events,
+	}
+	defaultYAML, err := service.GetDeploymentYAML("", "admin", "admin", "kubefate.net", valueobject.KubeRegistryConfig{})
+	assert.NoError(t, err)
+
+	wg, err := service.UpgradeKubeFATEEndpoint("test-endpoint", defaultYAML)
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, entity.EndpointStatusUnavailable, endpointRepo.endpoint.Status)
+	assert.Empty(t, endpointRepo.endpoint.DeploymentYAML)
+	assert.Contains(t, events.descriptions[len(events.descriptions)-1], "test error")
+}
+
+func TestUpgradeKubeFATEEndpoint_Rejected(t *testing.T) {
+	defaultYAML, err := (&EndpointService{}).GetDeploymentYAML("", "admin", "admin", "kubefate.net", valueobject.KubeRegistryConfig{})
+	assert.NoError(t, err)
+	version, err := kubefate.GetVersionFromYAML(defaultYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, kubefate.GetDefaultVersion(), version)
+
+	tests := []struct {
+		name         string
+		version      string
+		status       entity.EndpointStatus
+		participants []entity.ParticipantFATE
+		yaml         string
+		wantErr      string
+	}{
+		{
+			name:    "no recorded yaml",
+			version: "1.4.4",
+			status:  entity.EndpointStatusReady,
+			yaml:    "",
+			wantErr: "no recorded deployment yaml",
+		},
+		{
+			name:    "incompatible version",
+			version: "1.4.4",
+			status:  entity.EndpointStatusReady,
+			yaml:    strings.ReplaceAll(defaultYAML, "kubefate:v1.4.5", "kubefate:v1.4.0"),
+			wantErr: "not compatible",
+		},
+		{
+			name:    "downgrade",
+			version: "v1.5.0",
+			status:  entity.EndpointStatusReady,
+			yaml:    defaultYAML,
+			wantErr: "cannot downgrade",
+		},
+		{
+			name:    "ongoing participant operation",
+			version: "1.4.4",
+			status:  entity.EndpointStatusReady,
+			participants: []entity.ParticipantFATE{{
+				Participant: entity.Participant{Name: "test-cluster"},
+				Status:      entity.ParticipantFATEStatusInstalling,
+			}},
+			yaml:    defaultYAML,
+			wantErr: "test-cluster",
+		},
+		{
+			name:    "endpoint in creating status",
+			version: "1.4.4",
+			status:  entity.EndpointStatusCreating,
+			yaml:    defaultYAML,
+			wantErr: "cannot upgrade endpoint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &EndpointService{
+				EndpointKubeFATERepo: &mockRecordingEndpointKubeFATERepo{
+					endpoint: &entity.EndpointKubeFATE{
+						EndpointBase: entity.EndpointBase{
+							UUID:    "test-endpoint",
+							Version: tt.version,
+							Type:    entity.EndpointTypeKubeFATE,
+							Status:  tt.status,
+						},
+					},
+				},
+				ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+					ListByEndpointUUIDFn: func(string) (interface{}, error) {
+						return tt.participants, nil
+					},
+				},
+				ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+					ListByEndpointUUIDFn: func(string) (interface{}, error) {
+						return []entity.ParticipantOpenFL{}, nil
+					},
+				},
+				EventService: &mockRecordingEventServiceInt{},
+			}
+			_, err := service.UpgradeKubeFATEEndpoint("test-endpoint", tt.yaml)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+)
+
+// mockRecordingEndpointKubeFATERepo stores a single KubeFATE endpoint and records its status changes
+type mockRecordingEndpointKubeFATERepo struct {
+	mock.EndpointKubeFATERepoMock
+	endpoint *entity.EndpointKubeFATE
+	statuses []entity.EndpointStatus
+}
+
+func (m *mockRecordingEndpointKubeFATERepo) List() (interface{}, error) {
+	return []entity.EndpointKubeFATE{*m.endpoint}, nil
+}
+
+func (m *mockRecordingEndpointKubeFATERepo) GetByUUID(string) (interface{}, error) {
+	endpoint := *m.endpoint
+	return &endpoint, nil
+}
+
+func (m *mockRecordingEndpointKubeFATERepo) UpdateStatusByUUID(instance interface{}) error {
+	m.endpoint.Status = instance.(*entity.EndpointKubeFATE).Status
+	m.statuses = append(m.statuses, m.endpoint.Status)
+	return nil
+}
+
+func (m *mockRecordingEndpointKubeFATERepo) UpdateInfoByUUID(instance interface{}) error {
+	updated := instance.(*entity.EndpointKubeFATE)
+	m.endpoint.Version = updated.Version
+	m.endpoint.Status = updated.Status
+	m.statuses = append(m.statuses, m.endpoint.Status)
+	return nil
+}
+
+func (m *mockRecordingEndpointKubeFATERepo) UpdateDeploymentYAMLByUUID(instance interface{}) error {
+	m.endpoint.DeploymentYAML = instance.(*entity.EndpointKubeFATE).DeploymentYAML
+	return nil
+}
+
+var _ repo.EndpointRepository = (*mockRecordingEndpointKubeFATERepo)(nil)
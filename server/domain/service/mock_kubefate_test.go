@@ -22,6 +22,7 @@ import (
 )
 
 type mockKubeFATEClient struct {
+	clusterList []*modules.Cluster
 }
 
 func (m *mockKubeFATEClient) CheckVersion() (string, error) {
@@ -36,6 +37,10 @@ func (m *mockKubeFATEClient) ListClusterByNamespace(string) ([]*modules.Cluster,
 	return nil, nil
 }
 
+func (m *mockKubeFATEClient) ListClusters() ([]*modules.Cluster, error) {
+	return m.clusterList, nil
+}
+
 func (m *mockKubeFATEClient) SubmitClusterInstallationJob(string) (string, error) {
 	return "test-job-id", nil
 }
@@ -81,9 +86,15 @@ func (m *mockKubeFATEClient) StopJob(string) error {
 var _ kubefate.Client = (*mockKubeFATEClient)(nil) // TODO: add stubs
 
 type mockKubeFATEManager struct {
-	InstallFn   func() error
-	UninstallFn func() error
-	K8sClientFn func() kubernetes.Client
+	InstallFn                             func() error
+	UpgradeFn                             func() error
+	UninstallFn                           func() error
+	K8sClientFn                           func() kubernetes.Client
+	InstallIngressNginxControllerFn       func() error
+	GetKubeFATEDeploymentFn               func() (*appv1.Deployment, error)
+	GetIngressNginxControllerDeploymentFn func() (*appv1.Deployment, error)
+	// ClusterList is returned by the built client when listing the KubeFATE clusters
+	ClusterList []*modules.Cluster
 }
 
 func (m *mockKubeFATEManager) Install(bool) error {
@@ -93,6 +104,13 @@ func (m *mockKubeFATEManager) Install(bool) error {
 	return nil
 }
 
+func (m *mockKubeFATEManager) Upgrade() error {
+	if m.UpgradeFn != nil {
+		return m.UpgradeFn()
+	}
+	return nil
+}
+
 func (m *mockKubeFATEManager) K8sClient() kubernetes.Client {
 	if m.K8sClientFn != nil {
 		return m.K8sClientFn()
@@ -108,7 +126,7 @@ func (m *mockKubeFATEManager) Uninstall() error {
 }
 
 func (m *mockKubeFATEManager) BuildClient() (kubefate.Client, error) {
-	return &mockKubeFATEClient{}, nil
+	return &mockKubeFATEClient{clusterList: m.ClusterList}, nil
 }
 
 func (m *mockKubeFATEManager) BuildPFClient() (kubefate.Client, func(), error) {
@@ -116,10 +134,23 @@ func (m *mockKubeFATEManager) BuildPFClient() (kubefate.Client, func(), error) {
 }
 
 func (m *mockKubeFATEManager) InstallIngressNginxController() error {
+	if m.InstallIngressNginxControllerFn != nil {
+		return m.InstallIngressNginxControllerFn()
+	}
 	return nil
 }
 
 func (m *mockKubeFATEManager) GetKubeFATEDeployment() (*appv1.Deployment, error) {
+	if m.GetKubeFATEDeploymentFn != nil {
+		return m.GetKubeFATEDeploymentFn()
+	}
+	return nil, nil
+}
+
+func (m *mockKubeFATEManager) GetIngressNginxControllerDeployment() (*appv1.Deployment, error) {
+	if m.GetIngressNginxControllerDeploymentFn != nil {
+		return m.GetIngressNginxControllerDeploymentFn()
+	}
 	return nil, nil
 }
 
@@ -26,6 +26,8 @@ import (
 
 type mockParticipantFATECertificateServiceInt struct {
 	// TODO add stubs
+	// DeletedBindings are the participants whose bindings are deleted with DeleteBinding
+	DeletedBindings []string
 }
 
 func (m *mockParticipantFATECertificateServiceInt) DefaultCA() (*entity.CertificateAuthority, error) {
@@ -54,6 +56,17 @@ func (m *mockParticipantFATECertificateServiceInt) CreateCertificateSimple(strin
 	return
 }
 
+func (m *mockParticipantFATECertificateServiceInt) GetImportedCertificate(string, []string, x509.ExtKeyUsage) (*entity.Certificate, *rsa.PrivateKey, error) {
+	return m.CreateCertificateSimple("", 0, nil)
+}
+
+func (m *mockParticipantFATECertificateServiceInt) GetCertificateBySerialNumber(serialNumber string) (*entity.Certificate, error) {
+	cert, _, _ := m.CreateCertificateSimple("", 0, nil)
+	cert.UUID = "test-cert-" + serialNumber
+	cert.SerialNumberStr = serialNumber
+	return cert, nil
+}
+
 func (m *mockParticipantFATECertificateServiceInt) CreateBinding(*entity.Certificate, entity.CertificateBindingServiceType, string, string, entity.FederationType) error {
 	return nil
 }
@@ -62,4 +75,9 @@ func (m *mockParticipantFATECertificateServiceInt) RemoveBinding(string) error {
 	return nil
 }
 
+func (m *mockParticipantFATECertificateServiceInt) DeleteBinding(participantUUID string) error {
+	m.DeletedBindings = append(m.DeletedBindings, participantUUID)
+	return nil
+}
+
 var _ ParticipantCertificateServiceInt = (*mockParticipantFATECertificateServiceInt)(nil)
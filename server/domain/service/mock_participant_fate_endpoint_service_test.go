@@ -17,22 +17,29 @@ package service
 import (
 	"github.com/FederatedAI/FedLCM/pkg/kubefate"
 	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
 )
 
 type mockParticipantFATEEndpointServiceInt struct {
 	// TODO add stubs
+	// ClusterList is returned by the KubeFATE client of the endpoints
+	ClusterList []*modules.Cluster
 }
 
 func (m *mockParticipantFATEEndpointServiceInt) ensureEndpointExist(string, string, valueobject.KubeRegistryConfig) (string, error) {
 	return "", nil
 }
 
+func (m *mockParticipantFATEEndpointServiceInt) getInfraProviderUUID(string) (string, error) {
+	return "test-infra", nil
+}
+
 func (m *mockParticipantFATEEndpointServiceInt) TestKubeFATE(string) error {
 	return nil
 }
 
 func (m *mockParticipantFATEEndpointServiceInt) buildKubeFATEClientManagerFromEndpointUUID(string) (kubefate.ClientManager, error) {
-	return &mockKubeFATEManager{}, nil
+	return &mockKubeFATEManager{ClusterList: m.ClusterList}, nil
 }
 
 var _ ParticipantEndpointServiceInt = (*mockParticipantFATEEndpointServiceInt)(nil)
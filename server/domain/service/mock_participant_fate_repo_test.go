@@ -0,0 +1,94 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testExchangeDeploymentYAML = `chartName: fate-exchange
+chartVersion: v1.10.0-fedlcm-v0.3.0
+modules:
+- trafficServer
+- nginx
+name: test-exchange
+namespace: test-ns
+nginx:
+  route_table: null
+  type: NodePort
+partyId: 0
+trafficServer:
+  route_table:
+    sni: null
+  type: NodePort`
+
+// mockParticipantFATEListRepo makes the participant queries of fateRepo return the participants in participantList,
+// with the first one being the default exchange of the federation
+func mockParticipantFATEListRepo(participantList []entity.ParticipantFATE, fateRepo *mock.ParticipantFATERepoMock) *mock.ParticipantFATERepoMock {
+	fateRepo.ListByFederationUUIDFn = func(uuid string) (interface{}, error) {
+		return participantList, nil
+	}
+	fateRepo.GetExchangeByFederationUUIDFn = func(uuid string) (interface{}, error) {
+		return &participantList[0], nil
+	}
+	fateRepo.GetByUUIDFn = func(uuid string) (interface{}, error) {
+		for index := range participantList {
+			if participantList[index].UUID == uuid {
+				return &participantList[index], nil
+			}
+		}
+		return nil, assert.AnError
+	}
+	return fateRepo
+}
+
+func testFATEExchange(uuid, host string, isManaged bool) entity.ParticipantFATE {
+	return entity.ParticipantFATE{
+		Participant: entity.Participant{
+			UUID:           uuid,
+			Name:           uuid,
+			FederationUUID: "test-federation",
+			DeploymentYAML: testExchangeDeploymentYAML,
+			IsManaged:      isManaged,
+		},
+		Type:   entity.ParticipantFATETypeExchange,
+		Status: entity.ParticipantFATEStatusActive,
+		AccessInfo: entity.ParticipantFATEModulesAccessMap{
+			entity.ParticipantFATEServiceNameNginx: {Host: host, Port: 9300},
+			entity.ParticipantFATEServiceNameATS:   {Host: host, Port: 443},
+		},
+	}
+}
+
+func testFATECluster(uuid string, partyID int, exchangeUUID, standbyExchangeUUID string) entity.ParticipantFATE {
+	return entity.ParticipantFATE{
+		Participant: entity.Participant{
+			UUID:           uuid,
+			Name:           uuid,
+			FederationUUID: "test-federation",
+		},
+		Type:    entity.ParticipantFATETypeCluster,
+		PartyID: partyID,
+		Status:  entity.ParticipantFATEStatusActive,
+		AccessInfo: entity.ParticipantFATEModulesAccessMap{
+			entity.ParticipantFATEServiceNameNginx:  {Host: uuid + "-host", Port: 9300},
+			entity.ParticipantFATEServiceNamePulsar: {Host: uuid + "-host", Port: 6651, FQDN: uuid + ".example.com"},
+		},
+		ExchangeUUID:        exchangeUUID,
+		StandbyExchangeUUID: standbyExchangeUUID,
+	}
+}
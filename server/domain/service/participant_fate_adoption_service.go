@@ -0,0 +1,500 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ParticipantFATEAdoptionCandidate is a FATE cluster deployed by a KubeFATE service that FedLCM may adopt
+type ParticipantFATEAdoptionCandidate struct {
+	ClusterUUID  string                        `json:"cluster_uuid"`
+	Name         string                        `json:"name"`
+	Namespace    string                        `json:"namespace"`
+	ChartName    string                        `json:"chart_name"`
+	ChartVersion string                        `json:"chart_version"`
+	ChartUUID    string                        `json:"chart_uuid"`
+	Status       string                        `json:"status"`
+	PartyID      int                           `json:"party_id"`
+	Backend      entity.ParticipantFATEBackend `json:"backend"`
+	Adoptable    bool                          `json:"adoptable"`
+	Reason       string                        `json:"reason"`
+}
+
+// ParticipantFATEAdoptionRequest is the request to adopt an existing KubeFATE-deployed FATE cluster as a managed
+// cluster of a federation
+type ParticipantFATEAdoptionRequest struct {
+	FederationUUID      string `json:"federation_uuid"`
+	EndpointUUID        string `json:"endpoint_uuid"`
+	ClusterUUID         string `json:"cluster_uuid"`
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	ExchangeUUID        string `json:"exchange_uuid"`
+	StandbyExchangeUUID string `json:"standby_exchange_uuid"`
+}
+
+// ScanAdoptableClusters lists the FATE clusters deployed by the KubeFATE service of the endpoint and checks whether
+// each of them can be adopted
+func (s *ParticipantFATEService) ScanAdoptableClusters(endpointUUID string) ([]ParticipantFATEAdoptionCandidate, error) {
+	clusterList, err := s.listKubeFATEClusters(endpointUUID)
+	if err != nil {
+		return nil, err
+	}
+	adoptedMap, err := s.getAdoptedClusterMap(endpointUUID)
+	if err != nil {
+		return nil, err
+	}
+	candidateList := make([]ParticipantFATEAdoptionCandidate, 0)
+	for _, cluster := range clusterList {
+		if cluster.ChartName != "fate" {
+			continue
+		}
+		candidateList = append(candidateList, s.inspectAdoptionCandidate(cluster, adoptedMap))
+	}
+	return candidateList, nil
+}
+
+// AdoptCluster creates a managed cluster record for an existing KubeFATE-deployed FATE cluster, rebuilds its access
+// info, ingress info and certificate bindings from the running deployment, and connects it with the federation
+func (s *ParticipantFATEService) AdoptCluster(req *ParticipantFATEAdoptionRequest) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	federation, err := s.loadFATEFederation(req.FederationUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.EndpointService.TestKubeFATE(req.EndpointUUID); err != nil {
+		return nil, nil, err
+	}
+	clusterList, err := s.listKubeFATEClusters(req.EndpointUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var kfCluster *modules.Cluster
+	for _, cluster := range clusterList {
+		if cluster.Uuid == req.ClusterUUID {
+			kfCluster = cluster
+			break
+		}
+	}
+	if kfCluster == nil || kfCluster.ChartName != "fate" {
+		return nil, nil, errors.Errorf("FATE cluster %s is not found in the KubeFATE service of endpoint %s", req.ClusterUUID, req.EndpointUUID)
+	}
+	adoptedMap, err := s.getAdoptedClusterMap(req.EndpointUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	candidate := s.inspectAdoptionCandidate(kfCluster, adoptedMap)
+	if !candidate.Adoptable {
+		return nil, nil, errors.Errorf("cluster %s cannot be adopted: %s", kfCluster.Name, candidate.Reason)
+	}
+	if err := s.CheckPartyIDConflict(req.FederationUUID, candidate.PartyID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.validateFederationBackend(req.FederationUUID, candidate.Backend); err != nil {
+		return nil, nil, err
+	}
+	exchange, err := s.getClusterCreationExchange(req.FederationUUID, federation.Mode, req.ExchangeUUID, req.StandbyExchangeUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	deploymentYAML := kfCluster.Values
+	if exchange != nil {
+		req.ExchangeUUID = exchange.UUID
+		if err := validateExchangeBackendAccess(exchange, candidate.Backend); err != nil {
+			return nil, nil, err
+		}
+		if deploymentYAML, err = setClusterExchangeInYAML(deploymentYAML, exchange); err != nil {
+			return nil, nil, err
+		}
+	}
+	if deploymentYAML, err = s.setClusterDirectRoutesInYAML(deploymentYAML, federation, candidate.Backend); err != nil {
+		return nil, nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = kfCluster.Name
+	}
+	skipped := entity.ParticipantComponentCertInfo{BindingMode: entity.CertBindingModeSkip}
+	cluster := &entity.ParticipantFATE{
+		Participant: entity.Participant{
+			UUID:           uuid.NewV4().String(),
+			Name:           name,
+			Description:    req.Description,
+			FederationUUID: req.FederationUUID,
+			EndpointUUID:   req.EndpointUUID,
+			ChartUUID:      candidate.ChartUUID,
+			Namespace:      kfCluster.NameSpace,
+			ClusterUUID:    kfCluster.Uuid,
+			DeploymentYAML: deploymentYAML,
+			IsManaged:      true,
+			ExtraAttribute: entity.ParticipantExtraAttribute{
+				// the namespace is not created by FedLCM so it will be kept when the cluster is removed
+				IsNewNamespace: false,
+			},
+		},
+		Type:       entity.ParticipantFATETypeCluster,
+		PartyID:    candidate.PartyID,
+		Status:     entity.ParticipantFATEStatusReconfiguring,
+		AccessInfo: entity.ParticipantFATEModulesAccessMap{},
+		CertConfig: entity.ParticipantFATECertConfig{
+			PulsarServerCertInfo:     skipped,
+			SitePortalServerCertInfo: skipped,
+			SitePortalClientCertInfo: skipped,
+		},
+		ExchangeUUID:        req.ExchangeUUID,
+		StandbyExchangeUUID: req.StandbyExchangeUUID,
+		Backend:             candidate.Backend,
+	}
+	if err := setClusterRabbitMQPassword(cluster); err != nil {
+		return nil, nil, err
+	}
+	if err := s.ParticipantFATERepo.Create(cluster); err != nil {
+		return nil, nil, err
+	}
+
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, "start adopting cluster", entity.EventLogLevelInfo)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := log.Logger.With().Timestamp().Str("action", "adopting fate cluster").Str("uuid", cluster.UUID).Logger().
+			Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+				eventLvl := entity.EventLogLevelInfo
+				if level == zerolog.ErrorLevel {
+					eventLvl = entity.EventLogLevelError
+				}
+				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, message, eventLvl)
+			}))
+		operationLog.Info().Msgf("adopting KubeFATE cluster %s(%s) in namespace %s", kfCluster.Name, kfCluster.Uuid, kfCluster.NameSpace)
+		if err := func() error {
+			endpointMgr, kfClient, closer, err := s.buildKubeFATEMgrAndClient(cluster.EndpointUUID)
+			if closer != nil {
+				defer closer()
+			}
+			if err != nil {
+				return err
+			}
+			if err := s.adoptClusterAccessInfo(endpointMgr.K8sClient(), cluster, federation); err != nil {
+				return err
+			}
+			operationLog.Info().Msgf("rebuilt access info of %d services", len(cluster.AccessInfo))
+			if err := s.adoptClusterCertificates(endpointMgr.K8sClient(), cluster, &operationLog); err != nil {
+				return err
+			}
+			if err := s.BuildIngressInfoMap(cluster); err != nil {
+				return errors.Wrapf(err, "failed to get ingress info")
+			}
+			if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+				return errors.Wrap(err, "failed to save cluster info")
+			}
+
+			if cluster.DeploymentYAML != kfCluster.Values {
+				operationLog.Info().Msg("connecting the cluster with the federation")
+				jobUUID, err := kfClient.SubmitClusterUpdateJob(cluster.DeploymentYAML)
+				if err != nil {
+					return errors.Wrapf(err, "failed to submit cluster update job")
+				}
+				cluster.JobUUID = jobUUID
+				if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+					return errors.Wrap(err, "failed to update cluster's job uuid")
+				}
+				operationLog.Info().Msgf("KubeFATE update job uuid: %s", jobUUID)
+				if job, err := kfClient.WaitJob(jobUUID); err != nil {
+					return errors.Wrapf(err, "failed to query cluster update job status")
+				} else if job.Status != modules.JobStatusSuccess {
+					return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
+				}
+			}
+
+			cluster.Status = entity.ParticipantFATEStatusActive
+			if err := s.ParticipantFATERepo.UpdateStatusByUUID(cluster); err != nil {
+				return errors.Wrap(err, "failed to update cluster status")
+			}
+			operationLog.Info().Msg("rebuilding exchange route tables")
+			if err := s.rebuildFederationRouteTables(cluster.FederationUUID); err != nil {
+				operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while adopting cluster").Error())
+			}
+			return nil
+		}(); err != nil {
+			operationLog.Error().Msgf(errors.Wrap(err, "failed to adopt FATE cluster").Error())
+			s.abandonAdoptedCluster(cluster, &operationLog)
+			return
+		}
+		operationLog.Info().Msgf("FATE cluster %s(%s) adopted", cluster.Name, cluster.UUID)
+	}()
+	return cluster, wg, nil
+}
+
+// abandonAdoptedCluster releases a cluster whose adoption failed: its certificate bindings and its record are deleted,
+// so that the KubeFATE cluster is left to its owner instead of being uninstalled by a later removal of the record. The
+// certificates are kept as they are still used by the cluster. If the record can't be deleted, it is marked as failed
+// and not managed by FedLCM.
+func (s *ParticipantFATEService) abandonAdoptedCluster(cluster *entity.ParticipantFATE, operationLog *zerolog.Logger) {
+	if err := s.CertificateService.DeleteBinding(cluster.UUID); err != nil {
+		operationLog.Error().Msgf(errors.Wrap(err, "failed to delete the certificate bindings of the cluster").Error())
+	}
+	deleteErr := s.ParticipantFATERepo.DeleteByUUID(cluster.UUID)
+	if deleteErr == nil {
+		operationLog.Info().Msgf("KubeFATE cluster %s is released and can be adopted again", cluster.ClusterUUID)
+		return
+	}
+	operationLog.Error().Msgf(errors.Wrap(deleteErr, "failed to delete the cluster record").Error())
+	cluster.Status = entity.ParticipantFATEStatusFailed
+	cluster.IsManaged = false
+	if updateErr := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); updateErr != nil {
+		operationLog.Error().Msgf(errors.Wrap(updateErr, "failed to update FATE cluster info").Error())
+	}
+}
+
+// listKubeFATEClusters returns all the clusters deployed by the KubeFATE service of the endpoint
+func (s *ParticipantFATEService) listKubeFATEClusters(endpointUUID string) ([]*modules.Cluster, error) {
+	_, kfClient, closer, err := s.buildKubeFATEMgrAndClient(endpointUUID)
+	if closer != nil {
+		defer closer()
+	}
+	if err != nil {
+		return nil, err
+	}
+	clusterList, err := kfClient.ListClusters()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list KubeFATE clusters")
+	}
+	return clusterList, nil
+}
+
+// getAdoptedClusterMap returns the names of the participants deployed via the endpoint, keyed by their KubeFATE
+// cluster uuid
+func (s *ParticipantFATEService) getAdoptedClusterMap(endpointUUID string) (map[string]string, error) {
+	instanceList, err := s.ParticipantFATERepo.ListByEndpointUUID(endpointUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list participants of the endpoint")
+	}
+	adoptedMap := map[string]string{}
+	participantList, _ := instanceList.([]entity.ParticipantFATE)
+	for _, participant := range participantList {
+		if participant.ClusterUUID != "" {
+			adoptedMap[participant.ClusterUUID] = participant.Name
+		}
+	}
+	return adoptedMap, nil
+}
+
+// inspectAdoptionCandidate reads the deployment yaml and chart of the KubeFATE cluster and checks whether it can be
+// adopted
+func (s *ParticipantFATEService) inspectAdoptionCandidate(cluster *modules.Cluster, adoptedMap map[string]string) ParticipantFATEAdoptionCandidate {
+	candidate := ParticipantFATEAdoptionCandidate{
+		ClusterUUID:  cluster.Uuid,
+		Name:         cluster.Name,
+		Namespace:    cluster.NameSpace,
+		ChartName:    cluster.ChartName,
+		ChartVersion: cluster.ChartVersion,
+		Status:       cluster.Status.String(),
+	}
+	var values struct {
+		PartyID int `json:"partyId"`
+	}
+	if err := yaml.Unmarshal([]byte(cluster.Values), &values); err != nil {
+		candidate.Reason = fmt.Sprintf("failed to parse the deployment yaml: %v", err)
+		return candidate
+	}
+	candidate.PartyID = values.PartyID
+	backend, err := getClusterBackendFromYAML(cluster.Values)
+	if err != nil {
+		candidate.Reason = err.Error()
+		return candidate
+	}
+	candidate.Backend = backend
+	if instance, err := s.ChartRepo.GetByNameAndVersion(cluster.ChartName, cluster.ChartVersion); err != nil {
+		candidate.Reason = fmt.Sprintf("chart %s version %s is not available in FedLCM", cluster.ChartName, cluster.ChartVersion)
+		return candidate
+	} else {
+		chart := instance.(*entity.Chart)
+		if chart.Type != entity.ChartTypeFATECluster {
+			candidate.Reason = fmt.Sprintf("chart %s is not for FATE cluster deployment", chart.UUID)
+			return candidate
+		}
+		candidate.ChartUUID = chart.UUID
+	}
+	if name, ok := adoptedMap[cluster.Uuid]; ok {
+		candidate.Reason = fmt.Sprintf("the cluster is already managed as participant %s", name)
+		return candidate
+	}
+	if cluster.Status != modules.ClusterStatusRunning {
+		candidate.Reason = fmt.Sprintf("the cluster is in %s status, only running clusters can be adopted", cluster.Status.String())
+		return candidate
+	}
+	candidate.Adoptable = true
+	return candidate
+}
+
+// adoptClusterAccessInfo reads the exposed services of the adopted cluster and builds its access info
+func (s *ParticipantFATEService) adoptClusterAccessInfo(client kubernetes.Client, cluster *entity.ParticipantFATE, federation *entity.FederationFATE) error {
+	serviceType, host, port, err := getServiceAccess(client, cluster.Namespace, string(entity.ParticipantFATEServiceNameNginx), "http")
+	if err != nil {
+		return errors.Wrapf(err, "fail to get nginx access info")
+	}
+	cluster.AccessInfo[entity.ParticipantFATEServiceNameNginx] = entity.ParticipantModulesAccess{
+		ServiceType: serviceType,
+		Host:        host,
+		Port:        port,
+		TLS:         false,
+	}
+
+	switch cluster.Backend.OrDefault() {
+	case entity.ParticipantFATEBackendSparkRabbitMQ:
+		serviceType, host, port, err = getServiceAccessWithFallback(client, cluster.Namespace, string(entity.ParticipantFATEServiceNameRabbitMQ), "tcp-client", true)
+		if err != nil {
+			return errors.Wrapf(err, "fail to get rabbitmq access info")
+		}
+		cluster.AccessInfo[entity.ParticipantFATEServiceNameRabbitMQ] = entity.ParticipantModulesAccess{
+			ServiceType: serviceType,
+			Host:        host,
+			Port:        port,
+			TLS:         false,
+		}
+	case entity.ParticipantFATEBackendEggroll:
+		serviceType, host, port, err = getServiceAccessWithFallback(client, cluster.Namespace, string(entity.ParticipantFATEServiceNameRollsite), "tcp-grpc", true)
+		if err != nil {
+			return errors.Wrapf(err, "fail to get rollsite access info")
+		}
+		cluster.AccessInfo[entity.ParticipantFATEServiceNameRollsite] = entity.ParticipantModulesAccess{
+			ServiceType: serviceType,
+			Host:        host,
+			Port:        port,
+			TLS:         false,
+		}
+	case entity.ParticipantFATEBackendSparkPulsar:
+		pulsarDomain, err := getPulsarDomainFromYAML(cluster.DeploymentYAML)
+		if err != nil {
+			return err
+		}
+		if pulsarDomain == "" {
+			pulsarDomain = federation.Domain
+		}
+		pulsarAccess := entity.ParticipantModulesAccess{
+			TLS:  true,
+			FQDN: fmt.Sprintf("%d.%s", cluster.PartyID, pulsarDomain),
+		}
+		// clusters using an external pulsar don't have the pulsar-public-tls service
+		if pulsarAccess.ServiceType, pulsarAccess.Host, pulsarAccess.Port, err = getServiceAccessWithFallback(client, cluster.Namespace, string(entity.ParticipantFATEServiceNamePulsar), "tls-port", true); err != nil {
+			pulsarHost, pulsarSSLPort, yamlErr := getPulsarInformationFromYAML(cluster.DeploymentYAML)
+			if yamlErr != nil || pulsarHost == "" {
+				return errors.Wrapf(err, "fail to get pulsar access info")
+			}
+			pulsarAccess.ServiceType, pulsarAccess.Host, pulsarAccess.Port = "External", pulsarHost, pulsarSSLPort
+		}
+		cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar] = pulsarAccess
+	}
+
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(cluster.DeploymentYAML), &m); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	moduleList, _ := m["modules"].([]interface{})
+	for _, moduleName := range moduleList {
+		if moduleName == "frontend" {
+			serviceType, host, port, err := getServiceAccess(client, cluster.Namespace, string(entity.ParticipantFATEServiceNamePortal), "https-frontend")
+			if err != nil {
+				return errors.Wrapf(err, "fail to get site portal access info")
+			}
+			cluster.AccessInfo[entity.ParticipantFATEServiceNamePortal] = entity.ParticipantModulesAccess{
+				ServiceType: serviceType,
+				Host:        host,
+				Port:        port,
+				TLS:         true,
+				FQDN:        fmt.Sprintf("site-%v.server.%s", cluster.PartyID, federation.Domain),
+			}
+		}
+	}
+	return nil
+}
+
+// adoptClusterCertificates binds the certificates used by the adopted cluster if they are issued or imported by
+// FedLCM, other certificates are left unmanaged
+func (s *ParticipantFATEService) adoptClusterCertificates(client kubernetes.Client, cluster *entity.ParticipantFATE, operationLog *zerolog.Logger) error {
+	for _, item := range []struct {
+		certInfo    *entity.ParticipantComponentCertInfo
+		secretName  string
+		key         string
+		serviceType entity.CertificateBindingServiceType
+	}{
+		{&cluster.CertConfig.PulsarServerCertInfo, entity.ParticipantFATESecretNamePulsar, "broker.cert.pem", entity.CertificateBindingServiceTypePulsarServer},
+		{&cluster.CertConfig.SitePortalServerCertInfo, entity.ParticipantFATESecretNamePortal, "server.crt", entity.CertificateBindingServiceSitePortalServer},
+		{&cluster.CertConfig.SitePortalClientCertInfo, entity.ParticipantFATESecretNamePortal, "client.crt", entity.CertificateBindingServiceSitePortalClient},
+	} {
+		x509Cert, err := getSecretCertificate(client, cluster.Namespace, item.secretName, item.key)
+		if err != nil {
+			return err
+		}
+		if x509Cert == nil {
+			continue
+		}
+		cert, err := s.CertificateService.GetCertificateBySerialNumber(x509Cert.SerialNumber.String())
+		if err != nil {
+			operationLog.Warn().Msgf("certificate %s in secret %s is not issued or imported by FedLCM, it won't be managed: %v", item.key, item.secretName, err)
+			continue
+		}
+		if err := s.CertificateService.CreateBinding(cert, item.serviceType, cluster.UUID, cluster.FederationUUID, entity.FederationTypeFATE); err != nil {
+			return err
+		}
+		item.certInfo.BindingMode = entity.CertBindingModeCreate
+		if cert.Imported {
+			item.certInfo.BindingMode = entity.CertBindingModeReuse
+		}
+		item.certInfo.UUID = cert.UUID
+		item.certInfo.CommonName = x509Cert.Subject.CommonName
+		operationLog.Info().Msgf("bound certificate with serial number: %v for CN: %s", x509Cert.SerialNumber, x509Cert.Subject.CommonName)
+	}
+	return nil
+}
+
+// For mocking purpose
+var (
+	// getSecretCertificate returns the PEM-encoded certificate stored in the key of the secret, or nil if the secret or
+	// the key doesn't exist
+	getSecretCertificate = func(client kubernetes.Client, namespace, secretName, key string) (*x509.Certificate, error) {
+		secret, err := client.GetClientSet().CoreV1().Secrets(namespace).Get(context.TODO(), secretName, v1.GetOptions{})
+		if apierr.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to get secret %s", secretName)
+		}
+		data, ok := secret.Data[key]
+		if !ok {
+			return nil, nil
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.Errorf("key %s of secret %s does not contain a PEM-encoded certificate", key, secretName)
+		}
+		return x509.ParseCertificate(block.Bytes)
+	}
+)
@@ -0,0 +1,315 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testAdoptionClusterValues = `chartName: fate
+chartVersion: v1.11.1
+computing: Spark
+federation: Pulsar
+storage: HDFS
+modules:
+- python
+- mysql
+- spark
+- hdfs
+- nginx
+- pulsar
+name: existing-fate
+namespace: existing-fate-ns
+nginx:
+  type: NodePort
+partyId: 7777
+pulsar:
+  type: LoadBalancer
+`
+
+func TestScanAdoptableClusters(t *testing.T) {
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo([]entity.ParticipantFATE{testFATEExchange("exchange-a", "exchange-a-host", true)}, &mock.ParticipantFATERepoMock{
+			ListByEndpointUUIDFn: func(uuid string) (interface{}, error) {
+				adopted := testFATECluster("cluster-1", 9999, "", "")
+				adopted.ClusterUUID = "kf-adopted"
+				return []entity.ParticipantFATE{adopted}, nil
+			},
+		}),
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{},
+			ChartRepo:      &gorm.ChartMockRepo{},
+			EventService:   &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{
+				ClusterList: []*modules.Cluster{
+					{
+						Uuid:         "kf-running",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusRunning,
+					},
+					{
+						Uuid:         "kf-adopted",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusRunning,
+					},
+					{
+						Uuid:         "kf-unknown-chart",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v0.0.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusRunning,
+					},
+					{
+						Uuid:         "kf-unavailable",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusUnavailable,
+					},
+					{
+						Uuid:         "kf-exchange",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate-exchange",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusRunning,
+					},
+				},
+			},
+		},
+	}
+
+	candidateList, err := service.ScanAdoptableClusters("test-endpoint")
+	assert.NoError(t, err)
+	candidateMap := map[string]ParticipantFATEAdoptionCandidate{}
+	for _, candidate := range candidateList {
+		candidateMap[candidate.ClusterUUID] = candidate
+	}
+	assert.Len(t, candidateMap, 4, "only FATE clusters should be listed")
+	assert.True(t, candidateMap["kf-running"].Adoptable)
+	assert.Equal(t, 7777, candidateMap["kf-running"].PartyID)
+	assert.Equal(t, entity.ParticipantFATEBackendSparkPulsar, candidateMap["kf-running"].Backend)
+	assert.Equal(t, "d81d2b48-930d-4c5e-b522-322b93e8ef39", candidateMap["kf-running"].ChartUUID)
+	for _, clusterUUID := range []string{"kf-adopted", "kf-unknown-chart", "kf-unavailable"} {
+		assert.False(t, candidateMap[clusterUUID].Adoptable, clusterUUID)
+		assert.NotEmpty(t, candidateMap[clusterUUID].Reason, clusterUUID)
+	}
+}
+
+func TestAdoptCluster(t *testing.T) {
+	getServiceAccessWithFallback = func(client kubernetes.Client, namespace, serviceName, portName string, lbFallbackToNodePort bool) (serviceType corev1.ServiceType, host string, port int, err error) {
+		return corev1.ServiceTypeNodePort, serviceName + "-host", 30000, nil
+	}
+	getSecretCertificateOrig := getSecretCertificate
+	getSecretCertificate = func(client kubernetes.Client, namespace, secretName, key string) (*x509.Certificate, error) {
+		if secretName != entity.ParticipantFATESecretNamePulsar {
+			return nil, nil
+		}
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(1234),
+			Subject:      pkix.Name{CommonName: "7777.test.example.com"},
+		}, nil
+	}
+	defer func() {
+		getServiceAccessWithFallback = getServiceAccessWithFallbackOrig
+		getSecretCertificate = getSecretCertificateOrig
+	}()
+
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	var submittedCluster *entity.ParticipantFATE
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{
+			CreateFn: func(instance interface{}) error {
+				submittedCluster = instance.(*entity.ParticipantFATE)
+				return nil
+			},
+		}),
+		ParticipantService: ParticipantService{
+			FederationRepo:     &mock.FederationFATERepoMock{},
+			ChartRepo:          &gorm.ChartMockRepo{},
+			EventService:       &mockEventServiceInt{},
+			CertificateService: &mockParticipantFATECertificateServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{
+				ClusterList: []*modules.Cluster{
+					{
+						Uuid:         "kf-running",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusRunning,
+					},
+					{
+						Uuid:         "kf-unavailable",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusUnavailable,
+					},
+				},
+			},
+		},
+	}
+
+	_, _, err := service.AdoptCluster(&ParticipantFATEAdoptionRequest{
+		FederationUUID: "test-federation",
+		EndpointUUID:   "test-endpoint",
+		ClusterUUID:    "kf-unavailable",
+	})
+	assert.Error(t, err, "clusters not running cannot be adopted")
+
+	cluster, wg, err := service.AdoptCluster(&ParticipantFATEAdoptionRequest{
+		FederationUUID: "test-federation",
+		EndpointUUID:   "test-endpoint",
+		ClusterUUID:    "kf-running",
+	})
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Same(t, submittedCluster, cluster)
+	assert.Equal(t, entity.ParticipantFATEStatusActive, cluster.Status)
+	assert.True(t, cluster.IsManaged, "adopted cluster should be managed")
+	assert.Equal(t, "existing-fate", cluster.Name)
+	assert.Equal(t, "kf-running", cluster.ClusterUUID)
+	assert.Equal(t, "exchange-a", cluster.ExchangeUUID)
+	assert.Equal(t, 7777, cluster.PartyID)
+	assert.Equal(t, entity.ParticipantModulesAccess{ServiceType: corev1.ServiceTypeNodePort, Host: "nginx-host", Port: 30000},
+		cluster.AccessInfo[entity.ParticipantFATEServiceNameNginx])
+	assert.Equal(t, "7777.test.example.com", cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar].FQDN)
+
+	assert.Equal(t, entity.CertBindingModeCreate, cluster.CertConfig.PulsarServerCertInfo.BindingMode)
+	assert.Equal(t, "test-cert-1234", cluster.CertConfig.PulsarServerCertInfo.UUID)
+	assert.Equal(t, entity.CertBindingModeSkip, cluster.CertConfig.SitePortalServerCertInfo.BindingMode,
+		"the cluster has no site portal certificate")
+
+	var m struct {
+		Nginx struct {
+			Exchange struct {
+				IP       string `json:"ip"`
+				HTTPPort int    `json:"httpPort"`
+			} `json:"exchange"`
+		} `json:"nginx"`
+		Pulsar struct {
+			Exchange struct {
+				IP   string `json:"ip"`
+				Port int    `json:"port"`
+			} `json:"exchange"`
+		} `json:"pulsar"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(cluster.DeploymentYAML), &m))
+	assert.Equal(t, "exchange-a-host", m.Nginx.Exchange.IP)
+	assert.Equal(t, 9300, m.Nginx.Exchange.HTTPPort)
+	assert.Equal(t, "exchange-a-host", m.Pulsar.Exchange.IP)
+	assert.Equal(t, 443, m.Pulsar.Exchange.Port)
+
+	_, _, err = service.AdoptCluster(&ParticipantFATEAdoptionRequest{
+		FederationUUID: "test-federation",
+		EndpointUUID:   "test-endpoint",
+		ClusterUUID:    "kf-missing",
+	})
+	assert.Error(t, err, "unknown KubeFATE cluster cannot be adopted")
+}
+
+func TestAdoptCluster_Failed(t *testing.T) {
+	getServiceAccessWithFallback = func(client kubernetes.Client, namespace, serviceName, portName string, lbFallbackToNodePort bool) (serviceType corev1.ServiceType, host string, port int, err error) {
+		return corev1.ServiceTypeNodePort, serviceName + "-host", 30000, nil
+	}
+	getSecretCertificateOrig := getSecretCertificate
+	// the pulsar certificate is bound before the portal certificate fails to be read
+	getSecretCertificate = func(client kubernetes.Client, namespace, secretName, key string) (*x509.Certificate, error) {
+		if secretName != entity.ParticipantFATESecretNamePulsar {
+			return nil, errors.New("test error")
+		}
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(1234),
+			Subject:      pkix.Name{CommonName: "7777.test.example.com"},
+		}, nil
+	}
+	defer func() {
+		getServiceAccessWithFallback = getServiceAccessWithFallbackOrig
+		getSecretCertificate = getSecretCertificateOrig
+	}()
+
+	var deleted []string
+	certificateService := &mockParticipantFATECertificateServiceInt{}
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo([]entity.ParticipantFATE{testFATEExchange("exchange-a", "exchange-a-host", true)}, &mock.ParticipantFATERepoMock{
+			DeleteByUUIDFn: func(uuid string) error {
+				deleted = append(deleted, uuid)
+				return nil
+			},
+		}),
+		ParticipantService: ParticipantService{
+			FederationRepo:     &mock.FederationFATERepoMock{},
+			ChartRepo:          &gorm.ChartMockRepo{},
+			EventService:       &mockEventServiceInt{},
+			CertificateService: certificateService,
+			EndpointService: &mockParticipantFATEEndpointServiceInt{
+				ClusterList: []*modules.Cluster{
+					{
+						Uuid:         "kf-running",
+						Name:         "existing-fate",
+						NameSpace:    "existing-fate-ns",
+						ChartName:    "fate",
+						ChartVersion: "v1.11.1",
+						Values:       testAdoptionClusterValues,
+						Status:       modules.ClusterStatusRunning,
+					},
+				},
+			},
+		},
+	}
+
+	cluster, wg, err := service.AdoptCluster(&ParticipantFATEAdoptionRequest{
+		FederationUUID: "test-federation",
+		EndpointUUID:   "test-endpoint",
+		ClusterUUID:    "kf-running",
+	})
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, []string{cluster.UUID}, certificateService.DeletedBindings)
+	assert.Equal(t, []string{cluster.UUID}, deleted, "the record should be deleted so the cluster is not uninstalled later")
+}
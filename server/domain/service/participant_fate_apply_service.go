@@ -0,0 +1,819 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"sigs.k8s.io/yaml"
+)
+
+// FederationFATESpec is the declarative spec of a FATE federation and all its participants
+type FederationFATESpec struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Domain      string                    `json:"domain"`
+	Mode        entity.FederationFATEMode `json:"mode"`
+	// Exchange must be empty for a peer-to-peer federation
+	Exchange *ParticipantFATEExchangeSpec `json:"exchange"`
+	Clusters []ParticipantFATEClusterSpec `json:"clusters"`
+}
+
+// ParticipantFATEDeploymentSpec contains the deployment settings shared by exchanges and clusters. Either the
+// EndpointUUID or the InfraProviderUUID is required, and with the latter, a KubeFATE endpoint is installed in the
+// infra provider if there isn't one.
+type ParticipantFATEDeploymentSpec struct {
+	Name              string                               `json:"name"`
+	Description       string                               `json:"description"`
+	InfraProviderUUID string                               `json:"infra_provider_uuid"`
+	EndpointUUID      string                               `json:"endpoint_uuid"`
+	Namespace         string                               `json:"namespace"`
+	ChartUUID         string                               `json:"chart_uuid"`
+	ServiceType       entity.ParticipantDefaultServiceType `json:"service_type"`
+	RegistryConfig    valueobject.KubeRegistryConfig       `json:"registry_config"`
+	EnablePSP         bool                                 `json:"enable_psp"`
+}
+
+// ParticipantFATEExchangeSpec is the declarative spec of a FATE exchange
+type ParticipantFATEExchangeSpec struct {
+	ParticipantFATEDeploymentSpec
+	ProxyServerCertInfo      entity.ParticipantComponentCertInfo `json:"proxy_server_cert_info"`
+	FMLManagerServerCertInfo entity.ParticipantComponentCertInfo `json:"fml_manager_server_cert_info"`
+	FMLManagerClientCertInfo entity.ParticipantComponentCertInfo `json:"fml_manager_client_cert_info"`
+	// EnableRollsite deploys the rollsite in the exchange, which is required by eggroll clusters
+	EnableRollsite bool `json:"enable_rollsite"`
+}
+
+// ParticipantFATEClusterSpec is the declarative spec of a FATE cluster, which is identified by its party id
+type ParticipantFATEClusterSpec struct {
+	ParticipantFATEDeploymentSpec
+	PartyID                  int                                 `json:"party_id"`
+	EnablePersistence        bool                                `json:"enable_persistence"`
+	StorageClass             string                              `json:"storage_class"`
+	PulsarServerCertInfo     entity.ParticipantComponentCertInfo `json:"pulsar_server_cert_info"`
+	SitePortalServerCertInfo entity.ParticipantComponentCertInfo `json:"site_portal_server_cert_info"`
+	SitePortalClientCertInfo entity.ParticipantComponentCertInfo `json:"site_portal_client_cert_info"`
+	// Backend is the computing and federation engine combination, empty means Spark with Pulsar
+	Backend entity.ParticipantFATEBackend `json:"backend"`
+}
+
+// FederationFATEApplyAction is the action to take on an object to converge it to the spec
+type FederationFATEApplyAction string
+
+const (
+	FederationFATEApplyActionNone        FederationFATEApplyAction = "none"
+	FederationFATEApplyActionCreate      FederationFATEApplyAction = "create"
+	FederationFATEApplyActionUpgrade     FederationFATEApplyAction = "upgrade"
+	FederationFATEApplyActionReconfigure FederationFATEApplyAction = "reconfigure"
+	FederationFATEApplyActionReplace     FederationFATEApplyAction = "replace"
+	FederationFATEApplyActionRemove      FederationFATEApplyAction = "remove"
+)
+
+// FederationFATEApplyPlanItem is the action on one object of the federation
+type FederationFATEApplyPlanItem struct {
+	Action FederationFATEApplyAction `json:"action"`
+	// Type is "federation", "exchange" or "cluster"
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	UUID    string `json:"uuid"`
+	PartyID int    `json:"party_id"`
+	Reason  string `json:"reason"`
+	// UpgradeVersion is the chart version to upgrade to
+	UpgradeVersion string `json:"upgrade_version,omitempty"`
+
+	participant  *entity.ParticipantFATE
+	exchangeSpec *ParticipantFATEExchangeSpec
+	clusterSpec  *ParticipantFATEClusterSpec
+	// exchangeChanged and standbyExchangeChanged mean the cluster must be reconfigured because its primary or standby
+	// exchange is removed or replaced
+	exchangeChanged        bool
+	standbyExchangeChanged bool
+}
+
+// FederationFATEApplyPlan is the list of actions to converge the federation to the spec
+type FederationFATEApplyPlan struct {
+	FederationUUID string `json:"federation_uuid"`
+	DryRun         bool   `json:"dry_run"`
+	// Prune means the participants not in the spec are removed
+	Prune bool                          `json:"prune"`
+	Items []FederationFATEApplyPlanItem `json:"items"`
+}
+
+const (
+	applyPlanItemTypeFederation = "federation"
+	applyPlanItemTypeExchange   = "exchange"
+	applyPlanItemTypeCluster    = "cluster"
+)
+
+// ApplyFederation compares the spec with the existing federation and its participants and returns the plan to converge
+// them. The participants not in the spec are only removed if prune is set. Unless dryRun is set, the federation is
+// created if it doesn't exist, and the rest of the plan is executed in the background. The returned *sync.WaitGroup can
+// be used to wait for the completion.
+func (s *ParticipantFATEService) ApplyFederation(spec *FederationFATESpec, dryRun, prune bool) (*FederationFATEApplyPlan, *sync.WaitGroup, error) {
+	if err := s.validateFederationSpec(spec); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid federation spec")
+	}
+	federation, err := s.findFederationByName(spec.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan := &FederationFATEApplyPlan{
+		DryRun: dryRun,
+		Prune:  prune,
+	}
+	var participantList []entity.ParticipantFATE
+	if federation == nil {
+		plan.Items = append(plan.Items, FederationFATEApplyPlanItem{
+			Action: FederationFATEApplyActionCreate,
+			Type:   applyPlanItemTypeFederation,
+			Name:   spec.Name,
+			Reason: "federation does not exist",
+		})
+	} else {
+		if federation.Domain != spec.Domain {
+			return nil, nil, errors.Errorf("the domain of federation %s is %s and cannot be changed", federation.Name, federation.Domain)
+		}
+		if federation.Mode != spec.Mode {
+			return nil, nil, errors.Errorf("the mode of federation %s is %v and cannot be changed", federation.Name, federation.Mode)
+		}
+		plan.FederationUUID = federation.UUID
+		plan.Items = append(plan.Items, FederationFATEApplyPlanItem{
+			Action: FederationFATEApplyActionNone,
+			Type:   applyPlanItemTypeFederation,
+			Name:   federation.Name,
+			UUID:   federation.UUID,
+		})
+		instanceList, err := s.ParticipantFATERepo.ListByFederationUUID(federation.UUID)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to list participants")
+		}
+		participantList = instanceList.([]entity.ParticipantFATE)
+	}
+	items, err := s.buildApplyPlanItems(spec, participantList, prune)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan.Items = append(plan.Items, items...)
+	if dryRun {
+		return plan, nil, nil
+	}
+
+	if federation == nil {
+		federation = &entity.FederationFATE{
+			Federation: entity.Federation{
+				UUID:        uuid.NewV4().String(),
+				Name:        spec.Name,
+				Description: spec.Description,
+				Type:        entity.FederationTypeFATE,
+				Repo:        s.FederationRepo,
+			},
+			Domain: spec.Domain,
+			Mode:   spec.Mode,
+		}
+		if err := federation.Create(); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create federation")
+		}
+		plan.FederationUUID = federation.UUID
+		plan.Items[0].UUID = federation.UUID
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := log.Logger.With().Timestamp().Str("action", "applying fate federation spec").Str("federation_uuid", federation.UUID).Logger()
+		if err := s.executeApplyPlan(federation.UUID, plan); err != nil {
+			operationLog.Error().Msg(errors.Wrap(err, "failed to apply federation spec").Error())
+			return
+		}
+		operationLog.Info().Msgf("federation %s converged to the spec", federation.Name)
+	}()
+	return plan, wg, nil
+}
+
+// validateFederationSpec checks the spec and fills in the default values
+func (s *ParticipantFATEService) validateFederationSpec(spec *FederationFATESpec) error {
+	if spec.Name == "" {
+		return errors.New("missing federation name")
+	}
+	if !utils.IsDomainName(spec.Domain) {
+		return errors.New("invalid domain name")
+	}
+	if spec.Mode > entity.FederationFATEModePeerToPeer {
+		return errors.Errorf("unknown federation mode: %v", spec.Mode)
+	}
+	if spec.Mode == entity.FederationFATEModePeerToPeer && spec.Exchange != nil {
+		return errors.New("a peer-to-peer federation cannot have an exchange")
+	}
+	if spec.Mode == entity.FederationFATEModeExchange && spec.Exchange == nil && len(spec.Clusters) > 0 {
+		return errors.New("an exchange is required for the clusters")
+	}
+	if spec.Exchange != nil {
+		if err := s.ValidateExchangeSpec(spec.Name, spec.Exchange); err != nil {
+			return err
+		}
+	}
+	partyIDMap := map[int]bool{}
+	for index := range spec.Clusters {
+		cluster := &spec.Clusters[index]
+		if partyIDMap[cluster.PartyID] {
+			return errors.Errorf("party id %v is used by more than one cluster", cluster.PartyID)
+		}
+		partyIDMap[cluster.PartyID] = true
+		if err := s.ValidateClusterSpec(spec.Name, cluster); err != nil {
+			return err
+		}
+		if first := spec.Clusters[0].Backend; !cluster.Backend.IsFederationCompatible(first) {
+			return errors.Errorf("cluster %s using %s federation is not compatible with cluster %s using %s federation",
+				cluster.Name, cluster.Backend.Federation(), spec.Clusters[0].Name, first.Federation())
+		}
+		if cluster.Backend == entity.ParticipantFATEBackendEggroll && spec.Exchange != nil && !spec.Exchange.EnableRollsite {
+			return errors.Errorf("eggroll cluster %s requires the exchange to enable rollsite", cluster.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateExchangeSpec checks the spec of an exchange in the specified federation and fills in the default values
+func (s *ParticipantFATEService) ValidateExchangeSpec(federationName string, spec *ParticipantFATEExchangeSpec) error {
+	if spec.Name == "" {
+		spec.Name = fmt.Sprintf("%s-exchange", toDeploymentName(federationName))
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = fmt.Sprintf("%s-fate-exchange", toDeploymentName(federationName))
+	}
+	if err := s.validateDeploymentSpec(&spec.ParticipantFATEDeploymentSpec, entity.ChartTypeFATEExchange); err != nil {
+		return errors.Wrapf(err, "invalid exchange %s", spec.Name)
+	}
+	for _, certInfo := range []*entity.ParticipantComponentCertInfo{&spec.ProxyServerCertInfo,
+		&spec.FMLManagerServerCertInfo, &spec.FMLManagerClientCertInfo} {
+		defaultCertBindingMode(certInfo)
+	}
+	return nil
+}
+
+// ValidateClusterSpec checks the spec of a cluster in the specified federation and fills in the default values
+func (s *ParticipantFATEService) ValidateClusterSpec(federationName string, spec *ParticipantFATEClusterSpec) error {
+	if spec.PartyID <= 0 {
+		return errors.Errorf("invalid party id %v", spec.PartyID)
+	}
+	if spec.Name == "" {
+		spec.Name = fmt.Sprintf("fate-%v", spec.PartyID)
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = fmt.Sprintf("%s-fate-%v", toDeploymentName(federationName), spec.PartyID)
+	}
+	if err := s.validateDeploymentSpec(&spec.ParticipantFATEDeploymentSpec, entity.ChartTypeFATECluster); err != nil {
+		return errors.Wrapf(err, "invalid cluster %s", spec.Name)
+	}
+	if err := spec.Backend.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid cluster %s", spec.Name)
+	}
+	spec.Backend = spec.Backend.OrDefault()
+	for _, certInfo := range []*entity.ParticipantComponentCertInfo{&spec.PulsarServerCertInfo,
+		&spec.SitePortalServerCertInfo, &spec.SitePortalClientCertInfo} {
+		defaultCertBindingMode(certInfo)
+	}
+	return nil
+}
+
+func (s *ParticipantFATEService) validateDeploymentSpec(spec *ParticipantFATEDeploymentSpec, chartType entity.ChartType) error {
+	if spec.EndpointUUID == "" && spec.InfraProviderUUID == "" {
+		return errors.New("either the endpoint uuid or the infra provider uuid is required")
+	}
+	if spec.EndpointUUID == "" {
+		if _, err := s.InfraRepo.GetByUUID(spec.InfraProviderUUID); err != nil {
+			return errors.Wrapf(err, "failed to query infra provider")
+		}
+	}
+	instance, err := s.ChartRepo.GetByUUID(spec.ChartUUID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to query chart")
+	}
+	if chart := instance.(*entity.Chart); chart.Type != chartType {
+		return errors.Errorf("chart %s is not for %v deployment", chart.UUID, chartType)
+	}
+	if spec.ServiceType == entity.ParticipantDefaultServiceTypeUnknown {
+		spec.ServiceType = entity.ParticipantDefaultServiceTypeLoadBalancer
+	}
+	return nil
+}
+
+// defaultCertBindingMode makes the component use a new certificate if no binding mode is specified
+func defaultCertBindingMode(certInfo *entity.ParticipantComponentCertInfo) {
+	if certInfo.BindingMode == entity.CertBindingModeUnknown {
+		certInfo.BindingMode = entity.CertBindingModeCreate
+	}
+}
+
+// findFederationByName returns the FATE federation of the specified name, or nil if there is no such federation
+func (s *ParticipantFATEService) findFederationByName(name string) (*entity.FederationFATE, error) {
+	instanceList, err := s.FederationRepo.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list federations")
+	}
+	federationList, _ := instanceList.([]entity.FederationFATE)
+	for index := range federationList {
+		if federationList[index].Name == name {
+			return &federationList[index], nil
+		}
+	}
+	return nil, nil
+}
+
+// buildApplyPlanItems diffs the spec against the existing participants. Participants not in the spec are removed if
+// prune is set, and the ones whose deployment can't be changed in place are replaced. The clusters using a removed or
+// replaced exchange are reconfigured to connect to the exchange in the spec. Participants not managed by FedLCM are
+// left out of the plan and can't be in the spec.
+func (s *ParticipantFATEService) buildApplyPlanItems(spec *FederationFATESpec, participantList []entity.ParticipantFATE, prune bool) ([]FederationFATEApplyPlanItem, error) {
+	for _, participant := range participantList {
+		switch participant.Status {
+		case entity.ParticipantFATEStatusInstalling, entity.ParticipantFATEStatusRemoving,
+			entity.ParticipantFATEStatusUpgrading, entity.ParticipantFATEStatusReconfiguring:
+			return nil, errors.Errorf("participant %s is in status %v, try again later", participant.Name, participant.Status)
+		}
+	}
+
+	var exchangeItems, clusterItems []FederationFATEApplyPlanItem
+	// exchanges that are removed, replaced, or no longer exist because a previous apply stopped halfway
+	changedExchangeMap := map[string]bool{}
+	existingExchangeMap := map[string]bool{}
+	var defaultExchangeUUID string
+	for index := range participantList {
+		participant := &participantList[index]
+		if participant.Type != entity.ParticipantFATETypeExchange {
+			continue
+		}
+		if defaultExchangeUUID == "" {
+			defaultExchangeUUID = participant.UUID
+		}
+		existingExchangeMap[participant.UUID] = true
+		inSpec := spec.Exchange != nil && spec.Exchange.Name == participant.Name
+		if !participant.IsManaged && !inSpec {
+			continue
+		}
+		item := newNotInSpecPlanItem(applyPlanItemTypeExchange, participant, prune)
+		if inSpec {
+			item.exchangeSpec = spec.Exchange
+			if err := s.diffParticipant(&item, &spec.Exchange.ParticipantFATEDeploymentSpec); err != nil {
+				return nil, err
+			}
+		}
+		if item.Action == FederationFATEApplyActionRemove || item.Action == FederationFATEApplyActionReplace {
+			changedExchangeMap[participant.UUID] = true
+		}
+		exchangeItems = append(exchangeItems, item)
+	}
+	if spec.Exchange != nil {
+		found := false
+		for _, item := range exchangeItems {
+			found = found || item.exchangeSpec != nil
+		}
+		if !found {
+			exchangeItems = append(exchangeItems, FederationFATEApplyPlanItem{
+				Action:       FederationFATEApplyActionCreate,
+				Type:         applyPlanItemTypeExchange,
+				Name:         spec.Exchange.Name,
+				Reason:       "exchange does not exist",
+				exchangeSpec: spec.Exchange,
+			})
+		}
+	}
+
+	clusterSpecMap := map[int]*ParticipantFATEClusterSpec{}
+	for index := range spec.Clusters {
+		clusterSpecMap[spec.Clusters[index].PartyID] = &spec.Clusters[index]
+	}
+	for index := range participantList {
+		participant := &participantList[index]
+		if participant.Type != entity.ParticipantFATETypeCluster {
+			continue
+		}
+		clusterSpec, inSpec := clusterSpecMap[participant.PartyID]
+		if !participant.IsManaged && !inSpec {
+			continue
+		}
+		item := newNotInSpecPlanItem(applyPlanItemTypeCluster, participant, prune)
+		if inSpec {
+			delete(clusterSpecMap, participant.PartyID)
+			item.clusterSpec = clusterSpec
+			if err := s.diffParticipant(&item, &clusterSpec.ParticipantFATEDeploymentSpec); err != nil {
+				return nil, err
+			}
+			// changing the backend means reinstalling the cluster and losing its data, which must be asked for explicitly
+			if item.Action != FederationFATEApplyActionReplace && participant.Backend.OrDefault() != clusterSpec.Backend.OrDefault() {
+				return nil, errors.Errorf("the backend of cluster %s cannot be changed from %s to %s, as the cluster would be reinstalled and lose its data; "+
+					"remove the cluster with prune first to change its backend", participant.Name, participant.Backend.OrDefault(), clusterSpec.Backend.OrDefault())
+			}
+			if item.Action == FederationFATEApplyActionNone || item.Action == FederationFATEApplyActionUpgrade {
+				exchangeUUID := clusterExchangeUUID(participant, defaultExchangeUUID)
+				item.exchangeChanged = exchangeUUID != "" && (changedExchangeMap[exchangeUUID] || !existingExchangeMap[exchangeUUID])
+				item.standbyExchangeChanged = participant.StandbyExchangeUUID != "" &&
+					(changedExchangeMap[participant.StandbyExchangeUUID] || !existingExchangeMap[participant.StandbyExchangeUUID])
+				if item.exchangeChanged || item.standbyExchangeChanged {
+					reason := "its exchange is removed or replaced"
+					if item.Action == FederationFATEApplyActionUpgrade {
+						item.Reason = fmt.Sprintf("%s, and %s", item.Reason, reason)
+					} else {
+						item.Action = FederationFATEApplyActionReconfigure
+						item.Reason = reason
+					}
+				}
+			}
+		}
+		clusterItems = append(clusterItems, item)
+	}
+	for _, clusterSpec := range spec.Clusters {
+		clusterSpec := clusterSpec
+		if _, ok := clusterSpecMap[clusterSpec.PartyID]; ok {
+			clusterItems = append(clusterItems, FederationFATEApplyPlanItem{
+				Action:      FederationFATEApplyActionCreate,
+				Type:        applyPlanItemTypeCluster,
+				Name:        clusterSpec.Name,
+				PartyID:     clusterSpec.PartyID,
+				Reason:      "cluster does not exist",
+				clusterSpec: &clusterSpec,
+			})
+		}
+	}
+	return append(exchangeItems, clusterItems...), nil
+}
+
+// newNotInSpecPlanItem returns the plan item for a managed participant that is not in the spec
+func newNotInSpecPlanItem(itemType string, participant *entity.ParticipantFATE, prune bool) FederationFATEApplyPlanItem {
+	item := FederationFATEApplyPlanItem{
+		Action:      FederationFATEApplyActionRemove,
+		Type:        itemType,
+		Name:        participant.Name,
+		UUID:        participant.UUID,
+		Reason:      "not in the spec",
+		participant: participant,
+	}
+	if itemType == applyPlanItemTypeCluster {
+		item.PartyID = participant.PartyID
+	}
+	if !prune {
+		item.Action = FederationFATEApplyActionNone
+		item.Reason = "not in the spec, kept as prune is not set"
+	}
+	return item
+}
+
+// PlanParticipant compares an existing exchange or cluster with its spec, which should have been validated, and
+// returns the action to converge it
+func (s *ParticipantFATEService) PlanParticipant(participant *entity.ParticipantFATE, spec *ParticipantFATEDeploymentSpec) (*FederationFATEApplyPlanItem, error) {
+	item := &FederationFATEApplyPlanItem{
+		Type:        applyPlanItemTypeCluster,
+		Name:        participant.Name,
+		UUID:        participant.UUID,
+		PartyID:     participant.PartyID,
+		participant: participant,
+	}
+	if participant.Type == entity.ParticipantFATETypeExchange {
+		item.Type = applyPlanItemTypeExchange
+	}
+	if err := s.diffParticipant(item, spec); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ParticipantFATEDeploymentSettings contains the settings of a participant that only take effect when it is installed
+type ParticipantFATEDeploymentSettings struct {
+	ServiceType       string
+	Registry          string
+	EnablePSP         bool
+	EnablePersistence bool
+	StorageClass      string
+}
+
+// GetDeploymentSettings returns the settings the participant is installed with, read from its deployment yaml
+func (s *ParticipantFATEService) GetDeploymentSettings(participant *entity.ParticipantFATE) (*ParticipantFATEDeploymentSettings, error) {
+	var m struct {
+		Registry          string `json:"registry"`
+		Persistence       bool   `json:"persistence"`
+		PodSecurityPolicy struct {
+			Enabled bool `json:"enabled"`
+		} `json:"podSecurityPolicy"`
+		Nginx struct {
+			Type string `json:"type"`
+		} `json:"nginx"`
+		Python struct {
+			StorageClass string `json:"storageClass"`
+		} `json:"python"`
+	}
+	if err := yaml.Unmarshal([]byte(participant.DeploymentYAML), &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the deployment yaml of participant %s", participant.Name)
+	}
+	return &ParticipantFATEDeploymentSettings{
+		ServiceType:       m.Nginx.Type,
+		Registry:          m.Registry,
+		EnablePSP:         m.PodSecurityPolicy.Enabled,
+		EnablePersistence: m.Persistence,
+		StorageClass:      m.Python.StorageClass,
+	}, nil
+}
+
+// diffParticipant compares the existing participant with its spec and sets the action of the plan item. Participants not
+// managed by FedLCM can't be converged to a spec.
+func (s *ParticipantFATEService) diffParticipant(item *FederationFATEApplyPlanItem, spec *ParticipantFATEDeploymentSpec) error {
+	participant := item.participant
+	if !participant.IsManaged {
+		return errors.Errorf("%s %s is not managed by FedLCM and cannot be changed by a spec", item.Type, participant.Name)
+	}
+	item.Action = FederationFATEApplyActionReplace
+	switch {
+	case participant.Status == entity.ParticipantFATEStatusFailed:
+		item.Reason = "in failed status"
+	case participant.Name != spec.Name:
+		item.Reason = fmt.Sprintf("name changed from %s to %s", participant.Name, spec.Name)
+	case participant.Namespace != spec.Namespace:
+		item.Reason = fmt.Sprintf("namespace changed from %s to %s", participant.Namespace, spec.Namespace)
+	case spec.EndpointUUID != "" && participant.EndpointUUID != spec.EndpointUUID:
+		item.Reason = fmt.Sprintf("endpoint changed from %s to %s", participant.EndpointUUID, spec.EndpointUUID)
+	default:
+		if spec.EndpointUUID == "" {
+			infraUUID, err := s.EndpointService.getInfraProviderUUID(participant.EndpointUUID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get the infra provider of participant %s", participant.Name)
+			}
+			if infraUUID != spec.InfraProviderUUID {
+				item.Reason = fmt.Sprintf("infra provider changed from %s to %s", infraUUID, spec.InfraProviderUUID)
+				return nil
+			}
+		}
+		item.Action = FederationFATEApplyActionNone
+		item.Reason = ""
+		if participant.ChartUUID == spec.ChartUUID {
+			return nil
+		}
+		instance, err := s.ChartRepo.GetByUUID(participant.ChartUUID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to query the chart of participant %s", participant.Name)
+		}
+		currentChart := instance.(*entity.Chart)
+		instance, err = s.ChartRepo.GetByUUID(spec.ChartUUID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to query chart")
+		}
+		chart := instance.(*entity.Chart)
+		if chart.ChartName == currentChart.ChartName && utils.CompareVersion(currentChart.Version, chart.Version) < 0 {
+			item.Action = FederationFATEApplyActionUpgrade
+			item.Reason = fmt.Sprintf("chart version changed from %s to %s", currentChart.Version, chart.Version)
+			item.UpgradeVersion = chart.Version
+		} else {
+			item.Action = FederationFATEApplyActionReplace
+			item.Reason = fmt.Sprintf("chart changed from %s %s to %s %s", currentChart.ChartName, currentChart.Version, chart.ChartName, chart.Version)
+		}
+	}
+	return nil
+}
+
+// executeApplyPlan removes the clusters and exchanges first, then creates or upgrades the exchange and at last the
+// clusters, reconfiguring the ones whose exchange is changed. Each step waits for the previous one, and the execution
+// stops at the first failure.
+func (s *ParticipantFATEService) executeApplyPlan(federationUUID string, plan *FederationFATEApplyPlan) error {
+	var removeList, changeList []FederationFATEApplyPlanItem
+	movingClusterUUIDs := map[string]bool{}
+	for _, item := range plan.Items {
+		if item.exchangeChanged || item.standbyExchangeChanged {
+			movingClusterUUIDs[item.UUID] = true
+		}
+		switch item.Action {
+		case FederationFATEApplyActionRemove, FederationFATEApplyActionReplace:
+			removeList = append(removeList, item)
+		}
+		switch item.Action {
+		case FederationFATEApplyActionCreate, FederationFATEApplyActionReplace, FederationFATEApplyActionUpgrade,
+			FederationFATEApplyActionReconfigure:
+			if item.Type != applyPlanItemTypeFederation {
+				changeList = append(changeList, item)
+			}
+		}
+	}
+	// clusters are removed before the exchanges they are using
+	for _, itemType := range []string{applyPlanItemTypeCluster, applyPlanItemTypeExchange} {
+		for _, item := range removeList {
+			if item.Type == itemType {
+				if err := s.applyRemoval(&item, movingClusterUUIDs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	exchangeUUID := ""
+	for _, item := range plan.Items {
+		if item.Type == applyPlanItemTypeExchange && item.exchangeSpec != nil && item.Action != FederationFATEApplyActionReplace {
+			exchangeUUID = item.UUID
+		}
+	}
+	for _, item := range changeList {
+		if item.exchangeChanged || item.standbyExchangeChanged {
+			if err := s.applyExchangeReconfiguration(exchangeUUID, &item); err != nil {
+				return err
+			}
+			if item.Action == FederationFATEApplyActionReconfigure {
+				continue
+			}
+		}
+		var participant *entity.ParticipantFATE
+		var wg *sync.WaitGroup
+		var err error
+		switch {
+		case item.Action == FederationFATEApplyActionUpgrade && item.Type == applyPlanItemTypeExchange:
+			participant, wg, err = s.UpgradeExchange(&ParticipantFATEExchangeUpgradeRequest{
+				ExchangeUUID:   item.UUID,
+				FederationUUID: federationUUID,
+				UpgradeVersion: item.UpgradeVersion,
+			})
+		case item.Action == FederationFATEApplyActionUpgrade:
+			participant, wg, err = s.UpgradeCluster(&ParticipantFATEClusterUpgradeRequest{
+				ClusterUUID:    item.UUID,
+				FederationUUID: federationUUID,
+				UpgradeVersion: item.UpgradeVersion,
+			})
+		case item.Type == applyPlanItemTypeExchange:
+			participant, wg, err = s.CreateExchangeFromSpec(federationUUID, item.exchangeSpec)
+		default:
+			participant, wg, err = s.CreateClusterFromSpec(federationUUID, exchangeUUID, item.clusterSpec)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to %s %s %s", item.Action, item.Type, item.Name)
+		}
+		wg.Wait()
+		participant, err = s.loadParticipant(participant.UUID)
+		if err != nil {
+			return err
+		}
+		if participant.Status != entity.ParticipantFATEStatusActive {
+			return errors.Errorf("%s %s is in status %v after the %s action", item.Type, item.Name, participant.Status, item.Action)
+		}
+		if item.Type == applyPlanItemTypeExchange {
+			exchangeUUID = participant.UUID
+		}
+		log.Info().Str("federation_uuid", federationUUID).Msgf("%s %s: %s done", item.Type, item.Name, item.Action)
+	}
+	return nil
+}
+
+// applyExchangeReconfiguration connects the cluster to the exchange of the spec in place, and drops its standby exchange
+// if it is removed or replaced
+func (s *ParticipantFATEService) applyExchangeReconfiguration(exchangeUUID string, item *FederationFATEApplyPlanItem) error {
+	if !item.exchangeChanged {
+		cluster, err := s.loadParticipant(item.UUID)
+		if err != nil {
+			return err
+		}
+		cluster.StandbyExchangeUUID = ""
+		if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+			return errors.Wrap(err, "failed to update cluster exchange info")
+		}
+		return nil
+	}
+	if exchangeUUID == "" {
+		return errors.Errorf("no exchange in the spec for cluster %s to connect to", item.Name)
+	}
+	wg, err := s.ConnectClusterToExchange(item.UUID, exchangeUUID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconfigure cluster %s", item.Name)
+	}
+	wg.Wait()
+	cluster, err := s.loadParticipant(item.UUID)
+	if err != nil {
+		return err
+	}
+	if cluster.Status != entity.ParticipantFATEStatusActive {
+		return errors.Errorf("cluster %s is in status %v after connecting to its new exchange", cluster.Name, cluster.Status)
+	}
+	log.Info().Str("federation_uuid", cluster.FederationUUID).Msgf("cluster %s connected to exchange %s", cluster.Name, exchangeUUID)
+	return nil
+}
+
+// applyRemoval removes the participant, an exchange can be removed while the clusters in movingClusterUUIDs are using it
+// as they are reconfigured afterwards
+func (s *ParticipantFATEService) applyRemoval(item *FederationFATEApplyPlanItem, movingClusterUUIDs map[string]bool) error {
+	force := item.participant.Status == entity.ParticipantFATEStatusFailed
+	var wg *sync.WaitGroup
+	var err error
+	if item.Type == applyPlanItemTypeExchange {
+		wg, err = s.removeExchange(item.UUID, force, movingClusterUUIDs)
+	} else {
+		wg, err = s.RemoveCluster(item.UUID, force)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove %s %s", item.Type, item.Name)
+	}
+	wg.Wait()
+	if _, err := s.ParticipantFATERepo.GetByUUID(item.UUID); err == nil {
+		return errors.Errorf("%s %s is not removed, check its events for details", item.Type, item.Name)
+	}
+	log.Info().Str("federation_uuid", item.participant.FederationUUID).Msgf("%s %s removed", item.Type, item.Name)
+	return nil
+}
+
+// CreateExchangeFromSpec creates an exchange in the federation from its validated spec
+func (s *ParticipantFATEService) CreateExchangeFromSpec(federationUUID string, spec *ParticipantFATEExchangeSpec) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	endpointUUID, err := s.prepareSpecEndpoint(&spec.ParticipantFATEDeploymentSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	yamlReq := ParticipantFATEExchangeYAMLCreationRequest{
+		ChartUUID:      spec.ChartUUID,
+		Name:           spec.Name,
+		Namespace:      spec.Namespace,
+		ServiceType:    spec.ServiceType,
+		RegistryConfig: spec.RegistryConfig,
+		EnablePSP:      spec.EnablePSP,
+		EnableRollsite: spec.EnableRollsite,
+	}
+	deploymentYAML, err := s.GetExchangeDeploymentYAML(&yamlReq)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get deployment yaml")
+	}
+	return s.CreateExchange(&ParticipantFATEExchangeCreationRequest{
+		ParticipantFATEExchangeYAMLCreationRequest: yamlReq,
+		ParticipantDeploymentBaseInfo: ParticipantDeploymentBaseInfo{
+			Description:    spec.Description,
+			EndpointUUID:   endpointUUID,
+			DeploymentYAML: deploymentYAML,
+		},
+		FederationUUID:           federationUUID,
+		ProxyServerCertInfo:      spec.ProxyServerCertInfo,
+		FMLManagerServerCertInfo: spec.FMLManagerServerCertInfo,
+		FMLManagerClientCertInfo: spec.FMLManagerClientCertInfo,
+	})
+}
+
+// CreateClusterFromSpec creates a cluster in the federation from its validated spec, an empty exchangeUUID means using
+// the first exchange of the federation
+func (s *ParticipantFATEService) CreateClusterFromSpec(federationUUID, exchangeUUID string, spec *ParticipantFATEClusterSpec) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	yamlReq := ParticipantFATEClusterYAMLCreationRequest{
+		ParticipantFATEExchangeYAMLCreationRequest: ParticipantFATEExchangeYAMLCreationRequest{
+			ChartUUID:      spec.ChartUUID,
+			Name:           spec.Name,
+			Namespace:      spec.Namespace,
+			ServiceType:    spec.ServiceType,
+			RegistryConfig: spec.RegistryConfig,
+			EnablePSP:      spec.EnablePSP,
+		},
+		FederationUUID:    federationUUID,
+		PartyID:           spec.PartyID,
+		EnablePersistence: spec.EnablePersistence,
+		StorageClass:      spec.StorageClass,
+		ExchangeUUID:      exchangeUUID,
+		Backend:           spec.Backend,
+	}
+	deploymentYAML, err := s.GetClusterDeploymentYAML(&yamlReq)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get deployment yaml")
+	}
+	creationReq := &ParticipantFATEClusterCreationRequest{
+		ParticipantFATEClusterYAMLCreationRequest: yamlReq,
+		ParticipantDeploymentBaseInfo: ParticipantDeploymentBaseInfo{
+			Description:    spec.Description,
+			EndpointUUID:   spec.EndpointUUID,
+			DeploymentYAML: deploymentYAML,
+		},
+		PulsarServerCertInfo:     spec.PulsarServerCertInfo,
+		SitePortalServerCertInfo: spec.SitePortalServerCertInfo,
+		SitePortalClientCertInfo: spec.SitePortalClientCertInfo,
+	}
+	if spec.EndpointUUID == "" {
+		creationReq.prepareEndpoint = func() (string, error) {
+			return s.prepareSpecEndpoint(&spec.ParticipantFATEDeploymentSpec)
+		}
+	}
+	return s.CreateCluster(creationReq)
+}
+
+// prepareSpecEndpoint returns the endpoint in the spec, or makes sure there is a KubeFATE endpoint in the infra provider
+func (s *ParticipantFATEService) prepareSpecEndpoint(spec *ParticipantFATEDeploymentSpec) (string, error) {
+	if spec.EndpointUUID != "" {
+		return spec.EndpointUUID, nil
+	}
+	endpointUUID, err := s.EndpointService.ensureEndpointExist(spec.InfraProviderUUID, "", spec.RegistryConfig)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to prepare the kubefate endpoint")
+	}
+	return endpointUUID, nil
+}
@@ -0,0 +1,678 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	testExchangeChartUUID      = "19c7c751-0de0-4780-95d6-e152440ab287"
+	testClusterChartUUID       = "d81d2b48-930d-4c5e-b522-322b93e8ef39"
+	testOldClusterChartUUID    = "7a1e3009-ce43-47b5-9ce9-a5256c399151"
+	testFedLCMClusterChartUUID = "73acbbc0-4cdf-46bf-b48f-25fe1e03b91f"
+)
+
+func setTestDeploymentInfo(participant *entity.ParticipantFATE, namespace, chartUUID string) {
+	participant.IsManaged = true
+	participant.EndpointUUID = "test-endpoint"
+	participant.Namespace = namespace
+	participant.ChartUUID = chartUUID
+}
+
+func findTestPlanItem(plan *FederationFATEApplyPlan, itemType, name string) *FederationFATEApplyPlanItem {
+	for index := range plan.Items {
+		if plan.Items[index].Type == itemType && plan.Items[index].Name == name {
+			return &plan.Items[index]
+		}
+	}
+	return nil
+}
+
+func TestApplyFederation_PosDryRunNewFederation(t *testing.T) {
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(nil, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "new-federation",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+		Clusters: []ParticipantFATEClusterSpec{
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 9999,
+			},
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 10000,
+			},
+		},
+	}
+	plan, wg, err := service.ApplyFederation(spec, true, false)
+	assert.NoError(t, err)
+	assert.Nil(t, wg)
+	assert.True(t, plan.DryRun)
+	assert.Empty(t, plan.FederationUUID)
+	assert.Len(t, plan.Items, 4)
+	for _, item := range plan.Items {
+		assert.Equal(t, FederationFATEApplyActionCreate, item.Action)
+	}
+	item := findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-9999")
+	if assert.NotNil(t, item) {
+		assert.Equal(t, 9999, item.PartyID)
+		assert.Equal(t, "new-federation-fate-9999", item.clusterSpec.Namespace)
+		assert.Equal(t, entity.ParticipantDefaultServiceTypeLoadBalancer, item.clusterSpec.ServiceType)
+		assert.Equal(t, entity.CertBindingModeCreate, item.clusterSpec.PulsarServerCertInfo.BindingMode)
+	}
+}
+
+func TestApplyFederation_PosDryRunExistingFederation(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("fate-9999", 9999, "", ""),
+		testFATECluster("fate-10000", 10000, "", ""),
+		testFATECluster("fate-10001", 10001, "", ""),
+		testFATECluster("fate-10002", 10002, "", ""),
+		testFATECluster("fate-10003", 10003, "", ""),
+	}
+	setTestDeploymentInfo(&participantList[0], "test-ns", testExchangeChartUUID)
+	setTestDeploymentInfo(&participantList[1], "test-fate-9999", testClusterChartUUID)
+	setTestDeploymentInfo(&participantList[2], "test-fate-10000", testOldClusterChartUUID)
+	setTestDeploymentInfo(&participantList[3], "other-ns", testClusterChartUUID)
+	setTestDeploymentInfo(&participantList[4], "test-fate-10002", testClusterChartUUID)
+	participantList[5].Status = entity.ParticipantFATEStatusFailed
+	setTestDeploymentInfo(&participantList[5], "test-fate-10003", testClusterChartUUID)
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+		Clusters: []ParticipantFATEClusterSpec{
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 9999,
+			},
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 10000,
+			},
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 10001,
+			},
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 10003,
+			},
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 10004,
+			},
+		},
+	}
+	plan, _, err := service.ApplyFederation(spec, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-federation", plan.FederationUUID)
+
+	for name, action := range map[string]FederationFATEApplyAction{
+		"fate-9999":  FederationFATEApplyActionNone,
+		"fate-10000": FederationFATEApplyActionUpgrade,
+		"fate-10001": FederationFATEApplyActionReplace,
+		"fate-10002": FederationFATEApplyActionRemove,
+		"fate-10003": FederationFATEApplyActionReplace,
+		"fate-10004": FederationFATEApplyActionCreate,
+	} {
+		item := findTestPlanItem(plan, applyPlanItemTypeCluster, name)
+		if assert.NotNil(t, item, name) {
+			assert.Equal(t, action, item.Action, name)
+		}
+	}
+	assert.Equal(t, FederationFATEApplyActionNone, findTestPlanItem(plan, applyPlanItemTypeExchange, "exchange-a").Action)
+	assert.Equal(t, "v1.11.1", findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-10000").UpgradeVersion)
+}
+
+func TestApplyFederation_PosNoPrune(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("fate-9999", 9999, "", ""),
+		testFATECluster("fate-10000", 10000, "", ""),
+	}
+	setTestDeploymentInfo(&participantList[0], "test-ns", testExchangeChartUUID)
+	setTestDeploymentInfo(&participantList[1], "test-fate-9999", testClusterChartUUID)
+	setTestDeploymentInfo(&participantList[2], "test-fate-10000", testClusterChartUUID)
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+		Clusters: []ParticipantFATEClusterSpec{
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 9999,
+			},
+		},
+	}
+	plan, _, err := service.ApplyFederation(spec, true, false)
+	assert.NoError(t, err)
+	assert.False(t, plan.Prune)
+	assert.Equal(t, FederationFATEApplyActionNone, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-10000").Action)
+
+	plan, _, err = service.ApplyFederation(spec, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, FederationFATEApplyActionRemove, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-10000").Action)
+}
+
+func TestApplyFederation_PosUnmanagedParticipants(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("fate-9999", 9999, "", ""),
+		testFATECluster("fate-10000", 10000, "", ""),
+	}
+	setTestDeploymentInfo(&participantList[0], "test-ns", testExchangeChartUUID)
+	setTestDeploymentInfo(&participantList[1], "test-fate-9999", testClusterChartUUID)
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	// an unmanaged cluster not in the spec is left out of the plan, even with prune
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+		Clusters: []ParticipantFATEClusterSpec{
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 9999,
+			},
+		},
+	}
+	plan, _, err := service.ApplyFederation(spec, true, true)
+	assert.NoError(t, err)
+	assert.Nil(t, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-10000"))
+	assert.Equal(t, FederationFATEApplyActionNone, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-9999").Action)
+
+	// and it cannot be converged to a spec
+	spec.Clusters = append(spec.Clusters, ParticipantFATEClusterSpec{
+		ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+			InfraProviderUUID: "test-infra",
+			ChartUUID:         testClusterChartUUID,
+		},
+		PartyID: 10000,
+	})
+	_, _, err = service.ApplyFederation(spec, true, true)
+	assert.Error(t, err)
+}
+
+func TestApplyFederation_PosReplacedExchangeReconfiguresClusters(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("fate-9999", 9999, "", ""),
+	}
+	setTestDeploymentInfo(&participantList[0], "test-ns", testExchangeChartUUID)
+	setTestDeploymentInfo(&participantList[1], "test-fate-9999", testClusterChartUUID)
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+		Clusters: []ParticipantFATEClusterSpec{
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 9999,
+			},
+		},
+	}
+	spec.Exchange.Namespace = "other-ns"
+	plan, _, err := service.ApplyFederation(spec, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, FederationFATEApplyActionReplace, findTestPlanItem(plan, applyPlanItemTypeExchange, "exchange-a").Action)
+	item := findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-9999")
+	assert.Equal(t, FederationFATEApplyActionReconfigure, item.Action)
+	assert.True(t, item.exchangeChanged)
+
+	// a cluster whose exchange no longer exists is reconfigured too
+	spec.Exchange.Namespace = "test-ns"
+	participantList[1].ExchangeUUID = "removed-exchange"
+	plan, _, err = service.ApplyFederation(spec, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, FederationFATEApplyActionReconfigure, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-9999").Action)
+	participantList[1].ExchangeUUID = ""
+
+	// a newer chart of the same chart name is upgraded to in place
+	spec.Clusters[0].ChartUUID = testFedLCMClusterChartUUID
+	plan, _, err = service.ApplyFederation(spec, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, FederationFATEApplyActionNone, findTestPlanItem(plan, applyPlanItemTypeExchange, "exchange-a").Action)
+	assert.Equal(t, FederationFATEApplyActionUpgrade, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-9999").Action)
+}
+
+func TestApplyFederation_NegInvalidSpec(t *testing.T) {
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo([]entity.ParticipantFATE{
+			testFATECluster("fate-9999", 9999, "", ""),
+		}, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+	}
+	spec.Mode = entity.FederationFATEModePeerToPeer
+	_, _, err := service.ApplyFederation(spec, true, false)
+	assert.Error(t, err, "a peer-to-peer federation should not have an exchange")
+	spec.Mode = entity.FederationFATEModeExchange
+
+	spec.Clusters = []ParticipantFATEClusterSpec{
+		{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				InfraProviderUUID: "test-infra",
+				ChartUUID:         testClusterChartUUID,
+			},
+			PartyID: 9999,
+		},
+		{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				InfraProviderUUID: "test-infra",
+				ChartUUID:         testClusterChartUUID,
+			},
+			PartyID: 9999,
+		},
+	}
+	_, _, err = service.ApplyFederation(spec, true, false)
+	assert.Error(t, err, "party ids should be unique")
+
+	spec.Clusters = spec.Clusters[:1]
+	spec.Clusters[0].ChartUUID = testExchangeChartUUID
+	_, _, err = service.ApplyFederation(spec, true, false)
+	assert.Error(t, err, "an exchange chart cannot be used for a cluster")
+	spec.Clusters = nil
+
+	spec.Domain = "other.example.com"
+	_, _, err = service.ApplyFederation(spec, true, false)
+	assert.Error(t, err, "the federation domain cannot be changed")
+}
+
+func TestApplyFederation_NegBackendChanged(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("fate-9999", 9999, "", ""),
+	}
+	setTestDeploymentInfo(&participantList[0], "test-ns", testExchangeChartUUID)
+	setTestDeploymentInfo(&participantList[1], "test-fate-9999", testClusterChartUUID)
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+		Clusters: []ParticipantFATEClusterSpec{
+			{
+				ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+					InfraProviderUUID: "test-infra",
+					ChartUUID:         testClusterChartUUID,
+				},
+				PartyID: 9999,
+			},
+		},
+	}
+	spec.Clusters[0].Backend = entity.ParticipantFATEBackendSparkRabbitMQ
+	_, _, err := service.ApplyFederation(spec, true, false)
+	assert.ErrorContains(t, err, "lose its data")
+
+	// a failed cluster is reinstalled anyway so it can use another backend
+	participantList[1].Status = entity.ParticipantFATEStatusFailed
+	plan, _, err := service.ApplyFederation(spec, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, FederationFATEApplyActionReplace, findTestPlanItem(plan, applyPlanItemTypeCluster, "fate-9999").Action)
+}
+
+func TestApplyFederation_NegParticipantInProgress(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("fate-9999", 9999, "", ""),
+	}
+	participantList[1].Status = entity.ParticipantFATEStatusInstalling
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	spec := &FederationFATESpec{
+		Name:   "test",
+		Domain: "example.com",
+		Exchange: &ParticipantFATEExchangeSpec{
+			ParticipantFATEDeploymentSpec: ParticipantFATEDeploymentSpec{
+				Name:         "exchange-a",
+				EndpointUUID: "test-endpoint",
+				Namespace:    "test-ns",
+				ChartUUID:    testExchangeChartUUID,
+			},
+		},
+	}
+	_, _, err := service.ApplyFederation(spec, true, false)
+	assert.Error(t, err)
+}
+
+func TestApplyExchangeReconfiguration(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-b", "exchange-b-host", true),
+		testFATECluster("fate-9999", 9999, "exchange-a", "exchange-a"),
+	}
+	cluster := &participantList[1]
+	setTestDeploymentInfo(cluster, "test-fate-9999", testClusterChartUUID)
+	cluster.DeploymentYAML = `nginx:
+  exchange:
+    ip: exchange-a-host
+    httpPort: 9300
+pulsar:
+  exchange:
+    domain: example.com
+    ip: exchange-a-host
+    port: 443`
+	service := ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				ListFn: func() (interface{}, error) {
+					return []entity.FederationFATE{{
+						Federation: entity.Federation{
+							UUID: "test-federation",
+							Name: "test",
+						},
+						Domain: "example.com",
+					}}, nil
+				},
+			},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	assert.NoError(t, service.applyExchangeReconfiguration("exchange-b", &FederationFATEApplyPlanItem{
+		Action:                 FederationFATEApplyActionReconfigure,
+		UUID:                   cluster.UUID,
+		exchangeChanged:        true,
+		standbyExchangeChanged: true,
+	}))
+	assert.Equal(t, "exchange-b", cluster.ExchangeUUID)
+	assert.Empty(t, cluster.StandbyExchangeUUID)
+	assert.Equal(t, entity.ParticipantFATEStatusActive, cluster.Status)
+
+	var m map[string]map[string]map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(cluster.DeploymentYAML), &m))
+	assert.Equal(t, "exchange-b-host", m["nginx"]["exchange"]["ip"])
+	assert.Equal(t, "exchange-b-host", m["pulsar"]["exchange"]["ip"])
+}
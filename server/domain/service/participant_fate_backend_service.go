@@ -0,0 +1,391 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+type rollsitePartyListEntry struct {
+	PartyID   int    `json:"partyId"`
+	PartyIP   string `json:"partyIp"`
+	PartyPort int    `json:"partyPort"`
+}
+
+type rabbitMQRouteTableEntry struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// rabbitMQUser is the rabbitmq user of the spark_rabbitmq clusters, each cluster has its own random password
+const rabbitMQUser = "fate"
+
+// backendModules are the modules only deployed for certain backends, the other modules are common to all of them
+var backendModules = map[entity.ParticipantFATEBackend][]string{
+	entity.ParticipantFATEBackendSparkPulsar:   {"spark", "hdfs", "pulsar"},
+	entity.ParticipantFATEBackendSparkRabbitMQ: {"spark", "hdfs", "rabbitmq"},
+	entity.ParticipantFATEBackendEggroll:       {"rollsite", "clustermanager", "nodemanager"},
+}
+
+// validateFederationBackend returns error if the backend cannot talk to the existing clusters in the federation
+func (s *ParticipantFATEService) validateFederationBackend(federationUUID string, backend entity.ParticipantFATEBackend) error {
+	if err := backend.Validate(); err != nil {
+		return err
+	}
+	instanceList, err := s.ParticipantFATERepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return errors.Wrap(err, "failed to list participants")
+	}
+	participantList, _ := instanceList.([]entity.ParticipantFATE)
+	for _, participant := range participantList {
+		if participant.Type == entity.ParticipantFATETypeCluster && !participant.Backend.IsFederationCompatible(backend) {
+			return errors.Errorf("backend %s uses %s federation, which is not compatible with cluster %s using %s federation",
+				backend.OrDefault(), backend.Federation(), participant.Name, participant.Backend.Federation())
+		}
+	}
+	return nil
+}
+
+// validateExchangeBackendAccess returns error if the exchange cannot route the traffic of clusters using the backend
+func validateExchangeBackendAccess(exchange *entity.ParticipantFATE, backend entity.ParticipantFATEBackend) error {
+	if _, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameNginx]; !ok {
+		return errors.New("missing exchange nginx access info")
+	}
+	switch backend.OrDefault() {
+	case entity.ParticipantFATEBackendSparkPulsar:
+		if _, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameATS]; !ok {
+			return errors.New("missing exchange traffic-server access info")
+		}
+	case entity.ParticipantFATEBackendEggroll:
+		if _, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameRollsite]; !ok {
+			return errors.Errorf("exchange %s is not deployed with rollsite, which is required by eggroll clusters", exchange.Name)
+		}
+	}
+	return nil
+}
+
+// validateClusterBackendExternals returns error if the external components requested do not belong to the backend
+func validateClusterBackendExternals(req *ParticipantFATEClusterYAMLCreationRequest) error {
+	switch req.Backend.OrDefault() {
+	case entity.ParticipantFATEBackendSparkRabbitMQ:
+		if req.ExternalPulsar.Enable {
+			return errors.New("external pulsar cannot be used with the spark_rabbitmq backend")
+		}
+	case entity.ParticipantFATEBackendEggroll:
+		if req.ExternalSpark.Enable || req.ExternalHDFS.Enable || req.ExternalPulsar.Enable {
+			return errors.New("external spark, hdfs or pulsar cannot be used with the eggroll backend")
+		}
+	}
+	return nil
+}
+
+// validateClusterBackendInYAML returns error if the engines in the deployment yaml do not match the backend
+func validateClusterBackendInYAML(deploymentYAML string, backend entity.ParticipantFATEBackend) error {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	if computing := fmt.Sprint(m["computing"]); computing != backend.Computing() {
+		return errors.Errorf("computing engine %s in the deployment yaml does not match backend %s", computing, backend.OrDefault())
+	}
+	if federation := fmt.Sprint(m["federation"]); federation != backend.Federation() {
+		return errors.Errorf("federation engine %s in the deployment yaml does not match backend %s", federation, backend.OrDefault())
+	}
+	return nil
+}
+
+// getClusterBackendFromYAML returns the backend matching the engines in the deployment yaml of an existing cluster
+func getClusterBackendFromYAML(deploymentYAML string) (entity.ParticipantFATEBackend, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	computing, federation := fmt.Sprint(m["computing"]), fmt.Sprint(m["federation"])
+	for _, backend := range []entity.ParticipantFATEBackend{
+		entity.ParticipantFATEBackendSparkPulsar,
+		entity.ParticipantFATEBackendSparkRabbitMQ,
+		entity.ParticipantFATEBackendEggroll,
+	} {
+		if computing == backend.Computing() && federation == backend.Federation() {
+			return backend, nil
+		}
+	}
+	return "", errors.Errorf("computing engine %s with federation engine %s is not a supported backend", computing, federation)
+}
+
+// setClusterBackendInYAML converts the cluster deployment yaml generated for Spark with Pulsar to use the backend,
+// the rollsite of an eggroll cluster connects to the exchange if it is not nil
+func setClusterBackendInYAML(deploymentYAML string, backend entity.ParticipantFATEBackend, exchange *entity.ParticipantFATE) (string, error) {
+	backend = backend.OrDefault()
+	if backend == entity.ParticipantFATEBackendSparkPulsar {
+		return deploymentYAML, nil
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	m["computing"] = backend.Computing()
+	m["federation"] = backend.Federation()
+	m["storage"] = backend.Storage()
+
+	// swap the modules of the default backend with the ones of the chosen backend, keeping the common ones and the
+	// shared ones that are not deployed because an external service is used
+	excluded := map[string]bool{}
+	for _, module := range backendModules[entity.ParticipantFATEBackendSparkPulsar] {
+		excluded[module] = true
+	}
+	var moduleList []interface{}
+	for _, module := range backendModules[backend] {
+		if _, ok := excluded[module]; !ok {
+			moduleList = append(moduleList, module)
+		}
+		excluded[module] = false
+	}
+	if existing, ok := m["modules"].([]interface{}); ok {
+		for _, module := range existing {
+			if !excluded[fmt.Sprint(module)] {
+				moduleList = append(moduleList, module)
+			}
+		}
+	}
+	m["modules"] = moduleList
+
+	serviceType := "ClusterIP"
+	if nginx, ok := m["nginx"].(map[string]interface{}); ok && nginx["type"] != nil {
+		serviceType = fmt.Sprint(nginx["type"])
+	}
+	python := getOrCreateYAMLSection(m, "python")
+	ingress, _ := m["ingress"].(map[string]interface{})
+	for module, isExcluded := range excluded {
+		if isExcluded {
+			delete(m, module)
+			delete(python, module)
+			if ingress != nil {
+				delete(ingress, module)
+			}
+		}
+	}
+
+	switch backend {
+	case entity.ParticipantFATEBackendSparkRabbitMQ:
+		// rabbitmq is reached by other parties directly so it is exposed the same way as nginx, and it is protected by
+		// a password generated for the cluster instead of the well-known default one
+		password, err := newRabbitMQPassword()
+		if err != nil {
+			return "", err
+		}
+		m["rabbitmq"] = map[string]interface{}{
+			"type":         serviceType,
+			"default_user": rabbitMQUser,
+			"default_pass": password,
+			"user":         rabbitMQUser,
+			"password":     password,
+			"route_table":  map[string]interface{}{},
+		}
+		python["rabbitmq"] = map[string]interface{}{
+			"host":     "rabbitmq",
+			"mng_port": 15672,
+			"port":     5672,
+			"user":     rabbitMQUser,
+			"password": password,
+		}
+	case entity.ParticipantFATEBackendEggroll:
+		rollsite := map[string]interface{}{
+			"type":      serviceType,
+			"partyList": []interface{}{},
+		}
+		if exchange != nil {
+			if err := validateExchangeBackendAccess(exchange, backend); err != nil {
+				return "", err
+			}
+			access := exchange.AccessInfo[entity.ParticipantFATEServiceNameRollsite]
+			rollsite["exchange"] = map[string]interface{}{
+				"ip":   access.Host,
+				"port": access.Port,
+			}
+		}
+		m["rollsite"] = rollsite
+		m["nodemanager"] = map[string]interface{}{
+			"replicas":                 2,
+			"sessionProcessorsPerNode": 2,
+			"subPath":                  "nodemanager",
+			"storageClass":             python["storageClass"],
+			"accessMode":               "ReadWriteOnce",
+			"size":                     "1Gi",
+		}
+		m["clustermanager"] = map[string]interface{}{}
+	}
+	updatedYAML, err := yaml.Marshal(m)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get final yaml content")
+	}
+	return string(updatedYAML), nil
+}
+
+// newRabbitMQPassword generates a random password for the rabbitmq of a spark_rabbitmq cluster
+func newRabbitMQPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate the rabbitmq password")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// setClusterRabbitMQPassword saves the rabbitmq password in the deployment yaml of a spark_rabbitmq cluster to the
+// cluster, so that it can be given to the peer clusters
+func setClusterRabbitMQPassword(cluster *entity.ParticipantFATE) error {
+	if cluster.Backend.OrDefault() != entity.ParticipantFATEBackendSparkRabbitMQ {
+		return nil
+	}
+	var m struct {
+		RabbitMQ struct {
+			Password string `json:"password"`
+		} `json:"rabbitmq"`
+	}
+	if err := yaml.Unmarshal([]byte(cluster.DeploymentYAML), &m); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	return cluster.SetRabbitMQPassword(m.RabbitMQ.Password)
+}
+
+// setExchangeRollsiteInYAML returns the exchange deployment yaml with the rollsite module added, so that the exchange
+// can route the traffic of eggroll clusters
+func setExchangeRollsiteInYAML(deploymentYAML string) (string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	moduleList, _ := m["modules"].([]interface{})
+	for _, module := range moduleList {
+		if fmt.Sprint(module) == "rollsite" {
+			return deploymentYAML, nil
+		}
+	}
+	m["modules"] = append([]interface{}{"rollsite"}, moduleList...)
+	serviceType := "ClusterIP"
+	if nginx, ok := m["nginx"].(map[string]interface{}); ok && nginx["type"] != nil {
+		serviceType = fmt.Sprint(nginx["type"])
+	}
+	m["rollsite"] = map[string]interface{}{
+		"type":      serviceType,
+		"partyList": []interface{}{},
+	}
+	updatedYAML, err := yaml.Marshal(m)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get final yaml content")
+	}
+	return string(updatedYAML), nil
+}
+
+// buildRollsiteRouteTable adds the route to the eggroll cluster, the traffic goes through the peer exchange if it is not nil
+func buildRollsiteRouteTable(partyList []interface{}, cluster *entity.ParticipantFATE, peer *entity.ParticipantFATE) []interface{} {
+	access := cluster.AccessInfo[entity.ParticipantFATEServiceNameRollsite]
+	if peer != nil {
+		access = peer.AccessInfo[entity.ParticipantFATEServiceNameRollsite]
+	}
+	return append(partyList, rollsitePartyListEntry{
+		PartyID:   cluster.PartyID,
+		PartyIP:   access.Host,
+		PartyPort: access.Port,
+	})
+}
+
+// setClusterDirectRoutesInYAML sets the routes of the cluster that do not go through any exchange: all the routes in a
+// peer-to-peer federation, or the rabbitmq routes of a spark_rabbitmq cluster in a federation with exchanges
+func (s *ParticipantFATEService) setClusterDirectRoutesInYAML(deploymentYAML string, federation *entity.FederationFATE, backend entity.ParticipantFATEBackend) (string, error) {
+	if federation.Mode != entity.FederationFATEModePeerToPeer && backend.OrDefault() != entity.ParticipantFATEBackendSparkRabbitMQ {
+		return deploymentYAML, nil
+	}
+	peerList, err := s.listPeerClusters(federation.UUID, "")
+	if err != nil {
+		return "", err
+	}
+	if federation.Mode == entity.FederationFATEModePeerToPeer {
+		return s.setClusterPeerRoutesInYAML(deploymentYAML, peerList)
+	}
+	return setClusterRabbitMQRoutesInYAML(deploymentYAML, peerList)
+}
+
+// setClusterRabbitMQRoutesInYAML returns the cluster deployment yaml with the rabbitmq route table pointing to the
+// peer clusters directly, as the exchange does not relay rabbitmq traffic
+func setClusterRabbitMQRoutesInYAML(deploymentYAML string, peerList []entity.ParticipantFATE) (string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	rabbitmq, ok := m["rabbitmq"].(map[string]interface{})
+	if !ok {
+		return deploymentYAML, nil
+	}
+	routeTable := map[string]interface{}{}
+	for _, peer := range peerList {
+		if access, ok := peer.AccessInfo[entity.ParticipantFATEServiceNameRabbitMQ]; ok {
+			entry := rabbitMQRouteTableEntry{
+				Host: access.Host,
+				Port: access.Port,
+			}
+			// clusters created before the passwords were recorded have no password
+			if peer.EncryptedRabbitMQPassword != "" {
+				password, err := peer.RabbitMQPassword()
+				if err != nil {
+					return "", errors.Wrapf(err, "failed to get the rabbitmq password of cluster %s", peer.Name)
+				}
+				entry.User = rabbitMQUser
+				entry.Password = password
+			}
+			routeTable[fmt.Sprintf("%d", peer.PartyID)] = entry
+		}
+	}
+	rabbitmq["route_table"] = routeTable
+	updatedYAML, err := yaml.Marshal(m)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get final yaml content")
+	}
+	return string(updatedYAML), nil
+}
+
+// rebuildRabbitMQRouteTables regenerates the rabbitmq route tables of all the managed spark_rabbitmq clusters in a
+// federation with exchanges, and applies the changed ones
+func (s *ParticipantFATEService) rebuildRabbitMQRouteTables(federationUUID string) error {
+	clusterList, err := s.listPeerClusters(federationUUID, "")
+	if err != nil {
+		return err
+	}
+	var errMessages []string
+	for _, cluster := range clusterList {
+		cluster := cluster
+		if !cluster.IsManaged || cluster.Backend.OrDefault() != entity.ParticipantFATEBackendSparkRabbitMQ {
+			continue
+		}
+		if err := s.rebuildClusterPeerRouteTable(&cluster, clusterList, func(deploymentYAML string, peerList []entity.ParticipantFATE) (string, error) {
+			return setClusterRabbitMQRoutesInYAML(deploymentYAML, peerList)
+		}); err != nil {
+			errMessages = append(errMessages, fmt.Sprintf("cluster %s: %v", cluster.Name, err))
+		}
+	}
+	if len(errMessages) > 0 {
+		return errors.Errorf("failed to rebuild rabbitmq route tables: %s", strings.Join(errMessages, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+type testBackendClusterYAML struct {
+	Modules    []string               `json:"modules"`
+	Computing  string                 `json:"computing"`
+	Federation string                 `json:"federation"`
+	Storage    string                 `json:"storage"`
+	Python     map[string]interface{} `json:"python"`
+	Pulsar     map[string]interface{} `json:"pulsar"`
+	Spark      map[string]interface{} `json:"spark"`
+	RabbitMQ   struct {
+		Type       string                             `json:"type"`
+		Password   string                             `json:"password"`
+		RouteTable map[string]rabbitMQRouteTableEntry `json:"route_table"`
+	} `json:"rabbitmq"`
+	Rollsite struct {
+		Exchange struct {
+			IP   string `json:"ip"`
+			Port int    `json:"port"`
+		} `json:"exchange"`
+		PartyList []rollsitePartyListEntry `json:"partyList"`
+	} `json:"rollsite"`
+}
+
+func TestGetClusterDeploymentYAML_Backends(t *testing.T) {
+	exchange := testFATEExchange("exchange-a", "exchange-a-host", true)
+	exchange.AccessInfo[entity.ParticipantFATEServiceNameRollsite] = entity.ParticipantModulesAccess{Host: "exchange-a-host", Port: 9370}
+	participantList := []entity.ParticipantFATE{
+		exchange,
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	participantList[1].Backend = entity.ParticipantFATEBackendSparkRabbitMQ
+	participantList[1].AccessInfo = entity.ParticipantFATEModulesAccessMap{
+		entity.ParticipantFATEServiceNameNginx:    {Host: "cluster-1-host", Port: 9300},
+		entity.ParticipantFATEServiceNameRabbitMQ: {Host: "cluster-1-host", Port: 5672},
+	}
+	assert.NoError(t, participantList[1].SetRabbitMQPassword("cluster-1-password"))
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	req := &ParticipantFATEClusterYAMLCreationRequest{
+		ParticipantFATEExchangeYAMLCreationRequest: ParticipantFATEExchangeYAMLCreationRequest{
+			ChartUUID:   "d81d2b48-930d-4c5e-b522-322b93e8ef39", // from the chart test repo
+			Name:        "test-fate",
+			Namespace:   "test-fate-ns",
+			ServiceType: entity.ParticipantDefaultServiceTypeNodePort,
+		},
+		FederationUUID: "test-federation",
+		PartyID:        7777,
+		Backend:        entity.ParticipantFATEBackendSparkRabbitMQ,
+	}
+	var m testBackendClusterYAML
+	deploymentYAML, err := service.GetClusterDeploymentYAML(req)
+	assert.NoError(t, err)
+	assert.NoError(t, yaml.Unmarshal([]byte(deploymentYAML), &m))
+	assert.Equal(t, "RabbitMQ", m.Federation)
+	assert.Contains(t, m.Modules, "rabbitmq")
+	assert.Contains(t, m.Modules, "spark")
+	assert.NotContains(t, m.Modules, "pulsar")
+	assert.Nil(t, m.Pulsar)
+	assert.NotContains(t, m.Python, "pulsar")
+	assert.Contains(t, m.Python, "rabbitmq")
+	assert.Equal(t, "NodePort", m.RabbitMQ.Type)
+	assert.Equal(t, rabbitMQRouteTableEntry{Host: "cluster-1-host", Port: 5672, User: rabbitMQUser, Password: "cluster-1-password"},
+		m.RabbitMQ.RouteTable["9999"], "rabbitmq should route to the other clusters directly with their credentials")
+	assert.NotEmpty(t, m.RabbitMQ.Password)
+	assert.NotEqual(t, "fate", m.RabbitMQ.Password)
+	assert.Equal(t, m.RabbitMQ.Password, m.Python["rabbitmq"].(map[string]interface{})["password"])
+
+	// each cluster gets its own password, which is saved encrypted with the cluster
+	anotherYAML, err := service.GetClusterDeploymentYAML(req)
+	assert.NoError(t, err)
+	assert.NotContains(t, anotherYAML, m.RabbitMQ.Password)
+	cluster := &entity.ParticipantFATE{
+		Participant: entity.Participant{DeploymentYAML: deploymentYAML},
+		Backend:     entity.ParticipantFATEBackendSparkRabbitMQ,
+	}
+	assert.NoError(t, setClusterRabbitMQPassword(cluster))
+	assert.NotContains(t, cluster.EncryptedRabbitMQPassword, m.RabbitMQ.Password)
+	password, err := cluster.RabbitMQPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, m.RabbitMQ.Password, password)
+
+	participantList[1].Backend = entity.ParticipantFATEBackendEggroll
+	participantList[1].AccessInfo = entity.ParticipantFATEModulesAccessMap{
+		entity.ParticipantFATEServiceNameNginx:    {Host: "cluster-1-host", Port: 9300},
+		entity.ParticipantFATEServiceNameRollsite: {Host: "cluster-1-host", Port: 9370},
+	}
+	req.Backend = entity.ParticipantFATEBackendEggroll
+	m = testBackendClusterYAML{}
+	deploymentYAML, err = service.GetClusterDeploymentYAML(req)
+	assert.NoError(t, err)
+	assert.NoError(t, yaml.Unmarshal([]byte(deploymentYAML), &m))
+	assert.Equal(t, "Eggroll", m.Computing)
+	assert.Equal(t, "Eggroll", m.Federation)
+	assert.Equal(t, "Eggroll", m.Storage)
+	assert.Subset(t, m.Modules, []string{"rollsite", "clustermanager", "nodemanager", "python", "nginx"})
+	for _, module := range []string{"spark", "hdfs", "pulsar"} {
+		assert.NotContains(t, m.Modules, module)
+		assert.NotContains(t, m.Python, module)
+	}
+	assert.Nil(t, m.Spark)
+	assert.Equal(t, "exchange-a-host", m.Rollsite.Exchange.IP)
+	assert.Equal(t, 9370, m.Rollsite.Exchange.Port)
+
+	assert.NoError(t, validateClusterBackendInYAML(deploymentYAML, entity.ParticipantFATEBackendEggroll))
+	assert.Error(t, validateClusterBackendInYAML(deploymentYAML, entity.ParticipantFATEBackendSparkPulsar))
+}
+
+func TestGetClusterDeploymentYAML_BackendRejected(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	participantList[1].Backend = entity.ParticipantFATEBackendSparkPulsar
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	req := &ParticipantFATEClusterYAMLCreationRequest{
+		ParticipantFATEExchangeYAMLCreationRequest: ParticipantFATEExchangeYAMLCreationRequest{
+			ChartUUID:   "d81d2b48-930d-4c5e-b522-322b93e8ef39", // from the chart test repo
+			Name:        "test-fate",
+			Namespace:   "test-fate-ns",
+			ServiceType: entity.ParticipantDefaultServiceTypeNodePort,
+		},
+		FederationUUID: "test-federation",
+		PartyID:        7777,
+		Backend:        "spark_local",
+	}
+	_, err := service.GetClusterDeploymentYAML(req)
+	assert.Error(t, err, "unknown backend should be rejected")
+	req.Backend = entity.ParticipantFATEBackendSparkRabbitMQ
+	_, err = service.GetClusterDeploymentYAML(req)
+	assert.Error(t, err, "rabbitmq cluster cannot talk to the existing pulsar cluster")
+	req.Backend = ""
+	_, err = service.GetClusterDeploymentYAML(req)
+	assert.NoError(t, err, "the default backend is spark with pulsar")
+
+	req.ExternalPulsar.Enable = true
+	req.Backend = entity.ParticipantFATEBackendSparkRabbitMQ
+	assert.Error(t, validateClusterBackendExternals(req))
+	req.ExternalPulsar.Enable = false
+
+	// the exchange has no rollsite to route eggroll clusters
+	participantList = participantList[:1]
+	service = &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			ChartRepo:       &gorm.ChartMockRepo{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+	req.Backend = entity.ParticipantFATEBackendEggroll
+	_, err = service.GetClusterDeploymentYAML(req)
+	assert.Error(t, err)
+}
+
+func TestRebuildRouteTable_Backends(t *testing.T) {
+	exchangeYAML, err := setExchangeRollsiteInYAML(testExchangeDeploymentYAML)
+	assert.NoError(t, err)
+	exchange := testFATEExchange("exchange-a", "exchange-a-host", true)
+	exchange.DeploymentYAML = exchangeYAML
+	participantList := []entity.ParticipantFATE{
+		exchange,
+		testFATECluster("cluster-1", 9999, "", ""),
+		testFATECluster("cluster-2", 10000, "", ""),
+		testFATECluster("cluster-3", 10001, "", ""),
+	}
+	participantList[2].Backend = entity.ParticipantFATEBackendEggroll
+	participantList[2].AccessInfo = entity.ParticipantFATEModulesAccessMap{
+		entity.ParticipantFATEServiceNameNginx:    {Host: "cluster-2-host", Port: 9300},
+		entity.ParticipantFATEServiceNameRollsite: {Host: "cluster-2-host", Port: 9370},
+	}
+	participantList[3].Backend = entity.ParticipantFATEBackendSparkRabbitMQ
+	participantList[3].AccessInfo = entity.ParticipantFATEModulesAccessMap{
+		entity.ParticipantFATEServiceNameNginx:    {Host: "cluster-3-host", Port: 9300},
+		entity.ParticipantFATEServiceNameRabbitMQ: {Host: "cluster-3-host", Port: 5672},
+	}
+	var updatedYAML string
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{
+			UpdateDeploymentYAMLByUUIDFn: func(instance interface{}) error {
+				updatedYAML = instance.(*entity.ParticipantFATE).DeploymentYAML
+				return nil
+			},
+		}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	assert.NoError(t, service.rebuildRouteTable(&participantList[0]))
+
+	var m struct {
+		Modules []string `json:"modules"`
+		Nginx   struct {
+			RouteTable map[string]map[string][]nginxRouteTableEntry `json:"route_table"`
+		} `json:"nginx"`
+		TrafficServer struct {
+			RouteTable struct {
+				SNI []atsRouteTableEntry `json:"sni"`
+			} `json:"route_table"`
+		} `json:"trafficServer"`
+		Rollsite struct {
+			Type      string                   `json:"type"`
+			PartyList []rollsitePartyListEntry `json:"partyList"`
+		} `json:"rollsite"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(updatedYAML), &m))
+	assert.Contains(t, m.Modules, "rollsite")
+	assert.Equal(t, "NodePort", m.Rollsite.Type)
+	assert.Len(t, m.Nginx.RouteTable, 3, "fateflow traffic of all the backends goes through nginx")
+	assert.Equal(t, []atsRouteTableEntry{
+		{FQDN: "cluster-1.example.com", TunnelRoute: "cluster-1-host:6651"},
+	}, m.TrafficServer.RouteTable.SNI)
+	assert.Equal(t, []rollsitePartyListEntry{
+		{PartyID: 10000, PartyIP: "cluster-2-host", PartyPort: 9370},
+	}, m.Rollsite.PartyList)
+}
+
+func TestSetClusterPeerRoutesInYAML_Backends(t *testing.T) {
+	peerList := []entity.ParticipantFATE{
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	peerList[0].Backend = entity.ParticipantFATEBackendEggroll
+	peerList[0].AccessInfo = entity.ParticipantFATEModulesAccessMap{
+		entity.ParticipantFATEServiceNameNginx:    {Host: "cluster-1-host", Port: 9300},
+		entity.ParticipantFATEServiceNameRollsite: {Host: "cluster-1-host", Port: 9370},
+	}
+	deploymentYAML, err := setClusterBackendInYAML(testPeerClusterDeploymentYAML, entity.ParticipantFATEBackendEggroll, nil)
+	assert.NoError(t, err)
+	updatedYAML, err := (&ParticipantFATEService{}).setClusterPeerRoutesInYAML(deploymentYAML, peerList)
+	assert.NoError(t, err)
+
+	var m testBackendClusterYAML
+	assert.NoError(t, yaml.Unmarshal([]byte(updatedYAML), &m))
+	assert.Empty(t, m.Rollsite.Exchange.IP)
+	assert.Equal(t, []rollsitePartyListEntry{
+		{PartyID: 9999, PartyIP: "cluster-1-host", PartyPort: 9370},
+	}, m.Rollsite.PartyList)
+}
+
+func TestParticipantFATEBackend(t *testing.T) {
+	assert.Equal(t, entity.ParticipantFATEBackendSparkPulsar, entity.ParticipantFATEBackend("").OrDefault())
+	assert.True(t, entity.ParticipantFATEBackend("").IsFederationCompatible(entity.ParticipantFATEBackendSparkPulsar))
+	assert.False(t, entity.ParticipantFATEBackendSparkRabbitMQ.IsFederationCompatible(entity.ParticipantFATEBackendSparkPulsar))
+	assert.False(t, entity.ParticipantFATEBackendEggroll.IsFederationCompatible(entity.ParticipantFATEBackendSparkRabbitMQ))
+	assert.NoError(t, entity.ParticipantFATEBackendEggroll.Validate())
+	assert.Error(t, entity.ParticipantFATEBackend("spark_local").Validate())
+}
@@ -0,0 +1,750 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// defaultDiagnosticsImage is used when neither the image nor the participant's registry is configured, a copy of it
+	// is expected in the registry otherwise
+	defaultDiagnosticsImage   = "nicolaka/netshoot:v0.11"
+	defaultDiagnosticsTimeout = time.Minute * 3
+	diagnosticsProbeTimeout   = 10
+)
+
+// FederationFATEProbeType is the type of connectivity check between two participants
+type FederationFATEProbeType string
+
+const (
+	// FederationFATEProbeTypeTCP checks the address can be connected to
+	FederationFATEProbeTypeTCP FederationFATEProbeType = "tcp"
+	// FederationFATEProbeTypeTLS checks the TLS handshake with the expected server name succeeds, and the server
+	// certificate is issued by the FedLCM CA for that name
+	FederationFATEProbeTypeTLS FederationFATEProbeType = "tls"
+	// FederationFATEProbeTypeFATEFlow checks the FATE-Flow of the target cluster can be reached via the nginx route
+	FederationFATEProbeTypeFATEFlow FederationFATEProbeType = "fateflow"
+)
+
+// FederationFATEConnectivityStatus is the result of the connectivity checks
+type FederationFATEConnectivityStatus string
+
+const (
+	FederationFATEConnectivityStatusOK      FederationFATEConnectivityStatus = "ok"
+	FederationFATEConnectivityStatusFailed  FederationFATEConnectivityStatus = "failed"
+	FederationFATEConnectivityStatusSkipped FederationFATEConnectivityStatus = "skipped"
+	// FederationFATEConnectivityStatusPartial means some checks passed and the others were skipped
+	FederationFATEConnectivityStatusPartial FederationFATEConnectivityStatus = "partial"
+)
+
+// FederationFATETopologyEdgeType is the role of a connection between two participants
+type FederationFATETopologyEdgeType string
+
+const (
+	// FederationFATETopologyEdgeTypePrimaryExchange is a cluster connecting to its primary exchange
+	FederationFATETopologyEdgeTypePrimaryExchange FederationFATETopologyEdgeType = "primary_exchange"
+	// FederationFATETopologyEdgeTypeStandbyExchange is a cluster connecting to its standby exchange
+	FederationFATETopologyEdgeTypeStandbyExchange FederationFATETopologyEdgeType = "standby_exchange"
+	// FederationFATETopologyEdgeTypeExchangeRoute is an exchange routing the traffic to one of its clusters
+	FederationFATETopologyEdgeTypeExchangeRoute FederationFATETopologyEdgeType = "exchange_route"
+	// FederationFATETopologyEdgeTypeExchangePeer is an exchange routing the traffic to a peered exchange
+	FederationFATETopologyEdgeTypeExchangePeer FederationFATETopologyEdgeType = "exchange_peer"
+	// FederationFATETopologyEdgeTypeDirect is a cluster connecting to another cluster without any exchange
+	FederationFATETopologyEdgeTypeDirect FederationFATETopologyEdgeType = "direct"
+)
+
+// FederationFATETopologyNode is a participant in the topology graph
+type FederationFATETopologyNode struct {
+	UUID      string                                 `json:"uuid"`
+	Name      string                                 `json:"name"`
+	Type      string                                 `json:"type"`
+	PartyID   int                                    `json:"party_id"`
+	Status    string                                 `json:"status"`
+	IsManaged bool                                   `json:"is_managed"`
+	Backend   entity.ParticipantFATEBackend          `json:"backend,omitempty"`
+	Services  entity.ParticipantFATEModulesAccessMap `json:"services"`
+	Ingresses entity.ParticipantFATEIngressMap       `json:"ingresses"`
+}
+
+// FederationFATETopologyEdge is a connection from one participant to another in the topology graph
+type FederationFATETopologyEdge struct {
+	Source   string                              `json:"source"`
+	Target   string                              `json:"target"`
+	Type     FederationFATETopologyEdgeType      `json:"type"`
+	Services []entity.ParticipantFATEServiceName `json:"services"`
+	// Status is only set in the diagnostics report
+	Status FederationFATEConnectivityStatus `json:"status,omitempty"`
+}
+
+// FederationFATETopology is the graph of the participants in a FATE federation and the connections between them
+type FederationFATETopology struct {
+	FederationUUID string                       `json:"federation_uuid"`
+	Mode           string                       `json:"mode"`
+	Nodes          []FederationFATETopologyNode `json:"nodes"`
+	Edges          []FederationFATETopologyEdge `json:"edges"`
+}
+
+// FederationFATEConnectivityCheck is the result of one probe from a participant to a service of another participant
+type FederationFATEConnectivityCheck struct {
+	Source     string                            `json:"source"`
+	SourceName string                            `json:"source_name"`
+	Target     string                            `json:"target"`
+	TargetName string                            `json:"target_name"`
+	Probe      FederationFATEProbeType           `json:"probe"`
+	Service    entity.ParticipantFATEServiceName `json:"service"`
+	Address    string                            `json:"address"`
+	ServerName string                            `json:"server_name,omitempty"`
+	Status     FederationFATEConnectivityStatus  `json:"status"`
+	Message    string                            `json:"message"`
+	Suggestion string                            `json:"suggestion,omitempty"`
+	// Command can be run in the source participant's cluster to repeat the check
+	Command string `json:"command,omitempty"`
+
+	edge   *FederationFATETopologyEdge
+	target *entity.ParticipantFATE
+}
+
+// FederationFATEDiagnosticsReport contains the topology of the federation and the connectivity between its participants
+type FederationFATEDiagnosticsReport struct {
+	FederationUUID string                 `json:"federation_uuid"`
+	StartedAt      time.Time              `json:"started_at"`
+	FinishedAt     time.Time              `json:"finished_at"`
+	Topology       FederationFATETopology `json:"topology"`
+	// Matrix contains the overall status of the connection from the source participant, the first key, to the target
+	// participant, the second key
+	Matrix map[string]map[string]FederationFATEConnectivityStatus `json:"matrix"`
+	Checks []FederationFATEConnectivityCheck                      `json:"checks"`
+}
+
+// GetFederationTopology returns the participants of the federation and the connections between them, built from their
+// access info
+func (s *ParticipantFATEService) GetFederationTopology(federationUUID string) (*FederationFATETopology, error) {
+	topology, _, err := s.buildFederationTopology(federationUUID)
+	return topology, err
+}
+
+// StartFederationDiagnostics creates a diagnostics record and runs DiagnoseFederation in the background, the report or
+// the failure is saved in the returned record, and the returned *sync.WaitGroup can be used to wait for its completion
+func (s *ParticipantFATEService) StartFederationDiagnostics(federationUUID string) (*entity.FederationFATEDiagnostics, *sync.WaitGroup, error) {
+	if _, err := s.loadFATEFederation(federationUUID); err != nil {
+		return nil, nil, err
+	}
+	diagnostics := &entity.FederationFATEDiagnostics{
+		UUID:           uuid.NewV4().String(),
+		FederationUUID: federationUUID,
+		Status:         entity.FederationFATEDiagnosticsStatusRunning,
+	}
+	if err := s.DiagnosticsRepo.Create(diagnostics); err != nil {
+		return nil, nil, err
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := func() error {
+			report, err := s.DiagnoseFederation(federationUUID)
+			if err != nil {
+				return err
+			}
+			reportJSON, err := json.Marshal(report)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal the report")
+			}
+			diagnostics.Report = string(reportJSON)
+			return nil
+		}(); err != nil {
+			log.Err(err).Str("federation", federationUUID).Msgf("diagnostics %s failed", diagnostics.UUID)
+			diagnostics.Status = entity.FederationFATEDiagnosticsStatusFailed
+			diagnostics.Message = err.Error()
+		} else {
+			diagnostics.Status = entity.FederationFATEDiagnosticsStatusSucceeded
+		}
+		if err := s.DiagnosticsRepo.UpdateStatusAndReportByUUID(diagnostics); err != nil {
+			log.Err(err).Msgf("failed to save the result of diagnostics %s", diagnostics.UUID)
+		}
+	}()
+	return diagnostics, wg, nil
+}
+
+// DiagnoseFederation runs probe jobs in the clusters of the managed participants to check the connections in the
+// federation topology, and returns the results with suggestions for the failed ones
+func (s *ParticipantFATEService) DiagnoseFederation(federationUUID string) (*FederationFATEDiagnosticsReport, error) {
+	report := &FederationFATEDiagnosticsReport{
+		FederationUUID: federationUUID,
+		StartedAt:      time.Now(),
+	}
+	topology, participantMap, err := s.buildFederationTopology(federationUUID)
+	if err != nil {
+		return nil, err
+	}
+	federation, err := s.loadFATEFederation(federationUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	checksBySource := map[string][]*FederationFATEConnectivityCheck{}
+	var checkList []*FederationFATEConnectivityCheck
+	for index := range topology.Edges {
+		edge := &topology.Edges[index]
+		for _, check := range buildConnectivityChecks(edge, participantMap[edge.Source], participantMap[edge.Target], federation) {
+			checkList = append(checkList, check)
+			if check.Status == "" {
+				checksBySource[edge.Source] = append(checksBySource[edge.Source], check)
+			}
+		}
+	}
+
+	caPEM := ""
+	if ca, err := s.CertificateService.DefaultCA(); err != nil {
+		log.Warn().Err(err).Msg("no CA configured, server certificates won't be verified by the TLS probes")
+	} else if caCert, err := ca.RootCert(); err != nil {
+		log.Warn().Err(err).Msg("failed to get the CA certificate, server certificates won't be verified by the TLS probes")
+	} else {
+		caPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}))
+	}
+
+	wg := &sync.WaitGroup{}
+	for sourceUUID, sourceChecks := range checksBySource {
+		source := participantMap[sourceUUID]
+		sourceChecks := sourceChecks
+		if !source.IsManaged {
+			for _, check := range sourceChecks {
+				check.Status = FederationFATEConnectivityStatusSkipped
+				check.Message = fmt.Sprintf("%s %s is not managed by FedLCM, run the command in its cluster to check the connection", source.Type, source.Name)
+			}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runConnectivityChecks(source, sourceChecks, caPEM)
+		}()
+	}
+	wg.Wait()
+
+	report.Matrix = map[string]map[string]FederationFATEConnectivityStatus{}
+	for index := range topology.Edges {
+		edge := &topology.Edges[index]
+		var statusList []FederationFATEConnectivityStatus
+		for _, check := range checkList {
+			if check.edge == edge {
+				statusList = append(statusList, check.Status)
+			}
+		}
+		edge.Status = aggregateConnectivityStatus(statusList)
+		if report.Matrix[edge.Source] == nil {
+			report.Matrix[edge.Source] = map[string]FederationFATEConnectivityStatus{}
+		}
+		report.Matrix[edge.Source][edge.Target] = aggregateConnectivityStatus([]FederationFATEConnectivityStatus{
+			report.Matrix[edge.Source][edge.Target], edge.Status})
+	}
+	report.Checks = make([]FederationFATEConnectivityCheck, 0, len(checkList))
+	for _, check := range checkList {
+		report.Checks = append(report.Checks, *check)
+	}
+	report.Topology = *topology
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// buildFederationTopology returns the topology graph of the federation and the participants keyed by their uuid
+func (s *ParticipantFATEService) buildFederationTopology(federationUUID string) (*FederationFATETopology, map[string]*entity.ParticipantFATE, error) {
+	federation, err := s.loadFATEFederation(federationUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	instanceList, err := s.ParticipantFATERepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list participants")
+	}
+	participantList, _ := instanceList.([]entity.ParticipantFATE)
+
+	topology := &FederationFATETopology{
+		FederationUUID: federationUUID,
+		Mode:           federation.Mode.String(),
+		Nodes:          make([]FederationFATETopologyNode, 0, len(participantList)),
+		Edges:          make([]FederationFATETopologyEdge, 0),
+	}
+	participantMap := map[string]*entity.ParticipantFATE{}
+	var exchangeList, clusterList []*entity.ParticipantFATE
+	for index := range participantList {
+		participant := &participantList[index]
+		participantMap[participant.UUID] = participant
+		node := FederationFATETopologyNode{
+			UUID:      participant.UUID,
+			Name:      participant.Name,
+			Type:      participant.Type.String(),
+			Status:    participant.Status.String(),
+			IsManaged: participant.IsManaged,
+			Services:  participant.AccessInfo,
+			Ingresses: participant.IngressInfo,
+		}
+		if participant.Type == entity.ParticipantFATETypeExchange {
+			exchangeList = append(exchangeList, participant)
+		} else {
+			node.PartyID = participant.PartyID
+			node.Backend = participant.Backend.OrDefault()
+			clusterList = append(clusterList, participant)
+		}
+		topology.Nodes = append(topology.Nodes, node)
+	}
+
+	addEdge := func(source, target *entity.ParticipantFATE, edgeType FederationFATETopologyEdgeType) {
+		if source == nil || target == nil {
+			return
+		}
+		topology.Edges = append(topology.Edges, FederationFATETopologyEdge{
+			Source:   source.UUID,
+			Target:   target.UUID,
+			Type:     edgeType,
+			Services: edgeTargetServices(edgeType, source, target),
+		})
+	}
+	if federation.Mode == entity.FederationFATEModePeerToPeer {
+		for _, source := range clusterList {
+			for _, target := range clusterList {
+				if source != target {
+					addEdge(source, target, FederationFATETopologyEdgeTypeDirect)
+				}
+			}
+		}
+		return topology, participantMap, nil
+	}
+
+	defaultExchangeUUID := ""
+	if len(exchangeList) > 0 {
+		defaultExchangeUUID = exchangeList[0].UUID
+	}
+	for _, source := range exchangeList {
+		for _, target := range exchangeList {
+			if source != target {
+				addEdge(source, target, FederationFATETopologyEdgeTypeExchangePeer)
+			}
+		}
+	}
+	for _, cluster := range clusterList {
+		primary := participantMap[clusterExchangeUUID(cluster, defaultExchangeUUID)]
+		addEdge(cluster, primary, FederationFATETopologyEdgeTypePrimaryExchange)
+		addEdge(primary, cluster, FederationFATETopologyEdgeTypeExchangeRoute)
+		if cluster.StandbyExchangeUUID != "" {
+			addEdge(cluster, participantMap[cluster.StandbyExchangeUUID], FederationFATETopologyEdgeTypeStandbyExchange)
+		}
+		// rabbitmq traffic doesn't go through the exchanges
+		if cluster.Backend.OrDefault() == entity.ParticipantFATEBackendSparkRabbitMQ {
+			for _, peer := range clusterList {
+				if peer != cluster && peer.Backend.OrDefault() == entity.ParticipantFATEBackendSparkRabbitMQ {
+					addEdge(cluster, peer, FederationFATETopologyEdgeTypeDirect)
+				}
+			}
+		}
+	}
+	return topology, participantMap, nil
+}
+
+// edgeTargetServices returns the services of the target participant used by the connection
+func edgeTargetServices(edgeType FederationFATETopologyEdgeType, source, target *entity.ParticipantFATE) []entity.ParticipantFATEServiceName {
+	// the backend of the cluster decides the federation engine used on the connection
+	backend := source.Backend.OrDefault()
+	if source.Type == entity.ParticipantFATETypeExchange {
+		backend = target.Backend.OrDefault()
+	}
+	if edgeType == FederationFATETopologyEdgeTypeDirect && backend == entity.ParticipantFATEBackendSparkRabbitMQ {
+		return []entity.ParticipantFATEServiceName{entity.ParticipantFATEServiceNameNginx, entity.ParticipantFATEServiceNameRabbitMQ}
+	}
+	if edgeType == FederationFATETopologyEdgeTypeExchangePeer {
+		services := []entity.ParticipantFATEServiceName{entity.ParticipantFATEServiceNameNginx, entity.ParticipantFATEServiceNameATS}
+		if _, ok := target.AccessInfo[entity.ParticipantFATEServiceNameRollsite]; ok {
+			services = append(services, entity.ParticipantFATEServiceNameRollsite)
+		}
+		return services
+	}
+	services := []entity.ParticipantFATEServiceName{entity.ParticipantFATEServiceNameNginx}
+	switch backend {
+	case entity.ParticipantFATEBackendSparkPulsar:
+		if target.Type == entity.ParticipantFATETypeExchange {
+			services = append(services, entity.ParticipantFATEServiceNameATS)
+		} else {
+			services = append(services, entity.ParticipantFATEServiceNamePulsar)
+		}
+	case entity.ParticipantFATEBackendEggroll:
+		services = append(services, entity.ParticipantFATEServiceNameRollsite)
+	}
+	return services
+}
+
+// buildConnectivityChecks returns the checks of the connection. Checks that can't be run are returned with their
+// status set.
+func buildConnectivityChecks(edge *FederationFATETopologyEdge, source, target *entity.ParticipantFATE, federation *entity.FederationFATE) []*FederationFATEConnectivityCheck {
+	var checkList []*FederationFATEConnectivityCheck
+	newCheck := func(probe FederationFATEProbeType, serviceName entity.ParticipantFATEServiceName) *FederationFATEConnectivityCheck {
+		check := &FederationFATEConnectivityCheck{
+			Source:     source.UUID,
+			SourceName: source.Name,
+			Target:     target.UUID,
+			TargetName: target.Name,
+			Probe:      probe,
+			Service:    serviceName,
+			edge:       edge,
+			target:     target,
+		}
+		checkList = append(checkList, check)
+		return check
+	}
+	for _, serviceName := range edge.Services {
+		access, ok := target.AccessInfo[serviceName]
+		probeList := []FederationFATEProbeType{FederationFATEProbeTypeTCP}
+		if serviceName == entity.ParticipantFATEServiceNameATS || serviceName == entity.ParticipantFATEServiceNamePulsar {
+			probeList = []FederationFATEProbeType{FederationFATEProbeTypeTLS}
+		} else if serviceName == entity.ParticipantFATEServiceNameNginx && target.Type == entity.ParticipantFATETypeCluster {
+			probeList = append(probeList, FederationFATEProbeTypeFATEFlow)
+		}
+		for _, probe := range probeList {
+			check := newCheck(probe, serviceName)
+			if !ok {
+				check.Status = FederationFATEConnectivityStatusFailed
+				check.Message = fmt.Sprintf("%s %s has no access info of the %s service", target.Type, target.Name, serviceName)
+				check.Suggestion = fmt.Sprintf("make sure the %s service is deployed, and update the access info of %s", serviceName, target.Name)
+				continue
+			}
+			check.Address = fmt.Sprintf("%s:%d", access.Host, access.Port)
+			if probe == FederationFATEProbeTypeTLS {
+				check.ServerName = access.FQDN
+				if check.ServerName == "" && serviceName == entity.ParticipantFATEServiceNameATS {
+					check.ServerName = fmt.Sprintf("proxy.%s", federation.Domain)
+				}
+			}
+			check.Command = probeCommand(check, "")
+		}
+	}
+	for _, participant := range []*entity.ParticipantFATE{source, target} {
+		if participant.Status != entity.ParticipantFATEStatusActive {
+			for _, check := range checkList {
+				if check.Status == "" {
+					check.Status = FederationFATEConnectivityStatusSkipped
+					check.Message = fmt.Sprintf("%s %s is in %s status", participant.Type, participant.Name, participant.Status)
+				}
+			}
+		}
+	}
+	return checkList
+}
+
+// runConnectivityChecks runs the checks in a probe job in the cluster of the source participant and sets their results
+func (s *ParticipantFATEService) runConnectivityChecks(source *entity.ParticipantFATE, checkList []*FederationFATEConnectivityCheck, caPEM string) {
+	if err := func() error {
+		endpointMgr, _, closer, err := s.buildKubeFATEMgrAndClient(source.EndpointUUID)
+		if closer != nil {
+			defer closer()
+		}
+		if err != nil {
+			return err
+		}
+		script := buildProbeScript(checkList)
+		image := viper.GetString("lifecyclemanager.diagnostics.image")
+		if image == "" {
+			image = defaultDiagnosticsImage
+		}
+		timeout := viper.GetDuration("lifecyclemanager.diagnostics.timeout")
+		if timeout <= 0 {
+			timeout = defaultDiagnosticsTimeout
+		}
+		job := buildProbeJob(image, script, caPEM, source.ExtraAttribute.UseRegistrySecret)
+		log.Info().Str("participant", source.Name).Msgf("running probe job %s with %d checks in namespace %s", job.Name, len(checkList), source.Namespace)
+		output, err := runProbeJob(endpointMgr.K8sClient(), source.Namespace, job, timeout)
+		if err != nil {
+			return errors.Wrapf(err, "failed to run the probe job with image %s", image)
+		}
+		parseProbeOutput(output, checkList)
+		return nil
+	}(); err != nil {
+		for _, check := range checkList {
+			check.Status = FederationFATEConnectivityStatusFailed
+			check.Message = err.Error()
+			check.Suggestion = fmt.Sprintf("make sure the KubeFATE endpoint of %s is available and the probe image can be pulled in namespace %s, "+
+				"or set LIFECYCLEMANAGER_DIAGNOSTICS_IMAGE to an image with sh, nc, openssl and curl", source.Name, source.Namespace)
+		}
+	}
+}
+
+// diagnosticsImage returns the image of the probe job run for the participant. The configured image takes precedence,
+// then the default image in the registry of the participant, so the job is pulled from where the FATE images are.
+func (s *ParticipantFATEService) diagnosticsImage(participant *entity.ParticipantFATE) string {
+	if image := viper.GetString("lifecyclemanager.diagnostics.image"); image != "" {
+		return image
+	}
+	settings, err := s.GetDeploymentSettings(participant)
+	if err != nil {
+		log.Warn().Err(err).Msgf("failed to get the registry of participant %s, using the default probe image", participant.Name)
+	} else if settings.Registry != "" {
+		return strings.TrimSuffix(settings.Registry, "/") + "/" + path.Base(defaultDiagnosticsImage)
+	}
+	return defaultDiagnosticsImage
+}
+
+// shellQuote quotes the string to be used as a single argument in the probe script
+func shellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
+
+// probeCommand returns the shell command of the check, caArgs are the openssl arguments to verify the server certificate
+func probeCommand(check *FederationFATEConnectivityCheck, caArgs string) string {
+	host, port := check.Address[:strings.LastIndex(check.Address, ":")], check.Address[strings.LastIndex(check.Address, ":")+1:]
+	switch check.Probe {
+	case FederationFATEProbeTypeTLS:
+		command := fmt.Sprintf("timeout %d openssl s_client -connect %s", diagnosticsProbeTimeout, shellQuote(check.Address))
+		if check.ServerName != "" {
+			command += fmt.Sprintf(" -servername %s -verify_hostname %s", shellQuote(check.ServerName), shellQuote(check.ServerName))
+		}
+		if caArgs != "" {
+			command += " " + caArgs
+		}
+		return command + " </dev/null"
+	case FederationFATEProbeTypeFATEFlow:
+		return fmt.Sprintf("curl -sS -m %d -o /dev/null -w '%%{http_code}' -X POST -H %s http://%s/v1/version/get",
+			diagnosticsProbeTimeout, shellQuote(fmt.Sprintf("dest-party-id: %d", check.target.PartyID)), shellQuote(check.Address))
+	}
+	return fmt.Sprintf("nc -z -v -w %d %s %s", diagnosticsProbeTimeout, shellQuote(host), shellQuote(port))
+}
+
+// buildProbeScript returns the script running all the checks and printing one "PROBE <index> <OK|FAIL> <output>" line
+// for each of them
+func buildProbeScript(checkList []*FederationFATEConnectivityCheck) string {
+	var builder strings.Builder
+	builder.WriteString(`CA_ARGS=""
+if [ -n "$CA_PEM" ]; then echo "$CA_PEM" > /tmp/ca.pem; CA_ARGS="-CAfile /tmp/ca.pem -verify_return_error"; fi
+`)
+	for index, check := range checkList {
+		command := probeCommand(check, "$CA_ARGS")
+		switch check.Probe {
+		case FederationFATEProbeTypeFATEFlow:
+			fmt.Fprintf(&builder, `out=$(%s 2>&1)
+if [ "$out" = "200" ]; then echo "PROBE %d OK"; else echo "PROBE %d FAIL $(echo "$out" | tr '\n' ' ')"; fi
+`, command, index, index)
+		case FederationFATEProbeTypeTLS:
+			fmt.Fprintf(&builder, `if out=$(%s 2>&1); then echo "PROBE %d OK $(echo "$out" | grep -m1 '^subject=')"; else echo "PROBE %d FAIL $(echo "$out" | grep -iE 'error|errno|refused|timed out|no peer|bad address' | head -3 | tr '\n' ' ')"; fi
+`, command, index, index)
+		default:
+			fmt.Fprintf(&builder, `if out=$(%s 2>&1); then echo "PROBE %d OK"; else echo "PROBE %d FAIL $(echo "$out" | tr '\n' ' ')"; fi
+`, command, index, index)
+		}
+	}
+	return builder.String()
+}
+
+// buildProbeJob returns the job running the probe script
+func buildProbeJob(image, script, caPEM string, useRegistrySecret bool) *batchv1.Job {
+	backoffLimit := int32(0)
+	ttl := int32(300)
+	job := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "fedlcm-diagnostics-" + strings.Split(uuid.NewV4().String(), "-")[0],
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "fedlcm",
+				"app.kubernetes.io/component":  "diagnostics",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "probe",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", script},
+							Env: []corev1.EnvVar{
+								{
+									Name:  "CA_PEM",
+									Value: caPEM,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if useRegistrySecret {
+		job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: imagePullSecretsNameFATE}}
+	}
+	return job
+}
+
+// parseProbeOutput sets the results of the checks from the output of the probe script
+func parseProbeOutput(output string, checkList []*FederationFATEConnectivityCheck) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 4)
+		if len(fields) < 3 || fields[0] != "PROBE" {
+			continue
+		}
+		index, err := strconv.Atoi(fields[1])
+		if err != nil || index < 0 || index >= len(checkList) {
+			continue
+		}
+		check := checkList[index]
+		if len(fields) == 4 {
+			check.Message = strings.TrimSpace(fields[3])
+		}
+		if fields[2] == "OK" {
+			check.Status = FederationFATEConnectivityStatusOK
+			if check.Message == "" {
+				check.Message = fmt.Sprintf("%s check to %s passed", check.Probe, check.Address)
+			}
+		} else {
+			check.Status = FederationFATEConnectivityStatusFailed
+			check.Suggestion = suggestProbeFix(check)
+		}
+	}
+	for _, check := range checkList {
+		if check.Status == "" {
+			check.Status = FederationFATEConnectivityStatusFailed
+			check.Message = "the probe job finished without the result of this check"
+		}
+	}
+}
+
+// suggestProbeFix returns what to check according to the error of the failed probe
+func suggestProbeFix(check *FederationFATEConnectivityCheck) string {
+	message := strings.ToLower(check.Message)
+	target := fmt.Sprintf("%s %s", check.target.Type, check.TargetName)
+	switch {
+	case strings.Contains(message, "bad address") || strings.Contains(message, "could not resolve") ||
+		strings.Contains(message, "name does not resolve") || strings.Contains(message, "name or service not known"):
+		return fmt.Sprintf("the host of %s can't be resolved from %s, use an IP address or a resolvable domain name in the access info of %s",
+			check.Address, check.SourceName, target)
+	case strings.Contains(message, "refused"):
+		return fmt.Sprintf("nothing is listening on %s, check the %s service of %s is running and its port matches the access info",
+			check.Address, check.Service, target)
+	case strings.Contains(message, "timed out") || strings.Contains(message, "timeout") || strings.Contains(message, "no route to host"):
+		return fmt.Sprintf("%s is unreachable from %s, check the LoadBalancer IP or the NodePort of the %s service, and the firewall rules and network policies between them",
+			check.Address, check.SourceName, check.Service)
+	case strings.Contains(message, "hostname mismatch"):
+		return fmt.Sprintf("the certificate of the %s service of %s is not issued for %s, re-issue it with this name or fix the FQDN in the access info",
+			check.Service, target, check.ServerName)
+	case strings.Contains(message, "certificate has expired"):
+		return fmt.Sprintf("the certificate of the %s service of %s has expired, rotate it from the certificate management page", check.Service, target)
+	case strings.Contains(message, "unable to get local issuer") || strings.Contains(message, "self signed") || strings.Contains(message, "self-signed"):
+		return fmt.Sprintf("the certificate of the %s service of %s is not issued by the CA configured in FedLCM", check.Service, target)
+	case strings.Contains(message, "no peer certificate") || strings.Contains(message, "handshake failure") || strings.Contains(message, "unexpected eof"):
+		if check.Service == entity.ParticipantFATEServiceNameATS {
+			return fmt.Sprintf("the traffic server of %s rejected the server name %s, check its certificate and rebuild the route tables of the exchange", target, check.ServerName)
+		}
+		return fmt.Sprintf("the TLS handshake with %s failed, check the %s service of %s is serving TLS with a valid certificate", check.Address, check.Service, target)
+	}
+	if check.Probe == FederationFATEProbeTypeFATEFlow {
+		return fmt.Sprintf("FATE-Flow of %s can't be reached via nginx at %s, check the fateflow pod of %s and the nginx route table entry of party %d",
+			check.TargetName, check.Address, check.TargetName, check.target.PartyID)
+	}
+	return fmt.Sprintf("check the %s service of %s and the network between %s and %s", check.Service, target, check.SourceName, check.TargetName)
+}
+
+// aggregateConnectivityStatus returns the overall status of a list of checks, ignoring the empty ones
+func aggregateConnectivityStatus(statusList []FederationFATEConnectivityStatus) FederationFATEConnectivityStatus {
+	statusSet := map[FederationFATEConnectivityStatus]bool{}
+	for _, status := range statusList {
+		if status == FederationFATEConnectivityStatusPartial {
+			statusSet[FederationFATEConnectivityStatusOK] = true
+			statusSet[FederationFATEConnectivityStatusSkipped] = true
+		} else if status != "" {
+			statusSet[status] = true
+		}
+	}
+	switch {
+	case statusSet[FederationFATEConnectivityStatusFailed]:
+		return FederationFATEConnectivityStatusFailed
+	case statusSet[FederationFATEConnectivityStatusOK] && statusSet[FederationFATEConnectivityStatusSkipped]:
+		return FederationFATEConnectivityStatusPartial
+	case statusSet[FederationFATEConnectivityStatusOK]:
+		return FederationFATEConnectivityStatusOK
+	}
+	return FederationFATEConnectivityStatusSkipped
+}
+
+// For mocking purpose
+var (
+	// runProbeJob creates the job, waits for it to finish within the timeout and returns the logs of its pod. The job
+	// is deleted afterwards.
+	runProbeJob = func(client kubernetes.Client, namespace string, job *batchv1.Job, timeout time.Duration) (string, error) {
+		jobClient := client.GetClientSet().BatchV1().Jobs(namespace)
+		if _, err := jobClient.Create(context.TODO(), job, v1.CreateOptions{}); err != nil {
+			return "", errors.Wrapf(err, "failed to create job %s", job.Name)
+		}
+		defer func() {
+			propagation := v1.DeletePropagationBackground
+			if err := jobClient.Delete(context.TODO(), job.Name, v1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+				log.Warn().Err(err).Msgf("failed to delete probe job %s", job.Name)
+			}
+		}()
+		deadline := time.Now().Add(timeout)
+		for {
+			current, err := jobClient.Get(context.TODO(), job.Name, v1.GetOptions{})
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to get job %s", job.Name)
+			}
+			if current.Status.Failed > 0 {
+				return "", errors.Errorf("job %s failed", job.Name)
+			}
+			if current.Status.Succeeded > 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				return "", errors.Errorf("job %s didn't finish in %v", job.Name, timeout)
+			}
+			time.Sleep(time.Second * 2)
+		}
+		podList, err := client.GetClientSet().CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{
+			LabelSelector: "job-name=" + job.Name,
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to list pods of job %s", job.Name)
+		}
+		if len(podList.Items) == 0 {
+			return "", errors.Errorf("no pod found for job %s", job.Name)
+		}
+		sort.Slice(podList.Items, func(i, j int) bool {
+			return podList.Items[i].CreationTimestamp.After(podList.Items[j].CreationTimestamp.Time)
+		})
+		logs, err := client.GetClientSet().CoreV1().Pods(namespace).GetLogs(podList.Items[0].Name, &corev1.PodLogOptions{}).Do(context.TODO()).Raw()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get logs of pod %s", podList.Items[0].Name)
+		}
+		return string(logs), nil
+	}
+)
@@ -0,0 +1,277 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func TestGetFederationTopology(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATEExchange("exchange-b", "exchange-b-host", false),
+		testFATECluster("cluster-1", 9999, "", ""),
+		testFATECluster("cluster-2", 10000, "exchange-b", "exchange-a"),
+	}
+	participantList[2].IsManaged = true
+	participantList[3].IsManaged = true
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	topology, err := service.GetFederationTopology("test-federation")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.FederationFATEModeExchange.String(), topology.Mode)
+	assert.Len(t, topology.Nodes, 4)
+	assert.Equal(t, entity.ParticipantFATEBackendSparkPulsar, topology.Nodes[2].Backend)
+
+	edges := map[string]FederationFATETopologyEdgeType{}
+	for _, edge := range topology.Edges {
+		edges[edge.Source+"->"+edge.Target] = edge.Type
+	}
+	assert.Equal(t, map[string]FederationFATETopologyEdgeType{
+		"exchange-a->exchange-b": FederationFATETopologyEdgeTypeExchangePeer,
+		"exchange-b->exchange-a": FederationFATETopologyEdgeTypeExchangePeer,
+		"cluster-1->exchange-a":  FederationFATETopologyEdgeTypePrimaryExchange,
+		"exchange-a->cluster-1":  FederationFATETopologyEdgeTypeExchangeRoute,
+		"cluster-2->exchange-b":  FederationFATETopologyEdgeTypePrimaryExchange,
+		"exchange-b->cluster-2":  FederationFATETopologyEdgeTypeExchangeRoute,
+		"cluster-2->exchange-a":  FederationFATETopologyEdgeTypeStandbyExchange,
+	}, edges)
+}
+
+func TestDiagnoseFederation(t *testing.T) {
+	runProbeJobOrig := runProbeJob
+	defer func() {
+		runProbeJob = runProbeJobOrig
+	}()
+	commandRegexp := regexp.MustCompile(`out=\$\((.*) 2>&1\)`)
+	probeRegexp := regexp.MustCompile(`PROBE (\d+) OK`)
+	lock := &sync.Mutex{}
+	var jobList []*batchv1.Job
+	runProbeJob = func(client kubernetes.Client, namespace string, job *batchv1.Job, timeout time.Duration) (string, error) {
+		lock.Lock()
+		jobList = append(jobList, job)
+		lock.Unlock()
+		var output []string
+		command := ""
+		for _, line := range strings.Split(job.Spec.Template.Spec.Containers[0].Command[2], "\n") {
+			if match := commandRegexp.FindStringSubmatch(line); match != nil {
+				command = match[1]
+			}
+			if match := probeRegexp.FindStringSubmatch(line); match != nil {
+				// the pulsar of cluster-1 is not reachable
+				if strings.Contains(command, "cluster-1-host:6651") {
+					output = append(output, fmt.Sprintf("PROBE %s FAIL connect: Connection timed out connect:errno=110", match[1]))
+				} else {
+					output = append(output, fmt.Sprintf("PROBE %s OK", match[1]))
+				}
+			}
+		}
+		return strings.Join(output, "\n"), nil
+	}
+
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATEExchange("exchange-b", "exchange-b-host", false),
+		testFATECluster("cluster-1", 9999, "", ""),
+		testFATECluster("cluster-2", 10000, "exchange-b", "exchange-a"),
+	}
+	participantList[2].IsManaged = true
+	participantList[3].IsManaged = true
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:     &mock.FederationFATERepoMock{},
+			EventService:       &mockEventServiceInt{},
+			CertificateService: &mockParticipantFATECertificateServiceInt{},
+			EndpointService:    &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	report, err := service.DiagnoseFederation("test-federation")
+	assert.NoError(t, err)
+	// one job for each managed participant
+	assert.Len(t, jobList, 3)
+	assert.Equal(t, map[string]map[string]FederationFATEConnectivityStatus{
+		"exchange-a": {
+			"exchange-b": FederationFATEConnectivityStatusOK,
+			"cluster-1":  FederationFATEConnectivityStatusFailed,
+		},
+		"exchange-b": {
+			"exchange-a": FederationFATEConnectivityStatusSkipped,
+			"cluster-2":  FederationFATEConnectivityStatusSkipped,
+		},
+		"cluster-1": {
+			"exchange-a": FederationFATEConnectivityStatusOK,
+		},
+		"cluster-2": {
+			"exchange-a": FederationFATEConnectivityStatusOK,
+			"exchange-b": FederationFATEConnectivityStatusOK,
+		},
+	}, report.Matrix)
+
+	for _, check := range report.Checks {
+		switch {
+		case check.Source == "exchange-a" && check.Service == entity.ParticipantFATEServiceNamePulsar:
+			assert.Equal(t, FederationFATEConnectivityStatusFailed, check.Status)
+			assert.Equal(t, "cluster-1.example.com", check.ServerName)
+			assert.Contains(t, check.Suggestion, "NodePort")
+		case check.Source == "exchange-b":
+			assert.Contains(t, check.Message, "not managed")
+			assert.NotEmpty(t, check.Command)
+		case check.Source == "cluster-1" && check.Service == entity.ParticipantFATEServiceNameATS:
+			assert.Equal(t, "proxy.test.example.com", check.ServerName)
+			assert.Contains(t, check.Command, "-servername 'proxy.test.example.com'")
+		case check.Target == "cluster-1" && check.Probe == FederationFATEProbeTypeFATEFlow:
+			assert.Contains(t, check.Command, "dest-party-id: 9999")
+		}
+	}
+}
+
+func TestDiagnoseFederation_InactiveAndMissingAccess(t *testing.T) {
+	runProbeJobOrig := runProbeJob
+	defer func() {
+		runProbeJob = runProbeJobOrig
+	}()
+	runProbeJob = func(client kubernetes.Client, namespace string, job *batchv1.Job, timeout time.Duration) (string, error) {
+		t.Errorf("no probe job should be run")
+		return "", nil
+	}
+
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	participantList[0].Status = entity.ParticipantFATEStatusFailed
+	delete(participantList[1].AccessInfo, entity.ParticipantFATEServiceNamePulsar)
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:     &mock.FederationFATERepoMock{},
+			EventService:       &mockEventServiceInt{},
+			CertificateService: &mockParticipantFATECertificateServiceInt{},
+			EndpointService:    &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	report, err := service.DiagnoseFederation("test-federation")
+	assert.NoError(t, err)
+	assert.Equal(t, FederationFATEConnectivityStatusSkipped, report.Matrix["cluster-1"]["exchange-a"])
+	assert.Equal(t, FederationFATEConnectivityStatusFailed, report.Matrix["exchange-a"]["cluster-1"])
+}
+
+func TestStartFederationDiagnostics(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", false),
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	fateRepo := &mock.ParticipantFATERepoMock{}
+	var created, updated *entity.FederationFATEDiagnostics
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, fateRepo),
+		DiagnosticsRepo: &mock.FederationFATEDiagnosticsRepoMock{
+			CreateFn: func(instance interface{}) error {
+				diagnostics := *instance.(*entity.FederationFATEDiagnostics)
+				created = &diagnostics
+				return nil
+			},
+			UpdateStatusAndReportByUUIDFn: func(instance interface{}) error {
+				updated = instance.(*entity.FederationFATEDiagnostics)
+				return nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo:     &mock.FederationFATERepoMock{},
+			EventService:       &mockEventServiceInt{},
+			CertificateService: &mockParticipantFATECertificateServiceInt{},
+			EndpointService:    &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	diagnostics, wg, err := service.StartFederationDiagnostics("test-federation")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.FederationFATEDiagnosticsStatusRunning, created.Status)
+	assert.Equal(t, diagnostics.UUID, created.UUID)
+	wg.Wait()
+	assert.Equal(t, entity.FederationFATEDiagnosticsStatusSucceeded, updated.Status)
+	report := &FederationFATEDiagnosticsReport{}
+	assert.NoError(t, json.Unmarshal([]byte(updated.Report), report))
+	assert.Equal(t, FederationFATEConnectivityStatusSkipped, report.Matrix["exchange-a"]["cluster-1"])
+
+	// the failure of the run is saved in the record
+	fateRepo.ListByFederationUUIDFn = func(uuid string) (interface{}, error) {
+		return nil, assert.AnError
+	}
+	_, wg, err = service.StartFederationDiagnostics("test-federation")
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, entity.FederationFATEDiagnosticsStatusFailed, updated.Status)
+	assert.Contains(t, updated.Message, "failed to list participants")
+	assert.Empty(t, updated.Report)
+}
+
+func TestDiagnosticsImage(t *testing.T) {
+	service := &ParticipantFATEService{}
+	participant := testFATECluster("cluster-1", 9999, "", "")
+	assert.Equal(t, defaultDiagnosticsImage, service.diagnosticsImage(&participant))
+
+	participant.DeploymentYAML = "registry: harbor.example.com/federatedai/\n"
+	assert.Equal(t, "harbor.example.com/federatedai/netshoot:v0.11", service.diagnosticsImage(&participant))
+
+	viper.Set("lifecyclemanager.diagnostics.image", "example.com/probe:latest")
+	defer viper.Set("lifecyclemanager.diagnostics.image", "")
+	assert.Equal(t, "example.com/probe:latest", service.diagnosticsImage(&participant))
+}
+
+func TestSuggestProbeFix(t *testing.T) {
+	target := testFATECluster("cluster-1", 9999, "", "")
+	for message, expected := range map[string]string{
+		"nc: getaddrinfo for host 'cluster-1-host' port 6651: Name does not resolve": "can't be resolved",
+		"nc: connect to cluster-1-host port 6651 (tcp) failed: Connection refused":   "nothing is listening",
+		"verify error:num=62:hostname mismatch":                                      "not issued for",
+		"verify error:num=20:unable to get local issuer certificate":                 "not issued by the CA",
+		"verify error:num=10:certificate has expired":                                "has expired",
+	} {
+		check := &FederationFATEConnectivityCheck{
+			TargetName: target.Name,
+			Probe:      FederationFATEProbeTypeTLS,
+			Service:    entity.ParticipantFATEServiceNamePulsar,
+			Address:    "cluster-1-host:6651",
+			ServerName: "cluster-1.example.com",
+			Message:    message,
+			target:     &target,
+		}
+		assert.Contains(t, suggestProbeFix(check), expected, message)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+// pulsarRouteTableEntry is an entry of the pulsar route table, only the TLS port of the peers is known so it is also
+// used as the plain port
+type pulsarRouteTableEntry struct {
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	SSLPort int    `json:"sslPort"`
+	Proxy   string `json:"proxy"`
+}
+
+// loadFATEFederation returns the FATE federation of the specified uuid
+func (s *ParticipantFATEService) loadFATEFederation(federationUUID string) (*entity.FederationFATE, error) {
+	instance, err := s.FederationRepo.GetByUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting federation info")
+	}
+	return instance.(*entity.FederationFATE), nil
+}
+
+// listPeerClusters returns the active clusters in the federation except the one with the excluded uuid
+func (s *ParticipantFATEService) listPeerClusters(federationUUID, excludedUUID string) ([]entity.ParticipantFATE, error) {
+	instanceList, err := s.ParticipantFATERepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list participants")
+	}
+	var peerList []entity.ParticipantFATE
+	for _, participant := range instanceList.([]entity.ParticipantFATE) {
+		if participant.Type == entity.ParticipantFATETypeCluster && participant.Status == entity.ParticipantFATEStatusActive &&
+			participant.UUID != excludedUUID {
+			peerList = append(peerList, participant)
+		}
+	}
+	return peerList, nil
+}
+
+// rebuildPeerRouteTables regenerates the route tables of all the managed clusters in a peer-to-peer federation, so
+// that every cluster routes to the other clusters directly, and applies the changed ones
+func (s *ParticipantFATEService) rebuildPeerRouteTables(federationUUID string) error {
+	clusterList, err := s.listPeerClusters(federationUUID, "")
+	if err != nil {
+		return err
+	}
+	var errMessages []string
+	for _, cluster := range clusterList {
+		cluster := cluster
+		if !cluster.IsManaged {
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID,
+				"cluster is not managed, its route table must be updated by its operator", entity.EventLogLevelInfo)
+			continue
+		}
+		if err := s.rebuildClusterPeerRouteTable(&cluster, clusterList, s.setClusterPeerRoutesInYAML); err != nil {
+			errMessages = append(errMessages, fmt.Sprintf("cluster %s: %v", cluster.Name, err))
+		}
+	}
+	if len(errMessages) > 0 {
+		return errors.Errorf("failed to rebuild route tables: %s", strings.Join(errMessages, "; "))
+	}
+	return nil
+}
+
+// rebuildClusterPeerRouteTable updates the deployment of the cluster with routes, set by setRoutes, to the other clusters
+// in the list
+func (s *ParticipantFATEService) rebuildClusterPeerRouteTable(cluster *entity.ParticipantFATE, clusterList []entity.ParticipantFATE,
+	setRoutes func(deploymentYAML string, peerList []entity.ParticipantFATE) (string, error)) error {
+	operationLog := log.Logger.With().Timestamp().Str("action", "rebuilding fate peer route table").Str("uuid", cluster.UUID).Logger().
+		Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+			eventLvl := entity.EventLogLevelInfo
+			if level == zerolog.ErrorLevel {
+				eventLvl = entity.EventLogLevelError
+			}
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, message, eventLvl)
+		}))
+	var peerList []entity.ParticipantFATE
+	for _, peer := range clusterList {
+		if peer.UUID != cluster.UUID {
+			peerList = append(peerList, peer)
+		}
+	}
+	updatedYAML, err := setRoutes(cluster.DeploymentYAML, peerList)
+	if err != nil {
+		return err
+	}
+
+	var originalMap map[string]interface{}
+	_ = yaml.Unmarshal([]byte(cluster.DeploymentYAML), &originalMap)
+	originalYAML, _ := yaml.Marshal(originalMap)
+	if bytes.Equal([]byte(updatedYAML), originalYAML) {
+		operationLog.Info().Msgf("cluster %s route table not changed", cluster.Name)
+		return nil
+	}
+	operationLog.Info().Msgf("updating route table with %v peer cluster(s)", len(peerList))
+
+	clusterEndpointMgr, kfClient, closer, err := s.buildKubeFATEMgrAndClient(cluster.EndpointUUID)
+	if closer != nil {
+		defer closer()
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot get cluster endpoint manager")
+	}
+
+	cluster.DeploymentYAML = updatedYAML
+	if err := s.ParticipantFATERepo.UpdateDeploymentYAMLByUUID(cluster); err != nil {
+		return errors.Wrapf(err, "failed to update cluster info")
+	}
+	jobUUID, err := kfClient.SubmitClusterUpdateJob(cluster.DeploymentYAML)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit cluster update job")
+	}
+	cluster.JobUUID = jobUUID
+	if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+		return errors.Wrap(err, "failed to update cluster's job uuid")
+	}
+	operationLog.Info().Msgf("KubeFATE update job uuid: %s", jobUUID)
+	if job, err := kfClient.WaitJob(jobUUID); err != nil {
+		return errors.Wrapf(err, "failed to query cluster update job status")
+	} else if job.Status != modules.JobStatusSuccess {
+		return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
+	}
+	// nginx only loads its route table when starting
+	if err := deletePodWithPrefix(clusterEndpointMgr.K8sClient(), cluster.Namespace, "nginx"); err != nil {
+		return err
+	}
+	operationLog.Info().Msg("done updating cluster route table")
+	return nil
+}
+
+// setClusterPeerRoutesInYAML returns the cluster deployment yaml with the exchange settings removed and the nginx, and
+// the pulsar, rabbitmq or rollsite route tables pointing to the peer clusters directly
+func (s *ParticipantFATEService) setClusterPeerRoutesInYAML(deploymentYAML string, peerList []entity.ParticipantFATE) (string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	nginxRouteTable := map[string]interface{}{}
+	rollsitePartyList := []interface{}{}
+	// the entry of the cluster itself and the other fields of the existing peer entries are kept
+	existingPulsarRouteTable := map[string]interface{}{}
+	if pulsar, ok := m["pulsar"].(map[string]interface{}); ok {
+		if routeTable, ok := pulsar["route_table"].(map[string]interface{}); ok {
+			existingPulsarRouteTable = routeTable
+		}
+	}
+	pulsarRouteTable := map[string]interface{}{}
+	if partyID, ok := m["partyId"]; ok {
+		selfKey := fmt.Sprintf("%v", partyID)
+		if entry, ok := existingPulsarRouteTable[selfKey]; ok {
+			pulsarRouteTable[selfKey] = entry
+		}
+	}
+	for index := range peerList {
+		peer := &peerList[index]
+		if _, ok := peer.AccessInfo[entity.ParticipantFATEServiceNameNginx]; ok {
+			s.buildNginxRouteTable(nginxRouteTable, peer, nil)
+		}
+		if access, ok := peer.AccessInfo[entity.ParticipantFATEServiceNamePulsar]; ok {
+			key := fmt.Sprintf("%d", peer.PartyID)
+			entry, ok := existingPulsarRouteTable[key].(map[string]interface{})
+			if !ok {
+				entry = map[string]interface{}{}
+			}
+			entry["host"] = access.Host
+			entry["sslPort"] = access.Port
+			if _, ok := entry["port"]; !ok {
+				entry["port"] = access.Port
+			}
+			if _, ok := entry["proxy"]; !ok {
+				entry["proxy"] = ""
+			}
+			pulsarRouteTable[key] = entry
+		}
+		if _, ok := peer.AccessInfo[entity.ParticipantFATEServiceNameRollsite]; ok {
+			rollsitePartyList = buildRollsiteRouteTable(rollsitePartyList, peer, nil)
+		}
+	}
+	if nginx, ok := m["nginx"].(map[string]interface{}); ok {
+		delete(nginx, "exchange")
+		nginx["route_table"] = nginxRouteTable
+	}
+	if pulsar, ok := m["pulsar"].(map[string]interface{}); ok {
+		delete(pulsar, "exchange")
+		pulsar["route_table"] = pulsarRouteTable
+	}
+	if rollsite, ok := m["rollsite"].(map[string]interface{}); ok {
+		delete(rollsite, "exchange")
+		rollsite["partyList"] = rollsitePartyList
+	}
+	updatedYAML, err := yaml.Marshal(m)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get final yaml content")
+	}
+	return setClusterRabbitMQRoutesInYAML(string(updatedYAML), peerList)
+}
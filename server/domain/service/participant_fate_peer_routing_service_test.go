@@ -0,0 +1,178 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const testPeerClusterDeploymentYAML = `chartName: fate
+name: test-cluster
+namespace: test-ns
+nginx:
+  exchange:
+    httpPort: 9300
+    ip: exchange-host
+  type: NodePort
+partyId: 9999
+pulsar:
+  exchange:
+    domain: example.com
+    ip: exchange-host
+    port: 443
+  route_table:
+    "9999":
+      host: pulsar
+      port: 6650
+      sslPort: 6651
+    "10001":
+      host: old-host
+      port: 30105
+      sslPort: 30109
+      proxy: proxy-host:443
+    "10005":
+      host: removed-host
+      port: 30105
+      sslPort: 30109`
+
+type testPeerRouteTables struct {
+	Nginx struct {
+		Exchange   map[string]interface{}                       `json:"exchange"`
+		RouteTable map[string]map[string][]nginxRouteTableEntry `json:"route_table"`
+	} `json:"nginx"`
+	Pulsar struct {
+		Exchange   map[string]interface{}           `json:"exchange"`
+		RouteTable map[string]pulsarRouteTableEntry `json:"route_table"`
+	} `json:"pulsar"`
+}
+
+func TestSetClusterPeerRoutesInYAML(t *testing.T) {
+	peerList := []entity.ParticipantFATE{
+		testFATECluster("cluster-2", 10000, "", ""),
+		testFATECluster("cluster-3", 10001, "", ""),
+	}
+	updatedYAML, err := (&ParticipantFATEService{}).setClusterPeerRoutesInYAML(testPeerClusterDeploymentYAML, peerList)
+	assert.NoError(t, err)
+
+	var m testPeerRouteTables
+	assert.NoError(t, yaml.Unmarshal([]byte(updatedYAML), &m))
+	assert.Nil(t, m.Nginx.Exchange)
+	assert.Nil(t, m.Pulsar.Exchange)
+	assert.Len(t, m.Nginx.RouteTable, 2)
+	assert.Equal(t, nginxRouteTableEntry{Host: "cluster-3-host", HttpPort: 9300}, m.Nginx.RouteTable["10001"]["fateflow"][0])
+	assert.Equal(t, map[string]pulsarRouteTableEntry{
+		"9999":  {Host: "pulsar", Port: 6650, SSLPort: 6651},
+		"10000": {Host: "cluster-2-host", Port: 6651, SSLPort: 6651},
+		"10001": {Host: "cluster-3-host", Port: 30105, SSLPort: 6651, Proxy: "proxy-host:443"},
+	}, m.Pulsar.RouteTable)
+}
+
+func TestRebuildPeerRouteTables_PosUpdateManagedClusters(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATECluster("cluster-1", 9999, "", ""),
+		testFATECluster("cluster-2", 10000, "", ""),
+		testFATECluster("cluster-3", 10001, "", ""),
+		testFATECluster("cluster-4", 10002, "", ""),
+	}
+	participantList[0].IsManaged = true
+	participantList[0].DeploymentYAML = testPeerClusterDeploymentYAML
+	participantList[1].IsManaged = true
+	participantList[1].DeploymentYAML = testPeerClusterDeploymentYAML
+	// an external cluster is routed to but its deployment can't be updated
+	participantList[2].IsManaged = false
+	// a cluster being removed is not routed to
+	participantList[3].Status = entity.ParticipantFATEStatusRemoving
+
+	updatedYAMLMap := map[string]string{}
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{
+			UpdateDeploymentYAMLByUUIDFn: func(instance interface{}) error {
+				participant := instance.(*entity.ParticipantFATE)
+				updatedYAMLMap[participant.UUID] = participant.DeploymentYAML
+				for index := range participantList {
+					if participantList[index].UUID == participant.UUID {
+						participantList[index].DeploymentYAML = participant.DeploymentYAML
+					}
+				}
+				return nil
+			},
+		}),
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				GetByUUIDfn: func(uuid string) (interface{}, error) {
+					return &entity.FederationFATE{
+						Domain: "example.com",
+						Mode:   entity.FederationFATEModePeerToPeer,
+					}, nil
+				},
+			},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	assert.NoError(t, service.rebuildFederationRouteTables("test-federation"))
+	assert.Len(t, updatedYAMLMap, 2)
+
+	var m testPeerRouteTables
+	assert.NoError(t, yaml.Unmarshal([]byte(updatedYAMLMap["cluster-1"]), &m))
+	assert.Len(t, m.Pulsar.RouteTable, 3)
+	assert.Contains(t, m.Pulsar.RouteTable, "10000")
+	assert.Contains(t, m.Pulsar.RouteTable, "10001")
+	assert.NotContains(t, m.Pulsar.RouteTable, "10002", "a cluster being removed should not be routed to")
+	assert.Equal(t, "pulsar", m.Pulsar.RouteTable["9999"].Host, "the entry of the cluster itself should be kept")
+
+	m = testPeerRouteTables{}
+	assert.NoError(t, yaml.Unmarshal([]byte(updatedYAMLMap["cluster-2"]), &m))
+	assert.Len(t, m.Nginx.RouteTable, 2)
+	assert.Contains(t, m.Nginx.RouteTable, "9999")
+	assert.Contains(t, m.Nginx.RouteTable, "10001")
+
+	// nothing is submitted when the route tables are already up to date
+	updatedYAMLMap = map[string]string{}
+	assert.NoError(t, service.rebuildFederationRouteTables("test-federation"))
+	assert.Empty(t, updatedYAMLMap)
+}
+
+func TestCreateExternalExchange_NegPeerToPeerFederation(t *testing.T) {
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo([]entity.ParticipantFATE{
+			testFATECluster("cluster-1", 9999, "", ""),
+		}, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo: &mock.FederationFATERepoMock{
+				GetByUUIDfn: func(uuid string) (interface{}, error) {
+					return &entity.FederationFATE{
+						Domain: "example.com",
+						Mode:   entity.FederationFATEModePeerToPeer,
+					}, nil
+				},
+			},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	_, err := service.CreateExternalExchange(&ParticipantFATEExternalExchangeCreationRequest{
+		Name:           "exchange",
+		FederationUUID: "test-federation",
+	})
+	assert.Error(t, err, "a peer-to-peer federation should not have exchanges")
+}
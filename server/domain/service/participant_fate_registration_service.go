@@ -0,0 +1,191 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+)
+
+// ParticipantFATEClusterRegistrationRequest is the request a remote organization sends to register its FATE cluster
+// into a FATE federation using a registration token
+type ParticipantFATEClusterRegistrationRequest struct {
+	// required
+	KubeConfig string `json:"kubeconfig"`
+	TokenStr   string `json:"token"`
+	PartyID    int    `json:"party_id"`
+
+	// optional
+	Name              string                               `json:"name"`
+	Description       string                               `json:"description"`
+	Namespace         string                               `json:"namespace"`
+	ChartUUID         string                               `json:"chart_uuid"`
+	ServiceType       entity.ParticipantDefaultServiceType `json:"service_type"`
+	RegistryConfig    valueobject.KubeRegistryConfig       `json:"registry_config"`
+	EnablePSP         bool                                 `json:"enable_psp"`
+	EnablePersistence bool                                 `json:"enable_persistence"`
+	StorageClass      string                               `json:"storage_class"`
+	LessPrivileged    bool                                 `json:"less_privileged"`
+
+	// SourceAddress is the address of the requester, for auditing
+	SourceAddress string `json:"-"`
+}
+
+// HandleClusterRegistrationRequest validates the token and its constraints and then deploys the FATE cluster into the
+// Kubernetes cluster specified in the request. The returned credential is used to access the cluster afterwards.
+func (s *ParticipantFATEService) HandleClusterRegistrationRequest(req *ParticipantFATEClusterRegistrationRequest) (*entity.ParticipantFATE, string, *sync.WaitGroup, error) {
+	if req.PartyID <= 0 {
+		return nil, "", nil, errors.New("invalid party id")
+	}
+	token, err := s.validateClusterToken(req.TokenStr)
+	if err != nil {
+		return nil, "", nil, errors.Wrapf(err, "failed to validate token")
+	}
+	if req.ChartUUID == "" && len(token.Constraints.ChartUUIDs) == 1 {
+		req.ChartUUID = token.Constraints.ChartUUIDs[0]
+	}
+	if req.ChartUUID == "" {
+		return nil, "", nil, errors.New("missing chart uuid")
+	}
+	if err := token.Constraints.Check(req.ChartUUID, req.PartyID); err != nil {
+		return nil, "", nil, err
+	}
+	if err := s.CheckPartyIDConflict(token.FederationUUID, req.PartyID); err != nil {
+		return nil, "", nil, err
+	}
+
+	instance, err := s.FederationRepo.GetByUUID(token.FederationUUID)
+	if err != nil {
+		return nil, "", nil, errors.Wrap(err, "error getting federation info")
+	}
+	federation := instance.(*entity.FederationFATE)
+
+	if req.Name == "" {
+		req.Name = fmt.Sprintf("fate-%v", req.PartyID)
+	}
+	if req.Namespace == "" {
+		req.Namespace = fmt.Sprintf("%s-fate-%v", toDeploymentName(federation.Name), req.PartyID)
+	}
+	if req.ServiceType == entity.ParticipantDefaultServiceTypeUnknown {
+		req.ServiceType = entity.ParticipantDefaultServiceTypeLoadBalancer
+	}
+
+	infraProvider, err := ensureInfraProviderFromKubeConfig(s.InfraRepo, req.KubeConfig, req.Namespace, req.LessPrivileged, "added during registering FATE cluster")
+	if err != nil {
+		return nil, "", nil, errors.Wrapf(err, "failed to prepare the cluster infra provider")
+	}
+
+	yamlReq := &ParticipantFATEClusterYAMLCreationRequest{
+		ParticipantFATEExchangeYAMLCreationRequest: ParticipantFATEExchangeYAMLCreationRequest{
+			ChartUUID:      req.ChartUUID,
+			Name:           req.Name,
+			Namespace:      req.Namespace,
+			ServiceType:    req.ServiceType,
+			RegistryConfig: req.RegistryConfig,
+			EnablePSP:      req.EnablePSP,
+		},
+		FederationUUID:    token.FederationUUID,
+		PartyID:           req.PartyID,
+		EnablePersistence: req.EnablePersistence,
+		StorageClass:      req.StorageClass,
+	}
+	deploymentYAML, err := s.GetClusterDeploymentYAML(yamlReq)
+	if err != nil {
+		return nil, "", nil, errors.Wrapf(err, "failed to get deployment yaml")
+	}
+
+	kfNamespace := ""
+	if req.LessPrivileged {
+		kfNamespace = req.Namespace
+	}
+	creationReq := &ParticipantFATEClusterCreationRequest{
+		ParticipantFATEClusterYAMLCreationRequest: *yamlReq,
+		ParticipantDeploymentBaseInfo: ParticipantDeploymentBaseInfo{
+			Description:    req.Description,
+			DeploymentYAML: deploymentYAML,
+		},
+		PulsarServerCertInfo: entity.ParticipantComponentCertInfo{
+			BindingMode: entity.CertBindingModeCreate,
+		},
+		SitePortalServerCertInfo: entity.ParticipantComponentCertInfo{
+			BindingMode: entity.CertBindingModeCreate,
+		},
+		SitePortalClientCertInfo: entity.ParticipantComponentCertInfo{
+			BindingMode: entity.CertBindingModeCreate,
+		},
+		tokenUUID: token.UUID,
+		prepareEndpoint: func() (string, error) {
+			return s.EndpointService.ensureEndpointExist(infraProvider.UUID, kfNamespace, req.RegistryConfig)
+		},
+	}
+	credential := ""
+	creationReq.recordRegistration = func(cluster *entity.ParticipantFATE) (err error) {
+		credential, err = s.recordRegistration(&token.RegistrationToken, entity.FederationTypeFATE, cluster.UUID, cluster.Name, cluster.Type.String(), req.SourceAddress)
+		return err
+	}
+	cluster, wg, err := s.CreateCluster(creationReq)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return cluster, credential, wg, nil
+}
+
+// GetClusterWithTokenVerification returns the FATE cluster if the credential is the one returned by its registration
+func (s *ParticipantFATEService) GetClusterWithTokenVerification(uuid, credential string) (*entity.ParticipantFATE, error) {
+	instance, err := s.ParticipantFATERepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query cluster")
+	}
+	cluster := instance.(*entity.ParticipantFATE)
+	if cluster.Type != entity.ParticipantFATETypeCluster || cluster.TokenUUID == "" {
+		return nil, errors.Errorf("participant %s is not a self-registered FATE cluster", cluster.UUID)
+	}
+	instance, err = s.TokenRepo.GetByUUID(cluster.TokenUUID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query token")
+	}
+	token := instance.(*entity.RegistrationTokenFATE)
+	if err := s.verifyParticipantCredential(&token.RegistrationToken, entity.FederationTypeFATE, cluster.UUID, cluster.Name,
+		cluster.Type.String(), credential); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+func (s *ParticipantFATEService) validateClusterToken(tokenDisplayedStr string) (*entity.RegistrationTokenFATE, error) {
+	tokenType, tokenStr, err := entity.RegistrationTokenParse(tokenDisplayedStr)
+	if err != nil {
+		return nil, err
+	}
+	token := &entity.RegistrationTokenFATE{
+		RegistrationToken: entity.RegistrationToken{
+			TokenType: tokenType,
+			TokenStr:  tokenStr,
+			Repo:      s.TokenRepo,
+		},
+		ParticipantRepo: s.ParticipantFATERepo,
+	}
+	if err := s.TokenRepo.LoadByTypeAndStr(token); err != nil {
+		return nil, err
+	}
+	if err := token.Validate(); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,527 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+// getFederationExchange returns the specified exchange of the federation, or the first exchange of the federation if
+// exchangeUUID is empty
+func (s *ParticipantFATEService) getFederationExchange(federationUUID, exchangeUUID string) (*entity.ParticipantFATE, error) {
+	if exchangeUUID == "" {
+		instance, err := s.ParticipantFATERepo.GetExchangeByFederationUUID(federationUUID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check exchange existence status")
+		}
+		return instance.(*entity.ParticipantFATE), nil
+	}
+	exchange, err := s.loadParticipant(exchangeUUID)
+	if err != nil {
+		return nil, err
+	}
+	if exchange.Type != entity.ParticipantFATETypeExchange || exchange.FederationUUID != federationUUID {
+		return nil, errors.Errorf("participant %s is not an exchange in federation %s", exchangeUUID, federationUUID)
+	}
+	return exchange, nil
+}
+
+// getClusterExchange returns the primary exchange of the cluster
+func (s *ParticipantFATEService) getClusterExchange(cluster *entity.ParticipantFATE) (*entity.ParticipantFATE, error) {
+	return s.getFederationExchange(cluster.FederationUUID, cluster.ExchangeUUID)
+}
+
+// validateStandbyExchange makes sure the standby exchange, if specified, is another exchange in the federation
+func (s *ParticipantFATEService) validateStandbyExchange(federationUUID, primaryExchangeUUID, standbyExchangeUUID string) error {
+	if standbyExchangeUUID == "" {
+		return nil
+	}
+	if standbyExchangeUUID == primaryExchangeUUID {
+		return errors.New("the standby exchange must be different from the primary exchange")
+	}
+	_, err := s.getFederationExchange(federationUUID, standbyExchangeUUID)
+	return err
+}
+
+// getClusterCreationExchange validates the exchanges specified for a new cluster and returns its primary exchange. The
+// returned exchange is nil in a peer-to-peer federation, where clusters don't use any exchange.
+func (s *ParticipantFATEService) getClusterCreationExchange(federationUUID string, mode entity.FederationFATEMode, exchangeUUID, standbyExchangeUUID string) (*entity.ParticipantFATE, error) {
+	if mode == entity.FederationFATEModePeerToPeer {
+		if exchangeUUID != "" || standbyExchangeUUID != "" {
+			return nil, errors.New("clusters in a peer-to-peer federation cannot use exchanges")
+		}
+		return nil, nil
+	}
+	exchange, err := s.getFederationExchange(federationUUID, exchangeUUID)
+	if err != nil {
+		return nil, err
+	}
+	if exchange.Status != entity.ParticipantFATEStatusActive {
+		return nil, errors.Errorf("exchange %v is not in active status", exchange.UUID)
+	}
+	if err := s.validateStandbyExchange(federationUUID, exchange.UUID, standbyExchangeUUID); err != nil {
+		return nil, err
+	}
+	if exchange.IsManaged {
+		if err := s.EndpointService.TestKubeFATE(exchange.EndpointUUID); err != nil {
+			return nil, err
+		}
+	}
+	return exchange, nil
+}
+
+// clusterExchangeUUID returns the primary exchange uuid of the cluster, clusters created before multiple exchanges
+// are supported use the first exchange of the federation, which is the defaultExchangeUUID
+func clusterExchangeUUID(cluster *entity.ParticipantFATE, defaultExchangeUUID string) string {
+	if cluster.ExchangeUUID == "" {
+		return defaultExchangeUUID
+	}
+	return cluster.ExchangeUUID
+}
+
+// rebuildFederationRouteTables rebuilds the route tables of all the managed exchanges in the federation. As exchanges
+// are peered with each other, a membership change affects every one of them. In a peer-to-peer federation, the route
+// tables of the clusters are rebuilt instead.
+func (s *ParticipantFATEService) rebuildFederationRouteTables(federationUUID string) error {
+	federation, err := s.loadFATEFederation(federationUUID)
+	if err != nil {
+		return err
+	}
+	if federation.Mode == entity.FederationFATEModePeerToPeer {
+		return s.rebuildPeerRouteTables(federationUUID)
+	}
+	instanceList, err := s.ParticipantFATERepo.ListExchangesByFederationUUID(federationUUID)
+	if err != nil {
+		return errors.Wrap(err, "failed to list exchanges")
+	}
+	var errMessages []string
+	for _, exchange := range instanceList.([]entity.ParticipantFATE) {
+		exchange := exchange
+		if !exchange.IsManaged || exchange.Status != entity.ParticipantFATEStatusActive {
+			continue
+		}
+		if err := s.rebuildRouteTable(&exchange); err != nil {
+			errMessages = append(errMessages, fmt.Sprintf("exchange %s: %v", exchange.Name, err))
+		}
+	}
+	if err := s.rebuildRabbitMQRouteTables(federationUUID); err != nil {
+		errMessages = append(errMessages, err.Error())
+	}
+	if len(errMessages) > 0 {
+		return errors.Errorf("failed to rebuild route tables: %s", strings.Join(errMessages, "; "))
+	}
+	return nil
+}
+
+// removeClusterFromRouteTable deletes the route entries of the cluster from the exchange, which is faster than the
+// rebuildRouteTable function
+func (s *ParticipantFATEService) removeClusterFromRouteTable(exchange, cluster *entity.ParticipantFATE, operationLog *zerolog.Logger) error {
+	exchangeEndpointMgr, exchangeKFClient, exchangeKFClientCloser, err := s.buildKubeFATEMgrAndClient(exchange.EndpointUUID)
+	if exchangeKFClientCloser != nil {
+		defer exchangeKFClientCloser()
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot get exchange endpoint manager")
+	}
+
+	var m map[string]interface{}
+	_ = yaml.Unmarshal([]byte(exchange.DeploymentYAML), &m)
+
+	if m["nginx"].(map[string]interface{})["route_table"] != nil {
+		routeTable := m["nginx"].(map[string]interface{})["route_table"].(map[string]interface{})
+		delete(routeTable, fmt.Sprintf("%v", cluster.PartyID))
+	}
+
+	sniTable := m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{})["sni"]
+	if sniTable != nil {
+		var newTable []interface{}
+
+		sniTableList := sniTable.([]interface{})
+		for _, item := range sniTableList {
+			itemBytes, _ := yaml.Marshal(item)
+			var entry atsRouteTableEntry
+			_ = yaml.Unmarshal(itemBytes, &entry)
+			if strings.HasPrefix(entry.FQDN, fmt.Sprintf("%v.", cluster.PartyID)) {
+				continue
+			}
+			newTable = append(newTable, item)
+		}
+		m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{})["sni"] = newTable
+	}
+
+	if rollsite, ok := m["rollsite"].(map[string]interface{}); ok && rollsite["partyList"] != nil {
+		var newPartyList []interface{}
+		for _, item := range rollsite["partyList"].([]interface{}) {
+			itemBytes, _ := yaml.Marshal(item)
+			var entry rollsitePartyListEntry
+			_ = yaml.Unmarshal(itemBytes, &entry)
+			if entry.PartyID == cluster.PartyID {
+				continue
+			}
+			newPartyList = append(newPartyList, item)
+		}
+		rollsite["partyList"] = newPartyList
+	}
+
+	updatedYaml, _ := yaml.Marshal(m)
+
+	var originalMap map[string]interface{}
+	_ = yaml.Unmarshal([]byte(exchange.DeploymentYAML), &originalMap)
+	originalYaml, _ := yaml.Marshal(originalMap)
+	if bytes.Equal(updatedYaml, originalYaml) {
+		operationLog.Info().Msgf("exchange %s yaml not changed", exchange.Name)
+		return nil
+	}
+
+	exchange.DeploymentYAML = string(updatedYaml)
+	if err := s.ParticipantFATERepo.UpdateDeploymentYAMLByUUID(exchange); err != nil {
+		return errors.Wrapf(err, "failed to update exchange info")
+	}
+
+	jobUUID, err := exchangeKFClient.SubmitClusterUpdateJob(exchange.DeploymentYAML)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit exchange update job")
+	}
+	exchange.JobUUID = jobUUID
+	if err := s.ParticipantFATERepo.UpdateInfoByUUID(exchange); err != nil {
+		return errors.Wrap(err, "failed to update exchange's job uuid")
+	}
+	if job, err := exchangeKFClient.WaitJob(jobUUID); err != nil {
+		return errors.Wrapf(err, "failed to query exchange update job status")
+	} else if job.Status != modules.JobStatusSuccess {
+		operationLog.Warn().Msgf("updating job not succeeded, status: %v, job info: %v", job.Status, job)
+	}
+	operationLog.Info().Msgf("restarting ATS of exchange %s", exchange.Name)
+	return deletePodWithPrefix(exchangeEndpointMgr.K8sClient(), exchange.Namespace, "traffic-server")
+}
+
+// FailoverCluster switches the routing of the cluster to its standby exchange, and the original primary exchange
+// becomes the new standby. The route tables of all the exchanges are rebuilt, and a managed cluster is reconfigured to
+// connect to its new primary exchange.
+func (s *ParticipantFATEService) FailoverCluster(uuid string) (*sync.WaitGroup, error) {
+	cluster, err := s.loadParticipant(uuid)
+	if err != nil {
+		return nil, err
+	}
+	primary, standby, err := s.checkClusterFailover(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.prepareClusterFailover(cluster, primary, standby); err != nil {
+		return nil, err
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := log.Logger.With().Timestamp().Str("action", "failing over fate cluster").Str("uuid", cluster.UUID).Logger().
+			Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+				eventLvl := entity.EventLogLevelInfo
+				if level == zerolog.ErrorLevel {
+					eventLvl = entity.EventLogLevelError
+				}
+				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, message, eventLvl)
+			}))
+		operationLog.Info().Msg("rebuilding exchange route tables")
+		if err := s.rebuildFederationRouteTables(cluster.FederationUUID); err != nil {
+			operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while failing over cluster").Error())
+		}
+		s.reconfigureClusterExchange(cluster, &operationLog)
+	}()
+	return wg, nil
+}
+
+// ConnectClusterToExchange reconfigures the managed cluster in place to use the exchange as its primary exchange, an
+// empty exchangeUUID means the first exchange of the federation. The standby exchange is dropped if it becomes the
+// primary one or no longer exists. The returned *sync.WaitGroup can be used to wait for the completion.
+func (s *ParticipantFATEService) ConnectClusterToExchange(clusterUUID, exchangeUUID string) (*sync.WaitGroup, error) {
+	cluster, err := s.loadParticipant(clusterUUID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Type != entity.ParticipantFATETypeCluster {
+		return nil, errors.Errorf("participant %s is not a FATE cluster", cluster.UUID)
+	}
+	if !cluster.IsManaged {
+		return nil, errors.Errorf("cluster %s is not managed by FedLCM", cluster.Name)
+	}
+	if cluster.Status != entity.ParticipantFATEStatusActive {
+		return nil, errors.Errorf("cluster cannot be reconfigured when in status: %v", cluster.Status)
+	}
+	exchange, err := s.getFederationExchange(cluster.FederationUUID, exchangeUUID)
+	if err != nil {
+		return nil, err
+	}
+	if exchange.Status != entity.ParticipantFATEStatusActive {
+		return nil, errors.Errorf("exchange %s is not in active status", exchange.Name)
+	}
+	deploymentYAML, err := setClusterExchangeInYAML(cluster.DeploymentYAML, exchange)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.StandbyExchangeUUID == exchange.UUID {
+		cluster.StandbyExchangeUUID = ""
+	} else if cluster.StandbyExchangeUUID != "" {
+		if _, err := s.loadParticipant(cluster.StandbyExchangeUUID); err != nil {
+			cluster.StandbyExchangeUUID = ""
+		}
+	}
+	cluster.DeploymentYAML = deploymentYAML
+	cluster.ExchangeUUID = exchange.UUID
+	cluster.Status = entity.ParticipantFATEStatusReconfiguring
+	if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+		return nil, errors.Wrap(err, "failed to update cluster exchange info")
+	}
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID,
+		fmt.Sprintf("start connecting to exchange %s", exchange.Name), entity.EventLogLevelInfo)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := log.Logger.With().Timestamp().Str("action", "connecting fate cluster to exchange").Str("uuid", cluster.UUID).Logger().
+			Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+				eventLvl := entity.EventLogLevelInfo
+				if level == zerolog.ErrorLevel {
+					eventLvl = entity.EventLogLevelError
+				}
+				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, message, eventLvl)
+			}))
+		operationLog.Info().Msg("rebuilding exchange route tables")
+		if err := s.rebuildFederationRouteTables(cluster.FederationUUID); err != nil {
+			operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while connecting cluster to exchange").Error())
+		}
+		s.reconfigureClusterExchange(cluster, &operationLog)
+	}()
+	return wg, nil
+}
+
+// FailoverExchange switches the routing of all the clusters using the exchange as their primary exchange to their
+// standby exchanges, all these clusters must have a standby exchange configured
+func (s *ParticipantFATEService) FailoverExchange(uuid string) (*sync.WaitGroup, error) {
+	exchange, err := s.loadParticipant(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if exchange.Type != entity.ParticipantFATETypeExchange {
+		return nil, errors.Errorf("participant %s is not a FATE exchange", exchange.UUID)
+	}
+	defaultExchange, err := s.getFederationExchange(exchange.FederationUUID, "")
+	if err != nil {
+		return nil, err
+	}
+	instanceList, err := s.ParticipantFATERepo.ListByFederationUUID(exchange.FederationUUID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list participants in federation")
+	}
+	// all the clusters are checked before any of them is changed
+	var clusterList, standbyList []*entity.ParticipantFATE
+	for _, participant := range instanceList.([]entity.ParticipantFATE) {
+		participant := participant
+		if participant.Type != entity.ParticipantFATETypeCluster || clusterExchangeUUID(&participant, defaultExchange.UUID) != exchange.UUID {
+			continue
+		}
+		_, standby, err := s.checkClusterFailover(&participant)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot fail over cluster %s", participant.Name)
+		}
+		clusterList = append(clusterList, &participant)
+		standbyList = append(standbyList, standby)
+	}
+	if len(clusterList) == 0 {
+		return nil, errors.Errorf("no cluster is using exchange %s as the primary exchange", exchange.Name)
+	}
+	for index, cluster := range clusterList {
+		if err := s.prepareClusterFailover(cluster, exchange, standbyList[index]); err != nil {
+			return nil, errors.Wrapf(err, "failed to fail over cluster %s", cluster.Name)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := log.Logger.With().Timestamp().Str("action", "failing over fate exchange").Str("uuid", exchange.UUID).Logger().
+			Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+				eventLvl := entity.EventLogLevelInfo
+				if level == zerolog.ErrorLevel {
+					eventLvl = entity.EventLogLevelError
+				}
+				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeExchange, exchange.UUID, message, eventLvl)
+			}))
+		operationLog.Info().Msgf("failing over %v cluster(s) to their standby exchanges", len(clusterList))
+		if err := s.rebuildFederationRouteTables(exchange.FederationUUID); err != nil {
+			operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while failing over exchange").Error())
+		}
+		for _, cluster := range clusterList {
+			s.reconfigureClusterExchange(cluster, &operationLog)
+		}
+		operationLog.Info().Msg("done failing over exchange")
+	}()
+	return wg, nil
+}
+
+// checkClusterFailover makes sure the cluster can be failed over, and returns its current primary and standby exchange
+func (s *ParticipantFATEService) checkClusterFailover(cluster *entity.ParticipantFATE) (primary, standby *entity.ParticipantFATE, err error) {
+	if cluster.Type != entity.ParticipantFATETypeCluster {
+		return nil, nil, errors.Errorf("participant %s is not a FATE cluster", cluster.UUID)
+	}
+	if cluster.StandbyExchangeUUID == "" {
+		return nil, nil, errors.Errorf("cluster %s has no standby exchange", cluster.Name)
+	}
+	if cluster.Status != entity.ParticipantFATEStatusActive {
+		return nil, nil, errors.Errorf("cluster cannot be failed over when in status: %v", cluster.Status)
+	}
+	primary, err = s.getClusterExchange(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	standby, err = s.getFederationExchange(cluster.FederationUUID, cluster.StandbyExchangeUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if standby.Status != entity.ParticipantFATEStatusActive {
+		return nil, nil, errors.Errorf("standby exchange %v is not in active status", standby.UUID)
+	}
+	if cluster.IsManaged {
+		if err := s.EndpointService.TestKubeFATE(cluster.EndpointUUID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return primary, standby, nil
+}
+
+// prepareClusterFailover swaps the primary and standby exchange of the cluster and saves the new exchange access
+// info into the deployment yaml of a managed cluster
+func (s *ParticipantFATEService) prepareClusterFailover(cluster, primary, standby *entity.ParticipantFATE) error {
+	if cluster.IsManaged {
+		deploymentYAML, err := setClusterExchangeInYAML(cluster.DeploymentYAML, standby)
+		if err != nil {
+			return err
+		}
+		cluster.DeploymentYAML = deploymentYAML
+		cluster.Status = entity.ParticipantFATEStatusReconfiguring
+	}
+	cluster.ExchangeUUID = standby.UUID
+	cluster.StandbyExchangeUUID = primary.UUID
+	if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+		return errors.Wrap(err, "failed to update cluster exchange info")
+	}
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID,
+		fmt.Sprintf("start failing over from exchange %s to exchange %s", primary.Name, standby.Name), entity.EventLogLevelInfo)
+	return nil
+}
+
+// reconfigureClusterExchange applies the updated exchange access info of a managed cluster
+func (s *ParticipantFATEService) reconfigureClusterExchange(cluster *entity.ParticipantFATE, operationLog *zerolog.Logger) {
+	if !cluster.IsManaged {
+		operationLog.Warn().Msgf("cluster %s is not managed, the exchange access info must be updated in the cluster by its operator", cluster.Name)
+		return
+	}
+	if err := func() error {
+		_, kfClient, closer, err := s.buildKubeFATEMgrAndClient(cluster.EndpointUUID)
+		if closer != nil {
+			defer closer()
+		}
+		if err != nil {
+			return err
+		}
+		jobUUID, err := kfClient.SubmitClusterUpdateJob(cluster.DeploymentYAML)
+		if err != nil {
+			return errors.Wrapf(err, "failed to submit cluster update job")
+		}
+		cluster.JobUUID = jobUUID
+		if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+			return errors.Wrap(err, "failed to update cluster's job uuid")
+		}
+		operationLog.Info().Msgf("KubeFATE update job uuid: %s", jobUUID)
+		if job, err := kfClient.WaitJob(jobUUID); err != nil {
+			return errors.Wrapf(err, "failed to query cluster update job status")
+		} else if job.Status != modules.JobStatusSuccess {
+			return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
+		}
+		return nil
+	}(); err != nil {
+		operationLog.Error().Msg(errors.Wrapf(err, "failed to reconfigure cluster %s", cluster.Name).Error())
+		cluster.Status = entity.ParticipantFATEStatusFailed
+	} else {
+		operationLog.Info().Msgf("cluster %s is now connected to its new primary exchange", cluster.Name)
+		cluster.Status = entity.ParticipantFATEStatusActive
+	}
+	if err := s.ParticipantFATERepo.UpdateStatusByUUID(cluster); err != nil {
+		operationLog.Error().Msg(errors.Wrap(err, "failed to update cluster status").Error())
+	}
+}
+
+// setClusterExchangeInYAML returns the cluster deployment yaml with the nginx, and the pulsar or rollsite components
+// connecting to the specified exchange
+func setClusterExchangeInYAML(deploymentYAML string, exchange *entity.ParticipantFATE) (string, error) {
+	nginxAccess, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameNginx]
+	if !ok {
+		return "", errors.New("missing exchange nginx access info")
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	if rollsite, ok := m["rollsite"].(map[string]interface{}); ok {
+		rollsiteAccess, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameRollsite]
+		if !ok {
+			return "", errors.New("missing exchange rollsite access info")
+		}
+		rollsite["exchange"] = map[string]interface{}{
+			"ip":   rollsiteAccess.Host,
+			"port": rollsiteAccess.Port,
+		}
+	}
+	if nginx, ok := m["nginx"].(map[string]interface{}); ok {
+		exchangeConfig, _ := nginx["exchange"].(map[string]interface{})
+		if exchangeConfig == nil {
+			exchangeConfig = map[string]interface{}{}
+			nginx["exchange"] = exchangeConfig
+		}
+		exchangeConfig["ip"] = nginxAccess.Host
+		exchangeConfig["httpPort"] = nginxAccess.Port
+	}
+	if pulsar, ok := m["pulsar"].(map[string]interface{}); ok {
+		atsAccess, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameATS]
+		if !ok {
+			return "", errors.New("missing exchange traffic-server access info")
+		}
+		exchangeConfig, _ := pulsar["exchange"].(map[string]interface{})
+		if exchangeConfig == nil {
+			exchangeConfig = map[string]interface{}{}
+			pulsar["exchange"] = exchangeConfig
+		}
+		exchangeConfig["ip"] = atsAccess.Host
+		exchangeConfig["port"] = atsAccess.Port
+	}
+	updatedYAML, err := yaml.Marshal(m)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get final yaml content")
+	}
+	return string(updatedYAML), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRebuildRouteTable_PeerRoutesToOtherExchanges(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATEExchange("exchange-b", "exchange-b-host", true),
+		// cluster-1 is created before multiple exchanges are supported, so it uses the first exchange
+		testFATECluster("cluster-1", 9999, "", ""),
+		testFATECluster("cluster-2", 10000, "exchange-b", "exchange-a"),
+	}
+	var updatedYAML string
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{
+			UpdateDeploymentYAMLByUUIDFn: func(instance interface{}) error {
+				updatedYAML = instance.(*entity.ParticipantFATE).DeploymentYAML
+				return nil
+			},
+		}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	assert.NoError(t, service.rebuildRouteTable(&participantList[0]))
+
+	var m struct {
+		Nginx struct {
+			RouteTable map[string]map[string][]nginxRouteTableEntry `json:"route_table"`
+		} `json:"nginx"`
+		TrafficServer struct {
+			RouteTable struct {
+				SNI []atsRouteTableEntry `json:"sni"`
+			} `json:"route_table"`
+		} `json:"trafficServer"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(updatedYAML), &m))
+	assert.Equal(t, "cluster-1-host", m.Nginx.RouteTable["9999"]["fateflow"][0].Host)
+	assert.Equal(t, "exchange-b-host", m.Nginx.RouteTable["10000"]["fateflow"][0].Host, "cluster of another exchange should be routed to that exchange")
+	assert.ElementsMatch(t, []atsRouteTableEntry{
+		{FQDN: "cluster-1.example.com", TunnelRoute: "cluster-1-host:6651"},
+		{FQDN: "cluster-2.example.com", TunnelRoute: "exchange-b-host:443"},
+	}, m.TrafficServer.RouteTable.SNI)
+}
+
+func TestFailoverCluster_PosSwitchToStandby(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", false),
+		testFATEExchange("exchange-b", "exchange-b-host", false),
+		testFATECluster("cluster-1", 9999, "exchange-a", "exchange-b"),
+	}
+	cluster := &participantList[2]
+	cluster.IsManaged = true
+	cluster.DeploymentYAML = `nginx:
+  exchange:
+    ip: exchange-a-host
+    httpPort: 9300
+pulsar:
+  exchange:
+    domain: example.com
+    ip: exchange-a-host
+    port: 443`
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	wg, err := service.FailoverCluster(cluster.UUID)
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, "exchange-b", cluster.ExchangeUUID)
+	assert.Equal(t, "exchange-a", cluster.StandbyExchangeUUID)
+	assert.Equal(t, entity.ParticipantFATEStatusActive, cluster.Status)
+
+	var m map[string]map[string]map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(cluster.DeploymentYAML), &m))
+	assert.Equal(t, "exchange-b-host", m["nginx"]["exchange"]["ip"])
+	assert.Equal(t, "exchange-b-host", m["pulsar"]["exchange"]["ip"])
+	assert.Equal(t, "example.com", m["pulsar"]["exchange"]["domain"])
+
+	// failing over again switches the cluster back
+	wg, err = service.FailoverCluster(cluster.UUID)
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, "exchange-a", cluster.ExchangeUUID)
+	assert.Equal(t, "exchange-b", cluster.StandbyExchangeUUID)
+
+	cluster.StandbyExchangeUUID = ""
+	_, err = service.FailoverCluster(cluster.UUID)
+	assert.Error(t, err, "cluster without a standby exchange cannot be failed over")
+}
+
+func TestRemoveExchange_NegUsedAsStandby(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATEExchange("exchange-a", "exchange-a-host", true),
+		testFATEExchange("exchange-b", "exchange-b-host", true),
+		testFATECluster("cluster-1", 9999, "exchange-a", "exchange-b"),
+	}
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	_, err := service.RemoveExchange("exchange-b", false)
+	assert.Error(t, err, "exchange used as a standby exchange should not be removed")
+}
@@ -48,6 +48,10 @@ const (
 // ParticipantFATEService is the service to manage fate participants
 type ParticipantFATEService struct {
 	ParticipantFATERepo repo.ParticipantFATERepository
+	TokenRepo           repo.RegistrationTokenRepository
+	InfraRepo           repo.InfraProviderRepository
+	SizingProfileRepo   repo.ParticipantFATESizingProfileRepository
+	DiagnosticsRepo     repo.FederationFATEDiagnosticsRepository
 	ParticipantService
 }
 
@@ -60,6 +64,8 @@ type ParticipantFATEExchangeYAMLCreationRequest struct {
 	// RegistrySecretConfig in valueobject.KubeRegistryConfig is not used for generating the yaml content
 	RegistryConfig valueobject.KubeRegistryConfig `json:"registry_config"`
 	EnablePSP      bool                           `json:"enable_psp"`
+	// EnableRollsite deploys the rollsite in an exchange so that it can route eggroll clusters, it is not used for clusters
+	EnableRollsite bool `json:"enable_rollsite"`
 }
 
 // ExternalSpark is the request to get the external Spark information
@@ -103,6 +109,14 @@ type ParticipantFATEClusterYAMLCreationRequest struct {
 	ExternalSpark     ExternalSpark
 	ExternalHDFS      ExternalHDFS
 	ExternalPulsar    ExternalPulsar
+	// ExchangeUUID is the primary exchange of the cluster, empty means the first exchange of the federation
+	ExchangeUUID string `json:"exchange_uuid"`
+	// StandbyExchangeUUID is the exchange the cluster's routing can fail over to, it is not used for generating the yaml content
+	StandbyExchangeUUID string `json:"standby_exchange_uuid"`
+	// SizingProfileUUID is the sizing profile applied to the cluster, empty means the defaults of the chart
+	SizingProfileUUID string `json:"sizing_profile_uuid"`
+	// Backend is the computing and federation engine combination of the cluster, empty means Spark with Pulsar
+	Backend entity.ParticipantFATEBackend `json:"backend"`
 }
 
 // ParticipantFATEExternalExchangeCreationRequest is the request for creating a record of an exchange not managed by this service
@@ -122,6 +136,10 @@ type ParticipantFATEExternalClusterCreationRequest struct {
 	PartyID          int                             `json:"party_id"`
 	PulsarAccessInfo entity.ParticipantModulesAccess `json:"pulsar_access_info"`
 	NginxAccessInfo  entity.ParticipantModulesAccess `json:"nginx_access_info"`
+	// ExchangeUUID is the primary exchange of the cluster, empty means the first exchange of the federation
+	ExchangeUUID string `json:"exchange_uuid"`
+	// StandbyExchangeUUID is the exchange the cluster's routing can fail over to
+	StandbyExchangeUUID string `json:"standby_exchange_uuid"`
 }
 
 type nginxRouteTableEntry struct {
@@ -151,6 +169,12 @@ type ParticipantFATEClusterCreationRequest struct {
 	PulsarServerCertInfo     entity.ParticipantComponentCertInfo `json:"pulsar_server_cert_info"`
 	SitePortalServerCertInfo entity.ParticipantComponentCertInfo `json:"site_portal_server_cert_info"`
 	SitePortalClientCertInfo entity.ParticipantComponentCertInfo `json:"site_portal_client_cert_info"`
+
+	// internal fields set by the self-registration workflow
+	tokenUUID       string
+	prepareEndpoint func() (string, error)
+	// recordRegistration is called once the cluster record is created, the cluster is removed and not installed if it fails
+	recordRegistration func(cluster *entity.ParticipantFATE) error
 }
 
 // CheckPartyIDConflict returns error if the party id is taken in a federation
@@ -204,6 +228,9 @@ func (s *ParticipantFATEService) GetExchangeDeploymentYAML(req *ParticipantFATEE
 	if err := t.Execute(&buf, data); err != nil {
 		return "", err
 	}
+	if req.EnableRollsite {
+		return setExchangeRollsiteInYAML(buf.String())
+	}
 	return buf.String(), nil
 }
 
@@ -225,19 +252,33 @@ func (s *ParticipantFATEService) GetClusterDeploymentYAML(req *ParticipantFATECl
 	}
 	federation := instance.(*entity.FederationFATE)
 
-	instance, err = s.ParticipantFATERepo.GetExchangeByFederationUUID(federationUUID)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to check exchange existence status")
+	if err := s.validateFederationBackend(federationUUID, req.Backend); err != nil {
+		return "", err
 	}
-	exchange := instance.(*entity.ParticipantFATE)
-
-	if exchange.Status != entity.ParticipantFATEStatusActive {
-		return "", errors.Errorf("exchange %v is not in active status", exchange.UUID)
+	if err := validateClusterBackendExternals(req); err != nil {
+		return "", err
 	}
 
-	accessInfoMap := exchange.AccessInfo
-	if accessInfoMap == nil {
-		return "", errors.New("exchange access info is missing")
+	// clusters in a peer-to-peer federation are not connected to any exchange
+	var exchange *entity.ParticipantFATE
+	var accessInfoMap entity.ParticipantFATEModulesAccessMap
+	if federation.Mode != entity.FederationFATEModePeerToPeer {
+		exchange, err = s.getFederationExchange(federationUUID, req.ExchangeUUID)
+		if err != nil {
+			return "", err
+		}
+
+		if exchange.Status != entity.ParticipantFATEStatusActive {
+			return "", errors.Errorf("exchange %v is not in active status", exchange.UUID)
+		}
+
+		accessInfoMap = exchange.AccessInfo
+		if accessInfoMap == nil {
+			return "", errors.New("exchange access info is missing")
+		}
+		if err := validateExchangeBackendAccess(exchange, req.Backend); err != nil {
+			return "", err
+		}
 	}
 
 	data := struct {
@@ -313,15 +354,12 @@ func (s *ParticipantFATEService) GetClusterDeploymentYAML(req *ParticipantFATECl
 		ExternalPulsarPort:                 req.ExternalPulsar.Port,
 		ExternalPulsarSSLPort:              req.ExternalPulsar.SSLPort,
 	}
-	if nginxAccess, ok := accessInfoMap[entity.ParticipantFATEServiceNameNginx]; !ok {
-		return "", errors.New("missing exchange nginx access info")
-	} else {
+	if accessInfoMap != nil {
+		// the access info required by the backend has been validated, and the unused pulsar settings are removed later
+		nginxAccess := accessInfoMap[entity.ParticipantFATEServiceNameNginx]
 		data.ExchangeNginxHost = nginxAccess.Host
 		data.ExchangeNginxPort = nginxAccess.Port
-	}
-	if atsAccess, ok := accessInfoMap[entity.ParticipantFATEServiceNameATS]; !ok {
-		return "", errors.New("missing exchange traffic-server access info")
-	} else {
+		atsAccess := accessInfoMap[entity.ParticipantFATEServiceNameATS]
 		data.ExchangeATSHost = atsAccess.Host
 		data.ExchangeATSPort = atsAccess.Port
 	}
@@ -334,7 +372,17 @@ func (s *ParticipantFATEService) GetClusterDeploymentYAML(req *ParticipantFATECl
 	if err := t.Execute(&buf, data); err != nil {
 		return "", err
 	}
-	return buf.String(), nil
+	deploymentYAML, err := setClusterBackendInYAML(buf.String(), req.Backend, exchange)
+	if err != nil {
+		return "", err
+	}
+	if deploymentYAML, err = s.setClusterDirectRoutesInYAML(deploymentYAML, federation, req.Backend); err != nil {
+		return "", err
+	}
+	if req.SizingProfileUUID != "" {
+		return s.applySizingProfileToYAML(deploymentYAML, req.SizingProfileUUID)
+	}
+	return deploymentYAML, nil
 }
 
 // CreateExchange creates a FATE exchange, the returned *sync.WaitGroup can be used to wait for the completion of the async goroutine
@@ -345,11 +393,8 @@ func (s *ParticipantFATEService) CreateExchange(req *ParticipantFATEExchangeCrea
 		return nil, nil, errors.Wrap(err, "error getting federation info")
 	}
 	federation := instance.(*entity.FederationFATE)
-
-	if exist, err := s.ParticipantFATERepo.IsExchangeCreatedByFederationUUID(federationUUID); err != nil {
-		return nil, nil, errors.Wrapf(err, "failed to check exchange existence status")
-	} else if exist {
-		return nil, nil, errors.Errorf("an exchange is already deployed in federation %s", federationUUID)
+	if federation.Mode == entity.FederationFATEModePeerToPeer {
+		return nil, nil, errors.Errorf("federation %s is in peer-to-peer mode and cannot have exchanges", federation.Name)
 	}
 
 	if err := s.EndpointService.TestKubeFATE(req.EndpointUUID); err != nil {
@@ -380,14 +425,14 @@ func (s *ParticipantFATEService) CreateExchange(req *ParticipantFATEExchangeCrea
 	}
 	req.DeploymentYAML = string(finalYAMLBytes)
 
-	if req.ProxyServerCertInfo.BindingMode == entity.CertBindingModeReuse {
-		return nil, nil, errors.New("cannot re-use existing certificate")
+	if err := validateComponentCertInfo(req.ProxyServerCertInfo, req.FMLManagerServerCertInfo, req.FMLManagerClientCertInfo); err != nil {
+		return nil, nil, err
 	}
 
 	var caCert *x509.Certificate
-	if req.ProxyServerCertInfo.BindingMode == entity.CertBindingModeCreate ||
-		req.FMLManagerServerCertInfo.BindingMode == entity.CertBindingModeCreate ||
-		req.FMLManagerClientCertInfo.BindingMode == entity.CertBindingModeCreate {
+	if req.ProxyServerCertInfo.BindingMode.RequiresCertificate() ||
+		req.FMLManagerServerCertInfo.BindingMode.RequiresCertificate() ||
+		req.FMLManagerClientCertInfo.BindingMode.RequiresCertificate() {
 		ca, err := s.CertificateService.DefaultCA()
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "failed to get default CA")
@@ -488,15 +533,15 @@ func (s *ParticipantFATEService) CreateExchange(req *ParticipantFATEExchangeCrea
 			if atsFQDN == "" {
 				atsFQDN = fmt.Sprintf("proxy.%s", federation.Domain)
 			}
-			if req.ProxyServerCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.ProxyServerCertInfo.BindingMode.RequiresCertificate() {
 				if req.ProxyServerCertInfo.CommonName == "" {
 					req.ProxyServerCertInfo.CommonName = atsFQDN
 				}
-				operationLog.Info().Msgf("creating certificate for the ATS service with CN: %s", req.ProxyServerCertInfo.CommonName)
+				operationLog.Info().Msgf("preparing certificate for the ATS service with CN: %s", req.ProxyServerCertInfo.CommonName)
 				dnsNames := []string{req.ProxyServerCertInfo.CommonName}
-				cert, pk, err := s.CertificateService.CreateCertificateSimple(req.ProxyServerCertInfo.CommonName, defaultCertLifetime, dnsNames)
+				cert, pk, err := s.prepareComponentCertificate(&req.ProxyServerCertInfo, dnsNames, x509.ExtKeyUsageServerAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create ATS certificate")
+					return errors.Wrapf(err, "failed to prepare ATS certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", cert.SerialNumber, cert.Subject.CommonName)
 				err = createATSSecret(endpointMgr.K8sClient(), req.Namespace, caCert, cert, pk)
@@ -519,27 +564,27 @@ func (s *ParticipantFATEService) CreateExchange(req *ParticipantFATEExchangeCrea
 			}
 			var serverCert, clientCert *entity.Certificate
 			var serverPrivateKey, clientPrivateKey *rsa.PrivateKey
-			if req.FMLManagerServerCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.FMLManagerServerCertInfo.BindingMode.RequiresCertificate() {
 				if req.FMLManagerServerCertInfo.CommonName == "" {
 					req.FMLManagerServerCertInfo.CommonName = fmt.Sprintf("fmlmanager.server.%s", federation.Domain)
 				}
-				operationLog.Info().Msgf("creating certificate for FML Manager server with CN: %s", req.FMLManagerServerCertInfo.CommonName)
+				operationLog.Info().Msgf("preparing certificate for FML Manager server with CN: %s", req.FMLManagerServerCertInfo.CommonName)
 				dnsNames := []string{req.FMLManagerServerCertInfo.CommonName, "localhost"}
-				serverCert, serverPrivateKey, err = s.CertificateService.CreateCertificateSimple(req.FMLManagerServerCertInfo.CommonName, defaultCertLifetime, dnsNames)
+				serverCert, serverPrivateKey, err = s.prepareComponentCertificate(&req.FMLManagerServerCertInfo, dnsNames, x509.ExtKeyUsageServerAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create FML Manager server certificate")
+					return errors.Wrapf(err, "failed to prepare FML Manager server certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", serverCert.SerialNumber, serverCert.Subject.CommonName)
 			}
-			if req.FMLManagerClientCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.FMLManagerClientCertInfo.BindingMode.RequiresCertificate() {
 				if req.FMLManagerClientCertInfo.CommonName == "" {
 					req.FMLManagerClientCertInfo.CommonName = fmt.Sprintf("fmlmanager.client.%s", federation.Domain)
 				}
-				operationLog.Info().Msgf("creating certificate for FML Manager client with CN: %s", req.FMLManagerClientCertInfo.CommonName)
+				operationLog.Info().Msgf("preparing certificate for FML Manager client with CN: %s", req.FMLManagerClientCertInfo.CommonName)
 				dnsNames := []string{req.FMLManagerClientCertInfo.CommonName}
-				clientCert, clientPrivateKey, err = s.CertificateService.CreateCertificateSimple(req.FMLManagerClientCertInfo.CommonName, defaultCertLifetime, dnsNames)
+				clientCert, clientPrivateKey, err = s.prepareComponentCertificate(&req.FMLManagerClientCertInfo, dnsNames, x509.ExtKeyUsageClientAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create FML Manager client certificate")
+					return errors.Wrapf(err, "failed to prepare FML Manager client certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", clientCert.SerialNumber, clientCert.Subject.CommonName)
 			}
@@ -630,13 +675,44 @@ func (s *ParticipantFATEService) CreateExchange(req *ParticipantFATEExchangeCrea
 						FQDN:        fmlManagerFQDN,
 					}
 				}
+				if moduleName.(string) == "rollsite" {
+					operationLog.Info().Msgf("rollsite deployed, retrieving access info")
+					serviceType, host, port, err := getServiceAccess(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantFATEServiceNameRollsite), "tcp-grpc")
+					if err != nil {
+						return errors.Wrapf(err, "fail to get rollsite access info")
+					}
+					exchange.AccessInfo[entity.ParticipantFATEServiceNameRollsite] = entity.ParticipantModulesAccess{
+						ServiceType: serviceType,
+						Host:        host,
+						Port:        port,
+						TLS:         false,
+					}
+				}
 			}
 
 			exchange.Status = entity.ParticipantFATEStatusActive
 			if err := s.BuildIngressInfoMap(exchange); err != nil {
 				return errors.Wrapf(err, "failed to get ingress info")
 			}
-			return s.ParticipantFATERepo.UpdateInfoByUUID(exchange)
+			if err := s.ParticipantFATERepo.UpdateInfoByUUID(exchange); err != nil {
+				return err
+			}
+			// an exchange joining a federation with existing clusters needs the routes to them via their exchanges
+			instanceList, err := s.ParticipantFATERepo.ListByFederationUUID(federationUUID)
+			if err != nil {
+				return errors.Wrap(err, "failed to list participants")
+			}
+			participantList, _ := instanceList.([]entity.ParticipantFATE)
+			for _, participant := range participantList {
+				if participant.Type == entity.ParticipantFATETypeCluster {
+					operationLog.Info().Msg("building route table to the existing clusters")
+					if err := s.rebuildRouteTable(exchange); err != nil {
+						operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route table while creating exchange").Error())
+					}
+					break
+				}
+			}
+			return nil
 		}(); err != nil {
 			operationLog.Error().Msgf(errors.Wrapf(err, "failed to install FATE exchange").Error())
 			exchange.Status = entity.ParticipantFATEStatusFailed
@@ -653,6 +729,12 @@ func (s *ParticipantFATEService) CreateExchange(req *ParticipantFATEExchangeCrea
 
 // RemoveExchange removes and uninstalls a FATE exchange
 func (s *ParticipantFATEService) RemoveExchange(uuid string, force bool) (*sync.WaitGroup, error) {
+	return s.removeExchange(uuid, force, nil)
+}
+
+// removeExchange removes the exchange, the clusters in movingClusterUUIDs are still using it but are going to be
+// connected to another exchange
+func (s *ParticipantFATEService) removeExchange(uuid string, force bool, movingClusterUUIDs map[string]bool) (*sync.WaitGroup, error) {
 	exchange, err := s.loadParticipant(uuid)
 	if err != nil {
 		return nil, err
@@ -670,8 +752,19 @@ func (s *ParticipantFATEService) RemoveExchange(uuid string, force bool) (*sync.
 		return nil, errors.Wrapf(err, "failed to list participants in federation")
 	}
 	participantList := instanceList.([]entity.ParticipantFATE)
-	if len(participantList) > 1 {
-		return nil, errors.Errorf("cannot remove exchange as there are %v cluster(s) in this federation", len(participantList)-1)
+	defaultExchange, err := s.getFederationExchange(exchange.FederationUUID, "")
+	if err != nil {
+		return nil, err
+	}
+	clusterCount := 0
+	for _, participant := range participantList {
+		if participant.Type == entity.ParticipantFATETypeCluster && !movingClusterUUIDs[participant.UUID] &&
+			(clusterExchangeUUID(&participant, defaultExchange.UUID) == exchange.UUID || participant.StandbyExchangeUUID == exchange.UUID) {
+			clusterCount++
+		}
+	}
+	if clusterCount > 0 {
+		return nil, errors.Errorf("cannot remove exchange as there are %v cluster(s) using it as the primary or standby exchange", clusterCount)
 	}
 
 	exchange.Status = entity.ParticipantFATEStatusRemoving
@@ -795,16 +888,14 @@ func (s *ParticipantFATEService) RemoveExchange(uuid string, force bool) (*sync.
 // CreateExternalExchange creates an external FATE exchange with the access info provided by user
 func (s *ParticipantFATEService) CreateExternalExchange(req *ParticipantFATEExternalExchangeCreationRequest) (*entity.ParticipantFATE, error) {
 	federationUUID := req.FederationUUID
-	if exist, err := s.ParticipantFATERepo.IsExchangeCreatedByFederationUUID(federationUUID); err != nil {
-		return nil, errors.Wrapf(err, "failed to check exchange existence status")
-	} else if exist {
-		return nil, errors.Errorf("an exchange is already existed in federation %s", federationUUID)
-	}
 	instance, err := s.FederationRepo.GetByUUID(federationUUID)
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting federation info")
 	}
 	federation := instance.(*entity.FederationFATE)
+	if federation.Mode == entity.FederationFATEModePeerToPeer {
+		return nil, errors.Errorf("federation %s is in peer-to-peer mode and cannot have exchanges", federation.Name)
+	}
 
 	exchange := &entity.ParticipantFATE{
 		Participant: entity.Participant{
@@ -858,23 +949,25 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 	}
 	federation := instance.(*entity.FederationFATE)
 
-	instance, err = s.ParticipantFATERepo.GetExchangeByFederationUUID(federationUUID)
-	if err != nil {
-		return nil, nil, errors.Wrapf(err, "failed to check exchange existence status")
+	if err := s.validateFederationBackend(federationUUID, req.Backend); err != nil {
+		return nil, nil, err
 	}
-	exchange := instance.(*entity.ParticipantFATE)
-
-	if exchange.Status != entity.ParticipantFATEStatusActive {
-		return nil, nil, errors.Errorf("exchange %v is not in active status", exchange.UUID)
+	exchange, err := s.getClusterCreationExchange(federationUUID, federation.Mode, req.ExchangeUUID, req.StandbyExchangeUUID)
+	if err != nil {
+		return nil, nil, err
 	}
-	if exchange.IsManaged {
-		if err := s.EndpointService.TestKubeFATE(exchange.EndpointUUID); err != nil {
+	if exchange != nil {
+		req.ExchangeUUID = exchange.UUID
+		if err := validateExchangeBackendAccess(exchange, req.Backend); err != nil {
 			return nil, nil, err
 		}
 	}
 
-	if err := s.EndpointService.TestKubeFATE(req.EndpointUUID); err != nil {
-		return nil, nil, err
+	// self-registered clusters will have their endpoint prepared during the installation
+	if req.prepareEndpoint == nil {
+		if err := s.EndpointService.TestKubeFATE(req.EndpointUUID); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	instance, err = s.ChartRepo.GetByUUID(req.ChartUUID)
@@ -901,6 +994,18 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 	}
 	req.DeploymentYAML = string(finalYAMLBytes)
 
+	if err := validateClusterBackendInYAML(req.DeploymentYAML, req.Backend); err != nil {
+		return nil, nil, err
+	}
+	if req.DeploymentYAML, err = s.setClusterDirectRoutesInYAML(req.DeploymentYAML, federation, req.Backend); err != nil {
+		return nil, nil, err
+	}
+	if req.SizingProfileUUID != "" {
+		if req.DeploymentYAML, err = s.applySizingProfileToYAML(req.DeploymentYAML, req.SizingProfileUUID); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	pulsarDomain, err := getPulsarDomainFromYAML(req.DeploymentYAML)
 	if err != nil {
 		return nil, nil, err
@@ -910,11 +1015,19 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 		pulsarDomain = federation.Domain
 	}
 	pulsarFQDN := fmt.Sprintf("%d.%s", req.PartyID, pulsarDomain)
+	if req.Backend.OrDefault() != entity.ParticipantFATEBackendSparkPulsar {
+		// only pulsar is exposed with a certificate
+		req.PulsarServerCertInfo = entity.ParticipantComponentCertInfo{BindingMode: entity.CertBindingModeSkip}
+	}
+
+	if err := validateComponentCertInfo(req.PulsarServerCertInfo, req.SitePortalServerCertInfo, req.SitePortalClientCertInfo); err != nil {
+		return nil, nil, err
+	}
 
 	var caCert *x509.Certificate
-	if req.PulsarServerCertInfo.BindingMode == entity.CertBindingModeCreate ||
-		req.SitePortalClientCertInfo.BindingMode == entity.CertBindingModeCreate ||
-		req.SitePortalServerCertInfo.BindingMode == entity.CertBindingModeCreate {
+	if req.PulsarServerCertInfo.BindingMode.RequiresCertificate() ||
+		req.SitePortalClientCertInfo.BindingMode.RequiresCertificate() ||
+		req.SitePortalServerCertInfo.BindingMode.RequiresCertificate() {
 		ca, err := s.CertificateService.DefaultCA()
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "failed to get default CA")
@@ -950,11 +1063,27 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 			SitePortalServerCertInfo: req.SitePortalServerCertInfo,
 			SitePortalClientCertInfo: req.SitePortalClientCertInfo,
 		},
+		TokenUUID:           req.tokenUUID,
+		ExchangeUUID:        req.ExchangeUUID,
+		StandbyExchangeUUID: req.StandbyExchangeUUID,
+		SizingProfileUUID:   req.SizingProfileUUID,
+		Backend:             req.Backend.OrDefault(),
+	}
+	if err := setClusterRabbitMQPassword(cluster); err != nil {
+		return nil, nil, err
 	}
 	err = s.ParticipantFATERepo.Create(cluster)
 	if err != nil {
 		return nil, nil, err
 	}
+	if req.recordRegistration != nil {
+		if err := req.recordRegistration(cluster); err != nil {
+			if deleteErr := s.ParticipantFATERepo.DeleteByUUID(cluster.UUID); deleteErr != nil {
+				log.Err(deleteErr).Msgf("failed to delete cluster %s", cluster.UUID)
+			}
+			return nil, nil, err
+		}
+	}
 
 	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, "start creating cluster", entity.EventLogLevelInfo)
 
@@ -972,6 +1101,18 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 			}))
 		operationLog.Info().Msgf("creating FATE cluster %s with UUID %s", cluster.Name, cluster.UUID)
 		if err := func() error {
+			if req.prepareEndpoint != nil {
+				endpointUUID, err := req.prepareEndpoint()
+				if err != nil {
+					return errors.Wrap(err, "failed to prepare the kubefate endpoint")
+				}
+				req.EndpointUUID = endpointUUID
+				cluster.EndpointUUID = endpointUUID
+				if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+					return errors.Wrap(err, "failed to update cluster endpoint")
+				}
+				operationLog.Info().Msgf("kubefate endpoint prepared, uuid: %s", endpointUUID)
+			}
 			endpointMgr, kfClient, closer, err := s.buildKubeFATEMgrAndClient(req.EndpointUUID)
 			if closer != nil {
 				defer closer()
@@ -1010,13 +1151,13 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 				}
 			}
 
-			if req.PulsarServerCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.PulsarServerCertInfo.BindingMode.RequiresCertificate() {
 				req.PulsarServerCertInfo.CommonName = fmt.Sprintf("%v.%s", req.PartyID, federation.Domain)
-				operationLog.Info().Msgf("creating certificate for the pulsar service with CN: %s", req.PulsarServerCertInfo.CommonName)
+				operationLog.Info().Msgf("preparing certificate for the pulsar service with CN: %s", req.PulsarServerCertInfo.CommonName)
 				dnsNames := []string{req.PulsarServerCertInfo.CommonName}
-				cert, pk, err := s.CertificateService.CreateCertificateSimple(req.PulsarServerCertInfo.CommonName, defaultCertLifetime, dnsNames)
+				cert, pk, err := s.prepareComponentCertificate(&req.PulsarServerCertInfo, dnsNames, x509.ExtKeyUsageServerAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create pulsar certificate")
+					return errors.Wrapf(err, "failed to prepare pulsar certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", cert.SerialNumber, cert.Subject.CommonName)
 				err = createPulsarSecret(endpointMgr.K8sClient(), req.Namespace, caCert, cert, pk)
@@ -1036,28 +1177,28 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 			sitePortalFQDN := fmt.Sprintf("site-%v.server.%s", req.PartyID, federation.Domain)
 			var serverCert, clientCert *entity.Certificate
 			var serverPrivateKey, clientPrivateKey *rsa.PrivateKey
-			if req.SitePortalServerCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.SitePortalServerCertInfo.BindingMode.RequiresCertificate() {
 				if req.SitePortalServerCertInfo.CommonName == "" {
 					req.SitePortalServerCertInfo.CommonName = fmt.Sprintf("site-%v.server.%s", req.PartyID, federation.Domain)
 				}
-				operationLog.Info().Msgf("creating certificate for Site Portal server with CN: %s", req.SitePortalServerCertInfo.CommonName)
+				operationLog.Info().Msgf("preparing certificate for Site Portal server with CN: %s", req.SitePortalServerCertInfo.CommonName)
 				// we need localhost in the dnsNames because the site portal will call itself during some workflows
 				dnsNames := []string{req.SitePortalServerCertInfo.CommonName, "localhost"}
-				serverCert, serverPrivateKey, err = s.CertificateService.CreateCertificateSimple(req.SitePortalServerCertInfo.CommonName, defaultCertLifetime, dnsNames)
+				serverCert, serverPrivateKey, err = s.prepareComponentCertificate(&req.SitePortalServerCertInfo, dnsNames, x509.ExtKeyUsageServerAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create Site Portal server certificate")
+					return errors.Wrapf(err, "failed to prepare Site Portal server certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", serverCert.SerialNumber, serverCert.Subject.CommonName)
 			}
-			if req.SitePortalClientCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.SitePortalClientCertInfo.BindingMode.RequiresCertificate() {
 				if req.SitePortalClientCertInfo.CommonName == "" {
 					req.SitePortalClientCertInfo.CommonName = fmt.Sprintf("site-%v.client.%s", req.PartyID, federation.Domain)
 				}
-				operationLog.Info().Msgf("creating certificate for Site Portal client with CN: %s", req.SitePortalClientCertInfo.CommonName)
+				operationLog.Info().Msgf("preparing certificate for Site Portal client with CN: %s", req.SitePortalClientCertInfo.CommonName)
 				dnsNames := []string{req.SitePortalClientCertInfo.CommonName}
-				clientCert, clientPrivateKey, err = s.CertificateService.CreateCertificateSimple(req.SitePortalClientCertInfo.CommonName, defaultCertLifetime, dnsNames)
+				clientCert, clientPrivateKey, err = s.prepareComponentCertificate(&req.SitePortalClientCertInfo, dnsNames, x509.ExtKeyUsageClientAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create Site Portal client certificate")
+					return errors.Wrapf(err, "failed to prepare Site Portal client certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", clientCert.SerialNumber, clientCert.Subject.CommonName)
 			}
@@ -1121,30 +1262,55 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 			}
 			operationLog.Info().Msgf("kubefate job succeeded")
 
-			if req.PulsarServerCertInfo.BindingMode != entity.CertBindingModeSkip {
-				// the pulsar-public-tls service is always of type LoadBalancer, we try to use nodePort if no LoadBalancer IP is available
-				serviceType, host, port, err = getServiceAccessWithFallback(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantFATEServiceNamePulsar), "tls-port", true)
+			switch req.Backend.OrDefault() {
+			case entity.ParticipantFATEBackendSparkRabbitMQ:
+				serviceType, host, port, err = getServiceAccessWithFallback(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantFATEServiceNameRabbitMQ), "tcp-client", true)
 				if err != nil {
-					return errors.Wrapf(err, "fail to get pulsar access info")
+					return errors.Wrapf(err, "fail to get rabbitmq access info")
 				}
-				cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar] = entity.ParticipantModulesAccess{
+				cluster.AccessInfo[entity.ParticipantFATEServiceNameRabbitMQ] = entity.ParticipantModulesAccess{
 					ServiceType: serviceType,
 					Host:        host,
 					Port:        port,
-					TLS:         true,
-					FQDN:        pulsarFQDN,
+					TLS:         false,
 				}
-			} else {
-				pulsarHost, pulsarSSLPort, err := getPulsarInformationFromYAML(req.DeploymentYAML)
+			case entity.ParticipantFATEBackendEggroll:
+				serviceType, host, port, err = getServiceAccessWithFallback(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantFATEServiceNameRollsite), "tcp-grpc", true)
 				if err != nil {
-					return errors.Wrapf(err, "fail to get pulsar access info")
+					return errors.Wrapf(err, "fail to get rollsite access info")
 				}
-				cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar] = entity.ParticipantModulesAccess{
-					ServiceType: "External",
-					Host:        pulsarHost,
-					Port:        pulsarSSLPort,
-					TLS:         true,
-					FQDN:        pulsarFQDN,
+				cluster.AccessInfo[entity.ParticipantFATEServiceNameRollsite] = entity.ParticipantModulesAccess{
+					ServiceType: serviceType,
+					Host:        host,
+					Port:        port,
+					TLS:         false,
+				}
+			case entity.ParticipantFATEBackendSparkPulsar:
+				if req.PulsarServerCertInfo.BindingMode != entity.CertBindingModeSkip {
+					// the pulsar-public-tls service is always of type LoadBalancer, we try to use nodePort if no LoadBalancer IP is available
+					serviceType, host, port, err = getServiceAccessWithFallback(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantFATEServiceNamePulsar), "tls-port", true)
+					if err != nil {
+						return errors.Wrapf(err, "fail to get pulsar access info")
+					}
+					cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar] = entity.ParticipantModulesAccess{
+						ServiceType: serviceType,
+						Host:        host,
+						Port:        port,
+						TLS:         true,
+						FQDN:        pulsarFQDN,
+					}
+				} else {
+					pulsarHost, pulsarSSLPort, err := getPulsarInformationFromYAML(req.DeploymentYAML)
+					if err != nil {
+						return errors.Wrapf(err, "fail to get pulsar access info")
+					}
+					cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar] = entity.ParticipantModulesAccess{
+						ServiceType: "External",
+						Host:        pulsarHost,
+						Port:        pulsarSSLPort,
+						TLS:         true,
+						FQDN:        pulsarFQDN,
+					}
 				}
 			}
 
@@ -1163,7 +1329,9 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 						TLS:         true,
 						FQDN:        sitePortalFQDN,
 					}
-					if fmlManagerInfo, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameFMLMgr]; ok {
+					if exchange == nil {
+						operationLog.Info().Msgf("there is no fml manager in a peer-to-peer federation, skipping configuration of site portal")
+					} else if fmlManagerInfo, ok := exchange.AccessInfo[entity.ParticipantFATEServiceNameFMLMgr]; ok {
 						go func() {
 							operationLog.Info().Msgf("automatically configure site portal and its connection with fml manager")
 							password, err := cluster.GetSitePortalAdminPassword()
@@ -1208,11 +1376,9 @@ func (s *ParticipantFATEService) CreateCluster(req *ParticipantFATEClusterCreati
 			if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
 				return errors.Wrap(err, "failed to save cluster info")
 			}
-			if exchange.IsManaged {
-				operationLog.Info().Msg("rebuilding exchange route table")
-				if err := s.rebuildRouteTable(exchange); err != nil {
-					operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route table while creating cluster").Error())
-				}
+			operationLog.Info().Msg("rebuilding exchange route tables")
+			if err := s.rebuildFederationRouteTables(federationUUID); err != nil {
+				operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while creating cluster").Error())
 			}
 			return nil
 		}(); err != nil {
@@ -1242,19 +1408,28 @@ func (s *ParticipantFATEService) RemoveCluster(uuid string, force bool) (*sync.W
 		return nil, errors.Errorf("cluster cannot be removed when in status: %v", cluster.Status)
 	}
 
-	instance, err := s.ParticipantFATERepo.GetExchangeByFederationUUID(cluster.FederationUUID)
+	instanceList, err := s.ParticipantFATERepo.ListExchangesByFederationUUID(cluster.FederationUUID)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to check exchange existence status")
-	}
-	exchange := instance.(*entity.ParticipantFATE)
-
-	if exchange.IsManaged {
+		return nil, errors.Wrapf(err, "failed to list exchanges")
+	}
+	// the cluster is removed from the route tables of all the exchanges, as the other exchanges have peer routes to it
+	var exchangeList []*entity.ParticipantFATE
+	for _, exchange := range instanceList.([]entity.ParticipantFATE) {
+		exchange := exchange
+		if !exchange.IsManaged {
+			continue
+		}
 		if err := s.EndpointService.TestKubeFATE(exchange.EndpointUUID); err != nil {
 			if !force {
 				return nil, err
 			}
-			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, "failed to test exchange endpoint connection, continue as the force flag is set", entity.EventLogLevelError)
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, fmt.Sprintf("failed to test exchange %s endpoint connection, continue as the force flag is set", exchange.Name), entity.EventLogLevelError)
 		}
+		exchangeList = append(exchangeList, &exchange)
+	}
+	federation, err := s.loadFATEFederation(cluster.FederationUUID)
+	if err != nil {
+		return nil, err
 	}
 	if cluster.IsManaged {
 		if err := s.EndpointService.TestKubeFATE(cluster.EndpointUUID); err != nil {
@@ -1295,74 +1470,9 @@ func (s *ParticipantFATEService) RemoveCluster(uuid string, force bool) (*sync.W
 			}))
 		operationLog.Info().Msgf("uninstalling FATE cluster %s with UUID %s", cluster.Name, cluster.UUID)
 		err := func() error {
-			if exchange.IsManaged {
-				operationLog.Info().Msgf("updating exchange route table")
-				if err := func() error {
-					exchangeEndpointMgr, exchangeKFClient, exchangeKFClientCloser, err := s.buildKubeFATEMgrAndClient(exchange.EndpointUUID)
-					if exchangeKFClientCloser != nil {
-						defer exchangeKFClientCloser()
-					}
-					if err != nil {
-						return errors.Wrap(err, "cannot get exchange endpoint manager")
-					}
-
-					// this process is faster than the rebuildRouteTable function
-					var m map[string]interface{}
-					_ = yaml.Unmarshal([]byte(exchange.DeploymentYAML), &m)
-
-					if m["nginx"].(map[string]interface{})["route_table"] != nil {
-						routeTable := m["nginx"].(map[string]interface{})["route_table"].(map[string]interface{})
-						delete(routeTable, fmt.Sprintf("%v", cluster.PartyID))
-					}
-
-					sniTable := m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{})["sni"]
-					if sniTable != nil {
-						var newTable []interface{}
-
-						sniTableList := sniTable.([]interface{})
-						for _, item := range sniTableList {
-							itemBytes, _ := yaml.Marshal(item)
-							var entry atsRouteTableEntry
-							_ = yaml.Unmarshal(itemBytes, &entry)
-							if strings.HasPrefix(entry.FQDN, fmt.Sprintf("%v.", cluster.PartyID)) {
-								continue
-							}
-							newTable = append(newTable, item)
-						}
-						m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{})["sni"] = newTable
-					}
-
-					updatedYaml, _ := yaml.Marshal(m)
-
-					var originalMap map[string]interface{}
-					_ = yaml.Unmarshal([]byte(exchange.DeploymentYAML), &originalMap)
-					originalYaml, _ := yaml.Marshal(originalMap)
-					if bytes.Equal(updatedYaml, originalYaml) {
-						operationLog.Info().Msg("exchange yaml not changed")
-						return nil
-					}
-
-					exchange.DeploymentYAML = string(updatedYaml)
-					if err := s.ParticipantFATERepo.UpdateDeploymentYAMLByUUID(exchange); err != nil {
-						return errors.Wrapf(err, "failed to update exchange info")
-					}
-
-					jobUUID, err := exchangeKFClient.SubmitClusterUpdateJob(exchange.DeploymentYAML)
-					if err != nil {
-						return errors.Wrapf(err, "failed to submit exchange update job")
-					}
-					exchange.JobUUID = jobUUID
-					if err := s.ParticipantFATERepo.UpdateInfoByUUID(exchange); err != nil {
-						return errors.Wrap(err, "failed to update exchange's job uuid")
-					}
-					if job, err := exchangeKFClient.WaitJob(jobUUID); err != nil {
-						return errors.Wrapf(err, "failed to query exchange update job status")
-					} else if job.Status != modules.JobStatusSuccess {
-						operationLog.Warn().Msgf("updating job not succeeded, status: %v, job info: %v", job.Status, job)
-					}
-					operationLog.Info().Msgf("restarting exchange ATS")
-					return deletePodWithPrefix(exchangeEndpointMgr.K8sClient(), exchange.Namespace, "traffic-server")
-				}(); err != nil {
+			for _, exchange := range exchangeList {
+				operationLog.Info().Msgf("updating route table of exchange %s", exchange.Name)
+				if err := s.removeClusterFromRouteTable(exchange, cluster, &operationLog); err != nil {
 					operationLog.Error().Msg(errors.Wrap(err, "error updating exchange route table").Error())
 					if err := s.rebuildRouteTable(exchange); err != nil {
 						operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route table while deleting cluster").Error())
@@ -1371,6 +1481,12 @@ func (s *ParticipantFATEService) RemoveCluster(uuid string, force bool) (*sync.W
 					}
 				}
 			}
+			if federation.Mode == entity.FederationFATEModePeerToPeer {
+				operationLog.Info().Msg("removing the cluster from the route tables of its peers")
+				if err := s.rebuildPeerRouteTables(cluster.FederationUUID); err != nil {
+					operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while deleting cluster").Error())
+				}
+			}
 			if cluster.IsManaged {
 				clusterEndpointMgr, clusterKFClient, clusterKFClientCloser, err := s.buildKubeFATEMgrAndClient(cluster.EndpointUUID)
 				if clusterKFClientCloser != nil {
@@ -1497,19 +1613,16 @@ func (s *ParticipantFATEService) CreateExternalCluster(req *ParticipantFATEExter
 	}
 	federation := instance.(*entity.FederationFATE)
 
-	instance, err = s.ParticipantFATERepo.GetExchangeByFederationUUID(federationUUID)
+	// external clusters are connected with their pulsar access info
+	if err := s.validateFederationBackend(federationUUID, entity.ParticipantFATEBackendSparkPulsar); err != nil {
+		return nil, nil, err
+	}
+	exchange, err := s.getClusterCreationExchange(federationUUID, federation.Mode, req.ExchangeUUID, req.StandbyExchangeUUID)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "failed to check exchange existence status")
+		return nil, nil, err
 	}
-	exchange := instance.(*entity.ParticipantFATE)
-
-	if exchange.Status != entity.ParticipantFATEStatusActive {
-		return nil, nil, errors.Errorf("exchange %v is not in active status", exchange.UUID)
-	}
-	if exchange.IsManaged {
-		if err := s.EndpointService.TestKubeFATE(exchange.EndpointUUID); err != nil {
-			return nil, nil, err
-		}
+	if exchange != nil {
+		req.ExchangeUUID = exchange.UUID
 	}
 	cluster := &entity.ParticipantFATE{
 		Participant: entity.Participant{
@@ -1524,10 +1637,12 @@ func (s *ParticipantFATEService) CreateExternalCluster(req *ParticipantFATEExter
 			DeploymentYAML: "Unknown",
 			IsManaged:      false,
 		},
-		Type:       entity.ParticipantFATETypeCluster,
-		PartyID:    req.PartyID,
-		Status:     entity.ParticipantFATEStatusActive,
-		AccessInfo: entity.ParticipantFATEModulesAccessMap{},
+		Type:                entity.ParticipantFATETypeCluster,
+		PartyID:             req.PartyID,
+		Status:              entity.ParticipantFATEStatusActive,
+		AccessInfo:          entity.ParticipantFATEModulesAccessMap{},
+		ExchangeUUID:        req.ExchangeUUID,
+		StandbyExchangeUUID: req.StandbyExchangeUUID,
 	}
 	cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar] = entity.ParticipantModulesAccess{
 		ServiceType: corev1.ServiceType(entity.ParticipantDefaultServiceTypeNodePort.String()),
@@ -1553,7 +1668,7 @@ func (s *ParticipantFATEService) CreateExternalCluster(req *ParticipantFATEExter
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if exchange.IsManaged {
+		if exchange == nil || exchange.IsManaged {
 			operationLog := log.Logger.With().Timestamp().Str("action", "configuring external fate cluster").Str("uuid", cluster.UUID).Logger().
 				Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
 					eventLvl := entity.EventLogLevelInfo
@@ -1562,8 +1677,8 @@ func (s *ParticipantFATEService) CreateExternalCluster(req *ParticipantFATEExter
 					}
 					_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, message, eventLvl)
 				}))
-			if err := s.rebuildRouteTable(exchange); err != nil {
-				operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route table while creating cluster").Error())
+			if err := s.rebuildFederationRouteTables(federationUUID); err != nil {
+				operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while creating cluster").Error())
 				cluster.Status = entity.ParticipantFATEStatusFailed
 				if err := s.ParticipantFATERepo.UpdateStatusByUUID(cluster); err != nil {
 					operationLog.Error().Msg(errors.Wrap(err, "failed to update cluster status").Error())
@@ -1574,25 +1689,35 @@ func (s *ParticipantFATEService) CreateExternalCluster(req *ParticipantFATEExter
 	return cluster, wg, nil
 }
 
-func (s *ParticipantFATEService) buildNginxRouteTable(routeTable map[string]interface{}, cluster *entity.ParticipantFATE) {
+// buildNginxRouteTable adds the route to the cluster, the traffic goes through the peer exchange if it is not nil
+func (s *ParticipantFATEService) buildNginxRouteTable(routeTable map[string]interface{}, cluster *entity.ParticipantFATE, peer *entity.ParticipantFATE) {
+	access := cluster.AccessInfo[entity.ParticipantFATEServiceNameNginx]
+	if peer != nil {
+		access = peer.AccessInfo[entity.ParticipantFATEServiceNameNginx]
+	}
 	routeTable[fmt.Sprintf("%d", cluster.PartyID)] = map[string][]nginxRouteTableEntry{
 		"fateflow": {
 			{
-				Host:     cluster.AccessInfo[entity.ParticipantFATEServiceNameNginx].Host,
-				HttpPort: cluster.AccessInfo[entity.ParticipantFATEServiceNameNginx].Port,
+				Host:     access.Host,
+				HttpPort: access.Port,
 			},
 		},
 	}
 }
 
-func (s *ParticipantFATEService) buildATSRouteTable(routeTable map[string]interface{}, cluster *entity.ParticipantFATE) {
+// buildATSRouteTable adds the route to the cluster, the traffic is tunneled to the peer exchange if it is not nil
+func (s *ParticipantFATEService) buildATSRouteTable(routeTable map[string]interface{}, cluster *entity.ParticipantFATE, peer *entity.ParticipantFATE) {
 	sniTable := routeTable["sni"]
 	if sniTable == nil {
 		sniTable = []interface{}{}
 	}
+	access := cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar]
+	if peer != nil {
+		access = peer.AccessInfo[entity.ParticipantFATEServiceNameATS]
+	}
 	sniTable = append(sniTable.([]interface{}), atsRouteTableEntry{
 		FQDN:        cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar].FQDN,
-		TunnelRoute: fmt.Sprintf("%s:%d", cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar].Host, cluster.AccessInfo[entity.ParticipantFATEServiceNamePulsar].Port),
+		TunnelRoute: fmt.Sprintf("%s:%d", access.Host, access.Port),
 	})
 	routeTable["sni"] = sniTable
 }
@@ -1613,6 +1738,17 @@ func (s *ParticipantFATEService) rebuildRouteTable(exchange *entity.ParticipantF
 	}
 	participantList := instanceList.([]entity.ParticipantFATE)
 
+	defaultExchange, err := s.getFederationExchange(exchange.FederationUUID, "")
+	if err != nil {
+		return err
+	}
+	exchangeMap := map[string]*entity.ParticipantFATE{}
+	for index, participant := range participantList {
+		if participant.Type == entity.ParticipantFATETypeExchange {
+			exchangeMap[participant.UUID] = &participantList[index]
+		}
+	}
+
 	exchangeEndpointMgr, exchangeKFClient, exchangeKFClientCloser, err := s.buildKubeFATEMgrAndClient(exchange.EndpointUUID)
 	if exchangeKFClientCloser != nil {
 		defer exchangeKFClientCloser()
@@ -1627,13 +1763,37 @@ func (s *ParticipantFATEService) rebuildRouteTable(exchange *entity.ParticipantF
 	// reset the route table
 	m["nginx"].(map[string]interface{})["route_table"] = map[string]interface{}{}
 	m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{})["sni"] = []interface{}{}
+	rollsite, hasRollsite := m["rollsite"].(map[string]interface{})
+	rollsitePartyList := []interface{}{}
 
 	for _, participant := range participantList {
 		if participant.Type == entity.ParticipantFATETypeCluster && participant.Status == entity.ParticipantFATEStatusActive {
-			s.buildNginxRouteTable(m["nginx"].(map[string]interface{})["route_table"].(map[string]interface{}), &participant)
-			s.buildATSRouteTable(m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{}), &participant)
+			// clusters of other exchanges are reached through their primary exchange, which peers the exchanges
+			var peer *entity.ParticipantFATE
+			if primaryUUID := clusterExchangeUUID(&participant, defaultExchange.UUID); primaryUUID != exchange.UUID {
+				if primary, ok := exchangeMap[primaryUUID]; ok && primary.Status == entity.ParticipantFATEStatusActive {
+					peer = primary
+				} else {
+					operationLog.Warn().Msgf("primary exchange %s of cluster %s is not available, routing to the cluster directly", primaryUUID, participant.Name)
+				}
+			}
+			s.buildNginxRouteTable(m["nginx"].(map[string]interface{})["route_table"].(map[string]interface{}), &participant, peer)
+			// spark_rabbitmq clusters connect to each other's rabbitmq directly, so only fateflow traffic goes through the exchange
+			switch participant.Backend.OrDefault() {
+			case entity.ParticipantFATEBackendSparkPulsar:
+				s.buildATSRouteTable(m["trafficServer"].(map[string]interface{})["route_table"].(map[string]interface{}), &participant, peer)
+			case entity.ParticipantFATEBackendEggroll:
+				if !hasRollsite {
+					operationLog.Warn().Msgf("exchange is not deployed with rollsite, eggroll cluster %s is not routed", participant.Name)
+					continue
+				}
+				rollsitePartyList = buildRollsiteRouteTable(rollsitePartyList, &participant, peer)
+			}
 		}
 	}
+	if hasRollsite {
+		rollsite["partyList"] = rollsitePartyList
+	}
 
 	updatedYaml, _ := yaml.Marshal(m)
 	exchange.DeploymentYAML = string(updatedYaml)
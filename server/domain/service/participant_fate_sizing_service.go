@@ -0,0 +1,256 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"sigs.k8s.io/yaml"
+)
+
+// ParticipantFATESizingProfileRequest contains the settings of a custom sizing profile
+type ParticipantFATESizingProfileRequest struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Sizing      valueobject.FATEClusterSizing `json:"sizing"`
+}
+
+// CreateSizingProfile creates a custom sizing profile
+func (s *ParticipantFATEService) CreateSizingProfile(req *ParticipantFATESizingProfileRequest) (*entity.ParticipantFATESizingProfile, error) {
+	if req.Name == "" {
+		return nil, errors.New("missing profile name")
+	}
+	if err := req.Sizing.Validate(); err != nil {
+		return nil, err
+	}
+	profile := &entity.ParticipantFATESizingProfile{
+		UUID:        uuid.NewV4().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Sizing:      req.Sizing,
+	}
+	if err := s.SizingProfileRepo.Create(profile); err != nil {
+		return nil, errors.Wrap(err, "failed to create sizing profile")
+	}
+	return profile, nil
+}
+
+// UpdateSizingProfile updates the description and sizing settings of a custom profile. The clusters using the profile
+// are not changed until they are resized again.
+func (s *ParticipantFATEService) UpdateSizingProfile(profileUUID string, req *ParticipantFATESizingProfileRequest) error {
+	profile, err := s.getCustomSizingProfile(profileUUID)
+	if err != nil {
+		return err
+	}
+	if err := req.Sizing.Validate(); err != nil {
+		return err
+	}
+	profile.Description = req.Description
+	profile.Sizing = req.Sizing
+	return s.SizingProfileRepo.UpdateByUUID(profile)
+}
+
+// DeleteSizingProfile deletes a custom profile that is not used by any cluster
+func (s *ParticipantFATEService) DeleteSizingProfile(profileUUID string) error {
+	if _, err := s.getCustomSizingProfile(profileUUID); err != nil {
+		return err
+	}
+	instanceList, err := s.ParticipantFATERepo.List()
+	if err != nil {
+		return errors.Wrap(err, "failed to list participants")
+	}
+	for _, participant := range instanceList.([]entity.ParticipantFATE) {
+		if participant.SizingProfileUUID == profileUUID {
+			return errors.Errorf("the profile is used by cluster %s", participant.Name)
+		}
+	}
+	return s.SizingProfileRepo.DeleteByUUID(profileUUID)
+}
+
+func (s *ParticipantFATEService) getCustomSizingProfile(profileUUID string) (*entity.ParticipantFATESizingProfile, error) {
+	instance, err := s.SizingProfileRepo.GetByUUID(profileUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query sizing profile")
+	}
+	profile := instance.(*entity.ParticipantFATESizingProfile)
+	if profile.BuiltIn {
+		return nil, errors.Errorf("built-in profile %s cannot be modified", profile.Name)
+	}
+	return profile, nil
+}
+
+// applySizingProfileToYAML returns the cluster deployment yaml with the settings of the profile applied
+func (s *ParticipantFATEService) applySizingProfileToYAML(deploymentYAML, profileUUID string) (string, error) {
+	instance, err := s.SizingProfileRepo.GetByUUID(profileUUID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to query sizing profile")
+	}
+	return setClusterSizingInYAML(deploymentYAML, &instance.(*entity.ParticipantFATESizingProfile).Sizing)
+}
+
+// ResizeCluster applies the sizing profile to a managed FATE cluster and updates the cluster via KubeFATE
+func (s *ParticipantFATEService) ResizeCluster(clusterUUID, profileUUID string) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
+	instance, err := s.ParticipantFATERepo.GetByUUID(clusterUUID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to query cluster")
+	}
+	cluster := instance.(*entity.ParticipantFATE)
+	if cluster.Type != entity.ParticipantFATETypeCluster {
+		return nil, nil, errors.Errorf("participant %s is not a FATE cluster", cluster.Name)
+	}
+	if !cluster.IsManaged {
+		return nil, nil, errors.New("the cluster not managed by FedLCM cannot be resized")
+	}
+	if cluster.Status != entity.ParticipantFATEStatusActive {
+		return nil, nil, errors.Errorf("cluster cannot be resized when in status: %v", cluster.Status)
+	}
+	if err := s.EndpointService.TestKubeFATE(cluster.EndpointUUID); err != nil {
+		return nil, nil, err
+	}
+	deploymentYAML, err := s.applySizingProfileToYAML(cluster.DeploymentYAML, profileUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previousDeploymentYAML, previousProfileUUID := cluster.DeploymentYAML, cluster.SizingProfileUUID
+	cluster.DeploymentYAML = deploymentYAML
+	cluster.SizingProfileUUID = profileUUID
+	cluster.Status = entity.ParticipantFATEStatusReconfiguring
+	if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to update cluster info")
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := log.Logger.With().Timestamp().Str("action", "resizing fate cluster").Str("uuid", cluster.UUID).Logger().
+			Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+				eventLvl := entity.EventLogLevelInfo
+				if level == zerolog.ErrorLevel {
+					eventLvl = entity.EventLogLevelError
+				}
+				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeCluster, cluster.UUID, message, eventLvl)
+			}))
+		operationLog.Info().Msgf("resizing FATE cluster %s with sizing profile %s", cluster.Name, profileUUID)
+		if err := func() error {
+			_, kfClient, closer, err := s.buildKubeFATEMgrAndClient(cluster.EndpointUUID)
+			if closer != nil {
+				defer closer()
+			}
+			if err != nil {
+				return err
+			}
+			jobUUID, err := kfClient.SubmitClusterUpdateJob(cluster.DeploymentYAML)
+			if err != nil {
+				return errors.Wrapf(err, "failed to submit cluster update job")
+			}
+			cluster.JobUUID = jobUUID
+			if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+				return errors.Wrap(err, "failed to update cluster's job uuid")
+			}
+			operationLog.Info().Msgf("KubeFATE update job uuid: %s", jobUUID)
+			if job, err := kfClient.WaitJob(jobUUID); err != nil {
+				return errors.Wrapf(err, "failed to query cluster update job status")
+			} else if job.Status != modules.JobStatusSuccess {
+				return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
+			}
+			return nil
+		}(); err != nil {
+			operationLog.Error().Msg(errors.Wrap(err, "failed to resize FATE cluster").Error())
+			// we still mark the cluster to be active as kubefate can roll back the failed update
+			cluster.DeploymentYAML = previousDeploymentYAML
+			cluster.SizingProfileUUID = previousProfileUUID
+		} else {
+			operationLog.Info().Msgf("FATE cluster %s(%s) resized", cluster.Name, cluster.UUID)
+		}
+		cluster.Status = entity.ParticipantFATEStatusActive
+		if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
+			operationLog.Error().Msg(errors.Wrap(err, "failed to update cluster info").Error())
+		}
+	}()
+	return cluster, wg, nil
+}
+
+// setClusterSizingInYAML returns the cluster deployment yaml with the sizing settings applied to the deployed modules.
+// Existing resource settings that are not specified, like the GPU requests, are kept.
+func setClusterSizingInYAML(deploymentYAML string, sizing *valueobject.FATEClusterSizing) (string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	deployedModules := map[string]bool{}
+	if moduleList, ok := m["modules"].([]interface{}); ok {
+		for _, module := range moduleList {
+			deployedModules[fmt.Sprint(module)] = true
+		}
+	}
+	if deployedModules["python"] {
+		setComponentSizing(m, "python", &sizing.Python)
+	}
+	if deployedModules["nodemanager"] {
+		setComponentSizing(m, "nodemanager", &sizing.NodeManager)
+		if sizing.ProcessorsPerNode > 0 {
+			getOrCreateYAMLSection(m, "nodemanager")["sessionProcessorsPerNode"] = sizing.ProcessorsPerNode
+		}
+	}
+	if deployedModules["spark"] {
+		setComponentSizing(getOrCreateYAMLSection(m, "spark"), "worker", &sizing.SparkWorker)
+		// fateflow sizes the spark tasks according to the spark cluster
+		pythonSpark := getOrCreateYAMLSection(getOrCreateYAMLSection(m, "python"), "spark")
+		if sizing.SparkWorker.Replicas > 0 {
+			pythonSpark["nodes"] = sizing.SparkWorker.Replicas
+		}
+		if sizing.ProcessorsPerNode > 0 {
+			pythonSpark["cores_per_node"] = sizing.ProcessorsPerNode
+		}
+	}
+	updatedYAML, err := yaml.Marshal(m)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get final yaml content")
+	}
+	return string(updatedYAML), nil
+}
+
+// setComponentSizing sets the replicas and merges the resources into the component section under the parent
+func setComponentSizing(parent map[string]interface{}, componentKey string, sizing *valueobject.FATEComponentSizing) {
+	if sizing.Replicas > 0 {
+		getOrCreateYAMLSection(parent, componentKey)["replicas"] = sizing.Replicas
+	}
+	for key, values := range sizing.Resources() {
+		resources := getOrCreateYAMLSection(getOrCreateYAMLSection(parent, componentKey), "resources")
+		section := getOrCreateYAMLSection(resources, key)
+		for name, value := range values.(map[string]interface{}) {
+			section[name] = value
+		}
+	}
+}
+
+func getOrCreateYAMLSection(m map[string]interface{}, key string) map[string]interface{} {
+	section, ok := m[key].(map[string]interface{})
+	if !ok {
+		section = map[string]interface{}{}
+		m[key] = section
+	}
+	return section
+}
@@ -0,0 +1,205 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const testSparkClusterDeploymentYAML = `chartName: fate
+modules:
+- mysql
+- python
+- spark
+- hdfs
+- pulsar
+- nginx
+name: test-cluster
+python:
+  resources:
+    requests:
+      nvidia.com/gpu: 1
+    limits:
+      nvidia.com/gpu: 1
+  spark:
+    cores_per_node: 20
+    nodes: 2
+    master: spark://spark-master:7077
+spark:
+  master:
+    replicas: 1
+  worker:
+    replicas: 2`
+
+const testEggrollClusterDeploymentYAML = `chartName: fate
+modules:
+- rollsite
+- clustermanager
+- nodemanager
+- python
+name: test-cluster
+nodemanager:
+  replicas: 2
+  sessionProcessorsPerNode: 2`
+
+func TestSetClusterSizingInYAML_PosSpark(t *testing.T) {
+	sizing := entity.BuiltInParticipantFATESizingProfiles()[1].Sizing
+	deploymentYAML, err := setClusterSizingInYAML(testSparkClusterDeploymentYAML, &sizing)
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(deploymentYAML), &m))
+	python := m["python"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "2", "memory": "4Gi", "nvidia.com/gpu": float64(1)},
+		"limits":   map[string]interface{}{"cpu": "4", "memory": "8Gi", "nvidia.com/gpu": float64(1)},
+	}, python["resources"], "the gpu settings should be kept")
+	pythonSpark := python["spark"].(map[string]interface{})
+	assert.Equal(t, float64(2), pythonSpark["nodes"])
+	assert.Equal(t, float64(4), pythonSpark["cores_per_node"])
+	assert.Equal(t, "spark://spark-master:7077", pythonSpark["master"])
+	worker := m["spark"].(map[string]interface{})["worker"].(map[string]interface{})
+	assert.Equal(t, float64(2), worker["replicas"])
+	assert.Contains(t, worker, "resources")
+	assert.NotContains(t, m, "nodemanager", "modules not deployed should not be added")
+}
+
+func TestSetClusterSizingInYAML_PosEggroll(t *testing.T) {
+	sizing := valueobject.FATEClusterSizing{
+		NodeManager:       valueobject.FATEComponentSizing{Replicas: 3, MemoryLimit: "16Gi"},
+		SparkWorker:       valueobject.FATEComponentSizing{Replicas: 3},
+		ProcessorsPerNode: 8,
+	}
+	deploymentYAML, err := setClusterSizingInYAML(testEggrollClusterDeploymentYAML, &sizing)
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(deploymentYAML), &m))
+	assert.Equal(t, map[string]interface{}{
+		"replicas":                 float64(3),
+		"sessionProcessorsPerNode": float64(8),
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{"memory": "16Gi"},
+		},
+	}, m["nodemanager"])
+	assert.NotContains(t, m, "spark")
+	assert.NotContains(t, m, "python", "empty sizing should not change the component")
+}
+
+func TestFATEClusterSizing_Validate(t *testing.T) {
+	for _, profile := range entity.BuiltInParticipantFATESizingProfiles() {
+		assert.NoError(t, profile.Sizing.Validate(), profile.Name)
+	}
+	for name, sizing := range map[string]valueobject.FATEClusterSizing{
+		"negative replicas":     {SparkWorker: valueobject.FATEComponentSizing{Replicas: -1}},
+		"negative processors":   {ProcessorsPerNode: -1},
+		"invalid quantity":      {Python: valueobject.FATEComponentSizing{CPURequest: "two"}},
+		"request exceeds limit": {Python: valueobject.FATEComponentSizing{MemoryRequest: "8Gi", MemoryLimit: "4Gi"}},
+	} {
+		assert.Error(t, sizing.Validate(), name)
+	}
+}
+
+func TestSizingProfile_NegBuiltInAndUsedProfiles(t *testing.T) {
+	builtInProfile := entity.BuiltInParticipantFATESizingProfiles()[1]
+	builtInProfile.UUID = "built-in"
+	customProfile := builtInProfile
+	customProfile.UUID = "custom"
+	customProfile.BuiltIn = false
+	profiles := map[string]*entity.ParticipantFATESizingProfile{
+		"built-in": &builtInProfile,
+		"custom":   &customProfile,
+	}
+	cluster := testFATECluster("cluster-1", 9999, "", "")
+	cluster.SizingProfileUUID = "custom"
+	deleted := false
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: &mock.ParticipantFATERepoMock{
+			ListFn: func() (interface{}, error) {
+				return []entity.ParticipantFATE{cluster}, nil
+			},
+		},
+		SizingProfileRepo: &mock.ParticipantFATESizingProfileRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return profiles[uuid], nil
+			},
+			DeleteByUUIDFn: func(string) error {
+				deleted = true
+				return nil
+			},
+		},
+	}
+
+	req := &ParticipantFATESizingProfileRequest{Sizing: profiles["custom"].Sizing}
+	assert.Error(t, service.UpdateSizingProfile("built-in", req))
+	assert.Error(t, service.DeleteSizingProfile("built-in"))
+	assert.NoError(t, service.UpdateSizingProfile("custom", req))
+	assert.ErrorContains(t, service.DeleteSizingProfile("custom"), "cluster-1")
+	assert.False(t, deleted)
+
+	_, err := service.CreateSizingProfile(&ParticipantFATESizingProfileRequest{
+		Name:   "invalid",
+		Sizing: valueobject.FATEClusterSizing{ProcessorsPerNode: -1},
+	})
+	assert.Error(t, err)
+}
+
+func TestResizeCluster_PosApplyProfile(t *testing.T) {
+	participantList := []entity.ParticipantFATE{
+		testFATECluster("cluster-1", 9999, "", ""),
+	}
+	cluster := &participantList[0]
+	cluster.IsManaged = true
+	cluster.DeploymentYAML = testSparkClusterDeploymentYAML
+	service := &ParticipantFATEService{
+		ParticipantFATERepo: mockParticipantFATEListRepo(participantList, &mock.ParticipantFATERepoMock{}),
+		SizingProfileRepo: &mock.ParticipantFATESizingProfileRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				profile := entity.BuiltInParticipantFATESizingProfiles()[1]
+				profile.UUID = uuid
+				profile.BuiltIn = false
+				return &profile, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			FederationRepo:  &mock.FederationFATERepoMock{},
+			EventService:    &mockEventServiceInt{},
+			EndpointService: &mockParticipantFATEEndpointServiceInt{},
+		},
+	}
+
+	_, wg, err := service.ResizeCluster(cluster.UUID, "test-profile")
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, entity.ParticipantFATEStatusActive, cluster.Status)
+	assert.Equal(t, "test-profile", cluster.SizingProfileUUID)
+	assert.Equal(t, "test-job-id", cluster.JobUUID)
+	assert.Contains(t, cluster.DeploymentYAML, "cores_per_node: 4")
+
+	cluster.Status = entity.ParticipantFATEStatusUpgrading
+	_, _, err = service.ResizeCluster(cluster.UUID, "test-profile")
+	assert.Error(t, err, "cluster with ongoing operations cannot be resized")
+
+	cluster.Status = entity.ParticipantFATEStatusActive
+	cluster.IsManaged = false
+	_, _, err = service.ResizeCluster(cluster.UUID, "test-profile")
+	assert.Error(t, err, "external cluster cannot be resized")
+}
@@ -183,30 +183,36 @@ func (s *ParticipantFATEService) UpgradeExchange(req *ParticipantFATEExchangeUpg
 // UpgradeCluster upgrade a FATE cluster with exchange's access info, and will update exchange's route table
 func (s *ParticipantFATEService) UpgradeCluster(req *ParticipantFATEClusterUpgradeRequest) (*entity.ParticipantFATE, *sync.WaitGroup, error) {
 
-	instance, err := s.ParticipantFATERepo.GetExchangeByFederationUUID(req.FederationUUID)
+	participantInstance, err := s.ParticipantFATERepo.GetByUUID(req.ClusterUUID)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "failed to check exchange existence status")
+		return nil, nil, err
 	}
-	exchange := instance.(*entity.ParticipantFATE)
+	cluster := participantInstance.(*entity.ParticipantFATE)
 
-	if exchange.Status != entity.ParticipantFATEStatusActive {
-		return nil, nil, errors.Errorf("exchange %v is not in active status", exchange.UUID)
+	federation, err := s.loadFATEFederation(cluster.FederationUUID)
+	if err != nil {
+		return nil, nil, err
 	}
-	if exchange.IsManaged {
-		if err := s.EndpointService.TestKubeFATE(exchange.EndpointUUID); err != nil {
+	// clusters in a peer-to-peer federation don't use any exchange
+	if federation.Mode != entity.FederationFATEModePeerToPeer {
+		exchange, err := s.getClusterExchange(cluster)
+		if err != nil {
 			return nil, nil, err
 		}
-	}
 
-	participantInstance, err := s.ParticipantFATERepo.GetByUUID(req.ClusterUUID)
-	if err != nil {
-		return nil, nil, err
-	}
-	cluster := participantInstance.(*entity.ParticipantFATE)
+		if exchange.Status != entity.ParticipantFATEStatusActive {
+			return nil, nil, errors.Errorf("exchange %v is not in active status", exchange.UUID)
+		}
+		if exchange.IsManaged {
+			if err := s.EndpointService.TestKubeFATE(exchange.EndpointUUID); err != nil {
+				return nil, nil, err
+			}
+		}
 
-	//Check whether it is a cluster managed by fedlcm, a cluster not managed by fedlcm cannot be upgraded
-	if !exchange.IsManaged {
-		return nil, nil, errors.New("The cluster not managed by FedLCM cannot be upgraded.")
+		//Check whether it is a cluster managed by fedlcm, a cluster not managed by fedlcm cannot be upgraded
+		if !exchange.IsManaged {
+			return nil, nil, errors.New("The cluster not managed by FedLCM cannot be upgraded.")
+		}
 	}
 
 	if err := s.EndpointService.TestKubeFATE(cluster.EndpointUUID); err != nil {
@@ -221,7 +227,7 @@ func (s *ParticipantFATEService) UpgradeCluster(req *ParticipantFATEClusterUpgra
 		return nil, nil, errors.Errorf("the version passed in cannot be upgraded, currentVersion %s, upgradeVersion: %s", ClusterChartVersion, req.UpgradeVersion)
 	}
 
-	instance, err = s.ChartRepo.GetByNameAndVersion(ClusterChartName, req.UpgradeVersion)
+	instance, err := s.ChartRepo.GetByNameAndVersion(ClusterChartName, req.UpgradeVersion)
 	if err != nil {
 		log.Err(err).Strs("chartName and version", []string{ClusterChartName, req.UpgradeVersion}).Msg("GetByNameAndVersion err")
 		return nil, nil, errors.Wrapf(err, "faile to get chart")
@@ -321,11 +327,9 @@ func (s *ParticipantFATEService) UpgradeCluster(req *ParticipantFATEClusterUpgra
 			if err := s.ParticipantFATERepo.UpdateInfoByUUID(cluster); err != nil {
 				return errors.Wrap(err, "failed to save cluster info")
 			}
-			if exchange.IsManaged {
-				operationLog.Info().Msg("rebuilding exchange route table")
-				if err := s.rebuildRouteTable(exchange); err != nil {
-					operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route table while upgrade cluster").Error())
-				}
+			operationLog.Info().Msg("rebuilding exchange route tables")
+			if err := s.rebuildFederationRouteTables(cluster.FederationUUID); err != nil {
+				operationLog.Error().Msg(errors.Wrap(err, "error rebuilding route tables while upgrade cluster").Error())
 			}
 			return nil
 		}(); err != nil {
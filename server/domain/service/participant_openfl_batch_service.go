@@ -0,0 +1,227 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// envoyBatchConcurrency is the max number of envoys being operated at the same time in a batch operation
+const envoyBatchConcurrency = 10
+
+// ParticipantOpenFLEnvoyBatchRequest is the request to perform an operation on all the envoys matching the selector
+type ParticipantOpenFLEnvoyBatchRequest struct {
+	// Selector is a label selector in the Kubernetes syntax, e.g. "region=eu,gpu!=true"
+	Selector string                                     `json:"selector"`
+	Type     entity.ParticipantOpenFLBatchOperationType `json:"type"`
+	// Force is used by the remove operation
+	Force bool `json:"force"`
+	// ChartUUID is the chart to upgrade to, used by the upgrade operation
+	ChartUUID string `json:"chart_uuid"`
+	// ConfigYAML and PythonFiles are used by the reconfigure operation
+	ConfigYAML  string            `json:"config_yaml"`
+	PythonFiles map[string]string `json:"python_files"`
+	// ShardDescriptorVersion is the federation shard descriptor version to deploy, used by the shard descriptor rollout operation
+	ShardDescriptorVersion uint `json:"shard_descriptor_version"`
+}
+
+// FilterEnvoysBySelector returns the envoys whose labels match the selector, an empty selector matches all envoys
+func FilterEnvoysBySelector(envoyList []entity.ParticipantOpenFL, selector string) ([]entity.ParticipantOpenFL, error) {
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid label selector")
+	}
+	var filteredList []entity.ParticipantOpenFL
+	for _, envoy := range envoyList {
+		if envoy.Type != entity.ParticipantOpenFLTypeEnvoy {
+			continue
+		}
+		if labelSelector.Matches(labels.Set(envoy.Labels)) {
+			filteredList = append(filteredList, envoy)
+		}
+	}
+	return filteredList, nil
+}
+
+// UpdateEnvoyLabels replaces the labels of the envoy
+func (s *ParticipantOpenFLService) UpdateEnvoyLabels(uuid string, envoyLabels valueobject.Labels) error {
+	envoy, err := s.loadParticipant(uuid)
+	if err != nil {
+		return err
+	}
+	if envoy.Type != entity.ParticipantOpenFLTypeEnvoy {
+		return errors.Errorf("participant %s is not an OpenFL envoy", envoy.UUID)
+	}
+	for k, v := range envoyLabels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return errors.Errorf("invalid label key %s: %s", k, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return errors.Errorf("invalid label value %s: %s", v, strings.Join(errs, "; "))
+		}
+	}
+	if envoyLabels == nil {
+		envoyLabels = valueobject.Labels{}
+	}
+	envoy.Labels = envoyLabels
+	return s.ParticipantOpenFLRepo.UpdateLabelsByUUID(envoy)
+}
+
+// CreateEnvoyBatchOperation starts the operation on all the envoys in the federation matching the selector, the progress
+// of each envoy is saved in the returned operation, and the returned *sync.WaitGroup can be used to wait for its completion
+func (s *ParticipantOpenFLService) CreateEnvoyBatchOperation(federationUUID string, req *ParticipantOpenFLEnvoyBatchRequest) (*entity.ParticipantOpenFLBatchOperation, *sync.WaitGroup, error) {
+	if strings.TrimSpace(req.Selector) == "" {
+		return nil, nil, errors.New("a label selector is required for batch operations")
+	}
+	var upgradeChart *entity.Chart
+	var shardDescriptor *entity.FederationOpenFLShardDescriptor
+	switch req.Type {
+	case entity.ParticipantOpenFLBatchOperationTypeRemove:
+	case entity.ParticipantOpenFLBatchOperationTypeUpgrade:
+		instance, err := s.ChartRepo.GetByUUID(req.ChartUUID)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to query chart")
+		}
+		upgradeChart = instance.(*entity.Chart)
+		if upgradeChart.Type != entity.ChartTypeOpenFLEnvoy {
+			return nil, nil, errors.Errorf("chart %s is not for OpenFL envoy deployment", upgradeChart.UUID)
+		}
+	case entity.ParticipantOpenFLBatchOperationTypeReconfigure:
+		if req.ConfigYAML == "" && len(req.PythonFiles) == 0 {
+			return nil, nil, errors.New("nothing to reconfigure")
+		}
+	case entity.ParticipantOpenFLBatchOperationTypeShardDescriptorRollout:
+		instance, err := s.ShardDescriptorRepo.GetByFederationUUIDAndVersion(federationUUID, req.ShardDescriptorVersion)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to query shard descriptor version %v", req.ShardDescriptorVersion)
+		}
+		shardDescriptor = instance.(*entity.FederationOpenFLShardDescriptor)
+	default:
+		return nil, nil, errors.Errorf("unknown batch operation type: %v", req.Type)
+	}
+
+	instanceList, err := s.ParticipantOpenFLRepo.ListByFederationUUID(federationUUID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list participant by federation")
+	}
+	envoyList, err := FilterEnvoysBySelector(instanceList.([]entity.ParticipantOpenFL), req.Selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(envoyList) == 0 {
+		return nil, nil, errors.Errorf("no envoy matches the selector: %s", req.Selector)
+	}
+
+	operation := &entity.ParticipantOpenFLBatchOperation{
+		UUID:           uuid.NewV4().String(),
+		FederationUUID: federationUUID,
+		Type:           req.Type,
+		Selector:       req.Selector,
+		Status:         entity.ParticipantOpenFLBatchOperationStatusRunning,
+	}
+	for _, envoy := range envoyList {
+		operation.Items = append(operation.Items, entity.ParticipantOpenFLBatchOperationItem{
+			EnvoyUUID: envoy.UUID,
+			EnvoyName: envoy.Name,
+			Status:    entity.ParticipantOpenFLBatchOperationStatusPending,
+		})
+	}
+	if err := s.BatchOperationRepo.Create(operation); err != nil {
+		return nil, nil, err
+	}
+
+	// the operation is shared by the goroutines of each envoy, so accesses are guarded by the lock
+	lock := &sync.Mutex{}
+	updateItem := func(index int, status entity.ParticipantOpenFLBatchOperationStatus, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		operation.Items[index].Status = status
+		if err != nil {
+			operation.Items[index].Message = err.Error()
+		}
+		if updateErr := s.BatchOperationRepo.UpdateStatusAndItemsByUUID(operation); updateErr != nil {
+			log.Err(updateErr).Msgf("failed to update batch operation %s", operation.UUID)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Info().Msgf("starting %s batch operation %s on %v envoys", operation.Type, operation.UUID, len(envoyList))
+		envoyWg := &sync.WaitGroup{}
+		semaphore := make(chan struct{}, envoyBatchConcurrency)
+		for index := range envoyList {
+			semaphore <- struct{}{}
+			envoyWg.Add(1)
+			updateItem(index, entity.ParticipantOpenFLBatchOperationStatusRunning, nil)
+			done := func(index int) func(error) {
+				return func(err error) {
+					defer func() {
+						<-semaphore
+						envoyWg.Done()
+					}()
+					if err != nil {
+						updateItem(index, entity.ParticipantOpenFLBatchOperationStatusFailed, err)
+					} else {
+						updateItem(index, entity.ParticipantOpenFLBatchOperationStatusSucceeded, nil)
+					}
+				}
+			}(index)
+			envoy := &envoyList[index]
+			var err error
+			switch req.Type {
+			case entity.ParticipantOpenFLBatchOperationTypeRemove:
+				_, err = s.removeEnvoy(envoy, req.Force, done)
+			case entity.ParticipantOpenFLBatchOperationTypeUpgrade:
+				_, err = s.upgradeEnvoy(envoy, upgradeChart, done)
+			case entity.ParticipantOpenFLBatchOperationTypeReconfigure:
+				_, err = s.reconfigureEnvoy(envoy, req.ConfigYAML, req.PythonFiles, 0, done)
+			case entity.ParticipantOpenFLBatchOperationTypeShardDescriptorRollout:
+				configYAML, pythonFiles := shardDescriptorEnvoyFiles(shardDescriptor)
+				_, err = s.reconfigureEnvoy(envoy, configYAML, pythonFiles, shardDescriptor.Version, done)
+			}
+			// the done callback is not called if the operation didn't start
+			if err != nil {
+				done(err)
+			}
+		}
+		envoyWg.Wait()
+
+		lock.Lock()
+		defer lock.Unlock()
+		operation.Status = entity.ParticipantOpenFLBatchOperationStatusSucceeded
+		for _, item := range operation.Items {
+			if item.Status != entity.ParticipantOpenFLBatchOperationStatusSucceeded {
+				operation.Status = entity.ParticipantOpenFLBatchOperationStatusFailed
+				break
+			}
+		}
+		if err := s.BatchOperationRepo.UpdateStatusAndItemsByUUID(operation); err != nil {
+			log.Err(err).Msgf("failed to update batch operation %s", operation.UUID)
+		}
+		log.Info().Msgf("%s batch operation %s finished with status %s", operation.Type, operation.UUID, operation.Status)
+	}()
+	return operation, wg, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFilterEnvoysBySelector(t *testing.T) {
+	envoyList := []entity.ParticipantOpenFL{
+		{Participant: entity.Participant{UUID: "director"}, Type: entity.ParticipantOpenFLTypeDirector},
+		{
+			Participant: entity.Participant{UUID: "eu-gpu", Name: "eu-gpu", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusActive,
+			Labels:      valueobject.Labels{"region": "eu", "gpu": "true"},
+		},
+		{
+			Participant: entity.Participant{UUID: "eu-cpu", Name: "eu-cpu", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusActive,
+			Labels:      valueobject.Labels{"region": "eu"},
+		},
+		{
+			Participant: entity.Participant{UUID: "us-cpu", Name: "us-cpu", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusActive,
+			Labels:      valueobject.Labels{"region": "us", "gpu": "false"},
+		},
+	}
+
+	filtered, err := FilterEnvoysBySelector(envoyList, "region=eu,gpu!=true")
+	assert.NoError(t, err)
+	if assert.Len(t, filtered, 1) {
+		assert.Equal(t, "eu-cpu", filtered[0].UUID)
+	}
+
+	filtered, err = FilterEnvoysBySelector(envoyList, "")
+	assert.NoError(t, err)
+	assert.Len(t, filtered, 3, "empty selector should match all envoys but not the director")
+
+	_, err = FilterEnvoysBySelector(envoyList, "region in eu")
+	assert.Error(t, err)
+}
+
+func TestUpdateEnvoyLabels(t *testing.T) {
+	envoy := entity.ParticipantOpenFL{
+		Participant: entity.Participant{UUID: "envoy-uuid", Name: "envoy-uuid", DeploymentYAML: testEnvoyDeploymentYAML},
+		Type:        entity.ParticipantOpenFLTypeEnvoy,
+		Status:      entity.ParticipantOpenFLStatusActive,
+	}
+	var updated valueobject.Labels
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &envoy, nil
+			},
+			UpdateLabelsByUUIDFn: func(instance interface{}) error {
+				updated = instance.(*entity.ParticipantOpenFL).Labels
+				return nil
+			},
+		},
+	}
+	assert.Error(t, service.UpdateEnvoyLabels("envoy-uuid", valueobject.Labels{"invalid key": "v"}))
+	assert.Nil(t, updated)
+	assert.NoError(t, service.UpdateEnvoyLabels("envoy-uuid", valueobject.Labels{"region": "eu"}))
+	assert.Equal(t, valueobject.Labels{"region": "eu"}, updated)
+}
+
+func TestCreateEnvoyBatchOperation(t *testing.T) {
+	envoyList := []entity.ParticipantOpenFL{
+		{
+			Participant: entity.Participant{UUID: "envoy-1", Name: "envoy-1", Namespace: "ns-1", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusActive,
+			Labels:      valueobject.Labels{"region": "eu"},
+		},
+		{
+			Participant: entity.Participant{UUID: "envoy-2", Name: "envoy-2", Namespace: "ns-2", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusInstallingEnvoy,
+			Labels:      valueobject.Labels{"region": "eu"},
+		},
+		{
+			Participant: entity.Participant{UUID: "envoy-3", Name: "envoy-3", Namespace: "ns-3", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusActive,
+			Labels:      valueobject.Labels{"region": "us"},
+		},
+	}
+	clientSet := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "envoy", Namespace: "ns-1"}},
+		&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "envoy", Namespace: "ns-3"}},
+	)
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByFederationUUIDFn: func(uuid string) (interface{}, error) {
+				return envoyList, nil
+			},
+		},
+		BatchOperationRepo: &mock.ParticipantOpenFLBatchOperationRepoMock{},
+		ParticipantService: ParticipantService{
+			EventService: &mockEventServiceInt{},
+			EndpointService: &mockRotationEndpointServiceInt{
+				client: &mockK8sClient{
+					GetClientSetFn: func() clientgo.Interface {
+						return clientSet
+					},
+				},
+			},
+		},
+	}
+
+	_, _, err := service.CreateEnvoyBatchOperation("federation-uuid", &ParticipantOpenFLEnvoyBatchRequest{
+		Type:        entity.ParticipantOpenFLBatchOperationTypeReconfigure,
+		PythonFiles: map[string]string{"shard_descriptor.py": "# new descriptor"},
+	})
+	assert.Error(t, err, "empty selector should be rejected")
+
+	operation, wg, err := service.CreateEnvoyBatchOperation("federation-uuid", &ParticipantOpenFLEnvoyBatchRequest{
+		Selector:    "region=eu",
+		Type:        entity.ParticipantOpenFLBatchOperationTypeReconfigure,
+		PythonFiles: map[string]string{"shard_descriptor.py": "# new descriptor"},
+	})
+	assert.NoError(t, err)
+	wg.Wait()
+
+	if assert.Len(t, operation.Items, 2) {
+		assert.Equal(t, "envoy-1", operation.Items[0].EnvoyUUID)
+		assert.Equal(t, entity.ParticipantOpenFLBatchOperationStatusSucceeded, operation.Items[0].Status)
+		assert.Equal(t, "envoy-2", operation.Items[1].EnvoyUUID)
+		assert.Equal(t, entity.ParticipantOpenFLBatchOperationStatusFailed, operation.Items[1].Status)
+		assert.NotEmpty(t, operation.Items[1].Message)
+	}
+	assert.Equal(t, entity.ParticipantOpenFLBatchOperationStatusFailed, operation.Status)
+}
+
+func TestCreateEnvoyBatchOperationShardDescriptorRollout(t *testing.T) {
+	envoyList := []entity.ParticipantOpenFL{
+		{
+			Participant: entity.Participant{UUID: "envoy-1", Name: "envoy-1", Namespace: "ns-1", DeploymentYAML: testEnvoyDeploymentYAML},
+			Type:        entity.ParticipantOpenFLTypeEnvoy,
+			Status:      entity.ParticipantOpenFLStatusActive,
+			Labels:      valueobject.Labels{"region": "eu"},
+		},
+	}
+	clientSet := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "envoy", Namespace: "ns-1"}})
+	var updated entity.ParticipantOpenFL
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			ListByFederationUUIDFn: func(uuid string) (interface{}, error) {
+				return envoyList, nil
+			},
+			UpdateInfoByUUIDFn: func(instance interface{}) error {
+				updated = *instance.(*entity.ParticipantOpenFL)
+				return nil
+			},
+		},
+		BatchOperationRepo: &mock.ParticipantOpenFLBatchOperationRepoMock{},
+		ShardDescriptorRepo: &mock.FederationOpenFLShardDescriptorRepoMock{
+			GetByFederationUUIDAndVersionFn: func(federationUUID string, version uint) (interface{}, error) {
+				return &entity.FederationOpenFLShardDescriptor{
+					Version: version,
+					Config: valueobject.ShardDescriptorConfig{
+						EnvoyConfigYaml: "params:\n  cuda_devices: [0]",
+						PythonFiles:     map[string]string{"shard_descriptor.py": "# v3"},
+					},
+				}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			EventService: &mockEventServiceInt{},
+			EndpointService: &mockRotationEndpointServiceInt{
+				client: &mockK8sClient{
+					GetClientSetFn: func() clientgo.Interface {
+						return clientSet
+					},
+				},
+			},
+		},
+	}
+
+	operation, wg, err := service.CreateEnvoyBatchOperation("federation-uuid", &ParticipantOpenFLEnvoyBatchRequest{
+		Selector:               "region=eu",
+		Type:                   entity.ParticipantOpenFLBatchOperationTypeShardDescriptorRollout,
+		ShardDescriptorVersion: 3,
+	})
+	assert.NoError(t, err)
+	wg.Wait()
+
+	assert.Equal(t, entity.ParticipantOpenFLBatchOperationStatusSucceeded, operation.Status)
+	assert.Equal(t, uint(3), updated.ShardDescriptorVersion)
+	assert.Contains(t, updated.DeploymentYAML, "- 0")
+	configMap, err := clientSet.CoreV1().ConfigMaps("ns-1").Get(context.TODO(), "envoy-python-configs", v1.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "# v3", configMap.Data["shard_descriptor.py"])
+	}
+}
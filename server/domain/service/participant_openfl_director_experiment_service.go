@@ -0,0 +1,177 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	directorclient "github.com/FederatedAI/FedLCM/pkg/openfl-director-client"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	directorAPITimeout           = 10 * time.Second
+	directorMetricsCollectPeriod = 3 * time.Second
+	directorDefaultLogTailLines  = 200
+)
+
+// OpenFLDirectorExperiments contains the envoys and experiments reported by a director
+type OpenFLDirectorExperiments struct {
+	Envoys      []directorclient.EnvoyInfo          `json:"envoys"`
+	Experiments []directorclient.ExperimentListItem `json:"experiments"`
+}
+
+// OpenFLDirectorExperimentDetail contains the detailed status, metrics and director logs of an experiment
+type OpenFLDirectorExperimentDetail struct {
+	Description *directorclient.ExperimentDescription `json:"description"`
+	Metrics     []directorclient.Metric               `json:"metrics"`
+	Logs        string                                `json:"logs"`
+}
+
+// GetDirectorExperiments returns the connected envoys and the experiments of a director, by calling the director API
+func (s *ParticipantOpenFLService) GetDirectorExperiments(directorUUID string) (*OpenFLDirectorExperiments, error) {
+	_, client, err := s.buildDirectorClient(directorUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), directorAPITimeout)
+	defer cancel()
+	envoys, err := client.GetEnvoys(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get envoys from director")
+	}
+	experiments, err := client.GetExperimentsList(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get experiments from director")
+	}
+	return &OpenFLDirectorExperiments{
+		Envoys:      envoys,
+		Experiments: experiments,
+	}, nil
+}
+
+// GetDirectorExperimentDetail returns the status, the metrics collected so far and the recent director logs of an experiment
+func (s *ParticipantOpenFLService) GetDirectorExperimentDetail(directorUUID, experimentName string, logTailLines int64) (*OpenFLDirectorExperimentDetail, error) {
+	director, client, err := s.buildDirectorClient(directorUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), directorAPITimeout)
+	defer cancel()
+	description, err := client.GetExperimentDescription(ctx, experimentName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get description of experiment %s", experimentName)
+	}
+
+	// the metric stream stays open as long as the experiment is running, so we only collect for a short period
+	metricsCtx, metricsCancel := context.WithTimeout(context.Background(), directorMetricsCollectPeriod)
+	defer metricsCancel()
+	metrics, err := client.GetMetricStream(metricsCtx, experimentName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get metrics of experiment %s", experimentName)
+	}
+
+	if logTailLines <= 0 {
+		logTailLines = directorDefaultLogTailLines
+	}
+	installer, err := s.buildOpenFLInstaller(director)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the installer")
+	}
+	defer installer.Close()
+	logs, err := getDirectorLogs(installer.K8sClient(), director.Namespace, logTailLines)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get director logs")
+	}
+	return &OpenFLDirectorExperimentDetail{
+		Description: description,
+		Metrics:     metrics,
+		Logs:        logs,
+	}, nil
+}
+
+// buildDirectorClient creates a director API client using the Jupyter client certificate issued to the director
+func (s *ParticipantOpenFLService) buildDirectorClient(directorUUID string) (*entity.ParticipantOpenFL, directorclient.Client, error) {
+	director, err := s.loadParticipant(directorUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if director.Type != entity.ParticipantOpenFLTypeDirector {
+		return nil, nil, errors.Errorf("participant %s is not a director", director.UUID)
+	}
+	if director.Status != entity.ParticipantOpenFLStatusActive {
+		return nil, nil, errors.Errorf("director is not in active status")
+	}
+	access, ok := director.AccessInfo[entity.ParticipantOpenFLServiceNameDirector]
+	if !ok {
+		return nil, nil, errors.New("director API access info not found")
+	}
+	installer, err := s.buildOpenFLInstaller(director)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get the installer")
+	}
+	defer installer.Close()
+	secret, err := installer.K8sClient().GetClientSet().CoreV1().Secrets(director.Namespace).
+		Get(context.TODO(), entity.ParticipantOpenFLSecretNameJupyter, v1.GetOptions{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get jupyter client certificate")
+	}
+	client, err := newOpenFLDirectorClient(directorclient.Config{
+		Address:    fmt.Sprintf("%s:%d", access.Host, access.Port),
+		ServerName: access.FQDN,
+		CACert:     secret.Data["root_ca.crt"],
+		ClientCert: secret.Data["notebook.crt"],
+		ClientKey:  secret.Data["priv.key"],
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create director client")
+	}
+	return director, client, nil
+}
+
+// For mocking purpose
+var (
+	newOpenFLDirectorClient = directorclient.NewClient
+
+	getDirectorLogs = func(client kubernetes.Client, namespace string, tailLines int64) (string, error) {
+		podList, err := client.GetClientSet().CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			return "", err
+		}
+		for _, pod := range podList.Items {
+			if !strings.HasPrefix(pod.Name, "director") {
+				continue
+			}
+			logs, err := client.GetClientSet().CoreV1().Pods(namespace).
+				GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &tailLines}).Do(context.TODO()).Raw()
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to get logs of pod %s", pod.Name)
+			}
+			return string(logs), nil
+		}
+		return "", errors.Errorf("no director pod found in namespace %s", namespace)
+	}
+)
@@ -0,0 +1,119 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	directorclient "github.com/FederatedAI/FedLCM/pkg/openfl-director-client"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type mockDirectorClient struct {
+	experimentName string
+	closed         bool
+}
+
+func (m *mockDirectorClient) GetEnvoys(context.Context) ([]directorclient.EnvoyInfo, error) {
+	return []directorclient.EnvoyInfo{{Name: "envoy-1", NSamples: 100, IsOnline: true}}, nil
+}
+
+func (m *mockDirectorClient) GetExperimentsList(context.Context) ([]directorclient.ExperimentListItem, error) {
+	return []directorclient.ExperimentListItem{{Name: "mnist", Status: "In progress"}}, nil
+}
+
+func (m *mockDirectorClient) GetExperimentDescription(_ context.Context, name string) (*directorclient.ExperimentDescription, error) {
+	m.experimentName = name
+	return &directorclient.ExperimentDescription{Name: name, TotalRounds: 5, CurrentRound: 2}, nil
+}
+
+func (m *mockDirectorClient) GetMetricStream(context.Context, string) ([]directorclient.Metric, error) {
+	return []directorclient.Metric{{Origin: "aggregator", Name: "loss", Value: 0.5, Round: 1}}, nil
+}
+
+func (m *mockDirectorClient) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestGetDirectorExperiments(t *testing.T) {
+	director := &entity.ParticipantOpenFL{
+		Participant: entity.Participant{UUID: "director-uuid", Namespace: "director-ns"},
+		Type:        entity.ParticipantOpenFLTypeDirector,
+		Status:      entity.ParticipantOpenFLStatusActive,
+		AccessInfo: entity.ParticipantOpenFLModulesAccessMap{
+			entity.ParticipantOpenFLServiceNameDirector: {Host: "10.0.0.1", Port: 50051, FQDN: "director.example.com"},
+		},
+	}
+	clientSet := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: entity.ParticipantOpenFLSecretNameJupyter, Namespace: "director-ns"},
+			Data:       map[string][]byte{"notebook.crt": []byte("cert"), "priv.key": []byte("key"), "root_ca.crt": []byte("ca")},
+		},
+		&corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "director-5d8f7c", Namespace: "director-ns"}},
+	)
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return director, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			EventService: &mockEventServiceInt{},
+			EndpointService: &mockRotationEndpointServiceInt{
+				client: &mockK8sClient{
+					GetClientSetFn: func() clientgo.Interface {
+						return clientSet
+					},
+				},
+			},
+		},
+	}
+
+	mockClient := &mockDirectorClient{}
+	var config directorclient.Config
+	newOpenFLDirectorClient = func(c directorclient.Config) (directorclient.Client, error) {
+		config = c
+		return mockClient, nil
+	}
+	defer func() { newOpenFLDirectorClient = directorclient.NewClient }()
+
+	experiments, err := service.GetDirectorExperiments("director-uuid")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:50051", config.Address)
+	assert.Equal(t, "director.example.com", config.ServerName)
+	assert.Equal(t, []byte("cert"), config.ClientCert)
+	assert.Len(t, experiments.Envoys, 1)
+	assert.Len(t, experiments.Experiments, 1)
+	assert.True(t, mockClient.closed)
+
+	detail, err := service.GetDirectorExperimentDetail("director-uuid", "mnist", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "mnist", mockClient.experimentName)
+	assert.Equal(t, uint64(2), detail.Description.CurrentRound)
+	assert.Len(t, detail.Metrics, 1)
+	assert.NotEmpty(t, detail.Logs)
+
+	director.Status = entity.ParticipantOpenFLStatusFailed
+	_, err = service.GetDirectorExperiments("director-uuid")
+	assert.Error(t, err)
+}
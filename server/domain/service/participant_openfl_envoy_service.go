@@ -0,0 +1,313 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/utils"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+// ParticipantOpenFLEnvoyUnregistrationRequest is the request from the device agent to remove its envoy. The TokenStr
+// field in the requests from the device agent is the credential returned by the envoy registration.
+type ParticipantOpenFLEnvoyUnregistrationRequest struct {
+	TokenStr string `json:"token"`
+	Force    bool   `json:"force"`
+}
+
+// ParticipantOpenFLEnvoyHeartbeatRequest is the periodical heartbeat request from the device agent
+type ParticipantOpenFLEnvoyHeartbeatRequest struct {
+	TokenStr     string                               `json:"token"`
+	DeviceHealth entity.ParticipantOpenFLDeviceHealth `json:"device_health"`
+}
+
+// ParticipantOpenFLEnvoyReconfigureRequest is the request to update the envoy config and the shard descriptor python files.
+// Empty fields are left unchanged.
+type ParticipantOpenFLEnvoyReconfigureRequest struct {
+	TokenStr    string            `json:"token"`
+	ConfigYAML  string            `json:"config_yaml"`
+	PythonFiles map[string]string `json:"python_files"`
+}
+
+// ParticipantOpenFLEnvoyUpgradeRequest is the request to upgrade the envoy to a newer chart version
+type ParticipantOpenFLEnvoyUpgradeRequest struct {
+	TokenStr       string `json:"token"`
+	UpgradeVersion string `json:"upgrade_version"`
+}
+
+// UnregisterEnvoy removes the envoy on behalf of its device agent
+func (s *ParticipantOpenFLService) UnregisterEnvoy(uuid string, req *ParticipantOpenFLEnvoyUnregistrationRequest) error {
+	if _, err := s.AuthenticateEnvoy(uuid, req.TokenStr); err != nil {
+		return err
+	}
+	_, err := s.RemoveEnvoy(uuid, req.Force)
+	return err
+}
+
+// HandleEnvoyHeartbeat records the last-seen time and the device health reported by the device agent
+func (s *ParticipantOpenFLService) HandleEnvoyHeartbeat(uuid string, req *ParticipantOpenFLEnvoyHeartbeatRequest) error {
+	envoy, err := s.AuthenticateEnvoy(uuid, req.TokenStr)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	envoy.LastSeen = &now
+	envoy.DeviceHealth = req.DeviceHealth
+	return s.ParticipantOpenFLRepo.UpdateHeartbeatByUUID(envoy)
+}
+
+// ReconfigureEnvoy updates the envoy config and the shard descriptor python files in place and restarts the envoy,
+// the returned *sync.WaitGroup can be used to wait for the completion of the async goroutine
+func (s *ParticipantOpenFLService) ReconfigureEnvoy(uuid string, req *ParticipantOpenFLEnvoyReconfigureRequest) (*sync.WaitGroup, error) {
+	envoy, err := s.AuthenticateEnvoy(uuid, req.TokenStr)
+	if err != nil {
+		return nil, err
+	}
+	return s.reconfigureEnvoy(envoy, req.ConfigYAML, req.PythonFiles, 0, nil)
+}
+
+// reconfigureEnvoy is the actual reconfiguring logic, shardDescriptorVersion is the federation shard descriptor version the
+// config comes from, or 0 if it is not from the federation. done, if not nil, is called with the result when the async
+// goroutine finishes
+func (s *ParticipantOpenFLService) reconfigureEnvoy(envoy *entity.ParticipantOpenFL, configYAML string, pythonFiles map[string]string,
+	shardDescriptorVersion uint, done func(error)) (*sync.WaitGroup, error) {
+	if envoy.Status != entity.ParticipantOpenFLStatusActive {
+		return nil, errors.Errorf("envoy cannot be reconfigured when in status: %v", envoy.Status)
+	}
+	if configYAML == "" && len(pythonFiles) == 0 {
+		return nil, errors.New("nothing to reconfigure")
+	}
+
+	previousDeploymentYAML := envoy.DeploymentYAML
+	if configYAML != "" {
+		var envoyConfig map[string]interface{}
+		if err := yaml.Unmarshal([]byte(configYAML), &envoyConfig); err != nil {
+			return nil, errors.Wrapf(err, "invalid envoy config")
+		}
+		var m map[string]interface{}
+		if err := yaml.Unmarshal([]byte(envoy.DeploymentYAML), &m); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal deployment yaml")
+		}
+		envoyValues, ok := m["envoy"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("missing envoy section in the deployment yaml")
+		}
+		envoyValues["envoyConfigs"] = envoyConfig
+		finalYAMLBytes, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get final yaml content")
+		}
+		envoy.DeploymentYAML = string(finalYAMLBytes)
+	}
+
+	envoy.Status = entity.ParticipantOpenFLStatusReconfiguring
+	if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
+		return nil, err
+	}
+	if err := s.ParticipantOpenFLRepo.UpdateDeploymentYAMLAndChartByUUID(envoy); err != nil {
+		return nil, err
+	}
+
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeOpenFLEnvoy, envoy.UUID, "start reconfiguring envoy", entity.EventLogLevelInfo)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := s.envoyOperationLogger("reconfiguring openfl envoy", envoy.UUID)
+		operationLog.Info().Msgf("reconfiguring OpenFL envoy %s with UUID %s", envoy.Name, envoy.UUID)
+		err := func() error {
+			installer, err := s.buildOpenFLInstaller(envoy)
+			if err != nil {
+				return err
+			}
+			defer installer.Close()
+			if len(pythonFiles) > 0 {
+				operationLog.Info().Msgf("updating shard descriptor python files")
+				if err := createEnvoyShardDescriptor(installer.K8sClient(), envoy.Namespace, pythonFiles); err != nil {
+					return err
+				}
+			}
+			if envoy.DeploymentYAML != previousDeploymentYAML {
+				if err := installer.Update(envoy, nil, &operationLog); err != nil {
+					return err
+				}
+			}
+			// the python files and the envoy config are only loaded when the envoy starts
+			operationLog.Info().Msgf("restarting the envoy")
+			return restartEnvoyDeployment(installer.K8sClient(), envoy.Namespace)
+		}()
+		if done != nil {
+			defer done(err)
+		}
+		if err != nil {
+			operationLog.Error().Msgf(errors.Wrapf(err, "failed to reconfigure OpenFL envoy").Error())
+			envoy.DeploymentYAML = previousDeploymentYAML
+		} else {
+			envoy.ShardDescriptorVersion = shardDescriptorVersion
+			operationLog.Info().Msgf("OpenFL envoy %s(%s) reconfigured", envoy.Name, envoy.UUID)
+		}
+		// we still mark the envoy to be active as the failed update is rolled back by kubefate or helm
+		envoy.Status = entity.ParticipantOpenFLStatusActive
+		if updateErr := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); updateErr != nil {
+			operationLog.Error().Msgf(errors.Wrapf(updateErr, "failed to update OpenFL envoy info").Error())
+		}
+		if updateErr := s.ParticipantOpenFLRepo.UpdateDeploymentYAMLAndChartByUUID(envoy); updateErr != nil {
+			operationLog.Error().Msgf(errors.Wrapf(updateErr, "failed to update OpenFL envoy deployment yaml").Error())
+		}
+	}()
+	return wg, nil
+}
+
+// UpgradeEnvoy upgrades the envoy to a newer version of its chart,
+// the returned *sync.WaitGroup can be used to wait for the completion of the async goroutine
+func (s *ParticipantOpenFLService) UpgradeEnvoy(uuid string, req *ParticipantOpenFLEnvoyUpgradeRequest) (*sync.WaitGroup, error) {
+	envoy, err := s.AuthenticateEnvoy(uuid, req.TokenStr)
+	if err != nil {
+		return nil, err
+	}
+	envoyChartName := utils.GetChartNameFromDeploymentYAML(envoy.DeploymentYAML)
+	instance, err := s.ChartRepo.GetByNameAndVersion(envoyChartName, req.UpgradeVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get chart")
+	}
+	return s.upgradeEnvoy(envoy, instance.(*entity.Chart), nil)
+}
+
+// upgradeEnvoy upgrades the envoy to the specified chart, done, if not nil, is called with the result when the async goroutine finishes
+func (s *ParticipantOpenFLService) upgradeEnvoy(envoy *entity.ParticipantOpenFL, upgradeChart *entity.Chart, done func(error)) (*sync.WaitGroup, error) {
+	if envoy.Status != entity.ParticipantOpenFLStatusActive {
+		return nil, errors.Errorf("envoy cannot be upgraded when in status: %v", envoy.Status)
+	}
+	if upgradeChart.Type != entity.ChartTypeOpenFLEnvoy {
+		return nil, errors.Errorf("chart %s is not for OpenFL envoy deployment", upgradeChart.UUID)
+	}
+	envoyChartVersion := utils.GetChartVersionFromDeploymentYAML(envoy.DeploymentYAML)
+	envoyChartName := utils.GetChartNameFromDeploymentYAML(envoy.DeploymentYAML)
+	if envoyChartName != upgradeChart.ChartName {
+		return nil, errors.Errorf("chart %s is not an upgrade of the current chart %s", upgradeChart.ChartName, envoyChartName)
+	}
+	if utils.CompareVersion(envoyChartVersion, upgradeChart.Version) >= 0 {
+		return nil, errors.Errorf("the version passed in cannot be upgraded, currentVersion %s, upgradeVersion: %s", envoyChartVersion, upgradeChart.Version)
+	}
+
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(envoy.DeploymentYAML), &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	m["chartVersion"] = upgradeChart.Version
+	finalYAMLBytes, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get final yaml content")
+	}
+	previousDeploymentYAML := envoy.DeploymentYAML
+	envoy.DeploymentYAML = string(finalYAMLBytes)
+	envoy.Status = entity.ParticipantOpenFLStatusUpgrading
+	if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
+		return nil, err
+	}
+	if err := s.ParticipantOpenFLRepo.UpdateDeploymentYAMLAndChartByUUID(envoy); err != nil {
+		return nil, err
+	}
+
+	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeOpenFLEnvoy, envoy.UUID, "start upgrading envoy", entity.EventLogLevelInfo)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		operationLog := s.envoyOperationLogger("upgrading openfl envoy", envoy.UUID)
+		operationLog.Info().Msgf("upgrading OpenFL envoy %s with UUID %s to version %s", envoy.Name, envoy.UUID, upgradeChart.Version)
+		err := func() error {
+			installer, err := s.buildOpenFLInstaller(envoy)
+			if err != nil {
+				return err
+			}
+			defer installer.Close()
+			operationLog.Info().Msgf("preparing the chart, name: %s, version: %s", upgradeChart.ChartName, upgradeChart.Version)
+			if err := installer.PrepareChart(upgradeChart); err != nil {
+				return err
+			}
+			if err := installer.Update(envoy, upgradeChart, &operationLog); err != nil {
+				return err
+			}
+			envoy.ChartUUID = upgradeChart.UUID
+			return nil
+		}()
+		if done != nil {
+			defer done(err)
+		}
+		if err != nil {
+			operationLog.Error().Msgf(errors.Wrapf(err, "failed to upgrade OpenFL envoy").Error())
+			envoy.DeploymentYAML = previousDeploymentYAML
+		} else {
+			operationLog.Info().Msgf("OpenFL envoy %s(%s) upgraded", envoy.Name, envoy.UUID)
+		}
+		// we still mark the envoy to be active as the failed upgrade is rolled back by kubefate or helm
+		envoy.Status = entity.ParticipantOpenFLStatusActive
+		if updateErr := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); updateErr != nil {
+			operationLog.Error().Msgf(errors.Wrapf(updateErr, "failed to update OpenFL envoy info").Error())
+		}
+		if updateErr := s.ParticipantOpenFLRepo.UpdateDeploymentYAMLAndChartByUUID(envoy); updateErr != nil {
+			operationLog.Error().Msgf(errors.Wrapf(updateErr, "failed to update OpenFL envoy deployment yaml").Error())
+		}
+	}()
+	return wg, nil
+}
+
+// AuthenticateEnvoy loads the envoy and checks the credential is the one returned by its registration
+func (s *ParticipantOpenFLService) AuthenticateEnvoy(uuid, credential string) (*entity.ParticipantOpenFL, error) {
+	envoy, err := s.loadParticipant(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if envoy.Type != entity.ParticipantOpenFLTypeEnvoy {
+		return nil, errors.Errorf("participant %s is not an OpenFL envoy", envoy.UUID)
+	}
+	instance, err := s.TokenRepo.GetByUUID(envoy.TokenUUID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query token")
+	}
+	token := instance.(*entity.RegistrationTokenOpenFL)
+	if err := s.verifyParticipantCredential(&token.RegistrationToken, entity.FederationTypeOpenFL, envoy.UUID, envoy.Name,
+		envoy.Type.String(), credential); err != nil {
+		return nil, err
+	}
+	return envoy, nil
+}
+
+func (s *ParticipantOpenFLService) envoyOperationLogger(action, envoyUUID string) zerolog.Logger {
+	return log.Logger.With().Timestamp().Str("action", action).Str("uuid", envoyUUID).Logger().
+		Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
+			eventLvl := entity.EventLogLevelInfo
+			if level == zerolog.ErrorLevel {
+				eventLvl = entity.EventLogLevelError
+			}
+			_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeOpenFLEnvoy, envoyUUID, message, eventLvl)
+		}))
+}
+
+var (
+	restartEnvoyDeployment = func(client kubernetes.Client, namespace string) error {
+		return restartCertificateWorkload(client, namespace, certificateWorkload{certificateWorkloadKindDeployment, "envoy"})
+	}
+)
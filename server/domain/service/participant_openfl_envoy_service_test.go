@@ -0,0 +1,164 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const testEnvoyDeploymentYAML = `chartName: openfl-envoy
+chartVersion: v0.3.0
+envoy:
+  envoyConfigs:
+    params:
+      cuda_devices: []
+  image: fedlcm-openfl
+modules:
+- envoy
+name: envoy-test
+namespace: test-ns`
+
+const testEnvoyCredential = "test-credential"
+
+func TestHandleEnvoyHeartbeat(t *testing.T) {
+	envoy := &entity.ParticipantOpenFL{
+		Participant: entity.Participant{UUID: "envoy-uuid"},
+		Type:        entity.ParticipantOpenFLTypeEnvoy,
+		TokenUUID:   "token-uuid",
+	}
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return envoy, nil
+			},
+		},
+		TokenRepo: &mock.RegistrationTokenOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.RegistrationTokenOpenFL{
+					RegistrationToken: entity.RegistrationToken{
+						UUID:      uuid,
+						TokenType: entity.RegistrationTokenTypeRand16,
+						TokenStr:  "test-token",
+					},
+				}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			EventService: &mockEventServiceInt{},
+			RegistrationRecordRepo: &mock.RegistrationRecordRepoMock{
+				GetByParticipantUUIDFn: func(participantUUID string) (interface{}, error) {
+					return &entity.RegistrationRecord{
+						ParticipantUUID: participantUUID,
+						CredentialHash:  entity.HashRegistrationCredential(testEnvoyCredential),
+					}, nil
+				},
+			},
+		},
+	}
+
+	err := service.HandleEnvoyHeartbeat("envoy-uuid", &ParticipantOpenFLEnvoyHeartbeatRequest{TokenStr: "rand16:test-token"})
+	assert.Error(t, err)
+	assert.Nil(t, envoy.LastSeen)
+
+	err = service.HandleEnvoyHeartbeat("envoy-uuid", &ParticipantOpenFLEnvoyHeartbeatRequest{
+		TokenStr: testEnvoyCredential,
+		DeviceHealth: entity.ParticipantOpenFLDeviceHealth{
+			EnvoyPods: []entity.ParticipantOpenFLDevicePodInfo{{Name: "envoy-0", Phase: "Running", Ready: true}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, envoy.LastSeen)
+	assert.Equal(t, "envoy-0", envoy.DeviceHealth.EnvoyPods[0].Name)
+}
+
+func TestReconfigureEnvoy(t *testing.T) {
+	envoy := &entity.ParticipantOpenFL{
+		Participant: entity.Participant{UUID: "envoy-uuid", Namespace: "test-ns", DeploymentYAML: testEnvoyDeploymentYAML},
+		Type:        entity.ParticipantOpenFLTypeEnvoy,
+		Status:      entity.ParticipantOpenFLStatusActive,
+		TokenUUID:   "token-uuid",
+	}
+	clientSet := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "envoy", Namespace: "test-ns"}})
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return envoy, nil
+			},
+		},
+		TokenRepo: &mock.RegistrationTokenOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.RegistrationTokenOpenFL{
+					RegistrationToken: entity.RegistrationToken{
+						UUID:      uuid,
+						TokenType: entity.RegistrationTokenTypeRand16,
+						TokenStr:  "test-token",
+					},
+				}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			EventService: &mockEventServiceInt{},
+			RegistrationRecordRepo: &mock.RegistrationRecordRepoMock{
+				GetByParticipantUUIDFn: func(participantUUID string) (interface{}, error) {
+					return &entity.RegistrationRecord{
+						ParticipantUUID: participantUUID,
+						CredentialHash:  entity.HashRegistrationCredential(testEnvoyCredential),
+					}, nil
+				},
+			},
+			EndpointService: &mockRotationEndpointServiceInt{
+				client: &mockK8sClient{
+					GetClientSetFn: func() clientgo.Interface {
+						return clientSet
+					},
+				},
+			},
+		},
+	}
+
+	_, err := service.ReconfigureEnvoy("envoy-uuid", &ParticipantOpenFLEnvoyReconfigureRequest{TokenStr: testEnvoyCredential})
+	assert.Error(t, err, "empty request should be rejected")
+
+	wg, err := service.ReconfigureEnvoy("envoy-uuid", &ParticipantOpenFLEnvoyReconfigureRequest{
+		TokenStr:    testEnvoyCredential,
+		ConfigYAML:  "params:\n  cuda_devices: [0]\n",
+		PythonFiles: map[string]string{"shard_descriptor.py": "# new descriptor"},
+	})
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, entity.ParticipantOpenFLStatusActive, envoy.Status)
+
+	var m map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(envoy.DeploymentYAML), &m))
+	envoyConfigs := m["envoy"].(map[string]interface{})["envoyConfigs"].(map[string]interface{})
+	assert.Equal(t, []interface{}{float64(0)}, envoyConfigs["params"].(map[string]interface{})["cuda_devices"])
+
+	cm, err := clientSet.CoreV1().ConfigMaps("test-ns").Get(context.TODO(), "envoy-python-configs", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "# new descriptor", cm.Data["shard_descriptor.py"])
+	deployment, err := clientSet.AppsV1().Deployments("test-ns").Get(context.TODO(), "envoy", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, deployment.Spec.Template.Annotations[certificateRotationRestartAnnotation])
+}
@@ -0,0 +1,336 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"github.com/FederatedAI/FedLCM/pkg/helm"
+	"github.com/FederatedAI/FedLCM/pkg/kubefate"
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sigs.k8s.io/yaml"
+)
+
+// ParticipantOpenFLDeploymentStatus is the status of the chart release of a director or envoy
+type ParticipantOpenFLDeploymentStatus struct {
+	DeploymentMethod string `json:"deployment_method"`
+	ReleaseName      string `json:"release_name"`
+	Namespace        string `json:"namespace"`
+	Revision         int    `json:"revision"`
+	Status           string `json:"status"`
+	ChartName        string `json:"chart_name"`
+	ChartVersion     string `json:"chart_version"`
+}
+
+// openFLInstaller installs and manages the chart release of a director or envoy, using the deployment method of the federation
+type openFLInstaller interface {
+	// K8sClient returns the client of the cluster the participant is deployed in
+	K8sClient() kubernetes.Client
+	// PrepareChart makes sure the chart can be used to install or upgrade the participant
+	PrepareChart(chart *entity.Chart) error
+	// Install installs the participant with its deployment yaml and waits for the installation to finish
+	Install(participant *entity.ParticipantOpenFL, chart *entity.Chart, operationLog *zerolog.Logger) error
+	// Update applies the current deployment yaml of the participant and waits for the update to finish,
+	// a nil chart means the current chart of the participant
+	Update(participant *entity.ParticipantOpenFL, chart *entity.Chart, operationLog *zerolog.Logger) error
+	// Uninstall removes the chart release of the participant
+	Uninstall(participant *entity.ParticipantOpenFL, operationLog *zerolog.Logger) error
+	// Status returns the status of the chart release of the participant
+	Status(participant *entity.ParticipantOpenFL) (*ParticipantOpenFLDeploymentStatus, error)
+	// Close releases the resources used by the installer
+	Close()
+}
+
+// For mocking purpose
+var (
+	newHelmClientFn = helm.NewClient
+)
+
+// buildOpenFLInstaller returns the installer for the participant according to its deployment method
+func (s *ParticipantOpenFLService) buildOpenFLInstaller(participant *entity.ParticipantOpenFL) (openFLInstaller, error) {
+	switch participant.DeploymentMethod {
+	case entity.FederationOpenFLDeploymentMethodKubeFATE:
+		endpointMgr, kfClient, closer, err := s.buildKubeFATEMgrAndClient(participant.EndpointUUID)
+		if err != nil {
+			if closer != nil {
+				closer()
+			}
+			return nil, err
+		}
+		return &kubeFATEOpenFLInstaller{
+			endpointMgr: endpointMgr,
+			kfClient:    kfClient,
+			closer:      closer,
+			repo:        s.ParticipantOpenFLRepo,
+		}, nil
+	case entity.FederationOpenFLDeploymentMethodHelm:
+		if participant.InfraUUID == "" {
+			return nil, errors.New("missing infra provider uuid")
+		}
+		instance, err := s.InfraRepo.GetByUUID(participant.InfraUUID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to query infra provider")
+		}
+		infraProvider := instance.(*entity.InfraProviderKubernetes)
+		k8sClient, err := newK8sClientFn("", infraProvider.Config.KubeConfigContent, infraProvider.Config.IsInCluster)
+		if err != nil {
+			return nil, err
+		}
+		helmClient, err := newHelmClientFn(k8sClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get helm client")
+		}
+		return &helmOpenFLInstaller{
+			k8sClient:  k8sClient,
+			helmClient: helmClient,
+			chartRepo:  s.ChartRepo,
+		}, nil
+	}
+	return nil, errors.Errorf("unknown deployment method: %v", participant.DeploymentMethod)
+}
+
+// GetDeploymentStatus returns the status of the chart release of the director or envoy
+func (s *ParticipantOpenFLService) GetDeploymentStatus(uuid string) (*ParticipantOpenFLDeploymentStatus, error) {
+	participant, err := s.loadParticipant(uuid)
+	if err != nil {
+		return nil, err
+	}
+	installer, err := s.buildOpenFLInstaller(participant)
+	if err != nil {
+		return nil, err
+	}
+	defer installer.Close()
+	return installer.Status(participant)
+}
+
+// kubeFATEOpenFLInstaller deploys the participant as a KubeFATE cluster
+type kubeFATEOpenFLInstaller struct {
+	endpointMgr kubefate.ClientManager
+	kfClient    kubefate.Client
+	closer      func()
+	repo        repo.ParticipantOpenFLRepository
+}
+
+func (i *kubeFATEOpenFLInstaller) K8sClient() kubernetes.Client {
+	return i.endpointMgr.K8sClient()
+}
+
+func (i *kubeFATEOpenFLInstaller) PrepareChart(chart *entity.Chart) error {
+	if chart.Private {
+		if err := i.kfClient.EnsureChartExist(chart.ChartName, chart.Version, chart.ArchiveContent); err != nil {
+			return errors.Wrapf(err, "error uploading FedLCM private chart")
+		}
+	}
+	return nil
+}
+
+func (i *kubeFATEOpenFLInstaller) Install(participant *entity.ParticipantOpenFL, _ *entity.Chart, operationLog *zerolog.Logger) error {
+	jobUUID, err := i.kfClient.SubmitClusterInstallationJob(participant.DeploymentYAML)
+	if err != nil {
+		return errors.Wrapf(err, "fail to submit %s creation request", participant.Type)
+	}
+	participant.JobUUID = jobUUID
+	if err := i.repo.UpdateInfoByUUID(participant); err != nil {
+		return errors.Wrapf(err, "failed to update %s's job uuid", participant.Type)
+	}
+	clusterUUID, err := i.kfClient.WaitClusterUUID(jobUUID)
+	if err != nil {
+		return errors.Wrapf(err, "fail to get cluster uuid")
+	}
+	participant.ClusterUUID = clusterUUID
+	if err := i.repo.UpdateInfoByUUID(participant); err != nil {
+		return errors.Wrapf(err, "failed to update %s cluster uuid", participant.Type)
+	}
+	operationLog.Info().Msgf("job submitted and running: jobUUID: %s, clusterUUID: %s", jobUUID, clusterUUID)
+	return i.waitJob(jobUUID)
+}
+
+func (i *kubeFATEOpenFLInstaller) Update(participant *entity.ParticipantOpenFL, _ *entity.Chart, operationLog *zerolog.Logger) error {
+	jobUUID, err := i.kfClient.SubmitClusterUpdateJob(participant.DeploymentYAML)
+	if err != nil {
+		return errors.Wrapf(err, "fail to submit %s update request", participant.Type)
+	}
+	participant.JobUUID = jobUUID
+	if err := i.repo.UpdateInfoByUUID(participant); err != nil {
+		return errors.Wrapf(err, "failed to update %s's job uuid", participant.Type)
+	}
+	operationLog.Info().Msgf("kubefate job created, uuid: %s", participant.JobUUID)
+	if err := i.waitJob(jobUUID); err != nil {
+		return err
+	}
+	operationLog.Info().Msgf("kubefate job succeeded")
+	return nil
+}
+
+func (i *kubeFATEOpenFLInstaller) Uninstall(participant *entity.ParticipantOpenFL, operationLog *zerolog.Logger) error {
+	if participant.JobUUID != "" {
+		operationLog.Info().Msgf("try to stop KubeFATE job with UUID %s", participant.JobUUID)
+		if err := i.kfClient.StopJob(participant.JobUUID); err != nil {
+			return err
+		}
+	}
+	if participant.ClusterUUID != "" {
+		operationLog.Info().Msgf("delete KubeFATE cluster with UUID %s", participant.ClusterUUID)
+		jobUUID, err := i.kfClient.SubmitClusterDeletionJob(participant.ClusterUUID)
+		if err != nil {
+			return err
+		}
+		if jobUUID != "" {
+			participant.JobUUID = jobUUID
+			if err := i.repo.UpdateInfoByUUID(participant); err != nil {
+				return errors.Wrapf(err, "failed to update %s's job uuid", participant.Type)
+			}
+			if _, err := i.kfClient.WaitJob(jobUUID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (i *kubeFATEOpenFLInstaller) Status(participant *entity.ParticipantOpenFL) (*ParticipantOpenFLDeploymentStatus, error) {
+	if participant.ClusterUUID == "" {
+		return nil, errors.Errorf("%s %s has no KubeFATE cluster", participant.Type, participant.UUID)
+	}
+	clusterList, err := i.kfClient.ListClusterByNamespace(participant.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list KubeFATE clusters")
+	}
+	for _, cluster := range clusterList {
+		if cluster.Uuid == participant.ClusterUUID {
+			return &ParticipantOpenFLDeploymentStatus{
+				DeploymentMethod: entity.FederationOpenFLDeploymentMethodKubeFATE.String(),
+				ReleaseName:      cluster.Name,
+				Namespace:        cluster.NameSpace,
+				Revision:         int(cluster.HelmRevision),
+				Status:           cluster.Status.String(),
+				ChartName:        cluster.ChartName,
+				ChartVersion:     cluster.ChartVersion,
+			}, nil
+		}
+	}
+	return nil, errors.Errorf("KubeFATE cluster %s not found", participant.ClusterUUID)
+}
+
+func (i *kubeFATEOpenFLInstaller) Close() {
+	if i.closer != nil {
+		i.closer()
+	}
+}
+
+func (i *kubeFATEOpenFLInstaller) waitJob(jobUUID string) error {
+	job, err := i.kfClient.WaitJob(jobUUID)
+	if err != nil {
+		return err
+	}
+	if job.Status != modules.JobStatusSuccess {
+		return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
+	}
+	return nil
+}
+
+// helmOpenFLInstaller installs the chart of the participant as a helm release directly through the Kubernetes API
+type helmOpenFLInstaller struct {
+	k8sClient  kubernetes.Client
+	helmClient helm.Client
+	chartRepo  repo.ChartRepository
+}
+
+func (i *helmOpenFLInstaller) K8sClient() kubernetes.Client {
+	return i.k8sClient
+}
+
+func (i *helmOpenFLInstaller) PrepareChart(chart *entity.Chart) error {
+	if len(chart.ArchiveContent) == 0 {
+		return errors.Errorf("chart %s has no archive content and cannot be installed without KubeFATE", chart.UUID)
+	}
+	return nil
+}
+
+func (i *helmOpenFLInstaller) Install(participant *entity.ParticipantOpenFL, chart *entity.Chart, operationLog *zerolog.Logger) error {
+	release, err := helm.ReleaseFromDeploymentYAML(participant.DeploymentYAML, chart.ValuesTemplate, chart.ArchiveContent)
+	if err != nil {
+		return err
+	}
+	operationLog.Info().Msgf("installing helm release %s in namespace %s", release.Name, release.Namespace)
+	return i.helmClient.Install(release)
+}
+
+func (i *helmOpenFLInstaller) Update(participant *entity.ParticipantOpenFL, chart *entity.Chart, operationLog *zerolog.Logger) error {
+	if chart == nil {
+		instance, err := i.chartRepo.GetByUUID(participant.ChartUUID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to query chart")
+		}
+		chart = instance.(*entity.Chart)
+	}
+	release, err := helm.ReleaseFromDeploymentYAML(participant.DeploymentYAML, chart.ValuesTemplate, chart.ArchiveContent)
+	if err != nil {
+		return err
+	}
+	operationLog.Info().Msgf("upgrading helm release %s in namespace %s", release.Name, release.Namespace)
+	if err := i.helmClient.Upgrade(release); err != nil {
+		return err
+	}
+	operationLog.Info().Msgf("helm release upgraded")
+	return nil
+}
+
+func (i *helmOpenFLInstaller) Uninstall(participant *entity.ParticipantOpenFL, operationLog *zerolog.Logger) error {
+	releaseName, err := getReleaseNameFromDeploymentYAML(participant.DeploymentYAML)
+	if err != nil {
+		return err
+	}
+	operationLog.Info().Msgf("uninstalling helm release %s in namespace %s", releaseName, participant.Namespace)
+	return i.helmClient.Uninstall(participant.Namespace, releaseName)
+}
+
+func (i *helmOpenFLInstaller) Status(participant *entity.ParticipantOpenFL) (*ParticipantOpenFLDeploymentStatus, error) {
+	releaseName, err := getReleaseNameFromDeploymentYAML(participant.DeploymentYAML)
+	if err != nil {
+		return nil, err
+	}
+	status, err := i.helmClient.Status(participant.Namespace, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	return &ParticipantOpenFLDeploymentStatus{
+		DeploymentMethod: entity.FederationOpenFLDeploymentMethodHelm.String(),
+		ReleaseName:      status.Name,
+		Namespace:        status.Namespace,
+		Revision:         status.Revision,
+		Status:           status.Status,
+		ChartName:        status.ChartName,
+		ChartVersion:     status.ChartVersion,
+	}, nil
+}
+
+func (i *helmOpenFLInstaller) Close() {}
+
+// getReleaseNameFromDeploymentYAML returns the release name, which is the "name" field in the deployment yaml
+func getReleaseNameFromDeploymentYAML(deploymentYAML string) (string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(deploymentYAML), &m); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal deployment yaml")
+	}
+	name, ok := m["name"].(string)
+	if !ok || name == "" {
+		return "", errors.New("name not found in the deployment yaml")
+	}
+	return name, nil
+}
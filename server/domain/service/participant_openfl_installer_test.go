@@ -0,0 +1,159 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/pkg/helm"
+	"github.com/FederatedAI/FedLCM/pkg/kubernetes"
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeHelmClient struct {
+	upgraded    []*helm.Release
+	uninstalled []string
+}
+
+func (c *fakeHelmClient) Install(release *helm.Release) error {
+	return nil
+}
+
+func (c *fakeHelmClient) Upgrade(release *helm.Release) error {
+	c.upgraded = append(c.upgraded, release)
+	return nil
+}
+
+func (c *fakeHelmClient) Uninstall(namespace, name string) error {
+	c.uninstalled = append(c.uninstalled, namespace+"/"+name)
+	return nil
+}
+
+func (c *fakeHelmClient) Status(namespace, name string) (*helm.ReleaseStatus, error) {
+	return &helm.ReleaseStatus{
+		Name:         name,
+		Namespace:    namespace,
+		Revision:     2,
+		Status:       "deployed",
+		ChartName:    "openfl-envoy",
+		ChartVersion: "v0.3.0",
+	}, nil
+}
+
+var _ helm.Client = (*fakeHelmClient)(nil)
+
+func TestReconfigureEnvoyWithHelm(t *testing.T) {
+	envoy := &entity.ParticipantOpenFL{
+		Participant: entity.Participant{
+			UUID:           "envoy-uuid",
+			Namespace:      "test-ns",
+			ChartUUID:      "c62b27a6-bf0f-4515-840a-2554ed63aa56",
+			DeploymentYAML: testEnvoyDeploymentYAML,
+		},
+		Type:             entity.ParticipantOpenFLTypeEnvoy,
+		Status:           entity.ParticipantOpenFLStatusActive,
+		TokenUUID:        "token-uuid",
+		InfraUUID:        "infra-uuid",
+		DeploymentMethod: entity.FederationOpenFLDeploymentMethodHelm,
+	}
+	clientSet := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "envoy", Namespace: "test-ns"}})
+	service := &ParticipantOpenFLService{
+		ParticipantOpenFLRepo: &mock.ParticipantOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return envoy, nil
+			},
+		},
+		TokenRepo: &mock.RegistrationTokenOpenFLRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.RegistrationTokenOpenFL{
+					RegistrationToken: entity.RegistrationToken{
+						UUID:      uuid,
+						TokenType: entity.RegistrationTokenTypeRand16,
+						TokenStr:  "test-token",
+					},
+				}, nil
+			},
+		},
+		InfraRepo: &mock.InfraProviderKubernetesRepoMock{
+			GetByUUIDFn: func(uuid string) (interface{}, error) {
+				return &entity.InfraProviderKubernetes{}, nil
+			},
+		},
+		ParticipantService: ParticipantService{
+			ChartRepo:    &gorm.ChartMockRepo{},
+			EventService: &mockEventServiceInt{},
+			RegistrationRecordRepo: &mock.RegistrationRecordRepoMock{
+				GetByParticipantUUIDFn: func(participantUUID string) (interface{}, error) {
+					return &entity.RegistrationRecord{
+						ParticipantUUID: participantUUID,
+						CredentialHash:  entity.HashRegistrationCredential(testEnvoyCredential),
+					}, nil
+				},
+			},
+			EndpointService: &mockRotationEndpointServiceInt{
+				client: &mockK8sClient{
+					GetClientSetFn: func() clientgo.Interface {
+						return clientSet
+					},
+				},
+			},
+		},
+	}
+
+	helmClient := &fakeHelmClient{}
+	originalNewK8sClientFn, originalNewHelmClientFn := newK8sClientFn, newHelmClientFn
+	defer func() {
+		newK8sClientFn, newHelmClientFn = originalNewK8sClientFn, originalNewHelmClientFn
+	}()
+	newK8sClientFn = func(string, string, bool) (kubernetes.Client, error) {
+		return &mockK8sClient{
+			GetClientSetFn: func() clientgo.Interface {
+				return clientSet
+			},
+		}, nil
+	}
+	newHelmClientFn = func(kubernetes.Client) (helm.Client, error) {
+		return helmClient, nil
+	}
+
+	wg, err := service.ReconfigureEnvoy("envoy-uuid", &ParticipantOpenFLEnvoyReconfigureRequest{
+		TokenStr:   testEnvoyCredential,
+		ConfigYAML: "params:\n  cuda_devices: [0]\n",
+	})
+	assert.NoError(t, err)
+	wg.Wait()
+	assert.Equal(t, entity.ParticipantOpenFLStatusActive, envoy.Status)
+	if assert.Len(t, helmClient.upgraded, 1) {
+		release := helmClient.upgraded[0]
+		assert.Equal(t, "envoy-test", release.Name)
+		assert.Equal(t, "test-ns", release.Namespace)
+		assert.NotEmpty(t, release.ChartArchive)
+		assert.NotEmpty(t, release.Values)
+	}
+	assert.Empty(t, envoy.JobUUID, "no KubeFATE job should be created")
+
+	status, err := service.GetDeploymentStatus("envoy-uuid")
+	assert.NoError(t, err)
+	assert.Equal(t, "Helm", status.DeploymentMethod)
+	assert.Equal(t, "envoy-test", status.ReleaseName)
+	assert.Equal(t, "deployed", status.Status)
+}
@@ -34,7 +34,6 @@ import (
 	"github.com/FederatedAI/FedLCM/server/domain/repo"
 	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
 	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm/mock"
-	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/modules"
 	"github.com/Masterminds/sprig/v3"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -56,6 +55,8 @@ type ParticipantOpenFLService struct {
 	ParticipantOpenFLRepo repo.ParticipantOpenFLRepository
 	TokenRepo             repo.RegistrationTokenRepository
 	InfraRepo             repo.InfraProviderRepository
+	BatchOperationRepo    repo.ParticipantOpenFLBatchOperationRepository
+	ShardDescriptorRepo   repo.FederationOpenFLShardDescriptorRepository
 	ParticipantService
 }
 
@@ -79,6 +80,9 @@ type ParticipantOpenFLDirectorCreationRequest struct {
 	ParticipantDeploymentBaseInfo
 	DirectorServerCertInfo entity.ParticipantComponentCertInfo `json:"director_server_cert_info"`
 	JupyterClientCertInfo  entity.ParticipantComponentCertInfo `json:"jupyter_client_cert_info"`
+	// InfraProviderUUID is the infra provider to install the director into, used instead of the EndpointUUID
+	// when the federation uses the Helm deployment method
+	InfraProviderUUID string `json:"infra_provider_uuid"`
 }
 
 // ParticipantOpenFLEnvoyRegistrationRequest is the registration request from an envoy
@@ -99,11 +103,17 @@ type ParticipantOpenFLEnvoyRegistrationRequest struct {
 	EnablePSP             bool                           `json:"enable_psp"`
 	LessPrivileged        bool                           `json:"less_privileged"`
 
+	// SourceAddress is the address of the requester, for auditing
+	SourceAddress string `json:"-"`
+
 	// internal
 	federation   *entity.FederationOpenFL
 	caCert       *x509.Certificate
 	operationLog *zerolog.Logger
 	chart        *entity.Chart
+	// shardDescriptor is the current shard descriptor of the federation, nil means using the default one
+	shardDescriptor        *valueobject.ShardDescriptorConfig
+	shardDescriptorVersion uint
 }
 
 // GetOpenFLDirectorYAML returns the exchange deployment yaml content
@@ -205,13 +215,23 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 		return nil, nil, errors.Errorf("a director is already deployed in federation %s", federationUUID)
 	}
 
-	if req.DirectorServerCertInfo.BindingMode == entity.CertBindingModeReuse ||
-		req.JupyterClientCertInfo.BindingMode == entity.CertBindingModeReuse {
-		return nil, nil, errors.New("cannot re-use existing certificate")
+	if err := validateComponentCertInfo(req.DirectorServerCertInfo, req.JupyterClientCertInfo); err != nil {
+		return nil, nil, err
 	}
 
-	if err := s.EndpointService.TestKubeFATE(req.EndpointUUID); err != nil {
-		return nil, nil, err
+	if federation.DeploymentMethod == entity.FederationOpenFLDeploymentMethodHelm {
+		if req.InfraProviderUUID == "" {
+			return nil, nil, errors.New("an infra provider is required when deploying with Helm")
+		}
+		if _, err := s.InfraRepo.GetByUUID(req.InfraProviderUUID); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to query infra provider")
+		}
+		req.EndpointUUID = ""
+	} else {
+		if err := s.EndpointService.TestKubeFATE(req.EndpointUUID); err != nil {
+			return nil, nil, err
+		}
+		req.InfraProviderUUID = ""
 	}
 
 	instance, err = s.ChartRepo.GetByUUID(req.ChartUUID)
@@ -240,8 +260,8 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 	log.Debug().Msgf("openfl director deployment yaml: %s", req.DeploymentYAML)
 
 	var caCert *x509.Certificate
-	if req.DirectorServerCertInfo.BindingMode == entity.CertBindingModeCreate ||
-		req.JupyterClientCertInfo.BindingMode == entity.CertBindingModeCreate {
+	if req.DirectorServerCertInfo.BindingMode.RequiresCertificate() ||
+		req.JupyterClientCertInfo.BindingMode.RequiresCertificate() {
 		log.Info().Msg("preparing CA for issuing certificate for openfl director deployment")
 		ca, err := s.CertificateService.DefaultCA()
 		if err != nil {
@@ -269,13 +289,15 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 				UseRegistrySecret: req.RegistryConfig.UseRegistrySecret,
 			},
 		},
-		Type:   entity.ParticipantOpenFLTypeDirector,
-		Status: entity.ParticipantOpenFLStatusInstallingDirector,
+		Type:      entity.ParticipantOpenFLTypeDirector,
+		Status:    entity.ParticipantOpenFLStatusInstallingDirector,
+		InfraUUID: req.InfraProviderUUID,
 		CertConfig: entity.ParticipantOpenFLCertConfig{
 			DirectorServerCertInfo: req.DirectorServerCertInfo,
 			JupyterClientCertInfo:  req.JupyterClientCertInfo,
 		},
-		AccessInfo: entity.ParticipantOpenFLModulesAccessMap{},
+		AccessInfo:       entity.ParticipantOpenFLModulesAccessMap{},
+		DeploymentMethod: federation.DeploymentMethod,
 	}
 	err = s.ParticipantOpenFLRepo.Create(director)
 	if err != nil {
@@ -298,20 +320,17 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 			}))
 		operationLog.Info().Msgf("creating openfl director %s with UUID %s", req.Name, director.UUID)
 		if err := func() error {
-			endpointMgr, kfClient, clientCloser, err := s.buildKubeFATEMgrAndClient(req.EndpointUUID)
-			if clientCloser != nil {
-				defer clientCloser()
-			}
+			installer, err := s.buildOpenFLInstaller(director)
 			if err != nil {
 				return err
 			}
-			if chart.Private {
-				if err := kfClient.EnsureChartExist(chart.ChartName, chart.Version, chart.ArchiveContent); err != nil {
-					return errors.Wrapf(err, "error uploading FedLCM private chart")
-				}
+			defer installer.Close()
+			if err := installer.PrepareChart(chart); err != nil {
+				return err
 			}
+			k8sClient := installer.K8sClient()
 
-			if director.ExtraAttribute.IsNewNamespace, err = ensureNSExisting(endpointMgr.K8sClient(), req.Namespace); err != nil {
+			if director.ExtraAttribute.IsNewNamespace, err = ensureNSExisting(k8sClient, req.Namespace); err != nil {
 				return err
 			}
 			if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(director); err != nil {
@@ -320,7 +339,7 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 
 			//Create registry secret
 			if req.RegistryConfig.UseRegistrySecret {
-				if err := createRegistrySecret(endpointMgr.K8sClient(), imagePullSecretsNameOpenFL, req.Namespace, req.RegistryConfig.RegistrySecretConfig); err != nil {
+				if err := createRegistrySecret(k8sClient, imagePullSecretsNameOpenFL, req.Namespace, req.RegistryConfig.RegistrySecretConfig); err != nil {
 					return errors.Wrap(err, "failed to create registry secret")
 				} else {
 					operationLog.Info().Msgf("created registry secret %s with username %s for URL %s", imagePullSecretsNameOpenFL, req.RegistryConfig.RegistrySecretConfig.Username, req.RegistryConfig.RegistrySecretConfig.ServerURL)
@@ -331,17 +350,17 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 			if directorFQDN == "" {
 				directorFQDN = fmt.Sprintf("director.%s", federation.Domain)
 			}
-			if req.DirectorServerCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.DirectorServerCertInfo.BindingMode.RequiresCertificate() {
 				if req.DirectorServerCertInfo.CommonName == "" {
 					req.DirectorServerCertInfo.CommonName = directorFQDN
 				}
-				operationLog.Info().Msgf("creating certificate for the director server with CN: %s", directorFQDN)
-				cert, pk, err := s.CertificateService.CreateCertificateSimple(directorFQDN, defaultCertLifetime, []string{directorFQDN, "director"})
+				operationLog.Info().Msgf("preparing certificate for the director server with CN: %s", directorFQDN)
+				cert, pk, err := s.prepareComponentCertificate(&req.DirectorServerCertInfo, []string{directorFQDN, "director"}, x509.ExtKeyUsageServerAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create director certificate")
+					return errors.Wrapf(err, "failed to prepare director certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", cert.SerialNumber, cert.Subject.CommonName)
-				err = createDirectorSecret(endpointMgr.K8sClient(), req.Namespace, caCert, cert, pk)
+				err = createDirectorSecret(k8sClient, req.Namespace, caCert, cert, pk)
 				if err != nil {
 					return err
 				}
@@ -359,17 +378,17 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 			if jupyterCN == "" {
 				jupyterCN = fmt.Sprintf("jupyter.%s", federation.Domain)
 			}
-			if req.JupyterClientCertInfo.BindingMode == entity.CertBindingModeCreate {
+			if req.JupyterClientCertInfo.BindingMode.RequiresCertificate() {
 				if req.JupyterClientCertInfo.CommonName == "" {
 					req.JupyterClientCertInfo.CommonName = jupyterCN
 				}
-				operationLog.Info().Msgf("creating certificate for the jupyter client with CN: %s", req.JupyterClientCertInfo.CommonName)
-				cert, pk, err := s.CertificateService.CreateCertificateSimple(jupyterCN, defaultCertLifetime, []string{jupyterCN})
+				operationLog.Info().Msgf("preparing certificate for the jupyter client with CN: %s", req.JupyterClientCertInfo.CommonName)
+				cert, pk, err := s.prepareComponentCertificate(&req.JupyterClientCertInfo, []string{jupyterCN}, x509.ExtKeyUsageClientAuth)
 				if err != nil {
-					return errors.Wrapf(err, "failed to create jupyter certificate")
+					return errors.Wrapf(err, "failed to prepare jupyter certificate")
 				}
 				operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", cert.SerialNumber, cert.Subject.CommonName)
-				err = createJupyterSecret(endpointMgr.K8sClient(), req.Namespace, caCert, cert, pk)
+				err = createJupyterSecret(k8sClient, req.Namespace, caCert, cert, pk)
 				if err != nil {
 					return err
 				}
@@ -383,33 +402,11 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 				}
 			}
 
-			jobUUID, err := kfClient.SubmitClusterInstallationJob(director.DeploymentYAML)
-			if err != nil {
-				return errors.Wrapf(err, "fail to submit cluster creation request")
-			}
-			operationLog.Info().Msgf("director cluster installing job UUID: %s", jobUUID)
-			director.JobUUID = jobUUID
-			if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(director); err != nil {
-				return errors.Wrap(err, "failed to update director's job uuid")
-			}
-			clusterUUID, err := kfClient.WaitClusterUUID(jobUUID)
-			if err != nil {
-				return errors.Wrapf(err, "fail to get cluster uuid")
-			}
-			director.ClusterUUID = clusterUUID
-			if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(director); err != nil {
-				return errors.Wrap(err, "failed to update director cluster uuid")
-			}
-			//wait for job finished
-			job, err := kfClient.WaitJob(jobUUID)
-			if err != nil {
+			if err := installer.Install(director, chart, &operationLog); err != nil {
 				return err
 			}
-			if job.Status != modules.JobStatusSuccess {
-				return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
-			}
 
-			serviceType, host, port, err := getServiceAccess(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantOpenFLServiceNameDirector), "director")
+			serviceType, host, port, err := getServiceAccess(k8sClient, req.Namespace, string(entity.ParticipantOpenFLServiceNameDirector), "director")
 			if err != nil {
 				return errors.Wrapf(err, "fail to get director api access info")
 			}
@@ -421,7 +418,7 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 				FQDN:        directorFQDN,
 			}
 
-			serviceType, host, port, err = getServiceAccess(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantOpenFLServiceNameDirector), "agg")
+			serviceType, host, port, err = getServiceAccess(k8sClient, req.Namespace, string(entity.ParticipantOpenFLServiceNameDirector), "agg")
 			if err != nil {
 				return errors.Wrapf(err, "fail to get director agg access info")
 			}
@@ -433,7 +430,7 @@ func (s *ParticipantOpenFLService) CreateDirector(req *ParticipantOpenFLDirector
 				FQDN:        directorFQDN,
 			}
 
-			serviceType, host, port, err = getServiceAccess(endpointMgr.K8sClient(), req.Namespace, string(entity.ParticipantOpenFLServiceNameJupyter), "notebook")
+			serviceType, host, port, err = getServiceAccess(k8sClient, req.Namespace, string(entity.ParticipantOpenFLServiceNameJupyter), "notebook")
 			if err != nil {
 				return errors.Wrapf(err, "fail to get jupyter access info")
 			}
@@ -510,40 +507,19 @@ func (s *ParticipantOpenFLService) RemoveDirector(uuid string, force bool) (*syn
 			}))
 		operationLog.Info().Msgf("uninstalling OpenFL director %s with UUID %s", director.Name, director.UUID)
 		err := func() error {
-			endpointMgr, kfClient, kfClientCloser, err := s.buildKubeFATEMgrAndClient(director.EndpointUUID)
-			if kfClientCloser != nil {
-				defer kfClientCloser()
-			}
+			installer, err := s.buildOpenFLInstaller(director)
 			if err != nil {
 				return err
 			}
-			if director.JobUUID != "" {
-				operationLog.Info().Msgf("try to stop KubeFATE job with UUID %s", director.JobUUID)
-				err := kfClient.StopJob(director.JobUUID)
-				if err != nil {
-					return err
-				}
-			}
-			if director.ClusterUUID != "" {
-				operationLog.Info().Msgf("delete KubeFATE cluster with UUID %s", director.ClusterUUID)
-				jobUUID, err := kfClient.SubmitClusterDeletionJob(director.ClusterUUID)
-				if err != nil {
-					// TODO: use helm or client-go to try to further clean things up
-					return err
-				}
-				if jobUUID != "" {
-					director.JobUUID = jobUUID
-					if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(director); err != nil {
-						return errors.Wrap(err, "failed to update director's job uuid")
-					}
-					if _, err := kfClient.WaitJob(jobUUID); err != nil {
-						return err
-					}
-				}
+			defer installer.Close()
+			if err := installer.Uninstall(director, &operationLog); err != nil {
+				// TODO: use client-go to try to further clean things up
+				return err
 			}
+			k8sClient := installer.K8sClient()
 			// delete registry secret
 			if director.ExtraAttribute.UseRegistrySecret {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Secrets(director.Namespace).
+				if err := k8sClient.GetClientSet().CoreV1().Secrets(director.Namespace).
 					Delete(context.TODO(), imagePullSecretsNameOpenFL, v1.DeleteOptions{}); err != nil {
 					operationLog.Error().Msgf("error deleting registry secret: %v", err)
 				} else {
@@ -553,7 +529,7 @@ func (s *ParticipantOpenFLService) RemoveDirector(uuid string, force bool) (*syn
 
 			// delete certs secrets
 			if director.CertConfig.DirectorServerCertInfo.BindingMode != entity.CertBindingModeSkip {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Secrets(director.Namespace).
+				if err := k8sClient.GetClientSet().CoreV1().Secrets(director.Namespace).
 					Delete(context.TODO(), entity.ParticipantOpenFLSecretNameDirector, v1.DeleteOptions{}); err != nil {
 					operationLog.Error().Msgf("error deleting stale director cert secret: %v", err)
 				} else {
@@ -561,7 +537,7 @@ func (s *ParticipantOpenFLService) RemoveDirector(uuid string, force bool) (*syn
 				}
 			}
 			if director.CertConfig.JupyterClientCertInfo.BindingMode != entity.CertBindingModeSkip {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Secrets(director.Namespace).
+				if err := k8sClient.GetClientSet().CoreV1().Secrets(director.Namespace).
 					Delete(context.TODO(), entity.ParticipantOpenFLSecretNameJupyter, v1.DeleteOptions{}); err != nil {
 					operationLog.Error().Msgf("error deleting stale %s secret: %v", entity.ParticipantOpenFLSecretNameJupyter, err)
 				} else {
@@ -570,7 +546,7 @@ func (s *ParticipantOpenFLService) RemoveDirector(uuid string, force bool) (*syn
 			}
 			// finally, delete the namespace
 			if director.ExtraAttribute.IsNewNamespace {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Namespaces().Delete(context.TODO(), director.Namespace, v1.DeleteOptions{}); err != nil && !apierr.IsNotFound(err) {
+				if err := k8sClient.GetClientSet().CoreV1().Namespaces().Delete(context.TODO(), director.Namespace, v1.DeleteOptions{}); err != nil && !apierr.IsNotFound(err) {
 					return errors.Wrapf(err, "failed to delete namespace")
 				}
 				operationLog.Info().Msgf("namespace %s deleted", director.Namespace)
@@ -592,27 +568,31 @@ func (s *ParticipantOpenFLService) RemoveDirector(uuid string, force bool) (*syn
 	return wg, nil
 }
 
-// HandleRegistrationRequest process a Envoy device registration request
-func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpenFLEnvoyRegistrationRequest) (*entity.ParticipantOpenFL, error) {
+// HandleRegistrationRequest process a Envoy device registration request. The returned credential is used by the device
+// agent to manage the envoy afterwards.
+func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpenFLEnvoyRegistrationRequest) (*entity.ParticipantOpenFL, string, error) {
 	token, err := s.validateEnvoyToken(req)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to validate token")
+		return nil, "", errors.Wrapf(err, "failed to validate token")
 	}
 
 	instance, err := s.FederationRepo.GetByUUID(token.FederationUUID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	req.federation = instance.(*entity.FederationOpenFL)
+	if req.shardDescriptor, req.shardDescriptorVersion, err = s.currentShardDescriptor(req.federation); err != nil {
+		return nil, "", err
+	}
 
 	var caCert *x509.Certificate
 	ca, err := s.CertificateService.DefaultCA()
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get default CA")
+		return nil, "", errors.Wrapf(err, "failed to get default CA")
 	}
 	caCert, err = ca.RootCert()
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get CA cert")
+		return nil, "", errors.Wrapf(err, "failed to get CA cert")
 	}
 	req.caCert = caCert
 
@@ -621,11 +601,11 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 	}
 	instance, err = s.ChartRepo.GetByUUID(req.ChartUUID)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to query chart")
+		return nil, "", errors.Wrapf(err, "failed to query chart")
 	}
 	req.chart = instance.(*entity.Chart)
 	if req.chart.Type != entity.ChartTypeOpenFLEnvoy {
-		return nil, errors.Errorf("chart %s is not for OpenFL envoy deployment", req.chart.UUID)
+		return nil, "", errors.Errorf("chart %s is not for OpenFL envoy deployment", req.chart.UUID)
 	}
 
 	if req.Namespace == "" {
@@ -634,18 +614,18 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 
 	infraProvider, err := s.configEnvoyInfra(req)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to prepare the envoy infra provider")
+		return nil, "", errors.Wrapf(err, "failed to prepare the envoy infra provider")
 	}
 
 	if req.Name == "" {
 		infraAPIHost, err := infraProvider.Config.APIHost()
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		u, err := url.Parse(infraAPIHost)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		req.Name = fmt.Sprintf("envoy-%s", toDeploymentName(u.Hostname()))
 	}
@@ -655,17 +635,17 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 	if !req.LessPrivileged {
 		K8sClient, err := kubernetes.NewKubernetesClient("", infraProvider.Config.KubeConfigContent, infraProvider.Config.IsInCluster)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		_, err = K8sClient.GetClientSet().CoreV1().Namespaces().Get(context.TODO(), req.Namespace, v1.GetOptions{})
 		if err == nil {
-			return nil, errors.Errorf("namespace %s exists. cannot override", req.Namespace)
+			return nil, "", errors.Errorf("namespace %s exists. cannot override", req.Namespace)
 		}
 	}
 
 	deploymentYAML, err := s.GetOpenFLEnvoyYAML(req)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get deployment yaml")
+		return nil, "", errors.Wrapf(err, "failed to get deployment yaml")
 	}
 
 	envoy := &entity.ParticipantOpenFL{
@@ -695,8 +675,12 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 				BindingMode: entity.CertBindingModeCreate,
 			},
 		},
-		AccessInfo: nil,
-		Labels:     valueobject.Labels{},
+		AccessInfo:       nil,
+		Labels:           valueobject.Labels{},
+		DeploymentMethod: req.federation.DeploymentMethod,
+	}
+	if !req.SkipCommonPythonFiles && req.ConfigYAML == "" {
+		envoy.ShardDescriptorVersion = req.shardDescriptorVersion
 	}
 
 	for k, v := range token.Labels {
@@ -707,7 +691,15 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 	}
 
 	if err := s.ParticipantOpenFLRepo.Create(envoy); err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	credential, err := s.recordRegistration(&token.RegistrationToken, entity.FederationTypeOpenFL, envoy.UUID, envoy.Name, envoy.Type.String(), req.SourceAddress)
+	if err != nil {
+		// without the credential the device can't manage the envoy, so it is not installed
+		if deleteErr := s.ParticipantOpenFLRepo.DeleteByUUID(envoy.UUID); deleteErr != nil {
+			log.Err(deleteErr).Msgf("failed to delete envoy %s", envoy.UUID)
+		}
+		return nil, "", err
 	}
 
 	go func() {
@@ -722,6 +714,14 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 		req.operationLog = &operationLog
 		operationLog.Info().Msgf("creating envoy %s with UUID %s", req.Name, envoy.UUID)
 		if err := func() (err error) {
+			if envoy.DeploymentMethod == entity.FederationOpenFLDeploymentMethodHelm {
+				envoy.Status = entity.ParticipantOpenFLStatusInstallingEnvoy
+				if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
+					return err
+				}
+				operationLog.Info().Msgf("installing with helm, skipped preparing kubefate endpoint")
+				return s.installEnvoyInstance(req, envoy)
+			}
 			kfNamespace := ""
 			if req.LessPrivileged {
 				kfNamespace = req.Namespace
@@ -745,7 +745,7 @@ func (s *ParticipantOpenFLService) HandleRegistrationRequest(req *ParticipantOpe
 			}
 		}
 	}()
-	return envoy, nil
+	return envoy, credential, nil
 }
 
 // GetOpenFLEnvoyYAML generates the envoy deployment yaml based on the envoy registration request
@@ -807,8 +807,8 @@ func (s *ParticipantOpenFLService) GetOpenFLEnvoyYAML(req *ParticipantOpenFLEnvo
 
 	if req.ConfigYAML != "" {
 		data.EnvoyConfig = req.ConfigYAML
-	} else if federation.UseCustomizedShardDescriptor {
-		data.EnvoyConfig = federation.ShardDescriptorConfig.EnvoyConfigYaml
+	} else if req.shardDescriptor != nil && req.shardDescriptor.EnvoyConfigYaml != "" {
+		data.EnvoyConfig = req.shardDescriptor.EnvoyConfigYaml
 	}
 
 	if registryOverride := viper.GetString("lifecyclemanager.openfl.envoy.registry.override"); registryOverride != "" {
@@ -828,33 +828,47 @@ func (s *ParticipantOpenFLService) GetOpenFLEnvoyYAML(req *ParticipantOpenFLEnvo
 }
 
 // RemoveEnvoy removes and uninstalls an OpenFL envoy
-func (s *ParticipantOpenFLService) RemoveEnvoy(uuid string, force bool) error {
+func (s *ParticipantOpenFLService) RemoveEnvoy(uuid string, force bool) (*sync.WaitGroup, error) {
 	envoy, err := s.loadParticipant(uuid)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return s.removeEnvoy(envoy, force, nil)
+}
+
+// removeEnvoy uninstalls the envoy, done, if not nil, is called with the result when the async goroutine finishes
+func (s *ParticipantOpenFLService) removeEnvoy(envoy *entity.ParticipantOpenFL, force bool, done func(error)) (*sync.WaitGroup, error) {
 	if envoy.Type != entity.ParticipantOpenFLTypeEnvoy {
-		return errors.Errorf("participant %s is not an OpenFL envoy", envoy.UUID)
+		return nil, errors.Errorf("participant %s is not an OpenFL envoy", envoy.UUID)
 	}
 
 	if !force && envoy.Status != entity.ParticipantOpenFLStatusActive {
-		return errors.Errorf("envoy cannot be removed when in status: %v", envoy.Status)
+		return nil, errors.Errorf("envoy cannot be removed when in status: %v", envoy.Status)
 	}
 
 	envoy.Status = entity.ParticipantOpenFLStatusRemoving
 	if err := s.ParticipantOpenFLRepo.UpdateStatusByUUID(envoy); err != nil {
-		return errors.Wrapf(err, "failed to update director status")
+		return nil, errors.Wrapf(err, "failed to update director status")
 	}
 
 	// TODO: revoke the certificate
 	if err := s.CertificateService.RemoveBinding(envoy.UUID); err != nil {
-		return errors.Wrapf(err, "failed to remove certificate bindings")
+		return nil, errors.Wrapf(err, "failed to remove certificate bindings")
 	}
 
 	// record removing event
 	_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeOpenFLEnvoy, envoy.UUID, "start removing envoy", entity.EventLogLevelInfo)
 
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
+		var err error
+		if done != nil {
+			defer func() {
+				done(err)
+			}()
+		}
 		operationLog := log.Logger.With().Timestamp().Str("action", "uninstalling openfl envoy").Str("uuid", envoy.UUID).Logger().
 			Hook(zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, message string) {
 				eventLvl := entity.EventLogLevelInfo
@@ -864,41 +878,20 @@ func (s *ParticipantOpenFLService) RemoveEnvoy(uuid string, force bool) error {
 				_ = s.EventService.CreateEvent(entity.EventTypeLogMessage, entity.EntityTypeOpenFLEnvoy, envoy.UUID, message, eventLvl)
 			}))
 		operationLog.Info().Msgf("uninstalling OpenFL envoy %s with UUID %s", envoy.Name, envoy.UUID)
-		err := func() error {
-			endpointMgr, kfClient, kfClientCloser, err := s.buildKubeFATEMgrAndClient(envoy.EndpointUUID)
-			if kfClientCloser != nil {
-				defer kfClientCloser()
-			}
+		err = func() error {
+			installer, err := s.buildOpenFLInstaller(envoy)
 			if err != nil {
 				return err
 			}
-			if envoy.JobUUID != "" {
-				operationLog.Info().Msgf("try to stop envoy job: %s", envoy.JobUUID)
-				err := kfClient.StopJob(envoy.JobUUID)
-				if err != nil {
-					return err
-				}
-			}
-			if envoy.ClusterUUID != "" {
-				operationLog.Info().Msgf("try to delete envoy cluster in KubeFATE with uuid: %s", envoy.ClusterUUID)
-				jobUUID, err := kfClient.SubmitClusterDeletionJob(envoy.ClusterUUID)
-				if err != nil {
-					// TODO: use helm or client-go to try to clean things up
-					return err
-				}
-				if jobUUID != "" {
-					envoy.JobUUID = jobUUID
-					if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
-						return errors.Wrap(err, "failed to update envoy's job uuid")
-					}
-					if _, err := kfClient.WaitJob(jobUUID); err != nil {
-						return err
-					}
-				}
+			defer installer.Close()
+			if err := installer.Uninstall(envoy, &operationLog); err != nil {
+				// TODO: use client-go to try to clean things up
+				return err
 			}
+			k8sClient := installer.K8sClient()
 			//Delete registry secret
 			if envoy.ExtraAttribute.UseRegistrySecret {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Secrets(envoy.Namespace).
+				if err := k8sClient.GetClientSet().CoreV1().Secrets(envoy.Namespace).
 					Delete(context.TODO(), imagePullSecretsNameOpenFL, v1.DeleteOptions{}); err != nil {
 					operationLog.Error().Msgf("error deleting registry secret: %v", err)
 				} else {
@@ -906,7 +899,7 @@ func (s *ParticipantOpenFLService) RemoveEnvoy(uuid string, force bool) error {
 				}
 			}
 			if envoy.CertConfig.EnvoyClientCertInfo.BindingMode != entity.CertBindingModeSkip {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Secrets(envoy.Namespace).
+				if err := k8sClient.GetClientSet().CoreV1().Secrets(envoy.Namespace).
 					Delete(context.TODO(), entity.ParticipantOpenFLSecretNameEnvoy, v1.DeleteOptions{}); err != nil {
 					operationLog.Error().Msgf("error deleting stale %s secret: %v", entity.ParticipantOpenFLSecretNameEnvoy, err)
 				} else {
@@ -914,7 +907,7 @@ func (s *ParticipantOpenFLService) RemoveEnvoy(uuid string, force bool) error {
 				}
 			}
 			if envoy.ExtraAttribute.IsNewNamespace {
-				if err := endpointMgr.K8sClient().GetClientSet().CoreV1().Namespaces().Delete(context.TODO(), envoy.Namespace, v1.DeleteOptions{}); err != nil && !apierr.IsNotFound(err) {
+				if err := k8sClient.GetClientSet().CoreV1().Namespaces().Delete(context.TODO(), envoy.Namespace, v1.DeleteOptions{}); err != nil && !apierr.IsNotFound(err) {
 					return errors.Wrapf(err, "failed to delete namespace")
 				}
 				operationLog.Info().Msgf("namespace %s deleted", envoy.Namespace)
@@ -929,11 +922,14 @@ func (s *ParticipantOpenFLService) RemoveEnvoy(uuid string, force bool) error {
 		}
 		if deleteErr := s.ParticipantOpenFLRepo.DeleteByUUID(envoy.UUID); deleteErr != nil {
 			operationLog.Info().Msgf("error deleting envoy from repo: %v", deleteErr)
+			if err == nil {
+				err = deleteErr
+			}
 			return
 		}
 		operationLog.Info().Msgf("uninstalled OpenFL envoy %s with UUID %s", envoy.Name, envoy.UUID)
 	}()
-	return nil
+	return wg, nil
 }
 
 func (s *ParticipantOpenFLService) loadParticipant(uuid string) (*entity.ParticipantOpenFL, error) {
@@ -948,55 +944,7 @@ func (s *ParticipantOpenFLService) configEnvoyInfra(req *ParticipantOpenFLEnvoyR
 	if req.federation == nil {
 		return nil, errors.New("missing federation")
 	}
-	kubeconfig := valueobject.KubeConfig{
-		KubeConfigContent: req.KubeConfig,
-	}
-	if req.LessPrivileged {
-		kubeconfig.NamespacesList = []string{req.Namespace}
-	}
-	if err := kubeconfig.Validate(); err != nil {
-		return nil, err
-	}
-
-	infraAPIHost, err := kubeconfig.APIHost()
-	if err != nil {
-		return nil, err
-	}
-
-	u, err := url.Parse(infraAPIHost)
-	if err != nil {
-		return nil, err
-	}
-
-	infraProvider := &entity.InfraProviderKubernetes{
-		InfraProviderBase: entity.InfraProviderBase{
-			Name:        u.Hostname(),
-			Description: "added during registering OpenFL envoy",
-			Type:        entity.InfraProviderTypeK8s,
-		},
-		Config: kubeconfig,
-		Repo:   s.InfraRepo,
-	}
-	if req.LessPrivileged {
-		infraProvider.Name = infraProvider.Name + "-" + req.Namespace
-	}
-
-	if err := s.InfraRepo.ProviderExists(infraProvider); err == nil {
-		log.Info().Msgf("creating infra provider during envoy registration, name: %s", infraProvider.Name)
-		if err := infraProvider.Create(); err != nil {
-			return nil, err
-		}
-	} else if errors.Is(err, repo.ErrProviderExist) {
-		infraProviderInstance, err := s.InfraRepo.GetByConfigSHA256(infraProvider.Config.SHA2565())
-		if err != nil {
-			// TODO: if error due to name conflicts, retry by using a new, generated name
-			return nil, errors.Wrap(err, "failed to load infra provider: this may be caused by existing same-name infra with different config")
-		}
-		infraProvider = infraProviderInstance.(*entity.InfraProviderKubernetes)
-	} else {
-		return nil, errors.Wrap(err, "failed to check provider existence")
-	}
-	return infraProvider, nil
+	return ensureInfraProviderFromKubeConfig(s.InfraRepo, req.KubeConfig, req.Namespace, req.LessPrivileged, "added during registering OpenFL envoy")
 }
 
 func (s *ParticipantOpenFLService) validateEnvoyToken(req *ParticipantOpenFLEnvoyRegistrationRequest) (*entity.RegistrationTokenOpenFL, error) {
@@ -1022,7 +970,7 @@ func (s *ParticipantOpenFLService) validateEnvoyToken(req *ParticipantOpenFLEnvo
 }
 
 func (s *ParticipantOpenFLService) installEnvoyInstance(req *ParticipantOpenFLEnvoyRegistrationRequest, envoy *entity.ParticipantOpenFL) error {
-	if envoy.EndpointUUID == "" {
+	if envoy.DeploymentMethod == entity.FederationOpenFLDeploymentMethodKubeFATE && envoy.EndpointUUID == "" {
 		return errors.New("missing endpoint uuid")
 	}
 	if req.federation == nil {
@@ -1037,20 +985,17 @@ func (s *ParticipantOpenFLService) installEnvoyInstance(req *ParticipantOpenFLEn
 	if req.chart == nil {
 		return errors.New("missing chart")
 	}
-	endpointMgr, kfClient, kfClientCloser, err := s.buildKubeFATEMgrAndClient(envoy.EndpointUUID)
-	if kfClientCloser != nil {
-		defer kfClientCloser()
-	}
+	installer, err := s.buildOpenFLInstaller(envoy)
 	if err != nil {
 		return err
 	}
-	if req.chart.Private {
-		if err := kfClient.EnsureChartExist(req.chart.ChartName, req.chart.Version, req.chart.ArchiveContent); err != nil {
-			return errors.Wrapf(err, "error uploading FedLCM private chart")
-		}
+	defer installer.Close()
+	if err := installer.PrepareChart(req.chart); err != nil {
+		return err
 	}
+	k8sClient := installer.K8sClient()
 
-	if envoy.ExtraAttribute.IsNewNamespace, err = ensureNSExisting(endpointMgr.K8sClient(), req.Namespace); err != nil {
+	if envoy.ExtraAttribute.IsNewNamespace, err = ensureNSExisting(k8sClient, req.Namespace); err != nil {
 		return err
 	}
 	if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
@@ -1060,16 +1005,16 @@ func (s *ParticipantOpenFLService) installEnvoyInstance(req *ParticipantOpenFLEn
 	if !req.SkipCommonPythonFiles {
 		req.operationLog.Info().Msgf("creating shard descriptor python files")
 		pythonFiles := mock.DefaultEnvoyPythonConfig
-		if req.federation.UseCustomizedShardDescriptor && len(req.federation.ShardDescriptorConfig.PythonFiles) > 0 {
-			pythonFiles = req.federation.ShardDescriptorConfig.PythonFiles
+		if req.shardDescriptor != nil && len(req.shardDescriptor.PythonFiles) > 0 {
+			pythonFiles = req.shardDescriptor.PythonFiles
 		}
-		if err := createEnvoyShardDescriptor(endpointMgr.K8sClient(), req.Namespace, pythonFiles); err != nil {
+		if err := createEnvoyShardDescriptor(k8sClient, req.Namespace, pythonFiles); err != nil {
 			return err
 		}
 	}
 
 	if req.RegistryConfig.UseRegistrySecret {
-		if err := createRegistrySecret(endpointMgr.K8sClient(), imagePullSecretsNameOpenFL, req.Namespace, req.RegistryConfig.RegistrySecretConfig); err != nil {
+		if err := createRegistrySecret(k8sClient, imagePullSecretsNameOpenFL, req.Namespace, req.RegistryConfig.RegistrySecretConfig); err != nil {
 			return errors.Wrap(err, "failed to create registry secret")
 		} else {
 			req.operationLog.Info().Msgf("created registry secret %s with username %s for URL %s", imagePullSecretsNameOpenFL, req.RegistryConfig.RegistrySecretConfig.Username, req.RegistryConfig.RegistrySecretConfig.ServerURL)
@@ -1085,7 +1030,7 @@ func (s *ParticipantOpenFLService) installEnvoyInstance(req *ParticipantOpenFLEn
 			return errors.Wrapf(err, "failed to create envoy certificate")
 		}
 		req.operationLog.Info().Msgf("got certificate with serial number: %v for CN: %s", cert.SerialNumber, cert.Subject.CommonName)
-		err = createEnvoySecret(endpointMgr.K8sClient(), req.Namespace, req.caCert, cert, pk)
+		err = createEnvoySecret(k8sClient, req.Namespace, req.caCert, cert, pk)
 		if err != nil {
 			return err
 		}
@@ -1099,31 +1044,9 @@ func (s *ParticipantOpenFLService) installEnvoyInstance(req *ParticipantOpenFLEn
 		req.operationLog.Info().Msgf("certificate prepared")
 	}
 
-	jobUUID, err := kfClient.SubmitClusterInstallationJob(envoy.DeploymentYAML)
-	if err != nil {
-		return errors.Wrapf(err, "fail to submit envoy creation request")
-	}
-	envoy.JobUUID = jobUUID
-	if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
-		return errors.Wrap(err, "failed to update envoy's job uuid")
-	}
-	clusterUUID, err := kfClient.WaitClusterUUID(jobUUID)
-	if err != nil {
-		return errors.Wrapf(err, "fail to get cluster uuid")
-	}
-	envoy.ClusterUUID = clusterUUID
-	if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
-		return errors.Wrap(err, "failed to update cluster uuid")
-	}
-	req.operationLog.Info().Msgf("job submitted and running: jobUUID: %s, clusterUUID: %s", jobUUID, clusterUUID)
-
-	job, err := kfClient.WaitJob(jobUUID)
-	if err != nil {
+	if err := installer.Install(envoy, req.chart, req.operationLog); err != nil {
 		return err
 	}
-	if job.Status != modules.JobStatusSuccess {
-		return errors.Errorf("job is %s, job info: %v", job.Status.String(), job)
-	}
 	envoy.Status = entity.ParticipantOpenFLStatusActive
 	if err := s.ParticipantOpenFLRepo.UpdateInfoByUUID(envoy); err != nil {
 		return errors.Wrap(err, "failed to save cluster info")
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm/mock"
+	"github.com/pkg/errors"
+)
+
+// currentShardDescriptor returns the shard descriptor config new envoys should use and its version. It is the latest
+// version of the federation, or the config specified at the federation creation if there is no version. A nil config
+// means using the default shard descriptor.
+func (s *ParticipantOpenFLService) currentShardDescriptor(federation *entity.FederationOpenFL) (*valueobject.ShardDescriptorConfig, uint, error) {
+	if s.ShardDescriptorRepo != nil {
+		version, err := s.ShardDescriptorRepo.GetLatestVersionByFederationUUID(federation.UUID)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to query the latest shard descriptor version")
+		}
+		if version > 0 {
+			instance, err := s.ShardDescriptorRepo.GetByFederationUUIDAndVersion(federation.UUID, version)
+			if err != nil {
+				return nil, 0, errors.Wrapf(err, "failed to query shard descriptor version %v", version)
+			}
+			return &instance.(*entity.FederationOpenFLShardDescriptor).Config, version, nil
+		}
+	}
+	if federation.UseCustomizedShardDescriptor {
+		return federation.ShardDescriptorConfig, 0, nil
+	}
+	return nil, 0, nil
+}
+
+// shardDescriptorEnvoyFiles returns the envoy config and the python files to deploy for the shard descriptor version,
+// the default ones are used for the missing parts, same as when installing an envoy
+func shardDescriptorEnvoyFiles(descriptor *entity.FederationOpenFLShardDescriptor) (string, map[string]string) {
+	configYAML, pythonFiles := descriptor.Config.EnvoyConfigYaml, descriptor.Config.PythonFiles
+	if configYAML == "" {
+		configYAML = mock.DefaultEnvoyConfig
+	}
+	if len(pythonFiles) == 0 {
+		pythonFiles = mock.DefaultEnvoyPythonConfig
+	}
+	return configYAML, pythonFiles
+}
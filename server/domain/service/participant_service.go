@@ -17,6 +17,7 @@ package service
 import (
 	"context"
 	"crypto/rsa"
+	"crypto/x509"
 	"net"
 	"net/url"
 	"regexp"
@@ -46,6 +47,8 @@ type ParticipantService struct {
 	EventService       EventServiceInt
 	CertificateService ParticipantCertificateServiceInt
 	EndpointService    ParticipantEndpointServiceInt
+	// RegistrationRecordRepo is used to audit the token based self-registrations
+	RegistrationRecordRepo repo.RegistrationRecordRepository
 }
 
 func (s *ParticipantService) buildKubeFATEMgrAndClient(endpointUUID string) (kubefate.ClientManager, kubefate.Client, func(), error) {
@@ -64,6 +67,38 @@ func (s *ParticipantService) buildKubeFATEMgrAndClient(endpointUUID string) (kub
 	return endpointMgr, kfClient, closer, nil
 }
 
+// prepareComponentCertificate creates a new certificate or gets the imported one for a participant component according
+// to its binding mode. The dnsNames are the SANs of the created certificate, and for server certificates, an imported
+// one must contain them too.
+func (s *ParticipantService) prepareComponentCertificate(certInfo *entity.ParticipantComponentCertInfo, dnsNames []string, usage x509.ExtKeyUsage) (*entity.Certificate, *rsa.PrivateKey, error) {
+	switch certInfo.BindingMode {
+	case entity.CertBindingModeCreate:
+		return s.CertificateService.CreateCertificateSimple(certInfo.CommonName, defaultCertLifetime, dnsNames)
+	case entity.CertBindingModeReuse:
+		var requiredDNSNames []string
+		if usage == x509.ExtKeyUsageServerAuth {
+			requiredDNSNames = dnsNames
+		}
+		cert, pk, err := s.CertificateService.GetImportedCertificate(certInfo.UUID, requiredDNSNames, usage)
+		if err != nil {
+			return nil, nil, err
+		}
+		certInfo.CommonName = cert.Subject.CommonName
+		return cert, pk, nil
+	}
+	return nil, nil, errors.Errorf("unsupported certificate binding mode: %v", certInfo.BindingMode)
+}
+
+// validateComponentCertInfo checks the certificate info provided by the user
+func validateComponentCertInfo(certInfoList ...entity.ParticipantComponentCertInfo) error {
+	for _, certInfo := range certInfoList {
+		if certInfo.BindingMode == entity.CertBindingModeReuse && certInfo.UUID == "" {
+			return errors.New("the uuid of the imported certificate to reuse is required")
+		}
+	}
+	return nil
+}
+
 // ParticipantDeploymentBaseInfo contains basic deployment information for a participant
 type ParticipantDeploymentBaseInfo struct {
 	Description    string `json:"description"`
@@ -291,8 +326,11 @@ func toDeploymentName(name string) string {
 type ParticipantCertificateServiceInt interface {
 	DefaultCA() (*entity.CertificateAuthority, error)
 	CreateCertificateSimple(commonName string, lifetime time.Duration, dnsNames []string) (cert *entity.Certificate, pk *rsa.PrivateKey, err error)
+	GetImportedCertificate(certUUID string, dnsNames []string, usage x509.ExtKeyUsage) (*entity.Certificate, *rsa.PrivateKey, error)
+	GetCertificateBySerialNumber(serialNumber string) (*entity.Certificate, error)
 	CreateBinding(cert *entity.Certificate, serviceType entity.CertificateBindingServiceType, participantUUID string, federationUUID string, federationType entity.FederationType) error
 	RemoveBinding(participantUUID string) error
+	DeleteBinding(participantUUID string) error
 }
 
 // ParticipantEndpointServiceInt declares the methods of an endpoint service that participant service needs
@@ -301,4 +339,5 @@ type ParticipantEndpointServiceInt interface {
 
 	buildKubeFATEClientManagerFromEndpointUUID(uuid string) (kubefate.ClientManager, error)
 	ensureEndpointExist(infraUUID string, namespace string, registryConfig valueobject.KubeRegistryConfig) (string, error)
+	getInfraProviderUUID(uuid string) (string, error)
 }
@@ -0,0 +1,172 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/subtle"
+	"net/url"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/FedLCM/server/domain/valueobject"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ParticipantRegistrationResponse is returned to a participant registered using a registration token
+type ParticipantRegistrationResponse struct {
+	UUID string `json:"uuid"`
+	// Credential is used to access the participant afterwards, it is only returned once
+	Credential string `json:"credential"`
+}
+
+// recordRegistration saves the audit record of a token based self-registration and rotates the token if it is
+// configured to be rotated on use. It returns the credential the registered participant uses to authenticate itself
+// afterwards, only the hash of which is saved.
+func (s *ParticipantService) recordRegistration(token *entity.RegistrationToken, federationType entity.FederationType,
+	participantUUID, participantName, participantType, sourceAddress string) (string, error) {
+	credential, credentialHash, err := entity.NewRegistrationCredential()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate credential")
+	}
+	record := &entity.RegistrationRecord{
+		TokenUUID:       token.UUID,
+		TokenName:       token.Name,
+		CredentialHash:  credentialHash,
+		FederationUUID:  token.FederationUUID,
+		FederationType:  federationType,
+		ParticipantUUID: participantUUID,
+		ParticipantName: participantName,
+		ParticipantType: participantType,
+		SourceAddress:   sourceAddress,
+	}
+	if err := s.RegistrationRecordRepo.Create(record); err != nil {
+		return "", errors.Wrapf(err, "failed to save the registration record of participant %s", participantUUID)
+	}
+	if token.RotateOnUse {
+		if err := token.Rotate(); err != nil {
+			log.Err(err).Msgf("failed to rotate token %s", token.UUID)
+		} else {
+			log.Info().Msgf("token %s(%s) rotated after being used", token.Name, token.UUID)
+		}
+	}
+	return credential, nil
+}
+
+// verifyParticipantCredential checks the credential provided by a self-registered participant is the one returned by
+// its registration, and the token it registered with is not disabled. Participants registered before the credentials
+// were introduced have no registration record and use the token string instead, which is accepted once and then saved
+// as their credential, so rotating the token afterwards doesn't lock them out.
+func (s *ParticipantService) verifyParticipantCredential(token *entity.RegistrationToken, federationType entity.FederationType,
+	participantUUID, participantName, participantType, credential string) error {
+	if token.Disabled {
+		return errors.Errorf("token %s is disabled", token.Name)
+	}
+	if s.RegistrationRecordRepo == nil {
+		return errors.New("invalid credential")
+	}
+	instance, err := s.RegistrationRecordRepo.GetByParticipantUUID(participantUUID)
+	if errors.Is(err, repo.ErrRegistrationRecordNotFound) {
+		return s.migrateLegacyCredential(token, federationType, participantUUID, participantName, participantType, credential)
+	}
+	if err != nil {
+		log.Err(err).Msgf("failed to query the registration record of participant %s", participantUUID)
+		return errors.New("invalid credential")
+	}
+	if !instance.(*entity.RegistrationRecord).VerifyCredential(credential) {
+		return errors.New("invalid credential")
+	}
+	return nil
+}
+
+// migrateLegacyCredential accepts the current token string from a participant without a registration record, and saves
+// a record with the token string as the participant's credential
+func (s *ParticipantService) migrateLegacyCredential(token *entity.RegistrationToken, federationType entity.FederationType,
+	participantUUID, participantName, participantType, credential string) error {
+	tokenStr := token.Display()
+	if credential == "" || tokenStr == "" || subtle.ConstantTimeCompare([]byte(credential), []byte(tokenStr)) != 1 {
+		return errors.New("invalid credential")
+	}
+	record := &entity.RegistrationRecord{
+		TokenUUID:       token.UUID,
+		TokenName:       token.Name,
+		CredentialHash:  entity.HashRegistrationCredential(credential),
+		FederationUUID:  token.FederationUUID,
+		FederationType:  federationType,
+		ParticipantUUID: participantUUID,
+		ParticipantName: participantName,
+		ParticipantType: participantType,
+	}
+	if err := s.RegistrationRecordRepo.Create(record); err != nil {
+		return errors.Wrapf(err, "failed to save the credential of participant %s", participantUUID)
+	}
+	log.Info().Msgf("saved the token string of participant %s(%s) as its credential", participantName, participantUUID)
+	return nil
+}
+
+// ensureInfraProviderFromKubeConfig returns the infra provider of the kubeconfig sent in the self-registration requests,
+// the provider is created if it doesn't exist yet
+func ensureInfraProviderFromKubeConfig(infraRepo repo.InfraProviderRepository, kubeconfigContent, namespace string,
+	lessPrivileged bool, description string) (*entity.InfraProviderKubernetes, error) {
+	kubeconfig := valueobject.KubeConfig{
+		KubeConfigContent: kubeconfigContent,
+	}
+	if lessPrivileged {
+		kubeconfig.NamespacesList = []string{namespace}
+	}
+	if err := kubeconfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	infraAPIHost, err := kubeconfig.APIHost()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(infraAPIHost)
+	if err != nil {
+		return nil, err
+	}
+
+	infraProvider := &entity.InfraProviderKubernetes{
+		InfraProviderBase: entity.InfraProviderBase{
+			Name:        u.Hostname(),
+			Description: description,
+			Type:        entity.InfraProviderTypeK8s,
+		},
+		Config: kubeconfig,
+		Repo:   infraRepo,
+	}
+	if lessPrivileged {
+		infraProvider.Name = infraProvider.Name + "-" + namespace
+	}
+
+	if err := infraRepo.ProviderExists(infraProvider); err == nil {
+		log.Info().Msgf("creating infra provider during self-registration, name: %s", infraProvider.Name)
+		if err := infraProvider.Create(); err != nil {
+			return nil, err
+		}
+	} else if errors.Is(err, repo.ErrProviderExist) {
+		infraProviderInstance, err := infraRepo.GetByConfigSHA256(infraProvider.Config.SHA2565())
+		if err != nil {
+			// TODO: if error due to name conflicts, retry by using a new, generated name
+			return nil, errors.Wrap(err, "failed to load infra provider: this may be caused by existing same-name infra with different config")
+		}
+		infraProvider = infraProviderInstance.(*entity.InfraProviderKubernetes)
+	} else {
+		return nil, errors.Wrap(err, "failed to check provider existence")
+	}
+	return infraProvider, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/FederatedAI/FedLCM/server/domain/repo/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordRegistration(t *testing.T) {
+	var record *entity.RegistrationRecord
+	rotatedStr := ""
+	service := &ParticipantService{
+		RegistrationRecordRepo: &mock.RegistrationRecordRepoMock{
+			CreateFn: func(instance interface{}) error {
+				record = instance.(*entity.RegistrationRecord)
+				return nil
+			},
+		},
+	}
+	token := &entity.RegistrationToken{
+		UUID:           "token-uuid",
+		Name:           "token",
+		TokenType:      entity.RegistrationTokenTypeRand16,
+		TokenStr:       "test-token",
+		FederationUUID: "federation-uuid",
+		RotateOnUse:    true,
+		Repo: &mock.RegistrationTokenOpenFLRepoMock{
+			UpdateTokenStrByUUIDFn: func(uuid string, tokenStr string) error {
+				rotatedStr = tokenStr
+				return nil
+			},
+		},
+	}
+	credential, err := service.recordRegistration(token, entity.FederationTypeOpenFL, "envoy-uuid", "envoy", "envoy", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, credential)
+
+	if assert.NotNil(t, record) {
+		assert.Equal(t, "token-uuid", record.TokenUUID)
+		assert.Equal(t, "federation-uuid", record.FederationUUID)
+		assert.Equal(t, "10.0.0.1", record.SourceAddress)
+		// only the hash of the credential is saved
+		assert.NotEqual(t, credential, record.CredentialHash)
+		assert.True(t, record.VerifyCredential(credential))
+	}
+	assert.NotEqual(t, "test-token", token.TokenStr)
+	assert.Equal(t, token.TokenStr, rotatedStr)
+}
+
+func TestVerifyParticipantCredential(t *testing.T) {
+	credential, credentialHash, err := entity.NewRegistrationCredential()
+	assert.NoError(t, err)
+	otherCredential, _, err := entity.NewRegistrationCredential()
+	assert.NoError(t, err)
+	service := &ParticipantService{
+		RegistrationRecordRepo: &mock.RegistrationRecordRepoMock{
+			GetByParticipantUUIDFn: func(participantUUID string) (interface{}, error) {
+				if participantUUID != "uuid" {
+					return nil, assert.AnError
+				}
+				return &entity.RegistrationRecord{
+					ParticipantUUID: participantUUID,
+					CredentialHash:  credentialHash,
+				}, nil
+			},
+		},
+	}
+	token := &entity.RegistrationToken{
+		TokenType: entity.RegistrationTokenTypeRand16,
+		TokenStr:  "current-token",
+	}
+	verify := func(participantUUID, credential string) error {
+		return service.verifyParticipantCredential(token, entity.FederationTypeOpenFL, participantUUID, "envoy", "envoy", credential)
+	}
+	assert.NoError(t, verify("uuid", credential))
+	// the credential is bound to the participant
+	assert.Error(t, verify("other-uuid", credential))
+	assert.Error(t, verify("uuid", otherCredential))
+	// the token string is not a credential
+	assert.Error(t, verify("uuid", "rand16:current-token"))
+	assert.Error(t, verify("uuid", ""))
+	// disabling the token revokes the access
+	token.Disabled = true
+	assert.Error(t, verify("uuid", credential))
+}
+
+func TestVerifyParticipantCredential_LegacyParticipant(t *testing.T) {
+	var records []*entity.RegistrationRecord
+	service := &ParticipantService{
+		RegistrationRecordRepo: &mock.RegistrationRecordRepoMock{
+			CreateFn: func(instance interface{}) error {
+				records = append(records, instance.(*entity.RegistrationRecord))
+				return nil
+			},
+			GetByParticipantUUIDFn: func(participantUUID string) (interface{}, error) {
+				if len(records) == 0 {
+					return nil, repo.ErrRegistrationRecordNotFound
+				}
+				return records[len(records)-1], nil
+			},
+		},
+	}
+	token := &entity.RegistrationToken{
+		UUID:      "token-uuid",
+		TokenType: entity.RegistrationTokenTypeRand16,
+		TokenStr:  "current-token",
+	}
+	verify := func(credential string) error {
+		return service.verifyParticipantCredential(token, entity.FederationTypeOpenFL, "uuid", "envoy", "envoy", credential)
+	}
+	// only the token string is accepted from a participant without a record
+	assert.Error(t, verify("rand16:other-token"))
+	assert.Empty(t, records)
+	assert.NoError(t, verify("rand16:current-token"))
+	assert.Len(t, records, 1)
+	assert.Equal(t, "uuid", records[0].ParticipantUUID)
+	assert.Equal(t, "token-uuid", records[0].TokenUUID)
+
+	// the token string keeps working as the credential after the token is rotated
+	token.TokenStr = "rotated-token"
+	assert.NoError(t, verify("rand16:current-token"))
+	assert.Error(t, verify("rand16:rotated-token"))
+	assert.Len(t, records, 1)
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package valueobject
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// FATEComponentSizing contains the replica count and resource settings of a FATE component, zero values mean keeping
+// the defaults of the chart
+type FATEComponentSizing struct {
+	Replicas      int    `json:"replicas"`
+	CPURequest    string `json:"cpu_request"`
+	MemoryRequest string `json:"memory_request"`
+	CPULimit      string `json:"cpu_limit"`
+	MemoryLimit   string `json:"memory_limit"`
+}
+
+// FATEClusterSizing contains the sizing settings of the components in a FATE cluster
+type FATEClusterSizing struct {
+	// Python is the sizing of the fateflow pod running the python workers
+	Python FATEComponentSizing `json:"python"`
+	// NodeManager is the sizing of the eggroll nodemanagers, used when eggroll is the computing engine
+	NodeManager FATEComponentSizing `json:"nodemanager"`
+	// SparkWorker is the sizing of the spark workers, used when the in-cluster spark is the computing engine
+	SparkWorker FATEComponentSizing `json:"spark_worker"`
+	// ProcessorsPerNode is the number of python worker processes on each computing node, i.e. the session processors
+	// of an eggroll nodemanager or the cores of a spark worker
+	ProcessorsPerNode int `json:"processors_per_node"`
+}
+
+func (s FATEClusterSizing) Value() (driver.Value, error) {
+	bJson, err := json.Marshal(s)
+	return bJson, err
+}
+
+func (s *FATEClusterSizing) Scan(v interface{}) error {
+	return json.Unmarshal([]byte(v.(string)), s)
+}
+
+// Validate checks the replica and processor counts are not negative and the resource quantities are valid
+func (s *FATEClusterSizing) Validate() error {
+	if s.ProcessorsPerNode < 0 {
+		return errors.Errorf("invalid processors per node: %d", s.ProcessorsPerNode)
+	}
+	for name, component := range map[string]FATEComponentSizing{
+		"python":       s.Python,
+		"nodemanager":  s.NodeManager,
+		"spark worker": s.SparkWorker,
+	} {
+		if err := component.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid %s sizing", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks the replica count is not negative and the requests are valid quantities not exceeding the limits
+func (s *FATEComponentSizing) Validate() error {
+	if s.Replicas < 0 {
+		return errors.Errorf("invalid replicas: %d", s.Replicas)
+	}
+	for _, pair := range [][2]string{{s.CPURequest, s.CPULimit}, {s.MemoryRequest, s.MemoryLimit}} {
+		var quantities [2]resource.Quantity
+		for i, value := range pair {
+			if value == "" {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid quantity %q", value)
+			}
+			quantities[i] = quantity
+		}
+		if pair[0] != "" && pair[1] != "" && quantities[0].Cmp(quantities[1]) > 0 {
+			return errors.Errorf("request %s is larger than limit %s", pair[0], pair[1])
+		}
+	}
+	return nil
+}
+
+// Resources returns the Kubernetes resources section of the component, or nil if no resource is specified
+func (s *FATEComponentSizing) Resources() map[string]interface{} {
+	requests := map[string]interface{}{}
+	limits := map[string]interface{}{}
+	for _, item := range []struct {
+		m     map[string]interface{}
+		key   string
+		value string
+	}{
+		{requests, "cpu", s.CPURequest},
+		{requests, "memory", s.MemoryRequest},
+		{limits, "cpu", s.CPULimit},
+		{limits, "memory", s.MemoryLimit},
+	} {
+		if item.value != "" {
+			item.m[item.key] = item.value
+		}
+	}
+	resources := map[string]interface{}{}
+	if len(requests) > 0 {
+		resources["requests"] = requests
+	}
+	if len(limits) > 0 {
+		resources["limits"] = limits
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	return resources
+}
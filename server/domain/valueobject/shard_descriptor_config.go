@@ -17,6 +17,19 @@ package valueobject
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// shardDescriptorMaxFileSize is the max size of a python file
+	shardDescriptorMaxFileSize = 256 * 1024
+	// shardDescriptorMaxTotalSize is the max total size of the python files, which are stored in one ConfigMap and
+	// a ConfigMap cannot exceed 1MiB
+	shardDescriptorMaxTotalSize = 900 * 1024
 )
 
 // ShardDescriptorConfig contains configurations for OpenFL shards
@@ -35,3 +48,27 @@ func (c ShardDescriptorConfig) Value() (driver.Value, error) {
 func (c *ShardDescriptorConfig) Scan(v interface{}) error {
 	return json.Unmarshal([]byte(v.(string)), c)
 }
+
+// Validate checks the python file names can be used as ConfigMap keys, the content size of the files and the envoy config
+func (c *ShardDescriptorConfig) Validate() error {
+	totalSize := 0
+	for name, content := range c.PythonFiles {
+		if errs := validation.IsConfigMapKey(name); len(errs) > 0 {
+			return errors.Errorf("invalid file name %q: %s", name, strings.Join(errs, "; "))
+		}
+		if len(content) > shardDescriptorMaxFileSize {
+			return errors.Errorf("file %s is larger than %d bytes", name, shardDescriptorMaxFileSize)
+		}
+		totalSize += len(content)
+	}
+	if totalSize > shardDescriptorMaxTotalSize {
+		return errors.Errorf("total size of the python files is larger than %d bytes", shardDescriptorMaxTotalSize)
+	}
+	if c.EnvoyConfigYaml != "" {
+		var envoyConfig map[string]interface{}
+		if err := yaml.Unmarshal([]byte(c.EnvoyConfigYaml), &envoyConfig); err != nil {
+			return errors.Wrapf(err, "invalid envoy config")
+		}
+	}
+	return nil
+}
@@ -61,6 +61,29 @@ func (r *CertificateRepo) GetByUUID(uuid string) (interface{}, error) {
 	return cert, nil
 }
 
+func (r *CertificateRepo) UpdateByUUID(instance interface{}) error {
+	cert := instance.(*entity.Certificate)
+	return db.Where("uuid = ?", cert.UUID).
+		Select("serial_number_str", "pem", "chain_pem").Updates(cert).Error
+}
+
+func (r *CertificateRepo) UpdateRevocationByUUID(instance interface{}) error {
+	cert := instance.(*entity.Certificate)
+	return db.Model(cert).Where("uuid = ?", cert.UUID).
+		UpdateColumns(map[string]interface{}{
+			"revoked_at":        cert.RevokedAt,
+			"revocation_reason": cert.RevocationReason,
+		}).Error
+}
+
+func (r *CertificateRepo) ListRevoked() (interface{}, error) {
+	var certs []entity.Certificate
+	if err := db.Unscoped().Where("revoked_at IS NOT NULL").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
 // InitTable makes sure the table is created in the db
 func (r *CertificateRepo) InitTable() {
 	if err := db.AutoMigrate(entity.Certificate{}); err != nil {
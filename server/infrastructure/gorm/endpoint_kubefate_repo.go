@@ -94,6 +94,13 @@ func (r *EndpointKubeFATERepo) UpdateInfoByUUID(instance interface{}) error {
 		Updates(endpoint).Error
 }
 
+func (r *EndpointKubeFATERepo) UpdateDeploymentYAMLByUUID(instance interface{}) error {
+	endpoint := instance.(*entity.EndpointKubeFATE)
+	return db.Where("uuid = ?", endpoint.UUID).
+		Select("deployment_yaml", "ingress_controller_yaml").
+		Updates(endpoint).Error
+}
+
 // InitTable makes sure the table is created in the db
 func (r *EndpointKubeFATERepo) InitTable() {
 	if err := db.AutoMigrate(entity.EndpointKubeFATE{}); err != nil {
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+// FederationFATEDiagnosticsRepo implements repo.FederationFATEDiagnosticsRepository interface
+type FederationFATEDiagnosticsRepo struct{}
+
+var _ repo.FederationFATEDiagnosticsRepository = (*FederationFATEDiagnosticsRepo)(nil)
+
+func (r *FederationFATEDiagnosticsRepo) Create(instance interface{}) error {
+	diagnostics := instance.(*entity.FederationFATEDiagnostics)
+	return db.Create(diagnostics).Error
+}
+
+func (r *FederationFATEDiagnosticsRepo) UpdateStatusAndReportByUUID(instance interface{}) error {
+	diagnostics := instance.(*entity.FederationFATEDiagnostics)
+	return db.Where("uuid = ?", diagnostics.UUID).Select("status", "message", "report").Updates(diagnostics).Error
+}
+
+func (r *FederationFATEDiagnosticsRepo) GetByUUID(uuid string) (interface{}, error) {
+	diagnostics := &entity.FederationFATEDiagnostics{}
+	if err := db.Where("uuid = ?", uuid).First(diagnostics).Error; err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
+
+func (r *FederationFATEDiagnosticsRepo) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	var diagnosticsList []entity.FederationFATEDiagnostics
+	if err := db.Where("federation_uuid = ?", federationUUID).Order("created_at desc").Find(&diagnosticsList).Error; err != nil {
+		return nil, err
+	}
+	return diagnosticsList, nil
+}
+
+// InitTable makes sure the table is created in the db
+func (r *FederationFATEDiagnosticsRepo) InitTable() {
+	if err := db.AutoMigrate(entity.FederationFATEDiagnostics{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+// FederationOpenFLShardDescriptorRepo implements repo.FederationOpenFLShardDescriptorRepository interface
+type FederationOpenFLShardDescriptorRepo struct{}
+
+var _ repo.FederationOpenFLShardDescriptorRepository = (*FederationOpenFLShardDescriptorRepo)(nil)
+
+func (r *FederationOpenFLShardDescriptorRepo) Create(instance interface{}) error {
+	descriptor := instance.(*entity.FederationOpenFLShardDescriptor)
+	return db.Create(descriptor).Error
+}
+
+func (r *FederationOpenFLShardDescriptorRepo) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	var descriptors []entity.FederationOpenFLShardDescriptor
+	if err := db.Where("federation_uuid = ?", federationUUID).Order("version desc").Find(&descriptors).Error; err != nil {
+		return nil, err
+	}
+	return descriptors, nil
+}
+
+func (r *FederationOpenFLShardDescriptorRepo) GetByFederationUUIDAndVersion(federationUUID string, version uint) (interface{}, error) {
+	descriptor := &entity.FederationOpenFLShardDescriptor{}
+	if err := db.Where("federation_uuid = ? AND version = ?", federationUUID, version).First(descriptor).Error; err != nil {
+		return nil, err
+	}
+	return descriptor, nil
+}
+
+func (r *FederationOpenFLShardDescriptorRepo) GetLatestVersionByFederationUUID(federationUUID string) (uint, error) {
+	var version uint
+	if err := db.Model(&entity.FederationOpenFLShardDescriptor{}).Where("federation_uuid = ?", federationUUID).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func (r *FederationOpenFLShardDescriptorRepo) DeleteByFederationUUID(federationUUID string) error {
+	return db.Where("federation_uuid = ?", federationUUID).Delete(&entity.FederationOpenFLShardDescriptor{}).Error
+}
+
+// InitTable makes sure the table is created in the db
+func (r *FederationOpenFLShardDescriptorRepo) InitTable() {
+	if err := db.AutoMigrate(entity.FederationOpenFLShardDescriptor{}); err != nil {
+		panic(err)
+	}
+}
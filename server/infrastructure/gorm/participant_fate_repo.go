@@ -95,7 +95,7 @@ func (r *ParticipantFATERepo) UpdateDeploymentYAMLByUUID(instance interface{}) e
 func (r *ParticipantFATERepo) UpdateInfoByUUID(instance interface{}) error {
 	participant := instance.(*entity.ParticipantFATE)
 	return db.Where("uuid = ?", participant.UUID).
-		Select("cluster_uuid", "status", "access_info", "cert_config", "extra_attribute", "ingress_info", "job_uuid", "deployment_yaml", "chart_uuid").
+		Select("cluster_uuid", "status", "is_managed", "access_info", "cert_config", "extra_attribute", "ingress_info", "job_uuid", "deployment_yaml", "chart_uuid", "endpoint_uuid", "exchange_uuid", "standby_exchange_uuid", "sizing_profile_uuid", "backend").
 		Updates(participant).Error
 }
 
@@ -107,6 +107,14 @@ func (r *ParticipantFATERepo) GetExchangeByFederationUUID(uuid string) (interfac
 	return participant, nil
 }
 
+func (r *ParticipantFATERepo) ListExchangesByFederationUUID(uuid string) (interface{}, error) {
+	var participants []entity.ParticipantFATE
+	if err := db.Where("federation_uuid = ? AND type = ?", uuid, entity.ParticipantFATETypeExchange).Order("id").Find(&participants).Error; err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
 func (r *ParticipantFATERepo) IsExchangeCreatedByFederationUUID(uuid string) (bool, error) {
 	var count int64
 	if err := db.Model(&entity.ParticipantFATE{}).Where("federation_uuid = ? AND type = ?", uuid, entity.ParticipantFATETypeExchange).Count(&count).Error; err != nil {
@@ -121,6 +129,14 @@ func (r *ParticipantFATERepo) IsConflictedByFederationUUIDAndPartyID(federationU
 	return count > 0, err
 }
 
+func (r *ParticipantFATERepo) CountByTokenUUID(uuid string) (int, error) {
+	var count int64
+	if err := db.Unscoped().Model(&entity.ParticipantFATE{}).Where("token_uuid = ?", uuid).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
 // InitTable makes sure the table is created in the db
 func (r *ParticipantFATERepo) InitTable() {
 	if err := db.AutoMigrate(entity.ParticipantFATE{}); err != nil {
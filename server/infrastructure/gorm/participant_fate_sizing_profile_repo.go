@@ -0,0 +1,87 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ParticipantFATESizingProfileRepo implements the repo.ParticipantFATESizingProfileRepository interface
+type ParticipantFATESizingProfileRepo struct{}
+
+var _ repo.ParticipantFATESizingProfileRepository = (*ParticipantFATESizingProfileRepo)(nil)
+
+// ErrSizingProfileExist means new profile cannot be created due to the existence of the same-name profile
+var ErrSizingProfileExist = errors.New("sizing profile already exists")
+
+func (r *ParticipantFATESizingProfileRepo) Create(instance interface{}) error {
+	var count int64
+	profile := instance.(*entity.ParticipantFATESizingProfile)
+	db.Model(&entity.ParticipantFATESizingProfile{}).Where("name = ?", profile.Name).Count(&count)
+	if count > 0 {
+		return ErrSizingProfileExist
+	}
+	return db.Create(profile).Error
+}
+
+func (r *ParticipantFATESizingProfileRepo) List() (interface{}, error) {
+	var profiles []entity.ParticipantFATESizingProfile
+	if err := db.Order("id").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *ParticipantFATESizingProfileRepo) GetByUUID(uuid string) (interface{}, error) {
+	profile := &entity.ParticipantFATESizingProfile{}
+	if err := db.Where("uuid = ?", uuid).First(profile).Error; err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (r *ParticipantFATESizingProfileRepo) UpdateByUUID(instance interface{}) error {
+	profile := instance.(*entity.ParticipantFATESizingProfile)
+	return db.Where("uuid = ?", profile.UUID).Select("description", "sizing").Updates(profile).Error
+}
+
+func (r *ParticipantFATESizingProfileRepo) DeleteByUUID(uuid string) error {
+	return db.Where("uuid = ?", uuid).Delete(&entity.ParticipantFATESizingProfile{}).Error
+}
+
+// InitTable makes sure the table is created in the db
+func (r *ParticipantFATESizingProfileRepo) InitTable() {
+	if err := db.AutoMigrate(entity.ParticipantFATESizingProfile{}); err != nil {
+		panic(err)
+	}
+}
+
+// InitData inserts the built-in profiles that don't exist yet
+func (r *ParticipantFATESizingProfileRepo) InitData() {
+	for _, profile := range entity.BuiltInParticipantFATESizingProfiles() {
+		profile.UUID = uuid.NewV4().String()
+		if err := r.Create(&profile); err != nil {
+			if err == ErrSizingProfileExist {
+				log.Debug().Msgf("sizing profile: %s exists", profile.Name)
+			} else {
+				panic(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+// ParticipantOpenFLBatchOperationRepo implements repo.ParticipantOpenFLBatchOperationRepository interface
+type ParticipantOpenFLBatchOperationRepo struct{}
+
+var _ repo.ParticipantOpenFLBatchOperationRepository = (*ParticipantOpenFLBatchOperationRepo)(nil)
+
+func (r *ParticipantOpenFLBatchOperationRepo) Create(instance interface{}) error {
+	operation := instance.(*entity.ParticipantOpenFLBatchOperation)
+	return db.Create(operation).Error
+}
+
+func (r *ParticipantOpenFLBatchOperationRepo) UpdateStatusAndItemsByUUID(instance interface{}) error {
+	operation := instance.(*entity.ParticipantOpenFLBatchOperation)
+	return db.Where("uuid = ?", operation.UUID).Select("status", "items").Updates(operation).Error
+}
+
+func (r *ParticipantOpenFLBatchOperationRepo) GetByUUID(uuid string) (interface{}, error) {
+	operation := &entity.ParticipantOpenFLBatchOperation{}
+	if err := db.Where("uuid = ?", uuid).First(operation).Error; err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (r *ParticipantOpenFLBatchOperationRepo) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	var operations []entity.ParticipantOpenFLBatchOperation
+	if err := db.Where("federation_uuid = ?", federationUUID).Order("created_at desc").Find(&operations).Error; err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+// InitTable makes sure the table is created in the db
+func (r *ParticipantOpenFLBatchOperationRepo) InitTable() {
+	if err := db.AutoMigrate(entity.ParticipantOpenFLBatchOperation{}); err != nil {
+		panic(err)
+	}
+}
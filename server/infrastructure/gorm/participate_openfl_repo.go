@@ -91,10 +91,19 @@ func (r *ParticipantOpenFLRepo) UpdateDeploymentYAMLByUUID(instance interface{})
 func (r *ParticipantOpenFLRepo) UpdateInfoByUUID(instance interface{}) error {
 	participant := instance.(*entity.ParticipantOpenFL)
 	return db.Where("uuid = ?", participant.UUID).
-		Select("endpoint_uuid", "cluster_uuid", "status", "access_info", "cert_config", "extra_attribute", "job_uuid").
+		Select("endpoint_uuid", "cluster_uuid", "status", "access_info", "cert_config", "extra_attribute", "job_uuid", "shard_descriptor_version").
 		Updates(participant).Error
 }
 
+func (r *ParticipantOpenFLRepo) UpdateDeploymentYAMLAndChartByUUID(instance interface{}) error {
+	participant := instance.(*entity.ParticipantOpenFL)
+	return db.Model(&entity.ParticipantOpenFL{}).Where("uuid = ?", participant.UUID).
+		UpdateColumns(map[string]interface{}{
+			"deployment_yaml": participant.DeploymentYAML,
+			"chart_uuid":      participant.ChartUUID,
+		}).Error
+}
+
 func (r *ParticipantOpenFLRepo) IsDirectorCreatedByFederationUUID(uuid string) (bool, error) {
 	var count int64
 	if err := db.Model(&entity.ParticipantOpenFL{}).Where("federation_uuid = ? AND type = ?", uuid, entity.ParticipantOpenFLTypeDirector).Count(&count).Error; err != nil {
@@ -119,6 +128,21 @@ func (r *ParticipantOpenFLRepo) GetDirectorByFederationUUID(uuid string) (interf
 	return participant, nil
 }
 
+func (r *ParticipantOpenFLRepo) UpdateHeartbeatByUUID(instance interface{}) error {
+	participant := instance.(*entity.ParticipantOpenFL)
+	return db.Model(&entity.ParticipantOpenFL{}).Where("uuid = ?", participant.UUID).
+		UpdateColumns(map[string]interface{}{
+			"last_seen":     participant.LastSeen,
+			"device_health": participant.DeviceHealth,
+		}).Error
+}
+
+func (r *ParticipantOpenFLRepo) UpdateLabelsByUUID(instance interface{}) error {
+	participant := instance.(*entity.ParticipantOpenFL)
+	return db.Model(&entity.ParticipantOpenFL{}).Where("uuid = ?", participant.UUID).
+		Update("labels", participant.Labels).Error
+}
+
 // InitTable makes sure the table is created in the db
 func (r *ParticipantOpenFLRepo) InitTable() {
 	if err := db.AutoMigrate(entity.ParticipantOpenFL{}); err != nil {
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// RegistrationRecordRepo implements repo.RegistrationRecordRepository interface
+type RegistrationRecordRepo struct{}
+
+var _ repo.RegistrationRecordRepository = (*RegistrationRecordRepo)(nil)
+
+func (r *RegistrationRecordRepo) Create(instance interface{}) error {
+	record := instance.(*entity.RegistrationRecord)
+	return db.Create(record).Error
+}
+
+func (r *RegistrationRecordRepo) ListByFederationUUID(federationUUID string) (interface{}, error) {
+	var records []entity.RegistrationRecord
+	if err := db.Where("federation_uuid = ?", federationUUID).Order("created_at desc").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *RegistrationRecordRepo) GetByParticipantUUID(participantUUID string) (interface{}, error) {
+	record := &entity.RegistrationRecord{}
+	if err := db.Where("participant_uuid = ?", participantUUID).Last(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repo.ErrRegistrationRecordNotFound
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// InitTable makes sure the table is created in the db
+func (r *RegistrationRecordRepo) InitTable() {
+	if err := db.AutoMigrate(entity.RegistrationRecord{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/server/domain/repo"
+)
+
+// RegistrationTokenFATERepo implements repo.RegistrationTokenRepository interface
+type RegistrationTokenFATERepo struct{}
+
+var _ repo.RegistrationTokenRepository = (*RegistrationTokenFATERepo)(nil)
+
+func (r *RegistrationTokenFATERepo) Create(instance interface{}) error {
+	var count int64
+	token := instance.(*entity.RegistrationTokenFATE)
+	db.Model(&entity.RegistrationTokenFATE{}).Where("name = ? AND federation_uuid = ?", token.Name, token.FederationUUID).Count(&count)
+	if count > 0 {
+		return ErrTokenExist
+	}
+
+	if err := db.Create(token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *RegistrationTokenFATERepo) ListByFederation(federationUUID string) (interface{}, error) {
+	var tokens []entity.RegistrationTokenFATE
+	err := db.Where("federation_uuid = ?", federationUUID).Find(&tokens).Error
+	if err != nil {
+		return 0, err
+	}
+	return tokens, nil
+}
+
+func (r *RegistrationTokenFATERepo) DeleteByFederation(federationUUID string) error {
+	return db.Unscoped().Where("federation_uuid = ?", federationUUID).Delete(&entity.RegistrationTokenFATE{}).Error
+}
+
+func (r *RegistrationTokenFATERepo) DeleteByUUID(uuid string) error {
+	return db.Unscoped().Where("uuid = ?", uuid).Delete(&entity.RegistrationTokenFATE{}).Error
+}
+
+func (r *RegistrationTokenFATERepo) GetByUUID(uuid string) (interface{}, error) {
+	token := &entity.RegistrationTokenFATE{}
+	if err := db.Where("uuid = ?", uuid).First(token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *RegistrationTokenFATERepo) LoadByTypeAndStr(instance interface{}) error {
+	token := instance.(*entity.RegistrationTokenFATE)
+	if err := db.Where("token_type = ? AND token_str = ?", token.TokenType, token.TokenStr).First(token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *RegistrationTokenFATERepo) UpdateDisabledByUUID(uuid string, disabled bool) error {
+	return db.Model(&entity.RegistrationTokenFATE{}).Where("uuid = ?", uuid).Update("disabled", disabled).Error
+}
+
+func (r *RegistrationTokenFATERepo) UpdateTokenStrByUUID(uuid string, tokenStr string) error {
+	return db.Model(&entity.RegistrationTokenFATE{}).Where("uuid = ?", uuid).Update("token_str", tokenStr).Error
+}
+
+// InitTable makes sure the table is created in the db
+func (r *RegistrationTokenFATERepo) InitTable() {
+	if err := db.AutoMigrate(entity.RegistrationTokenFATE{}); err != nil {
+		panic(err)
+	}
+}
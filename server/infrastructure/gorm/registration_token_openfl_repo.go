@@ -75,6 +75,14 @@ func (r *RegistrationTokenOpenFLRepo) LoadByTypeAndStr(instance interface{}) err
 	return nil
 }
 
+func (r *RegistrationTokenOpenFLRepo) UpdateDisabledByUUID(uuid string, disabled bool) error {
+	return db.Model(&entity.RegistrationTokenOpenFL{}).Where("uuid = ?", uuid).Update("disabled", disabled).Error
+}
+
+func (r *RegistrationTokenOpenFLRepo) UpdateTokenStrByUUID(uuid string, tokenStr string) error {
+	return db.Model(&entity.RegistrationTokenOpenFL{}).Where("uuid = ?", uuid).Update("token_str", tokenStr).Error
+}
+
 // InitTable makes sure the table is created in the db
 func (r *RegistrationTokenOpenFLRepo) InitTable() {
 	if err := db.AutoMigrate(entity.RegistrationTokenOpenFL{}); err != nil {
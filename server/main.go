@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/FederatedAI/FedLCM/server/api"
 	"github.com/FederatedAI/FedLCM/server/constants"
+	"github.com/FederatedAI/FedLCM/server/controller"
 	"github.com/FederatedAI/FedLCM/server/infrastructure/gorm"
 	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/utils/logging"
 	"github.com/gin-contrib/logger"
@@ -30,6 +32,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	ctrl "sigs.k8s.io/controller-runtime"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -146,12 +149,21 @@ func initRouter(r *gin.Engine) {
 		federationFATERepo.InitTable()
 		federationOpenFLRepo := &gorm.FederationOpenFLRepo{}
 		federationOpenFLRepo.InitTable()
+		federationOpenFLShardDescriptorRepo := &gorm.FederationOpenFLShardDescriptorRepo{}
+		federationOpenFLShardDescriptorRepo.InitTable()
+		federationFATEDiagnosticsRepo := &gorm.FederationFATEDiagnosticsRepo{}
+		federationFATEDiagnosticsRepo.InitTable()
 
 		// participant management
 		participantFATETRepo := &gorm.ParticipantFATERepo{}
 		participantFATETRepo.InitTable()
 		participantOpenFLRepo := &gorm.ParticipantOpenFLRepo{}
 		participantOpenFLRepo.InitTable()
+		participantOpenFLBatchOperationRepo := &gorm.ParticipantOpenFLBatchOperationRepo{}
+		participantOpenFLBatchOperationRepo.InitTable()
+		participantFATESizingProfileRepo := &gorm.ParticipantFATESizingProfileRepo{}
+		participantFATESizingProfileRepo.InitTable()
+		participantFATESizingProfileRepo.InitData()
 
 		// certificate management
 		certificateAuthorityRepo := &gorm.CertificateAuthorityRepo{}
@@ -168,15 +180,70 @@ func initRouter(r *gin.Engine) {
 		// Registration token management
 		registrationTokenOpenFLRepo := &gorm.RegistrationTokenOpenFLRepo{}
 		registrationTokenOpenFLRepo.InitTable()
+		registrationTokenFATERepo := &gorm.RegistrationTokenFATERepo{}
+		registrationTokenFATERepo.InitTable()
+		registrationRecordRepo := &gorm.RegistrationRecordRepo{}
+		registrationRecordRepo.InitTable()
 
 		api.NewInfraProviderController(infraProviderKubernetesRepo, endpointKubeFATERepo).Route(v1)
-		api.NewEndpointController(infraProviderKubernetesRepo, endpointKubeFATERepo, participantFATETRepo, participantOpenFLRepo, eventRepo).Route(v1)
+		endpointController := api.NewEndpointController(infraProviderKubernetesRepo, endpointKubeFATERepo, participantFATETRepo, participantOpenFLRepo, eventRepo)
+		endpointController.Route(v1)
+		if healthCheckEnabled, _ := strconv.ParseBool(viper.GetString("lifecyclemanager.endpoint.healthcheck.enabled")); healthCheckEnabled {
+			interval := viper.GetDuration("lifecyclemanager.endpoint.healthcheck.interval")
+			if interval <= 0 {
+				interval = time.Minute * 5
+			}
+			autoRepair, _ := strconv.ParseBool(viper.GetString("lifecyclemanager.endpoint.healthcheck.autorepair"))
+			endpointController.StartHealthMonitor(interval, autoRepair)
+		}
 		api.NewFederationController(infraProviderKubernetesRepo, endpointKubeFATERepo,
 			federationFATERepo, federationOpenFLRepo, chartRepo, participantFATETRepo, participantOpenFLRepo, certificateAuthorityRepo,
-			certificateRepo, certificateBindingRepo, registrationTokenOpenFLRepo, eventRepo).Route(v1)
-
-		api.NewCertificateAuthorityController(certificateAuthorityRepo).Route(v1)
-		api.NewCertificateController(certificateAuthorityRepo, certificateRepo, certificateBindingRepo, participantFATETRepo, participantOpenFLRepo, federationFATERepo, federationOpenFLRepo).Route(v1)
+			certificateRepo, certificateBindingRepo, registrationTokenOpenFLRepo,
+			registrationTokenFATERepo, registrationRecordRepo, participantOpenFLBatchOperationRepo,
+			federationOpenFLShardDescriptorRepo, participantFATESizingProfileRepo, federationFATEDiagnosticsRepo, eventRepo).Route(v1)
+
+		api.NewCertificateAuthorityController(certificateAuthorityRepo, certificateRepo).Route(v1)
+		certificateController := api.NewCertificateController(certificateAuthorityRepo, certificateRepo, certificateBindingRepo, participantFATETRepo, participantOpenFLRepo,
+			federationFATERepo, federationOpenFLRepo, infraProviderKubernetesRepo, endpointKubeFATERepo, eventRepo)
+		certificateController.Route(v1)
+		if autoRotationEnabled, _ := strconv.ParseBool(viper.GetString("lifecyclemanager.certificate.autorotation.enabled")); autoRotationEnabled {
+			interval := viper.GetDuration("lifecyclemanager.certificate.autorotation.interval")
+			if interval <= 0 {
+				interval = time.Hour * 12
+			}
+			renewBefore := viper.GetDuration("lifecyclemanager.certificate.autorotation.renewbefore")
+			if renewBefore <= 0 {
+				renewBefore = time.Hour * 24 * 30
+			}
+			certificateController.StartAutoRotation(interval, renewBefore)
+		}
 		api.NewEventController(eventRepo).Route(v1)
+
+		if controllerEnabled, _ := strconv.ParseBool(viper.GetString("lifecyclemanager.controller.enabled")); controllerEnabled {
+			reconciler := controller.NewReconciler(infraProviderKubernetesRepo, endpointKubeFATERepo,
+				federationFATERepo, federationOpenFLRepo, chartRepo, participantFATETRepo, participantOpenFLRepo, certificateAuthorityRepo,
+				certificateRepo, certificateBindingRepo, registrationTokenOpenFLRepo,
+				registrationTokenFATERepo, registrationRecordRepo, participantOpenFLBatchOperationRepo,
+				federationOpenFLShardDescriptorRepo, participantFATESizingProfileRepo, eventRepo)
+			reconciler.PollInterval = viper.GetDuration("lifecyclemanager.controller.pollinterval")
+			reconciler.ResyncInterval = viper.GetDuration("lifecyclemanager.controller.resyncinterval")
+			leaderElection, _ := strconv.ParseBool(viper.GetString("lifecyclemanager.controller.leaderelection"))
+			options := controller.Options{
+				Namespace:               viper.GetString("lifecyclemanager.controller.namespace"),
+				LeaderElection:          leaderElection,
+				LeaderElectionNamespace: viper.GetString("lifecyclemanager.controller.leaderelectionnamespace"),
+			}
+			// the management cluster is the one in the kubeconfig file specified by the KUBECONFIG env, or the one the
+			// service is running in
+			config, err := ctrl.GetConfig()
+			if err != nil {
+				panic(err)
+			}
+			go func() {
+				if err := reconciler.Start(context.Background(), config, options); err != nil {
+					log.Error().Err(err).Msg("custom resource controller stopped")
+				}
+			}()
+		}
 	}
 }
@@ -0,0 +1,304 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/application/service"
+	"github.com/FederatedAI/FedLCM/site-portal/server/constants"
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// auditLogUnverifiedRemoteSite is the actor of an internal request without a verified client certificate
+	auditLogUnverifiedRemoteSite = "unverified"
+	// auditLogSummaryLimit is the max length of the request summary and the captured response body
+	auditLogSummaryLimit = 4096
+	// auditLogRedacted replaces the value of sensitive fields in the request summary
+	auditLogRedacted = "******"
+)
+
+var auditLogSensitiveFieldRegexp = regexp.MustCompile(`(?i)password|passwd|secret|token|private_key|kubeconfig`)
+
+// AuditLogController handles audit log related APIs
+type AuditLogController struct {
+	auditLogApp *service.AuditLogApp
+}
+
+// NewAuditLogController returns a controller instance to handle audit log API requests
+func NewAuditLogController(auditLogRepo repo.AuditLogRepository) *AuditLogController {
+	return &AuditLogController{
+		auditLogApp: &service.AuditLogApp{
+			AuditLogRepo: auditLogRepo,
+		},
+	}
+}
+
+// Route set up route mappings to audit log related APIs
+func (controller *AuditLogController) Route(r *gin.RouterGroup) {
+	auditLog := r.Group("audit-log")
+	auditLog.Use(authMiddleware.MiddlewareFunc())
+	{
+		auditLog.GET("", controller.list)
+		auditLog.GET("/export", controller.export)
+	}
+}
+
+// list returns the audit logs matching the filters
+//	@Summary	List audit logs, newest first
+//	@Tags		AuditLog
+//	@Produce	json
+//	@Param		actor_type	query		int											false	"1: user, 2: remote site"
+//	@Param		actor		query		string										false	"Username or remote site UUID"
+//	@Param		action		query		string										false	"Part of the action, e.g. approve"
+//	@Param		target_type	query		string										false	"Target type, e.g. job, project, data, user"
+//	@Param		target_uuid	query		string										false	"Target UUID"
+//	@Param		outcome		query		int											false	"1: succeeded, 2: failed"
+//	@Param		start_time	query		string										false	"RFC3339 formatted start time"
+//	@Param		end_time	query		string										false	"RFC3339 formatted end time"
+//	@Param		page		query		int											false	"1-based page number, default to 1"
+//	@Param		page_size	query		int											false	"Number of logs in a page, default to 20, max 100"
+//	@Success	200			{object}	GeneralResponse{data=service.AuditLogList}	"Success"
+//	@Failure	401			{object}	GeneralResponse								"Unauthorized operation"
+//	@Failure	500			{object}	GeneralResponse{code=int}					"Internal server error"
+//	@Router		/audit-log [get]
+func (controller *AuditLogController) list(c *gin.Context) {
+	if logList, err := func() (*service.AuditLogList, error) {
+		query := &service.AuditLogQuery{}
+		if err := c.ShouldBindQuery(query); err != nil {
+			return nil, err
+		}
+		return controller.auditLogApp.List(query)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: logList,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// export returns a file containing the audit logs matching the filters
+//	@Summary	Export audit logs as a CSV or JSON file
+//	@Tags		AuditLog
+//	@Produce	octet-stream
+//	@Param		format		query		string						false	"csv or json, default to csv"
+//	@Param		actor_type	query		int							false	"1: user, 2: remote site"
+//	@Param		actor		query		string						false	"Username or remote site UUID"
+//	@Param		action		query		string						false	"Part of the action, e.g. approve"
+//	@Param		target_type	query		string						false	"Target type, e.g. job, project, data, user"
+//	@Param		target_uuid	query		string						false	"Target UUID"
+//	@Param		outcome		query		int							false	"1: succeeded, 2: failed"
+//	@Param		start_time	query		string						false	"RFC3339 formatted start time"
+//	@Param		end_time	query		string						false	"RFC3339 formatted end time"
+//	@Failure	401			{object}	GeneralResponse				"Unauthorized operation"
+//	@Failure	500			{object}	GeneralResponse{code=int}	"Internal server error"
+//	@Router		/audit-log/export [get]
+func (controller *AuditLogController) export(c *gin.Context) {
+	format := service.AuditLogExportFormat(c.DefaultQuery("format", string(service.AuditLogExportFormatCSV)))
+	if content, err := func() ([]byte, error) {
+		query := &service.AuditLogQuery{}
+		if err := c.ShouldBindQuery(query); err != nil {
+			return nil, err
+		}
+		return controller.auditLogApp.Export(query, format)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		contentType := "text/csv"
+		if format == service.AuditLogExportFormatJSON {
+			contentType = "application/json"
+		}
+		fileName := fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102150405"), format)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+		c.Data(http.StatusOK, contentType, content)
+	}
+}
+
+// CreateAuditLogMiddleware returns a middleware that records the state-changing requests and data downloads
+func CreateAuditLogMiddleware(auditLogRepo repo.AuditLogRepository) gin.HandlerFunc {
+	auditLogApp := &service.AuditLogApp{
+		AuditLogRepo: auditLogRepo,
+	}
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" || !shouldAuditRequest(c.Request.Method, route) {
+			c.Next()
+			return
+		}
+		requestFields := readAuditLogRequestFields(c)
+		writer := &auditLogResponseWriter{
+			ResponseWriter: c.Writer,
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		request := &service.AuditLogRecordRequest{
+			Action:         c.Request.Method + " " + strings.TrimPrefix(route, "/api/"+constants.APIVersion),
+			TargetType:     getAuditLogTargetType(route),
+			TargetUUID:     c.Param("uuid"),
+			RequestSummary: buildAuditLogRequestSummary(c, requestFields),
+			Succeeded:      writer.Status() < http.StatusBadRequest,
+		}
+		if request.TargetUUID == "" {
+			request.TargetUUID = c.Param("id")
+		}
+		if strings.Contains(route, "/internal/") {
+			request.ActorType = entity.AuditLogActorTypeRemoteSite
+			request.Actor = getAuditLogRemoteSite(c)
+		} else {
+			request.ActorType = entity.AuditLogActorTypeUser
+			if username, ok := jwt.ExtractClaims(c)[nameKey].(string); ok {
+				request.Actor = username
+			} else if username, ok := requestFields["username"].(string); ok {
+				// for requests like logging in
+				request.Actor = username
+			}
+		}
+		if !request.Succeeded {
+			response := &GeneralResponse{}
+			if err := json.Unmarshal(writer.body.Bytes(), response); err == nil {
+				request.OutcomeMessage = response.Message
+			} else {
+				request.OutcomeMessage = http.StatusText(writer.Status())
+			}
+		}
+		if err := auditLogApp.Record(request); err != nil {
+			log.Err(err).Str("action", request.Action).Msg("failed to record audit log")
+		}
+	}
+}
+
+// shouldAuditRequest returns whether the request changes any state or downloads data
+func shouldAuditRequest(method, route string) bool {
+	if method != http.MethodGet {
+		return true
+	}
+	return strings.Contains(route, "download") || strings.HasSuffix(route, "/file") || strings.HasSuffix(route, "/export")
+}
+
+// getAuditLogTargetType returns the first segment of the route after the API version, e.g. "job"
+func getAuditLogTargetType(route string) string {
+	route = strings.TrimPrefix(route, "/api/"+constants.APIVersion+"/")
+	return strings.Split(route, "/")[0]
+}
+
+// getAuditLogRemoteSite returns the common name of the verified client certificate of an internal request,
+// fields in the request body or headers are not used as they can be set by the caller freely
+func getAuditLogRemoteSite(c *gin.Context) string {
+	if clientCert := getVerifiedClientCertificate(c); clientCert != nil {
+		return clientCert.Subject.CommonName
+	}
+	return auditLogUnverifiedRemoteSite
+}
+
+// readAuditLogRequestFields returns the fields in the JSON request body, the body is kept for the later handlers
+func readAuditLogRequestFields(c *gin.Context) map[string]interface{} {
+	if c.ContentType() != gin.MIMEJSON || c.Request.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Err(err).Msg("failed to read request body for audit log")
+		return nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		// not a JSON object, e.g. a list of data
+		return map[string]interface{}{
+			"body": string(body),
+		}
+	}
+	return fields
+}
+
+// buildAuditLogRequestSummary returns the route params and the request fields with sensitive values removed
+func buildAuditLogRequestSummary(c *gin.Context, fields map[string]interface{}) string {
+	summary := map[string]interface{}{}
+	if len(c.Params) > 0 {
+		params := map[string]string{}
+		for _, param := range c.Params {
+			params[param.Key] = param.Value
+		}
+		summary["params"] = params
+	}
+	if len(fields) > 0 {
+		summary["body"] = redactAuditLogFields(fields)
+	}
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return ""
+	}
+	if len(summaryBytes) > auditLogSummaryLimit {
+		return string(summaryBytes[:auditLogSummaryLimit]) + "..."
+	}
+	return string(summaryBytes)
+}
+
+// redactAuditLogFields replaces the values of sensitive fields recursively
+func redactAuditLogFields(fields map[string]interface{}) map[string]interface{} {
+	redacted := map[string]interface{}{}
+	for key, value := range fields {
+		if auditLogSensitiveFieldRegexp.MatchString(key) {
+			redacted[key] = auditLogRedacted
+			continue
+		}
+		if nestedFields, ok := value.(map[string]interface{}); ok {
+			redacted[key] = redactAuditLogFields(nestedFields)
+		} else {
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+// auditLogResponseWriter keeps the beginning of the response body to retrieve the error message
+type auditLogResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditLogResponseWriter) Write(data []byte) (int, error) {
+	if remaining := auditLogSummaryLimit - w.body.Len(); remaining > 0 {
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
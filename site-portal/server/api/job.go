@@ -37,7 +37,8 @@ func NewJobController(jobRepo repo.JobRepository,
 	projectRepo repo.ProjectRepository,
 	siteRepo repo.SiteRepository,
 	projectDataRepo repo.ProjectDataRepository,
-	modelRepo repo.ModelRepository) *JobController {
+	modelRepo repo.ModelRepository,
+	auditLogRepo repo.AuditLogRepository) *JobController {
 	return &JobController{
 		jobApp: &service.JobApp{
 			SiteRepo:        siteRepo,
@@ -46,6 +47,7 @@ func NewJobController(jobRepo repo.JobRepository,
 			ProjectRepo:     projectRepo,
 			ProjectDataRepo: projectDataRepo,
 			ModelRepo:       modelRepo,
+			AuditLogRepo:    auditLogRepo,
 		},
 	}
 }
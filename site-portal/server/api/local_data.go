@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"path/filepath"
+	"strconv"
 
 	"github.com/FederatedAI/FedLCM/site-portal/server/application/service"
 	"github.com/FederatedAI/FedLCM/site-portal/server/constants"
@@ -34,13 +35,15 @@ type LocalDataController struct {
 func NewLocalDataController(localDataRepo repo.LocalDataRepository,
 	siteRepo repo.SiteRepository,
 	projectRepo repo.ProjectRepository,
-	projectDataRepo repo.ProjectDataRepository) *LocalDataController {
+	projectDataRepo repo.ProjectDataRepository,
+	uploadSessionRepo repo.LocalDataUploadSessionRepository) *LocalDataController {
 	return &LocalDataController{
 		localDataApp: &service.LocalDataApp{
-			LocalDataRepo:   localDataRepo,
-			SiteRepo:        siteRepo,
-			ProjectRepo:     projectRepo,
-			ProjectDataRepo: projectDataRepo,
+			LocalDataRepo:     localDataRepo,
+			SiteRepo:          siteRepo,
+			ProjectRepo:       projectRepo,
+			ProjectDataRepo:   projectDataRepo,
+			UploadSessionRepo: uploadSessionRepo,
 		},
 	}
 }
@@ -52,20 +55,28 @@ func (controller *LocalDataController) Route(r *gin.RouterGroup) {
 	{
 		data.POST("", controller.upload)
 		data.POST("associate", controller.associate)
+		data.POST("import", controller.importFromURI)
+		data.POST("upload-session", controller.createUploadSession)
+		data.GET("upload-session/:uuid", controller.getUploadSession)
+		data.PUT("upload-session/:uuid/part/:index", controller.uploadPart)
+		data.POST("upload-session/:uuid/complete", controller.completeUploadSession)
+		data.DELETE("upload-session/:uuid", controller.abortUploadSession)
 		data.GET("", controller.list)
 		data.GET("/:uuid", controller.get)
 		data.GET("/:uuid/columns", controller.getColumns)
 		data.GET("/:uuid/file", controller.download)
 		data.DELETE("/:uuid", controller.delete)
 		data.PUT("/:uuid/idmetainfo", controller.putIdMetaInfo)
+		data.GET("/:uuid/profile", controller.getProfile)
+		data.POST("/:uuid/profile", controller.generateProfile)
 	}
 }
 
 // upload uploads a local csv data
-//	@Summary	Upload a local csv data
+//	@Summary	Upload a local data file in csv, Parquet (.parquet) or JSON Lines (.jsonl) format
 //	@Tags		LocalData
 //	@Produce	json
-//	@Param		file		formData	file						true	"The csv file"
+//	@Param		file		formData	file						true	"The data file"
 //	@Param		name		formData	string						true	"Data name"
 //	@Param		description	formData	string						true	"Data description"
 //	@Success	200			{object}	GeneralResponse{}			"Success, the data field is the data UUID"
@@ -296,3 +307,233 @@ func (controller *LocalDataController) associate(c *gin.Context) {
 		c.JSON(http.StatusOK, resp)
 	}
 }
+
+// importFromURI creates a data by importing the file from external storages
+//	@Summary	Import a csv data from S3-compatible storage or HDFS, the data is imported asynchronously
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		request	body		service.LocalDataImportRequest	true	"The data name, description, uri and the storage connection info"
+//	@Success	200		{object}	GeneralResponse{}				"Success, the data field is the data UUID"
+//	@Failure	401		{object}	GeneralResponse					"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}		"Internal server error"
+//	@Router		/data/import [post]
+func (controller *LocalDataController) importFromURI(c *gin.Context) {
+	if uuid, err := func() (string, error) {
+		request := &service.LocalDataImportRequest{}
+		if err := c.ShouldBindJSON(request); err != nil {
+			return "", err
+		}
+		return controller.localDataApp.ImportFromURI(request)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: uuid,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// createUploadSession starts a chunked upload session
+//	@Summary	Start a resumable chunked upload session for a large csv data
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		request	body		service.LocalDataUploadSessionCreationRequest				true	"The data name, description and the file info"
+//	@Success	200		{object}	GeneralResponse{data=service.LocalDataUploadSessionInfo}	"Success"
+//	@Failure	401		{object}	GeneralResponse												"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}									"Internal server error"
+//	@Router		/data/upload-session [post]
+func (controller *LocalDataController) createUploadSession(c *gin.Context) {
+	if session, err := func() (*service.LocalDataUploadSessionInfo, error) {
+		request := &service.LocalDataUploadSessionCreationRequest{}
+		if err := c.ShouldBindJSON(request); err != nil {
+			return nil, err
+		}
+		return controller.localDataApp.CreateUploadSession(request)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: session,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getUploadSession returns the upload session info
+//	@Summary	Get the upload session info, including the uploaded chunks
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		uuid	path		string														true	"Upload session UUID"
+//	@Success	200		{object}	GeneralResponse{data=service.LocalDataUploadSessionInfo}	"Success"
+//	@Failure	401		{object}	GeneralResponse												"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}									"Internal server error"
+//	@Router		/data/upload-session/{uuid} [get]
+func (controller *LocalDataController) getUploadSession(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if session, err := controller.localDataApp.GetUploadSession(uuid); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: session,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// uploadPart uploads one chunk of the data file
+//	@Summary	Upload one chunk of the data file, the request body is the raw chunk content
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		uuid		path		string						true	"Upload session UUID"
+//	@Param		index		path		int							true	"Chunk index, starting from 0"
+//	@Param		checksum	query		string						true	"Hex-encoded SHA256 checksum of the chunk"
+//	@Success	200			{object}	GeneralResponse				"Success"
+//	@Failure	401			{object}	GeneralResponse				"Unauthorized operation"
+//	@Failure	500			{object}	GeneralResponse{code=int}	"Internal server error"
+//	@Router		/data/upload-session/{uuid}/part/{index} [put]
+func (controller *LocalDataController) uploadPart(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if err := func() error {
+		index, err := strconv.ParseUint(c.Param("index"), 10, 32)
+		if err != nil {
+			return err
+		}
+		return controller.localDataApp.UploadPart(uuid, uint(index), c.Request.Body, c.Query("checksum"))
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// completeUploadSession creates the data from the uploaded chunks
+//	@Summary	Complete the upload session, the data is created and imported asynchronously
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		uuid	path		string						true	"Upload session UUID"
+//	@Success	200		{object}	GeneralResponse{}			"Success, the data field is the data UUID"
+//	@Failure	401		{object}	GeneralResponse				"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}	"Internal server error"
+//	@Router		/data/upload-session/{uuid}/complete [post]
+func (controller *LocalDataController) completeUploadSession(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if dataUUID, err := controller.localDataApp.CompleteUploadSession(uuid); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: dataUUID,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// abortUploadSession removes the upload session
+//	@Summary	Abort the upload session and remove the uploaded chunks
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		uuid	path		string						true	"Upload session UUID"
+//	@Success	200		{object}	GeneralResponse				"Success"
+//	@Failure	401		{object}	GeneralResponse				"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}	"Internal server error"
+//	@Router		/data/upload-session/{uuid} [delete]
+func (controller *LocalDataController) abortUploadSession(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if err := controller.localDataApp.AbortUploadSession(uuid); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getProfile returns the aggregated statistics of the data
+//	@Summary	Get the profile of a local data or a data from other sites associated in a joined project
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		uuid	path		string											true	"Data UUID"
+//	@Success	200		{object}	GeneralResponse{data=entity.LocalDataProfile}	"Success"
+//	@Failure	401		{object}	GeneralResponse									"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}						"Internal server error"
+//	@Router		/data/{uuid}/profile [get]
+func (controller *LocalDataController) getProfile(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if profile, err := controller.localDataApp.GetProfile(uuid); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: profile,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// generateProfile re-generates the profile of the data
+//	@Summary	Re-generate the profile of a local data asynchronously, optionally with a different label column
+//	@Tags		LocalData
+//	@Produce	json
+//	@Param		uuid	path		string										true	"Data UUID"
+//	@Param		request	body		service.LocalDataProfileGenerationRequest	true	"The profile generation options"
+//	@Success	200		{object}	GeneralResponse								"Success"
+//	@Failure	401		{object}	GeneralResponse								"Unauthorized operation"
+//	@Failure	500		{object}	GeneralResponse{code=int}					"Internal server error"
+//	@Router		/data/{uuid}/profile [post]
+func (controller *LocalDataController) generateProfile(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if err := func() error {
+		request := &service.LocalDataProfileGenerationRequest{}
+		if err := c.ShouldBindJSON(request); err != nil {
+			return err
+		}
+		return controller.localDataApp.GenerateProfile(uuid, request)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
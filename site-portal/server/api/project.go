@@ -121,6 +121,8 @@ func (controller *ProjectController) Route(r *gin.RouterGroup) {
 		project.GET("/:uuid/data", controller.listData)
 		project.GET("/:uuid/data/local", controller.listLocalAvailableData)
 		project.DELETE("/:uuid/data/:dataUUID", controller.removeData)
+		project.GET("/:uuid/data/:dataUUID/policy", controller.getDataUsagePolicy)
+		project.PUT("/:uuid/data/:dataUUID/policy", controller.updateDataUsagePolicy)
 
 		project.GET("/:uuid/job", controller.listJob)
 		project.POST("/:uuid/job", controller.submitJob)
@@ -805,6 +807,70 @@ func (controller *ProjectController) removeData(c *gin.Context) {
 	}
 }
 
+// getDataUsagePolicy returns the usage policy of an associated local data
+//	@Summary	Get the usage policy of an associated local data
+//	@Tags		Project
+//	@Produce	json
+//	@Param		uuid		path		string													true	"Project UUID"
+//	@Param		dataUUID	path		string													true	"Data UUID"
+//	@Success	200			{object}	GeneralResponse{data=entity.ProjectDataUsagePolicy}	"Success"
+//	@Failure	401			{object}	GeneralResponse											"Unauthorized operation"
+//	@Failure	500			{object}	GeneralResponse{code=int}								"Internal server error"
+//	@Router		/project/{uuid}/data/{dataUUID}/policy [get]
+func (controller *ProjectController) getDataUsagePolicy(c *gin.Context) {
+	if policy, err := func() (*entity.ProjectDataUsagePolicy, error) {
+		projectUUID := c.Param("uuid")
+		dataUUID := c.Param("dataUUID")
+		return controller.projectApp.GetDataUsagePolicy(projectUUID, dataUUID)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+			Data: policy,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// updateDataUsagePolicy changes the usage policy of an associated local data
+//	@Summary	Update the usage policy of an associated local data, the policy is evaluated for jobs initiated by other sites
+//	@Tags		Project
+//	@Produce	json
+//	@Param		uuid		path		string							true	"Project UUID"
+//	@Param		dataUUID	path		string							true	"Data UUID"
+//	@Param		policy		body		entity.ProjectDataUsagePolicy	true	"The usage policy"
+//	@Success	200			{object}	GeneralResponse{}				"Success"
+//	@Failure	401			{object}	GeneralResponse					"Unauthorized operation"
+//	@Failure	500			{object}	GeneralResponse{code=int}		"Internal server error"
+//	@Router		/project/{uuid}/data/{dataUUID}/policy [put]
+func (controller *ProjectController) updateDataUsagePolicy(c *gin.Context) {
+	if err := func() error {
+		projectUUID := c.Param("uuid")
+		dataUUID := c.Param("dataUUID")
+		policy := &entity.ProjectDataUsagePolicy{}
+		if err := c.ShouldBindJSON(policy); err != nil {
+			return err
+		}
+		return controller.projectApp.UpdateDataUsagePolicy(projectUUID, dataUUID, policy)
+	}(); err != nil {
+		resp := &GeneralResponse{
+			Code:    constants.RespInternalErr,
+			Message: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+	} else {
+		resp := &GeneralResponse{
+			Code: constants.RespNoErr,
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 // handleRemoteDataAssociation associate remote data
 //	@Summary	Add associated remote data to current project, called by FML manager only
 //	@Tags		Project
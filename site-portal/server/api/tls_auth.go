@@ -15,24 +15,119 @@
 package api
 
 import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/constants"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
+// CertRevocationChecker checks if a certificate has been revoked
+type CertRevocationChecker interface {
+	IsRevoked(cert *x509.Certificate) bool
+}
+
+// certRevocationChecker is used to check the caller's certificate, nil means the check is disabled
+var certRevocationChecker CertRevocationChecker
+
+// clientCAPool contains the CA certificates to verify the caller's certificate forwarded by the frontend
+var clientCAPool *x509.CertPool
+
+// SetClientCAPool sets the CA certificates used to verify the caller's certificate forwarded by the frontend
+func SetClientCAPool(pool *x509.CertPool) {
+	clientCAPool = pool
+}
+
+// SetCertRevocationChecker enables the revocation check of the caller's certificate using the specified checker
+func SetCertRevocationChecker(checker CertRevocationChecker) {
+	certRevocationChecker = checker
+}
+
 // certAuthenticator is used to further check the caller's certificate
 func certAuthenticator() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		clientCommonName := c.GetHeader("X-SP-CLIENT-SDN")
 		clientVerify := c.GetHeader("X-SP-CLIENT-VERIFY")
-		// clientCert := c.GetHeader("X-SP-CLIENT-CERT")
 
 		log.Info().Msgf("Request URL: %s", c.Request.URL.String())
 		log.Info().Msgf("Client common name in X-SP-CLIENT-SDN is: %s", clientCommonName)
 		log.Info().Msgf("Client verify result in X-SP-CLIENT-VERIFY is: %s", clientVerify)
 
+		if certRevocationChecker != nil {
+			for _, clientCert := range getClientCertificates(c) {
+				if certRevocationChecker.IsRevoked(clientCert) {
+					log.Warn().Msgf("Client certificate of %s with serial number %s has been revoked", clientCert.Subject.CommonName, clientCert.SerialNumber.String())
+					c.AbortWithStatusJSON(http.StatusUnauthorized, &GeneralResponse{
+						Code:    constants.RespInternalErr,
+						Message: "client certificate has been revoked",
+					})
+					return
+				}
+			}
+		}
+
 		// TODO: validate the clientCommonName, like if its domain is same to the sitePortalCommonName's domain
 
 		c.Next()
 	}
 }
+
+// getClientCertificates returns the caller's certificate forwarded by the frontend in X-SP-CLIENT-CERT,
+// and the one used in the TLS connection to this service
+func getClientCertificates(c *gin.Context) []*x509.Certificate {
+	var certs []*x509.Certificate
+	if cert := getForwardedClientCertificate(c); cert != nil {
+		certs = append(certs, cert)
+	}
+	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+		certs = append(certs, c.Request.TLS.PeerCertificates[0])
+	}
+	return certs
+}
+
+// getVerifiedClientCertificate returns the caller's certificate if the request comes from a TLS connection whose
+// peer certificate has been verified. The certificate forwarded by the frontend is preferred, and it is used only
+// if it can be verified by the CA as well. nil is returned if no certificate is verified.
+func getVerifiedClientCertificate(c *gin.Context) *x509.Certificate {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+	if c.GetHeader("X-SP-CLIENT-CERT") == "" {
+		return c.Request.TLS.VerifiedChains[0][0]
+	}
+	cert := getForwardedClientCertificate(c)
+	if cert == nil || clientCAPool == nil {
+		return nil
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     clientCAPool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		log.Err(err).Msgf("failed to verify the forwarded client certificate of %s", cert.Subject.CommonName)
+		return nil
+	}
+	return cert
+}
+
+// getForwardedClientCertificate returns the caller's certificate forwarded by the frontend in X-SP-CLIENT-CERT
+func getForwardedClientCertificate(c *gin.Context) *x509.Certificate {
+	clientCertPEM, err := url.QueryUnescape(c.GetHeader("X-SP-CLIENT-CERT"))
+	if err != nil {
+		log.Err(err).Msg("failed to decode X-SP-CLIENT-CERT")
+		return nil
+	}
+	block, _ := pem.Decode([]byte(clientCertPEM))
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		log.Err(err).Msg("failed to parse the certificate in X-SP-CLIENT-CERT")
+		return nil
+	}
+	return cert
+}
@@ -0,0 +1,235 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"github.com/pkg/errors"
+)
+
+const (
+	// auditLogDefaultPageSize is the number of logs returned in a page if the page size is not specified
+	auditLogDefaultPageSize = 20
+	// auditLogMaxPageSize is the max number of logs returned in a page
+	auditLogMaxPageSize = 100
+)
+
+// AuditLogApp provides functions to record and query audit logs
+type AuditLogApp struct {
+	AuditLogRepo repo.AuditLogRepository
+}
+
+// AuditLogRecordRequest contains the info of an action to be recorded
+type AuditLogRecordRequest struct {
+	ActorType      entity.AuditLogActorType
+	Actor          string
+	Action         string
+	TargetType     string
+	TargetUUID     string
+	RequestSummary string
+	Succeeded      bool
+	OutcomeMessage string
+}
+
+// AuditLogQuery contains the filters for querying the audit logs
+type AuditLogQuery struct {
+	ActorType  entity.AuditLogActorType `form:"actor_type"`
+	Actor      string                   `form:"actor"`
+	Action     string                   `form:"action"`
+	TargetType string                   `form:"target_type"`
+	TargetUUID string                   `form:"target_uuid"`
+	Outcome    entity.AuditLogOutcome   `form:"outcome"`
+	StartTime  time.Time                `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime    time.Time                `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Page is the 1-based page number of the list, it is ignored by the export
+	Page int `form:"page"`
+	// PageSize is the number of logs in a page, it is ignored by the export
+	PageSize int `form:"page_size"`
+}
+
+// AuditLogList is a page of the audit logs matching the query
+type AuditLogList struct {
+	// Total is the number of all the matched logs
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Items    []AuditLogItem `json:"items"`
+}
+
+// AuditLogItem is the audit log info for displaying or exporting
+type AuditLogItem struct {
+	UUID           string                   `json:"uuid"`
+	Time           time.Time                `json:"time"`
+	ActorType      entity.AuditLogActorType `json:"actor_type"`
+	ActorTypeStr   string                   `json:"actor_type_str"`
+	Actor          string                   `json:"actor"`
+	Action         string                   `json:"action"`
+	TargetType     string                   `json:"target_type"`
+	TargetUUID     string                   `json:"target_uuid"`
+	RequestSummary string                   `json:"request_summary"`
+	Outcome        entity.AuditLogOutcome   `json:"outcome"`
+	OutcomeStr     string                   `json:"outcome_str"`
+	OutcomeMessage string                   `json:"outcome_message"`
+}
+
+// AuditLogExportFormat is the file format of the exported logs
+type AuditLogExportFormat string
+
+const (
+	AuditLogExportFormatJSON AuditLogExportFormat = "json"
+	AuditLogExportFormatCSV  AuditLogExportFormat = "csv"
+)
+
+// Record saves a new audit log
+func (app *AuditLogApp) Record(request *AuditLogRecordRequest) error {
+	auditLog := &entity.AuditLog{
+		ActorType:      request.ActorType,
+		Actor:          request.Actor,
+		Action:         request.Action,
+		TargetType:     request.TargetType,
+		TargetUUID:     request.TargetUUID,
+		RequestSummary: request.RequestSummary,
+		Outcome:        entity.AuditLogOutcomeSucceeded,
+		OutcomeMessage: request.OutcomeMessage,
+		Repo:           app.AuditLogRepo,
+	}
+	if !request.Succeeded {
+		auditLog.Outcome = entity.AuditLogOutcomeFailed
+	}
+	return auditLog.Create()
+}
+
+// List returns a page of the audit logs matching the query
+func (app *AuditLogApp) List(query *AuditLogQuery) (*AuditLogList, error) {
+	page, pageSize := query.Page, query.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = auditLogDefaultPageSize
+	} else if pageSize > auditLogMaxPageSize {
+		pageSize = auditLogMaxPageSize
+	}
+	filter := app.buildFilter(query)
+	total, err := app.AuditLogRepo.CountByFilter(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count audit logs")
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+	itemList, err := app.listItems(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLogList{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    itemList,
+	}, nil
+}
+
+// buildFilter is a helper function to convert the query to the repo filter
+func (app *AuditLogApp) buildFilter(query *AuditLogQuery) *entity.AuditLogFilter {
+	return &entity.AuditLogFilter{
+		ActorType:  query.ActorType,
+		Actor:      query.Actor,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetUUID: query.TargetUUID,
+		Outcome:    query.Outcome,
+		StartTime:  query.StartTime,
+		EndTime:    query.EndTime,
+	}
+}
+
+// listItems is a helper function to return the audit logs matching the filter
+func (app *AuditLogApp) listItems(filter *entity.AuditLogFilter) ([]AuditLogItem, error) {
+	logListInstance, err := app.AuditLogRepo.GetListByFilter(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query audit logs")
+	}
+	logList := logListInstance.([]entity.AuditLog)
+
+	itemList := make([]AuditLogItem, 0)
+	for _, auditLog := range logList {
+		itemList = append(itemList, AuditLogItem{
+			UUID:           auditLog.UUID,
+			Time:           auditLog.CreatedAt,
+			ActorType:      auditLog.ActorType,
+			ActorTypeStr:   auditLog.ActorType.String(),
+			Actor:          auditLog.Actor,
+			Action:         auditLog.Action,
+			TargetType:     auditLog.TargetType,
+			TargetUUID:     auditLog.TargetUUID,
+			RequestSummary: auditLog.RequestSummary,
+			Outcome:        auditLog.Outcome,
+			OutcomeStr:     auditLog.Outcome.String(),
+			OutcomeMessage: auditLog.OutcomeMessage,
+		})
+	}
+	return itemList, nil
+}
+
+// Export returns the content of all the audit logs matching the query in the specified format
+func (app *AuditLogApp) Export(query *AuditLogQuery, format AuditLogExportFormat) ([]byte, error) {
+	itemList, err := app.listItems(app.buildFilter(query))
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case AuditLogExportFormatJSON:
+		return json.MarshalIndent(itemList, "", "  ")
+	case AuditLogExportFormatCSV:
+		return app.buildCSV(itemList)
+	}
+	return nil, errors.Errorf("unsupported export format: %s", format)
+}
+
+// buildCSV is a helper function to convert the logs to csv content
+func (app *AuditLogApp) buildCSV(itemList []AuditLogItem) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	if err := writer.Write([]string{"uuid", "time", "actor_type", "actor", "action", "target_type", "target_uuid",
+		"request_summary", "outcome", "outcome_message"}); err != nil {
+		return nil, err
+	}
+	for _, item := range itemList {
+		if err := writer.Write([]string{
+			item.UUID,
+			item.Time.Format(time.RFC3339),
+			item.ActorTypeStr,
+			item.Actor,
+			item.Action,
+			item.TargetType,
+			item.TargetUUID,
+			item.RequestSummary,
+			item.OutcomeStr,
+			item.OutcomeMessage,
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to write audit log %s", item.UUID)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuditLogRepo struct {
+	logs []entity.AuditLog
+}
+
+func (r *fakeAuditLogRepo) Create(instance interface{}) error {
+	r.logs = append(r.logs, *instance.(*entity.AuditLog))
+	return nil
+}
+
+func (r *fakeAuditLogRepo) GetListByFilter(instance interface{}) (interface{}, error) {
+	filter := instance.(*entity.AuditLogFilter)
+	var logList []entity.AuditLog
+	for _, auditLog := range r.logs {
+		if filter.Actor == "" || filter.Actor == auditLog.Actor {
+			logList = append(logList, auditLog)
+		}
+	}
+	if filter.Offset >= len(logList) {
+		return []entity.AuditLog{}, nil
+	}
+	logList = logList[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(logList) {
+		logList = logList[:filter.Limit]
+	}
+	return logList, nil
+}
+
+func (r *fakeAuditLogRepo) CountByFilter(instance interface{}) (int64, error) {
+	filter := *instance.(*entity.AuditLogFilter)
+	filter.Offset, filter.Limit = 0, 0
+	logList, err := r.GetListByFilter(&filter)
+	return int64(len(logList.([]entity.AuditLog))), err
+}
+
+func TestAuditLogRecordAndExport(t *testing.T) {
+	app := &AuditLogApp{AuditLogRepo: &fakeAuditLogRepo{}}
+	assert.Error(t, app.Record(&AuditLogRecordRequest{}))
+	assert.NoError(t, app.Record(&AuditLogRecordRequest{
+		ActorType:      entity.AuditLogActorTypeUser,
+		Actor:          "Admin",
+		Action:         "POST /job/:uuid/approve",
+		TargetType:     "job",
+		TargetUUID:     "job-uuid",
+		RequestSummary: `{"params":{"uuid":"job-uuid"}}`,
+		Succeeded:      true,
+	}))
+	assert.NoError(t, app.Record(&AuditLogRecordRequest{
+		ActorType:      entity.AuditLogActorTypeRemoteSite,
+		Actor:          "site-uuid",
+		Action:         "POST /job/internal/:uuid/response",
+		TargetType:     "job",
+		TargetUUID:     "job-uuid",
+		Succeeded:      false,
+		OutcomeMessage: "cannot find participant",
+	}))
+
+	logList, err := app.List(&AuditLogQuery{Actor: "site-uuid"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), logList.Total)
+	assert.Len(t, logList.Items, 1)
+	assert.Equal(t, entity.AuditLogOutcomeFailed, logList.Items[0].Outcome)
+	assert.Equal(t, "Remote Site", logList.Items[0].ActorTypeStr)
+
+	logList, err = app.List(&AuditLogQuery{Page: 2, PageSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), logList.Total)
+	assert.Equal(t, 2, logList.Page)
+	assert.Len(t, logList.Items, 1)
+	assert.Equal(t, "site-uuid", logList.Items[0].Actor)
+
+	logList, err = app.List(&AuditLogQuery{PageSize: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, logList.Page)
+	assert.Equal(t, auditLogMaxPageSize, logList.PageSize)
+	assert.Len(t, logList.Items, 2)
+
+	content, err := app.Export(&AuditLogQuery{}, AuditLogExportFormatCSV)
+	assert.NoError(t, err)
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "request_summary", records[0][7])
+	assert.Equal(t, `{"params":{"uuid":"job-uuid"}}`, records[1][7])
+
+	content, err = app.Export(&AuditLogQuery{}, AuditLogExportFormatJSON)
+	assert.NoError(t, err)
+	var items []AuditLogItem
+	assert.NoError(t, json.Unmarshal(content, &items))
+	assert.Len(t, items, 2)
+
+	_, err = app.Export(&AuditLogQuery{}, "xml")
+	assert.Error(t, err)
+}
@@ -41,6 +41,7 @@ type JobApp struct {
 	ProjectRepo     repo.ProjectRepository
 	ProjectDataRepo repo.ProjectDataRepository
 	ModelRepo       repo.ModelRepository
+	AuditLogRepo    repo.AuditLogRepository
 }
 
 // JobInfoBase contains the basic info of a job
@@ -833,6 +834,8 @@ func (app *JobApp) buildJobAggregate(username string, request *JobSubmissionRequ
 		Participants:    map[string]*entity.JobParticipant{},
 		JobRepo:         app.JobRepo,
 		ParticipantRepo: app.ParticipantRepo,
+		ProjectDataRepo: app.ProjectDataRepo,
+		AuditLogRepo:    app.AuditLogRepo,
 		FMLManagerConnectionInfo: aggregate.FMLManagerConnectionInfo{
 			Connected:  site.FMLManagerConnected,
 			Endpoint:   site.FMLManagerEndpoint,
@@ -938,6 +941,8 @@ func (app *JobApp) loadJobAggregate(jobUUID string) (*aggregate.JobAggregate, er
 		Participants:    map[string]*entity.JobParticipant{},
 		JobRepo:         app.JobRepo,
 		ParticipantRepo: app.ParticipantRepo,
+		ProjectDataRepo: app.ProjectDataRepo,
+		AuditLogRepo:    app.AuditLogRepo,
 		FMLManagerConnectionInfo: aggregate.FMLManagerConnectionInfo{
 			Connected:  site.FMLManagerConnected,
 			Endpoint:   site.FMLManagerEndpoint,
@@ -989,6 +994,9 @@ func (app *JobApp) buildJobStatusMessage(jobAggregate *aggregate.JobAggregate) s
 				}
 			}
 		}
+		if jobAggregate.Job.StatusMessage != "" {
+			return fmt.Sprintf("Job is rejected by %s: %s", rejectedParticipantStr, jobAggregate.Job.StatusMessage)
+		}
 		return "Job is rejected by " + rejectedParticipantStr
 	}
 	if jobAggregate.Job.Status == entity.JobStatusPending {
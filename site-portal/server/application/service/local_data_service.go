@@ -16,16 +16,19 @@ package service
 
 import (
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/aggregate"
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/valueobject"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 // LocalDataApp provides local data management services
@@ -34,6 +37,8 @@ type LocalDataApp struct {
 	SiteRepo        repo.SiteRepository
 	ProjectRepo     repo.ProjectRepository
 	ProjectDataRepo repo.ProjectDataRepository
+	// UploadSessionRepo is used for chunked uploads
+	UploadSessionRepo repo.LocalDataUploadSessionRepository
 }
 
 // LocalDataUploadRequest contains basic upload request information
@@ -43,6 +48,32 @@ type LocalDataUploadRequest struct {
 	FileHeader  *multipart.FileHeader
 }
 
+// LocalDataUploadSessionCreationRequest contains the info of a data file to be uploaded in chunks
+type LocalDataUploadSessionCreationRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	FileName    string `json:"file_name"`
+	FileSize    int64  `json:"file_size"`
+	ChunkSize   int64  `json:"chunk_size"`
+	// Checksum is the optional hex-encoded SHA256 checksum of the whole file
+	Checksum string `json:"checksum"`
+}
+
+// LocalDataUploadSessionInfo contains the info of a chunked upload session
+type LocalDataUploadSessionInfo struct {
+	*entity.LocalDataUploadSession
+	UploadedParts []uint `json:"uploaded_parts"`
+}
+
+// LocalDataImportRequest contains the info to import a data file from external storages
+type LocalDataImportRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// URI locates the file, in the format of "s3://<bucket>/<object>" or "hdfs://<namenode-host>:<webhdfs-port>/<path>"
+	URI string `json:"uri"`
+	valueobject.DataImportSourceConfig
+}
+
 // LocalDataAssociateRequest contains basic local data association request information
 type LocalDataAssociateRequest struct {
 	Name           string `json:"name"`
@@ -71,6 +102,13 @@ type LocalDataDetail struct {
 	Features    []string                `json:"features_array"`
 	Preview     string                  `json:"preview_array"`
 	NotUploaded bool                    `json:"not_uploaded_locally"`
+	Format      entity.LocalDataFormat  `json:"format"`
+}
+
+// LocalDataProfileGenerationRequest contains the options to generate the data profile
+type LocalDataProfileGenerationRequest struct {
+	// LabelColumn is the optional column to calculate the label distribution
+	LabelColumn string `json:"label_column"`
 }
 
 // LocalDataIDMetaInfoUpdateRequest contains basic upload request information
@@ -93,10 +131,11 @@ func (s *LocalDataApp) Upload(request *LocalDataUploadRequest) (string, error) {
 		FATEFlowIsHttps: false,
 	}
 	data := entity.LocalData{
-		Name:          request.Name,
-		Description:   request.Description,
-		UploadContext: context,
-		Repo:          s.LocalDataRepo,
+		Name:               request.Name,
+		Description:        request.Description,
+		UploadContext:      context,
+		ProfileGeneratedCB: s.shareProfile,
+		Repo:               s.LocalDataRepo,
 	}
 	if err := data.Upload(request.FileHeader); err != nil {
 		return "", err
@@ -104,6 +143,127 @@ func (s *LocalDataApp) Upload(request *LocalDataUploadRequest) (string, error) {
 	return data.UUID, nil
 }
 
+// CreateUploadSession starts a chunked upload session for a large data file
+func (s *LocalDataApp) CreateUploadSession(request *LocalDataUploadSessionCreationRequest) (*LocalDataUploadSessionInfo, error) {
+	if err := s.LocalDataRepo.CheckNameConflict(request.Name); err != nil {
+		return nil, err
+	}
+	session := &entity.LocalDataUploadSession{
+		Name:        request.Name,
+		Description: request.Description,
+		FileName:    request.FileName,
+		FileSize:    request.FileSize,
+		ChunkSize:   request.ChunkSize,
+		Checksum:    request.Checksum,
+		Repo:        s.UploadSessionRepo,
+	}
+	if err := session.Init(); err != nil {
+		return nil, err
+	}
+	return &LocalDataUploadSessionInfo{
+		LocalDataUploadSession: session,
+		UploadedParts:          []uint{},
+	}, nil
+}
+
+// GetUploadSession returns the session info including the uploaded chunks, so the client can resume the upload
+func (s *LocalDataApp) GetUploadSession(uuid string) (*LocalDataUploadSessionInfo, error) {
+	session, err := s.loadUploadSession(uuid)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := session.GetUploadedParts()
+	if err != nil {
+		return nil, err
+	}
+	return &LocalDataUploadSessionInfo{
+		LocalDataUploadSession: session,
+		UploadedParts:          parts,
+	}, nil
+}
+
+// UploadPart saves one chunk of the upload session
+func (s *LocalDataApp) UploadPart(uuid string, index uint, src io.Reader, checksum string) error {
+	session, err := s.loadUploadSession(uuid)
+	if err != nil {
+		return err
+	}
+	return session.UploadPart(index, src, checksum)
+}
+
+// CompleteUploadSession creates the data from the uploaded chunks, the data is imported into FATE asynchronously
+func (s *LocalDataApp) CompleteUploadSession(uuid string) (string, error) {
+	session, err := s.loadUploadSession(uuid)
+	if err != nil {
+		return "", err
+	}
+	context, err := s.loadUploadContext()
+	if err != nil {
+		return "", err
+	}
+	data := &entity.LocalData{
+		UploadContext:      *context,
+		ProfileGeneratedCB: s.shareProfile,
+		Repo:               s.LocalDataRepo,
+	}
+	if err := session.Complete(data); err != nil {
+		return "", err
+	}
+	return data.UUID, nil
+}
+
+// AbortUploadSession removes the upload session and the uploaded chunks
+func (s *LocalDataApp) AbortUploadSession(uuid string) error {
+	session, err := s.loadUploadSession(uuid)
+	if err != nil {
+		return err
+	}
+	return session.Abort()
+}
+
+// ImportFromURI creates the data by streaming the file from the external storage into FATE asynchronously
+func (s *LocalDataApp) ImportFromURI(request *LocalDataImportRequest) (string, error) {
+	context, err := s.loadUploadContext()
+	if err != nil {
+		return "", err
+	}
+	data := entity.LocalData{
+		Name:               request.Name,
+		Description:        request.Description,
+		UploadContext:      *context,
+		ProfileGeneratedCB: s.shareProfile,
+		Repo:               s.LocalDataRepo,
+	}
+	if err := data.ImportFromURI(request.URI, &request.DataImportSourceConfig); err != nil {
+		return "", err
+	}
+	return data.UUID, nil
+}
+
+func (s *LocalDataApp) loadUploadSession(uuid string) (*entity.LocalDataUploadSession, error) {
+	instance, err := s.UploadSessionRepo.GetByUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query upload session")
+	}
+	session := instance.(*entity.LocalDataUploadSession)
+	session.Repo = s.UploadSessionRepo
+	return session, nil
+}
+
+func (s *LocalDataApp) loadUploadContext() (*entity.UploadContext, error) {
+	site := entity.Site{
+		Repo: s.SiteRepo,
+	}
+	if err := site.Load(); err != nil {
+		return nil, errors.Wrapf(err, "failed to load connection info of FATE flow")
+	}
+	return &entity.UploadContext{
+		FATEFlowHost:    site.FATEFlowHost,
+		FATEFlowPort:    site.FATEFlowHTTPPort,
+		FATEFlowIsHttps: false,
+	}, nil
+}
+
 // AssociateFlowTable creates a local data record associated with existing flow table
 func (s *LocalDataApp) AssociateFlowTable(request *LocalDataAssociateRequest) (string, error) {
 	site := entity.Site{
@@ -174,6 +334,7 @@ func (s *LocalDataApp) Get(uuid string) (*LocalDataDetail, error) {
 		IDMetaInfo:  localData.IDMetaInfo,
 		Features:    localData.Features,
 		Preview:     localData.Preview,
+		Format:      localData.Format,
 	}
 	if localData.LocalFilePath == "" {
 		localDataDetail.Filename = localDataDetail.Name
@@ -284,3 +445,90 @@ func (s *LocalDataApp) DeleteData(uuid string) error {
 func (s *LocalDataApp) UpdateIDMetaInfo(uuid string, req *LocalDataIDMetaInfoUpdateRequest) error {
 	return s.LocalDataRepo.UpdateIDMetaInfoByUUID(uuid, req.IDMetaInfo)
 }
+
+// GetProfile returns the profile of a local data, or of a data from other sites associated in a joined project
+func (s *LocalDataApp) GetProfile(uuid string) (*entity.LocalDataProfile, error) {
+	if instance, err := s.LocalDataRepo.GetByUUID(uuid); err == nil {
+		localData := instance.(*entity.LocalData)
+		if localData.Profile == nil {
+			return nil, errors.New("the profile of this data is not available")
+		}
+		return localData.Profile, nil
+	}
+	dataListInstance, err := s.ProjectDataRepo.GetListByDataUUID(uuid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query project data association")
+	}
+	for _, projectData := range dataListInstance.([]entity.ProjectData) {
+		if projectData.Type == entity.ProjectDataTypeRemote && projectData.Status == entity.ProjectDataStatusAssociated &&
+			projectData.Profile != nil {
+			return projectData.Profile, nil
+		}
+	}
+	return nil, errors.New("the profile of this data is not available")
+}
+
+// GenerateProfile re-generates the profile of a local data
+func (s *LocalDataApp) GenerateProfile(uuid string, req *LocalDataProfileGenerationRequest) error {
+	instance, err := s.LocalDataRepo.GetByUUID(uuid)
+	if err != nil {
+		return err
+	}
+	localData := instance.(*entity.LocalData)
+	localData.Repo = s.LocalDataRepo
+	localData.ProfileGeneratedCB = s.shareProfile
+	return localData.GenerateProfile(req.LabelColumn)
+}
+
+// shareProfile sends the newly generated profile of a local data to the projects it is associated with
+func (s *LocalDataApp) shareProfile(dataUUID string, profile *entity.LocalDataProfile) {
+	dataListInstance, err := s.ProjectDataRepo.GetListByDataUUID(dataUUID)
+	if err != nil {
+		log.Err(err).Str("data uuid", dataUUID).Msg("failed to query project data association")
+		return
+	}
+	dataList := dataListInstance.([]entity.ProjectData)
+	if len(dataList) == 0 {
+		return
+	}
+	site := entity.Site{
+		Repo: s.SiteRepo,
+	}
+	if err := site.Load(); err != nil {
+		log.Err(err).Str("data uuid", dataUUID).Msg("failed to load site info")
+		return
+	}
+	for index := range dataList {
+		projectData := &dataList[index]
+		if projectData.Type != entity.ProjectDataTypeLocal || projectData.Status != entity.ProjectDataStatusAssociated {
+			continue
+		}
+		projectInstance, err := s.ProjectRepo.GetByUUID(projectData.ProjectUUID)
+		if err != nil {
+			log.Err(err).Str("project uuid", projectData.ProjectUUID).Msg("failed to query project")
+			continue
+		}
+		project := projectInstance.(*entity.Project)
+		if project.Status != entity.ProjectStatusManaged && project.Status != entity.ProjectStatusJoined {
+			continue
+		}
+		projectData.Profile = profile
+		projectAggregate := aggregate.ProjectAggregate{
+			Project:     project,
+			ProjectData: projectData,
+			ProjectRepo: s.ProjectRepo,
+			DataRepo:    s.ProjectDataRepo,
+		}
+		if err := projectAggregate.AssociateLocalData(&aggregate.ProjectLocalDataAssociationContext{
+			FMLManagerConnectionInfo: &aggregate.FMLManagerConnectionInfo{
+				Connected:  site.FMLManagerConnected,
+				Endpoint:   site.FMLManagerEndpoint,
+				ServerName: site.FMLManagerServerName,
+			},
+			LocalData: projectData,
+		}); err != nil {
+			log.Err(err).Str("project uuid", project.UUID).Str("data uuid", dataUUID).
+				Msg("failed to share the data profile")
+		}
+	}
+}
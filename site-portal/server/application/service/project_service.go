@@ -866,6 +866,7 @@ func (app *ProjectApp) CreateDataAssociation(projectUUID string, request *Projec
 			TableNamespace: localData.TableNamespace,
 			CreationTime:   localData.CreatedAt,
 			UpdateTime:     localData.UpdatedAt,
+			Profile:        localData.Profile,
 			Repo:           app.ProjectDataRepo,
 		},
 	})
@@ -912,6 +913,33 @@ func (app *ProjectApp) RemoveDataAssociation(projectUUID, dataUUID string) error
 	})
 }
 
+// GetDataUsagePolicy returns the usage policy of the associated local data
+func (app *ProjectApp) GetDataUsagePolicy(projectUUID, dataUUID string) (*entity.ProjectDataUsagePolicy, error) {
+	projectDataInstance, err := app.ProjectDataRepo.GetByProjectAndDataUUID(projectUUID, dataUUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query project data")
+	}
+	projectData := projectDataInstance.(*entity.ProjectData)
+	if projectData.UsagePolicy == nil {
+		return &entity.ProjectDataUsagePolicy{}, nil
+	}
+	return projectData.UsagePolicy, nil
+}
+
+// UpdateDataUsagePolicy changes the usage policy of the associated local data
+func (app *ProjectApp) UpdateDataUsagePolicy(projectUUID, dataUUID string, policy *entity.ProjectDataUsagePolicy) error {
+	if err := app.EnsureProjectIsOpen(projectUUID); err != nil {
+		return err
+	}
+	projectDataInstance, err := app.ProjectDataRepo.GetByProjectAndDataUUID(projectUUID, dataUUID)
+	if err != nil {
+		return errors.Wrap(err, "failed to query project data")
+	}
+	projectData := projectDataInstance.(*entity.ProjectData)
+	projectData.Repo = app.ProjectDataRepo
+	return projectData.UpdateUsagePolicy(policy)
+}
+
 // CreateRemoteProjectDataAssociation adds the passed remote data association to the specified project
 func (app *ProjectApp) CreateRemoteProjectDataAssociation(projectUUID string, dataList []entity.ProjectData) error {
 	site, err := app.LoadSite()
@@ -15,6 +15,7 @@
 package aggregate
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -35,6 +36,8 @@ type JobAggregate struct {
 	Participants             map[string]*entity.JobParticipant
 	JobRepo                  repo.JobRepository
 	ParticipantRepo          repo.JobParticipantRepository
+	ProjectDataRepo          repo.ProjectDataRepository
+	AuditLogRepo             repo.AuditLogRepository
 	FMLManagerConnectionInfo FMLManagerConnectionInfo
 	JobContext               JobContext
 }
@@ -411,19 +414,130 @@ func (aggregate *JobAggregate) HandleRemoteJobCreation() error {
 		}
 	}
 
-	if aggregate.JobContext.AutoApprovalEnabled {
+	autoProcess := aggregate.JobContext.AutoApprovalEnabled
+	autoProcessRule := "project auto-approval"
+	var policyErr error
+	usagePolicy, err := aggregate.loadDataUsagePolicy()
+	if err != nil {
+		return err
+	}
+	if usagePolicy != nil && usagePolicy.Enabled {
+		// the usage policy takes precedence over the project's auto-approval setting
+		autoProcess = true
+		autoProcessRule = "data usage policy"
+		policyErr = aggregate.evaluateDataUsagePolicy(usagePolicy)
+	}
+
+	if autoProcess {
 		go func() {
 			// add a delay to make sure other sites are in-sync
 			time.Sleep(time.Second * 10)
+			if policyErr != nil {
+				log.Info().Msgf("auto rejecting job %s(%s): %v", aggregate.Job.Name, aggregate.Job.UUID, policyErr)
+				if err := aggregate.Job.UpdateStatusMessage(policyErr.Error()); err != nil {
+					log.Err(err).Msgf("failed to update job status message: %s", aggregate.Job.UUID)
+				}
+				err := aggregate.RejectJob()
+				if err != nil {
+					log.Err(err).Msgf("failed to reject job: %s", aggregate.Job.UUID)
+				}
+				aggregate.recordAutoProcessing("auto reject", autoProcessRule, policyErr.Error(), err)
+				return
+			}
 			log.Info().Msgf("auto approving job %s(%s)", aggregate.Job.Name, aggregate.Job.UUID)
-			if err := aggregate.ApproveJob(); err != nil {
+			err := aggregate.ApproveJob()
+			if err != nil {
 				log.Err(err).Msgf("failed to approve job: %s", aggregate.Job.UUID)
 			}
+			aggregate.recordAutoProcessing("auto approve", autoProcessRule, "", err)
 		}()
 	}
 	return nil
 }
 
+// recordAutoProcessing writes an audit log for the job approval or rejection made by the rule without user interaction
+func (aggregate *JobAggregate) recordAutoProcessing(action, rule, reason string, err error) {
+	if aggregate.AuditLogRepo == nil {
+		return
+	}
+	summary, _ := json.Marshal(map[string]string{
+		"job_name": aggregate.Job.Name,
+		"reason":   reason,
+	})
+	auditLog := &entity.AuditLog{
+		ActorType:      entity.AuditLogActorTypeSystem,
+		Actor:          rule,
+		Action:         action,
+		TargetType:     "job",
+		TargetUUID:     aggregate.Job.UUID,
+		RequestSummary: string(summary),
+		Outcome:        entity.AuditLogOutcomeSucceeded,
+		Repo:           aggregate.AuditLogRepo,
+	}
+	if err != nil {
+		auditLog.Outcome = entity.AuditLogOutcomeFailed
+		auditLog.OutcomeMessage = err.Error()
+	}
+	if err := auditLog.Create(); err != nil {
+		log.Err(err).Str("job uuid", aggregate.Job.UUID).Msg("failed to record audit log")
+	}
+}
+
+// loadDataUsagePolicy returns the usage policy of the data the current site provides in the job, if any
+func (aggregate *JobAggregate) loadDataUsagePolicy() (*entity.ProjectDataUsagePolicy, error) {
+	var dataUUID string
+	if participant, ok := aggregate.Participants[aggregate.JobContext.CurrentSiteUUID]; ok {
+		dataUUID = participant.DataUUID
+	} else if aggregate.Initiator != nil && aggregate.Initiator.SiteUUID == aggregate.JobContext.CurrentSiteUUID {
+		dataUUID = aggregate.Initiator.DataUUID
+	} else {
+		return nil, nil
+	}
+	if aggregate.ProjectDataRepo == nil {
+		return nil, nil
+	}
+	projectDataInstance, err := aggregate.ProjectDataRepo.GetByProjectAndDataUUID(aggregate.Job.ProjectUUID, dataUUID)
+	if err != nil {
+		if errors.Is(err, repo.ErrProjectDataNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to query project data")
+	}
+	return projectDataInstance.(*entity.ProjectData).UsagePolicy, nil
+}
+
+// evaluateDataUsagePolicy checks the job against the usage policy of the data the current site provides
+func (aggregate *JobAggregate) evaluateDataUsagePolicy(policy *entity.ProjectDataUsagePolicy) error {
+	participant, ok := aggregate.Participants[aggregate.JobContext.CurrentSiteUUID]
+	if !ok {
+		return errors.Errorf("cannot find participant %s", aggregate.JobContext.CurrentSiteUUID)
+	}
+	usageContext := &entity.ProjectDataUsageContext{
+		JobType:          aggregate.Job.Type,
+		AlgorithmType:    aggregate.Job.AlgorithmType,
+		PartnerSiteUUIDs: []string{aggregate.Initiator.SiteUUID},
+	}
+	for siteUUID := range aggregate.Participants {
+		if siteUUID != aggregate.JobContext.CurrentSiteUUID {
+			usageContext.PartnerSiteUUIDs = append(usageContext.PartnerSiteUUIDs, siteUUID)
+		}
+	}
+	if policy.MaxJobCount > 0 {
+		participantListInstance, err := aggregate.ParticipantRepo.GetListByDataUUID(participant.DataUUID)
+		if err != nil {
+			return errors.Wrap(err, "failed to query jobs using the data")
+		}
+		// jobs initiated by this site use the data as well, their participant status is "Initiator"
+		for _, usage := range participantListInstance.([]entity.JobParticipant) {
+			if usage.JobUUID != aggregate.Job.UUID && (usage.Status == entity.JobParticipantStatusApproved ||
+				usage.Status == entity.JobParticipantStatusInitiator) {
+				usageContext.ApprovedJobCount++
+			}
+		}
+	}
+	return policy.Evaluate(usageContext)
+}
+
 // handleJobApproval process job approval, and may start the job if all participants approved it
 func (aggregate *JobAggregate) handleJobApproval(siteUUID string) error {
 	participant, ok := aggregate.Participants[siteUUID]
@@ -619,6 +733,13 @@ func (aggregate *JobAggregate) GetDataResultDownloadRequest() (*http.Request, er
 	if aggregate.Job.Status != entity.JobStatusSucceeded {
 		return nil, errors.New("job did not finished successfully")
 	}
+	usagePolicy, err := aggregate.loadDataUsagePolicy()
+	if err != nil {
+		return nil, err
+	}
+	if err := usagePolicy.CheckDataResultDownload(); err != nil {
+		return nil, err
+	}
 	role := aggregate.Initiator.SiteRole
 	partyID := aggregate.Initiator.SitePartyID
 	if participant, ok := aggregate.Participants[aggregate.JobContext.CurrentSiteUUID]; ok {
@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -91,3 +93,83 @@ func TestGenerateGeneralTrainingConf(t *testing.T) {
 	// hostUuid2 matches party id 3
 	assert.Equal(t, int(host[0].(float64)), 3)
 }
+
+// usageParticipantRepo returns the participants using the data, other methods are not implemented
+type usageParticipantRepo struct {
+	repo.JobParticipantRepository
+	participants []entity.JobParticipant
+}
+
+func (r *usageParticipantRepo) GetListByDataUUID(string) (interface{}, error) {
+	return r.participants, nil
+}
+
+func TestEvaluateDataUsagePolicy_MaxJobCount(t *testing.T) {
+	participantRepo := &usageParticipantRepo{
+		participants: []entity.JobParticipant{
+			// a job initiated by this site using the data
+			{JobUUID: "job-initiated", SiteUUID: "site-a", DataUUID: "data-a", Status: entity.JobParticipantStatusInitiator},
+			// a remote job rejected by this site
+			{JobUUID: "job-rejected", SiteUUID: "site-a", DataUUID: "data-a", Status: entity.JobParticipantStatusRejected},
+			// the job being evaluated
+			{JobUUID: "job-new", SiteUUID: "site-a", DataUUID: "data-a", Status: entity.JobParticipantStatusPending},
+		},
+	}
+	jobAggregate := &JobAggregate{
+		Job: &entity.Job{
+			UUID: "job-new",
+			Type: entity.JobTypePSI,
+		},
+		Initiator: &entity.JobParticipant{SiteUUID: "site-b", DataUUID: "data-b"},
+		Participants: map[string]*entity.JobParticipant{
+			"site-a": {JobUUID: "job-new", SiteUUID: "site-a", DataUUID: "data-a"},
+		},
+		ParticipantRepo: participantRepo,
+		JobContext: JobContext{
+			CurrentSiteUUID: "site-a",
+		},
+	}
+
+	assert.NoError(t, jobAggregate.evaluateDataUsagePolicy(&entity.ProjectDataUsagePolicy{Enabled: true, MaxJobCount: 2}))
+	assert.Error(t, jobAggregate.evaluateDataUsagePolicy(&entity.ProjectDataUsagePolicy{Enabled: true, MaxJobCount: 1}))
+
+	participantRepo.participants = append(participantRepo.participants, entity.JobParticipant{
+		JobUUID: "job-approved", SiteUUID: "site-a", DataUUID: "data-a", Status: entity.JobParticipantStatusApproved,
+	})
+	assert.Error(t, jobAggregate.evaluateDataUsagePolicy(&entity.ProjectDataUsagePolicy{Enabled: true, MaxJobCount: 2}))
+}
+
+// createdAuditLogRepo keeps the created logs, other methods are not implemented
+type createdAuditLogRepo struct {
+	repo.AuditLogRepository
+	logs []entity.AuditLog
+}
+
+func (r *createdAuditLogRepo) Create(instance interface{}) error {
+	r.logs = append(r.logs, *instance.(*entity.AuditLog))
+	return nil
+}
+
+func TestRecordAutoProcessing(t *testing.T) {
+	auditLogRepo := &createdAuditLogRepo{}
+	jobAggregate := &JobAggregate{
+		Job: &entity.Job{
+			Name: "job-name",
+			UUID: "job-uuid",
+		},
+		AuditLogRepo: auditLogRepo,
+	}
+	jobAggregate.recordAutoProcessing("auto reject", "data usage policy", "the data has reached its max job count 1", nil)
+	jobAggregate.recordAutoProcessing("auto approve", "project auto-approval", "", errors.New("failed to send response"))
+
+	assert.Len(t, auditLogRepo.logs, 2)
+	rejection := auditLogRepo.logs[0]
+	assert.Equal(t, entity.AuditLogActorTypeSystem, rejection.ActorType)
+	assert.Equal(t, "data usage policy", rejection.Actor)
+	assert.Equal(t, "job-uuid", rejection.TargetUUID)
+	assert.Equal(t, entity.AuditLogOutcomeSucceeded, rejection.Outcome)
+	assert.Contains(t, rejection.RequestSummary, "max job count")
+	approval := auditLogRepo.logs[1]
+	assert.Equal(t, entity.AuditLogOutcomeFailed, approval.Outcome)
+	assert.Equal(t, "failed to send response", approval.OutcomeMessage)
+}
@@ -15,6 +15,8 @@
 package aggregate
 
 import (
+	"encoding/json"
+
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
 	"github.com/FederatedAI/FedLCM/site-portal/server/infrastructure/fmlmanager"
@@ -184,6 +186,7 @@ func (aggregate *ProjectAggregate) InviteParticipant(invitationContext *ProjectI
 			TableNamespace: localData.TableNamespace,
 			CreationTime:   localData.CreationTime,
 			UpdateTime:     localData.UpdateTime,
+			Profile:        marshalDataProfile(localData.Profile),
 		}
 	}
 	if err := client.SendInvitation(fmlmanager.ProjectInvitation{
@@ -522,6 +525,7 @@ func (aggregate *ProjectAggregate) AssociateLocalData(localDataAssociationCtx *P
 			TableNamespace: localDataAssociationCtx.LocalData.TableNamespace,
 			CreationTime:   localDataAssociationCtx.LocalData.CreationTime,
 			UpdateTime:     localDataAssociationCtx.LocalData.UpdateTime,
+			Profile:        marshalDataProfile(localDataAssociationCtx.LocalData.Profile),
 		}); err != nil {
 			return errors.Wrap(err, "failed to send project data association to FML manager")
 		}
@@ -555,10 +559,43 @@ func (aggregate *ProjectAggregate) CreateOrUpdateData(newData *entity.ProjectDat
 		if err := aggregate.DataRepo.UpdateStatusByUUID(data); err != nil {
 			return errors.Wrapf(err, "failed to update data association")
 		}
+		if newData.Profile != nil {
+			data.Profile = newData.Profile
+			if err := aggregate.DataRepo.UpdateProfileByUUID(data); err != nil {
+				return errors.Wrapf(err, "failed to update data profile")
+			}
+		}
 	}
 	return nil
 }
 
+// marshalDataProfile returns the data profile to be shared with other sites, only successfully generated
+// profiles are shared
+func marshalDataProfile(profile *entity.LocalDataProfile) json.RawMessage {
+	if profile == nil || profile.Status != entity.LocalDataProfileStatusSucceeded {
+		return nil
+	}
+	profileBytes, err := json.Marshal(profile)
+	if err != nil {
+		log.Err(err).Msg("failed to marshal data profile")
+		return nil
+	}
+	return profileBytes
+}
+
+// unmarshalDataProfile parses the data profile shared by other sites
+func unmarshalDataProfile(profileBytes json.RawMessage) *entity.LocalDataProfile {
+	if len(profileBytes) == 0 || string(profileBytes) == "null" {
+		return nil
+	}
+	profile := &entity.LocalDataProfile{}
+	if err := json.Unmarshal(profileBytes, profile); err != nil {
+		log.Err(err).Msg("failed to unmarshal data profile")
+		return nil
+	}
+	return profile
+}
+
 // DismissAssociatedLocalData dismisses local data association
 func (aggregate *ProjectAggregate) DismissAssociatedLocalData(context *ProjectLocalDataDismissalContext) error {
 	if aggregate.Project.Type == entity.ProjectTypeRemote || aggregate.Project.Type == entity.ProjectTypeFederatedLocal {
@@ -676,6 +713,7 @@ func (aggregate *ProjectAggregate) SyncDataAssociation(context *ProjectSyncConte
 			TableNamespace: associatedData.TableNamespace,
 			CreationTime:   associatedData.CreationTime,
 			UpdateTime:     associatedData.UpdateTime,
+			Profile:        unmarshalDataProfile(associatedData.Profile),
 			Repo:           aggregate.DataRepo,
 		}
 		if associatedData.SiteUUID == context.LocalSiteUUID {
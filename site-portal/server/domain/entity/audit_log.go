@@ -0,0 +1,112 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package entity
+
+import (
+	"time"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records an action performed by a user or a remote site. The records are append-only.
+type AuditLog struct {
+	gorm.Model
+	UUID string `json:"uuid" gorm:"type:varchar(36);index;unique"`
+	// ActorType is whether the action is performed by a local user or a remote site
+	ActorType AuditLogActorType `json:"actor_type"`
+	// Actor is the username of a user, the certificate common name of a remote site, or the name of the
+	// automatic rule for system actions
+	Actor string `json:"actor" gorm:"type:varchar(255);index"`
+	// Action is the name of the action, i.e. the HTTP method and route of the request
+	Action string `json:"action" gorm:"type:varchar(255);index"`
+	// TargetType is the type of the entity the action is performed on
+	TargetType string `json:"target_type" gorm:"type:varchar(255)"`
+	// TargetUUID is the uuid of the entity the action is performed on
+	TargetUUID string `json:"target_uuid" gorm:"type:varchar(255);index"`
+	// RequestSummary contains the request content with sensitive fields removed
+	RequestSummary string `json:"request_summary" gorm:"type:text"`
+	// Outcome is whether the action succeeded
+	Outcome AuditLogOutcome `json:"outcome"`
+	// OutcomeMessage is the error message if the action failed
+	OutcomeMessage string `json:"outcome_message" gorm:"type:text"`
+	// Repo is used to store the log into the storage
+	Repo repo.AuditLogRepository `json:"-" gorm:"-"`
+}
+
+// AuditLogActorType is the type of the actor
+type AuditLogActorType uint8
+
+const (
+	AuditLogActorTypeUnknown AuditLogActorType = iota
+	AuditLogActorTypeUser
+	AuditLogActorTypeRemoteSite
+	AuditLogActorTypeSystem
+)
+
+func (t AuditLogActorType) String() string {
+	names := map[AuditLogActorType]string{
+		AuditLogActorTypeUnknown:    "Unknown",
+		AuditLogActorTypeUser:       "User",
+		AuditLogActorTypeRemoteSite: "Remote Site",
+		AuditLogActorTypeSystem:     "System",
+	}
+	return names[t]
+}
+
+// AuditLogOutcome is the result of the audited action
+type AuditLogOutcome uint8
+
+const (
+	AuditLogOutcomeUnknown AuditLogOutcome = iota
+	AuditLogOutcomeSucceeded
+	AuditLogOutcomeFailed
+)
+
+func (o AuditLogOutcome) String() string {
+	names := map[AuditLogOutcome]string{
+		AuditLogOutcomeUnknown:   "Unknown",
+		AuditLogOutcomeSucceeded: "Succeeded",
+		AuditLogOutcomeFailed:    "Failed",
+	}
+	return names[o]
+}
+
+// AuditLogFilter contains the conditions for querying the audit logs, empty fields are ignored
+type AuditLogFilter struct {
+	ActorType  AuditLogActorType
+	Actor      string
+	Action     string
+	TargetType string
+	TargetUUID string
+	Outcome    AuditLogOutcome
+	StartTime  time.Time
+	EndTime    time.Time
+	// Offset is the number of the matched logs to skip
+	Offset int
+	// Limit is the max number of logs to return, 0 means no limit
+	Limit int
+}
+
+// Create saves the log into the repo
+func (l *AuditLog) Create() error {
+	if l.Action == "" {
+		return errors.New("empty audit log action")
+	}
+	l.Model = gorm.Model{}
+	l.UUID = uuid.NewV4().String()
+	return l.Repo.Create(l)
+}
@@ -16,8 +16,10 @@ package entity
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,6 +34,7 @@ import (
 
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/valueobject"
+	"github.com/FederatedAI/FedLCM/site-portal/server/infrastructure/datasource"
 	"github.com/FederatedAI/FedLCM/site-portal/server/infrastructure/fateclient"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -67,6 +70,8 @@ type LocalData struct {
 	Preview string `json:"preview" gorm:"type:text"`
 	// IDMetaInfo is the meta data describing the ID column
 	IDMetaInfo *valueobject.IDMetaInfo `json:"id_meta_info" gorm:"type:text;column:id_meta_info"`
+	// Profile contains the aggregated statistics of the data
+	Profile *LocalDataProfile `json:"profile" gorm:"type:text"`
 	// JobID is the related FATE upload job id
 	JobID string `json:"-" gorm:"type:varchar(255);column:job_id"`
 	// JobConf is the related FATE upload job conf
@@ -75,10 +80,14 @@ type LocalData struct {
 	JobStatus UploadJobStatus `json:"status"`
 	// JobErrorString records the error message if the job failed
 	JobErrorMsg string `json:"job_error_msg" gorm:"type:text"`
-	// LocalFilePath is the file path relative to the baseDir
+	// LocalFilePath is the file path relative to the baseDir, non-csv files are stored after converted to csv
 	LocalFilePath string `json:"-" gorm:"type:varchar(255)"`
+	// Format is the format of the uploaded file
+	Format LocalDataFormat `json:"format"`
 	// UploadContext contains info needed to finish the upload job
 	UploadContext UploadContext `json:"-" gorm:"-"`
+	// ProfileGeneratedCB is called with the data uuid and the profile once the profile is successfully generated
+	ProfileGeneratedCB func(dataUUID string, profile *LocalDataProfile) `json:"-" gorm:"-"`
 	// Repo is used to store the necessary data into the storage
 	Repo repo.LocalDataRepository `json:"-" gorm:"-"`
 }
@@ -102,6 +111,7 @@ const (
 	UploadJobStatusRunning
 	UploadJobStatusFailed
 	UploadJobStatusSucceeded
+	UploadJobStatusImporting
 )
 
 // MarshalJSON convert Cluster status to string
@@ -112,6 +122,7 @@ func (s *UploadJobStatus) MarshalJSON() ([]byte, error) {
 		UploadJobStatusRunning:     `"Running"`,
 		UploadJobStatusFailed:      `"Failed"`,
 		UploadJobStatusSucceeded:   `"Succeeded"`,
+		UploadJobStatusImporting:   `"Importing"`,
 	}
 	return bytes.NewBufferString(names[*s]).Bytes(), nil
 }
@@ -136,170 +147,342 @@ func (d *LocalData) Upload(fileHeader *multipart.FileHeader) error {
 	if err := d.Repo.CheckNameConflict(d.Name); err != nil {
 		return err
 	}
+	if err := validateDataFileName(fileHeader.Filename); err != nil {
+		return err
+	}
 	src, err := fileHeader.Open()
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 	d.UUID = uuid.NewV4().String()
-	parentDir := filepath.Join(getBaseDir(), d.UUID)
-	if err = os.MkdirAll(parentDir, 0700); err != nil {
+	if err := d.saveAndParse(fileHeader.Filename, src); err != nil {
 		return err
 	}
-	// check if the file name is valid
-	if strings.TrimSpace(fileHeader.Filename) == "" {
-		return errors.Errorf("file name can not be empty")
+	d.JobStatus = UploadJobStatusToBeCreated
+	d.TableName = fmt.Sprintf("table-%s", d.UUID)
+	d.TableNamespace = fmt.Sprintf("ns-%s", d.UUID)
+	if err := d.Repo.Create(d); err != nil {
+		return err
 	}
-	filename := strings.Split(fileHeader.Filename, ".")[0]
-	var isStringAlphabetic = regexp.MustCompile(`^[a-zA-Z0-9\s\d\-_/]*$`).MatchString
-	if !isStringAlphabetic(filename) {
-		return errors.Errorf("file name can not contain special characters")
+	if err := d.GenerateProfile(d.getDefaultLabelColumn()); err != nil {
+		log.Err(err).Str("data uuid", d.UUID).Msg("failed to start generating data profile")
 	}
-	if len(filename) < 2 {
-		return errors.Errorf("file name is too short")
+
+	go d.uploadToFATE()
+	return nil
+}
+
+// Import creates the data record and then asynchronously streams the content from src into the local storage
+// and the FATE system, so large files won't block the request or be loaded into the memory. If checksum is not
+// empty, it is compared with the hex-encoded SHA256 checksum of the content. Once Import returns without error,
+// src is owned by the data and will be closed after the content is read.
+func (d *LocalData) Import(filename string, src io.ReadCloser, checksum string) error {
+	if d.UploadContext.FATEFlowHost == "" || d.UploadContext.FATEFlowPort == 0 {
+		return errors.Errorf("cannot find valid FATE flow connection info")
 	}
-	if len(filename) > 255 {
-		return errors.Errorf("file name is too long")
+	if err := d.Repo.CheckNameConflict(d.Name); err != nil {
+		return err
+	}
+	if err := validateDataFileName(filename); err != nil {
+		return err
+	}
+	d.UUID = uuid.NewV4().String()
+	d.LocalFilePath = filepath.Join(d.UUID, filepath.Base(filename))
+	d.JobStatus = UploadJobStatusImporting
+	d.IDMetaInfo = nil
+	d.Preview = "[]"
+	d.TableName = fmt.Sprintf("table-%s", d.UUID)
+	d.TableNamespace = fmt.Sprintf("ns-%s", d.UUID)
+	if err := d.Repo.Create(d); err != nil {
+		return err
 	}
-	// parse the csv file and record some meta data and previews
-	if csvErr := func() error {
-		csvFile, err := fileHeader.Open()
-		if err != nil {
-			return err
-		}
-		defer csvFile.Close()
-		csvReader := csv.NewReader(csvFile)
 
-		// check headers
-		headers, err := csvReader.Read()
-		if err != nil {
-			return nil
-		}
-		d.Column = headers
-		if features, containsID := func() ([]string, bool) {
-			containsID := false
-			features := Headers{}
-			for _, header := range headers {
-				if strings.ToLower(header) == "id" {
-					containsID = true
-				} else if strings.ToLower(header) != "y" {
-					features = append(features, header)
-				}
+	go func() {
+		if err := func() error {
+			defer src.Close()
+			hash := sha256.New()
+			if err := d.saveAndParse(filename, io.TeeReader(src, hash)); err != nil {
+				return err
 			}
-			return features, containsID
-		}(); containsID == false {
-			return errors.New("data must contain an id field")
-		} else {
-			d.Features = features
-		}
-
-		// count data lines and keep the first 10 lines as preview
-		if count, previewJsonStr, err := func() (uint64, string, error) {
-			var records []map[string]string
-			var lines uint64
-			for {
-				recordLine, err := csvReader.Read()
-				if err != nil {
-					break
-				}
-				if lines < 10 {
-					record := map[string]string{}
-					for i, value := range recordLine {
-						record[headers[i]] = value
-					}
-					records = append(records, record)
+			if checksum != "" {
+				if sum := hex.EncodeToString(hash.Sum(nil)); sum != strings.ToLower(checksum) {
+					return errors.Errorf("checksum mismatch, expected: %s, actual: %s", checksum, sum)
 				}
-				lines++
 			}
-			if err != nil && err != io.EOF {
-				return 0, "", err
-			}
-			jsonBytes, err := json.Marshal(records)
-			if err != nil {
-				return 0, "", err
-			}
-			return lines, string(jsonBytes), nil
+			return d.Repo.UpdateDataInfoByUUID(d)
 		}(); err != nil {
-			return errors.Wrap(err, "error parsing data records")
-		} else {
-			d.Count = count
-			d.Preview = previewJsonStr
+			log.Err(err).Str("data uuid", d.UUID).Msg("failed to import data")
+			d.JobErrorMsg = err.Error()
+			d.ChangeJobStatus(UploadJobStatusFailed)
+			return
 		}
-		return nil
-	}(); csvErr != nil {
-		return errors.Wrap(csvErr, "error parsing the uploaded csv file")
+		if err := d.GenerateProfile(d.getDefaultLabelColumn()); err != nil {
+			log.Err(err).Str("data uuid", d.UUID).Msg("failed to start generating data profile")
+		}
+		d.ChangeJobStatus(UploadJobStatusToBeCreated)
+		d.uploadToFATE()
+	}()
+	return nil
+}
+
+// ImportFromURI imports the data file located by the uri, which can be an "s3://" or "hdfs://" uri
+func (d *LocalData) ImportFromURI(uri string, config *valueobject.DataImportSourceConfig) error {
+	src, filename, err := datasource.Open(uri, config)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", uri)
 	}
+	if err := d.Import(filename, src, ""); err != nil {
+		_ = src.Close()
+		return err
+	}
+	return nil
+}
 
-	d.LocalFilePath = filepath.Join(d.UUID, filepath.Base(fileHeader.Filename))
+// saveAndParse saves the content into the local storage and records the meta data and previews at the same time
+func (d *LocalData) saveAndParse(filename string, src io.Reader) error {
+	parentDir := filepath.Join(getBaseDir(), d.UUID)
+	if err := os.MkdirAll(parentDir, 0700); err != nil {
+		return err
+	}
+	d.Format = getLocalDataFormat(filename)
+	d.LocalFilePath = filepath.Join(d.UUID, filepath.Base(filename))
+	if d.Format != LocalDataFormatCSV {
+		return d.saveAndConvert(filename, src)
+	}
 	dst, err := os.Create(filepath.Join(getBaseDir(), d.LocalFilePath))
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("saving uploaded data file %s to %s", fileHeader.Filename, dst.Name())
-	_, err = io.Copy(dst, src)
-	if err != nil {
+	defer dst.Close()
+	log.Info().Msgf("saving data file %s to %s", filename, dst.Name())
+	// the content read by the csv parser is written to the file too
+	if err := d.parseCSV(io.TeeReader(src, dst)); err != nil {
+		return errors.Wrap(err, "error parsing the uploaded csv file")
+	}
+	// copy what's left in case the parser stopped early
+	if _, err := io.Copy(dst, src); err != nil {
 		return err
 	}
-	d.JobStatus = UploadJobStatusToBeCreated
-	d.IDMetaInfo = nil
-	d.TableName = fmt.Sprintf("table-%s", d.UUID)
-	d.TableNamespace = fmt.Sprintf("ns-%s", d.UUID)
-	if err := d.Repo.Create(d); err != nil {
+	return nil
+}
+
+// saveAndConvert saves the non-csv content, converts it to a csv file and parses the converted file
+func (d *LocalData) saveAndConvert(filename string, src io.Reader) error {
+	srcPath := filepath.Join(getBaseDir(), d.LocalFilePath)
+	if err := func() error {
+		dst, err := os.Create(srcPath)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+		log.Info().Msgf("saving %s data file %s to %s", d.Format.String(), filename, dst.Name())
+		_, err = io.Copy(dst, src)
+		return err
+	}(); err != nil {
 		return err
 	}
+	// only the converted file is kept
+	defer os.Remove(srcPath)
 
-	go func() {
-		log.Info().Msgf("uploading data file %s to FATE", dst.Name())
-		fateClient := fateclient.NewFATEFlowClient(d.UploadContext.FATEFlowHost, d.UploadContext.FATEFlowPort, d.UploadContext.FATEFlowIsHttps)
-		d.ChangeJobStatus(UploadJobStatusCreating)
-
-		uploadConf := fateclient.DataUploadRequest{
-			File:      dst.Name(),
-			Head:      1,
-			Partition: 8, // XXX: use viper configuration instead of a hard-code one
-			Namespace: d.TableNamespace,
-			TableName: d.TableName,
-			Drop:      1,
+	d.LocalFilePath = filepath.Join(d.UUID, getConvertedFileName(filename))
+	dstPath := filepath.Join(getBaseDir(), d.LocalFilePath)
+	log.Info().Msgf("converting data file %s to %s", srcPath, dstPath)
+	if err := convertToCSV(d.Format, srcPath, dstPath); err != nil {
+		return errors.Wrapf(err, "error converting the uploaded %s file", d.Format.String())
+	}
+	converted, err := os.Open(dstPath)
+	if err != nil {
+		return err
+	}
+	defer converted.Close()
+	if err := d.parseCSV(converted); err != nil {
+		return errors.Wrap(err, "error parsing the converted csv file")
+	}
+	return nil
+}
+
+// parseCSV parses the csv content and records some meta data and previews
+func (d *LocalData) parseCSV(src io.Reader) error {
+	csvReader := csv.NewReader(src)
+
+	// check headers
+	headers, err := csvReader.Read()
+	if err != nil {
+		return nil
+	}
+	d.Column = headers
+	if features, containsID := func() ([]string, bool) {
+		containsID := false
+		features := Headers{}
+		for _, header := range headers {
+			if strings.ToLower(header) == "id" {
+				containsID = true
+			} else if strings.ToLower(header) != "y" {
+				features = append(features, header)
+			}
 		}
-		confBytes, _ := json.Marshal(uploadConf)
-		d.JobConf = string(confBytes)
-		if jobID, err := fateClient.UploadData(uploadConf); err != nil {
-			log.Err(err).Msgf("failed to upload data %s to FATE", d.UUID)
-			d.ChangeJobStatus(UploadJobStatusFailed)
-			d.JobErrorMsg = err.Error()
-		} else {
-			log.Info().Msgf("uploading job ID is %s", jobID)
-			d.ChangeJobStatus(UploadJobStatusRunning)
-			d.JobID = jobID
+		return features, containsID
+	}(); containsID == false {
+		return errors.New("data must contain an id field")
+	} else {
+		d.Features = features
+	}
+
+	// count data lines, keep the first 10 lines as preview and sample the id values
+	idIndex, _ := detectIDColumn(headers)
+	var idValues []string
+	if count, previewJsonStr, err := func() (uint64, string, error) {
+		var records []map[string]string
+		var lines uint64
+		for {
+			recordLine, err := csvReader.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return 0, "", err
+			}
+			if len(idValues) < idMetaInfoDetectionSampleSize && recordLine[idIndex] != "" {
+				idValues = append(idValues, recordLine[idIndex])
+			}
+			if lines < 10 {
+				record := map[string]string{}
+				for i, value := range recordLine {
+					record[headers[i]] = value
+				}
+				records = append(records, record)
+			}
+			lines++
 		}
-		if err := d.Repo.UpdateJobInfoByUUID(d); err != nil {
-			log.Err(err).Str("data uuid", d.UUID).Msg("failed to update data job info")
-			return
+		jsonBytes, err := json.Marshal(records)
+		if err != nil {
+			return 0, "", err
 		}
-		// TODO: we should use a cron-like task to monitor the job status, preferably even be able to survive service restarts
-		func() {
-			for d.JobStatus == UploadJobStatusRunning {
-				if status, err := fateClient.QueryJobStatus(d.JobID); err != nil {
-					// TODO: exit after maximum number of retries and set job status to failed
-					log.Err(err).Str("data uuid", d.UUID).Msg("failed to query job status")
-				} else if status == "success" {
-					d.ChangeJobStatus(UploadJobStatusSucceeded)
-				} else if status == "canceled" || status == "timeout" || status == "failed" {
-					log.Error().Str("data uuid", d.UUID).Str("status", status).Send()
-					d.ChangeJobStatus(UploadJobStatusFailed)
-					return
-				} else {
-					log.Info().Str("data uuid", d.UUID).Str("status", status).Send()
-				}
-				time.Sleep(5 * time.Second)
+		return lines, string(jsonBytes), nil
+	}(); err != nil {
+		return errors.Wrap(err, "error parsing data records")
+	} else {
+		d.Count = count
+		d.Preview = previewJsonStr
+		d.IDMetaInfo = detectIDMetaInfo(idValues)
+	}
+	return nil
+}
+
+// uploadToFATE uploads the saved data file to FATE and monitors the upload job
+func (d *LocalData) uploadToFATE() {
+	absPath := filepath.Join(getBaseDir(), d.LocalFilePath)
+	log.Info().Msgf("uploading data file %s to FATE", absPath)
+	fateClient := fateclient.NewFATEFlowClient(d.UploadContext.FATEFlowHost, d.UploadContext.FATEFlowPort, d.UploadContext.FATEFlowIsHttps)
+	d.ChangeJobStatus(UploadJobStatusCreating)
+
+	uploadConf := fateclient.DataUploadRequest{
+		File:      absPath,
+		Head:      1,
+		Partition: 8, // XXX: use viper configuration instead of a hard-code one
+		Namespace: d.TableNamespace,
+		TableName: d.TableName,
+		Drop:      1,
+	}
+	confBytes, _ := json.Marshal(uploadConf)
+	d.JobConf = string(confBytes)
+	if jobID, err := fateClient.UploadData(uploadConf); err != nil {
+		log.Err(err).Msgf("failed to upload data %s to FATE", d.UUID)
+		d.ChangeJobStatus(UploadJobStatusFailed)
+		d.JobErrorMsg = err.Error()
+	} else {
+		log.Info().Msgf("uploading job ID is %s", jobID)
+		d.ChangeJobStatus(UploadJobStatusRunning)
+		d.JobID = jobID
+	}
+	if err := d.Repo.UpdateJobInfoByUUID(d); err != nil {
+		log.Err(err).Str("data uuid", d.UUID).Msg("failed to update data job info")
+		return
+	}
+	// TODO: we should use a cron-like task to monitor the job status, preferably even be able to survive service restarts
+	func() {
+		for d.JobStatus == UploadJobStatusRunning {
+			if status, err := fateClient.QueryJobStatus(d.JobID); err != nil {
+				// TODO: exit after maximum number of retries and set job status to failed
+				log.Err(err).Str("data uuid", d.UUID).Msg("failed to query job status")
+			} else if status == "success" {
+				d.ChangeJobStatus(UploadJobStatusSucceeded)
+			} else if status == "canceled" || status == "timeout" || status == "failed" {
+				log.Error().Str("data uuid", d.UUID).Str("status", status).Send()
+				d.ChangeJobStatus(UploadJobStatusFailed)
+				return
+			} else {
+				log.Info().Str("data uuid", d.UUID).Str("status", status).Send()
 			}
-		}()
-		log.Info().Str("data uuid", d.UUID).Str("job id", d.JobID).
-			Msgf("finished monitoring uploading job")
+			time.Sleep(5 * time.Second)
+		}
 	}()
+	log.Info().Str("data uuid", d.UUID).Str("job id", d.JobID).
+		Msgf("finished monitoring uploading job")
+}
+
+// GenerateProfile calculates the statistics of the data file asynchronously, labelColumn is the optional
+// column to calculate the label distribution
+func (d *LocalData) GenerateProfile(labelColumn string) error {
+	if d.LocalFilePath == "" {
+		return errors.New("this data was not uploaded via this service")
+	}
+	if d.Profile != nil && d.Profile.Status == LocalDataProfileStatusGenerating {
+		return errors.New("the profile is being generated")
+	}
+	if labelColumn != "" {
+		found := false
+		for _, column := range d.Column {
+			if column == labelColumn {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("label column %s doesn't exist", labelColumn)
+		}
+	}
+	d.Profile = &LocalDataProfile{
+		Status:      LocalDataProfileStatusGenerating,
+		LabelColumn: labelColumn,
+		UpdateTime:  time.Now(),
+	}
+	if err := d.Repo.UpdateProfileByUUID(d.UUID, d.Profile); err != nil {
+		return err
+	}
+	go func(dataUUID, absPath string) {
+		log.Info().Str("data uuid", dataUUID).Msg("generating data profile")
+		profile, err := generateLocalDataProfile(absPath, labelColumn)
+		if err != nil {
+			log.Err(err).Str("data uuid", dataUUID).Msg("failed to generate data profile")
+			profile = &LocalDataProfile{
+				Status:      LocalDataProfileStatusFailed,
+				ErrorMsg:    err.Error(),
+				LabelColumn: labelColumn,
+				UpdateTime:  time.Now(),
+			}
+		}
+		if err := d.Repo.UpdateProfileByUUID(dataUUID, profile); err != nil {
+			log.Err(err).Str("data uuid", dataUUID).Msg("failed to save data profile")
+			return
+		}
+		if profile.Status == LocalDataProfileStatusSucceeded && d.ProfileGeneratedCB != nil {
+			d.ProfileGeneratedCB(dataUUID, profile)
+		}
+	}(d.UUID, filepath.Join(getBaseDir(), d.LocalFilePath))
 	return nil
 }
 
+// getDefaultLabelColumn returns the "y" column, which is treated as the label column in this service
+func (d *LocalData) getDefaultLabelColumn() string {
+	for _, column := range d.Column {
+		if strings.ToLower(column) == "y" {
+			return column
+		}
+	}
+	return ""
+}
+
 // ChangeJobStatus upload the data's upload job status
 func (d *LocalData) ChangeJobStatus(newStatus UploadJobStatus) {
 	d.JobStatus = newStatus
@@ -413,6 +596,25 @@ func (d *LocalData) CreateFromExistingTable() error {
 	return nil
 }
 
+// validateDataFileName checks if the file name is valid
+func validateDataFileName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.Errorf("file name can not be empty")
+	}
+	filename := strings.Split(name, ".")[0]
+	var isStringAlphabetic = regexp.MustCompile(`^[a-zA-Z0-9\s\d\-_/]*$`).MatchString
+	if !isStringAlphabetic(filename) {
+		return errors.Errorf("file name can not contain special characters")
+	}
+	if len(filename) < 2 {
+		return errors.Errorf("file name is too short")
+	}
+	if len(filename) > 255 {
+		return errors.Errorf("file name is too long")
+	}
+	return nil
+}
+
 func getBaseDir() string {
 	once.Do(func() {
 		baseDir = viper.GetString("siteportal.localdata.basedir")
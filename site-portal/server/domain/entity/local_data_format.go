@@ -0,0 +1,432 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/valueobject"
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// LocalDataFormat is the format of the uploaded data file
+type LocalDataFormat uint8
+
+const (
+	// LocalDataFormatCSV is the default format, data uploaded before the format is recorded are all csv files
+	LocalDataFormatCSV LocalDataFormat = iota
+	LocalDataFormatParquet
+	LocalDataFormatJSONLines
+)
+
+const (
+	// parquetReadBatchSize is the number of rows to read from a parquet file each time
+	parquetReadBatchSize = 1000
+	// idMetaInfoDetectionSampleSize is the number of id values used to detect the id meta info
+	idMetaInfoDetectionSampleSize = 100
+)
+
+var (
+	// idColumnCandidates are the names of the columns that can be used as the id column, in the order of priority
+	idColumnCandidates = []string{"id", "_id", "sid", "uid", "sample_id", "user_id", "userid"}
+
+	md5Regexp       = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	sha256Regexp    = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	cellPhoneRegexp = regexp.MustCompile(`^1\d{10}$`)
+	imeiRegexp      = regexp.MustCompile(`^\d{15}$`)
+)
+
+func (f LocalDataFormat) String() string {
+	names := map[LocalDataFormat]string{
+		LocalDataFormatCSV:       "csv",
+		LocalDataFormatParquet:   "parquet",
+		LocalDataFormatJSONLines: "jsonl",
+	}
+	return names[f]
+}
+
+// MarshalJSON converts the format to string
+func (f LocalDataFormat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+// getLocalDataFormat returns the format based on the file extension
+func getLocalDataFormat(filename string) LocalDataFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".parquet":
+		return LocalDataFormatParquet
+	case ".jsonl", ".ndjson":
+		return LocalDataFormatJSONLines
+	default:
+		return LocalDataFormatCSV
+	}
+}
+
+// tableReader reads the records of a non-csv data file as rows of strings
+type tableReader interface {
+	// Headers returns the column names
+	Headers() []string
+	// Read returns the next record, or io.EOF when there is no more records
+	Read() ([]string, error)
+	// Close releases the resources of the reader
+	Close() error
+}
+
+// convertToCSV converts the data file to the csv format FATE flow expects, the detected id column is renamed
+// to "id" and moved to the first column
+func convertToCSV(format LocalDataFormat, srcPath, dstPath string) error {
+	var tr tableReader
+	var err error
+	switch format {
+	case LocalDataFormatParquet:
+		tr, err = newParquetTableReader(srcPath)
+	case LocalDataFormatJSONLines:
+		tr, err = newJSONLinesTableReader(srcPath)
+	default:
+		return errors.Errorf("cannot convert data of format %s", format.String())
+	}
+	if err != nil {
+		return err
+	}
+	defer tr.Close()
+
+	headers := tr.Headers()
+	idIndex, err := detectIDColumn(headers)
+	if err != nil {
+		return err
+	}
+	// the column order in the converted file
+	order := []int{idIndex}
+	csvHeaders := []string{"id"}
+	for i, header := range headers {
+		if i == idIndex {
+			continue
+		}
+		if strings.ToLower(header) == "id" {
+			return errors.Errorf("column %s conflicts with the id column", header)
+		}
+		order = append(order, i)
+		csvHeaders = append(csvHeaders, header)
+	}
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	csvWriter := csv.NewWriter(dst)
+	if err := csvWriter.Write(csvHeaders); err != nil {
+		return err
+	}
+	csvRecord := make([]string, len(order))
+	for {
+		record, err := tr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		for i, index := range order {
+			csvRecord[i] = record[index]
+		}
+		if err := csvWriter.Write(csvRecord); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// detectIDColumn returns the index of the column to be used as the id column
+func detectIDColumn(headers []string) (int, error) {
+	for _, candidate := range idColumnCandidates {
+		for i, header := range headers {
+			if strings.ToLower(header) == candidate {
+				return i, nil
+			}
+		}
+	}
+	return -1, errors.Errorf("data must contain an id field, supported names are: %v", idColumnCandidates)
+}
+
+// isIDColumn returns whether the column name is one of the id column candidates
+func isIDColumn(name string) bool {
+	for _, candidate := range idColumnCandidates {
+		if strings.ToLower(name) == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// detectIDMetaInfo guesses the id type and encryption type from the sample id values, nil is returned
+// if the values don't match any known pattern
+func detectIDMetaInfo(values []string) *valueobject.IDMetaInfo {
+	if len(values) == 0 {
+		return nil
+	}
+	matchAll := func(r *regexp.Regexp) bool {
+		for _, value := range values {
+			if !r.MatchString(value) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case matchAll(md5Regexp):
+		return &valueobject.IDMetaInfo{IDType: valueobject.IDTypeOther, IDEncryptionType: valueobject.IDEncryptionTypeMD5}
+	case matchAll(sha256Regexp):
+		return &valueobject.IDMetaInfo{IDType: valueobject.IDTypeOther, IDEncryptionType: valueobject.IDEncryptionTypeSHA256}
+	case matchAll(cellPhoneRegexp):
+		return &valueobject.IDMetaInfo{IDType: valueobject.IDTypeCellPhone, IDEncryptionType: valueobject.IDEncryptionTypeNone}
+	case matchAll(imeiRegexp):
+		return &valueobject.IDMetaInfo{IDType: valueobject.IDTypeDeviceIMEI, IDEncryptionType: valueobject.IDEncryptionTypeNone}
+	}
+	return nil
+}
+
+// jsonLinesTableReader reads a JSON Lines file, each line is a flat JSON object and the keys of
+// the first object are used as the headers
+type jsonLinesTableReader struct {
+	file    *os.File
+	decoder *json.Decoder
+	headers []string
+	index   map[string]int
+	first   map[string]interface{}
+}
+
+func newJSONLinesTableReader(path string) (*jsonLinesTableReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &jsonLinesTableReader{
+		file:    file,
+		decoder: json.NewDecoder(file),
+		index:   map[string]int{},
+	}
+	r.decoder.UseNumber()
+	if err := r.readHeaders(); err != nil {
+		_ = file.Close()
+		return nil, errors.Wrap(err, "failed to read the first record")
+	}
+	return r, nil
+}
+
+// readHeaders decodes the first object and keeps the order of its keys as the headers
+func (r *jsonLinesTableReader) readHeaders() error {
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return err
+	}
+	keyDecoder := json.NewDecoder(bytes.NewReader(raw))
+	keyDecoder.UseNumber()
+	if token, err := keyDecoder.Token(); err != nil {
+		return err
+	} else if token != json.Delim('{') {
+		return errors.New("the record is not a JSON object")
+	}
+	for keyDecoder.More() {
+		token, err := keyDecoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		r.index[key] = len(r.headers)
+		r.headers = append(r.headers, key)
+		// skip the value
+		var value json.RawMessage
+		if err := keyDecoder.Decode(&value); err != nil {
+			return err
+		}
+	}
+	if len(r.headers) == 0 {
+		return errors.New("the record has no fields")
+	}
+	firstDecoder := json.NewDecoder(bytes.NewReader(raw))
+	firstDecoder.UseNumber()
+	return firstDecoder.Decode(&r.first)
+}
+
+func (r *jsonLinesTableReader) Headers() []string {
+	return r.headers
+}
+
+func (r *jsonLinesTableReader) Read() ([]string, error) {
+	object := r.first
+	r.first = nil
+	if object == nil {
+		if err := r.decoder.Decode(&object); err != nil {
+			return nil, err
+		}
+	}
+	record := make([]string, len(r.headers))
+	for key, value := range object {
+		index, ok := r.index[key]
+		if !ok {
+			return nil, errors.Errorf("field %s doesn't exist in the first record", key)
+		}
+		switch v := value.(type) {
+		case nil:
+			record[index] = ""
+		case string:
+			record[index] = v
+		case json.Number:
+			record[index] = v.String()
+		case bool:
+			record[index] = strconv.FormatBool(v)
+		default:
+			return nil, errors.Errorf("nested value of field %s is not supported", key)
+		}
+	}
+	return record, nil
+}
+
+func (r *jsonLinesTableReader) Close() error {
+	return r.file.Close()
+}
+
+// parquetTableReader reads a parquet file with flat schema
+type parquetTableReader struct {
+	file    source.ParquetFile
+	reader  *reader.ParquetReader
+	headers []string
+	rows    []interface{}
+	left    int64
+}
+
+func newParquetTableReader(path string) (*parquetTableReader, error) {
+	file, err := local.NewLocalFileReader(path)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := reader.NewParquetReader(file, nil, 1)
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.Wrap(err, "failed to read parquet file")
+	}
+	r := &parquetTableReader{
+		file:   file,
+		reader: pr,
+		left:   pr.GetNumRows(),
+	}
+	// the first element is the root
+	for i, element := range pr.SchemaHandler.SchemaElements {
+		if i == 0 {
+			continue
+		}
+		// the element names are renamed by the reader, so use the original names as the headers
+		name := pr.SchemaHandler.GetExName(i)
+		if element.GetNumChildren() > 0 {
+			_ = r.Close()
+			return nil, errors.Errorf("nested column %s is not supported", name)
+		}
+		r.headers = append(r.headers, name)
+	}
+	return r, nil
+}
+
+func (r *parquetTableReader) Headers() []string {
+	return r.headers
+}
+
+func (r *parquetTableReader) Read() ([]string, error) {
+	if len(r.rows) == 0 {
+		if r.left <= 0 {
+			return nil, io.EOF
+		}
+		batchSize := int64(parquetReadBatchSize)
+		if r.left < batchSize {
+			batchSize = r.left
+		}
+		rows, err := r.reader.ReadByNumber(int(batchSize))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		r.rows = rows
+		r.left -= int64(len(rows))
+	}
+	row := reflect.ValueOf(r.rows[0])
+	r.rows = r.rows[1:]
+	if row.Kind() == reflect.Ptr {
+		row = row.Elem()
+	}
+	if row.NumField() != len(r.headers) {
+		return nil, errors.Errorf("unexpected number of fields: %d", row.NumField())
+	}
+	record := make([]string, len(r.headers))
+	for i := range record {
+		value, err := formatParquetValue(row.Field(i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read column %s", r.headers[i])
+		}
+		record[i] = value
+	}
+	return record, nil
+}
+
+func (r *parquetTableReader) Close() error {
+	r.reader.ReadStop()
+	return r.file.Close()
+}
+
+// formatParquetValue converts the primitive value to string, null values become empty strings
+func formatParquetValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	default:
+		return "", errors.Errorf("unsupported value type %s", v.Type().String())
+	}
+}
+
+// getConvertedFileName returns the name of the converted csv file
+func getConvertedFileName(filename string) string {
+	base := filepath.Base(filename)
+	return fmt.Sprintf("%s.csv", strings.TrimSuffix(base, filepath.Ext(base)))
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/valueobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+type parquetTestRecord struct {
+	X0  float64 `parquet:"name=x0, type=DOUBLE"`
+	UID string  `parquet:"name=uid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Y   *int32  `parquet:"name=y, type=INT32, repetitiontype=OPTIONAL"`
+}
+
+func TestConvertToCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-data-format-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Equal(t, LocalDataFormatCSV, getLocalDataFormat("data.csv"))
+	assert.Equal(t, LocalDataFormatParquet, getLocalDataFormat("data.PARQUET"))
+	assert.Equal(t, LocalDataFormatJSONLines, getLocalDataFormat("data.jsonl"))
+	assert.Equal(t, "data.csv", getConvertedFileName("data.jsonl"))
+
+	jsonlPath := filepath.Join(dir, "data.jsonl")
+	assert.NoError(t, ioutil.WriteFile(jsonlPath, []byte(`{"x0": 0.5, "ID": "a", "y": true}
+{"ID": "b", "x0": null, "y": false}
+`), 0600))
+	csvPath := filepath.Join(dir, "data.csv")
+	assert.NoError(t, convertToCSV(LocalDataFormatJSONLines, jsonlPath, csvPath))
+	content, err := ioutil.ReadFile(csvPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,x0,y\na,0.5,true\nb,,false\n", string(content))
+
+	assert.NoError(t, ioutil.WriteFile(jsonlPath, []byte(`{"x0": 0.5, "id": "a"}
+{"id": "b", "x1": 1}
+`), 0600))
+	assert.Error(t, convertToCSV(LocalDataFormatJSONLines, jsonlPath, csvPath))
+	assert.NoError(t, ioutil.WriteFile(jsonlPath, []byte(`{"x0": 0.5, "name": "a"}`), 0600))
+	assert.Error(t, convertToCSV(LocalDataFormatJSONLines, jsonlPath, csvPath))
+
+	parquetPath := filepath.Join(dir, "data.parquet")
+	func() {
+		file, err := local.NewLocalFileWriter(parquetPath)
+		assert.NoError(t, err)
+		defer file.Close()
+		pw, err := writer.NewParquetWriter(file, new(parquetTestRecord), 1)
+		assert.NoError(t, err)
+		label := int32(1)
+		assert.NoError(t, pw.Write(parquetTestRecord{X0: 1.5, UID: "u1", Y: &label}))
+		assert.NoError(t, pw.Write(parquetTestRecord{X0: 2, UID: "u2"}))
+		assert.NoError(t, pw.WriteStop())
+	}()
+	assert.NoError(t, convertToCSV(LocalDataFormatParquet, parquetPath, csvPath))
+	content, err = ioutil.ReadFile(csvPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,x0,y\nu1,1.5,1\nu2,2,\n", string(content))
+}
+
+func TestDetectIDMetaInfo(t *testing.T) {
+	assert.Nil(t, detectIDMetaInfo(nil))
+	assert.Nil(t, detectIDMetaInfo([]string{"1", "2"}))
+	assert.Equal(t, &valueobject.IDMetaInfo{IDType: valueobject.IDTypeCellPhone},
+		detectIDMetaInfo([]string{"13800000000", "13900000000"}))
+	assert.Equal(t, &valueobject.IDMetaInfo{IDType: valueobject.IDTypeDeviceIMEI},
+		detectIDMetaInfo([]string{"490154203237518"}))
+	assert.Equal(t, &valueobject.IDMetaInfo{IDEncryptionType: valueobject.IDEncryptionTypeMD5},
+		detectIDMetaInfo([]string{"d41d8cd98f00b204e9800998ecf8427e"}))
+	assert.Equal(t, &valueobject.IDMetaInfo{IDEncryptionType: valueobject.IDEncryptionTypeSHA256},
+		detectIDMetaInfo([]string{"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}))
+}
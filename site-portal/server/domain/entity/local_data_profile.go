@@ -0,0 +1,288 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// profileMaxTrackedValues is the max number of distinct values tracked for a column, to limit the memory usage
+	profileMaxTrackedValues = 10000
+	// profileTopValueCount is the number of most frequent values kept for a categorical column
+	profileTopValueCount = 10
+	// profileTopValueMinCount is the min frequency of a value to be included in the top values, so that rare
+	// values, which may identify individual records, are not exposed
+	profileTopValueMinCount = 5
+	// profileMaxLabelClasses is the max number of label classes to include in the label distribution
+	profileMaxLabelClasses = 100
+)
+
+// LocalDataProfile contains the aggregated statistics of a data, no data records are included
+type LocalDataProfile struct {
+	// Status is the status of the profile generation
+	Status LocalDataProfileStatus `json:"status"`
+	// ErrorMsg records the error if the generation failed
+	ErrorMsg string `json:"error_msg"`
+	// RowCount is the number of data records
+	RowCount uint64 `json:"row_count"`
+	// LabelColumn is the column to calculate the label distribution
+	LabelColumn string `json:"label_column"`
+	// LabelDistribution is the number of records of each label value
+	LabelDistribution map[string]uint64 `json:"label_distribution"`
+	// Columns contains the statistics of each column
+	Columns []LocalDataColumnProfile `json:"columns"`
+	// UpdateTime is when the profile is generated
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// LocalDataProfileStatus is the status of the profile generation
+type LocalDataProfileStatus uint8
+
+const (
+	LocalDataProfileStatusUnknown LocalDataProfileStatus = iota
+	LocalDataProfileStatusGenerating
+	LocalDataProfileStatusFailed
+	LocalDataProfileStatusSucceeded
+)
+
+// LocalDataColumnType is the inferred type of a column
+type LocalDataColumnType string
+
+const (
+	LocalDataColumnTypeID          LocalDataColumnType = "id"
+	LocalDataColumnTypeNumeric     LocalDataColumnType = "numeric"
+	LocalDataColumnTypeCategorical LocalDataColumnType = "categorical"
+)
+
+// LocalDataColumnProfile contains the statistics of a column
+type LocalDataColumnProfile struct {
+	Name string              `json:"name"`
+	Type LocalDataColumnType `json:"type"`
+	// MissingCount is the number of empty or "NA"-like values
+	MissingCount uint64 `json:"missing_count"`
+	// MissingRatio is the ratio of the missing values in all records
+	MissingRatio float64 `json:"missing_ratio"`
+	// Cardinality is the number of distinct values, which is a lower bound if CardinalityOverflow is true
+	Cardinality uint64 `json:"cardinality"`
+	// CardinalityOverflow means there are more distinct values than the tracked ones
+	CardinalityOverflow bool `json:"cardinality_overflow"`
+	// Numeric contains the statistics of a numeric column
+	Numeric *LocalDataNumericStats `json:"numeric,omitempty"`
+	// TopValues contains the most frequent values of a categorical column
+	TopValues []LocalDataValueCount `json:"top_values,omitempty"`
+}
+
+// LocalDataNumericStats contains the statistics of a numeric column
+type LocalDataNumericStats struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+	Std  float64 `json:"std"`
+}
+
+// LocalDataValueCount is a value and its frequency
+type LocalDataValueCount struct {
+	Value string `json:"value"`
+	Count uint64 `json:"count"`
+}
+
+func (p LocalDataProfile) Value() (driver.Value, error) {
+	bJson, err := json.Marshal(p)
+	return bJson, err
+}
+
+func (p *LocalDataProfile) Scan(v interface{}) error {
+	return json.Unmarshal([]byte(v.(string)), p)
+}
+
+// columnProfiler accumulates the statistics of a column in one pass
+type columnProfiler struct {
+	name         string
+	missingCount uint64
+	// numeric stats using Welford's algorithm
+	numericCount uint64
+	nonNumeric   bool
+	min          float64
+	max          float64
+	mean         float64
+	m2           float64
+	// distinct values and their counts
+	valueCounts map[string]uint64
+	overflow    bool
+}
+
+func (c *columnProfiler) add(value string) {
+	value = strings.TrimSpace(value)
+	if isMissingValue(value) {
+		c.missingCount++
+		return
+	}
+	if count, ok := c.valueCounts[value]; ok {
+		c.valueCounts[value] = count + 1
+	} else if len(c.valueCounts) < profileMaxTrackedValues {
+		c.valueCounts[value] = 1
+	} else {
+		c.overflow = true
+	}
+	if c.nonNumeric {
+		return
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		c.nonNumeric = true
+		return
+	}
+	c.numericCount++
+	if c.numericCount == 1 || number < c.min {
+		c.min = number
+	}
+	if c.numericCount == 1 || number > c.max {
+		c.max = number
+	}
+	delta := number - c.mean
+	c.mean += delta / float64(c.numericCount)
+	c.m2 += delta * (number - c.mean)
+}
+
+func (c *columnProfiler) result(rowCount uint64) LocalDataColumnProfile {
+	profile := LocalDataColumnProfile{
+		Name:                c.name,
+		MissingCount:        c.missingCount,
+		Cardinality:         uint64(len(c.valueCounts)),
+		CardinalityOverflow: c.overflow,
+	}
+	if rowCount > 0 {
+		profile.MissingRatio = float64(c.missingCount) / float64(rowCount)
+	}
+	switch {
+	case isIDColumn(c.name):
+		// the values of the id columns are never exposed
+		profile.Type = LocalDataColumnTypeID
+	case !c.nonNumeric && c.numericCount > 0:
+		profile.Type = LocalDataColumnTypeNumeric
+		profile.Numeric = &LocalDataNumericStats{
+			Min:  c.min,
+			Max:  c.max,
+			Mean: c.mean,
+			Std:  math.Sqrt(c.m2 / float64(c.numericCount)),
+		}
+	default:
+		profile.Type = LocalDataColumnTypeCategorical
+		profile.TopValues = c.topValues()
+	}
+	return profile
+}
+
+func (c *columnProfiler) topValues() []LocalDataValueCount {
+	var values []LocalDataValueCount
+	for value, count := range c.valueCounts {
+		if count >= profileTopValueMinCount {
+			values = append(values, LocalDataValueCount{Value: value, Count: count})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count == values[j].Count {
+			return values[i].Value < values[j].Value
+		}
+		return values[i].Count > values[j].Count
+	})
+	if len(values) > profileTopValueCount {
+		values = values[:profileTopValueCount]
+	}
+	return values
+}
+
+func isMissingValue(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "na", "n/a", "nan", "null", "none":
+		return true
+	}
+	return false
+}
+
+// generateLocalDataProfile reads the csv file and calculates the profile in one pass
+func generateLocalDataProfile(path string, labelColumn string) (*LocalDataProfile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	csvReader := csv.NewReader(file)
+	csvReader.ReuseRecord = true
+	headers, err := csvReader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read headers")
+	}
+	labelIndex := -1
+	columns := make([]*columnProfiler, len(headers))
+	for i, header := range headers {
+		columns[i] = &columnProfiler{
+			name:        header,
+			valueCounts: map[string]uint64{},
+		}
+		if labelColumn != "" && header == labelColumn {
+			labelIndex = i
+		}
+	}
+	if labelColumn != "" && labelIndex < 0 {
+		return nil, errors.Errorf("label column %s doesn't exist", labelColumn)
+	}
+	var rowCount uint64
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read data records")
+		}
+		for i, value := range record {
+			columns[i].add(value)
+		}
+		rowCount++
+	}
+
+	profile := &LocalDataProfile{
+		Status:      LocalDataProfileStatusSucceeded,
+		RowCount:    rowCount,
+		LabelColumn: labelColumn,
+		Columns:     make([]LocalDataColumnProfile, len(columns)),
+		UpdateTime:  time.Now(),
+	}
+	for i, column := range columns {
+		profile.Columns[i] = column.result(rowCount)
+	}
+	if labelIndex >= 0 {
+		// a label column with too many classes is likely a regression target, so skip the distribution
+		if label := columns[labelIndex]; !label.overflow && len(label.valueCounts) <= profileMaxLabelClasses {
+			profile.LabelDistribution = map[string]uint64{}
+			for value, count := range label.valueCounts {
+				profile.LabelDistribution[value] = count
+			}
+		}
+	}
+	return profile, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateLocalDataProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-data-profile-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lines := []string{"id,y,x0,x1"}
+	for i := 0; i < 10; i++ {
+		x1 := "a"
+		if i%5 == 0 {
+			x1 = "b"
+		}
+		x0 := "1"
+		if i == 9 {
+			x0 = "NA"
+		}
+		lines = append(lines, strings.Join([]string{string(rune('0' + i)), string(rune('0' + i%2)), x0, x1}, ","))
+	}
+	path := filepath.Join(dir, "data.csv")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600))
+
+	_, err = generateLocalDataProfile(path, "label")
+	assert.Error(t, err)
+
+	profile, err := generateLocalDataProfile(path, "y")
+	assert.NoError(t, err)
+	assert.Equal(t, LocalDataProfileStatusSucceeded, profile.Status)
+	assert.Equal(t, uint64(10), profile.RowCount)
+	assert.Equal(t, map[string]uint64{"0": 5, "1": 5}, profile.LabelDistribution)
+	assert.Len(t, profile.Columns, 4)
+
+	id := profile.Columns[0]
+	assert.Equal(t, LocalDataColumnTypeID, id.Type)
+	assert.Equal(t, uint64(10), id.Cardinality)
+	assert.Nil(t, id.Numeric)
+	assert.Empty(t, id.TopValues)
+
+	x0 := profile.Columns[2]
+	assert.Equal(t, LocalDataColumnTypeNumeric, x0.Type)
+	assert.Equal(t, uint64(1), x0.MissingCount)
+	assert.InDelta(t, 0.1, x0.MissingRatio, 1e-9)
+	assert.Equal(t, &LocalDataNumericStats{Min: 1, Max: 1, Mean: 1, Std: 0}, x0.Numeric)
+
+	// "b" only appears twice so it is not included in the top values
+	x1 := profile.Columns[3]
+	assert.Equal(t, LocalDataColumnTypeCategorical, x1.Type)
+	assert.Equal(t, uint64(2), x1.Cardinality)
+	assert.Equal(t, []LocalDataValueCount{{Value: "a", Count: 8}}, x1.TopValues)
+}
+
+func TestGenerateLocalDataProfile_IDColumns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-data-profile-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the values of user_id repeat often enough to be included in the top values if it were categorical
+	lines := []string{"sample_id,User_ID,x0"}
+	for i := 0; i < 10; i++ {
+		lines = append(lines, strings.Join([]string{string(rune('0' + i)), "u" + string(rune('0'+i%2)), "a"}, ","))
+	}
+	path := filepath.Join(dir, "data.csv")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600))
+
+	profile, err := generateLocalDataProfile(path, "")
+	assert.NoError(t, err)
+	assert.Len(t, profile.Columns, 3)
+	for _, column := range profile.Columns[:2] {
+		assert.Equal(t, LocalDataColumnTypeID, column.Type, column.Name)
+		assert.Nil(t, column.Numeric, column.Name)
+		assert.Empty(t, column.TopValues, column.Name)
+	}
+	assert.Equal(t, []LocalDataValueCount{{Value: "a", Count: 10}}, profile.Columns[2].TopValues)
+}
@@ -0,0 +1,272 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxUploadSessionChunkCount is the max number of chunks a file can be split into
+	maxUploadSessionChunkCount = 10000
+	// uploadSessionDirName is the folder under the base dir to store uploaded chunks
+	uploadSessionDirName = "upload-sessions"
+)
+
+// LocalDataUploadSession tracks a resumable upload of a large data file, which is split into fixed-size chunks
+type LocalDataUploadSession struct {
+	gorm.Model
+	UUID string `json:"uuid" gorm:"type:varchar(36);index;unique"`
+	// Name is the name of the data to be created
+	Name string `json:"name" gorm:"type:varchar(255);not null"`
+	// Description is the description of the data to be created
+	Description string `json:"description" gorm:"type:text"`
+	// FileName is the name of the data file
+	FileName string `json:"file_name" gorm:"type:varchar(255)"`
+	// FileSize is the total size of the file in bytes
+	FileSize int64 `json:"file_size"`
+	// ChunkSize is the size of each chunk in bytes, the last chunk can be smaller
+	ChunkSize int64 `json:"chunk_size"`
+	// ChunkCount is the number of chunks of the file
+	ChunkCount uint `json:"chunk_count"`
+	// Checksum is the optional hex-encoded SHA256 checksum of the whole file
+	Checksum string `json:"checksum" gorm:"type:varchar(64)"`
+	// Status is the status of the session
+	Status LocalDataUploadSessionStatus `json:"status"`
+	// DataUUID is the uuid of the created data when the session is completed
+	DataUUID string `json:"data_uuid" gorm:"type:varchar(36)"`
+	// Repo is used to store the necessary data into the storage
+	Repo repo.LocalDataUploadSessionRepository `json:"-" gorm:"-"`
+}
+
+// LocalDataUploadSessionStatus is the status of the upload session
+type LocalDataUploadSessionStatus uint8
+
+const (
+	LocalDataUploadSessionStatusUnknown LocalDataUploadSessionStatus = iota
+	LocalDataUploadSessionStatusActive
+	LocalDataUploadSessionStatusCompleted
+	LocalDataUploadSessionStatusCompleting
+)
+
+// Init validates the session settings, prepares the chunk folder and saves the session
+func (s *LocalDataUploadSession) Init() error {
+	if err := validateDataFileName(s.FileName); err != nil {
+		return err
+	}
+	if s.FileSize <= 0 {
+		return errors.New("file size must be positive")
+	}
+	if s.ChunkSize <= 0 {
+		return errors.New("chunk size must be positive")
+	}
+	chunkCount := (s.FileSize + s.ChunkSize - 1) / s.ChunkSize
+	if chunkCount > maxUploadSessionChunkCount {
+		return errors.Errorf("too many chunks: %d, please use a larger chunk size", chunkCount)
+	}
+	if s.Checksum != "" {
+		if _, err := hex.DecodeString(s.Checksum); err != nil || len(s.Checksum) != sha256.Size*2 {
+			return errors.New("checksum should be a hex-encoded SHA256 checksum")
+		}
+	}
+	s.UUID = uuid.NewV4().String()
+	s.ChunkCount = uint(chunkCount)
+	s.Status = LocalDataUploadSessionStatusActive
+	if err := os.MkdirAll(s.getDir(), 0700); err != nil {
+		return err
+	}
+	return s.Repo.Create(s)
+}
+
+// UploadPart saves the content of the chunk specified by index, after checking its size and the hex-encoded SHA256
+// checksum. A chunk can be uploaded again to overwrite the previous content.
+func (s *LocalDataUploadSession) UploadPart(index uint, src io.Reader, checksum string) error {
+	if s.Status != LocalDataUploadSessionStatusActive {
+		return errors.New("the upload session is not active")
+	}
+	if index >= s.ChunkCount {
+		return errors.Errorf("invalid chunk index %d, chunk count is %d", index, s.ChunkCount)
+	}
+	if checksum == "" {
+		return errors.New("missing chunk checksum")
+	}
+	// write to a temp file first so a broken upload won't leave an incomplete chunk
+	tmpFile, err := ioutil.TempFile(s.getDir(), fmt.Sprintf("%s.*.tmp", s.getPartFileName(index)))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if err := func() error {
+		defer tmpFile.Close()
+		expectedSize := s.getPartSize(index)
+		hash := sha256.New()
+		written, err := io.Copy(io.MultiWriter(tmpFile, hash), io.LimitReader(src, expectedSize+1))
+		if err != nil {
+			return err
+		}
+		if written != expectedSize {
+			return errors.Errorf("invalid chunk size, expected: %d, actual: %d", expectedSize, written)
+		}
+		if sum := hex.EncodeToString(hash.Sum(nil)); sum != strings.ToLower(checksum) {
+			return errors.Errorf("checksum mismatch, expected: %s, actual: %s", checksum, sum)
+		}
+		return nil
+	}(); err != nil {
+		return errors.Wrapf(err, "failed to save chunk %d", index)
+	}
+	return os.Rename(tmpFile.Name(), filepath.Join(s.getDir(), s.getPartFileName(index)))
+}
+
+// GetUploadedParts returns the indexes of the chunks that have been uploaded
+func (s *LocalDataUploadSession) GetUploadedParts() ([]uint, error) {
+	parts := []uint{}
+	if s.Status != LocalDataUploadSessionStatusActive {
+		return parts, nil
+	}
+	for index := uint(0); index < s.ChunkCount; index++ {
+		if _, err := os.Stat(filepath.Join(s.getDir(), s.getPartFileName(index))); err == nil {
+			parts = append(parts, index)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+// Complete checks all the chunks are uploaded and imports them as the specified data. The chunks are removed
+// after the import finishes. The session is marked as completing first, so concurrent calls won't import the
+// chunks more than once.
+func (s *LocalDataUploadSession) Complete(data *LocalData) error {
+	if s.Status != LocalDataUploadSessionStatusActive {
+		return errors.New("the upload session is not active")
+	}
+	parts, err := s.GetUploadedParts()
+	if err != nil {
+		return err
+	}
+	if uint(len(parts)) != s.ChunkCount {
+		return errors.Errorf("only %d of %d chunks are uploaded", len(parts), s.ChunkCount)
+	}
+	s.Status = LocalDataUploadSessionStatusCompleting
+	if err := s.Repo.CompareAndUpdateStatusByUUID(s, LocalDataUploadSessionStatusActive); err != nil {
+		s.Status = LocalDataUploadSessionStatusActive
+		if errors.Is(err, repo.ErrUploadSessionStatusChanged) {
+			return errors.New("the upload session is being completed by another request")
+		}
+		return err
+	}
+	data.Name = s.Name
+	data.Description = s.Description
+	if err := data.Import(s.FileName, &uploadSessionPartsReader{
+		dir:   s.getDir(),
+		count: s.ChunkCount,
+		name:  s.getPartFileName,
+	}, s.Checksum); err != nil {
+		// so the user can retry the completion
+		s.Status = LocalDataUploadSessionStatusActive
+		if restoreErr := s.Repo.CompareAndUpdateStatusByUUID(s, LocalDataUploadSessionStatusCompleting); restoreErr != nil {
+			log.Err(restoreErr).Str("session uuid", s.UUID).Msg("failed to restore the upload session status")
+		}
+		return err
+	}
+	s.Status = LocalDataUploadSessionStatusCompleted
+	s.DataUUID = data.UUID
+	return s.Repo.CompareAndUpdateStatusByUUID(s, LocalDataUploadSessionStatusCompleting)
+}
+
+// Abort removes the uploaded chunks and the session
+func (s *LocalDataUploadSession) Abort() error {
+	if s.Status == LocalDataUploadSessionStatusCompleting {
+		return errors.New("the upload session is being completed")
+	}
+	if s.Status == LocalDataUploadSessionStatusActive {
+		log.Info().Str("session uuid", s.UUID).Msg("removing uploaded chunks")
+		if err := os.RemoveAll(s.getDir()); err != nil {
+			return errors.Wrap(err, "failed to remove uploaded chunks")
+		}
+	}
+	return s.Repo.DeleteByUUID(s.UUID)
+}
+
+func (s *LocalDataUploadSession) getDir() string {
+	return filepath.Join(getBaseDir(), uploadSessionDirName, s.UUID)
+}
+
+func (s *LocalDataUploadSession) getPartFileName(index uint) string {
+	return fmt.Sprintf("part-%05d", index)
+}
+
+func (s *LocalDataUploadSession) getPartSize(index uint) int64 {
+	if index == s.ChunkCount-1 {
+		return s.FileSize - int64(index)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// uploadSessionPartsReader reads the chunks sequentially as a whole file, the chunk folder is removed when closed
+type uploadSessionPartsReader struct {
+	dir     string
+	count   uint
+	name    func(uint) string
+	next    uint
+	current *os.File
+}
+
+func (r *uploadSessionPartsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next >= r.count {
+				return 0, io.EOF
+			}
+			f, err := os.Open(filepath.Join(r.dir, r.name(r.next)))
+			if err != nil {
+				return 0, err
+			}
+			r.current = f
+			r.next++
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			_ = r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *uploadSessionPartsReader) Close() error {
+	if r.current != nil {
+		_ = r.current.Close()
+		r.current = nil
+	}
+	return os.RemoveAll(r.dir)
+}
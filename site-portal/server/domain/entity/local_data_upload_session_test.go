@@ -0,0 +1,149 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUploadSessionRepo struct {
+	sessions map[string]*LocalDataUploadSession
+}
+
+func (r *fakeUploadSessionRepo) Create(instance interface{}) error {
+	session := *instance.(*LocalDataUploadSession)
+	r.sessions[session.UUID] = &session
+	return nil
+}
+
+func (r *fakeUploadSessionRepo) GetByUUID(uuid string) (interface{}, error) {
+	session := *r.sessions[uuid]
+	return &session, nil
+}
+
+func (r *fakeUploadSessionRepo) CompareAndUpdateStatusByUUID(instance interface{}, oldStatus interface{}) error {
+	session := instance.(*LocalDataUploadSession)
+	stored, ok := r.sessions[session.UUID]
+	if !ok || stored.Status != oldStatus.(LocalDataUploadSessionStatus) {
+		return repo.ErrUploadSessionStatusChanged
+	}
+	stored.Status = session.Status
+	stored.DataUUID = session.DataUUID
+	return nil
+}
+
+func (r *fakeUploadSessionRepo) DeleteByUUID(uuid string) error {
+	delete(r.sessions, uuid)
+	return nil
+}
+
+func checksumOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLocalDataUploadSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-data-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	viper.Set("siteportal.localdata.basedir", dir)
+
+	content := []byte("id,y,x0\n1,0,0.1\n2,1,0.2\n")
+	session := &LocalDataUploadSession{
+		Name:      "test",
+		FileName:  "test.csv",
+		FileSize:  int64(len(content)),
+		ChunkSize: 10,
+		Repo:      &fakeUploadSessionRepo{sessions: map[string]*LocalDataUploadSession{}},
+	}
+	assert.NoError(t, session.Init())
+	assert.Equal(t, uint(3), session.ChunkCount)
+
+	// invalid index, size and checksum
+	assert.Error(t, session.UploadPart(3, bytes.NewReader(content[:10]), checksumOf(content[:10])))
+	assert.Error(t, session.UploadPart(0, bytes.NewReader(content[:9]), checksumOf(content[:9])))
+	assert.Error(t, session.UploadPart(0, bytes.NewReader(content[:10]), checksumOf(content[1:11])))
+
+	assert.NoError(t, session.UploadPart(2, bytes.NewReader(content[20:]), checksumOf(content[20:])))
+	assert.NoError(t, session.UploadPart(0, bytes.NewReader(content[:10]), checksumOf(content[:10])))
+	parts, err := session.GetUploadedParts()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{0, 2}, parts)
+	assert.Error(t, session.Complete(&LocalData{}))
+
+	assert.NoError(t, session.UploadPart(1, bytes.NewReader(content[10:20]), checksumOf(content[10:20])))
+	reader := &uploadSessionPartsReader{
+		dir:   session.getDir(),
+		count: session.ChunkCount,
+		name:  session.getPartFileName,
+	}
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	localData := &LocalData{}
+	assert.NoError(t, localData.parseCSV(bytes.NewReader(data)))
+	assert.Equal(t, uint64(2), localData.Count)
+	assert.Equal(t, Headers{"x0"}, localData.Features)
+
+	assert.NoError(t, reader.Close())
+	_, err = os.Stat(filepath.Join(dir, uploadSessionDirName, session.UUID))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalDataUploadSession_ConcurrentComplete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-data-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	viper.Set("siteportal.localdata.basedir", dir)
+
+	content := []byte("id,y,x0\n1,0,0.1\n")
+	sessionRepo := &fakeUploadSessionRepo{sessions: map[string]*LocalDataUploadSession{}}
+	session := &LocalDataUploadSession{
+		Name:      "test",
+		FileName:  "test.csv",
+		FileSize:  int64(len(content)),
+		ChunkSize: int64(len(content)),
+		Repo:      sessionRepo,
+	}
+	assert.NoError(t, session.Init())
+	assert.NoError(t, session.UploadPart(0, bytes.NewReader(content), checksumOf(content)))
+
+	// another request loaded the session before this one marks it as completing
+	instance, err := sessionRepo.GetByUUID(session.UUID)
+	assert.NoError(t, err)
+	staleSession := instance.(*LocalDataUploadSession)
+	staleSession.Repo = sessionRepo
+
+	// the import fails without the FATE flow info, and the session becomes active again so it can be retried
+	assert.Error(t, session.Complete(&LocalData{}))
+	assert.Equal(t, LocalDataUploadSessionStatusActive, sessionRepo.sessions[session.UUID].Status)
+
+	sessionRepo.sessions[session.UUID].Status = LocalDataUploadSessionStatusCompleting
+	err = staleSession.Complete(&LocalData{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "another request")
+	assert.Equal(t, LocalDataUploadSessionStatusCompleting, sessionRepo.sessions[session.UUID].Status)
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
@@ -38,6 +39,8 @@ type ProjectData struct {
 	TableNamespace string                     `json:"table_namespace" gorm:"type:varchar(255)"`
 	CreationTime   time.Time                  `json:"creation_time"`
 	UpdateTime     time.Time                  `json:"update_time"`
+	UsagePolicy    *ProjectDataUsagePolicy    `json:"usage_policy" gorm:"type:text"`
+	Profile        *LocalDataProfile          `json:"profile" gorm:"type:text"`
 	Repo           repo.ProjectDataRepository `json:"-" gorm:"-"`
 }
 
@@ -58,3 +61,12 @@ const (
 	ProjectDataStatusDismissed
 	ProjectDataStatusAssociated
 )
+
+// UpdateUsagePolicy changes the usage policy of this data association
+func (d *ProjectData) UpdateUsagePolicy(policy *ProjectDataUsagePolicy) error {
+	if d.Type != ProjectDataTypeLocal {
+		return errors.New("cannot set usage policy for data from other sites")
+	}
+	d.UsagePolicy = policy
+	return d.Repo.UpdateUsagePolicyByUUID(d)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ProjectDataUsagePolicy contains the rules the data owner defines for using an associated data in jobs
+type ProjectDataUsagePolicy struct {
+	// Enabled is whether this policy is evaluated for jobs initiated by other sites
+	Enabled bool `json:"enabled"`
+	// AllowedJobTypes is the list of job types that can use the data, empty means all types are allowed
+	AllowedJobTypes []JobType `json:"allowed_job_types"`
+	// AllowedAlgorithmTypes is the list of algorithms that can use the data, empty means all algorithms are allowed
+	AllowedAlgorithmTypes []JobAlgorithmType `json:"allowed_algorithm_types"`
+	// AllowedSiteUUIDs is the list of partner sites that can use the data, empty means all sites are allowed
+	AllowedSiteUUIDs []string `json:"allowed_site_uuids"`
+	// MaxJobCount is the max number of approved or locally initiated jobs using the data, 0 means no limit
+	MaxJobCount uint `json:"max_job_count"`
+	// DataResultDownloadAllowed is whether the output data of jobs using the data can be downloaded
+	DataResultDownloadAllowed bool `json:"data_result_download_allowed"`
+}
+
+// ProjectDataUsageContext contains the info of a job requesting to use the data
+type ProjectDataUsageContext struct {
+	// JobType is the type of the requesting job
+	JobType JobType
+	// AlgorithmType is the algorithm of the requesting job
+	AlgorithmType JobAlgorithmType
+	// PartnerSiteUUIDs contains all the other sites in the requesting job
+	PartnerSiteUUIDs []string
+	// ApprovedJobCount is the number of jobs that have used the data, including the ones initiated by this site
+	ApprovedJobCount uint
+}
+
+func (p ProjectDataUsagePolicy) Value() (driver.Value, error) {
+	bJson, err := json.Marshal(p)
+	return bJson, err
+}
+
+func (p *ProjectDataUsagePolicy) Scan(v interface{}) error {
+	return json.Unmarshal([]byte(v.(string)), p)
+}
+
+// Evaluate returns an error describing the violated rule if the job is not allowed to use the data
+func (p *ProjectDataUsagePolicy) Evaluate(context *ProjectDataUsageContext) error {
+	if p == nil || !p.Enabled {
+		return nil
+	}
+	if len(p.AllowedJobTypes) > 0 {
+		allowed := false
+		for _, jobType := range p.AllowedJobTypes {
+			if jobType == context.JobType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.Errorf("job type %s is not allowed to use the data", context.JobType.String())
+		}
+	}
+	// PSI jobs don't use any algorithm
+	if len(p.AllowedAlgorithmTypes) > 0 && context.JobType != JobTypePSI {
+		allowed := false
+		for _, algorithmType := range p.AllowedAlgorithmTypes {
+			if algorithmType == context.AlgorithmType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.Errorf("algorithm type %d is not allowed to use the data", context.AlgorithmType)
+		}
+	}
+	if len(p.AllowedSiteUUIDs) > 0 {
+		allowedSites := map[string]bool{}
+		for _, siteUUID := range p.AllowedSiteUUIDs {
+			allowedSites[siteUUID] = true
+		}
+		for _, siteUUID := range context.PartnerSiteUUIDs {
+			if !allowedSites[siteUUID] {
+				return errors.Errorf("site %s is not allowed to use the data", siteUUID)
+			}
+		}
+	}
+	if p.MaxJobCount > 0 && context.ApprovedJobCount >= p.MaxJobCount {
+		return errors.Errorf("the data has reached its max job count %d", p.MaxJobCount)
+	}
+	return nil
+}
+
+// CheckDataResultDownload returns an error if the output data of a job using the data cannot be downloaded
+func (p *ProjectDataUsagePolicy) CheckDataResultDownload() error {
+	if p == nil || !p.Enabled || p.DataResultDownloadAllowed {
+		return nil
+	}
+	return errors.New("downloading output data is not allowed by the data usage policy")
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectDataUsagePolicyEvaluate(t *testing.T) {
+	context := &ProjectDataUsageContext{
+		JobType:          JobTypeTraining,
+		AlgorithmType:    JobAlgorithmTypeHeteroLR,
+		PartnerSiteUUIDs: []string{"site-a"},
+		ApprovedJobCount: 2,
+	}
+
+	var nilPolicy *ProjectDataUsagePolicy
+	assert.NoError(t, nilPolicy.Evaluate(context))
+	assert.NoError(t, (&ProjectDataUsagePolicy{AllowedJobTypes: []JobType{JobTypePSI}}).Evaluate(context))
+
+	policy := &ProjectDataUsagePolicy{
+		Enabled:               true,
+		AllowedJobTypes:       []JobType{JobTypeTraining, JobTypePSI},
+		AllowedAlgorithmTypes: []JobAlgorithmType{JobAlgorithmTypeHeteroLR},
+		AllowedSiteUUIDs:      []string{"site-a", "site-b"},
+		MaxJobCount:           3,
+	}
+	assert.NoError(t, policy.Evaluate(context))
+
+	policy.AllowedJobTypes = []JobType{JobTypePSI}
+	assert.Error(t, policy.Evaluate(context))
+	// algorithm types are ignored for PSI jobs
+	psiContext := *context
+	psiContext.JobType = JobTypePSI
+	psiContext.AlgorithmType = JobAlgorithmTypeUnknown
+	assert.NoError(t, policy.Evaluate(&psiContext))
+	policy.AllowedJobTypes = nil
+
+	policy.AllowedAlgorithmTypes = []JobAlgorithmType{JobAlgorithmTypeHomoLR}
+	assert.Error(t, policy.Evaluate(context))
+	policy.AllowedAlgorithmTypes = nil
+
+	policy.AllowedSiteUUIDs = []string{"site-b"}
+	assert.Error(t, policy.Evaluate(context))
+	policy.AllowedSiteUUIDs = nil
+
+	policy.MaxJobCount = 2
+	assert.Error(t, policy.Evaluate(context))
+}
+
+func TestProjectDataUsagePolicyCheckDataResultDownload(t *testing.T) {
+	var nilPolicy *ProjectDataUsagePolicy
+	assert.NoError(t, nilPolicy.CheckDataResultDownload())
+	assert.NoError(t, (&ProjectDataUsagePolicy{}).CheckDataResultDownload())
+	assert.Error(t, (&ProjectDataUsagePolicy{Enabled: true}).CheckDataResultDownload())
+	assert.NoError(t, (&ProjectDataUsagePolicy{Enabled: true, DataResultDownloadAllowed: true}).CheckDataResultDownload())
+}
@@ -0,0 +1,24 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package repo
+
+// AuditLogRepository is the interface for persisting audit logs, there is no update or deletion as the logs are append-only
+type AuditLogRepository interface {
+	// Create takes an *entity.AuditLog and saves it in the repo
+	Create(interface{}) error
+	// GetListByFilter takes an *entity.AuditLogFilter and returns the matched []entity.AuditLog, newest first
+	GetListByFilter(interface{}) (interface{}, error)
+	// CountByFilter takes an *entity.AuditLogFilter and returns the number of the matched logs, ignoring the offset and limit
+	CountByFilter(interface{}) (int64, error)
+}
@@ -29,4 +29,6 @@ type JobParticipantRepository interface {
 	GetByJobAndSiteUUID(string, string) (interface{}, error)
 	// GetListByJobUUID returns an []entity.JobParticipant list in the specified job
 	GetListByJobUUID(string) (interface{}, error)
+	// GetListByDataUUID returns an []entity.JobParticipant list that uses the specified data
+	GetListByDataUUID(string) (interface{}, error)
 }
@@ -21,6 +21,8 @@ type LocalDataRepository interface {
 	Create(interface{}) error
 	// UpdateJobInfoByUUID changes the data upload job status
 	UpdateJobInfoByUUID(interface{}) error
+	// UpdateDataInfoByUUID changes the parsed meta data, preview and file path of the data
+	UpdateDataInfoByUUID(interface{}) error
 	// GetAll returns all the uploaded local data
 	// the returned interface{} should be of type []entity.LocalData
 	GetAll() (interface{}, error)
@@ -30,6 +32,8 @@ type LocalDataRepository interface {
 	DeleteByUUID(string) error
 	// UpdateIDMetaInfoByUUID updates the IDMetaInfo, the passed interface{} is of type entity.IDMetaInfo
 	UpdateIDMetaInfoByUUID(string, interface{}) error
+	// UpdateProfileByUUID updates the profile, the passed interface{} is of type *entity.LocalDataProfile
+	UpdateProfileByUUID(string, interface{}) error
 	// CheckNameConflict returns an error if there are name conflicts
 	CheckNameConflict(string) error
 }
@@ -0,0 +1,34 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import "github.com/pkg/errors"
+
+// ErrUploadSessionStatusChanged is returned when the session status is not the expected one
+var ErrUploadSessionStatusChanged = errors.New("the upload session status has been changed")
+
+// LocalDataUploadSessionRepository holds methods to access the chunked upload sessions
+// The interface{} parameters in most of the functions should of type "*entity.LocalDataUploadSession"
+type LocalDataUploadSessionRepository interface {
+	// Create saves the session
+	Create(interface{}) error
+	// GetByUUID returns an *entity.LocalDataUploadSession object by providing the uuid
+	GetByUUID(string) (interface{}, error)
+	// CompareAndUpdateStatusByUUID changes the status and the created data uuid of the session only if its current
+	// status in the repo is the second parameter, ErrUploadSessionStatusChanged is returned otherwise
+	CompareAndUpdateStatusByUUID(interface{}, interface{}) error
+	// DeleteByUUID deletes the session
+	DeleteByUUID(string) error
+}
@@ -43,4 +43,8 @@ type ProjectDataRepository interface {
 	GetListByDataUUID(string) (interface{}, error)
 	// GetByDataUUID returns an *entity.ProjectData that has the specified data uuid, it is just used for retrieving data info
 	GetByDataUUID(string) (interface{}, error)
+	// UpdateUsagePolicyByUUID takes an *entity.ProjectData and update its usage policy
+	UpdateUsagePolicyByUUID(interface{}) error
+	// UpdateProfileByUUID takes an *entity.ProjectData and update its data profile
+	UpdateProfileByUUID(interface{}) error
 }
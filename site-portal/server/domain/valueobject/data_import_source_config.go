@@ -0,0 +1,45 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package valueobject
+
+// DataImportSourceConfig contains the connection info of the external storages to import data from
+type DataImportSourceConfig struct {
+	// S3 is used for "s3://<bucket>/<object>" URIs
+	S3 *S3Config `json:"s3"`
+	// HDFS is used for "hdfs://<namenode-host>:<webhdfs-port>/<path>" URIs
+	HDFS *HDFSConfig `json:"hdfs"`
+}
+
+// S3Config contains the connection info to an S3-compatible object storage
+type S3Config struct {
+	// Endpoint is the address of the storage service, without the scheme
+	Endpoint string `json:"endpoint"`
+	// AccessKey is the access key to the storage
+	AccessKey string `json:"access_key"`
+	// SecretKey is the secret key to the storage
+	SecretKey string `json:"secret_key"`
+	// SSLEnabled is whether this connection should be over ssl
+	SSLEnabled bool `json:"ssl_enabled"`
+	// Region is the region of the storage
+	Region string `json:"region"`
+}
+
+// HDFSConfig contains the info to access files via the WebHDFS REST API of the HDFS namenode
+type HDFSConfig struct {
+	// User is the user name used to access the file
+	User string `json:"user"`
+	// HTTPSEnabled is whether the WebHDFS service should be accessed via https
+	HTTPSEnabled bool `json:"https_enabled"`
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCertificate(t *testing.T, cn string, serial int64, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+	}
+	signer := key
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		parent = template
+	} else {
+		signer = parentKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func newTestCRL(t *testing.T, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey, serials ...*big.Int) []byte {
+	var revokedList []pkix.RevokedCertificate
+	for _, serial := range serials {
+		revokedList = append(revokedList, pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: time.Now(),
+		})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: revokedList,
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now(),
+		NextUpdate:          time.Now().Add(time.Hour),
+	}, issuer, issuerKey)
+	assert.NoError(t, err)
+	return crl
+}
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crl-checker-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	caCert, caKey := newTestCertificate(t, "Test CA", 1, nil, nil)
+	revokedCert, _ := newTestCertificate(t, "revoked.example.com", 2, caCert, caKey)
+	validCert, _ := newTestCertificate(t, "valid.example.com", 3, caCert, caKey)
+	otherCACert, otherCAKey := newTestCertificate(t, "Other CA", 1, nil, nil)
+
+	caPath := filepath.Join(dir, "ca.crt")
+	assert.NoError(t, ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0600))
+
+	// loading PEM encoded CRL from file
+	crlPath := filepath.Join(dir, "ca.crl")
+	assert.NoError(t, ioutil.WriteFile(crlPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "X509 CRL",
+		Bytes: newTestCRL(t, caCert, caKey, revokedCert.SerialNumber),
+	}), 0600))
+	checker, err := NewChecker(crlPath, caPath)
+	assert.NoError(t, err)
+	assert.True(t, checker.IsRevoked(revokedCert))
+	assert.False(t, checker.IsRevoked(validCert))
+
+	// CRL signed by other CA is rejected and the previous one is kept
+	assert.NoError(t, ioutil.WriteFile(crlPath, newTestCRL(t, otherCACert, otherCAKey, validCert.SerialNumber), 0600))
+	assert.Error(t, checker.Refresh())
+	assert.True(t, checker.IsRevoked(revokedCert))
+	assert.False(t, checker.IsRevoked(validCert))
+
+	// loading DER encoded CRL from URL
+	crlBytes := newTestCRL(t, caCert, caKey, revokedCert.SerialNumber, validCert.SerialNumber)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		_, _ = w.Write(crlBytes)
+	}))
+	defer server.Close()
+	checker, err = NewChecker(server.URL, caPath)
+	assert.NoError(t, err)
+	assert.True(t, checker.IsRevoked(revokedCert))
+	assert.True(t, checker.IsRevoked(validCert))
+
+	_, err = NewChecker(server.URL, crlPath)
+	assert.Error(t, err)
+}
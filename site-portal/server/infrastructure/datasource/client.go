@@ -0,0 +1,153 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	gourl "net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/valueobject"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// hdfsClient is used to access WebHDFS. There is no overall timeout as the file content is streamed, so only the
+// connection and the response headers are time-limited.
+var hdfsClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+	},
+}
+
+// Open returns a reader of the file content located by the URI, as well as the file name.
+// The content is streamed from the storage and the caller should close the reader.
+func Open(uri string, config *valueobject.DataImportSourceConfig) (io.ReadCloser, string, error) {
+	u, err := gourl.Parse(uri)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid uri")
+	}
+	filename := path.Base(u.Path)
+	if filename == "/" || filename == "." {
+		return nil, "", errors.Errorf("uri %s doesn't contain a file name", uri)
+	}
+	var reader io.ReadCloser
+	if config == nil {
+		config = &valueobject.DataImportSourceConfig{}
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		reader, err = openS3Object(u, config.S3)
+	case "hdfs":
+		reader, err = openHDFSFile(u, config.HDFS)
+	default:
+		return nil, "", errors.Errorf("unsupported uri scheme: %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, filename, nil
+}
+
+func openS3Object(u *gourl.URL, config *valueobject.S3Config) (io.ReadCloser, error) {
+	if config == nil || config.Endpoint == "" {
+		return nil, errors.New("missing s3 connection info")
+	}
+	bucket := u.Host
+	object := strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || object == "" {
+		return nil, errors.Errorf("invalid s3 uri, the format should be s3://<bucket>/<object>")
+	}
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.SSLEnabled,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(context.TODO(), bucket, object, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject doesn't send any request until the first read, so check the object info here to fail early
+	info, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		return nil, errors.Wrapf(err, "failed to get info of object %s in bucket %s", object, bucket)
+	}
+	log.Info().Msgf("opened s3 object %s in bucket %s, size: %d", object, bucket, info.Size)
+	return obj, nil
+}
+
+func openHDFSFile(u *gourl.URL, config *valueobject.HDFSConfig) (io.ReadCloser, error) {
+	if u.Host == "" || u.Path == "" {
+		return nil, errors.Errorf("invalid hdfs uri, the format should be hdfs://<namenode-host>:<webhdfs-port>/<path>")
+	}
+	if !isAllowedHDFSNamenode(u.Host) {
+		return nil, errors.Errorf("hdfs namenode %s is not allowed, configure it in siteportal.datasource.hdfs.namenodes", u.Host)
+	}
+	scheme := "http"
+	query := gourl.Values{}
+	query.Set("op", "OPEN")
+	if config != nil {
+		if config.HTTPSEnabled {
+			scheme = "https"
+		}
+		if config.User != "" {
+			query.Set("user.name", config.User)
+		}
+	}
+	// the namenode will redirect the request to the datanode and the http client follows the redirection
+	webHDFSURL := fmt.Sprintf("%s://%s/webhdfs/v1%s?%s", scheme, u.Host, u.EscapedPath(), query.Encode())
+	log.Info().Msgf("opening hdfs file via %s", webHDFSURL)
+	resp, err := hdfsClient.Get(webHDFSURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, errors.Errorf("failed to open hdfs file %s, status: %d, body: %s", u.Path, resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
+// isAllowedHDFSNamenode returns whether the "host:port" is one of the configured namenodes, so the service won't
+// send requests to arbitrary addresses specified by the users
+func isAllowedHDFSNamenode(host string) bool {
+	for _, namenode := range strings.Split(viper.GetString("siteportal.datasource.hdfs.namenodes"), ",") {
+		if namenode = strings.TrimSpace(namenode); namenode != "" && strings.EqualFold(namenode, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"testing"
+
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/valueobject"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenHDFSFile_NotAllowedNamenode(t *testing.T) {
+	viper.Set("siteportal.datasource.hdfs.namenodes", "namenode-1:9870, Namenode-2:9870")
+	defer viper.Set("siteportal.datasource.hdfs.namenodes", "")
+
+	assert.True(t, isAllowedHDFSNamenode("namenode-1:9870"))
+	assert.True(t, isAllowedHDFSNamenode("namenode-2:9870"))
+	assert.False(t, isAllowedHDFSNamenode("namenode-1:8080"))
+	assert.False(t, isAllowedHDFSNamenode("169.254.169.254"))
+
+	_, _, err := Open("hdfs://169.254.169.254:80/latest/meta-data/data.csv", &valueobject.DataImportSourceConfig{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+}
@@ -127,33 +127,40 @@ func NewFATEFlowClient(host string, port uint, https bool) *client {
 	}
 }
 
-// UploadData calls the /data/upload API to upload local data to FATE flow
+// UploadData calls the /data/upload API to upload local data to FATE flow. The file content is streamed
+// into the request body so large files are not loaded into the memory.
 func (c *client) UploadData(request DataUploadRequest) (string, error) {
+	src, err := os.Open(request.File)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	stat, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	// generate the multipart header and trailer first, so we can stream the file in between with a known content length
 	var b bytes.Buffer
 	w := multipart.NewWriter(&b)
-	if fw, err := w.CreateFormFile("file", request.File); err != nil {
+	if _, err := w.CreateFormFile("file", request.File); err != nil {
 		return "", err
-	} else {
-		src, err := os.Open(request.File)
-		if err != nil {
-			return "", err
-		}
-		defer src.Close()
-		if _, err = io.Copy(fw, src); err != nil {
-			return "", err
-		}
 	}
+	headerLen := b.Len()
 	_ = w.Close()
+	header := b.Bytes()[:headerLen]
+	trailer := b.Bytes()[headerLen:]
 
 	args, err := json.Marshal(request)
 	if err != nil {
 		return "", err
 	}
 	url := c.genURL(fmt.Sprintf("data/upload?%s", gourl.QueryEscape(string(args))))
-	req, err := http.NewRequest("POST", url, &b)
+	req, err := http.NewRequest("POST", url, io.MultiReader(bytes.NewReader(header), src, bytes.NewReader(trailer)))
 	if err != nil {
 		return "", err
 	}
+	req.ContentLength = int64(len(header)) + stat.Size() + int64(len(trailer))
 	req.Header.Set("Content-Type", w.FormDataContentType())
 
 	log.Info().Msg(fmt.Sprintf("Posting data upload request to %s", url))
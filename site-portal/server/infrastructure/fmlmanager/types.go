@@ -15,6 +15,7 @@
 package fmlmanager
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -80,6 +81,8 @@ type ProjectDataAssociation struct {
 	TableNamespace string    `json:"table_namespace"`
 	CreationTime   time.Time `json:"creation_time"`
 	UpdateTime     time.Time `json:"update_time"`
+	// Profile is the aggregated statistics of the data, in the JSON format
+	Profile json.RawMessage `json:"profile"`
 }
 
 // ProjectParticipant is a site in a project
@@ -0,0 +1,90 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepo implements repo.AuditLogRepository using gorm and PostgreSQL
+type AuditLogRepo struct{}
+
+// make sure AuditLogRepo implements the repo.AuditLogRepository interface
+var _ repo.AuditLogRepository = (*AuditLogRepo)(nil)
+
+func (r *AuditLogRepo) Create(instance interface{}) error {
+	newLog := instance.(*entity.AuditLog)
+	return db.Model(&entity.AuditLog{}).Create(newLog).Error
+}
+
+func (r *AuditLogRepo) GetListByFilter(instance interface{}) (interface{}, error) {
+	filter := instance.(*entity.AuditLogFilter)
+	query := r.buildFilterQuery(filter).Order("created_at desc").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var logList []entity.AuditLog
+	if err := query.Find(&logList).Error; err != nil {
+		return nil, err
+	}
+	return logList, nil
+}
+
+func (r *AuditLogRepo) CountByFilter(instance interface{}) (int64, error) {
+	filter := instance.(*entity.AuditLogFilter)
+	var count int64
+	if err := r.buildFilterQuery(filter).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// buildFilterQuery returns the query with the conditions in the filter
+func (r *AuditLogRepo) buildFilterQuery(filter *entity.AuditLogFilter) *gorm.DB {
+	query := db.Model(&entity.AuditLog{})
+	if filter.ActorType != entity.AuditLogActorTypeUnknown {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action LIKE ?", "%"+filter.Action+"%")
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetUUID != "" {
+		query = query.Where("target_uuid = ?", filter.TargetUUID)
+	}
+	if filter.Outcome != entity.AuditLogOutcomeUnknown {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.StartTime.IsZero() {
+		query = query.Where("created_at >= ?", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		query = query.Where("created_at <= ?", filter.EndTime)
+	}
+	return query
+}
+
+// InitTable make sure the table is created in the db
+func (r *AuditLogRepo) InitTable() {
+	if err := db.AutoMigrate(&entity.AuditLog{}); err != nil {
+		panic(err)
+	}
+}
@@ -59,6 +59,14 @@ func (r *JobParticipantRepo) GetListByJobUUID(jobUUID string) (interface{}, erro
 	return participantList, nil
 }
 
+func (r *JobParticipantRepo) GetListByDataUUID(dataUUID string) (interface{}, error) {
+	var participantList []entity.JobParticipant
+	if err := db.Where("data_uuid = ?", dataUUID).Find(&participantList).Error; err != nil {
+		return nil, err
+	}
+	return participantList, nil
+}
+
 // InitTable make sure the table is created in the db
 func (r *JobParticipantRepo) InitTable() {
 	if err := db.AutoMigrate(&entity.JobParticipant{}); err != nil {
@@ -49,6 +49,13 @@ func (r *LocalDataRepo) UpdateJobInfoByUUID(instance interface{}) error {
 		Updates(localData).Error
 }
 
+func (r *LocalDataRepo) UpdateDataInfoByUUID(instance interface{}) error {
+	localData := instance.(*entity.LocalData)
+	return db.Model(localData).Where("uuid = ?", localData.UUID).
+		Select("column", "features", "count", "preview", "id_meta_info", "local_file_path", "format").
+		Updates(localData).Error
+}
+
 func (r *LocalDataRepo) GetAll() (interface{}, error) {
 	var localDataList []entity.LocalData
 	if err := db.Find(&localDataList).Error; err != nil {
@@ -75,6 +82,12 @@ func (r *LocalDataRepo) UpdateIDMetaInfoByUUID(uuid string, instance interface{}
 		Update("id_meta_info", metaInfo).Error
 }
 
+func (r *LocalDataRepo) UpdateProfileByUUID(uuid string, instance interface{}) error {
+	profile := instance.(*entity.LocalDataProfile)
+	return db.Model(&entity.LocalData{}).Where("uuid = ?", uuid).
+		Update("profile", profile).Error
+}
+
 func (r *LocalDataRepo) CheckNameConflict(name string) error {
 	var count int64
 	err := db.Model(&entity.LocalData{}).Where("name = ?", name).Count(&count).Error
@@ -0,0 +1,64 @@
+// Copyright 2022 VMware, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gorm
+
+import (
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/entity"
+	"github.com/FederatedAI/FedLCM/site-portal/server/domain/repo"
+)
+
+// LocalDataUploadSessionRepo implements repo.LocalDataUploadSessionRepository using gorm and PostgreSQL
+type LocalDataUploadSessionRepo struct{}
+
+// make sure LocalDataUploadSessionRepo implements the repo.LocalDataUploadSessionRepository interface
+var _ repo.LocalDataUploadSessionRepository = (*LocalDataUploadSessionRepo)(nil)
+
+func (r *LocalDataUploadSessionRepo) Create(instance interface{}) error {
+	session := instance.(*entity.LocalDataUploadSession)
+	return db.Model(&entity.LocalDataUploadSession{}).Create(session).Error
+}
+
+func (r *LocalDataUploadSessionRepo) GetByUUID(uuid string) (interface{}, error) {
+	session := &entity.LocalDataUploadSession{}
+	if err := db.Model(&entity.LocalDataUploadSession{}).Where("uuid = ?", uuid).First(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *LocalDataUploadSessionRepo) CompareAndUpdateStatusByUUID(instance interface{}, oldStatus interface{}) error {
+	session := instance.(*entity.LocalDataUploadSession)
+	result := db.Model(&entity.LocalDataUploadSession{}).
+		Where("uuid = ? AND status = ?", session.UUID, oldStatus.(entity.LocalDataUploadSessionStatus)).
+		Select("status", "data_uuid").Updates(session)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repo.ErrUploadSessionStatusChanged
+	}
+	return nil
+}
+
+func (r *LocalDataUploadSessionRepo) DeleteByUUID(uuid string) error {
+	return db.Where("uuid = ?", uuid).Delete(&entity.LocalDataUploadSession{}).Error
+}
+
+// InitTable make sure the table is created in the db
+func (r *LocalDataUploadSessionRepo) InitTable() {
+	if err := db.AutoMigrate(&entity.LocalDataUploadSession{}); err != nil {
+		panic(err)
+	}
+}
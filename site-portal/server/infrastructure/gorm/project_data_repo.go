@@ -51,6 +51,18 @@ func (r *ProjectDataRepo) UpdateStatusByUUID(instance interface{}) error {
 		Update("status", data.Status).Error
 }
 
+func (r *ProjectDataRepo) UpdateUsagePolicyByUUID(instance interface{}) error {
+	data := instance.(*entity.ProjectData)
+	return db.Model(&entity.ProjectData{}).Where("uuid = ?", data.UUID).
+		Update("usage_policy", data.UsagePolicy).Error
+}
+
+func (r *ProjectDataRepo) UpdateProfileByUUID(instance interface{}) error {
+	data := instance.(*entity.ProjectData)
+	return db.Model(&entity.ProjectData{}).Where("uuid = ?", data.UUID).
+		Update("profile", data.Profile).Error
+}
+
 func (r *ProjectDataRepo) GetListByProjectUUID(projectUUID string) (interface{}, error) {
 	var projectDataList []entity.ProjectData
 	err := db.Where("project_uuid = ?", projectUUID).Find(&projectDataList).Error
@@ -29,6 +29,7 @@ import (
 
 	"github.com/FederatedAI/FedLCM/site-portal/server/api"
 	"github.com/FederatedAI/FedLCM/site-portal/server/constants"
+	"github.com/FederatedAI/FedLCM/site-portal/server/infrastructure/crl"
 	"github.com/FederatedAI/FedLCM/site-portal/server/infrastructure/gorm"
 	"github.com/FederatedAI/KubeFATE/k8s-deploy/pkg/utils/logging"
 	"github.com/gin-contrib/logger"
@@ -92,6 +93,19 @@ func main() {
 			ClientAuth: tls.RequireAndVerifyClientCert,
 			ClientCAs:  pool,
 		}
+		api.SetClientCAPool(pool)
+		if crlSource := viper.GetString("siteportal.tls.crl.source"); crlSource != "" {
+			checker, err := crl.NewChecker(crlSource, caCertPath, sitePortalServerCert)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to initialize CRL checker")
+			}
+			interval := viper.GetDuration("siteportal.tls.crl.interval")
+			if interval <= 0 {
+				interval = time.Hour
+			}
+			checker.Start(interval)
+			api.SetCertRevocationChecker(checker)
+		}
 		tlsPort := viper.GetString("siteportal.tls.port")
 		if tlsPort == "" {
 			tlsPort = "8443"
@@ -171,6 +185,11 @@ func initRouter(r *gin.Engine) {
 			})
 		})
 
+		// audit log, the middleware must be installed before any other controllers
+		auditLogRepo := &gorm.AuditLogRepo{}
+		auditLogRepo.InitTable()
+		v1.Use(api.CreateAuditLogMiddleware(auditLogRepo))
+
 		// user management
 		userRepo := &gorm.UserRepo{}
 		userRepo.InitTable()
@@ -180,6 +199,7 @@ func initRouter(r *gin.Engine) {
 			panic(err)
 		}
 		api.NewUserController(userRepo).Route(v1)
+		api.NewAuditLogController(auditLogRepo).Route(v1)
 
 		// site management
 		siteRepo := &gorm.SiteRepo{}
@@ -190,6 +210,8 @@ func initRouter(r *gin.Engine) {
 		// local data management repo
 		localDataRepo := &gorm.LocalDataRepo{}
 		localDataRepo.InitTable()
+		localDataUploadSessionRepo := &gorm.LocalDataUploadSessionRepo{}
+		localDataUploadSessionRepo.InitTable()
 
 		// project management repo
 		projectRepo := &gorm.ProjectRepo{}
@@ -216,7 +238,7 @@ func initRouter(r *gin.Engine) {
 		modelDeploymentRepo.InitTable()
 
 		// local data management
-		api.NewLocalDataController(localDataRepo, siteRepo, projectRepo, projectDataRepo).Route(v1)
+		api.NewLocalDataController(localDataRepo, siteRepo, projectRepo, projectDataRepo, localDataUploadSessionRepo).Route(v1)
 
 		// project management
 		api.NewProjectController(projectRepo, siteRepo, projectParticipantRepo,
@@ -224,7 +246,7 @@ func initRouter(r *gin.Engine) {
 			modelRepo).Route(v1)
 
 		// job management
-		api.NewJobController(jobRepo, jobParticipantRepo, projectRepo, siteRepo, projectDataRepo, modelRepo).Route(v1)
+		api.NewJobController(jobRepo, jobParticipantRepo, projectRepo, siteRepo, projectDataRepo, modelRepo, auditLogRepo).Route(v1)
 
 		// model management
 		api.NewModelController(modelRepo, modelDeploymentRepo, siteRepo, projectRepo).Route(v1)